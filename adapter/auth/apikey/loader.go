@@ -18,6 +18,11 @@ func (a *ApiKeyLoader) Name() string {
 	return a.name
 }
 
+// DependsOn loads the library after the authentication store
+func (a *ApiKeyLoader) DependsOn() []string {
+	return []string{"authstorage"}
+}
+
 func (a *ApiKeyLoader) Init(args ...any) (port.Library, error) {
 	config := args[1].(config.AuthConfig)
 	authn := &authn.AuthN{}
@@ -78,27 +78,37 @@ func (a *AuthN) Install(args ...any) error {
 	}
 	a.Authorizer = authorizer
 
+	// Let out.Send hide fields tagged with expose from principals without the role
+	out.SetRolesResolver(auth.CurrentUserRoles)
+
 	return nil
 }
 
 func (a *AuthN) GetAuthenticatonHandler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		if err := a.Validator.ValidateKey(c); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
 		}
 
-		if err := a.Authenticator.Check(c); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
+		user, err := a.Authenticator.Check(c)
+		if err != nil {
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
 		}
 
-		if err := a.Authorizer.Check(a.Authenticator.AuthStore.GetLoadedUser(), c.Method(), c.Path()); err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
+		if err := a.Authorizer.Check(user, c.Method(), c.Path()); err != nil {
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", err.Error()))
 		}
 
+		auth.SetCurrentUser(c, user)
+
 		return c.Next()
 	}
 }
 
+func (a *AuthN) GetAuthorization() auth.IAuthorization {
+	return a.Authorizer
+}
+
 func (a *AuthN) Uninstall() error {
 	return nil
 }
@@ -17,6 +17,11 @@ func (a *BasicAuthLoader) Name() string {
 	return a.name
 }
 
+// DependsOn loads the library after the authentication store
+func (a *BasicAuthLoader) DependsOn() []string {
+	return []string{"authstorage"}
+}
+
 func (a *BasicAuthLoader) Init(args ...any) (port.Library, error) {
 
 	authn := &authn.AuthN{}
@@ -1,7 +1,10 @@
 package yaml
 
 import (
+	"context"
+
 	"github.com/webcore-go/webcore/adapter/authstore/store"
+	"github.com/webcore-go/webcore/app/core"
 	"github.com/webcore-go/webcore/infra/config"
 	"github.com/webcore-go/webcore/port"
 )
@@ -10,6 +13,11 @@ type YamlLoader struct {
 	name string
 }
 
+// YamlParams are the dependencies of the loader, filled by the LibraryManager
+type YamlParams struct {
+	Config config.AuthConfig
+}
+
 func (a *YamlLoader) SetName(name string) {
 	a.name = name
 }
@@ -18,8 +26,16 @@ func (a *YamlLoader) Name() string {
 	return a.name
 }
 
+func (l *YamlLoader) Params() any {
+	return &YamlParams{}
+}
+
 func (l *YamlLoader) Init(args ...any) (port.Library, error) {
-	config := args[1].(config.AuthConfig)
+	return core.InitInjected(context.Background(), l, args...)
+}
+
+func (l *YamlLoader) InitWith(ctx context.Context, params any) (port.Library, error) {
+	config := params.(*YamlParams).Config
 	backend, err := YamlBackend(config.Control, config.Directory)
 	if err != nil {
 		return nil, err
@@ -27,7 +43,7 @@ func (l *YamlLoader) Init(args ...any) (port.Library, error) {
 
 	store := &store.AuthStore{}
 	store.SetBackend(backend)
-	err = store.Install(args...)
+	err = store.Install(config)
 	if err != nil {
 		return nil, err
 	}
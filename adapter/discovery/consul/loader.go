@@ -0,0 +1,28 @@
+package consul
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// ConsulRegistryLoader loads the Consul registry, register it as "discovery:consul"
+type ConsulRegistryLoader struct {
+	name string
+}
+
+func (a *ConsulRegistryLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *ConsulRegistryLoader) Name() string {
+	return a.name
+}
+
+func (l *ConsulRegistryLoader) Init(args ...any) (port.Library, error) {
+	registry := &ConsulRegistry{}
+	err := registry.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return registry, nil
+}
@@ -0,0 +1,135 @@
+package consul
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ConsulRegistry registers the instance in the local Consul agent with a TTL check,
+// the heartbeats set the check passing or critical
+type ConsulRegistry struct {
+	Config config.DiscoveryConfig
+	client *http.Client
+}
+
+type serviceRegistration struct {
+	ID      string            `json:"ID"`
+	Name    string            `json:"Name"`
+	Tags    []string          `json:"Tags,omitempty"`
+	Address string            `json:"Address"`
+	Port    int               `json:"Port"`
+	Meta    map[string]string `json:"Meta,omitempty"`
+	Check   serviceCheck      `json:"Check"`
+}
+
+type serviceCheck struct {
+	CheckID                        string `json:"CheckID"`
+	Name                           string `json:"Name"`
+	TTL                            string `json:"TTL"`
+	Status                         string `json:"Status"`
+	DeregisterCriticalServiceAfter string `json:"DeregisterCriticalServiceAfter,omitempty"`
+}
+
+type checkUpdate struct {
+	Status string `json:"Status"`
+	Output string `json:"Output"`
+}
+
+func (r *ConsulRegistry) Install(args ...any) error {
+	r.Config = args[1].(config.DiscoveryConfig)
+
+	if r.Config.Server == "" {
+		r.Config.Server = "http://127.0.0.1:8500"
+	}
+	r.Config.Server = strings.TrimSuffix(r.Config.Server, "/")
+	r.client = &http.Client{Timeout: 10 * time.Second}
+	return nil
+}
+
+func (r *ConsulRegistry) Uninstall() error {
+	r.client.CloseIdleConnections()
+	return nil
+}
+
+func (r *ConsulRegistry) Register(ctx context.Context, instance *port.ServiceInstance) error {
+	registration := serviceRegistration{
+		ID:      instance.ID,
+		Name:    instance.Name,
+		Tags:    instance.Tags,
+		Address: instance.Address,
+		Port:    instance.Port,
+		Meta:    instance.Meta,
+		Check: serviceCheck{
+			CheckID: checkID(instance.ID),
+			Name:    "readiness",
+			TTL:     instance.TTL.String(),
+			Status:  "critical",
+		},
+	}
+	if instance.DeregisterAfter > 0 {
+		registration.Check.DeregisterCriticalServiceAfter = instance.DeregisterAfter.String()
+	}
+
+	return r.put(ctx, "/v1/agent/service/register", registration)
+}
+
+func (r *ConsulRegistry) Heartbeat(ctx context.Context, id string, ready bool, note string) error {
+	status := "passing"
+	if !ready {
+		status = "critical"
+	}
+	return r.put(ctx, "/v1/agent/check/update/"+url.PathEscape(checkID(id)), checkUpdate{Status: status, Output: note})
+}
+
+func (r *ConsulRegistry) Deregister(ctx context.Context, id string) error {
+	return r.put(ctx, "/v1/agent/service/deregister/"+url.PathEscape(id), nil)
+}
+
+func (r *ConsulRegistry) put(ctx context.Context, path string, payload any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := helper.JSONMarshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, r.Config.Server+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.Config.Token != "" {
+		req.Header.Set("X-Consul-Token", r.Config.Token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("consul %s: %s", resp.Status, strings.TrimSpace(string(detail)))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+func checkID(serviceID string) string {
+	return "service:" + serviceID
+}
@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DatabaseEventLoader provides a port.IEventStore persisted with the default database library
+type DatabaseEventLoader struct {
+	name string
+}
+
+func (a *DatabaseEventLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *DatabaseEventLoader) Name() string {
+	return a.name
+}
+
+// DependsOn loads the library after the default database
+func (a *DatabaseEventLoader) DependsOn() []string {
+	return []string{"database"}
+}
+
+func (l *DatabaseEventLoader) Init(args ...any) (port.Library, error) {
+	context := args[0].(*core.AppContext)
+
+	libDb, ok := context.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Event store cannot be loaded, database %s not found", context.Config.Database.Driver)
+	}
+
+	store := &DatabaseEventStore{
+		Connection:    libDb.(port.IDatabase),
+		Table:         DefaultTable,
+		SnapshotTable: DefaultSnapshotTable,
+	}
+	err := store.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
@@ -0,0 +1,139 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+const (
+	// DefaultTable is the table (or collection) holding the commits of the streams
+	DefaultTable = "event_commits"
+	// DefaultSnapshotTable is the table (or collection) holding the snapshots, one per stream
+	DefaultSnapshotTable = "event_snapshots"
+)
+
+// eventCommit is a row of the table: the events of an append, JSON encoded. Its ID is the
+// stream with the version it was appended after, so a second writer appending after the same
+// version hits the primary key and the events of an append are stored together or not at all.
+type eventCommit struct {
+	ID        string    `db:"id"`
+	Stream    string    `db:"stream"`
+	Version   int64     `db:"version"` // of the last event of the commit
+	Events    []byte    `db:"events"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// DatabaseEventStore stores the streams of events in a table of commits, and their snapshots
+type DatabaseEventStore struct {
+	Connection    port.IDatabase
+	Table         string
+	SnapshotTable string
+}
+
+func (s *DatabaseEventStore) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseEventStore) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseEventStore) Append(ctx context.Context, stream string, expected int64, events []port.EventRecord) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	raw, err := helper.JSONMarshal(events)
+	if err != nil {
+		return err
+	}
+	data, err := helper.MarshalDbMap(eventCommit{
+		ID:        fmt.Sprintf("%s@%d", stream, expected),
+		Stream:    stream,
+		Version:   events[len(events)-1].Version,
+		Events:    raw,
+		CreatedAt: events[0].CreatedAt,
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.Connection.InsertOne(ctx, s.Table, data); err != nil {
+		if errors.Is(err, port.ErrDuplicateKey) {
+			return port.ErrVersionConflict
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *DatabaseEventStore) Load(ctx context.Context, stream string, after int64) ([]port.EventRecord, error) {
+	commits := []eventCommit{}
+	err := s.Connection.Find(ctx, &commits, s.Table, []string{}, []port.DbExpression{
+		{Expr: "stream", Args: []any{stream}},
+		{Expr: "version", Op: ">", Args: []any{after}},
+	}, map[string]int{"version": 1}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	result := []port.EventRecord{}
+	for _, commit := range commits {
+		events := []port.EventRecord{}
+		if err := helper.JSONUnmarshal(commit.Events, &events); err != nil {
+			return nil, fmt.Errorf("commit %s: %v", commit.ID, err)
+		}
+		for _, event := range events {
+			if event.Version > after {
+				result = append(result, event)
+			}
+		}
+	}
+	return result, nil
+}
+
+func (s *DatabaseEventStore) SaveSnapshot(ctx context.Context, snapshot *port.SnapshotRecord) error {
+	updated, err := s.Connection.UpdateOne(ctx, s.SnapshotTable, []port.DbExpression{
+		{Expr: "stream", Args: []any{snapshot.Stream}},
+	}, port.DbMap{
+		"version":    snapshot.Version,
+		"data":       snapshot.Data,
+		"created_at": snapshot.CreatedAt,
+	})
+	if err != nil || updated > 0 {
+		return err
+	}
+
+	data, err := helper.MarshalDbMap(snapshot)
+	if err != nil {
+		return err
+	}
+	_, err = s.Connection.InsertOne(ctx, s.SnapshotTable, data)
+	if errors.Is(err, port.ErrDuplicateKey) {
+		// saved by another writer in between, the next snapshot replaces it
+		return nil
+	}
+	return err
+}
+
+func (s *DatabaseEventStore) LoadSnapshot(ctx context.Context, stream string) (*port.SnapshotRecord, error) {
+	snapshots := []port.SnapshotRecord{}
+	err := s.Connection.Find(ctx, &snapshots, s.SnapshotTable, []string{}, []port.DbExpression{
+		{Expr: "stream", Args: []any{stream}},
+	}, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(snapshots) == 0 {
+		return nil, nil
+	}
+	return &snapshots[0], nil
+}
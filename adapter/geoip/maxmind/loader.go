@@ -0,0 +1,28 @@
+package maxmind
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// MaxMindLoader loads the MaxMind DB provider, register it as "geoip:maxmind"
+type MaxMindLoader struct {
+	name string
+}
+
+func (a *MaxMindLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *MaxMindLoader) Name() string {
+	return a.name
+}
+
+func (l *MaxMindLoader) Init(args ...any) (port.Library, error) {
+	provider := &MaxMindProvider{}
+	err := provider.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return provider, nil
+}
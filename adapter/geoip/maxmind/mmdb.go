@@ -0,0 +1,268 @@
+package maxmind
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"os"
+)
+
+var metadataMarker = []byte("\xAB\xCD\xEFMaxMind.com")
+
+// database reads a MaxMind DB (.mmdb) file, see https://maxmind.github.io/MaxMind-DB/
+type database struct {
+	data       []byte
+	tree       []byte
+	section    []byte // data section
+	nodeCount  uint
+	recordSize uint
+	ipVersion  uint
+	ipv4Start  uint // node of ::/96 in an IPv6 tree
+	dbType     string
+}
+
+func openDatabase(path string) (*database, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := bytes.LastIndex(data, metadataMarker)
+	if start < 0 {
+		return nil, fmt.Errorf("%s is not a MaxMind DB file", path)
+	}
+	db := &database{data: data, section: data[start+len(metadataMarker):]}
+	value, _, err := db.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("%s: invalid metadata: %v", path, err)
+	}
+	metadata, ok := value.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("%s: invalid metadata", path)
+	}
+
+	db.nodeCount = uint(toUint(metadata["node_count"]))
+	db.recordSize = uint(toUint(metadata["record_size"]))
+	db.ipVersion = uint(toUint(metadata["ip_version"]))
+	db.dbType, _ = metadata["database_type"].(string)
+	if db.recordSize != 24 && db.recordSize != 28 && db.recordSize != 32 {
+		return nil, fmt.Errorf("%s: unsupported record size %d", path, db.recordSize)
+	}
+
+	treeSize := db.nodeCount * db.recordSize / 4
+	if treeSize+16 > uint(start) {
+		return nil, fmt.Errorf("%s: truncated search tree", path)
+	}
+	db.tree = data[:treeSize]
+	db.section = data[treeSize+16 : start]
+
+	if db.ipVersion == 6 {
+		node := uint(0)
+		for i := 0; i < 96 && node < db.nodeCount; i++ {
+			node = db.record(node, 0)
+		}
+		db.ipv4Start = node
+	}
+	return db, nil
+}
+
+// lookup returns the record of ip, nil when the database has none
+func (db *database) lookup(ip net.IP) (any, error) {
+	node := uint(0)
+	bits := 128
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 32
+		if db.ipVersion == 6 {
+			node = db.ipv4Start
+		}
+	} else if db.ipVersion == 4 {
+		return nil, nil
+	}
+
+	for i := 0; i < bits && node < db.nodeCount; i++ {
+		bit := uint(ip[i/8]>>(7-uint(i%8))) & 1
+		node = db.record(node, bit)
+	}
+
+	switch {
+	case node == db.nodeCount:
+		return nil, nil
+	case node < db.nodeCount:
+		return nil, fmt.Errorf("invalid search tree")
+	}
+
+	value, _, err := db.decode(node - db.nodeCount - 16)
+	return value, err
+}
+
+func (db *database) record(node uint, bit uint) uint {
+	switch db.recordSize {
+	case 24:
+		b := db.tree[node*6+bit*3:]
+		return uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+	case 28:
+		b := db.tree[node*7:]
+		if bit == 0 {
+			return uint(b[3]&0xF0)<<20 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])
+		}
+		return uint(b[3]&0x0F)<<24 | uint(b[4])<<16 | uint(b[5])<<8 | uint(b[6])
+	default:
+		return uint(binary.BigEndian.Uint32(db.tree[node*8+bit*4:]))
+	}
+}
+
+// decode reads the value at offset of the data section and returns the offset after it
+func (db *database) decode(offset uint) (any, uint, error) {
+	if offset >= uint(len(db.section)) {
+		return nil, 0, fmt.Errorf("offset %d outside the data section", offset)
+	}
+
+	control := db.section[offset]
+	offset++
+	kind := uint(control >> 5)
+
+	if kind == 1 {
+		pointer, next, err := db.pointer(control, offset)
+		if err != nil {
+			return nil, 0, err
+		}
+		value, _, err := db.decode(pointer)
+		return value, next, err
+	}
+
+	if kind == 0 {
+		if offset >= uint(len(db.section)) {
+			return nil, 0, fmt.Errorf("truncated data section")
+		}
+		kind = 7 + uint(db.section[offset])
+		offset++
+	}
+
+	size := uint(control & 0x1F)
+	if size >= 29 {
+		extra := size - 28
+		if offset+extra > uint(len(db.section)) {
+			return nil, 0, fmt.Errorf("truncated data section")
+		}
+		n := uint(0)
+		for _, b := range db.section[offset : offset+extra] {
+			n = n<<8 | uint(b)
+		}
+		offset += extra
+		switch size {
+		case 29:
+			size = 29 + n
+		case 30:
+			size = 285 + n
+		default:
+			size = 65821 + n
+		}
+	}
+
+	switch kind {
+	case 7: // map
+		result := make(map[string]any, size)
+		for i := uint(0); i < size; i++ {
+			key, next, err := db.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			value, after, err := db.decode(next)
+			if err != nil {
+				return nil, 0, err
+			}
+			name, _ := key.(string)
+			result[name] = value
+			offset = after
+		}
+		return result, offset, nil
+	case 11: // array
+		result := make([]any, 0, size)
+		for i := uint(0); i < size; i++ {
+			value, next, err := db.decode(offset)
+			if err != nil {
+				return nil, 0, err
+			}
+			result = append(result, value)
+			offset = next
+		}
+		return result, offset, nil
+	case 14: // boolean, the value is the size
+		return size != 0, offset, nil
+	}
+
+	if offset+size > uint(len(db.section)) {
+		return nil, 0, fmt.Errorf("truncated data section")
+	}
+	raw := db.section[offset : offset+size]
+	next := offset + size
+
+	switch kind {
+	case 2: // utf-8 string
+		return string(raw), next, nil
+	case 3: // double
+		if size != 8 {
+			return nil, 0, fmt.Errorf("invalid double size %d", size)
+		}
+		return math.Float64frombits(binary.BigEndian.Uint64(raw)), next, nil
+	case 4: // bytes
+		return append([]byte(nil), raw...), next, nil
+	case 5, 6, 9: // uint16, uint32, uint64
+		n := uint64(0)
+		for _, b := range raw {
+			n = n<<8 | uint64(b)
+		}
+		return n, next, nil
+	case 8: // int32
+		n := uint32(0)
+		for _, b := range raw {
+			n = n<<8 | uint32(b)
+		}
+		if size == 4 {
+			return int64(int32(n)), next, nil
+		}
+		return int64(n), next, nil
+	case 10: // uint128, kept as bytes
+		return append([]byte(nil), raw...), next, nil
+	case 15: // float
+		if size != 4 {
+			return nil, 0, fmt.Errorf("invalid float size %d", size)
+		}
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(raw))), next, nil
+	}
+	return nil, 0, fmt.Errorf("unsupported data type %d", kind)
+}
+
+func (db *database) pointer(control byte, offset uint) (uint, uint, error) {
+	size := uint(control>>3)&0x3 + 1
+	if offset+size > uint(len(db.section)) {
+		return 0, 0, fmt.Errorf("truncated pointer")
+	}
+	b := db.section[offset : offset+size]
+
+	var pointer uint
+	switch size {
+	case 1:
+		pointer = uint(control&0x7)<<8 | uint(b[0])
+	case 2:
+		pointer = (uint(control&0x7)<<16 | uint(b[0])<<8 | uint(b[1])) + 2048
+	case 3:
+		pointer = (uint(control&0x7)<<24 | uint(b[0])<<16 | uint(b[1])<<8 | uint(b[2])) + 526336
+	default:
+		pointer = uint(binary.BigEndian.Uint32(b))
+	}
+	return pointer, offset + size, nil
+}
+
+func toUint(value any) uint64 {
+	switch v := value.(type) {
+	case uint64:
+		return v
+	case int64:
+		return uint64(v)
+	}
+	return 0
+}
@@ -0,0 +1,97 @@
+package maxmind
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// MaxMindProvider resolves IPs with MaxMind DB files: a GeoIP2/GeoLite2 Country or City
+// database and optionally an ASN database. The files are read into memory on install.
+type MaxMindProvider struct {
+	Config  config.GeoIPConfig
+	country *database
+	asn     *database
+}
+
+func (p *MaxMindProvider) Install(args ...any) error {
+	p.Config = args[1].(config.GeoIPConfig)
+
+	if p.Config.Database == "" && p.Config.ASNDatabase == "" {
+		return fmt.Errorf("maxmind database or asn_database is required")
+	}
+	if p.Config.Language == "" {
+		p.Config.Language = "en"
+	}
+
+	var err error
+	if p.Config.Database != "" {
+		if p.country, err = openDatabase(p.Config.Database); err != nil {
+			return err
+		}
+	}
+	if p.Config.ASNDatabase != "" {
+		if p.asn, err = openDatabase(p.Config.ASNDatabase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *MaxMindProvider) Uninstall() error {
+	p.country = nil
+	p.asn = nil
+	return nil
+}
+
+func (p *MaxMindProvider) Lookup(ip net.IP) (*port.GeoInfo, error) {
+	info := &port.GeoInfo{IP: ip.String()}
+
+	if p.country != nil {
+		value, err := p.country.lookup(ip)
+		if err != nil {
+			return nil, err
+		}
+		if record, ok := value.(map[string]any); ok {
+			country := field(record, "country")
+			if country == nil {
+				// anonymous proxies and satellite providers only have a registered country
+				country = field(record, "registered_country")
+			}
+			info.Country, _ = country["iso_code"].(string)
+			info.CountryName = p.name(country)
+			info.Continent, _ = field(record, "continent")["code"].(string)
+			info.City = p.name(field(record, "city"))
+		}
+	}
+
+	if p.asn != nil {
+		value, err := p.asn.lookup(ip)
+		if err != nil {
+			return nil, err
+		}
+		if record, ok := value.(map[string]any); ok {
+			info.ASN = uint32(toUint(record["autonomous_system_number"]))
+			info.Organization, _ = record["autonomous_system_organization"].(string)
+		}
+	}
+
+	return info, nil
+}
+
+// name returns the name in the configured language, in English when it has none
+func (p *MaxMindProvider) name(record map[string]any) string {
+	names := field(record, "names")
+	if name, ok := names[p.Config.Language].(string); ok {
+		return name
+	}
+	name, _ := names["en"].(string)
+	return name
+}
+
+func field(record map[string]any, key string) map[string]any {
+	value, _ := record[key].(map[string]any)
+	return value
+}
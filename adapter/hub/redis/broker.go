@@ -0,0 +1,301 @@
+package redis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+const (
+	commandTimeout = 5 * time.Second
+	pingInterval   = 30 * time.Second
+)
+
+// RedisHubBroker fans the hub messages out with Redis PUBLISH/SUBSCRIBE and keeps the
+// presence of channels in sorted sets scored by expiration. It speaks RESP directly
+// over the connection configured in redis.*.
+type RedisHubBroker struct {
+	Config config.RedisConfig
+	Prefix string // key prefix of the presence sets
+
+	mu     sync.Mutex
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (b *RedisHubBroker) Install(args ...any) error {
+	cfg := args[1].(*config.Config)
+	b.Config = cfg.Redis
+	b.Prefix = cfg.App.Hub.Topic + ":presence:"
+
+	if b.Config.Host == "" {
+		return fmt.Errorf("redis host is required by the hub broker")
+	}
+	return nil
+}
+
+func (b *RedisHubBroker) Uninstall() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.conn != nil {
+		b.conn.Close()
+		b.conn = nil
+	}
+	return nil
+}
+
+func (b *RedisHubBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	_, err := b.command(ctx, "PUBLISH", topic, string(message))
+	return err
+}
+
+func (b *RedisHubBroker) Subscribe(ctx context.Context, topic string, handler func(message []byte)) error {
+	conn, reader, err := b.dial(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := writeCommand(conn, "SUBSCRIBE", topic); err != nil {
+		return err
+	}
+
+	// a subscribed connection only reads, ping it to notice when Redis is gone
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				conn.Close()
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+				conn.SetWriteDeadline(time.Now().Add(commandTimeout))
+				writeCommand(conn, "PING")
+			}
+		}
+	}()
+
+	for {
+		conn.SetReadDeadline(time.Now().Add(2 * pingInterval))
+		reply, err := readReply(reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+
+		// ["message", topic, payload]; subscribe confirmations and pongs are skipped
+		items, ok := reply.([]any)
+		if !ok || len(items) != 3 {
+			continue
+		}
+		if kind, _ := items[0].(string); kind == "message" {
+			if payload, ok := items[2].(string); ok {
+				handler([]byte(payload))
+			}
+		}
+	}
+}
+
+func (b *RedisHubBroker) Join(ctx context.Context, channel string, member string, ttl time.Duration) error {
+	key := b.Prefix + channel
+	now := time.Now()
+
+	// drop the members of instances that stopped refreshing
+	if _, err := b.command(ctx, "ZREMRANGEBYSCORE", key, "-inf", strconv.FormatInt(now.UnixMilli(), 10)); err != nil {
+		return err
+	}
+	if _, err := b.command(ctx, "ZADD", key, strconv.FormatInt(now.Add(ttl).UnixMilli(), 10), member); err != nil {
+		return err
+	}
+	_, err := b.command(ctx, "PEXPIRE", key, strconv.FormatInt(ttl.Milliseconds(), 10))
+	return err
+}
+
+func (b *RedisHubBroker) Leave(ctx context.Context, channel string, member string) error {
+	_, err := b.command(ctx, "ZREM", b.Prefix+channel, member)
+	return err
+}
+
+func (b *RedisHubBroker) Members(ctx context.Context, channel string) ([]string, error) {
+	reply, err := b.command(ctx, "ZRANGEBYSCORE", b.Prefix+channel, strconv.FormatInt(time.Now().UnixMilli(), 10), "+inf")
+	if err != nil {
+		return nil, err
+	}
+
+	items, _ := reply.([]any)
+	members := make([]string, 0, len(items))
+	for _, item := range items {
+		if member, ok := item.(string); ok {
+			members = append(members, member)
+		}
+	}
+	return members, nil
+}
+
+// command runs a command on the shared connection, reconnecting once when it was dropped
+func (b *RedisHubBroker) command(ctx context.Context, args ...string) (any, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var lastErr error
+	for attempt := 0; attempt < 2; attempt++ {
+		if b.conn == nil {
+			conn, reader, err := b.dial(ctx)
+			if err != nil {
+				return nil, err
+			}
+			b.conn, b.reader = conn, reader
+		}
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			deadline = time.Now().Add(commandTimeout)
+		}
+		b.conn.SetDeadline(deadline)
+
+		err := writeCommand(b.conn, args...)
+		if err == nil {
+			var reply any
+			reply, err = readReply(b.reader)
+			var redisErr redisError
+			if err == nil || errors.As(err, &redisErr) {
+				return reply, err
+			}
+		}
+
+		// the connection is in an unknown state, open a new one
+		b.conn.Close()
+		b.conn = nil
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+func (b *RedisHubBroker) dial(ctx context.Context) (net.Conn, *bufio.Reader, error) {
+	dialer := net.Dialer{Timeout: commandTimeout}
+	address := net.JoinHostPort(b.Config.Host, strconv.Itoa(b.port()))
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return nil, nil, err
+	}
+	reader := bufio.NewReader(conn)
+
+	conn.SetDeadline(time.Now().Add(commandTimeout))
+	if b.Config.Password != "" {
+		if err := writeCommand(conn, "AUTH", b.Config.Password); err == nil {
+			_, err = readReply(reader)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("redis auth: %v", err)
+		}
+	}
+	if b.Config.DB != 0 {
+		if err := writeCommand(conn, "SELECT", strconv.Itoa(b.Config.DB)); err == nil {
+			_, err = readReply(reader)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, nil, fmt.Errorf("redis select: %v", err)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+
+	return conn, reader, nil
+}
+
+func (b *RedisHubBroker) port() int {
+	if b.Config.Port == 0 {
+		return 6379
+	}
+	return b.Config.Port
+}
+
+// redisError is an error reply of Redis, the connection stays usable
+type redisError string
+
+func (e redisError) Error() string {
+	return "redis: " + string(e)
+}
+
+func writeCommand(w io.Writer, args ...string) error {
+	buf := make([]byte, 0, 64)
+	buf = append(buf, '*')
+	buf = strconv.AppendInt(buf, int64(len(args)), 10)
+	buf = append(buf, '\r', '\n')
+	for _, arg := range args {
+		buf = append(buf, '$')
+		buf = strconv.AppendInt(buf, int64(len(arg)), 10)
+		buf = append(buf, '\r', '\n')
+		buf = append(buf, arg...)
+		buf = append(buf, '\r', '\n')
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// readReply reads a RESP2 reply: string, int64, nil, []any or a redisError
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("redis: invalid reply %q", line)
+	}
+	kind, value := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return value, nil
+	case '-':
+		return nil, redisError(value)
+	case ':':
+		return strconv.ParseInt(value, 10, 64)
+	case '$':
+		length, err := strconv.Atoi(value)
+		if err != nil || length < 0 {
+			return nil, err
+		}
+		data := make([]byte, length+2)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		return string(data[:length]), nil
+	case '*':
+		count, err := strconv.Atoi(value)
+		if err != nil || count < 0 {
+			return nil, err
+		}
+		items := make([]any, count)
+		for i := range items {
+			if items[i], err = readReply(r); err != nil {
+				var redisErr redisError
+				if !errors.As(err, &redisErr) {
+					return nil, err
+				}
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: invalid reply %q", line)
+}
@@ -0,0 +1,28 @@
+package redis
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// RedisHubLoader loads the Redis broker of the websocket hub, register it as "hub:redis"
+type RedisHubLoader struct {
+	name string
+}
+
+func (a *RedisHubLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *RedisHubLoader) Name() string {
+	return a.name
+}
+
+func (l *RedisHubLoader) Init(args ...any) (port.Library, error) {
+	broker := &RedisHubBroker{}
+	err := broker.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return broker, nil
+}
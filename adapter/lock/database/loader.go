@@ -0,0 +1,49 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DatabaseLockLoader provides a port.ILocker shared by the instances through the default
+// database library, register it as "lock:database" for app.scheduler.locker and
+// app.webhooks.locker
+type DatabaseLockLoader struct {
+	name string
+}
+
+func (a *DatabaseLockLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *DatabaseLockLoader) Name() string {
+	return a.name
+}
+
+// DependsOn loads the library after the default database
+func (a *DatabaseLockLoader) DependsOn() []string {
+	return []string{"database"}
+}
+
+func (l *DatabaseLockLoader) Init(args ...any) (port.Library, error) {
+	context := args[0].(*core.AppContext)
+
+	libDb, ok := context.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Locker cannot be loaded, database %s not found", context.Config.Database.Driver)
+	}
+
+	locker := &DatabaseLocker{
+		Connection: libDb.(port.IDatabase),
+		Table:      DefaultTable,
+		Clock:      context.Clock,
+	}
+	err := locker.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return locker, nil
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DefaultTable is the table (or collection) holding the locks
+const DefaultTable = "locks"
+
+// purgeEvery is the interval between the deletions of the expired locks, the scheduler never
+// unlocks the keys of its ticks
+const purgeEvery = time.Minute
+
+// lockRow is a row of the table. Its ID is the key, so a second instance locking a key
+// already held hits the primary key.
+type lockRow struct {
+	ID        string    `db:"id"`
+	Owner     string    `db:"owner"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// DatabaseLocker is a port.ILocker shared by the instances of the application through a table:
+// the first instance inserting a key holds it until it expires, then the first instance
+// updating the expired row takes it over
+type DatabaseLocker struct {
+	Connection port.IDatabase
+	Table      string
+	Clock      helper.Clock
+
+	owner string // the instance, only it unlocks its keys
+
+	mu       sync.Mutex
+	purgedAt time.Time
+}
+
+func (l *DatabaseLocker) Install(args ...any) error {
+	owner, err := helper.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	l.owner = owner
+	if l.Clock == nil {
+		l.Clock = helper.NewSystemClock()
+	}
+	return nil
+}
+
+func (l *DatabaseLocker) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (l *DatabaseLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	now := l.Clock.Now()
+	l.purge(ctx, now)
+
+	data, err := helper.MarshalDbMap(lockRow{ID: key, Owner: l.owner, ExpiresAt: now.Add(ttl)})
+	if err != nil {
+		return false, err
+	}
+	_, err = l.Connection.InsertOne(ctx, l.Table, data)
+	if err == nil {
+		return true, nil
+	}
+	if !errors.Is(err, port.ErrDuplicateKey) {
+		return false, err
+	}
+
+	// held by another instance unless it expired, only one of the instances updating it wins
+	taken, err := l.Connection.UpdateOne(ctx, l.Table, []port.DbExpression{
+		{Expr: "id", Args: []any{key}},
+		{Expr: "expires_at", Op: "<=", Args: []any{now}},
+	}, port.DbMap{
+		"owner":      l.owner,
+		"expires_at": now.Add(ttl),
+	})
+	if err != nil {
+		return false, err
+	}
+	return taken > 0, nil
+}
+
+func (l *DatabaseLocker) Unlock(ctx context.Context, key string) error {
+	_, err := l.Connection.DeleteOne(ctx, l.Table, []port.DbExpression{
+		{Expr: "id", Args: []any{key}},
+		{Expr: "owner", Args: []any{l.owner}},
+	})
+	return err
+}
+
+// purge deletes the expired locks at most every purgeEvery, a failure is left to the next one
+func (l *DatabaseLocker) purge(ctx context.Context, now time.Time) {
+	l.mu.Lock()
+	if now.Sub(l.purgedAt) < purgeEvery {
+		l.mu.Unlock()
+		return
+	}
+	l.purgedAt = now
+	l.mu.Unlock()
+
+	l.Connection.Delete(ctx, l.Table, []port.DbExpression{
+		{Expr: "expires_at", Op: "<=", Args: []any{now}},
+	})
+}
@@ -0,0 +1,37 @@
+package slog
+
+import (
+	stdslog "log/slog"
+	"strings"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// SlogBackend writes the lines with the handlers of log/slog: JSON lines when app.logging.format
+// is "json", else "key=value" lines, to app.logging.output
+type SlogBackend struct {
+	handler stdslog.Handler
+}
+
+func (b *SlogBackend) Install(args ...any) error {
+	cfg := args[1].(config.LoggingConfig)
+
+	// the level is the one of the logger, the handler writes every line it receives
+	opts := &stdslog.HandlerOptions{Level: stdslog.LevelDebug}
+	output := helper.FiberLoggerOutput(cfg.Output)
+	if strings.EqualFold(cfg.Format, "json") {
+		b.handler = stdslog.NewJSONHandler(output, opts)
+	} else {
+		b.handler = stdslog.NewTextHandler(output, opts)
+	}
+	return nil
+}
+
+func (b *SlogBackend) Uninstall() error {
+	return nil
+}
+
+func (b *SlogBackend) Handler() stdslog.Handler {
+	return b.handler
+}
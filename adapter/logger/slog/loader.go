@@ -0,0 +1,27 @@
+package slog
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type SlogLoader struct {
+	name string
+}
+
+func (a *SlogLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *SlogLoader) Name() string {
+	return a.name
+}
+
+func (l *SlogLoader) Init(args ...any) (port.Library, error) {
+	backend := &SlogBackend{}
+	err := backend.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend, nil
+}
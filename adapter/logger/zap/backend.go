@@ -0,0 +1,89 @@
+package zap
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// ZapBackend writes the lines in the layout of zap, so the pipelines parsing the logs of the
+// services on zap read them as well: with app.logging.format "json" the encoding of
+// zap.NewProduction ({"level":"info","ts":1700000000.123,"msg":"..."}), else "key=value"
+// lines with the time and the level of zap.NewDevelopment ("2006-01-02T15:04:05.000Z0700",
+// "INFO").
+//
+// A service logging with zap itself registers instead a loader returning the handler of its
+// logger (ex: zapslog.NewHandler(core)) as a port.ILogBackend.
+type ZapBackend struct {
+	handler slog.Handler
+}
+
+func (b *ZapBackend) Install(args ...any) error {
+	cfg := args[1].(config.LoggingConfig)
+
+	output := helper.FiberLoggerOutput(cfg.Output)
+	if strings.EqualFold(cfg.Format, "json") {
+		b.handler = slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceJSON})
+	} else {
+		b.handler = slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceConsole})
+	}
+	return nil
+}
+
+func (b *ZapBackend) Uninstall() error {
+	return nil
+}
+
+func (b *ZapBackend) Handler() slog.Handler {
+	return b.handler
+}
+
+// replaceJSON renames the built-in attributes as the production encoder of zap: the level in
+// lower case, the time "ts" in seconds since the epoch
+func replaceJSON(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.Float64("ts", float64(a.Value.Time().UnixNano())/float64(time.Second))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, levelName(a.Value))
+	}
+	return a
+}
+
+// replaceConsole writes the built-in attributes as the development encoder of zap: the time in
+// ISO 8601 with milliseconds, the level in upper case
+func replaceConsole(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String(slog.TimeKey, a.Value.Time().Format("2006-01-02T15:04:05.000Z0700"))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, strings.ToUpper(levelName(a.Value)))
+	}
+	return a
+}
+
+// levelName is the name of a level in zap, the levels between two of slog rounded down
+func levelName(v slog.Value) string {
+	level, ok := v.Any().(slog.Level)
+	if !ok {
+		return strings.ToLower(v.String())
+	}
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	}
+	return "error"
+}
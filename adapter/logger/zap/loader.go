@@ -0,0 +1,27 @@
+package zap
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type ZapLoader struct {
+	name string
+}
+
+func (a *ZapLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *ZapLoader) Name() string {
+	return a.name
+}
+
+func (l *ZapLoader) Init(args ...any) (port.Library, error) {
+	backend := &ZapBackend{}
+	err := backend.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend, nil
+}
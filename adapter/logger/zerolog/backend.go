@@ -0,0 +1,92 @@
+package zerolog
+
+import (
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// ZerologBackend writes the lines in the layout of zerolog, so the pipelines parsing the logs
+// of the services on zerolog read them as well: with app.logging.format "json" the default
+// encoding of zerolog ({"level":"info","time":"2006-01-02T15:04:05Z07:00","message":"..."}),
+// else "key=value" lines with the time and the level of its ConsoleWriter ("3:04PM", "INF").
+//
+// A service logging with zerolog itself registers instead a loader returning a handler writing
+// to its logger (ex: slogzerolog) as a port.ILogBackend.
+type ZerologBackend struct {
+	handler slog.Handler
+}
+
+func (b *ZerologBackend) Install(args ...any) error {
+	cfg := args[1].(config.LoggingConfig)
+
+	output := helper.FiberLoggerOutput(cfg.Output)
+	if strings.EqualFold(cfg.Format, "json") {
+		b.handler = slog.NewJSONHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceJSON})
+	} else {
+		b.handler = slog.NewTextHandler(output, &slog.HandlerOptions{Level: slog.LevelDebug, ReplaceAttr: replaceConsole})
+	}
+	return nil
+}
+
+func (b *ZerologBackend) Uninstall() error {
+	return nil
+}
+
+func (b *ZerologBackend) Handler() slog.Handler {
+	return b.handler
+}
+
+// replaceJSON renames the built-in attributes as zerolog: the level in lower case, the time in
+// RFC 3339 to the second and the message "message"
+func replaceJSON(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String(slog.TimeKey, a.Value.Time().Format(time.RFC3339))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, levelName(a.Value))
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: a.Value}
+	}
+	return a
+}
+
+// replaceConsole writes the built-in attributes as the ConsoleWriter of zerolog: the time of
+// the day and the level in three letters
+func replaceConsole(groups []string, a slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return a
+	}
+	switch a.Key {
+	case slog.TimeKey:
+		return slog.String(slog.TimeKey, a.Value.Time().Format(time.Kitchen))
+	case slog.LevelKey:
+		return slog.String(slog.LevelKey, consoleLevels[levelName(a.Value)])
+	}
+	return a
+}
+
+var consoleLevels = map[string]string{"debug": "DBG", "info": "INF", "warn": "WRN", "error": "ERR"}
+
+// levelName is the name of a level in zerolog, the levels between two of slog rounded down
+func levelName(v slog.Value) string {
+	level, ok := v.Any().(slog.Level)
+	if !ok {
+		return strings.ToLower(v.String())
+	}
+	switch {
+	case level < slog.LevelInfo:
+		return "debug"
+	case level < slog.LevelWarn:
+		return "info"
+	case level < slog.LevelError:
+		return "warn"
+	}
+	return "error"
+}
@@ -0,0 +1,27 @@
+package zerolog
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type ZerologLoader struct {
+	name string
+}
+
+func (a *ZerologLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *ZerologLoader) Name() string {
+	return a.name
+}
+
+func (l *ZerologLoader) Init(args ...any) (port.Library, error) {
+	backend := &ZerologBackend{}
+	err := backend.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return backend, nil
+}
@@ -0,0 +1,27 @@
+package log
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type LogMailerLoader struct {
+	name string
+}
+
+func (a *LogMailerLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *LogMailerLoader) Name() string {
+	return a.name
+}
+
+func (l *LogMailerLoader) Init(args ...any) (port.Library, error) {
+	mailer := &LogMailer{}
+	err := mailer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mailer, nil
+}
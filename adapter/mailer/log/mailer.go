@@ -0,0 +1,72 @@
+package log
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// LogMailer is meant for development: messages are logged instead of delivered and,
+// when Config.Directory is set, written as .eml files that any mail client can open
+type LogMailer struct {
+	Config config.MailConfig
+}
+
+func (m *LogMailer) Install(args ...any) error {
+	m.Config = args[1].(config.MailConfig)
+
+	if m.Config.Directory != "" {
+		return os.MkdirAll(m.Config.Directory, 0o755)
+	}
+	return nil
+}
+
+func (m *LogMailer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *LogMailer) Send(ctx context.Context, message *port.MailMessage) error {
+	data, err := helper.BuildMailMessage(message)
+	if err != nil {
+		return err
+	}
+
+	file := ""
+	if m.Config.Directory != "" {
+		id, err := helper.GenerateUUID()
+		if err != nil {
+			return err
+		}
+
+		file = filepath.Join(m.Config.Directory, time.Now().Format("20060102-150405")+"-"+id+".eml")
+		if err := os.WriteFile(file, data, 0o644); err != nil {
+			return err
+		}
+	}
+
+	logger.Info("Mail sent",
+		"from", message.From,
+		"to", strings.Join(helper.MailRecipients(message), ", "),
+		"subject", message.Subject,
+		"attachments", len(message.Attachments),
+		"file", file)
+	return nil
+}
+
+func (m *LogMailer) SendBatch(ctx context.Context, messages []*port.MailMessage) error {
+	for i, message := range messages {
+		if err := m.Send(ctx, message); err != nil {
+			return fmt.Errorf("message %d: %v", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package sendgrid
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type SendGridMailerLoader struct {
+	name string
+}
+
+func (a *SendGridMailerLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *SendGridMailerLoader) Name() string {
+	return a.name
+}
+
+func (l *SendGridMailerLoader) Init(args ...any) (port.Library, error) {
+	mailer := &SendGridMailer{}
+	err := mailer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mailer, nil
+}
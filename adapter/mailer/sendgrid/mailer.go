@@ -0,0 +1,169 @@
+package sendgrid
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const endpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridMailer sends messages through the SendGrid v3 Mail Send API
+type SendGridMailer struct {
+	Config config.MailConfig
+	client *http.Client
+}
+
+type sgAddress struct {
+	Email string `json:"email"`
+	Name  string `json:"name,omitempty"`
+}
+
+type sgPersonalization struct {
+	To  []sgAddress `json:"to,omitempty"`
+	Cc  []sgAddress `json:"cc,omitempty"`
+	Bcc []sgAddress `json:"bcc,omitempty"`
+}
+
+type sgContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+type sgAttachment struct {
+	Content     string `json:"content"`
+	Type        string `json:"type,omitempty"`
+	Filename    string `json:"filename"`
+	Disposition string `json:"disposition,omitempty"`
+	ContentID   string `json:"content_id,omitempty"`
+}
+
+type sgRequest struct {
+	Personalizations []sgPersonalization `json:"personalizations"`
+	From             sgAddress           `json:"from"`
+	ReplyTo          *sgAddress          `json:"reply_to,omitempty"`
+	Subject          string              `json:"subject"`
+	Content          []sgContent         `json:"content"`
+	Attachments      []sgAttachment      `json:"attachments,omitempty"`
+	Headers          map[string]string   `json:"headers,omitempty"`
+}
+
+func (m *SendGridMailer) Install(args ...any) error {
+	m.Config = args[1].(config.MailConfig)
+
+	if m.Config.APIKey == "" {
+		return fmt.Errorf("sendgrid api_key is required")
+	}
+
+	m.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (m *SendGridMailer) Uninstall() error {
+	m.client.CloseIdleConnections()
+	return nil
+}
+
+func (m *SendGridMailer) Send(ctx context.Context, message *port.MailMessage) error {
+	payload := sgRequest{
+		Personalizations: []sgPersonalization{{
+			To:  addresses(message.To),
+			Cc:  addresses(message.Cc),
+			Bcc: addresses(message.Bcc),
+		}},
+		From:    address(message.From),
+		Subject: message.Subject,
+		Headers: message.Headers,
+	}
+	if message.ReplyTo != "" {
+		replyTo := address(message.ReplyTo)
+		payload.ReplyTo = &replyTo
+	}
+
+	// SendGrid requires text/plain before text/html
+	if message.Text != "" {
+		payload.Content = append(payload.Content, sgContent{Type: "text/plain", Value: message.Text})
+	}
+	if message.HTML != "" {
+		payload.Content = append(payload.Content, sgContent{Type: "text/html", Value: message.HTML})
+	}
+	if len(payload.Content) == 0 {
+		return fmt.Errorf("mail has no content")
+	}
+
+	for _, attachment := range message.Attachments {
+		item := sgAttachment{
+			Content:     base64.StdEncoding.EncodeToString(attachment.Content),
+			Type:        attachment.ContentType,
+			Filename:    attachment.Filename,
+			Disposition: "attachment",
+		}
+		if attachment.ContentID != "" {
+			item.Disposition = "inline"
+			item.ContentID = attachment.ContentID
+		}
+		payload.Attachments = append(payload.Attachments, item)
+	}
+
+	body, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+m.Config.APIKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("sendgrid send failed: %s %s", resp.Status, detail)
+	}
+	return nil
+}
+
+func (m *SendGridMailer) SendBatch(ctx context.Context, messages []*port.MailMessage) error {
+	errs := []error{}
+	for i, message := range messages {
+		if err := m.Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("message %d: %v", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func address(value string) sgAddress {
+	if parsed, err := mail.ParseAddress(value); err == nil {
+		return sgAddress{Email: parsed.Address, Name: parsed.Name}
+	}
+	return sgAddress{Email: value}
+}
+
+func addresses(values []string) []sgAddress {
+	if len(values) == 0 {
+		return nil
+	}
+	result := make([]sgAddress, len(values))
+	for i, value := range values {
+		result[i] = address(value)
+	}
+	return result
+}
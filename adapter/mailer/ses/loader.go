@@ -0,0 +1,27 @@
+package ses
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type SESMailerLoader struct {
+	name string
+}
+
+func (a *SESMailerLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *SESMailerLoader) Name() string {
+	return a.name
+}
+
+func (l *SESMailerLoader) Init(args ...any) (port.Library, error) {
+	mailer := &SESMailer{}
+	err := mailer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mailer, nil
+}
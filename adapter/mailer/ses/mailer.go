@@ -0,0 +1,116 @@
+package ses
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/awsv4"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// SESMailer sends raw MIME messages through the Amazon SES v2 API
+type SESMailer struct {
+	Config   config.MailConfig
+	client   *http.Client
+	signer   awsv4.Signer
+	endpoint string
+}
+
+type sesRequest struct {
+	FromEmailAddress string         `json:"FromEmailAddress"`
+	Destination      sesDestination `json:"Destination"`
+	Content          sesContent     `json:"Content"`
+}
+
+type sesDestination struct {
+	ToAddresses  []string `json:"ToAddresses,omitempty"`
+	CcAddresses  []string `json:"CcAddresses,omitempty"`
+	BccAddresses []string `json:"BccAddresses,omitempty"`
+}
+
+type sesContent struct {
+	Raw struct {
+		Data []byte `json:"Data"` // base64 encoded by the JSON encoder
+	} `json:"Raw"`
+}
+
+func (m *SESMailer) Install(args ...any) error {
+	m.Config = args[1].(config.MailConfig)
+
+	if m.Config.AccessKey == "" || m.Config.SecretKey == "" {
+		return fmt.Errorf("ses access_key and secret_key are required")
+	}
+
+	m.client = &http.Client{Timeout: 30 * time.Second}
+	m.signer = awsv4.Signer{
+		AccessKey: m.Config.AccessKey,
+		SecretKey: m.Config.SecretKey,
+		Region:    m.Config.Region,
+		Service:   "ses",
+	}
+	m.endpoint = "https://email." + m.Config.Region + ".amazonaws.com/v2/email/outbound-emails"
+	return nil
+}
+
+func (m *SESMailer) Uninstall() error {
+	m.client.CloseIdleConnections()
+	return nil
+}
+
+func (m *SESMailer) Send(ctx context.Context, message *port.MailMessage) error {
+	data, err := helper.BuildMailMessage(message)
+	if err != nil {
+		return err
+	}
+
+	payload := sesRequest{
+		FromEmailAddress: message.From,
+		Destination: sesDestination{
+			ToAddresses:  message.To,
+			CcAddresses:  message.Cc,
+			BccAddresses: message.Bcc,
+		},
+	}
+	payload.Content.Raw.Data = data
+
+	body, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	m.signer.Sign(req, awsv4.PayloadHash(body), time.Now())
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("ses send failed: %s %s", resp.Status, detail)
+	}
+	return nil
+}
+
+func (m *SESMailer) SendBatch(ctx context.Context, messages []*port.MailMessage) error {
+	errs := []error{}
+	for i, message := range messages {
+		if err := m.Send(ctx, message); err != nil {
+			errs = append(errs, fmt.Errorf("message %d: %v", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,27 @@
+package smtp
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type SMTPMailerLoader struct {
+	name string
+}
+
+func (a *SMTPMailerLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *SMTPMailerLoader) Name() string {
+	return a.name
+}
+
+func (l *SMTPMailerLoader) Init(args ...any) (port.Library, error) {
+	mailer := &SMTPMailer{}
+	err := mailer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return mailer, nil
+}
@@ -0,0 +1,148 @@
+package smtp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const dialTimeout = 30 * time.Second
+
+type SMTPMailer struct {
+	Config config.MailConfig
+}
+
+func (m *SMTPMailer) Install(args ...any) error {
+	m.Config = args[1].(config.MailConfig)
+
+	if m.Config.Host == "" {
+		return fmt.Errorf("smtp host is required")
+	}
+	return nil
+}
+
+func (m *SMTPMailer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *SMTPMailer) Send(ctx context.Context, message *port.MailMessage) error {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	if err := m.deliver(client, message); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// SendBatch delivers every message over a single connection
+func (m *SMTPMailer) SendBatch(ctx context.Context, messages []*port.MailMessage) error {
+	client, err := m.connect(ctx)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	errs := []error{}
+	for i, message := range messages {
+		if ctx.Err() != nil {
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		if err := m.deliver(client, message); err != nil {
+			errs = append(errs, fmt.Errorf("message %d: %v", i, err))
+			// the transaction may be left half open after a failure
+			client.Reset()
+		}
+	}
+
+	client.Quit()
+	return errors.Join(errs...)
+}
+
+func (m *SMTPMailer) connect(ctx context.Context) (*smtp.Client, error) {
+	addr := net.JoinHostPort(m.Config.Host, strconv.Itoa(m.Config.Port))
+	tlsConfig := &tls.Config{ServerName: m.Config.Host}
+	dialer := &net.Dialer{Timeout: dialTimeout}
+
+	var conn net.Conn
+	var err error
+	if m.Config.TLS == "tls" {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: tlsConfig}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := smtp.NewClient(conn, m.Config.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	if m.Config.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			client.Close()
+			return nil, fmt.Errorf("smtp server %s does not support STARTTLS", m.Config.Host)
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	if m.Config.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", m.Config.Username, m.Config.Password, m.Config.Host)); err != nil {
+			client.Close()
+			return nil, err
+		}
+	}
+
+	return client, nil
+}
+
+func (m *SMTPMailer) deliver(client *smtp.Client, message *port.MailMessage) error {
+	data, err := helper.BuildMailMessage(message)
+	if err != nil {
+		return err
+	}
+
+	from := message.From
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
+	}
+
+	if err := client.Mail(from); err != nil {
+		return err
+	}
+	for _, recipient := range helper.MailRecipients(message) {
+		if err := client.Rcpt(recipient); err != nil {
+			return err
+		}
+	}
+
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
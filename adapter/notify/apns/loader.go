@@ -0,0 +1,27 @@
+package apns
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type APNsLoader struct {
+	name string
+}
+
+func (a *APNsLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *APNsLoader) Name() string {
+	return a.name
+}
+
+func (l *APNsLoader) Init(args ...any) (port.Library, error) {
+	notifier := &APNsNotifier{}
+	err := notifier.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifier, nil
+}
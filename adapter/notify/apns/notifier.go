@@ -0,0 +1,153 @@
+package apns
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const (
+	productionHost  = "https://api.push.apple.com"
+	developmentHost = "https://api.sandbox.push.apple.com"
+	// Apple rejects provider tokens older than one hour and throttles frequent renewals
+	tokenLifetime = 50 * time.Minute
+)
+
+// APNsNotifier sends push notifications to Apple devices with token based (.p8) authentication
+type APNsNotifier struct {
+	Config config.PushConfig
+	client *http.Client
+	key    *ecdsa.PrivateKey
+	host   string
+
+	mu       sync.Mutex
+	token    string
+	issuedAt time.Time
+}
+
+func (n *APNsNotifier) Install(args ...any) error {
+	n.Config = args[1].(config.PushConfig)
+
+	if n.Config.KeyID == "" || n.Config.TeamID == "" || n.Config.Topic == "" {
+		return fmt.Errorf("apns key_id, team_id and topic are required")
+	}
+
+	data, err := os.ReadFile(n.Config.KeyPath)
+	if err != nil {
+		return fmt.Errorf("apns key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return fmt.Errorf("apns key: invalid PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("apns key: %v", err)
+	}
+	key, ok := parsed.(*ecdsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("apns key: private key is not ECDSA")
+	}
+	n.key = key
+
+	n.host = developmentHost
+	if n.Config.Production {
+		n.host = productionHost
+	}
+
+	// APNs only speaks HTTP/2, negotiated automatically over TLS
+	n.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (n *APNsNotifier) Uninstall() error {
+	n.client.CloseIdleConnections()
+	return nil
+}
+
+func (n *APNsNotifier) Notify(ctx context.Context, notification *port.Notification) error {
+	token, err := n.providerToken()
+	if err != nil {
+		return err
+	}
+
+	payload := map[string]any{}
+	for key, value := range notification.Data {
+		payload[key] = value
+	}
+	payload["aps"] = map[string]any{
+		"alert": map[string]string{"title": notification.Title, "body": notification.Body},
+		"sound": "default",
+	}
+
+	body, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.host+"/3/device/"+notification.To, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "bearer "+token)
+	req.Header.Set("apns-topic", n.Config.Topic)
+	req.Header.Set("apns-push-type", "alert")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("apns send failed: %s %s", resp.Status, detail)
+	}
+	return nil
+}
+
+// providerToken returns the ES256 JWT authenticating the provider, renewed before it expires
+func (n *APNsNotifier) providerToken() (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.token != "" && time.Since(n.issuedAt) < tokenLifetime {
+		return n.token, nil
+	}
+
+	now := time.Now()
+	header, _ := helper.JSONMarshal(map[string]string{"alg": "ES256", "kid": n.Config.KeyID})
+	claims, _ := helper.JSONMarshal(map[string]any{"iss": n.Config.TeamID, "iat": now.Unix()})
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	hash := sha256.Sum256([]byte(unsigned))
+	r, s, err := ecdsa.Sign(rand.Reader, n.key, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	// JWS wants the raw 64 bytes r||s signature, not ASN.1
+	signature := make([]byte, 64)
+	r.FillBytes(signature[:32])
+	s.FillBytes(signature[32:])
+
+	n.token = unsigned + "." + base64.RawURLEncoding.EncodeToString(signature)
+	n.issuedAt = now
+	return n.token, nil
+}
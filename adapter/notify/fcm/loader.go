@@ -0,0 +1,27 @@
+package fcm
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type FCMLoader struct {
+	name string
+}
+
+func (a *FCMLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *FCMLoader) Name() string {
+	return a.name
+}
+
+func (l *FCMLoader) Init(args ...any) (port.Library, error) {
+	notifier := &FCMNotifier{}
+	err := notifier.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifier, nil
+}
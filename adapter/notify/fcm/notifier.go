@@ -0,0 +1,212 @@
+package fcm
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const (
+	messagingScope = "https://www.googleapis.com/auth/firebase.messaging"
+	defaultToken   = "https://oauth2.googleapis.com/token"
+)
+
+// FCMNotifier sends push notifications through the Firebase Cloud Messaging HTTP v1 API,
+// authenticated with a service account
+type FCMNotifier struct {
+	Config     config.PushConfig
+	client     *http.Client
+	credential config.GoogleCredential
+	key        *rsa.PrivateKey
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type fcmRequest struct {
+	Message struct {
+		Token        string            `json:"token"`
+		Notification *fcmNotification  `json:"notification,omitempty"`
+		Data         map[string]string `json:"data,omitempty"`
+	} `json:"message"`
+}
+
+type fcmNotification struct {
+	Title string `json:"title,omitempty"`
+	Body  string `json:"body,omitempty"`
+}
+
+func (n *FCMNotifier) Install(args ...any) error {
+	n.Config = args[1].(config.PushConfig)
+
+	data, err := os.ReadFile(n.Config.Credentials)
+	if err != nil {
+		return fmt.Errorf("fcm credentials: %v", err)
+	}
+	if err := helper.JSONUnmarshal(data, &n.credential); err != nil {
+		return fmt.Errorf("fcm credentials: %v", err)
+	}
+
+	block, _ := pem.Decode([]byte(n.credential.PrivateKey))
+	if block == nil {
+		return fmt.Errorf("fcm credentials: invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("fcm credentials: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("fcm credentials: private key is not RSA")
+	}
+	n.key = key
+
+	if n.Config.ProjectID == "" {
+		n.Config.ProjectID = n.credential.ProjectID
+	}
+	if n.credential.TokenURI == "" {
+		n.credential.TokenURI = defaultToken
+	}
+
+	n.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (n *FCMNotifier) Uninstall() error {
+	n.client.CloseIdleConnections()
+	return nil
+}
+
+func (n *FCMNotifier) Notify(ctx context.Context, notification *port.Notification) error {
+	token, err := n.token(ctx)
+	if err != nil {
+		return err
+	}
+
+	var payload fcmRequest
+	payload.Message.Token = notification.To
+	payload.Message.Data = notification.Data
+	if notification.Title != "" || notification.Body != "" {
+		payload.Message.Notification = &fcmNotification{Title: notification.Title, Body: notification.Body}
+	}
+
+	body, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return err
+	}
+
+	endpoint := "https://fcm.googleapis.com/v1/projects/" + url.PathEscape(n.Config.ProjectID) + "/messages:send"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("fcm send failed: %s %s", resp.Status, detail)
+	}
+	return nil
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed JWT when it expires
+func (n *FCMNotifier) token(ctx context.Context) (string, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.accessToken != "" && time.Now().Before(n.expiresAt) {
+		return n.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := n.signJWT(map[string]any{
+		"iss":   n.credential.ClientEmail,
+		"scope": messagingScope,
+		"aud":   n.credential.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.credential.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("fcm token exchange failed: %s %s", resp.Status, data)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := helper.JSONUnmarshal(data, &result); err != nil {
+		return "", err
+	}
+
+	n.accessToken = result.AccessToken
+	// refresh a minute early to avoid using a token expiring in flight
+	n.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return n.accessToken, nil
+}
+
+func (n *FCMNotifier) signJWT(claims map[string]any) (string, error) {
+	header, _ := helper.JSONMarshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, err := helper.JSONMarshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, n.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
@@ -0,0 +1,27 @@
+package twilio
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type TwilioLoader struct {
+	name string
+}
+
+func (a *TwilioLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *TwilioLoader) Name() string {
+	return a.name
+}
+
+func (l *TwilioLoader) Init(args ...any) (port.Library, error) {
+	notifier := &TwilioNotifier{}
+	err := notifier.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return notifier, nil
+}
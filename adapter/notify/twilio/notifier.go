@@ -0,0 +1,70 @@
+package twilio
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const apiBase = "https://api.twilio.com/2010-04-01/Accounts/"
+
+// TwilioNotifier sends SMS through the Twilio Messages API
+type TwilioNotifier struct {
+	Config config.SMSConfig
+	client *http.Client
+}
+
+func (n *TwilioNotifier) Install(args ...any) error {
+	n.Config = args[1].(config.SMSConfig)
+
+	if n.Config.AccountSID == "" || n.Config.AuthToken == "" || n.Config.From == "" {
+		return fmt.Errorf("twilio account_sid, auth_token and from are required")
+	}
+
+	n.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (n *TwilioNotifier) Uninstall() error {
+	n.client.CloseIdleConnections()
+	return nil
+}
+
+func (n *TwilioNotifier) Notify(ctx context.Context, notification *port.Notification) error {
+	body := notification.Body
+	if notification.Title != "" {
+		body = notification.Title + "\n" + body
+	}
+
+	form := url.Values{}
+	form.Set("To", notification.To)
+	form.Set("From", n.Config.From)
+	form.Set("Body", body)
+
+	endpoint := apiBase + url.PathEscape(n.Config.AccountSID) + "/Messages.json"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(n.Config.AccountSID, n.Config.AuthToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("twilio send failed: %s %s", resp.Status, detail)
+	}
+	return nil
+}
@@ -0,0 +1,350 @@
+package stripe
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const apiBase = "https://api.stripe.com"
+
+// signatureTolerance rejects replayed webhook deliveries signed longer ago
+const signatureTolerance = 5 * time.Minute
+
+// StripeGateway takes payments through the Stripe PaymentIntents API, or any API compatible
+// with it set as payment.base_url. Webhooks are verified with the Stripe-Signature header.
+type StripeGateway struct {
+	Config config.PaymentConfig
+	client *http.Client
+	now    func() time.Time
+}
+
+type stripeError struct {
+	Error struct {
+		Type    string `json:"type"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+type paymentIntent struct {
+	ID           string            `json:"id"`
+	Status       string            `json:"status"`
+	Amount       int64             `json:"amount"`
+	Currency     string            `json:"currency"`
+	ClientSecret string            `json:"client_secret"`
+	Metadata     map[string]string `json:"metadata"`
+	NextAction   *struct {
+		RedirectToURL *struct {
+			URL string `json:"url"`
+		} `json:"redirect_to_url"`
+	} `json:"next_action"`
+}
+
+type charge struct {
+	PaymentIntent  string            `json:"payment_intent"`
+	Amount         int64             `json:"amount"`
+	AmountRefunded int64             `json:"amount_refunded"`
+	Refunded       bool              `json:"refunded"`
+	Currency       string            `json:"currency"`
+	Metadata       map[string]string `json:"metadata"`
+}
+
+type refund struct {
+	ID            string `json:"id"`
+	PaymentIntent string `json:"payment_intent"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+}
+
+type event struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+func (g *StripeGateway) Install(args ...any) error {
+	g.Config = args[1].(config.PaymentConfig)
+
+	if g.Config.APIKey == "" {
+		return fmt.Errorf("stripe api_key is required")
+	}
+	if g.Config.BaseURL == "" {
+		g.Config.BaseURL = apiBase
+	}
+	g.Config.BaseURL = strings.TrimSuffix(g.Config.BaseURL, "/")
+
+	timeout := g.Config.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	g.client = &http.Client{Timeout: timeout}
+	g.now = time.Now
+	return nil
+}
+
+func (g *StripeGateway) Uninstall() error {
+	g.client.CloseIdleConnections()
+	return nil
+}
+
+func (g *StripeGateway) CreatePayment(ctx context.Context, request *port.PaymentRequest) (*port.Payment, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(request.Amount, 10))
+	form.Set("currency", strings.ToLower(request.Currency))
+	form.Set("automatic_payment_methods[enabled]", "true")
+	if request.Description != "" {
+		form.Set("description", request.Description)
+	}
+	if request.CustomerEmail != "" {
+		form.Set("receipt_email", request.CustomerEmail)
+	}
+	if request.ManualCapture {
+		form.Set("capture_method", "manual")
+	}
+	setMetadata(form, request.Metadata)
+
+	var intent paymentIntent
+	if err := g.call(ctx, http.MethodPost, "/v1/payment_intents", form, request.IdempotencyKey, &intent); err != nil {
+		return nil, err
+	}
+	return intent.payment(), nil
+}
+
+func (g *StripeGateway) GetPayment(ctx context.Context, id string) (*port.Payment, error) {
+	var intent paymentIntent
+	if err := g.call(ctx, http.MethodGet, "/v1/payment_intents/"+url.PathEscape(id), nil, "", &intent); err != nil {
+		return nil, err
+	}
+	return intent.payment(), nil
+}
+
+func (g *StripeGateway) CapturePayment(ctx context.Context, id string, amount int64) (*port.Payment, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+	}
+
+	var intent paymentIntent
+	if err := g.call(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/capture", form, "", &intent); err != nil {
+		return nil, err
+	}
+	return intent.payment(), nil
+}
+
+func (g *StripeGateway) CancelPayment(ctx context.Context, id string) (*port.Payment, error) {
+	var intent paymentIntent
+	if err := g.call(ctx, http.MethodPost, "/v1/payment_intents/"+url.PathEscape(id)+"/cancel", url.Values{}, "", &intent); err != nil {
+		return nil, err
+	}
+	return intent.payment(), nil
+}
+
+func (g *StripeGateway) Refund(ctx context.Context, request *port.RefundRequest) (*port.Refund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", request.PaymentID)
+	if request.Amount > 0 {
+		form.Set("amount", strconv.FormatInt(request.Amount, 10))
+	}
+	if request.Reason != "" {
+		form.Set("reason", request.Reason)
+	}
+	setMetadata(form, request.Metadata)
+
+	var result refund
+	if err := g.call(ctx, http.MethodPost, "/v1/refunds", form, request.IdempotencyKey, &result); err != nil {
+		return nil, err
+	}
+	return &port.Refund{
+		ID:        result.ID,
+		PaymentID: result.PaymentIntent,
+		Status:    result.Status,
+		Amount:    result.Amount,
+		Currency:  result.Currency,
+	}, nil
+}
+
+// VerifyWebhook checks the Stripe-Signature header ("t=<unix>,v1=<hex hmac>,...") signing
+// "<t>.<body>" with the webhook secret
+func (g *StripeGateway) VerifyWebhook(headers map[string]string, body []byte) (string, error) {
+	if g.Config.WebhookSecret == "" {
+		return "", fmt.Errorf("stripe webhook_secret is not configured")
+	}
+
+	var timestamp string
+	var signatures [][]byte
+	for _, part := range strings.Split(headers["Stripe-Signature"], ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			if signature, err := hex.DecodeString(value); err == nil {
+				signatures = append(signatures, signature)
+			}
+		}
+	}
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return "", fmt.Errorf("missing or malformed Stripe-Signature header")
+	}
+	if age := g.now().Sub(time.Unix(unix, 0)); math.Abs(float64(age)) > float64(signatureTolerance) {
+		return "", fmt.Errorf("webhook signed %s ago, outside the tolerance", age.Round(time.Second))
+	}
+
+	mac := hmac.New(sha256.New, []byte(g.Config.WebhookSecret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range signatures {
+		if hmac.Equal(signature, expected) {
+			var evt event
+			if err := helper.JSONUnmarshal(body, &evt); err != nil {
+				return "", err
+			}
+			return evt.ID, nil
+		}
+	}
+	return "", fmt.Errorf("signature mismatch")
+}
+
+func (g *StripeGateway) ParseWebhook(body []byte) (*port.PaymentEvent, error) {
+	var evt event
+	if err := helper.JSONUnmarshal(body, &evt); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasPrefix(evt.Type, "payment_intent."):
+		var intent paymentIntent
+		if err := helper.JSONUnmarshal(evt.Data.Object, &intent); err != nil {
+			return nil, err
+		}
+		status := intentStatus(intent.Status)
+		if evt.Type == "payment_intent.payment_failed" {
+			// the intent is back to requires_payment_method, the attempt failed
+			status = port.PaymentFailed
+		}
+		return &port.PaymentEvent{
+			ID:        evt.ID,
+			Type:      evt.Type,
+			PaymentID: intent.ID,
+			Status:    status,
+			Amount:    intent.Amount,
+			Currency:  intent.Currency,
+			Metadata:  intent.Metadata,
+		}, nil
+	case evt.Type == "charge.refunded":
+		var ch charge
+		if err := helper.JSONUnmarshal(evt.Data.Object, &ch); err != nil {
+			return nil, err
+		}
+		status := port.PaymentSucceeded
+		if ch.Refunded {
+			status = port.PaymentRefunded
+		}
+		return &port.PaymentEvent{
+			ID:        evt.ID,
+			Type:      evt.Type,
+			PaymentID: ch.PaymentIntent,
+			Status:    status,
+			Amount:    ch.Amount,
+			Refunded:  ch.AmountRefunded,
+			Currency:  ch.Currency,
+			Metadata:  ch.Metadata,
+		}, nil
+	}
+	return nil, nil
+}
+
+func (g *StripeGateway) call(ctx context.Context, method string, path string, form url.Values, idempotencyKey string, result any) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.Config.BaseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+g.Config.APIKey)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		var detail stripeError
+		if helper.JSONUnmarshal(data, &detail) == nil && detail.Error.Message != "" {
+			return fmt.Errorf("stripe %s: %s (%s)", resp.Status, detail.Error.Message, detail.Error.Type)
+		}
+		return fmt.Errorf("stripe %s: %s", resp.Status, data)
+	}
+	return helper.JSONUnmarshal(data, result)
+}
+
+func (i *paymentIntent) payment() *port.Payment {
+	payment := &port.Payment{
+		ID:           i.ID,
+		Status:       intentStatus(i.Status),
+		Amount:       i.Amount,
+		Currency:     i.Currency,
+		ClientSecret: i.ClientSecret,
+		Metadata:     i.Metadata,
+	}
+	if i.NextAction != nil && i.NextAction.RedirectToURL != nil {
+		payment.RedirectURL = i.NextAction.RedirectToURL.URL
+	}
+	return payment
+}
+
+func intentStatus(status string) string {
+	switch status {
+	case "requires_action":
+		return port.PaymentRequiresAction
+	case "requires_capture":
+		return port.PaymentAuthorized
+	case "succeeded":
+		return port.PaymentSucceeded
+	case "canceled":
+		return port.PaymentCanceled
+	}
+	// requires_payment_method, requires_confirmation, processing
+	return port.PaymentPending
+}
+
+func setMetadata(form url.Values, metadata map[string]string) {
+	for key, value := range metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+}
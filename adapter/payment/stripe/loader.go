@@ -0,0 +1,28 @@
+package stripe
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// StripeGatewayLoader loads the Stripe payment gateway, register it as "payment:stripe"
+type StripeGatewayLoader struct {
+	name string
+}
+
+func (a *StripeGatewayLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *StripeGatewayLoader) Name() string {
+	return a.name
+}
+
+func (l *StripeGatewayLoader) Init(args ...any) (port.Library, error) {
+	gateway := &StripeGateway{}
+	err := gateway.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return gateway, nil
+}
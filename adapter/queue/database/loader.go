@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DatabaseQueueLoader provides a port.IQueueStore persisted with the default database library
+type DatabaseQueueLoader struct {
+	name string
+}
+
+func (a *DatabaseQueueLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *DatabaseQueueLoader) Name() string {
+	return a.name
+}
+
+// DependsOn loads the library after the default database
+func (a *DatabaseQueueLoader) DependsOn() []string {
+	return []string{"database"}
+}
+
+func (l *DatabaseQueueLoader) Init(args ...any) (port.Library, error) {
+	context := args[0].(*core.AppContext)
+
+	libDb, ok := context.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Queue store cannot be loaded, database %s not found", context.Config.Database.Driver)
+	}
+
+	store := &DatabaseQueueStore{
+		Connection: libDb.(port.IDatabase),
+		Table:      DefaultTable,
+	}
+	err := store.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
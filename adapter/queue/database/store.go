@@ -0,0 +1,179 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DefaultTable is the table (or collection) holding the jobs
+const DefaultTable = "queue_jobs"
+
+// reserveBatch is the number of due jobs fetched per reservation attempt
+const reserveBatch = 10
+
+// DatabaseQueueStore stores jobs in a table, workers of every instance compete
+// for a job with a conditional update on its state
+type DatabaseQueueStore struct {
+	Connection port.IDatabase
+	Table      string
+}
+
+func (s *DatabaseQueueStore) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseQueueStore) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseQueueStore) Push(ctx context.Context, job *port.QueueJob) error {
+	data, err := helper.MarshalDbMap(job)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Connection.InsertOne(ctx, s.Table, data)
+	return err
+}
+
+func (s *DatabaseQueueStore) Reserve(ctx context.Context, queue string, now time.Time, lease time.Duration) (*port.QueueJob, error) {
+	job, err := s.reserve(ctx, now, lease, []port.DbExpression{
+		{Expr: "queue", Args: []any{queue}},
+		{Expr: "state", Args: []any{port.JobStatePending}},
+		{Expr: "run_at", Op: "<=", Args: []any{now}},
+	})
+	if job != nil || err != nil {
+		return job, err
+	}
+
+	// the jobs of the instances lost while running them
+	return s.reserve(ctx, now, lease, []port.DbExpression{
+		{Expr: "queue", Args: []any{queue}},
+		{Expr: "state", Args: []any{port.JobStateRunning}},
+		{Expr: "reserved_until", Op: "<", Args: []any{now}},
+	})
+}
+
+// reserve claims the first job matching filter, the filter being checked again by the claim
+func (s *DatabaseQueueStore) reserve(ctx context.Context, now time.Time, lease time.Duration, filter []port.DbExpression) (*port.QueueJob, error) {
+	var jobs []port.QueueJob
+	err := s.Connection.Find(ctx, &jobs, s.Table, []string{}, filter, map[string]int{"priority": -1}, reserveBatch, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// oldest first inside the same priority
+	for len(jobs) > 0 {
+		best := 0
+		for i, job := range jobs {
+			if job.Priority > jobs[best].Priority ||
+				(job.Priority == jobs[best].Priority && job.RunAt.Before(jobs[best].RunAt)) {
+				best = i
+			}
+		}
+		job := jobs[best]
+		jobs = append(jobs[:best], jobs[best+1:]...)
+
+		// another worker may have taken the job since it was read
+		claimed, err := s.Connection.UpdateOne(ctx, s.Table, append([]port.DbExpression{
+			{Expr: "id", Args: []any{job.ID}},
+		}, filter[1:]...), port.DbMap{
+			"state":          port.JobStateRunning,
+			"attempts":       job.Attempts + 1,
+			"reserved_until": now.Add(lease),
+			"updated_at":     now,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if claimed == 1 {
+			job.State = port.JobStateRunning
+			job.Attempts++
+			job.ReservedUntil = now.Add(lease)
+			job.UpdatedAt = now
+			return &job, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// held filters the job while it holds the reservation of attempt, each reservation counting
+// an attempt
+func held(job *port.QueueJob, attempt int) []port.DbExpression {
+	return []port.DbExpression{
+		{Expr: "id", Args: []any{job.ID}},
+		{Expr: "state", Args: []any{port.JobStateRunning}},
+		{Expr: "attempts", Args: []any{attempt}},
+	}
+}
+
+func (s *DatabaseQueueStore) Renew(ctx context.Context, job *port.QueueJob, until time.Time) error {
+	renewed, err := s.Connection.UpdateOne(ctx, s.Table, held(job, job.Attempts), port.DbMap{
+		"reserved_until": until,
+	})
+	if err != nil {
+		return err
+	}
+	if renewed == 0 {
+		return port.ErrJobLost
+	}
+
+	job.ReservedUntil = until
+	return nil
+}
+
+func (s *DatabaseQueueStore) Update(ctx context.Context, job *port.QueueJob, attempt int) error {
+	updated, err := s.Connection.UpdateOne(ctx, s.Table, held(job, attempt), port.DbMap{
+		"state":          job.State,
+		"attempts":       job.Attempts,
+		"run_at":         job.RunAt,
+		"last_error":     job.LastError,
+		"reserved_until": job.ReservedUntil,
+		"updated_at":     job.UpdatedAt,
+	})
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return port.ErrJobLost
+	}
+	return nil
+}
+
+func (s *DatabaseQueueStore) Stats(ctx context.Context, queue string) (map[string]int64, error) {
+	stats := map[string]int64{}
+	for _, state := range []string{port.JobStatePending, port.JobStateRunning, port.JobStateDone, port.JobStateFailed} {
+		count, err := s.Connection.Count(ctx, s.Table, []port.DbExpression{
+			{Expr: "queue", Args: []any{queue}},
+			{Expr: "state", Args: []any{state}},
+		})
+		if err != nil {
+			return nil, err
+		}
+		stats[state] = count
+	}
+	return stats, nil
+}
+
+func (s *DatabaseQueueStore) List(ctx context.Context, queue string, state string, limit int) ([]port.QueueJob, error) {
+	filter := []port.DbExpression{
+		{Expr: "queue", Args: []any{queue}},
+	}
+	if state != "" {
+		filter = append(filter, port.DbExpression{Expr: "state", Args: []any{state}})
+	}
+
+	jobs := []port.QueueJob{}
+	err := s.Connection.Find(ctx, &jobs, s.Table, []string{}, filter, map[string]int{"created_at": 1}, int64(limit), 0)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
@@ -0,0 +1,28 @@
+package chromium
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// ChromiumRendererLoader loads the headless Chromium driver, register it as "report:chromium"
+type ChromiumRendererLoader struct {
+	name string
+}
+
+func (a *ChromiumRendererLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *ChromiumRendererLoader) Name() string {
+	return a.name
+}
+
+func (l *ChromiumRendererLoader) Init(args ...any) (port.Library, error) {
+	renderer := &ChromiumRenderer{}
+	err := renderer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderer, nil
+}
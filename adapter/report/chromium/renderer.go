@@ -0,0 +1,119 @@
+package chromium
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+var headTag = regexp.MustCompile(`(?i)<head(\s[^>]*)?>`)
+
+var binaries = []string{"chromium", "chromium-browser", "google-chrome", "google-chrome-stable"}
+
+// ChromiumRenderer prints HTML to PDF with headless Chromium (or Chrome). The page size,
+// orientation and margins are applied with a CSS @page rule.
+type ChromiumRenderer struct {
+	Config config.ReportConfig
+	binary string
+}
+
+func (r *ChromiumRenderer) Install(args ...any) error {
+	r.Config = args[1].(config.ReportConfig)
+
+	candidates := binaries
+	if r.Config.Binary != "" {
+		candidates = []string{r.Config.Binary}
+	}
+	for _, binary := range candidates {
+		if path, err := exec.LookPath(binary); err == nil {
+			r.binary = path
+			return nil
+		}
+	}
+
+	return fmt.Errorf("chromium executable not found, tried %s", strings.Join(candidates, ", "))
+}
+
+func (r *ChromiumRenderer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (r *ChromiumRenderer) RenderPDF(ctx context.Context, html string, opts port.PDFOptions, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "report-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "report.html")
+	output := filepath.Join(dir, "report.pdf")
+	if err := os.WriteFile(input, []byte(withPageStyle(html, opts)), 0o600); err != nil {
+		return err
+	}
+
+	args := []string{
+		"--headless=new",
+		"--disable-gpu",
+		"--no-first-run",
+		"--no-pdf-header-footer",
+		"--user-data-dir=" + filepath.Join(dir, "profile"),
+		"--print-to-pdf=" + output,
+	}
+	if os.Geteuid() == 0 {
+		// Chromium refuses to run as root with its sandbox, typical in containers
+		args = append(args, "--no-sandbox")
+	}
+	args = append(args, "file://"+input)
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("chromium: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		return fmt.Errorf("chromium did not produce a PDF: %v", err)
+	}
+	defer file.Close()
+
+	_, err = io.Copy(w, file)
+	return err
+}
+
+// withPageStyle adds the @page rule to the head of the document, before the body when it has none
+func withPageStyle(html string, opts port.PDFOptions) string {
+	size := opts.PageSize
+	if size == "" {
+		size = "A4"
+	}
+	if opts.Landscape {
+		size += " landscape"
+	}
+
+	style := "<style>@page { size: " + size
+	if opts.Margin != "" {
+		style += "; margin: " + opts.Margin
+	}
+	style += " }</style>"
+
+	if loc := headTag.FindStringIndex(html); loc != nil {
+		return html[:loc[1]] + style + html[loc[1]:]
+	}
+	return style + html
+}
@@ -0,0 +1,28 @@
+package wkhtmltopdf
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// WkhtmltopdfRendererLoader loads the wkhtmltopdf driver, register it as "report:wkhtmltopdf"
+type WkhtmltopdfRendererLoader struct {
+	name string
+}
+
+func (a *WkhtmltopdfRendererLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *WkhtmltopdfRendererLoader) Name() string {
+	return a.name
+}
+
+func (l *WkhtmltopdfRendererLoader) Init(args ...any) (port.Library, error) {
+	renderer := &WkhtmltopdfRenderer{}
+	err := renderer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderer, nil
+}
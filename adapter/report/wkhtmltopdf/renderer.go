@@ -0,0 +1,71 @@
+package wkhtmltopdf
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// WkhtmltopdfRenderer converts HTML into PDF with the wkhtmltopdf executable,
+// the HTML is piped through stdin and the PDF read from stdout
+type WkhtmltopdfRenderer struct {
+	Config config.ReportConfig
+	binary string
+}
+
+func (r *WkhtmltopdfRenderer) Install(args ...any) error {
+	r.Config = args[1].(config.ReportConfig)
+
+	binary := r.Config.Binary
+	if binary == "" {
+		binary = "wkhtmltopdf"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return fmt.Errorf("wkhtmltopdf executable not found: %v", err)
+	}
+	r.binary = path
+
+	return nil
+}
+
+func (r *WkhtmltopdfRenderer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (r *WkhtmltopdfRenderer) RenderPDF(ctx context.Context, html string, opts port.PDFOptions, w io.Writer) error {
+	args := []string{"--quiet", "--encoding", "utf-8"}
+	if opts.PageSize != "" {
+		args = append(args, "--page-size", opts.PageSize)
+	}
+	if opts.Landscape {
+		args = append(args, "--orientation", "Landscape")
+	}
+	if opts.Margin != "" {
+		args = append(args,
+			"--margin-top", opts.Margin, "--margin-bottom", opts.Margin,
+			"--margin-left", opts.Margin, "--margin-right", opts.Margin)
+	}
+	args = append(args, "-", "-")
+
+	var stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	cmd.Stdin = strings.NewReader(html)
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return fmt.Errorf("wkhtmltopdf: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
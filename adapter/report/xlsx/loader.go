@@ -0,0 +1,28 @@
+package xlsx
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// XLSXRendererLoader loads the xlsx driver, register it as "report:xlsx"
+type XLSXRendererLoader struct {
+	name string
+}
+
+func (a *XLSXRendererLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *XLSXRendererLoader) Name() string {
+	return a.name
+}
+
+func (l *XLSXRendererLoader) Init(args ...any) (port.Library, error) {
+	renderer := &XLSXRenderer{}
+	err := renderer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return renderer, nil
+}
@@ -0,0 +1,270 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+)
+
+type workbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+		ID   string `xml:"http://schemas.openxmlformats.org/officeDocument/2006/relationships id,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+type relationshipsXML struct {
+	Relationships []struct {
+		ID     string `xml:"Id,attr"`
+		Target string `xml:"Target,attr"`
+	} `xml:"Relationship"`
+}
+
+// richText is the content of a shared string or an inline string, plain or split in runs
+type richText struct {
+	T    string `xml:"t"`
+	Runs []struct {
+		T string `xml:"t"`
+	} `xml:"r"`
+}
+
+func (t richText) String() string {
+	if len(t.Runs) == 0 {
+		return t.T
+	}
+	var b strings.Builder
+	for _, run := range t.Runs {
+		b.WriteString(run.T)
+	}
+	return b.String()
+}
+
+type cellXML struct {
+	Ref    string   `xml:"r,attr"`
+	Type   string   `xml:"t,attr"`
+	Value  string   `xml:"v"`
+	Inline richText `xml:"is"`
+}
+
+func (r *XLSXRenderer) ReadXLSX(ctx context.Context, src io.ReaderAt, size int64, sheet string, row func(cells []string) error) error {
+	archive, err := zip.NewReader(src, size)
+	if err != nil {
+		return fmt.Errorf("not an xlsx workbook: %v", err)
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+
+	name, err := sheetPath(files, sheet)
+	if err != nil {
+		return err
+	}
+
+	var shared []string
+	if file, ok := files["xl/sharedStrings.xml"]; ok {
+		if shared, err = sharedStrings(file); err != nil {
+			return err
+		}
+	}
+
+	part, err := files[name].Open()
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+
+	return readRows(ctx, xml.NewDecoder(part), shared, row)
+}
+
+// sheetPath returns the archive path of the worksheet named sheet, the first one when empty
+func sheetPath(files map[string]*zip.File, sheet string) (string, error) {
+	var workbook workbookXML
+	if err := decodeFile(files, "xl/workbook.xml", &workbook); err != nil {
+		return "", err
+	}
+	var rels relationshipsXML
+	if err := decodeFile(files, "xl/_rels/workbook.xml.rels", &rels); err != nil {
+		return "", err
+	}
+
+	id := ""
+	for _, s := range workbook.Sheets {
+		if sheet == "" || strings.EqualFold(s.Name, sheet) {
+			id = s.ID
+			break
+		}
+	}
+	if id == "" {
+		return "", fmt.Errorf("worksheet '%s' not found", sheet)
+	}
+
+	for _, rel := range rels.Relationships {
+		if rel.ID != id {
+			continue
+		}
+		name := path.Join("xl", rel.Target)
+		if strings.HasPrefix(rel.Target, "/") {
+			name = strings.TrimPrefix(rel.Target, "/")
+		}
+		if _, ok := files[name]; !ok {
+			return "", fmt.Errorf("worksheet part '%s' is missing", name)
+		}
+		return name, nil
+	}
+	return "", fmt.Errorf("worksheet '%s' has no part", sheet)
+}
+
+func decodeFile(files map[string]*zip.File, name string, v any) error {
+	file, ok := files[name]
+	if !ok {
+		return fmt.Errorf("not an xlsx workbook: %s is missing", name)
+	}
+	part, err := file.Open()
+	if err != nil {
+		return err
+	}
+	defer part.Close()
+
+	if err := xml.NewDecoder(part).Decode(v); err != nil {
+		return fmt.Errorf("%s: %v", name, err)
+	}
+	return nil
+}
+
+func sharedStrings(file *zip.File) ([]string, error) {
+	part, err := file.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer part.Close()
+
+	strs := []string{}
+	decoder := xml.NewDecoder(part)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return strs, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", file.Name, err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok && start.Name.Local == "si" {
+			var item richText
+			if err := decoder.DecodeElement(&item, &start); err != nil {
+				return nil, fmt.Errorf("%s: %v", file.Name, err)
+			}
+			strs = append(strs, item.String())
+		}
+	}
+}
+
+// readRows streams the rows of a worksheet, rows missing from the sheet are passed empty
+// so the callback counts rows like the spreadsheet does
+func readRows(ctx context.Context, decoder *xml.Decoder, shared []string, row func(cells []string) error) error {
+	number := 0
+	var cells []string
+	inRow := false
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("worksheet: %v", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "row":
+				if err := ctx.Err(); err != nil {
+					return err
+				}
+
+				next := number + 1
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "r" {
+						if n, err := strconv.Atoi(attr.Value); err == nil && n > number {
+							next = n
+						}
+					}
+				}
+				for ; number+1 < next; number++ {
+					if err := row(nil); err != nil {
+						return err
+					}
+				}
+				number = next
+				cells = nil
+				inRow = true
+			case "c":
+				if !inRow {
+					continue
+				}
+				var cell cellXML
+				if err := decoder.DecodeElement(&cell, &t); err != nil {
+					return fmt.Errorf("worksheet: %v", err)
+				}
+
+				column := len(cells)
+				if cell.Ref != "" {
+					column = columnIndex(cell.Ref)
+				}
+				if column < 0 || column >= 16384 {
+					return fmt.Errorf("worksheet: invalid cell reference '%s'", cell.Ref)
+				}
+				for len(cells) <= column {
+					cells = append(cells, "")
+				}
+				cells[column] = cellText(cell, shared)
+			}
+		case xml.EndElement:
+			if t.Name.Local == "row" && inRow {
+				inRow = false
+				for len(cells) > 0 && cells[len(cells)-1] == "" {
+					cells = cells[:len(cells)-1]
+				}
+				if err := row(cells); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+func cellText(cell cellXML, shared []string) string {
+	switch cell.Type {
+	case "s":
+		index, err := strconv.Atoi(strings.TrimSpace(cell.Value))
+		if err != nil || index < 0 || index >= len(shared) {
+			return ""
+		}
+		return shared[index]
+	case "inlineStr":
+		return cell.Inline.String()
+	}
+	return cell.Value
+}
+
+// columnIndex returns the zero based column of an A1 reference
+func columnIndex(ref string) int {
+	column := 0
+	for _, r := range ref {
+		if r >= 'a' && r <= 'z' {
+			r -= 'a' - 'A'
+		}
+		if r < 'A' || r > 'Z' {
+			break
+		}
+		column = column*26 + int(r-'A'+1)
+	}
+	return column - 1
+}
@@ -0,0 +1,328 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+const (
+	styleHeader   = 1
+	styleDateTime = 2
+
+	// longest text Excel keeps in a cell
+	maxCellLength = 32767
+)
+
+// XLSXRenderer writes Office Open XML workbooks with the standard library: one worksheet
+// per sheet with a bold frozen header row, strings are stored inline. It also reads the
+// worksheets of uploaded workbooks for imports.
+type XLSXRenderer struct{}
+
+func (r *XLSXRenderer) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (r *XLSXRenderer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (r *XLSXRenderer) RenderXLSX(ctx context.Context, sheets []port.ReportSheet, w io.Writer) error {
+	if len(sheets) == 0 {
+		sheets = []port.ReportSheet{{Name: "Sheet1"}}
+	}
+	names := sheetNames(sheets)
+
+	archive := zip.NewWriter(w)
+	files := []struct {
+		name    string
+		content []byte
+	}{
+		{"[Content_Types].xml", contentTypes(len(sheets))},
+		{"_rels/.rels", []byte(rootRels)},
+		{"xl/workbook.xml", workbook(names)},
+		{"xl/_rels/workbook.xml.rels", workbookRels(len(sheets))},
+		{"xl/styles.xml", []byte(styles)},
+	}
+	for _, file := range files {
+		if err := writeFile(archive, file.name, file.content); err != nil {
+			return err
+		}
+	}
+
+	for i, sheet := range sheets {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		part, err := archive.Create(fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1))
+		if err != nil {
+			return err
+		}
+		if err := writeSheet(part, sheet); err != nil {
+			return err
+		}
+	}
+
+	return archive.Close()
+}
+
+func writeFile(archive *zip.Writer, name string, content []byte) error {
+	part, err := archive.Create(name)
+	if err != nil {
+		return err
+	}
+	_, err = part.Write(content)
+	return err
+}
+
+func writeSheet(w io.Writer, sheet port.ReportSheet) error {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+
+	if len(sheet.Columns) > 0 {
+		buf.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+
+		buf.WriteString(`<cols>`)
+		for i, width := range columnWidths(sheet) {
+			fmt.Fprintf(&buf, `<col min="%d" max="%d" width="%s" customWidth="1"/>`, i+1, i+1, strconv.FormatFloat(width, 'f', -1, 64))
+		}
+		buf.WriteString(`</cols>`)
+	}
+
+	buf.WriteString(`<sheetData>`)
+	row := 0
+	if len(sheet.Columns) > 0 {
+		row++
+		fmt.Fprintf(&buf, `<row r="%d">`, row)
+		for i, column := range sheet.Columns {
+			writeString(&buf, cellRef(i, row), column.Title, styleHeader)
+		}
+		buf.WriteString(`</row>`)
+	}
+
+	for _, cells := range sheet.Rows {
+		row++
+		fmt.Fprintf(&buf, `<row r="%d">`, row)
+		for i, value := range cells {
+			writeCell(&buf, cellRef(i, row), value)
+		}
+		buf.WriteString(`</row>`)
+
+		// flush large sheets as they are written
+		if buf.Len() > 1<<20 {
+			if _, err := w.Write(buf.Bytes()); err != nil {
+				return err
+			}
+			buf.Reset()
+		}
+	}
+	buf.WriteString(`</sheetData></worksheet>`)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// columnWidths returns the configured widths, or fits the header and the first rows
+func columnWidths(sheet port.ReportSheet) []float64 {
+	widths := make([]float64, len(sheet.Columns))
+	for i, column := range sheet.Columns {
+		if column.Width > 0 {
+			widths[i] = column.Width
+			continue
+		}
+
+		chars := utf8.RuneCountInString(column.Title)
+		for _, cells := range sheet.Rows[:min(len(sheet.Rows), 100)] {
+			if i >= len(cells) {
+				continue
+			}
+			switch v := cells[i].(type) {
+			case nil:
+			case time.Time:
+				chars = max(chars, 19)
+			case string:
+				chars = max(chars, utf8.RuneCountInString(v))
+			default:
+				chars = max(chars, len(fmt.Sprint(v)))
+			}
+		}
+		widths[i] = math.Max(10, math.Min(60, float64(chars+2)))
+	}
+	return widths
+}
+
+func writeCell(buf *bytes.Buffer, ref string, value any) {
+	if value == nil {
+		return
+	}
+
+	switch v := value.(type) {
+	case string:
+		writeString(buf, ref, v, 0)
+		return
+	case time.Time:
+		if v.IsZero() {
+			return
+		}
+		fmt.Fprintf(buf, `<c r="%s" s="%d"><v>%s</v></c>`, ref, styleDateTime, strconv.FormatFloat(serialDate(v), 'f', -1, 64))
+		return
+	case bool:
+		b := 0
+		if v {
+			b = 1
+		}
+		fmt.Fprintf(buf, `<c r="%s" t="b"><v>%d</v></c>`, ref, b)
+		return
+	}
+
+	rv := reflect.ValueOf(value)
+	switch {
+	case rv.CanInt():
+		fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, rv.Int())
+	case rv.CanUint():
+		fmt.Fprintf(buf, `<c r="%s"><v>%d</v></c>`, ref, rv.Uint())
+	case rv.CanFloat() && !math.IsNaN(rv.Float()) && !math.IsInf(rv.Float(), 0):
+		fmt.Fprintf(buf, `<c r="%s"><v>%s</v></c>`, ref, strconv.FormatFloat(rv.Float(), 'g', -1, 64))
+	default:
+		writeString(buf, ref, fmt.Sprint(value), 0)
+	}
+}
+
+func writeString(buf *bytes.Buffer, ref string, s string, style int) {
+	if len(s) > maxCellLength {
+		s = s[:maxCellLength]
+		for !utf8.ValidString(s) {
+			s = s[:len(s)-1]
+		}
+	}
+
+	fmt.Fprintf(buf, `<c r="%s" t="inlineStr"`, ref)
+	if style != 0 {
+		fmt.Fprintf(buf, ` s="%d"`, style)
+	}
+	buf.WriteString(`><is><t xml:space="preserve">`)
+	xml.EscapeText(buf, []byte(s))
+	buf.WriteString(`</t></is></c>`)
+}
+
+// serialDate converts the wall clock of t into an Excel serial date (days since 1899-12-30)
+func serialDate(t time.Time) float64 {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	return wall.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)).Hours() / 24
+}
+
+// cellRef returns the A1 reference of a zero based column and a one based row
+func cellRef(column int, row int) string {
+	name := ""
+	for column++; column > 0; column = (column - 1) / 26 {
+		name = string(rune('A'+(column-1)%26)) + name
+	}
+	return name + strconv.Itoa(row)
+}
+
+// sheetNames returns valid and unique worksheet names: at most 31 characters without []:*?/\
+func sheetNames(sheets []port.ReportSheet) []string {
+	names := make([]string, len(sheets))
+	used := map[string]bool{}
+	for i, sheet := range sheets {
+		name := strings.Map(func(r rune) rune {
+			if strings.ContainsRune(`[]:*?/\`, r) {
+				return '_'
+			}
+			return r
+		}, strings.Trim(sheet.Name, "'"))
+		if name == "" {
+			name = fmt.Sprintf("Sheet%d", i+1)
+		}
+		name = truncate(name, 31)
+
+		unique := name
+		for n := 2; used[strings.ToLower(unique)]; n++ {
+			suffix := fmt.Sprintf(" (%d)", n)
+			unique = truncate(name, 31-len(suffix)) + suffix
+		}
+		used[strings.ToLower(unique)] = true
+		names[i] = unique
+	}
+	return names
+}
+
+func truncate(s string, runes int) string {
+	if utf8.RuneCountInString(s) <= runes {
+		return s
+	}
+	return string([]rune(s)[:runes])
+}
+
+func contentTypes(sheets int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	buf.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	buf.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	buf.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i)
+	}
+	buf.WriteString(`</Types>`)
+	return buf.Bytes()
+}
+
+func workbook(names []string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+	for i, name := range names {
+		buf.WriteString(`<sheet name="`)
+		xml.EscapeText(&buf, []byte(name))
+		fmt.Fprintf(&buf, `" sheetId="%d" r:id="rId%d"/>`, i+1, i+1)
+	}
+	buf.WriteString(`</sheets></workbook>`)
+	return buf.Bytes()
+}
+
+func workbookRels(sheets int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+	for i := 1; i <= sheets; i++ {
+		fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, i, i)
+	}
+	fmt.Fprintf(&buf, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`, sheets+1)
+	buf.WriteString(`</Relationships>`)
+	return buf.Bytes()
+}
+
+const rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const styles = xml.Header + `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<numFmts count="1"><numFmt numFmtId="164" formatCode="yyyy-mm-dd hh:mm:ss"/></numFmts>` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="3">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/>` +
+	`<xf numFmtId="164" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
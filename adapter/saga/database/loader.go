@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DatabaseSagaLoader provides a port.ISagaStore persisted with the default database library
+type DatabaseSagaLoader struct {
+	name string
+}
+
+func (a *DatabaseSagaLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *DatabaseSagaLoader) Name() string {
+	return a.name
+}
+
+// DependsOn loads the library after the default database
+func (a *DatabaseSagaLoader) DependsOn() []string {
+	return []string{"database"}
+}
+
+func (l *DatabaseSagaLoader) Init(args ...any) (port.Library, error) {
+	context := args[0].(*core.AppContext)
+
+	libDb, ok := context.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Saga store cannot be loaded, database %s not found", context.Config.Database.Driver)
+	}
+
+	store := &DatabaseSagaStore{
+		Connection: libDb.(port.IDatabase),
+		Table:      DefaultTable,
+	}
+	err := store.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DefaultTable is the table (or collection) holding the sagas
+const DefaultTable = "sagas"
+
+// DatabaseSagaStore stores sagas in a table, instances compete for a saga with a
+// conditional update on its version
+type DatabaseSagaStore struct {
+	Connection port.IDatabase
+	Table      string
+}
+
+func (s *DatabaseSagaStore) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseSagaStore) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseSagaStore) Insert(ctx context.Context, saga *port.SagaRecord) error {
+	data, err := helper.MarshalDbMap(saga)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.Connection.InsertOne(ctx, s.Table, data)
+	return err
+}
+
+func (s *DatabaseSagaStore) Update(ctx context.Context, saga *port.SagaRecord, version int64) (bool, error) {
+	updated, err := s.Connection.UpdateOne(ctx, s.Table, []port.DbExpression{
+		{Expr: "id", Args: []any{saga.ID}},
+		{Expr: "version", Args: []any{version}},
+	}, port.DbMap{
+		"state":        saga.State,
+		"step":         saga.Step,
+		"attempts":     saga.Attempts,
+		"data":         saga.Data,
+		"last_error":   saga.LastError,
+		"locked_until": saga.LockedUntil,
+		"version":      saga.Version,
+		"updated_at":   saga.UpdatedAt,
+	})
+	if err != nil {
+		return false, err
+	}
+	return updated == 1, nil
+}
+
+func (s *DatabaseSagaStore) Get(ctx context.Context, id string) (*port.SagaRecord, error) {
+	sagas := []port.SagaRecord{}
+	err := s.Connection.Find(ctx, &sagas, s.Table, []string{}, []port.DbExpression{
+		{Expr: "id", Args: []any{id}},
+	}, nil, 1, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sagas) == 0 {
+		return nil, nil
+	}
+	return &sagas[0], nil
+}
+
+func (s *DatabaseSagaStore) Stalled(ctx context.Context, now time.Time, limit int) ([]port.SagaRecord, error) {
+	sagas := []port.SagaRecord{}
+	err := s.Connection.Find(ctx, &sagas, s.Table, []string{}, []port.DbExpression{
+		{Expr: "state", Op: "IN", Args: []any{port.SagaRunning, port.SagaCompensating}},
+		{Expr: "locked_until", Op: "<", Args: []any{now}},
+	}, map[string]int{"locked_until": 1}, int64(limit), 0)
+	if err != nil {
+		return nil, err
+	}
+	return sagas, nil
+}
@@ -0,0 +1,146 @@
+package elasticsearch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ElasticsearchIndex writes documents with the bulk API of Elasticsearch (or OpenSearch)
+type ElasticsearchIndex struct {
+	Config config.SearchConfig
+	client *http.Client
+}
+
+type bulkAction struct {
+	Index string `json:"_index"`
+	ID    string `json:"_id"`
+}
+
+type bulkResponse struct {
+	Errors bool                        `json:"errors"`
+	Items  []map[string]bulkItemResult `json:"items"`
+}
+
+type bulkItemResult struct {
+	ID     string `json:"_id"`
+	Status int    `json:"status"`
+	Error  any    `json:"error,omitempty"`
+}
+
+func (e *ElasticsearchIndex) Install(args ...any) error {
+	e.Config = args[1].(config.SearchConfig)
+
+	if e.Config.URL == "" {
+		e.Config.URL = "http://127.0.0.1:9200"
+	}
+	e.Config.URL = strings.TrimSuffix(e.Config.URL, "/")
+	e.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (e *ElasticsearchIndex) Uninstall() error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+func (e *ElasticsearchIndex) Index(ctx context.Context, index string, docs []port.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, doc := range docs {
+		if err := writeLine(&body, map[string]bulkAction{"index": {Index: index, ID: doc.ID}}); err != nil {
+			return err
+		}
+		if err := writeLine(&body, doc.Body); err != nil {
+			return fmt.Errorf("document %s: %v", doc.ID, err)
+		}
+	}
+	return e.bulk(ctx, &body)
+}
+
+func (e *ElasticsearchIndex) Delete(ctx context.Context, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var body bytes.Buffer
+	for _, id := range ids {
+		if err := writeLine(&body, map[string]bulkAction{"delete": {Index: index, ID: id}}); err != nil {
+			return err
+		}
+	}
+	return e.bulk(ctx, &body)
+}
+
+// bulk sends the NDJSON body, the request succeeds even when some of its actions fail
+// so the result of every action is checked
+func (e *ElasticsearchIndex) bulk(ctx context.Context, body io.Reader) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.Config.URL+"/_bulk", body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if e.Config.APIKey != "" {
+		req.Header.Set("Authorization", "ApiKey "+e.Config.APIKey)
+	} else if e.Config.Username != "" {
+		req.SetBasicAuth(e.Config.Username, e.Config.Password)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("elasticsearch %s: %s", resp.Status, strings.TrimSpace(string(data[:min(len(data), 4096)])))
+	}
+
+	var result bulkResponse
+	if err := helper.JSONUnmarshal(data, &result); err != nil {
+		return err
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	failed := []string{}
+	for _, item := range result.Items {
+		for action, outcome := range item {
+			// deleting a missing document is not a failure
+			if outcome.Status < 300 || (action == "delete" && outcome.Status == http.StatusNotFound) {
+				continue
+			}
+			failed = append(failed, fmt.Sprintf("%s %s: %d %v", action, outcome.ID, outcome.Status, outcome.Error))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("elasticsearch bulk failed for %d documents: %s", len(failed), strings.Join(failed[:min(len(failed), 5)], "; "))
+}
+
+func writeLine(w *bytes.Buffer, v any) error {
+	data, err := helper.JSONMarshal(v)
+	if err != nil {
+		return err
+	}
+	w.Write(data)
+	w.WriteByte('\n')
+	return nil
+}
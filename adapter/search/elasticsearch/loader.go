@@ -0,0 +1,28 @@
+package elasticsearch
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// ElasticsearchLoader loads the Elasticsearch driver, register it as "search:elasticsearch"
+type ElasticsearchLoader struct {
+	name string
+}
+
+func (a *ElasticsearchLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *ElasticsearchLoader) Name() string {
+	return a.name
+}
+
+func (l *ElasticsearchLoader) Init(args ...any) (port.Library, error) {
+	index := &ElasticsearchIndex{}
+	err := index.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
@@ -0,0 +1,156 @@
+package meilisearch
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// PrimaryKey is the attribute holding the ID of the documents
+const PrimaryKey = "id"
+
+// MeilisearchIndex writes documents with the documents API of Meilisearch. Meilisearch
+// applies the changes asynchronously, the writes wait for their task to finish so that
+// failures are reported and retried.
+type MeilisearchIndex struct {
+	Config config.SearchConfig
+	client *http.Client
+}
+
+type enqueuedTask struct {
+	TaskUID int64 `json:"taskUid"`
+}
+
+type taskStatus struct {
+	Status string `json:"status"` // enqueued, processing, succeeded, failed or canceled
+	Error  *struct {
+		Message string `json:"message"`
+		Code    string `json:"code"`
+	} `json:"error"`
+}
+
+func (m *MeilisearchIndex) Install(args ...any) error {
+	m.Config = args[1].(config.SearchConfig)
+
+	if m.Config.URL == "" {
+		m.Config.URL = "http://127.0.0.1:7700"
+	}
+	m.Config.URL = strings.TrimSuffix(m.Config.URL, "/")
+	m.client = &http.Client{Timeout: 30 * time.Second}
+	return nil
+}
+
+func (m *MeilisearchIndex) Uninstall() error {
+	m.client.CloseIdleConnections()
+	return nil
+}
+
+func (m *MeilisearchIndex) Index(ctx context.Context, index string, docs []port.SearchDocument) error {
+	if len(docs) == 0 {
+		return nil
+	}
+
+	// the ID is stored in the document as its primary key
+	documents := make([]map[string]any, len(docs))
+	for i, doc := range docs {
+		data, err := helper.JSONMarshal(doc.Body)
+		if err != nil {
+			return fmt.Errorf("document %s: %v", doc.ID, err)
+		}
+		document := map[string]any{}
+		if err := helper.JSONUnmarshal(data, &document); err != nil {
+			return fmt.Errorf("document %s must be an object: %v", doc.ID, err)
+		}
+		document[PrimaryKey] = doc.ID
+		documents[i] = document
+	}
+
+	path := "/indexes/" + url.PathEscape(index) + "/documents?primaryKey=" + PrimaryKey
+	return m.write(ctx, path, documents)
+}
+
+func (m *MeilisearchIndex) Delete(ctx context.Context, index string, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return m.write(ctx, "/indexes/"+url.PathEscape(index)+"/documents/delete-batch", ids)
+}
+
+func (m *MeilisearchIndex) write(ctx context.Context, path string, payload any) error {
+	var task enqueuedTask
+	if err := m.do(ctx, http.MethodPost, path, payload, &task); err != nil {
+		return err
+	}
+
+	// poll the task until it is processed
+	delay := 50 * time.Millisecond
+	for {
+		var status taskStatus
+		if err := m.do(ctx, http.MethodGet, fmt.Sprintf("/tasks/%d", task.TaskUID), nil, &status); err != nil {
+			return err
+		}
+
+		switch status.Status {
+		case "succeeded":
+			return nil
+		case "failed", "canceled":
+			if status.Error != nil {
+				return fmt.Errorf("meilisearch task %d %s: %s (%s)", task.TaskUID, status.Status, status.Error.Message, status.Error.Code)
+			}
+			return fmt.Errorf("meilisearch task %d %s", task.TaskUID, status.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, time.Second)
+	}
+}
+
+func (m *MeilisearchIndex) do(ctx context.Context, method string, path string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := helper.JSONMarshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, m.Config.URL+path, body)
+	if err != nil {
+		return err
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if m.Config.APIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.Config.APIKey)
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("meilisearch %s: %s", resp.Status, strings.TrimSpace(string(data[:min(len(data), 4096)])))
+	}
+	return helper.JSONUnmarshal(data, result)
+}
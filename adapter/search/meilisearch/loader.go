@@ -0,0 +1,28 @@
+package meilisearch
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// MeilisearchLoader loads the Meilisearch driver, register it as "search:meilisearch"
+type MeilisearchLoader struct {
+	name string
+}
+
+func (a *MeilisearchLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *MeilisearchLoader) Name() string {
+	return a.name
+}
+
+func (l *MeilisearchLoader) Init(args ...any) (port.Library, error) {
+	index := &MeilisearchIndex{}
+	err := index.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return index, nil
+}
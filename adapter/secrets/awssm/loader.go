@@ -0,0 +1,27 @@
+package awssm
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type AWSSecretsLoader struct {
+	name string
+}
+
+func (a *AWSSecretsLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *AWSSecretsLoader) Name() string {
+	return a.name
+}
+
+func (l *AWSSecretsLoader) Init(args ...any) (port.Library, error) {
+	secrets := &AWSSecrets{}
+	err := secrets.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
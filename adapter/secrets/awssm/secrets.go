@@ -0,0 +1,133 @@
+package awssm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/awsv4"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// AWSSecrets reads the secrets of AWS Secrets Manager. A secret string holding a JSON object
+// (ex: the credentials of an RDS database) also has its fields in Data.
+type AWSSecrets struct {
+	Config   config.SecretsConfig
+	client   *http.Client
+	signer   awsv4.Signer
+	endpoint string
+}
+
+type getSecretValueResponse struct {
+	Name         string `json:"Name"`
+	SecretString string `json:"SecretString"`
+	SecretBinary []byte `json:"SecretBinary"` // base64 decoded by the JSON decoder
+	VersionId    string `json:"VersionId"`
+}
+
+func (s *AWSSecrets) Install(args ...any) error {
+	s.Config = args[1].(config.SecretsConfig)
+
+	if s.Config.AccessKey == "" || s.Config.SecretKey == "" {
+		return fmt.Errorf("aws secrets access_key and secret_key are required")
+	}
+	if s.Config.Timeout <= 0 {
+		s.Config.Timeout = 10 * time.Second
+	}
+
+	s.client = &http.Client{Timeout: s.Config.Timeout}
+	s.signer = awsv4.Signer{
+		AccessKey: s.Config.AccessKey,
+		SecretKey: s.Config.SecretKey,
+		Region:    s.Config.Region,
+		Service:   "secretsmanager",
+	}
+	s.endpoint = "https://secretsmanager." + s.Config.Region + ".amazonaws.com"
+	if s.Config.Endpoint != "" {
+		s.endpoint = strings.TrimRight(s.Config.Endpoint, "/")
+	}
+	return nil
+}
+
+func (s *AWSSecrets) Uninstall() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *AWSSecrets) GetSecret(ctx context.Context, name string) (*port.Secret, error) {
+	body, err := helper.JSONMarshal(map[string]string{"SecretId": name})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager.GetSecretValue")
+	s.signer.Sign(req, awsv4.PayloadHash(body), time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 300 {
+		if bytes.Contains(data, []byte("ResourceNotFoundException")) {
+			return nil, fmt.Errorf("aws secret '%s': %w", name, port.ErrSecretNotFound)
+		}
+		return nil, fmt.Errorf("aws secret '%s' failed: %s %s", name, resp.Status, data)
+	}
+
+	var result getSecretValueResponse
+	if err := helper.JSONUnmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	value := result.SecretString
+	if value == "" {
+		value = string(result.SecretBinary)
+	}
+	return &port.Secret{
+		Name:    name,
+		Value:   value,
+		Data:    secretData(value),
+		Version: result.VersionId,
+	}, nil
+}
+
+// RenewSecret reads the secret again, Secrets Manager has no leases
+func (s *AWSSecrets) RenewSecret(ctx context.Context, secret *port.Secret) (*port.Secret, error) {
+	return s.GetSecret(ctx, secret.Name)
+}
+
+// secretData returns the fields of a secret holding a JSON object, nil otherwise
+func secretData(value string) map[string]string {
+	var fields map[string]any
+	if json.Unmarshal([]byte(value), &fields) != nil {
+		return nil
+	}
+
+	data := make(map[string]string, len(fields))
+	for key, field := range fields {
+		if text, ok := field.(string); ok {
+			data[key] = text
+		} else {
+			data[key] = fmt.Sprint(field)
+		}
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+package gcpsm
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type GCPSecretsLoader struct {
+	name string
+}
+
+func (a *GCPSecretsLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *GCPSecretsLoader) Name() string {
+	return a.name
+}
+
+func (l *GCPSecretsLoader) Init(args ...any) (port.Library, error) {
+	secrets := &GCPSecrets{}
+	err := secrets.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
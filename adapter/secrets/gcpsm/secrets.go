@@ -0,0 +1,244 @@
+package gcpsm
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const (
+	platformScope   = "https://www.googleapis.com/auth/cloud-platform"
+	defaultToken    = "https://oauth2.googleapis.com/token"
+	defaultEndpoint = "https://secretmanager.googleapis.com"
+)
+
+// GCPSecrets reads the latest version of the secrets of Google Secret Manager, authenticated
+// with a service account. A secret holding a JSON object also has its fields in Data.
+type GCPSecrets struct {
+	Config     config.SecretsConfig
+	client     *http.Client
+	credential config.GoogleCredential
+	key        *rsa.PrivateKey
+	endpoint   string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+type accessResponse struct {
+	Name    string `json:"name"` // projects/<number>/secrets/<name>/versions/<version>
+	Payload struct {
+		Data []byte `json:"data"` // base64 decoded by the JSON decoder
+	} `json:"payload"`
+}
+
+func (s *GCPSecrets) Install(args ...any) error {
+	s.Config = args[1].(config.SecretsConfig)
+
+	data, err := os.ReadFile(s.Config.Credentials)
+	if err != nil {
+		return fmt.Errorf("gcp secrets credentials: %v", err)
+	}
+	if err := helper.JSONUnmarshal(data, &s.credential); err != nil {
+		return fmt.Errorf("gcp secrets credentials: %v", err)
+	}
+
+	block, _ := pem.Decode([]byte(s.credential.PrivateKey))
+	if block == nil {
+		return fmt.Errorf("gcp secrets credentials: invalid private key")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("gcp secrets credentials: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return fmt.Errorf("gcp secrets credentials: private key is not RSA")
+	}
+	s.key = key
+
+	if s.Config.ProjectID == "" {
+		s.Config.ProjectID = s.credential.ProjectID
+	}
+	if s.credential.TokenURI == "" {
+		s.credential.TokenURI = defaultToken
+	}
+	if s.Config.Timeout <= 0 {
+		s.Config.Timeout = 10 * time.Second
+	}
+	s.endpoint = defaultEndpoint
+	if s.Config.Endpoint != "" {
+		s.endpoint = strings.TrimRight(s.Config.Endpoint, "/")
+	}
+
+	s.client = &http.Client{Timeout: s.Config.Timeout}
+	return nil
+}
+
+func (s *GCPSecrets) Uninstall() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *GCPSecrets) GetSecret(ctx context.Context, name string) (*port.Secret, error) {
+	token, err := s.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	endpoint := s.endpoint + "/v1/projects/" + url.PathEscape(s.Config.ProjectID) + "/secrets/" + url.PathEscape(name) + "/versions/latest:access"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("gcp secret '%s': %w", name, port.ErrSecretNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("gcp secret '%s' failed: %s %s", name, resp.Status, data)
+	}
+
+	var result accessResponse
+	if err := helper.JSONUnmarshal(data, &result); err != nil {
+		return nil, err
+	}
+
+	value := string(result.Payload.Data)
+	return &port.Secret{
+		Name:    name,
+		Value:   value,
+		Data:    secretData(value),
+		Version: path.Base(result.Name),
+	}, nil
+}
+
+// RenewSecret reads the secret again, Secret Manager has no leases
+func (s *GCPSecrets) RenewSecret(ctx context.Context, secret *port.Secret) (*port.Secret, error) {
+	return s.GetSecret(ctx, secret.Name)
+}
+
+// token returns a cached OAuth2 access token, exchanging a signed JWT when it expires
+func (s *GCPSecrets) token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.accessToken != "" && time.Now().Before(s.expiresAt) {
+		return s.accessToken, nil
+	}
+
+	now := time.Now()
+	assertion, err := s.signJWT(map[string]any{
+		"iss":   s.credential.ClientEmail,
+		"scope": platformScope,
+		"aud":   s.credential.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "urn:ietf:params:oauth:grant-type:jwt-bearer")
+	form.Set("assertion", assertion)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.credential.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 300 {
+		return "", fmt.Errorf("gcp secrets token exchange failed: %s %s", resp.Status, data)
+	}
+
+	var result struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := helper.JSONUnmarshal(data, &result); err != nil {
+		return "", err
+	}
+
+	s.accessToken = result.AccessToken
+	// refresh a minute early to avoid using a token expiring in flight
+	s.expiresAt = now.Add(time.Duration(result.ExpiresIn)*time.Second - time.Minute)
+	return s.accessToken, nil
+}
+
+func (s *GCPSecrets) signJWT(claims map[string]any) (string, error) {
+	header, _ := helper.JSONMarshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	payload, err := helper.JSONMarshal(claims)
+	if err != nil {
+		return "", err
+	}
+
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	hash := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, hash[:])
+	if err != nil {
+		return "", err
+	}
+
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+// secretData returns the fields of a secret holding a JSON object, nil otherwise
+func secretData(value string) map[string]string {
+	var fields map[string]any
+	if json.Unmarshal([]byte(value), &fields) != nil {
+		return nil
+	}
+
+	data := make(map[string]string, len(fields))
+	for key, field := range fields {
+		if text, ok := field.(string); ok {
+			data[key] = text
+		} else {
+			data[key] = fmt.Sprint(field)
+		}
+	}
+	return data
+}
@@ -0,0 +1,27 @@
+package vault
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type VaultLoader struct {
+	name string
+}
+
+func (a *VaultLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *VaultLoader) Name() string {
+	return a.name
+}
+
+func (l *VaultLoader) Init(args ...any) (port.Library, error) {
+	secrets := &VaultSecrets{}
+	err := secrets.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
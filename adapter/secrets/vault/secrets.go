@@ -0,0 +1,154 @@
+package vault
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// VaultSecrets reads the secrets of HashiCorp Vault through its HTTP API: the names are paths of
+// the KV v2 engine of secrets.mount, names starting with "/" are read as is (ex: the dynamic
+// credentials of /database/creds/app, whose leases are renewed)
+type VaultSecrets struct {
+	Config config.SecretsConfig
+	client *http.Client
+}
+
+type vaultResponse struct {
+	LeaseID       string         `json:"lease_id"`
+	Renewable     bool           `json:"renewable"`
+	LeaseDuration int64          `json:"lease_duration"`
+	Data          map[string]any `json:"data"`
+}
+
+func (v *VaultSecrets) Install(args ...any) error {
+	v.Config = args[1].(config.SecretsConfig)
+
+	if v.Config.Address == "" || v.Config.Token == "" {
+		return fmt.Errorf("vault address and token are required")
+	}
+	if v.Config.Mount == "" {
+		v.Config.Mount = "secret"
+	}
+	if v.Config.Timeout <= 0 {
+		v.Config.Timeout = 10 * time.Second
+	}
+
+	v.client = &http.Client{Timeout: v.Config.Timeout}
+	return nil
+}
+
+func (v *VaultSecrets) Uninstall() error {
+	v.client.CloseIdleConnections()
+	return nil
+}
+
+func (v *VaultSecrets) GetSecret(ctx context.Context, name string) (*port.Secret, error) {
+	path, raw := strings.CutPrefix(name, "/")
+	if !raw {
+		path = strings.Trim(v.Config.Mount, "/") + "/data/" + name
+	}
+
+	var result vaultResponse
+	if err := v.call(ctx, http.MethodGet, path, nil, &result); err != nil {
+		return nil, err
+	}
+
+	secret := &port.Secret{
+		Name:      name,
+		LeaseID:   result.LeaseID,
+		Renewable: result.Renewable,
+	}
+	if result.LeaseDuration > 0 {
+		secret.ExpiresAt = time.Now().Add(time.Duration(result.LeaseDuration) * time.Second)
+	}
+
+	data := result.Data
+	if !raw {
+		// KV v2 nests the values and their version
+		data, _ = result.Data["data"].(map[string]any)
+		if metadata, ok := result.Data["metadata"].(map[string]any); ok && metadata["version"] != nil {
+			secret.Version = fmt.Sprint(metadata["version"])
+		}
+	}
+
+	secret.Data = make(map[string]string, len(data))
+	for key, value := range data {
+		secret.Data[key] = fmt.Sprint(value)
+	}
+	if value, ok := secret.Data["value"]; ok {
+		secret.Value = value
+	} else if len(secret.Data) == 1 {
+		for _, value := range secret.Data {
+			secret.Value = value
+		}
+	}
+	return secret, nil
+}
+
+func (v *VaultSecrets) RenewSecret(ctx context.Context, secret *port.Secret) (*port.Secret, error) {
+	if secret.LeaseID == "" || !secret.Renewable {
+		return nil, fmt.Errorf("vault secret '%s' has no renewable lease", secret.Name)
+	}
+
+	var result vaultResponse
+	body := map[string]any{"lease_id": secret.LeaseID}
+	if err := v.call(ctx, http.MethodPut, "sys/leases/renew", body, &result); err != nil {
+		return nil, err
+	}
+
+	renewed := *secret
+	renewed.Renewable = result.Renewable
+	renewed.ExpiresAt = time.Now().Add(time.Duration(result.LeaseDuration) * time.Second)
+	return &renewed, nil
+}
+
+func (v *VaultSecrets) call(ctx context.Context, method string, path string, payload any, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := helper.JSONMarshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	endpoint := strings.TrimRight(v.Config.Address, "/") + "/v1/" + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", v.Config.Token)
+	if v.Config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", v.Config.Namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1024*1024))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("vault %s: %w", path, port.ErrSecretNotFound)
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("vault %s failed: %s %s", path, resp.Status, data)
+	}
+	return helper.JSONUnmarshal(data, result)
+}
@@ -0,0 +1,27 @@
+package local
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+type LocalStorageLoader struct {
+	name string
+}
+
+func (a *LocalStorageLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *LocalStorageLoader) Name() string {
+	return a.name
+}
+
+func (l *LocalStorageLoader) Init(args ...any) (port.Library, error) {
+	storage := &LocalStorage{}
+	err := storage.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
@@ -0,0 +1,343 @@
+package local
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// metaDir holds the sidecar files with content type, etag and metadata of each object
+const metaDir = ".meta"
+
+// LocalStorage stores objects as files below Config.Root. Signed URLs are served
+// by a route mounted on Config.PublicPath.
+type LocalStorage struct {
+	Config     config.StorageConfig
+	signingKey []byte
+}
+
+type objectMeta struct {
+	ContentType  string            `json:"content_type,omitempty"`
+	CacheControl string            `json:"cache_control,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+func (s *LocalStorage) Install(args ...any) error {
+	context := args[0].(*core.AppContext)
+	s.Config = args[1].(config.StorageConfig)
+
+	if err := os.MkdirAll(s.Config.Root, 0o755); err != nil {
+		return err
+	}
+
+	if s.Config.SigningKey != "" {
+		s.signingKey = []byte(s.Config.SigningKey)
+	} else {
+		s.signingKey = make([]byte, 32)
+		if _, err := rand.Read(s.signingKey); err != nil {
+			return err
+		}
+		logger.Warn("Local storage has no signing key, signed URLs will not survive a restart")
+	}
+
+	if s.Config.PublicPath != "" && context.Web != nil {
+		context.Web.Get(s.Config.PublicPath+"/*", s.serve)
+		context.Web.Put(s.Config.PublicPath+"/*", s.serve)
+	}
+
+	return nil
+}
+
+func (s *LocalStorage) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *LocalStorage) Put(ctx context.Context, key string, content io.Reader, opts port.PutOptions) (*port.ObjectInfo, error) {
+	file, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+
+	// write to a temporary file first so readers never see a partial object
+	tmp, err := os.CreateTemp(filepath.Dir(file), ".upload-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+
+	hash := md5.New()
+	size, err := io.Copy(io.MultiWriter(tmp, hash), content)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.Rename(tmp.Name(), file); err != nil {
+		return nil, err
+	}
+
+	meta := objectMeta{
+		ContentType:  opts.ContentType,
+		CacheControl: opts.CacheControl,
+		ETag:         hex.EncodeToString(hash.Sum(nil)),
+		Metadata:     opts.Metadata,
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	if err := s.writeMeta(key, meta); err != nil {
+		return nil, err
+	}
+
+	return &port.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  meta.ContentType,
+		ETag:         meta.ETag,
+		LastModified: time.Now(),
+		Metadata:     meta.Metadata,
+	}, nil
+}
+
+func (s *LocalStorage) Get(ctx context.Context, key string) (io.ReadCloser, *port.ObjectInfo, error) {
+	info, err := s.Stat(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	file, _ := s.objectPath(key)
+	reader, err := os.Open(file)
+	if err != nil {
+		return nil, nil, mapNotFound(err)
+	}
+
+	return reader, info, nil
+}
+
+func (s *LocalStorage) Stat(ctx context.Context, key string) (*port.ObjectInfo, error) {
+	file, err := s.objectPath(key)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := os.Stat(file)
+	if err != nil {
+		return nil, mapNotFound(err)
+	}
+	if stat.IsDir() {
+		return nil, port.ErrObjectNotFound
+	}
+
+	meta := s.readMeta(key)
+	return &port.ObjectInfo{
+		Key:          key,
+		Size:         stat.Size(),
+		ContentType:  meta.ContentType,
+		ETag:         meta.ETag,
+		LastModified: stat.ModTime(),
+		Metadata:     meta.Metadata,
+	}, nil
+}
+
+func (s *LocalStorage) Delete(ctx context.Context, key string) error {
+	file, err := s.objectPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.Remove(file); err != nil {
+		return mapNotFound(err)
+	}
+
+	os.Remove(s.metaPath(key))
+	return nil
+}
+
+func (s *LocalStorage) List(ctx context.Context, prefix string, limit int) ([]port.ObjectInfo, error) {
+	result := []port.ObjectInfo{}
+
+	err := filepath.WalkDir(s.Config.Root, func(file string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(s.Config.Root, file)
+		key := filepath.ToSlash(rel)
+		if entry.IsDir() {
+			if key == metaDir {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(entry.Name(), ".upload-") || !strings.HasPrefix(key, prefix) {
+			return nil
+		}
+
+		info, err := s.Stat(ctx, key)
+		if err != nil {
+			return err
+		}
+		result = append(result, *info)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
+
+func (s *LocalStorage) SignedURL(ctx context.Context, key string, method string, expires time.Duration) (string, error) {
+	if s.Config.PublicPath == "" {
+		return "", fmt.Errorf("local storage has no public_path to serve signed URLs")
+	}
+	if _, err := s.objectPath(key); err != nil {
+		return "", err
+	}
+
+	method = strings.ToUpper(method)
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("method", method)
+	query.Set("expires", expiresAt)
+	query.Set("signature", s.sign(method, key, expiresAt))
+
+	return strings.TrimSuffix(s.Config.BaseURL, "/") + s.Config.PublicPath + "/" + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// serve answers signed URL requests
+func (s *LocalStorage) serve(c *fiber.Ctx) error {
+	key, err := url.PathUnescape(c.Params("*"))
+	if err != nil {
+		return fiber.ErrBadRequest
+	}
+
+	method := c.Query("method")
+	expiresAt := c.Query("expires")
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || method != c.Method() || time.Now().Unix() > expires ||
+		!hmac.Equal([]byte(c.Query("signature")), []byte(s.sign(method, key, expiresAt))) {
+		return out.Error(fiber.StatusForbidden, 2, "FORBIDDEN", "Invalid or expired signature")
+	}
+
+	if c.Method() == fiber.MethodPut {
+		info, err := s.Put(c.Context(), key, bytes.NewReader(c.Body()), port.PutOptions{
+			ContentType: string(c.Request().Header.ContentType()),
+		})
+		if err != nil {
+			return err
+		}
+		return out.Send(c, out.SuccessData(info))
+	}
+
+	reader, info, err := s.Get(c.Context(), key)
+	if err != nil {
+		if err == port.ErrObjectNotFound {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+
+	return out.Stream(c, reader, info.ContentType, path.Base(key), out.StreamOptions{
+		Size:    info.Size,
+		ModTime: info.LastModified,
+		Inline:  true,
+	})
+}
+
+func (s *LocalStorage) sign(method string, key string, expires string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// objectPath maps key to a file below the root, refusing keys escaping it
+func (s *LocalStorage) objectPath(key string) (string, error) {
+	clean := path.Clean("/" + key)
+	if key == "" || clean != "/"+key || strings.HasPrefix(clean, "/"+metaDir+"/") {
+		return "", fmt.Errorf("invalid object key '%s'", key)
+	}
+	return filepath.Join(s.Config.Root, filepath.FromSlash(clean)), nil
+}
+
+func (s *LocalStorage) metaPath(key string) string {
+	clean := path.Clean("/" + key)
+	return filepath.Join(s.Config.Root, metaDir, filepath.FromSlash(clean)+".json")
+}
+
+func (s *LocalStorage) writeMeta(key string, meta objectMeta) error {
+	file := s.metaPath(key)
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return err
+	}
+
+	data, err := helper.JSONMarshal(meta)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(file, data, 0o644)
+}
+
+func (s *LocalStorage) readMeta(key string) objectMeta {
+	meta := objectMeta{}
+	if data, err := os.ReadFile(s.metaPath(key)); err == nil {
+		helper.JSONUnmarshal(data, &meta)
+	}
+	if meta.ContentType == "" {
+		meta.ContentType = mime.TypeByExtension(path.Ext(key))
+	}
+	return meta
+}
+
+func mapNotFound(err error) error {
+	if os.IsNotExist(err) {
+		return port.ErrObjectNotFound
+	}
+	return err
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
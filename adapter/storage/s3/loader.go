@@ -0,0 +1,29 @@
+package s3
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// S3StorageLoader loads the S3 compatible driver. Register it as "storage:s3" and,
+// for Google Cloud Storage through its interoperability API with HMAC keys, as "storage:gcs".
+type S3StorageLoader struct {
+	name string
+}
+
+func (a *S3StorageLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *S3StorageLoader) Name() string {
+	return a.name
+}
+
+func (l *S3StorageLoader) Init(args ...any) (port.Library, error) {
+	storage := &S3Storage{}
+	err := storage.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return storage, nil
+}
@@ -0,0 +1,311 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/awsv4"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+const gcsEndpoint = "https://storage.googleapis.com"
+
+// S3Storage talks to S3 compatible object stores (AWS S3, MinIO, GCS interoperability API)
+// over their REST API, signing requests with AWS Signature Version 4
+type S3Storage struct {
+	Config   config.StorageConfig
+	client   *http.Client
+	endpoint *url.URL
+	signer   awsv4.Signer
+}
+
+func (s *S3Storage) Install(args ...any) error {
+	s.Config = args[1].(config.StorageConfig)
+
+	if s.Config.Bucket == "" {
+		return fmt.Errorf("storage bucket is required")
+	}
+
+	if s.Config.Region == "" {
+		s.Config.Region = "us-east-1"
+	}
+
+	endpoint := s.Config.Endpoint
+	if endpoint == "" {
+		if s.Config.Driver == "gcs" {
+			endpoint = gcsEndpoint
+		} else {
+			endpoint = "https://s3." + s.Config.Region + ".amazonaws.com"
+		}
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("invalid storage endpoint: %v", err)
+	}
+	s.endpoint = u
+	s.client = &http.Client{}
+	s.signer = awsv4.Signer{
+		AccessKey: s.Config.AccessKey,
+		SecretKey: s.Config.SecretKey,
+		Region:    s.Config.Region,
+		Service:   "s3",
+	}
+
+	return nil
+}
+
+func (s *S3Storage) Uninstall() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, content io.Reader, opts port.PutOptions) (*port.ObjectInfo, error) {
+	size := opts.Size
+	if size <= 0 {
+		// the REST API needs the length up front, spool unknown sized content to disk
+		tmp, err := os.CreateTemp("", "s3-upload-*")
+		if err != nil {
+			return nil, err
+		}
+		defer os.Remove(tmp.Name())
+		defer tmp.Close()
+
+		if size, err = io.Copy(tmp, content); err != nil {
+			return nil, err
+		}
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		content = tmp
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(key).String(), io.NopCloser(content))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = size
+	if opts.ContentType != "" {
+		req.Header.Set("Content-Type", opts.ContentType)
+	}
+	if opts.CacheControl != "" {
+		req.Header.Set("Cache-Control", opts.CacheControl)
+	}
+	for name, value := range opts.Metadata {
+		req.Header.Set("X-Amz-Meta-"+name, value)
+	}
+
+	resp, err := s.do(req, awsv4.UnsignedPayload)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return &port.ObjectInfo{
+		Key:          key,
+		Size:         size,
+		ContentType:  opts.ContentType,
+		ETag:         strings.Trim(resp.Header.Get("ETag"), `"`),
+		LastModified: time.Now(),
+		Metadata:     opts.Metadata,
+	}, nil
+}
+
+func (s *S3Storage) Get(ctx context.Context, key string) (io.ReadCloser, *port.ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	resp, err := s.do(req, awsv4.EmptyPayload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return resp.Body, objectInfo(key, resp.Header), nil
+}
+
+func (s *S3Storage) Stat(ctx context.Context, key string) (*port.ObjectInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(key).String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.do(req, awsv4.EmptyPayload)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+
+	return objectInfo(key, resp.Header), nil
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(key).String(), nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.do(req, awsv4.EmptyPayload)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+type listBucketResult struct {
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+	Contents              []struct {
+		Key          string    `xml:"Key"`
+		Size         int64     `xml:"Size"`
+		ETag         string    `xml:"ETag"`
+		LastModified time.Time `xml:"LastModified"`
+	} `xml:"Contents"`
+}
+
+func (s *S3Storage) List(ctx context.Context, prefix string, limit int) ([]port.ObjectInfo, error) {
+	result := []port.ObjectInfo{}
+	token := ""
+
+	for {
+		target := s.bucketURL()
+		query := url.Values{}
+		query.Set("list-type", "2")
+		query.Set("prefix", prefix)
+		if limit > 0 {
+			query.Set("max-keys", strconv.Itoa(min(limit-len(result), 1000)))
+		}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		target.RawQuery = awsv4.CanonicalQuery(query)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := s.do(req, awsv4.EmptyPayload)
+		if err != nil {
+			return nil, err
+		}
+
+		var page listBucketResult
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range page.Contents {
+			result = append(result, port.ObjectInfo{
+				Key:          item.Key,
+				Size:         item.Size,
+				ETag:         strings.Trim(item.ETag, `"`),
+				LastModified: item.LastModified,
+			})
+		}
+
+		if !page.IsTruncated || page.NextContinuationToken == "" || (limit > 0 && len(result) >= limit) {
+			break
+		}
+		token = page.NextContinuationToken
+	}
+
+	return result, nil
+}
+
+func (s *S3Storage) SignedURL(ctx context.Context, key string, method string, expires time.Duration) (string, error) {
+	method = strings.ToUpper(method)
+	if method != http.MethodGet && method != http.MethodPut {
+		return "", fmt.Errorf("signed URL method must be GET or PUT, got %s", method)
+	}
+	if expires <= 0 || expires > 7*24*time.Hour {
+		return "", fmt.Errorf("signed URL expiration must be between 1s and 7 days")
+	}
+
+	return s.signer.Presign(method, s.objectURL(key), expires, time.Now()), nil
+}
+
+// objectURL builds the URL of key, with the path already escaped as SigV4 expects
+func (s *S3Storage) objectURL(key string) *url.URL {
+	u := s.bucketURL()
+	u.Path += strings.TrimPrefix(key, "/")
+	u.RawPath += awsv4.URIEncode(strings.TrimPrefix(key, "/"), false)
+	return u
+}
+
+func (s *S3Storage) bucketURL() *url.URL {
+	u := *s.endpoint
+	if s.Config.PathStyle {
+		u.Path = "/" + s.Config.Bucket + "/"
+	} else {
+		u.Host = s.Config.Bucket + "." + u.Host
+		u.Path = "/"
+	}
+	u.RawPath = awsv4.URIEncode(u.Path, false)
+	return &u
+}
+
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+func (s *S3Storage) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	s.signer.Sign(req, payloadHash, time.Now())
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, port.ErrObjectNotFound
+	}
+
+	var e s3Error
+	xml.NewDecoder(io.LimitReader(resp.Body, 64*1024)).Decode(&e)
+	if e.Code == "" {
+		e.Code = resp.Status
+	}
+	return nil, fmt.Errorf("storage %s %s: %s %s", req.Method, req.URL.Path, e.Code, e.Message)
+}
+
+func objectInfo(key string, header http.Header) *port.ObjectInfo {
+	info := &port.ObjectInfo{
+		Key:         key,
+		ContentType: header.Get("Content-Type"),
+		ETag:        strings.Trim(header.Get("ETag"), `"`),
+		Metadata:    map[string]string{},
+	}
+	info.Size, _ = strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	info.LastModified, _ = http.ParseTime(header.Get("Last-Modified"))
+
+	for name := range header {
+		lower := strings.ToLower(name)
+		for _, prefix := range []string{"x-amz-meta-", "x-goog-meta-"} {
+			if strings.HasPrefix(lower, prefix) {
+				info.Metadata[strings.TrimPrefix(lower, prefix)] = header.Get(name)
+			}
+		}
+	}
+
+	return info
+}
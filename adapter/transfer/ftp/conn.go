@@ -0,0 +1,246 @@
+package ftp
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// conn is a logged in FTP control connection in binary mode (RFC 959, RFC 4217 for TLS)
+type conn struct {
+	raw     net.Conn
+	text    *textproto.Conn
+	host    string
+	tls     *tls.Config // nil for plain FTP
+	timeout time.Duration
+	detach  func() bool // stops closing the connection when the context is done
+}
+
+func dial(ctx context.Context, cfg config.TransferConfig, tlsConfig *tls.Config) (*conn, error) {
+	dialer := net.Dialer{Timeout: cfg.Timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{
+		raw:     raw,
+		host:    cfg.Host,
+		tls:     tlsConfig,
+		timeout: cfg.Timeout,
+		detach:  context.AfterFunc(ctx, func() { raw.Close() }),
+	}
+	control := net.Conn(&deadlineConn{Conn: raw, timeout: cfg.Timeout})
+
+	if tlsConfig != nil && cfg.ImplicitTLS {
+		control = tls.Client(control, tlsConfig)
+	}
+	c.text = textproto.NewConn(control)
+
+	if err := c.login(ctx, cfg, control); err != nil {
+		c.close()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *conn) login(ctx context.Context, cfg config.TransferConfig, control net.Conn) error {
+	if _, err := c.read(2); err != nil {
+		return err
+	}
+
+	if c.tls != nil && !cfg.ImplicitTLS {
+		if _, err := c.cmd(234, "AUTH TLS"); err != nil {
+			return err
+		}
+		secure := tls.Client(control, c.tls)
+		if err := secure.HandshakeContext(ctx); err != nil {
+			return err
+		}
+		c.text = textproto.NewConn(secure)
+	}
+
+	username := cfg.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	code, err := c.cmd(0, "USER %s", username)
+	if err != nil {
+		return err
+	}
+	if code == 331 {
+		if code, err = c.cmd(2, "PASS %s", cfg.Password); err != nil {
+			return err
+		}
+	}
+	if code/100 != 2 {
+		return fmt.Errorf("ftp login refused with %d", code)
+	}
+
+	if c.tls != nil {
+		// protect the data connections too
+		if _, err := c.cmd(2, "PBSZ 0"); err != nil {
+			return err
+		}
+		if _, err := c.cmd(2, "PROT P"); err != nil {
+			return err
+		}
+	}
+
+	_, err = c.cmd(2, "TYPE I")
+	return err
+}
+
+func (c *conn) close() {
+	c.detach()
+	if c.text != nil {
+		// best effort, the server closes the session anyway
+		c.text.PrintfLine("QUIT")
+	}
+	c.raw.Close()
+}
+
+// cmd sends a command and reads its reply, expect is a code or its first digit, 0 accepts any
+func (c *conn) cmd(expect int, format string, args ...any) (int, error) {
+	if err := c.text.PrintfLine(format, args...); err != nil {
+		return 0, err
+	}
+	return c.read(expect)
+}
+
+func (c *conn) read(expect int) (int, error) {
+	code, _, err := c.text.ReadResponse(expect)
+	return code, err
+}
+
+// transfer runs a command using a passive data connection, fn reads or writes the data
+func (c *conn) transfer(ctx context.Context, command string, fn func(data io.ReadWriter) error) error {
+	data, err := c.openData(ctx)
+	if err != nil {
+		return err
+	}
+	defer data.Close()
+
+	if _, err := c.cmd(1, "%s", command); err != nil {
+		return err
+	}
+	if secure, ok := data.(*tls.Conn); ok {
+		// even an empty upload must complete the handshake
+		if err := secure.HandshakeContext(ctx); err != nil {
+			return err
+		}
+	}
+
+	err = fn(data)
+	if closeErr := data.Close(); err == nil {
+		err = closeErr
+	}
+	if _, readErr := c.read(2); err == nil {
+		err = readErr
+	}
+	return err
+}
+
+// openData connects to the port the server listens to in passive mode, on the control host
+// since the address advertised by PASV is often wrong behind NAT
+func (c *conn) openData(ctx context.Context) (net.Conn, error) {
+	port, err := c.passivePort()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := net.Dialer{Timeout: c.timeout}
+	raw, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(c.host, strconv.Itoa(port)))
+	if err != nil {
+		return nil, err
+	}
+
+	data := net.Conn(&deadlineConn{Conn: raw, timeout: c.timeout})
+	if c.tls != nil {
+		data = tls.Client(data, c.tls)
+	}
+	return data, nil
+}
+
+func (c *conn) passivePort() (int, error) {
+	if err := c.text.PrintfLine("EPSV"); err != nil {
+		return 0, err
+	}
+	_, msg, err := c.text.ReadResponse(229)
+	if err == nil {
+		// "Entering Extended Passive Mode (|||6446|)"
+		if start := strings.Index(msg, "(|||"); start >= 0 {
+			if end := strings.Index(msg[start+4:], "|"); end >= 0 {
+				return strconv.Atoi(msg[start+4 : start+4+end])
+			}
+		}
+		return 0, fmt.Errorf("malformed EPSV reply %q", msg)
+	}
+
+	if err := c.text.PrintfLine("PASV"); err != nil {
+		return 0, err
+	}
+	if _, msg, err = c.text.ReadResponse(227); err != nil {
+		return 0, err
+	}
+	// "Entering Passive Mode (h1,h2,h3,h4,p1,p2)"
+	start := strings.Index(msg, "(")
+	end := strings.LastIndex(msg, ")")
+	if start < 0 || end < start {
+		return 0, fmt.Errorf("malformed PASV reply %q", msg)
+	}
+	numbers := strings.Split(msg[start+1:end], ",")
+	if len(numbers) != 6 {
+		return 0, fmt.Errorf("malformed PASV reply %q", msg)
+	}
+	high, err1 := strconv.Atoi(strings.TrimSpace(numbers[4]))
+	low, err2 := strconv.Atoi(strings.TrimSpace(numbers[5]))
+	if err1 != nil || err2 != nil {
+		return 0, fmt.Errorf("malformed PASV reply %q", msg)
+	}
+	return high<<8 | low, nil
+}
+
+// notFound turns "550 No such file" replies into port.ErrRemoteFileNotFound
+func notFound(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code == 550 {
+		return fmt.Errorf("%w: %s", port.ErrRemoteFileNotFound, reply.Msg)
+	}
+	return err
+}
+
+// unsupported reports replies of servers not implementing a command
+func unsupported(err error) bool {
+	var reply *textproto.Error
+	return errors.As(err, &reply) && (reply.Code == 500 || reply.Code == 502 || reply.Code == 504)
+}
+
+// deadlineConn fails reads and writes idle for longer than timeout
+type deadlineConn struct {
+	net.Conn
+	timeout time.Duration
+}
+
+func (c *deadlineConn) Read(p []byte) (int, error) {
+	c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Read(p)
+}
+
+func (c *deadlineConn) Write(p []byte) (int, error) {
+	c.Conn.SetWriteDeadline(time.Now().Add(c.timeout))
+	return c.Conn.Write(p)
+}
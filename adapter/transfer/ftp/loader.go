@@ -0,0 +1,28 @@
+package ftp
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// FTPTransferLoader loads the FTP driver, register it as "transfer:ftp" and "transfer:ftps"
+type FTPTransferLoader struct {
+	name string
+}
+
+func (a *FTPTransferLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *FTPTransferLoader) Name() string {
+	return a.name
+}
+
+func (l *FTPTransferLoader) Init(args ...any) (port.Library, error) {
+	transfer := &FTPTransfer{}
+	err := transfer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
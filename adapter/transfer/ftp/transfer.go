@@ -0,0 +1,185 @@
+package ftp
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// FTPTransfer exchanges files over FTP, or FTPS when the driver is "ftps" (explicit AUTH TLS,
+// or implicit TLS with transfer.implicit_tls). Every operation opens its own session in
+// passive mode, partners rarely keep idle control connections open for long.
+type FTPTransfer struct {
+	Config config.TransferConfig
+	tls    *tls.Config
+}
+
+func (t *FTPTransfer) Install(args ...any) error {
+	t.Config = args[1].(config.TransferConfig)
+
+	if t.Config.Host == "" {
+		return fmt.Errorf("ftp host is required")
+	}
+	if t.Config.Timeout <= 0 {
+		t.Config.Timeout = 30 * time.Second
+	}
+
+	if t.Config.Driver == "ftps" || t.Config.ImplicitTLS {
+		t.tls = &tls.Config{
+			ServerName:         t.Config.Host,
+			InsecureSkipVerify: t.Config.Insecure,
+			MinVersion:         tls.VersionTLS12,
+			// servers often require the data connections to resume the session of the control connection
+			ClientSessionCache: tls.NewLRUClientSessionCache(16),
+		}
+	}
+
+	if t.Config.Port == 0 {
+		t.Config.Port = 21
+		if t.Config.ImplicitTLS {
+			t.Config.Port = 990
+		}
+	}
+	return nil
+}
+
+func (t *FTPTransfer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (t *FTPTransfer) List(ctx context.Context, dir string) ([]port.RemoteFile, error) {
+	var files []port.RemoteFile
+	err := t.session(ctx, func(c *conn) error {
+		var err error
+		files, err = t.list(ctx, c, dir, "MLSD", parseMLSD)
+		if unsupported(err) {
+			now := time.Now()
+			files, err = t.list(ctx, c, dir, "LIST", func(line string) (port.RemoteFile, bool) {
+				return helper.ParseListLine(line, now)
+			})
+		}
+		return notFound(err)
+	})
+	return files, err
+}
+
+func (t *FTPTransfer) list(ctx context.Context, c *conn, dir string, command string, parse func(line string) (port.RemoteFile, bool)) ([]port.RemoteFile, error) {
+	if remote := t.remote(dir); remote != "" {
+		command += " " + remote
+	}
+
+	files := []port.RemoteFile{}
+	err := c.transfer(ctx, command, func(data io.ReadWriter) error {
+		scanner := bufio.NewScanner(data)
+		for scanner.Scan() {
+			file, ok := parse(strings.TrimRight(scanner.Text(), "\r"))
+			if !ok || file.Name == "." || file.Name == ".." {
+				continue
+			}
+			file.Path = path.Join(dir, file.Name)
+			files = append(files, file)
+		}
+		return scanner.Err()
+	})
+	return files, err
+}
+
+func (t *FTPTransfer) Get(ctx context.Context, path string, w io.Writer) error {
+	return t.session(ctx, func(c *conn) error {
+		return notFound(c.transfer(ctx, "RETR "+t.remote(path), func(data io.ReadWriter) error {
+			_, err := io.Copy(w, data)
+			return err
+		}))
+	})
+}
+
+func (t *FTPTransfer) Put(ctx context.Context, path string, content io.Reader) error {
+	return t.session(ctx, func(c *conn) error {
+		return c.transfer(ctx, "STOR "+t.remote(path), func(data io.ReadWriter) error {
+			_, err := io.Copy(data, content)
+			return err
+		})
+	})
+}
+
+func (t *FTPTransfer) Move(ctx context.Context, from string, to string) error {
+	return t.session(ctx, func(c *conn) error {
+		if _, err := c.cmd(350, "RNFR %s", t.remote(from)); err != nil {
+			return notFound(err)
+		}
+		_, err := c.cmd(2, "RNTO %s", t.remote(to))
+		return err
+	})
+}
+
+func (t *FTPTransfer) Delete(ctx context.Context, path string) error {
+	return t.session(ctx, func(c *conn) error {
+		_, err := c.cmd(2, "DELE %s", t.remote(path))
+		return notFound(err)
+	})
+}
+
+func (t *FTPTransfer) session(ctx context.Context, fn func(c *conn) error) error {
+	c, err := dial(ctx, t.Config, t.tls)
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if err := fn(c); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	return nil
+}
+
+// remote resolves a path against transfer.root
+func (t *FTPTransfer) remote(p string) string {
+	if t.Config.Root == "" {
+		return p
+	}
+	return path.Join(t.Config.Root, p)
+}
+
+// parseMLSD reads a machine listing line (RFC 3659): "type=file;size=42;modify=20240102150405; name"
+func parseMLSD(line string) (port.RemoteFile, bool) {
+	var file port.RemoteFile
+
+	facts, name, ok := strings.Cut(line, " ")
+	if !ok || name == "" {
+		return file, false
+	}
+	file.Name = name
+
+	for _, fact := range strings.Split(facts, ";") {
+		key, value, _ := strings.Cut(fact, "=")
+		switch strings.ToLower(key) {
+		case "type":
+			switch strings.ToLower(value) {
+			case "cdir", "pdir":
+				return file, false
+			case "dir":
+				file.IsDir = true
+			}
+		case "size":
+			file.Size, _ = strconv.ParseInt(value, 10, 64)
+		case "modify":
+			value, _, _ = strings.Cut(value, ".")
+			file.ModTime, _ = time.Parse("20060102150405", value)
+		}
+	}
+	return file, true
+}
@@ -0,0 +1,32 @@
+package sftp
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// SFTPTransferLoader loads the SFTP driver, register it as "transfer:sftp". The driver runs
+// the OpenSSH sftp client, which must be installed in the image (ex: the openssh-client
+// package, or transfer.binary); the installation fails without it and the doctor reports it.
+// It authenticates with a key only, transfer.private_key or the ssh agent: a transfer with a
+// password is refused, use the ftps driver for the partners accepting passwords only.
+type SFTPTransferLoader struct {
+	name string
+}
+
+func (a *SFTPTransferLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *SFTPTransferLoader) Name() string {
+	return a.name
+}
+
+func (l *SFTPTransferLoader) Init(args ...any) (port.Library, error) {
+	transfer := &SFTPTransfer{}
+	err := transfer.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return transfer, nil
+}
@@ -0,0 +1,220 @@
+package sftp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// SFTPTransfer exchanges files over SFTP with the OpenSSH sftp executable in batch mode,
+// files go through temporary files. It authenticates with transfer.private_key (or the
+// ssh agent) and only trusts host keys already listed in the known hosts file.
+type SFTPTransfer struct {
+	Config config.TransferConfig
+	binary string
+}
+
+func (t *SFTPTransfer) Install(args ...any) error {
+	t.Config = args[1].(config.TransferConfig)
+
+	if t.Config.Host == "" {
+		return fmt.Errorf("sftp host is required")
+	}
+	if t.Config.Password != "" {
+		return fmt.Errorf("sftp password authentication is not supported, use private_key or the ssh agent")
+	}
+	if t.Config.Port == 0 {
+		t.Config.Port = 22
+	}
+	if t.Config.Timeout <= 0 {
+		t.Config.Timeout = 30 * time.Second
+	}
+
+	binary := t.Config.Binary
+	if binary == "" {
+		binary = "sftp"
+	}
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return fmt.Errorf("sftp executable not found, install the OpenSSH client or set transfer.binary: %v", err)
+	}
+	t.binary = path
+
+	return nil
+}
+
+func (t *SFTPTransfer) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (t *SFTPTransfer) List(ctx context.Context, dir string) ([]port.RemoteFile, error) {
+	remote := t.remote(dir)
+	if remote == "" {
+		remote = "."
+	}
+
+	// -n formats the listing in the client, the long names of the servers differ
+	output, err := t.run(ctx, "ls -lan "+quote(remote))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	files := []port.RemoteFile{}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		file, ok := helper.ParseListLine(scanner.Text(), now)
+		if !ok || file.Name == "." || file.Name == ".." {
+			continue
+		}
+		file.Path = path.Join(dir, file.Name)
+		files = append(files, file)
+	}
+	return files, scanner.Err()
+}
+
+func (t *SFTPTransfer) Get(ctx context.Context, path string, w io.Writer) error {
+	local, err := os.CreateTemp("", "sftp-get-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(local.Name())
+	defer local.Close()
+
+	if _, err := t.run(ctx, "get "+quote(t.remote(path))+" "+quote(local.Name())); err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, local)
+	return err
+}
+
+func (t *SFTPTransfer) Put(ctx context.Context, path string, content io.Reader) error {
+	local, err := os.CreateTemp("", "sftp-put-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(local.Name())
+
+	_, err = io.Copy(local, content)
+	if closeErr := local.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = t.run(ctx, "put "+quote(local.Name())+" "+quote(t.remote(path)))
+	return err
+}
+
+func (t *SFTPTransfer) Move(ctx context.Context, from string, to string) error {
+	_, err := t.run(ctx, "rename "+quote(t.remote(from))+" "+quote(t.remote(to)))
+	return err
+}
+
+func (t *SFTPTransfer) Delete(ctx context.Context, path string) error {
+	_, err := t.run(ctx, "rm "+quote(t.remote(path)))
+	return err
+}
+
+// run executes batch commands in a new session and returns what they printed
+func (t *SFTPTransfer) run(ctx context.Context, commands ...string) ([]byte, error) {
+	for _, command := range commands {
+		// a line break would smuggle another command into the batch
+		if strings.ContainsAny(command, "\r\n") {
+			return nil, fmt.Errorf("sftp: line break in path")
+		}
+	}
+
+	seconds := strconv.Itoa(int(max(t.Config.Timeout/time.Second, 1)))
+	args := []string{
+		"-b", "-",
+		"-P", strconv.Itoa(t.Config.Port),
+		"-o", "BatchMode=yes",
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "ConnectTimeout=" + seconds,
+		"-o", "ServerAliveInterval=" + seconds,
+		"-o", "ServerAliveCountMax=2",
+	}
+	if t.Config.PrivateKey != "" {
+		args = append(args, "-i", t.Config.PrivateKey, "-o", "IdentitiesOnly=yes")
+	}
+	if t.Config.KnownHosts != "" {
+		args = append(args, "-o", "UserKnownHostsFile="+t.Config.KnownHosts)
+	}
+
+	host := t.Config.Host
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	if t.Config.Username != "" {
+		host = t.Config.Username + "@" + host
+	}
+	args = append(args, host)
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, t.binary, args...)
+	cmd.Stdin = strings.NewReader(strings.Join(commands, "\n") + "\n")
+	// the dates of the listing in English whatever the locale of the image
+	cmd.Env = append(os.Environ(), "LC_ALL=C")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		message := strings.TrimSpace(stderr.String())
+		if strings.Contains(message, "not found") || strings.Contains(message, "No such file") {
+			return nil, fmt.Errorf("%w: %s", port.ErrRemoteFileNotFound, message)
+		}
+		return nil, fmt.Errorf("sftp: %v: %s", err, message)
+	}
+
+	// batch mode echoes the commands
+	var output bytes.Buffer
+	scanner := bufio.NewScanner(&stdout)
+	for scanner.Scan() {
+		if line := scanner.Text(); !strings.HasPrefix(line, "sftp> ") {
+			output.WriteString(line)
+			output.WriteByte('\n')
+		}
+	}
+	return output.Bytes(), scanner.Err()
+}
+
+// remote resolves a path against transfer.root
+func (t *SFTPTransfer) remote(p string) string {
+	if t.Config.Root == "" {
+		return p
+	}
+	return path.Join(t.Config.Root, p)
+}
+
+// quote protects blanks, quotes and glob characters of a path in a batch command
+func quote(p string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range p {
+		if strings.ContainsRune(`"\*?[`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	b.WriteByte('"')
+	return b.String()
+}
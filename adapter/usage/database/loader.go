@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DatabaseUsageLoader provides a port.IUsageStore persisted with the default database library
+type DatabaseUsageLoader struct {
+	name string
+}
+
+func (a *DatabaseUsageLoader) SetName(name string) {
+	a.name = name
+}
+
+func (a *DatabaseUsageLoader) Name() string {
+	return a.name
+}
+
+// DependsOn loads the library after the default database
+func (a *DatabaseUsageLoader) DependsOn() []string {
+	return []string{"database"}
+}
+
+func (l *DatabaseUsageLoader) Init(args ...any) (port.Library, error) {
+	context := args[0].(*core.AppContext)
+
+	libDb, ok := context.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Usage store cannot be loaded, database %s not found", context.Config.Database.Driver)
+	}
+
+	store := &DatabaseUsageStore{
+		Connection: libDb.(port.IDatabase),
+		Table:      DefaultTable,
+	}
+	err := store.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return store, nil
+}
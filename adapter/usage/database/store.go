@@ -0,0 +1,108 @@
+package database
+
+import (
+	"context"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// DefaultTable is the table (or collection) holding the usage
+const DefaultTable = "usage"
+
+// DatabaseUsageStore stores a row per period, principal, API key, route and instance, the rows
+// of the instances are added together when queried
+type DatabaseUsageStore struct {
+	Connection port.IDatabase
+	Table      string
+}
+
+func (s *DatabaseUsageStore) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseUsageStore) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (s *DatabaseUsageStore) Save(ctx context.Context, records []port.UsageRecord) error {
+	for _, record := range records {
+		updated, err := s.Connection.UpdateOne(ctx, s.Table, []port.DbExpression{
+			{Expr: "period", Args: []any{record.Window}},
+			{Expr: "period_start", Args: []any{record.Start}},
+			{Expr: "principal", Args: []any{record.Principal}},
+			{Expr: "api_key", Args: []any{record.APIKey}},
+			{Expr: "route", Args: []any{record.Route}},
+			{Expr: "instance", Args: []any{record.Instance}},
+		}, port.DbMap{
+			"requests":  record.Requests,
+			"errors":    record.Errors,
+			"bytes_in":  record.BytesIn,
+			"bytes_out": record.BytesOut,
+		})
+		if err != nil {
+			return err
+		}
+		if updated > 0 {
+			continue
+		}
+
+		data, err := helper.MarshalDbMap(record)
+		if err != nil {
+			return err
+		}
+		if _, err := s.Connection.InsertOne(ctx, s.Table, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *DatabaseUsageStore) Query(ctx context.Context, query port.UsageQuery) ([]port.UsageRecord, error) {
+	filter := []port.DbExpression{}
+	if query.Window != "" {
+		filter = append(filter, port.DbExpression{Expr: "period", Args: []any{query.Window}})
+	}
+	if !query.From.IsZero() {
+		filter = append(filter, port.DbExpression{Expr: "period_start", Op: ">=", Args: []any{query.From}})
+	}
+	if !query.To.IsZero() {
+		filter = append(filter, port.DbExpression{Expr: "period_start", Op: "<=", Args: []any{query.To}})
+	}
+	for field, value := range map[string]string{"principal": query.Principal, "api_key": query.APIKey, "route": query.Route} {
+		if value != "" {
+			filter = append(filter, port.DbExpression{Expr: field, Args: []any{value}})
+		}
+	}
+
+	rows := []port.UsageRecord{}
+	err := s.Connection.Find(ctx, &rows, s.Table, []string{}, filter, map[string]int{"period_start": 1}, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// the totals of the instances are added together
+	type key struct {
+		window, principal, apiKey, route string
+		start                            int64
+	}
+	totals := map[key]int{}
+	result := []port.UsageRecord{}
+	for _, row := range rows {
+		k := key{row.Window, row.Principal, row.APIKey, row.Route, row.Start.UnixNano()}
+		i, ok := totals[k]
+		if !ok {
+			row.Instance = ""
+			totals[k] = len(result)
+			result = append(result, row)
+			continue
+		}
+		result[i].Requests += row.Requests
+		result[i].Errors += row.Errors
+		result[i].BytesIn += row.BytesIn
+		result[i].BytesOut += row.BytesOut
+	}
+	return result, nil
+}
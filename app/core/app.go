@@ -2,13 +2,22 @@ package core
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"sync"
 	"sync/atomic"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
 	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/grpc"
+	"github.com/webcore-go/webcore/infra/i18n"
 	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/metrics"
 	"github.com/webcore-go/webcore/infra/middleware"
 	"github.com/webcore-go/webcore/port/auth"
 )
@@ -19,6 +28,13 @@ type App struct {
 	Context        *AppContext
 	ModuleManager  *ModuleManager
 	LibraryManager *LibraryManager
+
+	commandsMu  sync.Mutex
+	commands    map[string]*Command // registered by RegisterCommand
+	console     *Console            // runtime console of a development instance, nil otherwise
+	stopMetrics func()              // unregisters the metrics of app.metrics, nil when disabled
+
+	err error // failure of NewApp, returned by Prepare
 }
 
 func (a *App) Load() *App {
@@ -40,79 +56,489 @@ func NewApp(ctx context.Context, cfg *config.Config, loaders map[string]LibraryL
 
 	// Initialize LibraryLoader Manager
 	manLibrary := CreateLibraryManager(loaders)
+	manLibrary.Configure(cfg.App.Libraries)
+	manLibrary.BindConfig(cfg)
+
+	// Initialize Module Manager, without the modules switched off in their configuration
+	manModule := CreateModuleManager(&cfg.App.Module, enabledModules(cfg, packages))
+
+	clock := helper.NewSystemClock()
+	eventBus := NewEventBus()
 
-	// Initialize Module Manager
-	manModule := CreateModuleManager(&cfg.App.Module, packages)
+	// Read the secrets referenced by the configuration before the services copy it
+	secrets := NewSecrets(cfg.Secrets, eventBus, clock)
+	secretsErr := loadSecrets(ctx, cfg, manLibrary, secrets)
+
+	queue := NewJobQueue(cfg.App.Queue, NewMemoryQueueStore(), clock)
+	mailer := NewMailer(cfg.Mail, queue)
+	retries := NewRetries(cfg.App.Retry, clock)
+	webhooks := NewWebhooks(cfg.App.Webhooks, queue, retries, NewLocalLocker(clock), clock)
+	readiness := NewReadiness()
+	tasks := NewTasks(cfg.App.Tasks, cfg.Server.PathPrefix, queue, clock)
+	hub := NewHub(cfg.App.Hub)
+	flags := NewFlags(cfg.App.Flags, eventBus, clock)
+	masking := NewMasking(cfg.App.Masking)
 
 	app := &App{
 		Context: &AppContext{
-			Context:  ctx,
-			Config:   cfg,
-			Web:      nil,
-			Root:     nil,
-			EventBus: NewEventBus(),
-			Hook:     NewHook(),
+			Context:      ctx,
+			Config:       cfg,
+			Web:          nil,
+			Root:         nil,
+			EventBus:     eventBus,
+			Hook:         NewHook(),
+			Clock:        clock,
+			Scheduler:    NewScheduler(clock, NewLocalLocker(clock)),
+			Queue:        queue,
+			Mailer:       mailer,
+			Notifier:     NewNotifier(cfg.Notify, mailer, queue),
+			Reporter:     NewReporter(cfg.Report, queue),
+			Images:       NewImageProcessor(cfg.Image, queue),
+			Webhooks:     webhooks,
+			Payments:     NewPayments(cfg.Payment, webhooks),
+			Tasks:        tasks,
+			Importer:     NewImporter(cfg.Import, tasks),
+			Search:       NewSearchSync(cfg.Search, queue, tasks, eventBus),
+			Hub:          hub,
+			Readiness:    readiness,
+			Discovery:    NewDiscovery(cfg.App.Discovery, readiness, clock),
+			DevTail:      NewDevTail(cfg.App.DevTail, clock),
+			Retention:    NewRetention(cfg.App.Retention, eventBus, clock),
+			Sagas:        NewSagas(cfg.App.Saga, NewMemorySagaStore(), eventBus, clock),
+			EventStore:   NewEventStore(cfg.App.EventStore, NewMemoryEventStore(), eventBus, clock),
+			Outbox:       NewOutbox(cfg.App.Outbox, manLibrary, eventBus, clock),
+			Flags:        flags,
+			Tenants:      NewConfigTenantStore(cfg.App.Tenancy.Tenants),
+			Resilience:   NewResilience(cfg.App.Resilience, eventBus, clock),
+			Retries:      retries,
+			Secrets:      secrets,
+			Cipher:       helper.NewFieldCipher(),
+			Signer:       helper.NewValueSigner(clock),
+			Deprecations: NewDeprecations(cfg.App.Deprecation, clock),
+			Usage:        NewUsage(cfg.App.Usage, cfg.Auth.APIKeyHeader, clock),
+			Quotas:       NewQuotas(cfg.App.Quotas, flags, clock),
+			Privacy:      NewPrivacy(cfg.App.Privacy, tasks, eventBus, clock),
+			Canaries:     NewCanaries(cfg.App.Canary, clock),
+			Masking:      masking,
+			Client:       NewClientPush(cfg.App.Client, flags, hub, eventBus),
+			Chaos:        NewChaos(cfg.App.Chaos, cfg.App.Environment, clock),
+			Capture:      NewCapture(cfg.App.Capture, cfg.Auth.APIKeyHeader, masking, clock),
+			Upgrades:     NewUpgrades(cfg.App.Upgrades, manLibrary, eventBus, clock),
 		},
 		ModuleManager:  manModule,
 		LibraryManager: manLibrary,
+		err:            secretsErr,
 	}
 
 	// update context reference
 	app.ModuleManager.context = app.Context
 
+	// Dependencies of the InjectableLoaders
+	manLibrary.Provide(ctx, cfg, app.Context)
+
+	// Calls spilled to the queue by the rate limit of their upstream
+	HandleJob(queue, HTTPCallJobType, app.Context.sendQueuedCall)
+
 	singleApp.Store(app)
 	return app
 }
 
 // Start starts the application
 func (a *App) Start() error {
+	if err := a.Prepare(); err != nil {
+		return err
+	}
+
+	// Start server
+	addr := fmt.Sprintf("%s:%d", a.Context.Config.Server.Host, a.Context.Config.Server.Port)
+	log.Printf("Server starting on %s", addr)
+
+	return a.listen(addr)
+}
+
+// listen serves on addr, over HTTPS when server.tls_cert and server.tls_key are set
+func (a *App) listen(addr string) error {
+	server := a.Context.Config.Server
+	if server.TLSCert != "" && server.TLSKey != "" {
+		return a.Context.Web.ListenTLS(addr, server.TLSCert, server.TLSKey)
+	}
+	return a.Context.Web.Listen(addr)
+}
+
+// Prepare loads the libraries and the modules, mounts the routes and starts the background
+// workers without listening, the Fiber app then serves requests through Web.Test or Listen
+func (a *App) Prepare() error {
+	if err := a.setup(); err != nil {
+		return err
+	}
+
+	return a.startBackground()
+}
+
+// setup loads the libraries and the modules and mounts the routes, the commands of the
+// binary run once it returns
+func (a *App) setup() error {
+	if a.err != nil {
+		return a.err
+	}
+
 	// Create Fiber app
 	a.Context.Web = fiber.New(a.Context.Config.GetFiberConfig(middleware.ErrorHandler))
 
+	// Accept traffic once the server listens
+	a.Context.Web.Hooks().OnListen(func(fiber.ListenData) error {
+		a.Context.Readiness.SetReady(true)
+		return nil
+	})
+
+	// Collect the logs and events from the start in development
+	if a.devTailEnabled() {
+		a.Context.DevTail.Attach(a.Context.EventBus)
+	}
+
+	// Masking policies, before the libraries and the modules log
+	if a.Context.Config.App.Masking.Enabled {
+		if err := a.Context.Masking.Load(); err != nil {
+			return err
+		}
+	}
+
 	// Initialize shared dependencies
 	if err := a.Context.Start(); err != nil {
 		return fmt.Errorf("failed to initialize shared dependencies: %v", err)
 	}
 
+	// Deprecated versions and routes of the configuration
+	if err := a.Context.Deprecations.LoadRules(); err != nil {
+		return err
+	}
+	if err := a.Context.Deprecations.LoadVersions(a.Context.Config.Server.PathPrefix, a.Context.Config.App.Versioning.Versions); err != nil {
+		return err
+	}
+
+	// Aggregation windows and quotas of the usage
+	if a.Context.Config.App.Usage.Enabled {
+		if err := a.Context.Usage.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Windows and routes of the feature quotas
+	if a.Context.Config.App.Quotas.Enabled {
+		if err := a.Context.Quotas.Validate(); err != nil {
+			return err
+		}
+	}
+
+	// Dependencies of the libraries, without cycle
+	if _, err := a.LibraryManager.InitOrder(); err != nil {
+		return err
+	}
+
+	// Strategies and jitters of the retry policies
+	if err := a.Context.Retries.Validate(); err != nil {
+		return err
+	}
+
+	// Locales offered to the clients
+	if a.Context.Config.App.I18n.Enabled {
+		for _, tag := range append([]string{a.Context.Config.App.I18n.Default}, a.Context.Config.App.I18n.Locales...) {
+			if _, ok := i18n.Lookup(tag); !ok {
+				return fmt.Errorf("app.i18n: locale '%s' is not registered", tag)
+			}
+		}
+	}
+
 	// Setup global middleware
 	a.setupGlobalMiddleware()
 
+	// Requests and libraries on app.metrics.path
+	if a.Context.Config.App.Metrics.Enabled {
+		a.stopMetrics = a.setupMetrics()
+	}
+
+	// Create the gRPC server before the modules so they can register their services
+	if a.Context.Config.App.GRPC.Enabled {
+		a.setupGRPC()
+	}
+
 	// Initialize modules better
 	if err := a.ModuleManager.InitializeModulesWithDependencies(); err != nil {
 		return err
 	}
 
+	// Size of the pages of the lists and format of the errors
+	out.SetPaginationLimits(a.Context.Config.App.Pagination.DefaultPerPage, a.Context.Config.App.Pagination.MaxPerPage)
+	out.SetProblemDetails(a.Context.Config.App.ProblemDetails.Enabled, a.Context.Config.App.ProblemDetails.TypeBase)
+
+	// Formats of the responses, after the modules registered their encoders
+	if err := out.SetFormats(a.Context.Config.App.Formats...); err != nil {
+		return fmt.Errorf("app.formats: %v", err)
+	}
+
 	// Setup routes
 	a.setupRoutes()
 
+	// The authorization checks the access the modules declared on their routes
+	if authz, ok := a.Context.Authorizer.(auth.IRouteAuthorization); ok {
+		authz.SetRoutes(a.ModuleManager.RouteRules())
+	}
+
+	// Stitch the GraphQL schema of the modules
+	if a.Context.Config.App.GraphQL.Enabled {
+		if err := a.setupGraphQL(); err != nil {
+			return fmt.Errorf("failed to setup GraphQL: %v", err)
+		}
+	}
+
+	// Serve the frontend once every route is registered
+	if a.Context.Config.App.Static.Enabled {
+		if err := a.setupStatic(); err != nil {
+			return err
+		}
+	}
+
 	// call start hooks
 	a.runStartHook()
 
-	// Start server
-	addr := fmt.Sprintf("%s:%d", a.Context.Config.Server.Host, a.Context.Config.Server.Port)
-	log.Printf("Server starting on %s", addr)
+	// Purge the expired rows of the retention rules declared by the modules
+	if a.Context.Config.App.Retention.Enabled {
+		if !a.Context.Config.App.Scheduler.Enabled {
+			logger.Warn("Retention needs app.scheduler to run its purges")
+		}
+		if err := a.Context.Scheduler.Register(a.Context.Retention.Job()); err != nil {
+			return fmt.Errorf("failed to schedule retention: %v", err)
+		}
+	}
 
-	return a.Context.Web.Listen(addr)
+	return nil
+}
+
+// startBackground starts the workers of the enabled features and registers the instance
+func (a *App) startBackground() error {
+	// Run jobs registered by the modules
+	if a.Context.Config.App.Scheduler.Enabled {
+		a.Context.Scheduler.Start(a.Context.Context)
+	}
+
+	// Start background job workers
+	if a.Context.Config.App.Queue.Enabled {
+		a.Context.Queue.Start(a.Context.Context)
+	}
+
+	// Execute sagas and resume those interrupted by a crash
+	if a.Context.Config.App.Saga.Enabled {
+		a.Context.Sagas.Start(a.Context.Context)
+	}
+
+	// Relay the messages of the outbox to the broker
+	if a.Context.Config.App.Outbox.Enabled {
+		a.Context.Outbox.Start(a.Context.Context)
+	}
+
+	// Reload the feature flags changed by the other instances
+	if a.Context.Config.App.Flags.Enabled {
+		a.Context.Flags.Start(a.Context.Context)
+	}
+
+	// Save the usage counted by the instance
+	if a.Context.Config.App.Usage.Enabled {
+		a.Context.Usage.Start(a.Context.Context)
+	}
+
+	// Renew the leases of the secrets and follow their rotations
+	a.Context.Secrets.Start(a.Context.Context)
+
+	// Start relaying the websocket hub
+	if a.Context.Config.App.Hub.Enabled {
+		a.Context.Hub.Start(a.Context.Context)
+	}
+
+	// Runtime console on the local socket in development
+	if a.Context.Config.App.Console.Enabled {
+		if a.Context.Config.App.Environment != "development" {
+			logger.Warn("Console is only served in development", "environment", a.Context.Config.App.Environment)
+		} else {
+			a.console = NewConsole(a, a.Context.Config.App.Console)
+			if err := a.console.Start(a.Context.Context); err != nil {
+				return fmt.Errorf("failed to start the console: %v", err)
+			}
+		}
+	}
+
+	// Background work of the modules
+	if err := a.ModuleManager.Start(a.Context.Context); err != nil {
+		return fmt.Errorf("failed to start the modules: %v", err)
+	}
+
+	// Serve gRPC next to the HTTP server
+	if a.Context.GRPC != nil {
+		if err := a.serveGRPC(); err != nil {
+			return fmt.Errorf("failed to start gRPC: %v", err)
+		}
+	}
+
+	// Register the instance, it receives traffic once ready
+	if a.Context.Config.App.Discovery.Enabled {
+		if err := a.Context.Discovery.Start(a.Context.Context, a.Context.ServiceInstance()); err != nil {
+			return fmt.Errorf("failed to register the service: %v", err)
+		}
+	}
+
+	return nil
 }
 
-// Stop stops the application gracefully
+// Stop stops the application gracefully within server.shutdown_timeout, see Shutdown
 func (a *App) Stop() error {
+	ctx := context.Background()
+	if timeout := a.Context.Config.Server.ShutdownTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	return a.Shutdown(ctx)
+}
+
+// Shutdown stops the application gracefully: it stops accepting requests and waits for those
+// in flight, stops the consumers and the background work, flushes the logs, then unloads the
+// modules and the libraries in the reverse order of their dependencies. The waits end with ctx.
+// It returns the failures joined.
+func (a *App) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	// Stop receiving traffic before anything is torn down
+	a.Context.Readiness.SetReady(false)
+	a.Context.Discovery.Stop()
+	if a.Context.Web != nil {
+		if err := a.Context.Web.ShutdownWithContext(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("server shutdown: %w", err))
+		}
+	}
+	if a.Context.GRPC != nil {
+		if err := a.Context.GRPC.Shutdown(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("gRPC shutdown: %w", err))
+		}
+	}
+
+	if a.console != nil {
+		a.console.Stop()
+	}
+
 	// call destroy hooks
 	a.runDestroyHook()
 
-	// Unload all libraries
-	a.LibraryManager.Destroy()
+	// The background work of the modules ends before the libraries it uses are quiesced
+	if err := a.ModuleManager.Stop(ctx); err != nil {
+		errs = append(errs, err)
+	}
 
-	// Unload all modules
-	a.ModuleManager.Destroy()
+	// The consumers stop taking messages and finish those in flight
+	if err := a.LibraryManager.Quiesce(ctx); err != nil {
+		errs = append(errs, err)
+	}
 
-	return a.Context.Destroy()
+	// Stop scheduled jobs before the libraries they use are unloaded
+	a.Context.Scheduler.Stop()
+	a.Context.Queue.Stop()
+	a.Context.Sagas.Stop()
+	a.Context.Outbox.Stop()
+	a.Context.Flags.Stop()
+	a.Context.Usage.Stop()
+	a.Context.Secrets.Stop()
+	a.Context.Hub.Stop()
+	a.Context.DevTail.Stop()
+	a.Context.Upgrades.Stop()
+
+	// The asynchronous subscribers handle the events queued before the libraries are unloaded
+	if err := a.Context.EventBus.Close(ctx); err != nil {
+		errs = append(errs, err)
+	}
+
+	// The remote log is a library, its buffered entries are sent before it is unloaded
+	if err := logger.Flush(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("log flush: %w", err))
+	}
+
+	// Unload all modules, then the libraries they use
+	if err := a.ModuleManager.Destroy(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := a.LibraryManager.Destroy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if a.stopMetrics != nil {
+		a.stopMetrics()
+	}
+
+	// A new application can be created once this one is stopped (ex: tests)
+	defer singleApp.CompareAndSwap(a, nil)
+	config.SetSecretResolver(nil)
+	logger.SetMasker(nil)
+
+	return errors.Join(errs...)
 }
 
 // setupGlobalMiddleware sets up global middleware
 func (a *App) setupGlobalMiddleware() {
+	// Recover the panics of every middleware and handler
+	if a.Context.Config.App.Features.Recovery {
+		a.Context.Web.Use(a.Context.RecoverMiddleware())
+	}
+
+	// Resolve the client location first, rate limits and request logs use it
+	if a.Context.GeoIP != nil {
+		a.Context.Web.Use(middleware.GeoIP(a.Context.GeoIP))
+	}
+
+	// Resolve the tenant before the rate limit, the routes of the path resolver depend on it
+	if a.Context.Config.App.Tenancy.Enabled {
+		a.Context.Web.Use(middleware.Tenant(a.Context.Config.App.Tenancy, a.Context.Config.Server.PathPrefix, a.Context.Tenants))
+	}
+
+	// Scope the libraries of the request, after the tenant they are often loaded for
+	if a.Context.Config.App.Libraries.RequestScope {
+		a.Context.Web.Use(a.LibraryManager.ScopeMiddleware())
+	}
+
+	// Resolve the locale before the handlers format their numbers and dates
+	if a.Context.Config.App.I18n.Enabled {
+		a.Context.Web.Use(middleware.Locale(a.Context.Config.App.I18n))
+	}
+
 	middleware.SetupGlobalMiddleware(a.Context.Web, a.Context.Config)
 
+	// Span of the request, after its request ID so a new trace takes its ID
+	if a.Context.Tracer != nil {
+		a.Context.Web.Use(a.Context.Tracer.Middleware())
+	}
+
+	// Typed Locals of the request (ex: GetLocal[*logger.Logger]), after its ID is set
+	a.Context.Web.Use(a.Context.LocalsMiddleware())
+
+	// Access log of the requests, inside the recovery and the request ID
+	if a.Context.Config.App.AccessLog.Enabled {
+		accessLog, err := middleware.AccessLog(a.Context.Config.App.AccessLog)
+		if err != nil {
+			logger.Fatal("Setup access log middleware", "error", err)
+		}
+		a.Context.Web.Use(accessLog)
+	}
+
+	// Sampled requests for the replay command, before the faults so they are captured too
+	admin := a.Context.Config.Server.PathPrefix + a.Context.Config.App.Admin.Path
+	if a.Context.Config.App.Capture.Enabled {
+		a.Context.Web.Use(a.Context.Capture.Middleware(admin))
+	}
+
+	// Faults of app.chaos, inside the logs and the recovery, never on the admin API removing them
+	if a.Context.Chaos.Enabled() {
+		a.Context.Web.Use(a.Context.Chaos.Middleware(admin))
+	}
+
+	// Announce the deprecated versions and routes of app.deprecation and of Deprecate
+	a.Context.Web.Use(a.Context.Deprecations.Middleware())
+
 	// Authentication middleware
 	a.setupAuthMiddleware()
 }
@@ -137,6 +563,9 @@ func (a *App) setupAuthMiddleware() {
 
 		authn := library.(auth.IAuthenticationManager)
 		handler = authn.GetAuthenticatonHandler()
+		if authz, ok := library.(auth.IAuthorizationManager); ok {
+			a.Context.Authorizer = authz.GetAuthorization()
+		}
 		logger.Info("Library Authentication loaded",
 			"type", a.Context.Config.Auth.Type,
 			"access", a.Context.Config.Auth.Store,
@@ -147,7 +576,75 @@ func (a *App) setupAuthMiddleware() {
 
 	// Apply authentication to protected routes
 	a.Context.Root = a.Context.Web.Group(a.Context.Config.Server.PathPrefix, handler)
+	// The authenticated user in the typed Locals, GetLocal[auth.IUserAuthInfo]
+	a.Context.Root.Use(userLocals)
 	a.Context.AuthHandler = handler
+
+	// Check the tenant of the request against the one of the user
+	if a.Context.Config.App.Tenancy.Enabled {
+		a.Context.Root.Use(middleware.TenantClaim(a.Context.Config.App.Tenancy, a.Context.Tenants))
+	}
+
+	// Target the feature flags at the authenticated user
+	if a.Context.Config.App.Flags.Enabled {
+		a.Context.Root.Use(a.Context.Flags.Middleware())
+	}
+
+	// Count the usage of the authenticated user and enforce its quota
+	if a.Context.Config.App.Usage.Enabled {
+		a.Context.Root.Use(a.Context.Usage.Middleware())
+	}
+
+	// Take the units of the feature quotas of the tenant or the user
+	if a.Context.Config.App.Quotas.Enabled {
+		a.Context.Root.Use(a.Context.Quotas.Middleware())
+	}
+
+	// Admin endpoints share the authentication of the protected routes, for the users of app.admin.roles
+	if a.Context.Config.App.Admin.Enabled {
+		a.setupAdmin()
+	}
+}
+
+// setupAdmin mounts the admin group for the users having one of app.admin.roles. Without
+// authentication it is only mounted in development, open to every caller.
+func (a *App) setupAdmin() {
+	cfg := a.Context.Config.App.Admin
+	if a.Context.Config.Auth.Type == "none" {
+		if a.Context.Config.App.Environment != "development" {
+			logger.Warn("Admin endpoints are not mounted without authentication outside development", "environment", a.Context.Config.App.Environment)
+			return
+		}
+		logger.Warn("Admin endpoints are open to every caller, auth.type is none")
+		a.Context.Admin = a.Context.Root.Group(cfg.Path)
+		return
+	}
+
+	if len(cfg.Roles) == 0 {
+		logger.Warn("Admin endpoints are not mounted, app.admin.roles is empty")
+		return
+	}
+	a.Context.Admin = a.Context.Root.Group(cfg.Path, a.Context.RequireAdmin())
+}
+
+// RequireAdmin refuses the requests of the users without one of app.admin.roles, it lets every
+// request through without authentication (auth.type none, only mounted in development)
+func (a *AppContext) RequireAdmin() fiber.Handler {
+	if a.Config.Auth.Type == "none" {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+
+	rule := auth.RouteRule{Roles: a.Config.App.Admin.Roles}
+	return func(c *fiber.Ctx) error {
+		// a rule without roles would let every user through
+		if len(rule.Roles) == 0 {
+			return auth.ErrAccessDenied
+		}
+		if err := rule.IsUserPermitted(auth.CurrentUser(c)); err != nil {
+			return err
+		}
+		return c.Next()
+	}
 }
 
 // setupRoutes sets up application routes
@@ -162,6 +659,35 @@ func (a *App) setupRoutes() {
 		})
 	})
 
+	// Readiness endpoint of load balancers and orchestrators
+	a.Context.Web.Get("/health/ready", func(c *fiber.Ctx) error {
+		if !a.Context.Readiness.Ready() {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "not ready"})
+		}
+		return c.JSON(fiber.Map{"status": "ready"})
+	})
+
+	// Kubernetes probes reporting the health of the loaded libraries
+	a.Context.Web.Get("/healthz", a.healthHandler(false))
+	a.Context.Web.Get("/readyz", a.healthHandler(true))
+
+	// Prometheus scrapes, without authentication like the probes
+	if a.Context.Config.App.Metrics.Enabled {
+		a.Context.Web.Get(a.Context.Config.App.Metrics.Path, metrics.Handler())
+	}
+
+	// Loaded libraries without authentication, for the instances unreachable from outside
+	if a.Context.Config.App.Libraries.Debug {
+		a.Context.Web.Get("/debug/libraries", func(c *fiber.Ctx) error {
+			return c.JSON(a.LibraryManager.Instances())
+		})
+	}
+
+	// OpenAPI document of the module routes
+	if a.Context.Config.App.OpenAPI.Serve {
+		a.registerOpenAPI()
+	}
+
 	// API version endpoint
 	a.Context.Web.Get("/info", func(c *fiber.Ctx) error {
 		return c.JSON(fiber.Map{
@@ -172,9 +698,250 @@ func (a *App) setupRoutes() {
 		})
 	})
 
+	if a.Context.Admin != nil {
+		// Scheduled jobs status
+		a.Context.Admin.Get("/scheduler/jobs", func(c *fiber.Ctx) error {
+			return out.Send(c, out.SuccessData(a.Context.Scheduler.Status()))
+		})
+
+		// Background jobs dashboard
+		a.Context.Admin.Get("/queue/stats", func(c *fiber.Ctx) error {
+			stats, err := a.Context.Queue.Stats(c.Context())
+			if err != nil {
+				return err
+			}
+			return out.Send(c, out.SuccessData(stats))
+		})
+		a.Context.Admin.Get("/queue/:queue/jobs", func(c *fiber.Ctx) error {
+			jobs, err := a.Context.Queue.List(c.Context(), c.Params("queue"), c.Query("state"), c.QueryInt("limit", 100))
+			if err != nil {
+				return err
+			}
+			return out.Send(c, out.SuccessData(jobs))
+		})
+
+		// Outbound HTTP clients health
+		a.Context.Admin.Get("/http/clients", func(c *fiber.Ctx) error {
+			return out.Send(c, out.SuccessData(a.Context.HTTPClientStats()))
+		})
+
+		// Circuit breakers, bulkheads and timeouts of the dependencies
+		a.Context.Admin.Get("/resilience", func(c *fiber.Ctx) error {
+			return out.Send(c, out.SuccessData(a.Context.Resilience.Stats()))
+		})
+
+		// Callers of the deprecated routes and versions
+		a.Context.Admin.Get("/deprecations", func(c *fiber.Ctx) error {
+			return out.Send(c, out.SuccessData(a.Context.Deprecations.Report()))
+		})
+
+		// Loaded instances of the libraries and their last health check
+		a.LibraryManager.RegisterAdminRoutes(a.Context.Admin, a.Context.Config.App.Health.Timeout)
+
+		// Rolling upgrades of the keyed libraries, cut over on demand
+		a.Context.Upgrades.RegisterAdminRoutes(a.Context.Admin)
+
+		// Traffic and errors of both variants of the canary routes
+		a.Context.Canaries.RegisterAdminRoutes(a.Context.Admin)
+
+		// Usage per principal, API key and route
+		if a.Context.Config.App.Usage.Enabled {
+			a.Context.Usage.RegisterAdminRoutes(a.Context.Admin)
+		}
+
+		// Faults injected on this instance
+		if a.Context.Chaos.Enabled() {
+			a.Context.Chaos.RegisterAdminRoutes(a.Context.Admin)
+		}
+
+		// Settings of the frontends changed on this instance
+		if a.Context.Config.App.Client.Enabled {
+			a.Context.Client.RegisterAdminRoutes(a.Context.Admin)
+		}
+
+		// Masking policies of the logs and the exports, by tenant
+		if a.Context.Config.App.Masking.Enabled {
+			a.Context.Masking.RegisterAdminRoutes(a.Context.Admin)
+		}
+
+		// Data owners and reports of the privacy requests, exports and erasures answered with the task to poll
+		if a.Context.Config.App.Privacy.Enabled {
+			a.Context.Admin.Get("/privacy", func(c *fiber.Ctx) error {
+				owners := []fiber.Map{}
+				for _, owner := range a.Context.Privacy.Owners() {
+					owners = append(owners, fiber.Map{
+						"name":   owner.Name,
+						"export": owner.Export != nil,
+						"erase":  owner.Erase != nil,
+					})
+				}
+				return out.Send(c, out.SuccessData(fiber.Map{
+					"owners":  owners,
+					"reports": a.Context.Privacy.Reports(),
+				}))
+			})
+			a.Context.Admin.Post("/privacy/:subject/export", func(c *fiber.Ctx) error {
+				return a.Context.Privacy.AcceptExport(c, c.Params("subject"))
+			})
+			a.Context.Admin.Post("/privacy/:subject/erasure", func(c *fiber.Ctx) error {
+				return a.Context.Privacy.AcceptErasure(c, c.Params("subject"))
+			})
+		}
+
+		// Retention rules and purge reports, purges run on demand with ?dry_run=true to preview them
+		if a.Context.Config.App.Retention.Enabled {
+			a.Context.Admin.Get("/retention", func(c *fiber.Ctx) error {
+				rules := []fiber.Map{}
+				for _, rule := range a.Context.Retention.Rules() {
+					rules = append(rules, fiber.Map{
+						"name":    rule.Name,
+						"table":   rule.Table,
+						"column":  rule.Column,
+						"max_age": rule.MaxAge.String(),
+					})
+				}
+				return out.Send(c, out.SuccessData(fiber.Map{
+					"rules":   rules,
+					"reports": a.Context.Retention.Reports(),
+				}))
+			})
+			a.Context.Admin.Post("/retention/:rule/purge", func(c *fiber.Ctx) error {
+				report, err := a.Context.Retention.Purge(c.UserContext(), c.Params("rule"), c.QueryBool("dry_run"))
+				if err != nil {
+					return fiber.NewError(fiber.StatusNotFound, err.Error())
+				}
+				return out.Send(c, out.SuccessData(report))
+			})
+		}
+
+		// Search sources and their backfill, answered with the task to poll
+		if a.Context.Config.Search.Driver != "" {
+			a.Context.Admin.Get("/search", func(c *fiber.Ctx) error {
+				sources := []fiber.Map{}
+				for _, source := range a.Context.Search.Sources() {
+					sources = append(sources, fiber.Map{
+						"name":   source.Name,
+						"index":  a.Context.Config.Search.Prefix + source.Index,
+						"table":  source.Table,
+						"events": source.Events,
+					})
+				}
+				return out.Send(c, out.SuccessData(sources))
+			})
+			a.Context.Admin.Post("/search/:source/backfill", func(c *fiber.Ctx) error {
+				return a.Context.Search.AcceptBackfill(c, c.Params("source"))
+			})
+		}
+
+		// State of a saga
+		if a.Context.Config.App.Saga.Enabled {
+			a.Context.Admin.Get("/sagas/:id", func(c *fiber.Ctx) error {
+				saga, err := a.Context.Sagas.Get(c.UserContext(), c.Params("id"))
+				if err != nil {
+					return err
+				}
+				if saga == nil {
+					return fiber.NewError(fiber.StatusNotFound, "Saga not found")
+				}
+				return out.Send(c, out.SuccessData(fiber.Map{
+					"saga": saga,
+					"data": json.RawMessage(saga.Data),
+				}))
+			})
+		}
+
+		// Feature flags, saved flags apply at once on this instance
+		if a.Context.Config.App.Flags.Enabled {
+			a.Context.Flags.RegisterAdminRoutes(a.Context.Admin)
+		}
+
+		// Websocket hub connections
+		if a.Context.Config.App.Hub.Enabled {
+			a.Context.Admin.Get("/hub/stats", func(c *fiber.Ctx) error {
+				return out.Send(c, out.SuccessData(a.Context.Hub.Stats()))
+			})
+		}
+	}
+
+	// Public endpoint of third party webhooks, authenticated by their signature
+	if a.Context.Config.App.Webhooks.Enabled {
+		path := a.Context.Config.Server.PathPrefix + a.Context.Config.App.Webhooks.Path + "/:source"
+		a.Context.Web.Post(path, a.Context.Webhooks.Handler())
+	}
+
+	// Status of long-running tasks
+	if a.Context.Config.App.Tasks.Enabled {
+		a.Context.Root.Get(a.Context.Config.App.Tasks.Path+"/:id", a.Context.Tasks.Handler())
+	}
+
+	// Websocket hub endpoint
+	if a.Context.Config.App.Hub.Enabled {
+		if a.Context.Authorizer != nil {
+			a.Context.Hub.SetAuthorization(a.Context.Authorizer)
+		}
+		a.Context.Root.Get(a.Context.Config.App.Hub.Path, a.Context.Hub.Handler())
+	}
+
+	// Consumption of the feature quotas of the requester
+	if a.Context.Config.App.Quotas.Enabled {
+		a.Context.Root.Get(a.Context.Config.App.Quotas.Path, a.Context.Quotas.StatusHandler())
+	}
+
+	// Flags and settings of the frontends, their changes are pushed on the hub
+	if a.Context.Config.App.Client.Enabled {
+		a.Context.Root.Get(a.Context.Config.App.Client.Path, a.Context.Client.Handler())
+	}
+
+	// Development tail of the logs and events
+	if a.devTailEnabled() {
+		a.Context.Root.Get(a.Context.Config.App.DevTail.Path, a.Context.DevTail.Handler())
+	} else if a.Context.Config.App.DevTail.Enabled {
+		logger.Warn("Dev tail is only served in development", "environment", a.Context.Config.App.Environment)
+	}
+
 	// Module routes will be automatically added by the registry
 }
 
+// devTailEnabled tells whether the dev tail is served, never outside of development
+func (a *App) devTailEnabled() bool {
+	return a.Context.Config.App.DevTail.Enabled && a.Context.Config.App.Environment == "development"
+}
+
+// setupGRPC creates the gRPC server with the health service following the readiness
+func (a *App) setupGRPC() {
+	a.Context.GRPC = grpc.NewServer()
+	a.Context.GRPCHealth = grpc.NewHealthServer()
+	a.Context.GRPCHealth.Register(a.Context.GRPC)
+	if a.Context.Config.App.GRPC.Reflection {
+		grpc.RegisterReflection(a.Context.GRPC)
+	}
+
+	a.Context.Readiness.OnChange(func(ready bool) {
+		status := grpc.StatusNotServing
+		if ready {
+			status = grpc.StatusServing
+		}
+		a.Context.GRPCHealth.SetServingStatus("", status)
+	})
+}
+
+func (a *App) serveGRPC() error {
+	addr := fmt.Sprintf("%s:%d", a.Context.Config.Server.Host, a.Context.Config.App.GRPC.Port)
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	go func() {
+		if err := a.Context.GRPC.Serve(listener); err != nil {
+			logger.Error("gRPC server stopped", "error", err)
+		}
+	}()
+
+	logger.Info("gRPC server listening", "address", addr, "services", a.Context.GRPC.Services())
+	return nil
+}
+
 func (a *App) runStartHook() {
 	a.Context.RunHook("start")
 }
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// Batcher groups the keys requested by concurrent callers into a single call of fetch, for
+// partner APIs with bulk endpoints (ex: one quote request for several products). Callers
+// asking for a key already waiting or being fetched share its result. Nothing is cached,
+// unlike graphql.Loader.
+type Batcher[K comparable, V any] struct {
+	fetch  func(ctx context.Context, keys []K) (map[K]V, error)
+	config config.HTTPBatchConfig
+	clock  helper.Clock
+
+	mu      sync.Mutex
+	pending map[K]*batchResult[V] // waiting in the current batch or being fetched
+	batch   *batcherBatch[K, V]
+}
+
+type batchResult[V any] struct {
+	done  chan struct{}
+	value V
+	found bool
+	err   error
+}
+
+type batcherBatch[K comparable, V any] struct {
+	ctx     context.Context
+	keys    []K
+	results map[K]*batchResult[V]
+}
+
+// NewBatcher creates a batcher calling fetch, usually with the batch settings of an upstream
+// (ex: NewBatcher(client.Config.Batch, app.Clock, fetch)). Keys missing from the result of
+// fetch fail with an error.
+func NewBatcher[K comparable, V any](cfg config.HTTPBatchConfig, clock helper.Clock, fetch func(ctx context.Context, keys []K) (map[K]V, error)) *Batcher[K, V] {
+	if cfg.Wait <= 0 {
+		cfg.Wait = 10 * time.Millisecond
+	}
+
+	return &Batcher[K, V]{
+		fetch:   fetch,
+		config:  cfg,
+		clock:   clock,
+		pending: make(map[K]*batchResult[V]),
+	}
+}
+
+// Load returns the value of key once the batch it joined was fetched
+func (b *Batcher[K, V]) Load(ctx context.Context, key K) (V, error) {
+	b.mu.Lock()
+	result, ok := b.pending[key]
+	if !ok {
+		result = &batchResult[V]{done: make(chan struct{})}
+		b.pending[key] = result
+
+		if b.batch == nil {
+			// the batch outlives the caller which opened it
+			batch := &batcherBatch[K, V]{ctx: context.WithoutCancel(ctx), results: make(map[K]*batchResult[V])}
+			b.batch = batch
+			go func() {
+				<-b.clock.After(b.config.Wait)
+				b.dispatch(batch)
+			}()
+		}
+		b.batch.keys = append(b.batch.keys, key)
+		b.batch.results[key] = result
+
+		if b.config.MaxSize > 0 && len(b.batch.keys) >= b.config.MaxSize {
+			go b.dispatch(b.batch)
+		}
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-result.done:
+		if result.err == nil && !result.found {
+			var zero V
+			return zero, fmt.Errorf("no value returned for %v", key)
+		}
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// dispatch fetches a batch once, either when it is full or when the wait elapsed
+func (b *Batcher[K, V]) dispatch(batch *batcherBatch[K, V]) {
+	b.mu.Lock()
+	if b.batch != batch {
+		// already dispatched
+		b.mu.Unlock()
+		return
+	}
+	b.batch = nil
+	b.mu.Unlock()
+
+	values, err := b.fetch(batch.ctx, batch.keys)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, result := range batch.results {
+		delete(b.pending, key)
+		result.value, result.found = values[key]
+		result.err = err
+		close(result.done)
+	}
+}
@@ -0,0 +1,271 @@
+package core
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Variants of a canary route
+const (
+	CanaryStable = "stable"
+	CanaryNew    = "canary"
+)
+
+// Canary serves a route with a second implementation for part of the traffic, the handlers of
+// the route serving the rest. A client keeps its variant while the percentage grows.
+type Canary struct {
+	Name    string          // identifies the canary in app.canary.routes and the report, "<METHOD> <path>" of the route when empty
+	Handler fiber.Handler   // the new implementation, in place of the last handler of the route
+	Percent int             // percent of the clients served by the canary
+	Rules   []port.FlagRule // clients matching a rule (principal, tenant, role, country) get the canary with its rollout instead of Percent
+}
+
+// CanaryVariantStats compares the requests served by a variant of a canary route
+type CanaryVariantStats struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`        // responses with a status of 500 or more
+	ClientErrors int64   `json:"client_errors"` // responses with a status between 400 and 499
+	ErrorRate    float64 `json:"error_rate"`    // Errors per request
+	AvgLatency   string  `json:"avg_latency"`
+	MaxLatency   string  `json:"max_latency"`
+
+	latency time.Duration
+	max     time.Duration
+}
+
+// CanaryStats is the state of a canary route in the report of Canaries
+type CanaryStats struct {
+	Name    string             `json:"name"`
+	Percent int                `json:"percent"`
+	Stable  CanaryVariantStats `json:"stable"`
+	Canary  CanaryVariantStats `json:"canary"`
+}
+
+type canaryRoute struct {
+	canary  Canary
+	percent int
+	stable  CanaryVariantStats
+	new     CanaryVariantStats
+}
+
+// Canaries splits the traffic of the canary routes between their variants and compares them.
+// The percentage of a canary is overridden by app.canary.routes and at runtime by SetPercent,
+// the app.canary.header header ("canary" or "stable") forces a variant.
+type Canaries struct {
+	mu     sync.RWMutex
+	config config.CanaryConfig
+	clock  helper.Clock
+	routes map[string]*canaryRoute
+}
+
+func NewCanaries(cfg config.CanaryConfig, clock helper.Clock) *Canaries {
+	return &Canaries{
+		config: cfg,
+		clock:  clock,
+		routes: make(map[string]*canaryRoute),
+	}
+}
+
+// Handler serves the requests with stable or with the Handler of canary, AppendRouteToArray
+// uses it in place of the last handler of the routes with a Canary
+func (s *Canaries) Handler(canary Canary, stable fiber.Handler) fiber.Handler {
+	route := s.register(canary)
+
+	return func(c *fiber.Ctx) error {
+		s.mu.RLock()
+		percent := route.percent
+		s.mu.RUnlock()
+
+		variant := s.variant(c, route.canary, percent)
+		handler := stable
+		if variant == CanaryNew {
+			handler = route.canary.Handler
+		}
+
+		started := s.clock.Now()
+		err := handler(c)
+		latency := s.clock.Since(started)
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the status is set by the error handler afterwards
+			status = fiber.StatusInternalServerError
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				status = e.Code
+			}
+		}
+
+		s.mu.Lock()
+		stats := &route.stable
+		if variant == CanaryNew {
+			stats = &route.new
+		}
+		stats.Requests++
+		switch {
+		case status >= 500:
+			stats.Errors++
+		case status >= 400:
+			stats.ClientErrors++
+		}
+		stats.latency += latency
+		stats.max = max(stats.max, latency)
+		s.mu.Unlock()
+
+		return err
+	}
+}
+
+func (s *Canaries) register(canary Canary) *canaryRoute {
+	route := &canaryRoute{canary: canary, percent: canary.Percent}
+	for _, override := range s.config.Routes {
+		if override.Name == canary.Name {
+			route.percent = override.Percent
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.routes[canary.Name]; exists {
+		logger.Warn("Canary registered twice, the report only shows the last route", "name", canary.Name)
+	}
+	s.routes[canary.Name] = route
+	return route
+}
+
+// variant chooses the variant serving the request: the one of the header, then the rollout of the
+// first rule matching the client, then Percent. The clients are spread by a hash of the canary
+// and the principal, or the IP address on public routes.
+func (s *Canaries) variant(c *fiber.Ctx, canary Canary, percent int) string {
+	if s.config.Header != "" {
+		switch strings.ToLower(c.Get(s.config.Header)) {
+		case CanaryNew:
+			return CanaryNew
+		case CanaryStable:
+			return CanaryStable
+		}
+	}
+
+	target := CurrentFlagTarget(c.UserContext())
+	if target.Principal == "" {
+		target = requestFlagTarget(c, "")
+	}
+	for _, rule := range canary.Rules {
+		if matchFlagRule(rule, target) {
+			percent = rule.Rollout
+			break
+		}
+	}
+
+	switch {
+	case percent >= 100:
+		return CanaryNew
+	case percent <= 0:
+		return CanaryStable
+	}
+
+	client := target.Principal
+	if client == "" {
+		client = "ip:" + c.IP()
+	}
+	h := fnv.New32a()
+	h.Write([]byte(canary.Name + ":" + client))
+	if int(h.Sum32()%100) < percent {
+		return CanaryNew
+	}
+	return CanaryStable
+}
+
+// SetPercent changes the percent of the clients served by the canary name on this instance
+func (s *Canaries) SetPercent(name string, percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	route, ok := s.routes[name]
+	if !ok {
+		return fmt.Errorf("canary '%s' not found", name)
+	}
+	route.percent = percent
+	logger.Info("Canary percent changed", "name", name, "percent", percent)
+	return nil
+}
+
+// Stats returns the canary routes sorted by name with the requests of both variants
+func (s *Canaries) Stats() []CanaryStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]CanaryStats, 0, len(s.routes))
+	for name, route := range s.routes {
+		result = append(result, CanaryStats{
+			Name:    name,
+			Percent: route.percent,
+			Stable:  route.stable.report(),
+			Canary:  route.new.report(),
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (v CanaryVariantStats) report() CanaryVariantStats {
+	v.AvgLatency = "0s"
+	if v.Requests > 0 {
+		v.ErrorRate = float64(v.Errors) / float64(v.Requests)
+		v.AvgLatency = (v.latency / time.Duration(v.Requests)).String()
+	}
+	v.MaxLatency = v.max.String()
+	return v
+}
+
+// RegisterAdminRoutes serves the canaries on router: the report, and the change of the
+// percent of a canary on this instance (PUT with {"name": "GET /api/orders/:id", "percent": 25})
+func (s *Canaries) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/canaries", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(s.Stats()))
+	})
+
+	router.Put("/canaries", func(c *fiber.Ctx) error {
+		var body struct {
+			Name    string `json:"name"`
+			Percent *int   `json:"percent"`
+		}
+		if err := c.BodyParser(&body); err != nil || body.Name == "" || body.Percent == nil {
+			return fiber.NewError(fiber.StatusBadRequest, "name and percent are required")
+		}
+
+		s.mu.RLock()
+		_, exists := s.routes[body.Name]
+		s.mu.RUnlock()
+		if !exists {
+			return fiber.NewError(fiber.StatusNotFound, "Canary not found")
+		}
+
+		if err := s.SetPercent(body.Name, *body.Percent); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		for _, stats := range s.Stats() {
+			if stats.Name == body.Name {
+				return out.Send(c, out.SuccessData(stats))
+			}
+		}
+		return nil
+	})
+}
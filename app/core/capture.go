@@ -0,0 +1,466 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/mask"
+	"github.com/webcore-go/webcore/port"
+)
+
+// CapturedExchange is a request and its response captured by Capture, with their personal data
+// and credentials masked
+type CapturedExchange struct {
+	ID       string            `json:"id"`
+	Time     time.Time         `json:"time"`
+	Method   string            `json:"method"`
+	URI      string            `json:"uri"` // path and query
+	Headers  map[string]string `json:"headers"`
+	Body     string            `json:"body,omitempty"`
+	Omitted  bool              `json:"omitted,omitempty"` // the request body was binary or larger than app.capture.max_body, the exchange is not replayed
+	Status   int               `json:"status"`
+	Response string            `json:"response,omitempty"` // empty when binary, streamed or larger than app.capture.max_body
+	Duration time.Duration     `json:"duration"`
+}
+
+// ReplayOptions are the options of Capture.Replay
+type ReplayOptions struct {
+	Target  string            // base URL of the environment receiving the requests
+	From    string            // date (2006-01-02) of the first replayed captures, every capture when empty
+	Limit   int               // exchanges replayed at most, every one when 0
+	Headers map[string]string // set on every request (ex: the credentials of the target)
+	Ignore  []string          // JSON fields whose values are not compared (ex: "id", "created_at")
+	Timeout time.Duration     // of each request
+}
+
+// ReplayResult is the outcome of the replay of a captured exchange
+type ReplayResult struct {
+	Exchange CapturedExchange
+	Status   int
+	Response string
+	Err      error
+	Same     bool // same status, and same response when the captured one was stored
+}
+
+// Capture stores a sample of the requests and their responses in the object storage, masked
+// with every policy of app.masking (and the credentials without it), and Replay sends them
+// again to another environment to compare the responses, to check a refactor against real
+// traffic.
+type Capture struct {
+	mu      sync.RWMutex
+	config  config.CaptureConfig
+	masking *Masking
+	clock   helper.Clock
+	storage port.IObjectStorage
+}
+
+func NewCapture(cfg config.CaptureConfig, apiKeyHeader string, masking *Masking, clock helper.Clock) *Capture {
+	if apiKeyHeader != "" {
+		cfg.RedactHeaders = append(cfg.RedactHeaders, apiKeyHeader)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = "captures/"
+	}
+	if cfg.MaxBody <= 0 {
+		cfg.MaxBody = 64 * 1024
+	}
+	return &Capture{config: cfg, masking: masking, clock: clock}
+}
+
+// SetStorage sets the object storage holding the captures
+func (s *Capture) SetStorage(storage port.IObjectStorage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storage = storage
+}
+
+func (s *Capture) getStorage() (port.IObjectStorage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.storage == nil {
+		return nil, fmt.Errorf("captures need an object storage, set storage.driver")
+	}
+	return s.storage, nil
+}
+
+// policy returns the policy masking the captures: every policy of app.masking, the credentials
+// only when it is disabled
+func (s *Capture) policy() *mask.Policy {
+	if policy := s.masking.Merged(); policy != nil {
+		return policy
+	}
+	return mask.Merge()
+}
+
+// Middleware captures the sampled requests of the paths of app.capture.paths, except the ones
+// of the paths of exclude (ex: the admin API)
+func (s *Capture) Middleware(exclude ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !s.sampled(c.Path(), exclude) {
+			return c.Next()
+		}
+
+		start := s.clock.Now()
+		exchange := s.request(c)
+		err := c.Next()
+
+		exchange.Status = c.Response().StatusCode()
+		if err != nil {
+			// the error handler writes the response after the middlewares
+			exchange.Status = fiber.StatusInternalServerError
+			if e, ok := err.(*fiber.Error); ok {
+				exchange.Status = e.Code
+			}
+		} else if !c.Response().IsBodyStream() {
+			exchange.Response, _ = s.body(c.GetRespHeader(fiber.HeaderContentType), c.Response().Body())
+		}
+		exchange.Time = start
+		exchange.Duration = s.clock.Since(start)
+
+		go s.save(exchange)
+		return err
+	}
+}
+
+// sampled tells whether the request of path is captured
+func (s *Capture) sampled(path string, exclude []string) bool {
+	for _, prefix := range exclude {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return false
+		}
+	}
+	if len(s.config.Paths) > 0 && !slices.ContainsFunc(s.config.Paths, func(prefix string) bool {
+		return strings.HasPrefix(path, prefix)
+	}) {
+		return false
+	}
+	return s.config.Sample > 0 && rand.Float64() < s.config.Sample
+}
+
+// request copies the request of c, masked, before the handlers change it
+func (s *Capture) request(c *fiber.Ctx) CapturedExchange {
+	policy := s.policy()
+	exchange := CapturedExchange{Method: c.Method(), Headers: map[string]string{}}
+
+	exchange.URI = c.Path()
+	if query := string(c.Request().URI().QueryString()); query != "" {
+		exchange.URI += "?" + maskQuery(policy, query)
+	}
+
+	redacted := append([]string{fiber.HeaderAuthorization, fiber.HeaderCookie, fiber.HeaderProxyAuthorization}, s.config.RedactHeaders...)
+	for key, value := range c.Request().Header.All() {
+		name := string(key)
+		switch {
+		case slices.ContainsFunc(redacted, func(header string) bool { return strings.EqualFold(header, name) }),
+			policy.Field(name, ""):
+			exchange.Headers[name] = config.RedactedValue
+		default:
+			exchange.Headers[name] = string(value)
+		}
+	}
+
+	body, ok := s.body(c.Get(fiber.HeaderContentType), c.Body())
+	exchange.Body, exchange.Omitted = body, !ok
+	return exchange
+}
+
+// body returns raw masked according to its content type, false when it is binary or too large
+func (s *Capture) body(contentType string, raw []byte) (string, bool) {
+	if len(raw) == 0 {
+		return "", true
+	}
+	if len(raw) > s.config.MaxBody {
+		return "", false
+	}
+
+	policy := s.policy()
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch {
+	case strings.HasSuffix(mediaType, "json"):
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return policy.String(string(raw)), true
+		}
+		masked, err := helper.JSONMarshal(policy.Value(value))
+		if err != nil {
+			return "", false
+		}
+		return string(masked), true
+	case mediaType == fiber.MIMEApplicationForm:
+		return maskQuery(policy, string(raw)), true
+	case strings.HasPrefix(mediaType, "text/"), strings.HasSuffix(mediaType, "xml"), mediaType == "":
+		if mediaType == "" && !isText(raw) {
+			return "", false
+		}
+		return policy.String(string(raw)), true
+	}
+	return "", false
+}
+
+// maskQuery masks the values of the masked keys of a query string, and the patterns in the others
+func maskQuery(policy *mask.Policy, query string) string {
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return policy.String(query)
+	}
+	for key, list := range values {
+		for i, value := range list {
+			if policy.Field(key, "") {
+				list[i] = config.RedactedValue
+			} else {
+				list[i] = policy.String(value)
+			}
+		}
+	}
+	return values.Encode()
+}
+
+// isText tells whether raw looks like text, for the bodies without content type
+func isText(raw []byte) bool {
+	return !bytes.ContainsRune(raw, 0) && strings.ToValidUTF8(string(raw), "") == string(raw)
+}
+
+// save writes exchange to the object storage under <prefix><date>/, named by time
+func (s *Capture) save(exchange CapturedExchange) {
+	storage, err := s.getStorage()
+	if err != nil {
+		logger.Warn("Capture not saved", "error", err)
+		return
+	}
+
+	if exchange.ID, err = helper.GenerateUUID(); err != nil {
+		logger.Warn("Capture not saved", "error", err)
+		return
+	}
+	raw, err := helper.JSONMarshal(exchange)
+	if err != nil {
+		logger.Warn("Capture not saved", "error", err)
+		return
+	}
+
+	key := fmt.Sprintf("%s%s/%s-%s.json", s.config.Prefix, exchange.Time.UTC().Format(time.DateOnly),
+		exchange.Time.UTC().Format("150405.000000"), exchange.ID)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := storage.Put(ctx, key, bytes.NewReader(raw), port.PutOptions{ContentType: fiber.MIMEApplicationJSON, Size: int64(len(raw))}); err != nil {
+		logger.Warn("Capture not saved", "key", key, "error", err)
+	}
+}
+
+// List returns the captured exchanges from the date from (2006-01-02, every date when empty) in
+// the order they were captured, up to limit (0 for all)
+func (s *Capture) List(ctx context.Context, from string, limit int) ([]CapturedExchange, error) {
+	storage, err := s.getStorage()
+	if err != nil {
+		return nil, err
+	}
+	if from != "" {
+		if _, err := time.Parse(time.DateOnly, from); err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected 2006-01-02", from)
+		}
+	}
+
+	objects, err := storage.List(ctx, s.config.Prefix, 0)
+	if err != nil {
+		return nil, err
+	}
+	slices.SortFunc(objects, func(a, b port.ObjectInfo) int { return strings.Compare(a.Key, b.Key) })
+
+	result := []CapturedExchange{}
+	for _, object := range objects {
+		if from != "" && strings.TrimPrefix(object.Key, s.config.Prefix) < from {
+			continue
+		}
+		if limit > 0 && len(result) >= limit {
+			break
+		}
+
+		reader, _, err := storage.Get(ctx, object.Key)
+		if err != nil {
+			return nil, err
+		}
+		var exchange CapturedExchange
+		err = json.NewDecoder(reader).Decode(&exchange)
+		reader.Close()
+		if err != nil {
+			return nil, fmt.Errorf("capture %s: %v", object.Key, err)
+		}
+		result = append(result, exchange)
+	}
+	return result, nil
+}
+
+// Replay sends the captured exchanges to opts.Target one by one and compares the responses,
+// masked like the captured ones. The exchanges whose request body was omitted are skipped.
+func (s *Capture) Replay(ctx context.Context, opts ReplayOptions) ([]ReplayResult, error) {
+	target, err := url.Parse(strings.TrimSuffix(opts.Target, "/"))
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("invalid target %q", opts.Target)
+	}
+	exchanges, err := s.List(ctx, opts.From, opts.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{Timeout: opts.Timeout}
+	results := []ReplayResult{}
+	for _, exchange := range exchanges {
+		if exchange.Omitted {
+			continue
+		}
+		if ctx.Err() != nil {
+			return results, ctx.Err()
+		}
+
+		result := ReplayResult{Exchange: exchange}
+		result.Status, result.Response, result.Err = s.send(ctx, client, target.String(), exchange, opts.Headers)
+		if result.Err == nil {
+			result.Same = result.Status == exchange.Status &&
+				(exchange.Response == "" || sameResponse(exchange.Response, result.Response, opts.Ignore))
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// send sends the request of exchange to target and returns the masked response
+func (s *Capture) send(ctx context.Context, client *http.Client, target string, exchange CapturedExchange, headers map[string]string) (int, string, error) {
+	req, err := http.NewRequestWithContext(ctx, exchange.Method, target+exchange.URI, strings.NewReader(exchange.Body))
+	if err != nil {
+		return 0, "", err
+	}
+	for key, value := range exchange.Headers {
+		switch strings.ToLower(key) {
+		case "host", "content-length", "connection", "accept-encoding":
+			continue
+		}
+		if value != config.RedactedValue {
+			req.Header.Set(key, value)
+		}
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(io.LimitReader(resp.Body, int64(s.config.MaxBody)+1))
+	if err != nil {
+		return resp.StatusCode, "", err
+	}
+	response, _ := s.body(resp.Header.Get(fiber.HeaderContentType), raw)
+	return resp.StatusCode, response, nil
+}
+
+// sameResponse compares two responses, as JSON without the fields of ignore when both decode
+func sameResponse(captured string, replayed string, ignore []string) bool {
+	var a, b any
+	if json.Unmarshal([]byte(captured), &a) != nil || json.Unmarshal([]byte(replayed), &b) != nil {
+		return captured == replayed
+	}
+	return reflect.DeepEqual(withoutFields(a, ignore), withoutFields(b, ignore))
+}
+
+// withoutFields removes the keys of ignore from the objects of v, at any depth
+func withoutFields(v any, ignore []string) any {
+	switch value := v.(type) {
+	case map[string]any:
+		for key, item := range value {
+			if slices.Contains(ignore, key) {
+				delete(value, key)
+			} else {
+				value[key] = withoutFields(item, ignore)
+			}
+		}
+	case []any:
+		for i, item := range value {
+			value[i] = withoutFields(item, ignore)
+		}
+	}
+	return v
+}
+
+func replayCommand() *Command {
+	var opts ReplayOptions
+	opts.Headers = map[string]string{}
+	var ignore string
+	return &Command{
+		Name:        "replay",
+		Description: "Send the requests captured by app.capture to another environment and compare the responses",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&opts.Target, "target", "", "base URL of the environment receiving the requests (required)")
+			fs.StringVar(&opts.From, "from", "", "date (2006-01-02) of the first replayed captures, all of them by default")
+			fs.IntVar(&opts.Limit, "limit", 0, "exchanges replayed at most, all of them by default")
+			fs.StringVar(&ignore, "ignore", "", "comma separated JSON fields not compared (ex: id,created_at)")
+			fs.DurationVar(&opts.Timeout, "timeout", 10*time.Second, "timeout of each request")
+			fs.Func("header", "header set on every request, repeatable (ex: \"Authorization: Bearer ...\")", func(value string) error {
+				key, value, ok := strings.Cut(value, ":")
+				if !ok || strings.TrimSpace(key) == "" {
+					return fmt.Errorf("expected \"Name: value\"")
+				}
+				opts.Headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+				return nil
+			})
+		},
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			if opts.Target == "" {
+				return fmt.Errorf("-target is required")
+			}
+			if ignore != "" {
+				opts.Ignore = strings.Split(ignore, ",")
+			}
+
+			results, err := app.Capture.Replay(ctx, opts)
+			if err != nil {
+				return err
+			}
+
+			w := tabwriter.NewWriter(CommandOutput(ctx), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "METHOD\tURI\tCAPTURED\tREPLAYED\tRESULT")
+			differ := 0
+			for _, result := range results {
+				outcome := "same"
+				switch {
+				case result.Err != nil:
+					outcome = result.Err.Error()
+				case result.Status != result.Exchange.Status:
+					outcome = "status differs"
+				case !result.Same:
+					outcome = "response differs"
+				}
+				if !result.Same {
+					differ++
+				}
+				fmt.Fprintf(w, "%s\t%s\t%d\t%d\t%s\n", result.Exchange.Method, result.Exchange.URI, result.Exchange.Status, result.Status, outcome)
+			}
+			w.Flush()
+
+			fmt.Fprintf(CommandOutput(ctx), "\n%d exchanges replayed, %d differ\n", len(results), differ)
+			if differ > 0 {
+				return fmt.Errorf("%d of %d exchanges differ", differ, len(results))
+			}
+			return nil
+		},
+	}
+}
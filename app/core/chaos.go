@@ -0,0 +1,319 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+var (
+	// ErrChaosFault is returned by the calls failed by a fault of Chaos
+	ErrChaosFault = errors.New("chaos: injected fault")
+	// ErrChaosDropped is returned by the calls whose connection was dropped by a fault of Chaos
+	ErrChaosDropped = errors.New("chaos: connection dropped")
+)
+
+// ChaosFaultStats is a fault of Chaos with the calls it disturbed
+type ChaosFaultStats struct {
+	Name      string  `json:"name"`
+	Target    string  `json:"target"`
+	Path      string  `json:"path,omitempty"`
+	Latency   string  `json:"latency"`
+	Jitter    string  `json:"jitter"`
+	ErrorRate float64 `json:"error_rate"`
+	Status    int     `json:"status,omitempty"`
+	DropRate  float64 `json:"drop_rate"`
+	Calls     int64   `json:"calls"`
+	Failed    int64   `json:"failed"`
+	Dropped   int64   `json:"dropped"`
+}
+
+type chaosFault struct {
+	config  config.ChaosFaultConfig
+	calls   int64
+	failed  int64
+	dropped int64
+}
+
+// Chaos injects latency, errors and dropped connections into the HTTP routes and the guarded
+// libraries, to check how the retries, the circuit breakers and the clients behave. The faults
+// of app.chaos.faults are changed at runtime on the instance with SetFault. Chaos only runs in
+// the environments of app.chaos.environments.
+type Chaos struct {
+	mu      sync.RWMutex
+	enabled bool
+	clock   helper.Clock
+	faults  map[string]*chaosFault
+}
+
+func NewChaos(cfg config.ChaosConfig, environment string, clock helper.Clock) *Chaos {
+	s := &Chaos{
+		enabled: cfg.Enabled && slices.Contains(cfg.Environments, environment),
+		clock:   clock,
+		faults:  make(map[string]*chaosFault),
+	}
+	if cfg.Enabled && !s.enabled {
+		logger.Warn("Chaos is refused in this environment", "environment", environment, "allowed", cfg.Environments)
+	}
+	for _, fault := range cfg.Faults {
+		if err := s.SetFault(fault); err != nil {
+			logger.Warn("Chaos fault ignored", "name", fault.Name, "error", err)
+		}
+	}
+	return s
+}
+
+// Enabled tells whether faults are injected
+func (s *Chaos) Enabled() bool {
+	return s.enabled
+}
+
+// SetFault adds or replaces the fault of the same name on this instance
+func (s *Chaos) SetFault(fault config.ChaosFaultConfig) error {
+	switch {
+	case fault.Name == "":
+		return fmt.Errorf("chaos fault name is required")
+	case fault.Target == "":
+		return fmt.Errorf("chaos fault target is required")
+	case fault.ErrorRate < 0 || fault.ErrorRate > 1 || fault.DropRate < 0 || fault.DropRate > 1:
+		return fmt.Errorf("chaos fault rates must be between 0 and 1")
+	case fault.Latency < 0 || fault.Jitter < 0:
+		return fmt.Errorf("chaos fault latency must be positive")
+	}
+	if fault.Status == 0 {
+		fault.Status = fiber.StatusServiceUnavailable
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults[fault.Name] = &chaosFault{config: fault}
+	if s.enabled {
+		logger.Warn("Chaos fault set", "name", fault.Name, "target", fault.Target)
+	}
+	return nil
+}
+
+// DeleteFault removes a fault on this instance
+func (s *Chaos) DeleteFault(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.faults[name]; !ok {
+		return fmt.Errorf("chaos fault '%s' not found", name)
+	}
+	delete(s.faults, name)
+	logger.Info("Chaos fault deleted", "name", name)
+	return nil
+}
+
+// Stats returns the faults sorted by name with the calls they disturbed
+func (s *Chaos) Stats() []ChaosFaultStats {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]ChaosFaultStats, 0, len(s.faults))
+	for _, fault := range s.faults {
+		result = append(result, ChaosFaultStats{
+			Name:      fault.config.Name,
+			Target:    fault.config.Target,
+			Path:      fault.config.Path,
+			Latency:   fault.config.Latency.String(),
+			Jitter:    fault.config.Jitter.String(),
+			ErrorRate: fault.config.ErrorRate,
+			Status:    fault.config.Status,
+			DropRate:  fault.config.DropRate,
+			Calls:     fault.calls,
+			Failed:    fault.failed,
+			Dropped:   fault.dropped,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+// chaosOutcome is what the faults decided for a call
+type chaosOutcome struct {
+	latency time.Duration
+	fail    *chaosFault // the fault failing the call, nil when it goes on
+	drop    bool
+}
+
+// decide rolls the faults of target matching path for a call
+func (s *Chaos) decide(target string, path string) chaosOutcome {
+	outcome := chaosOutcome{}
+	if !s.enabled {
+		return outcome
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, fault := range s.faults {
+		if !chaosMatch(fault.config, target, path) {
+			continue
+		}
+		fault.calls++
+		outcome.latency += fault.config.Latency
+		if fault.config.Jitter > 0 {
+			outcome.latency += rand.N(fault.config.Jitter)
+		}
+		if outcome.fail != nil {
+			continue
+		}
+		if fault.config.DropRate > 0 && rand.Float64() < fault.config.DropRate {
+			fault.dropped++
+			outcome.fail, outcome.drop = fault, true
+		} else if fault.config.ErrorRate > 0 && rand.Float64() < fault.config.ErrorRate {
+			fault.failed++
+			outcome.fail = fault
+		}
+	}
+	return outcome
+}
+
+// chaosMatch tells whether fault disturbs the calls of target: its own name, or a name of its
+// family (ex: "database" for "database:acme"), on a path starting with the one of the fault
+func chaosMatch(fault config.ChaosFaultConfig, target string, path string) bool {
+	if fault.Target != target && !strings.HasPrefix(target, fault.Target+":") {
+		return false
+	}
+	return strings.HasPrefix(path, fault.Path)
+}
+
+// wait sleeps the latency of a call, it stops when ctx is cancelled
+func (s *Chaos) wait(ctx context.Context, latency time.Duration) error {
+	if latency <= 0 {
+		return nil
+	}
+	select {
+	case <-s.clock.After(latency):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Middleware disturbs the requests with the "http" faults, except the ones of the paths of
+// exclude (ex: the admin API, which removes the faults). A dropped request is closed without
+// response.
+func (s *Chaos) Middleware(exclude ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, prefix := range exclude {
+			if prefix != "" && strings.HasPrefix(c.Path(), prefix) {
+				return c.Next()
+			}
+		}
+
+		outcome := s.decide("http", c.Path())
+		if err := s.wait(c.UserContext(), outcome.latency); err != nil {
+			return err
+		}
+		switch {
+		case outcome.drop:
+			c.Context().HijackSetNoResponse(true)
+			c.Context().Hijack(func(net.Conn) {})
+			return nil
+		case outcome.fail != nil:
+			return fiber.NewError(outcome.fail.config.Status, "Chaos fault injected")
+		}
+		return c.Next()
+	}
+}
+
+// Target returns the guard disturbing the calls of a library named name with its faults, to
+// pass to GuardDatabase, GuardPubSub, GuardKafka or GuardHubBroker:
+//
+//	pubsub = core.GuardPubSub(pubsub, m.context.Chaos.Target("pubsub"))
+func (s *Chaos) Target(name string) *ChaosTarget {
+	return &ChaosTarget{chaos: s, name: name}
+}
+
+// ChaosTarget is the Guard of Chaos.Target
+type ChaosTarget struct {
+	chaos *Chaos
+	name  string
+}
+
+// Execute calls fn after the latency of the faults, unless they fail or drop the call
+func (t *ChaosTarget) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	outcome := t.chaos.decide(t.name, "")
+	if err := t.chaos.wait(ctx, outcome.latency); err != nil {
+		return err
+	}
+	switch {
+	case outcome.drop:
+		return fmt.Errorf("%s: %w", t.name, ErrChaosDropped)
+	case outcome.fail != nil:
+		return fmt.Errorf("%s: %w", t.name, ErrChaosFault)
+	}
+	return fn(ctx)
+}
+
+// RegisterAdminRoutes serves the faults on router: the list with their counters, the change of
+// a fault on this instance (PUT with {"target": "database", "latency": "200ms", "error_rate": 0.1})
+// and its removal
+func (s *Chaos) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/chaos", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(fiber.Map{"enabled": s.enabled, "faults": s.Stats()}))
+	})
+
+	router.Put("/chaos/:name", func(c *fiber.Ctx) error {
+		var body struct {
+			Target    string  `json:"target"`
+			Path      string  `json:"path"`
+			Latency   string  `json:"latency"`
+			Jitter    string  `json:"jitter"`
+			ErrorRate float64 `json:"error_rate"`
+			Status    int     `json:"status"`
+			DropRate  float64 `json:"drop_rate"`
+		}
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid chaos fault")
+		}
+
+		fault := config.ChaosFaultConfig{
+			Name:      c.Params("name"),
+			Target:    body.Target,
+			Path:      body.Path,
+			ErrorRate: body.ErrorRate,
+			Status:    body.Status,
+			DropRate:  body.DropRate,
+		}
+		var err error
+		if body.Latency != "" {
+			if fault.Latency, err = time.ParseDuration(body.Latency); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid chaos fault latency")
+			}
+		}
+		if body.Jitter != "" {
+			if fault.Jitter, err = time.ParseDuration(body.Jitter); err != nil {
+				return fiber.NewError(fiber.StatusBadRequest, "Invalid chaos fault jitter")
+			}
+		}
+
+		if err := s.SetFault(fault); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return out.Send(c, out.SuccessData(fiber.Map{"enabled": s.enabled, "faults": s.Stats()}))
+	})
+
+	router.Delete("/chaos/:name", func(c *fiber.Ctx) error {
+		if err := s.DeleteFault(c.Params("name")); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "Chaos fault not found")
+		}
+		return out.Send(c, out.SuccessMessage("Chaos fault deleted"))
+	})
+}
@@ -0,0 +1,192 @@
+package core
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Kinds of the changes pushed to the frontends
+const (
+	ClientChangeFlag    = "flag"
+	ClientChangeSetting = "setting"
+)
+
+// ClientState is what a frontend reads of the application: the exposed flags evaluated for
+// its user and the settings of its tenant
+type ClientState struct {
+	Flags    map[string]bool `json:"flags"`
+	Settings map[string]any  `json:"settings"`
+}
+
+// ClientChange is pushed on the hub channel of app.client when an exposed flag or setting changes
+type ClientChange struct {
+	Kind  string `json:"kind"` // ClientChangeFlag or ClientChangeSetting
+	Key   string `json:"key"`
+	Value any    `json:"value"` // the flag evaluated for the user, or the setting of the tenant, nil when deleted
+}
+
+// ClientPush exposes flags and settings to the frontends: they read them once from the
+// app.client.path endpoint, then subscribe to the app.client.channel hub channel to receive the
+// changes evaluated for their user and tenant, without polling. Every instance pushes the
+// changes it observes to its own connections: the flags it reloads, the settings set on it.
+type ClientPush struct {
+	mu       sync.RWMutex
+	config   config.ClientConfig
+	flags    *Flags
+	hub      *Hub
+	settings map[string]map[string]any // of app.client.settings and set at runtime, by tenant ID, "" for every tenant
+}
+
+func NewClientPush(cfg config.ClientConfig, flags *Flags, hub *Hub, bus *EventBus) *ClientPush {
+	p := &ClientPush{
+		config:   cfg,
+		flags:    flags,
+		hub:      hub,
+		settings: map[string]map[string]any{"": maps.Clone(cfg.Settings)},
+	}
+	if p.settings[""] == nil {
+		p.settings[""] = map[string]any{}
+	}
+
+	if cfg.Enabled {
+		bus.Subscribe(EventFlagChanged, func(data any) {
+			if change, ok := data.(FlagChange); ok && p.exposed(change.Key) {
+				p.pushFlag(change.Key)
+			}
+		})
+	}
+	return p
+}
+
+// exposed tells whether the flag key is listed in app.client.flags
+func (p *ClientPush) exposed(key string) bool {
+	for _, pattern := range p.config.Flags {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(key, prefix) {
+				return true
+			}
+		} else if key == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// State returns the flags and settings of the user and tenant of ctx
+func (p *ClientPush) State(ctx context.Context) ClientState {
+	state := ClientState{Flags: map[string]bool{}, Settings: map[string]any{}}
+
+	target := CurrentFlagTarget(ctx)
+	for _, flag := range p.flags.List() {
+		if p.exposed(flag.Key) {
+			state.Flags[flag.Key] = p.flags.Evaluate(flag.Key, target)
+		}
+	}
+
+	tenant := helper.Tenant(ctx)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	for key := range p.settings[""] {
+		state.Settings[key] = p.setting(tenant, key)
+	}
+	if tenant != nil {
+		for key := range tenant.Settings {
+			if key, ok := strings.CutPrefix(key, "client."); ok {
+				state.Settings[key] = p.setting(tenant, key)
+			}
+		}
+		for key := range p.settings[tenant.ID] {
+			state.Settings[key] = p.setting(tenant, key)
+		}
+	}
+	return state
+}
+
+// setting returns the value of key for tenant: the one set at runtime for the tenant, then its
+// "client.<key>" setting, then the value for every tenant. p.mu must be held.
+func (p *ClientPush) setting(tenant *port.Tenant, key string) any {
+	if tenant != nil {
+		if value, ok := p.settings[tenant.ID][key]; ok {
+			return value
+		}
+		if value, ok := tenant.Settings["client."+key]; ok {
+			return value
+		}
+	}
+	return p.settings[""][key]
+}
+
+// Set changes the setting key on this instance, for the clients of tenant or for every client
+// when tenant is empty, and pushes it to them. A nil value deletes it.
+func (p *ClientPush) Set(tenant string, key string, value any) {
+	p.mu.Lock()
+	if value == nil {
+		delete(p.settings[tenant], key)
+	} else {
+		if p.settings[tenant] == nil {
+			p.settings[tenant] = map[string]any{}
+		}
+		p.settings[tenant][key] = value
+	}
+	p.mu.Unlock()
+
+	logger.Info("Client setting changed", "tenant", tenant, "setting", key)
+	p.hub.Push(p.config.Channel, func(client *HubClient) any {
+		if tenant != "" && client.Tenant != tenant {
+			return nil
+		}
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return ClientChange{Kind: ClientChangeSetting, Key: key, Value: p.setting(client.tenant, key)}
+	})
+}
+
+// pushFlag sends the flag key evaluated for their user to the subscribed clients
+func (p *ClientPush) pushFlag(key string) {
+	_, exists := p.flags.Get(key)
+	p.hub.Push(p.config.Channel, func(client *HubClient) any {
+		change := ClientChange{Kind: ClientChangeFlag, Key: key}
+		if exists {
+			change.Value = p.flags.Evaluate(key, client.target)
+		}
+		return change
+	})
+}
+
+// Handler serves the state of the requester, read by the frontends before they subscribe
+func (p *ClientPush) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if CurrentFlagTarget(ctx).Principal == "" {
+			ctx = WithFlagTarget(ctx, requestFlagTarget(c, ""))
+		}
+		return out.Send(c, out.SuccessData(p.State(ctx)))
+	}
+}
+
+// RegisterAdminRoutes serves the change of a setting on this instance on router (PUT with
+// {"tenant": "acme", "key": "banner", "value": "Maintenance at 22:00"}, a null value deletes it)
+func (p *ClientPush) RegisterAdminRoutes(router fiber.Router) {
+	router.Put("/client", func(c *fiber.Ctx) error {
+		var body struct {
+			Tenant string `json:"tenant"`
+			Key    string `json:"key"`
+			Value  any    `json:"value"`
+		}
+		if err := c.BodyParser(&body); err != nil || body.Key == "" {
+			return fiber.NewError(fiber.StatusBadRequest, "key is required")
+		}
+
+		p.Set(body.Tenant, body.Key, body.Value)
+		return out.Send(c, out.SuccessMessage("Client setting changed"))
+	})
+}
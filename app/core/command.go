@@ -0,0 +1,570 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Command is a subcommand of the application binary (ex: ./app migrate up). It runs once the
+// libraries and the modules are loaded (unless NoSetup), with the same configuration as the
+// server, but without the HTTP listener nor the background workers.
+type Command struct {
+	Name        string
+	Usage       string // arguments after the flags (ex: "[module...]")
+	Description string
+	NoSetup     bool // runs before the libraries and the modules are loaded (ex: doctor)
+
+	// Flags declares the flags of the command on fs
+	Flags func(fs *flag.FlagSet)
+
+	// Run executes the command with the arguments left after the flags, ctx is canceled on
+	// SIGINT or SIGTERM
+	Run func(ctx context.Context, app *AppContext, args []string) error
+}
+
+// ModuleCommands is implemented by modules adding commands to the binary
+type ModuleCommands interface {
+	Module
+
+	// Commands returns the commands of the module, called before Init
+	Commands() []*Command
+}
+
+// ModuleSeeder is implemented by modules inserting data with the seed command
+type ModuleSeeder interface {
+	Module
+
+	// Seed inserts the data of the module, it is called after Init
+	Seed(ctx context.Context) error
+}
+
+// ModuleConsumer is implemented by modules consuming messages with the consume command
+type ModuleConsumer interface {
+	Module
+
+	// Consume receives the messages of the module until ctx is done
+	Consume(ctx context.Context) error
+}
+
+// DefaultCommand runs when the binary is started without a command
+const DefaultCommand = "serve"
+
+type commandOutputKey struct{}
+
+// WithCommandOutput returns a copy of ctx where the command writes its output to w
+func WithCommandOutput(ctx context.Context, w io.Writer) context.Context {
+	return context.WithValue(ctx, commandOutputKey{}, w)
+}
+
+// CommandOutput returns where a command writes its output: the console session running it,
+// the standard output otherwise
+func CommandOutput(ctx context.Context) io.Writer {
+	if w, ok := ctx.Value(commandOutputKey{}).(io.Writer); ok {
+		return w
+	}
+	return os.Stdout
+}
+
+// RegisterCommand adds a command to the binary, it replaces the command of the same name
+func (a *App) RegisterCommand(cmd *Command) {
+	a.commandsMu.Lock()
+	defer a.commandsMu.Unlock()
+
+	if a.commands == nil {
+		a.commands = map[string]*Command{}
+	}
+	a.commands[cmd.Name] = cmd
+}
+
+// Run executes the command named by the first argument (serve by default) and stops the
+// application, returning the failure of the command joined with those of the stop. It is
+// called from main:
+//
+//	app := core.NewApp(ctx, cfg, deps.APP_LIBRARIES, deps.APP_PACKAGES)
+//	if err := app.Run(os.Args[1:]); err != nil {
+//		log.Fatal(err)
+//	}
+func (a *App) Run(args []string) (err error) {
+	defer func() {
+		err = errors.Join(err, a.Stop())
+	}()
+
+	name := DefaultCommand
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	commands := a.Commands()
+	if name == "help" {
+		a.printUsage(commands)
+		return nil
+	}
+
+	cmd, ok := commands[name]
+	if !ok {
+		a.printUsage(commands)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	fs := flag.NewFlagSet(a.Context.Config.App.Name+" "+cmd.Name, flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags] %s\n\n%s\n", a.Context.Config.App.Name, cmd.Name, cmd.Usage, cmd.Description)
+		fs.PrintDefaults()
+	}
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	ctx, cancel := signal.NotifyContext(a.Context.Context, os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	if cmd.Name == DefaultCommand && a.Context.Config.App.StartupCheck {
+		if err := a.startupCheck(ctx); err != nil {
+			return err
+		}
+	}
+
+	if !cmd.NoSetup {
+		if err := a.setup(); err != nil {
+			return err
+		}
+	}
+
+	return cmd.Run(ctx, a.Context, fs.Args())
+}
+
+// Commands returns the built-in commands, the commands of the modules and the registered
+// ones, by name
+func (a *App) Commands() map[string]*Command {
+	commands := map[string]*Command{}
+	for _, cmd := range a.builtinCommands() {
+		commands[cmd.Name] = cmd
+	}
+
+	names := a.ModuleManager.ListModules()
+	slices.Sort(names)
+	for _, name := range names {
+		module, err := a.ModuleManager.GetModule(name)
+		if err != nil {
+			continue
+		}
+		if provider, ok := module.(ModuleCommands); ok {
+			for _, cmd := range provider.Commands() {
+				commands[cmd.Name] = cmd
+			}
+		}
+	}
+
+	a.commandsMu.Lock()
+	defer a.commandsMu.Unlock()
+	for name, cmd := range a.commands {
+		commands[name] = cmd
+	}
+
+	return commands
+}
+
+func (a *App) printUsage(commands map[string]*Command) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(os.Stderr, "Usage: %s <command> [flags] [args]\n\nCommands:\n", a.Context.Config.App.Name)
+	w := tabwriter.NewWriter(os.Stderr, 0, 0, 2, ' ', 0)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %s\t%s\n", name, commands[name].Description)
+	}
+	w.Flush()
+	fmt.Fprintf(os.Stderr, "\nRun '%s <command> -h' for the flags of a command.\n", a.Context.Config.App.Name)
+}
+
+func (a *App) builtinCommands() []*Command {
+	return []*Command{
+		{
+			Name:        "serve",
+			Description: "Start the HTTP server and the background workers",
+			Run:         a.serve,
+		},
+		migrateCommand(),
+		{
+			Name:        "routes",
+			Description: "List the HTTP routes",
+			Run:         printRoutes,
+		},
+		{
+			Name:        "seed",
+			Usage:       "[module...]",
+			Description: "Insert the data of the modules, all of them by default",
+			Run:         a.seed,
+		},
+		{
+			Name:        "consume",
+			Usage:       "[module...]",
+			Description: "Consume the messages of the modules and the pub/sub receivers until stopped",
+			Run:         a.consume,
+		},
+		jobsRunCommand(),
+		sdkCommand(),
+		doctorCommand(),
+		consoleCommand(),
+		replayCommand(),
+	}
+}
+
+// serve listens until ctx is canceled, then the application is stopped by Run
+func (a *App) serve(ctx context.Context, app *AppContext, args []string) error {
+	if err := a.startBackground(); err != nil {
+		return err
+	}
+
+	addr := fmt.Sprintf("%s:%d", app.Config.Server.Host, app.Config.Server.Port)
+	logger.Info("Server starting", "address", addr, "tls", app.Config.Server.TLSCert != "" && app.Config.Server.TLSKey != "")
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- a.listen(addr)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+		logger.Info("Server stopping")
+		return nil
+	}
+}
+
+func migrateCommand() *Command {
+	var dir, service, table string
+	var dryRun bool
+	return &Command{
+		Name:  "migrate",
+		Usage: "[status | up [version] | down [steps] | baseline <version>]",
+		Description: "Run the migrations of the database, up by default: status lists the applied and pending " +
+			"migrations, up applies them up to version, down rolls back steps migrations (1 by default), " +
+			"baseline records the migrations up to version as applied without running them",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&dir, "dir", "migrations", "directory of the migrations")
+			fs.StringVar(&service, "service", "", "service owning the migrations, app.name by default")
+			fs.StringVar(&table, "table", MigrationTable, "table recording the applied migrations")
+			fs.BoolVar(&dryRun, "dry-run", false, "print the statements instead of running them")
+		},
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			lib, ok := app.GetDefaultSingletonInstance("database")
+			if !ok {
+				return fmt.Errorf("the database library is not loaded")
+			}
+			db := lib.(port.IDatabase)
+
+			command := "up"
+			if len(args) > 0 {
+				command, args = args[0], args[1:]
+			}
+			if service == "" {
+				service = app.Config.App.Name
+			}
+
+			// the databases not running SQL statements have their own migrations
+			if _, ok := db.(port.IDatabaseExec); !ok {
+				logger.Info("Migration", "command", command, "dir", dir, "service", service)
+				return db.StartMigration(ctx, service, command, dir, args)
+			}
+
+			migrations, err := LoadMigrations(dir)
+			if err != nil {
+				return err
+			}
+			migrator := &Migrator{Database: db, Migrations: migrations, Service: service, Table: table, DryRun: dryRun}
+			return runMigration(ctx, migrator, command, args)
+		},
+	}
+}
+
+func runMigration(ctx context.Context, migrator *Migrator, command string, args []string) error {
+	arg := func(usage string) (string, error) {
+		if len(args) > 1 {
+			return "", fmt.Errorf("expected: migrate %s", usage)
+		}
+		if len(args) == 0 {
+			return "", nil
+		}
+		return args[0], nil
+	}
+
+	switch command {
+	case "status":
+		if len(args) > 0 {
+			return fmt.Errorf("expected: migrate status")
+		}
+		return printMigrationStatus(ctx, migrator)
+
+	case "up":
+		target, err := arg("up [version]")
+		if err != nil {
+			return err
+		}
+		count, err := migrator.Up(ctx, target)
+		logger.Info("Migrations applied", "count", count, "dry_run", migrator.DryRun)
+		return err
+
+	case "down":
+		value, err := arg("down [steps]")
+		if err != nil {
+			return err
+		}
+		steps := 1
+		if value != "" {
+			if steps, err = strconv.Atoi(value); err != nil || steps <= 0 {
+				return fmt.Errorf("invalid steps %q", value)
+			}
+		}
+		count, err := migrator.Down(ctx, steps)
+		logger.Info("Migrations rolled back", "count", count, "dry_run", migrator.DryRun)
+		return err
+
+	case "baseline":
+		version, err := arg("baseline <version>")
+		if err != nil {
+			return err
+		}
+		if version == "" {
+			return fmt.Errorf("expected: migrate baseline <version>")
+		}
+		count, err := migrator.Baseline(ctx, version)
+		logger.Info("Migrations baselined", "count", count, "dry_run", migrator.DryRun)
+		return err
+	}
+
+	return fmt.Errorf("unknown migrate command %q, expected status, up, down or baseline", command)
+}
+
+func printMigrationStatus(ctx context.Context, migrator *Migrator) error {
+	statuses, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	pending := 0
+	w := tabwriter.NewWriter(migrator.out(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case status.Missing:
+			state = "applied, files missing"
+		case status.Baseline:
+			state = "baseline"
+		case status.Applied:
+			state = "applied"
+		default:
+			pending++
+		}
+		if status.Applied {
+			appliedAt = status.AppliedAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(migrator.out(), "\n%d migrations, %d pending\n", len(statuses), pending)
+	return nil
+}
+
+func printRoutes(ctx context.Context, app *AppContext, args []string) error {
+	routes := app.Web.GetRoutes(true)
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "METHOD\tPATH\tNAME")
+	for _, route := range routes {
+		if route.Method == "HEAD" {
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", route.Method, route.Path, route.Name)
+	}
+	return w.Flush()
+}
+
+// seed runs the seeders of the modules in the order of their dependencies
+func (a *App) seed(ctx context.Context, app *AppContext, args []string) error {
+	modules, err := a.selectModules(args)
+	if err != nil {
+		return err
+	}
+
+	count := 0
+	for _, module := range modules {
+		seeder, ok := module.(ModuleSeeder)
+		if !ok {
+			if len(args) > 0 {
+				return fmt.Errorf("module %s has no seeder", module.Name())
+			}
+			continue
+		}
+
+		logger.Info("Seeding", "module", module.Name())
+		if err := seeder.Seed(ctx); err != nil {
+			return fmt.Errorf("seed module '%s': %v", module.Name(), err)
+		}
+		count++
+	}
+
+	logger.Info("Seeding done", "modules", count)
+	return nil
+}
+
+// consume runs the consumers of the modules and the receivers of the pub/sub library until ctx
+// is canceled or a consumer fails past its retries
+func (a *App) consume(ctx context.Context, app *AppContext, args []string) error {
+	modules, err := a.selectModules(args)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+
+	count := 0
+	for _, module := range modules {
+		consumer, ok := module.(ModuleConsumer)
+		if !ok {
+			if len(args) > 0 {
+				return fmt.Errorf("module %s has no consumer", module.Name())
+			}
+			continue
+		}
+
+		count++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// a failed consumer is restarted with the "consumer" policy of app.retry
+			err := app.Retries.Do(ctx, RetryConsumer, RetryPolicy{MaxAttempts: 1}, func(ctx context.Context) error {
+				err := consumer.Consume(ctx)
+				if err != nil && ctx.Err() == nil {
+					logger.Warn("Consumer failed", "module", module.Name(), "error", err)
+				}
+				return err
+			})
+			if err != nil && ctx.Err() == nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("consumer of module '%s': %v", module.Name(), err))
+				mu.Unlock()
+				cancel()
+			}
+		}()
+	}
+
+	// receivers registered by the modules in Init
+	if lib, ok := app.GetSingletonInstance("pubsub"); ok && len(args) == 0 {
+		if pubsub, ok := lib.(port.IPubSub); ok {
+			count++
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pubsub.StartReceiving(ctx)
+			}()
+		}
+	}
+
+	if count == 0 {
+		return fmt.Errorf("no module consumes messages")
+	}
+
+	logger.Info("Consuming messages", "consumers", count)
+	<-ctx.Done()
+	wg.Wait()
+
+	return errors.Join(errs...)
+}
+
+func jobsRunCommand() *Command {
+	var scheduler bool
+	return &Command{
+		Name:        "jobs:run",
+		Description: "Run the job queue workers until stopped",
+		Flags: func(fs *flag.FlagSet) {
+			fs.BoolVar(&scheduler, "scheduler", false, "also run the scheduled jobs")
+		},
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			if len(app.Config.App.Queue.Workers) == 0 {
+				return fmt.Errorf("app.queue.workers has no queue")
+			}
+
+			app.Queue.Start(app.Context)
+			if scheduler {
+				app.Scheduler.Start(app.Context)
+			}
+
+			<-ctx.Done()
+			return nil
+		},
+	}
+}
+
+// selectModules returns the modules named by names, all of them by default, in the order of
+// their dependencies
+func (a *App) selectModules(names []string) ([]Module, error) {
+	graph, err := a.ModuleManager.buildDependencyGraph()
+	if err != nil {
+		return nil, err
+	}
+	order, err := a.ModuleManager.buildDependencyOrder(graph)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		if !slices.Contains(order, name) {
+			return nil, fmt.Errorf("module '%s' not found", name)
+		}
+	}
+
+	modules := []Module{}
+	for _, name := range order {
+		if len(names) > 0 && !slices.Contains(names, name) {
+			continue
+		}
+		module, err := a.ModuleManager.GetModule(name)
+		if err != nil {
+			return nil, err
+		}
+		modules = append(modules, module)
+	}
+	return modules, nil
+}
@@ -0,0 +1,296 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// consolePrompt is written when the console waits for a command
+const consolePrompt = "> "
+
+// Console serves a runtime console on a local Unix socket of a development instance: it lists
+// the loaded libraries and modules, publishes events on the EventBus, runs the checks of the
+// doctor and invokes the commands of the modules, while the server runs. A session starts with
+// the token of app.console, the console command of the binary connects with it.
+type Console struct {
+	mu       sync.Mutex
+	app      *App
+	config   config.ConsoleConfig
+	listener net.Listener
+	sessions map[net.Conn]struct{}
+}
+
+func NewConsole(app *App, cfg config.ConsoleConfig) *Console {
+	return &Console{
+		app:      app,
+		config:   cfg,
+		sessions: make(map[net.Conn]struct{}),
+	}
+}
+
+// Start listens on the socket of app.console, readable by the user of the process only
+func (s *Console) Start(ctx context.Context) error {
+	if s.config.Token == "" {
+		return fmt.Errorf("app.console.token is required")
+	}
+
+	// a socket left by a crashed instance is replaced, a served one is not
+	if conn, err := net.DialTimeout("unix", s.config.Socket, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("console socket %s is already served", s.config.Socket)
+	}
+	os.Remove(s.config.Socket)
+
+	listener, err := net.Listen("unix", s.config.Socket)
+	if err != nil {
+		return err
+	}
+	if err := os.Chmod(s.config.Socket, 0o600); err != nil {
+		listener.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.mu.Unlock()
+
+	logger.Info("Console listening", "socket", s.config.Socket)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(ctx, conn)
+		}
+	}()
+	return nil
+}
+
+// Stop closes the socket and the open sessions
+func (s *Console) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return
+	}
+	s.listener.Close()
+	s.listener = nil
+	for conn := range s.sessions {
+		conn.Close()
+	}
+}
+
+// serve reads the token, then the commands of a session until it quits
+func (s *Console) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	s.mu.Lock()
+	if s.listener == nil {
+		s.mu.Unlock()
+		return
+	}
+	s.sessions[conn] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, conn)
+		s.mu.Unlock()
+	}()
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	token, err := reader.ReadString('\n')
+	if err != nil || subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(s.config.Token)) != 1 {
+		logger.Warn("Console session refused")
+		fmt.Fprintln(conn, "unauthorized")
+		return
+	}
+	conn.SetReadDeadline(time.Time{})
+
+	logger.Info("Console session opened")
+	fmt.Fprintf(conn, "%s console, type help for the commands\n%s", s.app.Context.Config.App.Name, consolePrompt)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			break
+		}
+
+		name, rest, _ := strings.Cut(strings.TrimSpace(line), " ")
+		if name == "quit" || name == "exit" {
+			break
+		}
+		if name != "" {
+			if err := s.execute(ctx, conn, name, strings.TrimSpace(rest)); err != nil {
+				fmt.Fprintf(conn, "error: %v\n", err)
+			}
+		}
+		fmt.Fprint(conn, consolePrompt)
+	}
+	logger.Info("Console session closed")
+}
+
+// execute runs a command of the console, its output is written to w
+func (s *Console) execute(ctx context.Context, w io.Writer, name string, args string) error {
+	switch name {
+	case "help":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "libs\tlist the loaded libraries")
+		fmt.Fprintln(tw, "modules\tlist the loaded modules")
+		fmt.Fprintln(tw, "events\tlist the EventBus events with their subscribers")
+		fmt.Fprintln(tw, "publish <event> [json]\tpublish an event on the EventBus, with the JSON value as data")
+		fmt.Fprintln(tw, "health\trun the checks of the doctor command")
+		fmt.Fprintln(tw, "run <command> [flags] [args]\trun a command of the modules, the list without command")
+		fmt.Fprintln(tw, "quit\tclose the session")
+		return tw.Flush()
+
+	case "libs":
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "NAME\tKEY\tTYPE")
+		for _, instance := range s.app.LibraryManager.Instances() {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", instance.Library, instance.Key, instance.Type)
+		}
+		return tw.Flush()
+
+	case "modules":
+		names := s.app.ModuleManager.ListModules()
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintln(w, name)
+		}
+		return nil
+
+	case "events":
+		subscriptions := s.app.Context.EventBus.Subscriptions()
+		events := make([]string, 0, len(subscriptions))
+		for event := range subscriptions {
+			events = append(events, event)
+		}
+		sort.Strings(events)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "EVENT\tSUBSCRIBERS")
+		for _, event := range events {
+			fmt.Fprintf(tw, "%s\t%d\n", event, subscriptions[event])
+		}
+		return tw.Flush()
+
+	case "publish":
+		event, payload, _ := strings.Cut(args, " ")
+		if event == "" {
+			return fmt.Errorf("expected: publish <event> [json]")
+		}
+		var data any
+		if payload = strings.TrimSpace(payload); payload != "" {
+			if err := json.Unmarshal([]byte(payload), &data); err != nil {
+				return fmt.Errorf("invalid JSON data: %v", err)
+			}
+		}
+		s.app.Context.EventBus.Publish(event, data)
+		logger.Info("Console event published", "event", event)
+		fmt.Fprintf(w, "published %s to %d subscribers\n", event, s.app.Context.EventBus.GetSubscribers(event))
+		return nil
+
+	case "health":
+		s.app.PrintDiagnostics(w, s.app.Diagnose(ctx, 5*time.Second))
+		return nil
+
+	case "run":
+		return s.run(ctx, w, strings.Fields(args))
+	}
+
+	return fmt.Errorf("unknown command %q, type help for the commands", name)
+}
+
+// run invokes a command of the modules, or registered with RegisterCommand; the built-in
+// commands of the binary are not available on a running instance
+func (s *Console) run(ctx context.Context, w io.Writer, args []string) error {
+	commands := s.app.Commands()
+	for _, cmd := range s.app.builtinCommands() {
+		delete(commands, cmd.Name)
+	}
+
+	if len(args) == 0 {
+		names := make([]string, 0, len(commands))
+		for name := range commands {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(tw, "%s\t%s\n", name, commands[name].Description)
+		}
+		return tw.Flush()
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(w)
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if err := fs.Parse(args[1:]); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return nil
+		}
+		return err
+	}
+
+	logger.Info("Console command", "command", cmd.Name)
+	return cmd.Run(WithCommandOutput(ctx, w), s.app.Context, fs.Args())
+}
+
+// consoleCommand connects the terminal to the console of the instance running on this host
+func consoleCommand() *Command {
+	return &Command{
+		Name:        "console",
+		Description: "Open the runtime console of the development instance running on this host",
+		NoSetup:     true,
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			cfg := app.Config.App.Console
+			conn, err := net.Dial("unix", cfg.Socket)
+			if err != nil {
+				return fmt.Errorf("console of app.console.socket is not served: %v", err)
+			}
+			defer conn.Close()
+
+			if _, err := fmt.Fprintln(conn, cfg.Token); err != nil {
+				return err
+			}
+			go func() {
+				io.Copy(conn, os.Stdin)
+				conn.(*net.UnixConn).CloseWrite()
+			}()
+			go func() {
+				<-ctx.Done()
+				conn.Close()
+			}()
+
+			_, err = io.Copy(os.Stdout, conn)
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		},
+	}
+}
@@ -4,27 +4,124 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
 	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/grpc"
 	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/tracing"
+	"github.com/webcore-go/webcore/infra/view"
 	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
 )
 
 // Context represents shared dependencies that can be injected into modules
 type AppContext struct {
-	Context     context.Context
-	Config      *config.Config
-	Web         *fiber.App
-	Root        fiber.Router
-	AuthHandler fiber.Handler
-	EventBus    *EventBus
-	Hook        *Hook
+	Context      context.Context
+	Config       *config.Config
+	Web          *fiber.App
+	Root         fiber.Router
+	AuthHandler  fiber.Handler
+	Authorizer   auth.IAuthorization
+	GeoIP        port.IGeoIPProvider
+	EventBus     *EventBus
+	Hook         *Hook
+	Clock        helper.Clock
+	Scheduler    *Scheduler
+	Queue        *JobQueue
+	Mailer       *Mailer
+	Notifier     *Notifier
+	Reporter     *Reporter
+	Images       *ImageProcessor
+	Webhooks     *Webhooks
+	Payments     *Payments
+	Tasks        *Tasks
+	Importer     *Importer
+	Search       *SearchSync
+	Views        *view.Engine
+	Hub          *Hub
+	Readiness    *Readiness
+	Discovery    *Discovery
+	DevTail      *DevTail
+	Retention    *Retention
+	Sagas        *Sagas
+	EventStore   *EventStore
+	Outbox       *Outbox // events stored in the transactions of the modules and relayed to the broker of app.outbox
+	Flags        *Flags
+	Tenants      port.ITenantStore   // tenants of app.tenancy, resolved per request by the tenancy middleware
+	Resilience   *Resilience         // circuit breakers, bulkheads and timeouts of app.resilience and of the HTTP clients
+	Retries      *Retries            // retry policies of app.retry, shared by the database connections, the consumers, the webhooks and the HTTP clients
+	Secrets      *Secrets            // runtime secrets of the library of secrets.driver
+	Cipher       *helper.FieldCipher // encryption of the sensitive fields, with the key of secrets.field_key
+	Signer       *helper.ValueSigner // signature of the values handed to the clients, with the key of secrets.signing_key
+	Deprecations *Deprecations       // deprecated routes and versions, and who still calls them
+	Usage        *Usage              // requests and bytes per principal, API key and route, and their quotas
+	Quotas       *Quotas             // units of the features per tenant or principal (ex: reports a day)
+	Privacy      *Privacy            // export and erasure of the data of a subject across the modules
+	Canaries     *Canaries           // routes served by two implementations and the comparison of both
+	Masking      *Masking            // masking policies of the personal data in the logs and the exports, by tenant
+	Client       *ClientPush         // flags and settings exposed to the frontends, pushed on the hub when they change
+	Chaos        *Chaos              // latency, errors and dropped connections injected outside production
+	Capture      *Capture            // sampled requests and responses stored for the replay command
+	Upgrades     *Upgrades           // versions of the keyed libraries run side by side during a deploy, and their cutover
+	Tracer       *tracing.Tracer     // spans of app.tracing, nil when disabled (its wrappers then return the libraries unchanged)
+	GRPC         *grpc.Server        // nil unless app.grpc is enabled, services register their methods on it
+	GRPCHealth   *grpc.HealthServer
+	Admin        fiber.Router
+
+	// ErrorReporter receives the panics recovered in the requests (ex: Sentry), the remote log
+	// captures them when it is nil
+	ErrorReporter port.IErrorReporter
+
+	tenantMu sync.Mutex // connection of the tenant databases
+
+	groupMu sync.RWMutex
+	groups  map[string]*ModuleGroup // group of the child modules, by module name
 }
 
 func (a *AppContext) Start() error {
 	libmanager := Instance().LibraryManager
 
+	// Keys of the encrypted fields, rotated with their secret
+	if a.Config.Secrets.FieldKey != "" {
+		if err := a.Secrets.AddFieldKeys(a.Context, a.Cipher); err != nil {
+			return err
+		}
+	}
+
+	// Keys of the signed values, rotated with their secret
+	if a.Config.Secrets.SigningKey != "" {
+		if err := a.Secrets.AddSigningKeys(a.Context, a.Signer); err != nil {
+			return err
+		}
+	}
+
+	// Backend writing the lines, before the libraries log
+	if a.Config.App.Logging.Backend != "" {
+		loader, e := a.GetDefaultLibraryLoader("logger")
+		if e != nil {
+			return e
+		}
+
+		if loader != nil {
+			libBackend, err := libmanager.LoadSingletonFromLoader(loader, a.Context, a.Config.App.Logging)
+			if err != nil {
+				return err
+			}
+
+			backend, ok := libBackend.(port.ILogBackend)
+			if !ok {
+				return fmt.Errorf("library %s is not a log backend", loader.Name())
+			}
+			logger.SetBackend(backend.Handler())
+		}
+	}
+
 	if a.Config.App.Logging.Remote.Uri != "" {
 		loader, e := a.GetDefaultLibraryLoader("remotelog")
 		if e != nil {
@@ -64,12 +161,18 @@ func (a *AppContext) Start() error {
 				return err
 			}
 		}*/
-		_, err := a.StartDefaultSingletonInstance("database", a.Context, a.Config.Database)
+		library, err := a.startDatabase(a.Context, "", a.Config.Database)
 		if err != nil {
-			return err
-		}
+			if err := libmanager.tolerate(a.getDefaultName("database"), false, err); err != nil {
+				return err
+			}
+		} else {
+			// retention rules and search sources use the default database unless they name their own
+			a.Retention.SetDatabase(a.guardDatabase(library.(port.IDatabase), "database"))
+			a.Search.SetDatabase(a.guardDatabase(library.(port.IDatabase), "database"))
 
-		logger.Info("Library Database loaded", "driver", a.Config.Database.Driver)
+			logger.Info("Library Database loaded", "driver", a.Config.Database.Driver)
+		}
 	}
 
 	// Initialize database if configured
@@ -84,10 +187,12 @@ func (a *AppContext) Start() error {
 		if loader != nil {
 			_, err := libmanager.LoadSingletonFromLoader(loader, a.Config.Memory)
 			if err != nil {
-				return err
+				if err := libmanager.tolerate(name, false, err); err != nil {
+					return err
+				}
+			} else {
+				logger.Info("Library Cache", "loaded", name)
 			}
-
-			logger.Info("Library Cache", "loaded", name)
 		}
 	}
 
@@ -104,10 +209,12 @@ func (a *AppContext) Start() error {
 		if loader != nil {
 			_, err := libmanager.LoadSingletonFromLoader(loader, a.Config.Redis)
 			if err != nil {
-				return err
+				if err := libmanager.tolerate(name, false, err); err != nil {
+					return err
+				}
+			} else {
+				logger.Info("Library Cache", "loaded", name, "host", a.Config.Redis.Host)
 			}
-
-			logger.Info("Library Cache", "loaded", name, "host", a.Config.Redis.Host)
 		}
 	}
 
@@ -117,7 +224,7 @@ func (a *AppContext) Start() error {
 		loaderProducer, okProducer := libmanager.GetLoader("kafka:producer")
 		if okProducer {
 			_, err := libmanager.LoadSingletonFromLoader(loaderProducer, a.Config.Kafka)
-			if err != nil {
+			if err := libmanager.tolerate("kafka:producer", false, err); err != nil {
 				return err
 			}
 		}
@@ -164,6 +271,352 @@ func (a *AppContext) Start() error {
 		}
 	}
 
+	// Spans of the requests, before the modules wrap their libraries with the tracer
+	if a.Config.App.Tracing.Enabled {
+		cfg := a.Config.App.Tracing
+		if cfg.ServiceName == "" {
+			cfg.ServiceName = a.Config.App.Name
+		}
+		library, err := a.StartDefaultSingletonInstance("tracing", a, cfg, a.Config.App.Environment)
+		if err != nil {
+			return err
+		}
+
+		a.Tracer = library.(*tracing.Tracer)
+		logger.Info("Library Tracing loaded", "exporter", cfg.Exporter, "endpoint", cfg.Endpoint)
+	}
+
+	// Load the libraries declared by manifests, the lazy ones on their first use
+	if err := libmanager.startManifests(a.manifestArgs); err != nil {
+		return err
+	}
+
+	// Initialize IP geolocation if configured
+	if a.Config.App.GeoIP.Enabled {
+		library, err := a.StartDefaultSingletonInstance("geoip", a, a.Config.App.GeoIP)
+		if err != nil {
+			return err
+		}
+
+		a.GeoIP = library.(port.IGeoIPProvider)
+		logger.Info("Library GeoIP loaded", "provider", a.Config.App.GeoIP.Provider)
+	}
+
+	// Initialize object storage if configured
+	if a.Config.Storage.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("storage", a, a.Config.Storage)
+		if err != nil {
+			return err
+		}
+
+		// queued reports, image variants, imported files, data exports and captured requests are stored in the object storage
+		a.Reporter.SetStorage(library.(port.IObjectStorage))
+		a.Images.SetStorage(library.(port.IObjectStorage))
+		a.Importer.SetStorage(library.(port.IObjectStorage))
+		a.Privacy.SetStorage(library.(port.IObjectStorage))
+		a.Capture.SetStorage(library.(port.IObjectStorage))
+
+		logger.Info("Library Storage loaded", "driver", a.Config.Storage.Driver)
+	}
+
+	// Initialize search engine if configured
+	if a.Config.Search.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("search", a, a.Config.Search)
+		if err != nil {
+			return err
+		}
+
+		a.Search.SetDriver(library.(port.ISearchIndex))
+		logger.Info("Library Search loaded", "driver", a.Config.Search.Driver)
+	}
+
+	// Initialize mailer if configured
+	if a.Config.Mail.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("mailer", a, a.Config.Mail)
+		if err != nil {
+			return err
+		}
+
+		a.Mailer.SetDriver(library.(port.IMailer))
+		logger.Info("Library Mailer loaded", "driver", a.Config.Mail.Driver)
+	}
+
+	// Initialize payment gateway if configured
+	if a.Config.Payment.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("payment", a, a.Config.Payment)
+		if err != nil {
+			return err
+		}
+
+		a.Payments.SetDriver(library.(port.IPaymentGateway))
+		logger.Info("Library Payment loaded", "driver", a.Config.Payment.Driver)
+	}
+
+	// Initialize service discovery if configured
+	if a.Config.App.Discovery.Enabled {
+		library, err := a.StartDefaultSingletonInstance("discovery", a, a.Config.App.Discovery)
+		if err != nil {
+			return err
+		}
+
+		a.Discovery.SetRegistry(library.(port.IServiceRegistry))
+		logger.Info("Library Discovery loaded", "backend", a.Config.App.Discovery.Backend)
+	}
+
+	// Initialize notification channels if configured
+	if a.Config.Notify.SMS.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("sms", a, a.Config.Notify.SMS)
+		if err != nil {
+			return err
+		}
+
+		a.Notifier.RegisterChannel(port.NotifyChannelSMS, library.(port.INotifier))
+		logger.Info("Library SMS loaded", "driver", a.Config.Notify.SMS.Driver)
+	}
+
+	if a.Config.Notify.Push.Driver != "" {
+		library, err := a.StartDefaultSingletonInstance("push", a, a.Config.Notify.Push)
+		if err != nil {
+			return err
+		}
+
+		a.Notifier.RegisterChannel(port.NotifyChannelPush, library.(port.INotifier))
+		logger.Info("Library Push loaded", "driver", a.Config.Notify.Push.Driver)
+	}
+
+	// Initialize report drivers if configured
+	if a.Config.Report.PDF != "" {
+		library, err := a.StartDefaultSingletonInstance("pdf", a, a.Config.Report)
+		if err != nil {
+			return err
+		}
+
+		a.Reporter.SetPDFDriver(library.(port.IPDFRenderer))
+		logger.Info("Library PDF Report loaded", "driver", a.Config.Report.PDF)
+	}
+
+	if a.Config.Report.XLSX != "" {
+		library, err := a.StartDefaultSingletonInstance("xlsx", a, a.Config.Report)
+		if err != nil {
+			return err
+		}
+
+		a.Reporter.SetXLSXDriver(library.(port.ISpreadsheetRenderer))
+		if reader, ok := library.(port.ISpreadsheetReader); ok {
+			a.Importer.SetXLSXReader(reader)
+		}
+		logger.Info("Library XLSX Report loaded", "driver", a.Config.Report.XLSX)
+	}
+
+	// Load templates from the views directory unless the application set its own
+	if a.Views == nil && a.Config.View.Directory != "" {
+		if info, err := os.Stat(a.Config.View.Directory); err == nil && info.IsDir() {
+			if err := a.SetViews(os.DirFS(a.Config.View.Directory)); err != nil {
+				return err
+			}
+
+			logger.Info("Views loaded", "directory", a.Config.View.Directory)
+		}
+	}
+
+	// Use a shared lock so scheduled jobs only run on one instance
+	if a.Config.App.Scheduler.Enabled && a.Config.App.Scheduler.Locker != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Scheduler.Locker, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		locker, ok := library.(port.ILocker)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ILocker", a.Config.App.Scheduler.Locker)
+		}
+		a.Scheduler.SetLocker(locker)
+
+		logger.Info("Library Scheduler Locker loaded", "name", a.Config.App.Scheduler.Locker)
+	}
+
+	// Use a shared lock so webhook deliveries are handled once across instances
+	if a.Config.App.Webhooks.Enabled && a.Config.App.Webhooks.Locker != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Webhooks.Locker, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		locker, ok := library.(port.ILocker)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ILocker", a.Config.App.Webhooks.Locker)
+		}
+		a.Webhooks.SetLocker(locker)
+
+		logger.Info("Library Webhooks Locker loaded", "name", a.Config.App.Webhooks.Locker)
+	} else if a.Config.App.Webhooks.Enabled {
+		logger.Warn("Webhooks lock is in-process, a delivery retried on another instance is handled again, set app.webhooks.locker to a shared lock (ex: \"lock:database\")")
+	}
+
+	// Share the status of long-running tasks between instances
+	if a.Config.App.Tasks.Enabled && a.Config.App.Tasks.Store != "" {
+		library, ok := a.GetSingletonInstance(a.Config.App.Tasks.Store)
+		if !ok {
+			return fmt.Errorf("Library '%s' tidak ditemukan", a.Config.App.Tasks.Store)
+		}
+
+		store, ok := library.(port.ICacheMemory)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ICacheMemory", a.Config.App.Tasks.Store)
+		}
+		a.Tasks.SetStore(store)
+
+		logger.Info("Library Tasks Store loaded", "name", a.Config.App.Tasks.Store)
+	}
+
+	// Share the counters of the feature quotas between instances
+	if a.Config.App.Quotas.Enabled && a.Config.App.Quotas.Store != "" {
+		library, ok := a.GetSingletonInstance(a.Config.App.Quotas.Store)
+		if !ok {
+			return fmt.Errorf("Library '%s' tidak ditemukan", a.Config.App.Quotas.Store)
+		}
+
+		cache, ok := library.(port.ICacheMemory)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ICacheMemory", a.Config.App.Quotas.Store)
+		}
+		a.Quotas.SetStore(cache)
+
+		logger.Info("Library Quotas Store loaded", "name", a.Config.App.Quotas.Store)
+	}
+
+	// Persist background jobs in the configured store
+	if a.Config.App.Queue.Enabled && a.Config.App.Queue.Store != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Queue.Store, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		store, ok := library.(port.IQueueStore)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.IQueueStore", a.Config.App.Queue.Store)
+		}
+		a.Queue.SetStore(store)
+
+		logger.Info("Library Queue Store loaded", "name", a.Config.App.Queue.Store)
+	}
+
+	// Find the tenants in the configured store instead of the configuration
+	if a.Config.App.Tenancy.Enabled && a.Config.App.Tenancy.Store != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Tenancy.Store, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		store, ok := library.(port.ITenantStore)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ITenantStore", a.Config.App.Tenancy.Store)
+		}
+		a.Tenants = store
+
+		logger.Info("Library Tenant Store loaded", "name", a.Config.App.Tenancy.Store)
+	}
+
+	// Read the feature flags from the configured provider, before the modules use them
+	if a.Config.App.Flags.Enabled {
+		if err := a.setupFlagProvider(); err != nil {
+			return err
+		}
+		if err := a.Flags.Reload(a.Context); err != nil {
+			return fmt.Errorf("failed to load the feature flags: %v", err)
+		}
+	}
+
+	// Persist sagas in the configured store
+	if a.Config.App.Saga.Enabled && a.Config.App.Saga.Store != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Saga.Store, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		store, ok := library.(port.ISagaStore)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ISagaStore", a.Config.App.Saga.Store)
+		}
+		a.Sagas.SetStore(store)
+
+		logger.Info("Library Saga Store loaded", "name", a.Config.App.Saga.Store)
+	}
+
+	// Store the messages of the outbox in the default database
+	if a.Config.App.Outbox.Enabled {
+		library, ok := a.GetDefaultSingletonInstance("database")
+		if !ok {
+			return fmt.Errorf("Library 'database' tidak ditemukan")
+		}
+		a.Outbox.SetDatabase(library.(port.IDatabase))
+
+		logger.Info("Outbox loaded", "table", a.Config.App.Outbox.Table, "broker", a.Config.App.Outbox.Broker)
+	}
+
+	// Append the events of the aggregates to the configured store
+	if a.Config.App.EventStore.Enabled && a.Config.App.EventStore.Store != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.EventStore.Store, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		store, ok := library.(port.IEventStore)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.IEventStore", a.Config.App.EventStore.Store)
+		}
+		a.EventStore.SetStore(store)
+
+		logger.Info("Library Event Store loaded", "name", a.Config.App.EventStore.Store)
+	}
+
+	// Roll up the usage in the configured store, shared by the instances
+	if a.Config.App.Usage.Enabled && a.Config.App.Usage.Store != "" {
+		library, err := a.StartSingletonInstance(a.Config.App.Usage.Store, a, a.Config)
+		if err != nil {
+			return err
+		}
+
+		store, ok := library.(port.IUsageStore)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.IUsageStore", a.Config.App.Usage.Store)
+		}
+		a.Usage.SetStore(store)
+
+		logger.Info("Library Usage Store loaded", "name", a.Config.App.Usage.Store)
+	}
+
+	return nil
+}
+
+// setupFlagProvider selects the provider named by app.flags.provider, the flags stay in
+// memory when it is empty
+func (a *AppContext) setupFlagProvider() error {
+	cfg := a.Config.App.Flags
+	switch cfg.Provider {
+	case "":
+		return nil
+	case "file":
+		a.Flags.SetProvider(NewFileFlagProvider(cfg.File))
+	case "database":
+		library, ok := a.GetDefaultSingletonInstance("database")
+		if !ok {
+			return fmt.Errorf("Library 'database' tidak ditemukan")
+		}
+		a.Flags.SetProvider(NewDatabaseFlagProvider(library.(port.IDatabase), cfg.Table))
+	default:
+		library, ok := a.GetSingletonInstance(cfg.Provider)
+		if !ok {
+			return fmt.Errorf("Library '%s' tidak ditemukan", cfg.Provider)
+		}
+		cache, ok := library.(port.ICacheMemory)
+		if !ok {
+			return fmt.Errorf("Library '%s' bukan port.ICacheMemory", cfg.Provider)
+		}
+		a.Flags.SetProvider(NewCacheFlagProvider(cache, cfg.Key))
+	}
+
+	logger.Info("Feature flags provider loaded", "provider", cfg.Provider)
 	return nil
 }
 
@@ -268,6 +721,32 @@ func (a *AppContext) getDefaultName(name string) string {
 		name = name + ":" + a.Config.Auth.Session.Backend
 	case "authentication":
 		name = name + ":" + a.Config.Auth.Type
+	case "storage":
+		name = name + ":" + a.Config.Storage.Driver
+	case "mailer":
+		name = name + ":" + a.Config.Mail.Driver
+	case "secrets":
+		name = name + ":" + a.Config.Secrets.Driver
+	case "search":
+		name = name + ":" + a.Config.Search.Driver
+	case "sms":
+		name = "notify:" + a.Config.Notify.SMS.Driver
+	case "push":
+		name = "notify:" + a.Config.Notify.Push.Driver
+	case "pdf":
+		name = "report:" + a.Config.Report.PDF
+	case "xlsx":
+		name = "report:" + a.Config.Report.XLSX
+	case "payment":
+		name = name + ":" + a.Config.Payment.Driver
+	case "geoip":
+		name = name + ":" + a.Config.App.GeoIP.Provider
+	case "discovery":
+		name = name + ":" + a.Config.App.Discovery.Backend
+	case "tracing":
+		name = name + ":" + a.Config.App.Tracing.Exporter
+	case "logger":
+		name = name + ":" + a.Config.App.Logging.Backend
 	}
 	return name
 }
@@ -278,6 +757,27 @@ func AppendRouteToArray(routes []*ModuleRoute, route *ModuleRoute) []*ModuleRout
 		handlers = []fiber.Handler{route.Handler}
 	}
 
+	// Split the traffic between the last handler of the route and the canary
+	if route.Canary != nil && len(handlers) > 0 {
+		if app := Instance(); app != nil {
+			canary := *route.Canary
+			if canary.Name == "" {
+				canary.Name = strings.ToUpper(route.Method) + " " + routePrefix(route.Root) + route.Path
+			}
+			handlers = append(slices.Clone(handlers[:len(handlers)-1]), app.Context.Canaries.Handler(canary, handlers[len(handlers)-1]))
+		}
+	}
+
+	// Checks and middleware of the route before its handlers
+	handlers = append(routeMiddleware(route), handlers...)
+
+	// Announce the deprecation before the handlers of the route
+	if route.Deprecation != nil {
+		if app := Instance(); app != nil {
+			handlers = append([]fiber.Handler{app.Context.Deprecations.Handler(*route.Deprecation)}, handlers...)
+		}
+	}
+
 	route.Root.Add(route.Method, route.Path, handlers...)
 
 	routes = append(routes, route)
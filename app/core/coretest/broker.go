@@ -0,0 +1,188 @@
+package coretest
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// MemoryBroker is a port.IPubSub delivering each message to the registered receivers within
+// Publish once StartReceiving was called, so a test sees the effects as soon as Publish
+// returns. Messages published before are delivered by StartReceiving.
+type MemoryBroker struct {
+	mu        sync.Mutex
+	clock     helper.Clock
+	receivers []port.PubSubReceiver
+	receiving bool
+	published []*BrokerMessage
+	pending   []*BrokerMessage
+	nextID    int
+}
+
+// BrokerMessage is a message published on the MemoryBroker
+type BrokerMessage struct {
+	ID          string
+	Data        []byte
+	PublishTime time.Time
+	Attributes  map[string]string
+	Acked       bool // a receiver acknowledged the message
+}
+
+func (m *BrokerMessage) GetID() string {
+	return m.ID
+}
+
+func (m *BrokerMessage) GetData() []byte {
+	return m.Data
+}
+
+func (m *BrokerMessage) GetPublishTime() time.Time {
+	return m.PublishTime
+}
+
+func (m *BrokerMessage) GetAttributes() map[string]string {
+	return m.Attributes
+}
+
+// NewMemoryBroker creates a broker without receivers
+func NewMemoryBroker(clock helper.Clock) *MemoryBroker {
+	return &MemoryBroker{clock: clock}
+}
+
+func (b *MemoryBroker) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (b *MemoryBroker) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (b *MemoryBroker) Connect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (b *MemoryBroker) Disconnect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+// Publish stores message, []byte and string are sent as is and anything else JSON encoded
+func (b *MemoryBroker) Publish(ctx context.Context, message any, attributes map[string]string) (string, error) {
+	var data []byte
+	switch v := message.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		encoded, err := json.Marshal(message)
+		if err != nil {
+			return "", err
+		}
+		data = encoded
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	msg := &BrokerMessage{
+		ID:          strconv.Itoa(b.nextID),
+		Data:        data,
+		PublishTime: b.clock.Now(),
+		Attributes:  attributes,
+	}
+	b.published = append(b.published, msg)
+	b.pending = append(b.pending, msg)
+	receiving := b.receiving
+	b.mu.Unlock()
+
+	if receiving {
+		b.deliver(ctx)
+	}
+	return msg.ID, nil
+}
+
+func (b *MemoryBroker) RegisterReceiver(receiver port.PubSubReceiver) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.receivers = append(b.receivers, receiver)
+}
+
+func (b *MemoryBroker) StartReceiving(ctx context.Context) {
+	b.mu.Lock()
+	b.receiving = true
+	b.mu.Unlock()
+
+	b.deliver(ctx)
+}
+
+// Published returns the messages published so far, for assertions
+func (b *MemoryBroker) Published() []*BrokerMessage {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return append([]*BrokerMessage(nil), b.published...)
+}
+
+// Redeliver delivers again the messages no receiver acknowledged
+func (b *MemoryBroker) Redeliver(ctx context.Context) {
+	b.mu.Lock()
+	for _, msg := range b.published {
+		if !msg.Acked {
+			b.pending = append(b.pending, msg)
+		}
+	}
+	b.mu.Unlock()
+
+	b.deliver(ctx)
+}
+
+// Reset forgets the published messages, the receivers stay registered
+func (b *MemoryBroker) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.published = nil
+	b.pending = nil
+}
+
+func (b *MemoryBroker) deliver(ctx context.Context) {
+	b.mu.Lock()
+	pending := b.pending
+	b.pending = nil
+	receivers := append([]port.PubSubReceiver(nil), b.receivers...)
+	b.mu.Unlock()
+
+	if len(pending) == 0 {
+		return
+	}
+
+	messages := make([]port.IPubSubMessage, len(pending))
+	for i, msg := range pending {
+		messages[i] = msg
+	}
+
+	for _, receiver := range receivers {
+		acks, err := receiver.Consume(ctx, messages)
+		if err != nil {
+			logger.Warn("Receiver failed to consume messages", "error", err)
+		}
+
+		b.mu.Lock()
+		for _, msg := range pending {
+			if acks[msg.ID] {
+				msg.Acked = true
+			}
+		}
+		b.mu.Unlock()
+	}
+}
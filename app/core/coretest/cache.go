@@ -0,0 +1,126 @@
+package coretest
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+)
+
+// MemoryCache is a port.ICacheMemory keeping JSON encoded values, like the network caches,
+// so the values read back are copies
+type MemoryCache struct {
+	mu      sync.Mutex
+	clock   helper.Clock
+	entries map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	value   []byte
+	expires time.Time // zero without TTL
+}
+
+// NewMemoryCache creates an empty cache, the entries expire according to clock
+func NewMemoryCache(clock helper.Clock) *MemoryCache {
+	return &MemoryCache{clock: clock, entries: make(map[string]cacheEntry)}
+}
+
+func (m *MemoryCache) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *MemoryCache) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *MemoryCache) Connect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *MemoryCache) Disconnect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (m *MemoryCache) Set(key string, value any, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	entry := cacheEntry{value: data}
+	if ttl > 0 {
+		entry.expires = m.clock.Now().Add(ttl)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries[key] = entry
+	return nil
+}
+
+func (m *MemoryCache) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var value int64
+	entry, ok := m.entries[key]
+	if ok && (entry.expires.IsZero() || m.clock.Now().Before(entry.expires)) {
+		if err := json.Unmarshal(entry.value, &value); err != nil {
+			return 0, err
+		}
+	} else {
+		entry = cacheEntry{}
+		if ttl > 0 {
+			entry.expires = m.clock.Now().Add(ttl)
+		}
+	}
+
+	value += delta
+	entry.value, _ = json.Marshal(value)
+	m.entries[key] = entry
+	return value, nil
+}
+
+func (m *MemoryCache) Get(key string, outvalue any) bool {
+	m.mu.Lock()
+	entry, ok := m.entries[key]
+	if ok && !entry.expires.IsZero() && !m.clock.Now().Before(entry.expires) {
+		delete(m.entries, key)
+		ok = false
+	}
+	m.mu.Unlock()
+
+	if !ok {
+		return false
+	}
+	return json.Unmarshal(entry.value, outvalue) == nil
+}
+
+// Keys returns the keys not expired yet, for assertions
+func (m *MemoryCache) Keys() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.clock.Now()
+	keys := make([]string, 0, len(m.entries))
+	for key, entry := range m.entries {
+		if entry.expires.IsZero() || now.Before(entry.expires) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// Reset removes all the entries
+func (m *MemoryCache) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.entries = make(map[string]cacheEntry)
+}
@@ -0,0 +1,295 @@
+// Package containers starts ephemeral MongoDB, PostgreSQL, Redis and Kafka containers with the
+// docker CLI for end-to-end tests, and points the configuration of the harness at them. The
+// real drivers are registered with the loaders of the harness and loaded by the core as in
+// production:
+//
+//	pg := containers.Postgres(t)
+//	h := coretest.New(t, coretest.Options{
+//		Configure: pg.Configure,
+//		Loaders:   map[string]core.LibraryLoader{"database:postgres": &postgres.PostgresLoader{}},
+//	})
+//
+// Tests are skipped when docker is not available. A container is shared by all the tests of
+// the test binary asking for the same spec, so tests must clean the data they create; call
+// TerminateAll from TestMain to remove the containers once the tests are done.
+package containers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"os/exec"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// Label marks the containers started by the package (ex: docker rm -f $(docker ps -qf label=webcore.coretest))
+const Label = "webcore.coretest"
+
+// Spec describes a container
+type Spec struct {
+	Image string
+	Ports []string // container ports published on a random host port (ex: "5432/tcp"), or "host:container" to choose it
+	Env   map[string]string
+	Cmd   []string
+
+	WaitLog        string // log line printed once the service accepts connections
+	WaitOccurrence int    // occurrences of WaitLog to wait for, 1 by default
+	WaitTimeout    time.Duration
+}
+
+// Container is a running container
+type Container struct {
+	ID    string
+	Host  string
+	Spec  Spec
+	ports map[string]int // container port to host port
+
+	configure func(c *Container, cfg *config.Config)
+}
+
+// Port returns the host port publishing port of the container (ex: "5432/tcp")
+func (c *Container) Port(port string) int {
+	return c.ports[normalizePort(port)]
+}
+
+// Addr returns host:port for port of the container
+func (c *Container) Addr(port string) string {
+	return net.JoinHostPort(c.Host, strconv.Itoa(c.Port(port)))
+}
+
+// Configure points cfg at the container, it can be given to coretest.Options.Configure
+func (c *Container) Configure(cfg *config.Config) {
+	if c.configure != nil {
+		c.configure(c, cfg)
+	}
+}
+
+var (
+	mu         sync.Mutex
+	running    = map[string]*Container{}
+	dockerErr  error
+	dockerOnce sync.Once
+)
+
+// Start runs the container of spec, or returns the one already started for the same spec by
+// the test binary
+func Start(tb testing.TB, spec Spec) *Container {
+	tb.Helper()
+
+	return startService(tb, spec, nil)
+}
+
+// startService starts the container of a service, configure is kept with the container
+func startService(tb testing.TB, spec Spec, configure func(c *Container, cfg *config.Config)) *Container {
+	tb.Helper()
+
+	dockerOnce.Do(func() {
+		if _, err := exec.LookPath("docker"); err != nil {
+			dockerErr = err
+			return
+		}
+		dockerErr = exec.Command("docker", "info").Run()
+	})
+	if dockerErr != nil {
+		tb.Skipf("containers: docker is not available: %v", dockerErr)
+	}
+
+	key := specKey(spec)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if c, ok := running[key]; ok {
+		return c
+	}
+
+	c, err := start(spec)
+	if err != nil {
+		tb.Fatalf("containers: %s: %v", spec.Image, err)
+	}
+	c.configure = configure
+	running[key] = c
+	return c
+}
+
+// TerminateAll removes the containers started by the test binary
+func TerminateAll() {
+	mu.Lock()
+	defer mu.Unlock()
+
+	for key, c := range running {
+		exec.Command("docker", "rm", "-f", "-v", c.ID).Run()
+		delete(running, key)
+	}
+}
+
+func start(spec Spec) (*Container, error) {
+	args := []string{"run", "-d", "--rm", "--label", Label}
+	for _, port := range spec.Ports {
+		if strings.Contains(port, ":") {
+			args = append(args, "-p", "127.0.0.1:"+port)
+		} else {
+			args = append(args, "-p", "127.0.0.1::"+port)
+		}
+	}
+	for _, name := range sortedKeys(spec.Env) {
+		args = append(args, "-e", name+"="+spec.Env[name])
+	}
+	args = append(args, spec.Image)
+	args = append(args, spec.Cmd...)
+
+	out, err := docker(context.Background(), args...)
+	if err != nil {
+		return nil, err
+	}
+	c := &Container{ID: strings.TrimSpace(out), Host: "127.0.0.1", Spec: spec, ports: map[string]int{}}
+
+	if err := c.inspectPorts(); err != nil {
+		exec.Command("docker", "rm", "-f", "-v", c.ID).Run()
+		return nil, err
+	}
+	if err := c.wait(); err != nil {
+		exec.Command("docker", "rm", "-f", "-v", c.ID).Run()
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *Container) inspectPorts() error {
+	for _, port := range c.Spec.Ports {
+		if i := strings.LastIndex(port, ":"); i >= 0 {
+			port = port[i+1:]
+		}
+		port = normalizePort(port)
+
+		out, err := docker(context.Background(), "port", c.ID, port)
+		if err != nil {
+			return err
+		}
+		// one line per address, ex: 127.0.0.1:49153
+		line := strings.TrimSpace(strings.SplitN(out, "\n", 2)[0])
+		_, hostPort, err := net.SplitHostPort(line)
+		if err != nil {
+			return fmt.Errorf("unexpected port mapping %q", line)
+		}
+		c.ports[port], _ = strconv.Atoi(hostPort)
+	}
+	return nil
+}
+
+// wait follows the logs until WaitLog was printed, then checks the published ports accept
+// connections
+func (c *Container) wait() error {
+	timeout := c.Spec.WaitTimeout
+	if timeout <= 0 {
+		timeout = 2 * time.Minute
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if c.Spec.WaitLog != "" {
+		occurrences := max(c.Spec.WaitOccurrence, 1)
+
+		cmd := exec.CommandContext(ctx, "docker", "logs", "-f", c.ID)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			return err
+		}
+		cmd.Stderr = cmd.Stdout
+		if err := cmd.Start(); err != nil {
+			return err
+		}
+
+		found := 0
+		scanner := bufio.NewScanner(stdout)
+		for found < occurrences && scanner.Scan() {
+			if strings.Contains(scanner.Text(), c.Spec.WaitLog) {
+				found++
+			}
+		}
+		cancel()
+		cmd.Wait()
+		if found < occurrences {
+			return fmt.Errorf("%q not logged within %s", c.Spec.WaitLog, timeout)
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for port := range c.ports {
+		for {
+			conn, err := net.DialTimeout("tcp", c.Addr(port), time.Second)
+			if err == nil {
+				conn.Close()
+				break
+			}
+			if time.Now().After(deadline) {
+				return fmt.Errorf("port %s not reachable: %v", port, err)
+			}
+			time.Sleep(200 * time.Millisecond)
+		}
+	}
+	return nil
+}
+
+func docker(ctx context.Context, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, "docker", args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("docker %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+// freePort returns a port free on the loopback interface, for the services advertising
+// their own address
+func freePort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+func specKey(spec Spec) string {
+	var sb strings.Builder
+	sb.WriteString(spec.Image)
+	for _, port := range spec.Ports {
+		sb.WriteString("|" + port)
+	}
+	for _, name := range sortedKeys(spec.Env) {
+		sb.WriteString("|" + name + "=" + spec.Env[name])
+	}
+	for _, arg := range spec.Cmd {
+		sb.WriteString("|" + arg)
+	}
+	return sb.String()
+}
+
+func normalizePort(port string) string {
+	if !strings.Contains(port, "/") {
+		return port + "/tcp"
+	}
+	return port
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,142 @@
+package containers
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// Images of the services, they can be changed before the first container is started
+var (
+	PostgresImage = "postgres:16-alpine"
+	MongoImage    = "mongo:7"
+	RedisImage    = "redis:7-alpine"
+	KafkaImage    = "apache/kafka:3.7.0"
+)
+
+// Credentials of the databases started by Postgres and Mongo
+const (
+	DatabaseUser     = "webcore"
+	DatabasePassword = "webcore"
+	DatabaseName     = "webcore_test"
+)
+
+// Postgres starts PostgreSQL, Configure sets the database section with the driver "postgres"
+func Postgres(tb testing.TB) *Container {
+	tb.Helper()
+
+	return startService(tb, Spec{
+		Image: PostgresImage,
+		Ports: []string{"5432/tcp"},
+		Env: map[string]string{
+			"POSTGRES_USER":     DatabaseUser,
+			"POSTGRES_PASSWORD": DatabasePassword,
+			"POSTGRES_DB":       DatabaseName,
+		},
+		// the server restarts once the database is initialized
+		WaitLog:        "database system is ready to accept connections",
+		WaitOccurrence: 2,
+	}, func(c *Container, cfg *config.Config) {
+		cfg.Database = config.DatabaseConfig{
+			Driver:   "postgres",
+			Host:     c.Host,
+			Port:     c.Port("5432/tcp"),
+			User:     DatabaseUser,
+			Password: DatabasePassword,
+			Name:     DatabaseName,
+			SSLMode:  "disable",
+		}
+	})
+}
+
+// Mongo starts MongoDB, Configure sets the database section with the driver "mongodb"
+func Mongo(tb testing.TB) *Container {
+	tb.Helper()
+
+	return startService(tb, Spec{
+		Image: MongoImage,
+		Ports: []string{"27017/tcp"},
+		Env: map[string]string{
+			"MONGO_INITDB_ROOT_USERNAME": DatabaseUser,
+			"MONGO_INITDB_ROOT_PASSWORD": DatabasePassword,
+		},
+		// the server restarts once the root user is created
+		WaitLog:        "Waiting for connections",
+		WaitOccurrence: 2,
+	}, func(c *Container, cfg *config.Config) {
+		cfg.Database = config.DatabaseConfig{
+			Driver:   "mongodb",
+			Uri:      fmt.Sprintf("mongodb://%s:%s@%s/?authSource=admin", DatabaseUser, DatabasePassword, c.Addr("27017/tcp")),
+			Host:     c.Host,
+			Port:     c.Port("27017/tcp"),
+			User:     DatabaseUser,
+			Password: DatabasePassword,
+			Name:     DatabaseName,
+		}
+	})
+}
+
+// Redis starts Redis, Configure sets the redis section
+func Redis(tb testing.TB) *Container {
+	tb.Helper()
+
+	return startService(tb, Spec{
+		Image:   RedisImage,
+		Ports:   []string{"6379/tcp"},
+		WaitLog: "Ready to accept connections",
+	}, func(c *Container, cfg *config.Config) {
+		cfg.Redis = config.RedisConfig{
+			Host: c.Host,
+			Port: c.Port("6379/tcp"),
+		}
+	})
+}
+
+var (
+	kafkaPort     int
+	kafkaPortErr  error
+	kafkaPortOnce sync.Once
+)
+
+// Kafka starts a single node Kafka in KRaft mode, Configure enables the kafka section
+func Kafka(tb testing.TB) *Container {
+	tb.Helper()
+
+	// the broker advertises its address to the clients, so the host port is chosen first
+	kafkaPortOnce.Do(func() {
+		kafkaPort, kafkaPortErr = freePort()
+	})
+	if kafkaPortErr != nil {
+		tb.Fatalf("containers: %v", kafkaPortErr)
+	}
+
+	return startService(tb, Spec{
+		Image: KafkaImage,
+		Ports: []string{fmt.Sprintf("%d:9092/tcp", kafkaPort)},
+		Env: map[string]string{
+			"KAFKA_NODE_ID":                                  "1",
+			"KAFKA_PROCESS_ROLES":                            "broker,controller",
+			"KAFKA_LISTENERS":                                "PLAINTEXT://:9092,CONTROLLER://:9093",
+			"KAFKA_ADVERTISED_LISTENERS":                     fmt.Sprintf("PLAINTEXT://127.0.0.1:%d", kafkaPort),
+			"KAFKA_CONTROLLER_LISTENER_NAMES":                "CONTROLLER",
+			"KAFKA_LISTENER_SECURITY_PROTOCOL_MAP":           "CONTROLLER:PLAINTEXT,PLAINTEXT:PLAINTEXT",
+			"KAFKA_CONTROLLER_QUORUM_VOTERS":                 "1@localhost:9093",
+			"KAFKA_OFFSETS_TOPIC_REPLICATION_FACTOR":         "1",
+			"KAFKA_TRANSACTION_STATE_LOG_REPLICATION_FACTOR": "1",
+			"KAFKA_TRANSACTION_STATE_LOG_MIN_ISR":            "1",
+			"KAFKA_GROUP_INITIAL_REBALANCE_DELAY_MS":         "0",
+		},
+		WaitLog: "Kafka Server started",
+	}, func(c *Container, cfg *config.Config) {
+		cfg.Kafka.Enabled = true
+		cfg.Kafka.Brokers = []string{c.Addr("9092/tcp")}
+		if cfg.Kafka.GroupID == "" {
+			cfg.Kafka.GroupID = "webcore-test"
+		}
+		if cfg.Kafka.AutoOffsetReset == "" {
+			cfg.Kafka.AutoOffsetReset = "earliest"
+		}
+	})
+}
@@ -0,0 +1,502 @@
+package coretest
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// MemoryDatabase is a port.IDatabase keeping its tables in memory. Filters support column
+// expressions with the operators =, !=, <>, <, <=, >, >=, IN, NOT IN, LIKE, IS NULL and
+// IS NOT NULL. Rows are port.DbMap, structs are converted with their db tags.
+type MemoryDatabase struct {
+	mu         sync.RWMutex
+	tables     map[string][]port.DbMap
+	nextID     int64
+	statements []string
+}
+
+// NewMemoryDatabase creates an empty database
+func NewMemoryDatabase() *MemoryDatabase {
+	return &MemoryDatabase{tables: make(map[string][]port.DbMap)}
+}
+
+func (d *MemoryDatabase) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (d *MemoryDatabase) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (d *MemoryDatabase) Connect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (d *MemoryDatabase) Disconnect() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (d *MemoryDatabase) Ping(ctx context.Context) error {
+	return nil
+}
+
+func (d *MemoryDatabase) GetConnection() any {
+	return d
+}
+
+func (d *MemoryDatabase) GetDriver() string {
+	return Driver
+}
+
+func (d *MemoryDatabase) GetName() string {
+	return "memory"
+}
+
+// Rows returns a copy of the rows of table, for assertions
+func (d *MemoryDatabase) Rows(table string) []port.DbMap {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows := make([]port.DbMap, 0, len(d.tables[table]))
+	for _, row := range d.tables[table] {
+		rows = append(rows, cloneRow(row, nil))
+	}
+	return rows
+}
+
+// Seed inserts rows in table, each row is a port.DbMap or a struct with db tags
+func (d *MemoryDatabase) Seed(table string, rows ...any) error {
+	for _, row := range rows {
+		if _, err := d.InsertOne(context.Background(), table, row); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset drops all the tables and forgets the statements
+func (d *MemoryDatabase) Reset() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.tables = make(map[string][]port.DbMap)
+	d.statements = nil
+}
+
+// Exec records statement without running it, the migrate command goes through it
+func (d *MemoryDatabase) Exec(ctx context.Context, statement string) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.statements = append(d.statements, statement)
+	return nil
+}
+
+// Statements returns the statements given to Exec, for assertions
+func (d *MemoryDatabase) Statements() []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return slices.Clone(d.statements)
+}
+
+// Transaction runs fn and restores the tables as they were when fn fails. The writes of fn are
+// not isolated: the other requests see them before fn returns.
+func (d *MemoryDatabase) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	d.mu.RLock()
+	tables := make(map[string][]port.DbMap, len(d.tables))
+	for table, rows := range d.tables {
+		tables[table] = make([]port.DbMap, 0, len(rows))
+		for _, row := range rows {
+			tables[table] = append(tables[table], cloneRow(row, nil))
+		}
+	}
+	d.mu.RUnlock()
+
+	if err := fn(ctx); err != nil {
+		d.mu.Lock()
+		d.tables = tables
+		d.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+func (d *MemoryDatabase) Count(ctx context.Context, table string, filter []port.DbExpression) (int64, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	rows, err := d.match(table, filter)
+	return int64(len(rows)), err
+}
+
+func (d *MemoryDatabase) Find(ctx context.Context, results any, table string, column []string, filter []port.DbExpression, sort map[string]int, limit int64, skip int64) error {
+	d.mu.RLock()
+	rows, err := d.match(table, filter)
+	if err != nil {
+		d.mu.RUnlock()
+		return err
+	}
+	found := make([]port.DbMap, 0, len(rows))
+	for _, i := range rows {
+		found = append(found, cloneRow(d.tables[table][i], column))
+	}
+	d.mu.RUnlock()
+
+	sortRows(found, sort)
+	if skip > 0 {
+		found = found[min(int(skip), len(found)):]
+	}
+	if limit > 0 && int(limit) < len(found) {
+		found = found[:limit]
+	}
+
+	return decodeRows(found, results)
+}
+
+func (d *MemoryDatabase) FindOne(ctx context.Context, result any, table string, column []string, filter []port.DbExpression, sort map[string]int) error {
+	rows := []port.DbMap{}
+	if err := d.Find(ctx, &rows, table, column, filter, sort, 1, 0); err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return fmt.Errorf("%s: %w", table, port.ErrRecordNotFound)
+	}
+
+	return decodeRow(rows[0], result)
+}
+
+// InsertOne stores data and returns its "id" column, a sequence number is assigned to rows
+// without one
+func (d *MemoryDatabase) InsertOne(ctx context.Context, table string, data any) (any, error) {
+	row, err := encodeRow(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if id, ok := row["id"]; ok && id != nil && !reflect.ValueOf(id).IsZero() {
+		for _, existing := range d.tables[table] {
+			if compare(existing["id"], id) == 0 {
+				return nil, fmt.Errorf("%s id %v: %w", table, id, port.ErrDuplicateKey)
+			}
+		}
+	} else {
+		d.nextID++
+		row["id"] = d.nextID
+	}
+
+	d.tables[table] = append(d.tables[table], row)
+	return row["id"], nil
+}
+
+func (d *MemoryDatabase) Update(ctx context.Context, table string, filter []port.DbExpression, data any) (int64, error) {
+	return d.update(table, filter, data, false)
+}
+
+func (d *MemoryDatabase) UpdateOne(ctx context.Context, table string, filter []port.DbExpression, data any) (int64, error) {
+	return d.update(table, filter, data, true)
+}
+
+func (d *MemoryDatabase) Delete(ctx context.Context, table string, filter []port.DbExpression) (int64, error) {
+	return d.delete(table, filter, false)
+}
+
+func (d *MemoryDatabase) DeleteOne(ctx context.Context, table string, filter []port.DbExpression) (int64, error) {
+	return d.delete(table, filter, true)
+}
+
+func (d *MemoryDatabase) StartMigration(ctx context.Context, service string, command string, dir string, args []string) error {
+	// tables are created on the first insert, the migrate command runs the files through Exec
+	return nil
+}
+
+func (d *MemoryDatabase) update(table string, filter []port.DbExpression, data any, one bool) (int64, error) {
+	values, err := encodeRow(data)
+	if err != nil {
+		return 0, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rows, err := d.match(table, filter)
+	if err != nil {
+		return 0, err
+	}
+	if one && len(rows) > 1 {
+		rows = rows[:1]
+	}
+
+	for _, i := range rows {
+		for column, value := range values {
+			d.tables[table][i][column] = value
+		}
+	}
+	return int64(len(rows)), nil
+}
+
+func (d *MemoryDatabase) delete(table string, filter []port.DbExpression, one bool) (int64, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	rows, err := d.match(table, filter)
+	if err != nil {
+		return 0, err
+	}
+	if one && len(rows) > 1 {
+		rows = rows[:1]
+	}
+
+	kept := d.tables[table][:0]
+	for i, row := range d.tables[table] {
+		if !slices.Contains(rows, i) {
+			kept = append(kept, row)
+		}
+	}
+	d.tables[table] = kept
+	return int64(len(rows)), nil
+}
+
+// match returns the index of the rows of table matching all the expressions of filter, the
+// caller holds the lock
+func (d *MemoryDatabase) match(table string, filter []port.DbExpression) ([]int, error) {
+	rows := []int{}
+	for i, row := range d.tables[table] {
+		ok := true
+		for _, expr := range filter {
+			matched, err := matchExpression(row, expr)
+			if err != nil {
+				return nil, err
+			}
+			if !matched {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			rows = append(rows, i)
+		}
+	}
+	return rows, nil
+}
+
+func matchExpression(row port.DbMap, expr port.DbExpression) (bool, error) {
+	if strings.ContainsAny(expr.Expr, " ()?$") {
+		return false, fmt.Errorf("expression %q is not supported by the memory database", expr.Expr)
+	}
+
+	value := row[expr.Expr]
+	op := strings.ToUpper(strings.TrimSpace(expr.Op))
+	switch op {
+	case "IS NULL":
+		return value == nil, nil
+	case "IS NOT NULL":
+		return value != nil, nil
+	case "IN", "NOT IN":
+		in := slices.ContainsFunc(flattenArgs(expr.Args), func(arg any) bool {
+			return compare(value, arg) == 0
+		})
+		return in == (op == "IN"), nil
+	}
+
+	if len(expr.Args) == 0 {
+		return false, fmt.Errorf("expression %q has no argument", expr.Expr)
+	}
+	arg := expr.Args[0]
+
+	switch op {
+	case "", "=", "==":
+		return compare(value, arg) == 0, nil
+	case "!=", "<>":
+		return compare(value, arg) != 0, nil
+	case "<":
+		return value != nil && compare(value, arg) < 0, nil
+	case "<=":
+		return value != nil && compare(value, arg) <= 0, nil
+	case ">":
+		return value != nil && compare(value, arg) > 0, nil
+	case ">=":
+		return value != nil && compare(value, arg) >= 0, nil
+	case "LIKE":
+		return like(fmt.Sprint(value), fmt.Sprint(arg)), nil
+	}
+	return false, fmt.Errorf("operator %q is not supported by the memory database", expr.Op)
+}
+
+// flattenArgs accepts the values of IN given one by one or as a single slice
+func flattenArgs(args []any) []any {
+	if len(args) != 1 {
+		return args
+	}
+
+	v := reflect.ValueOf(args[0])
+	if v.Kind() != reflect.Slice || v.Type().Elem().Kind() == reflect.Uint8 {
+		return args
+	}
+
+	values := make([]any, v.Len())
+	for i := range values {
+		values[i] = v.Index(i).Interface()
+	}
+	return values
+}
+
+// compare orders numbers by value, times chronologically and anything else by its text
+func compare(a, b any) int {
+	if a == nil || b == nil {
+		switch {
+		case a == nil && b == nil:
+			return 0
+		case a == nil:
+			return -1
+		default:
+			return 1
+		}
+	}
+
+	if x, ok := number(a); ok {
+		if y, ok := number(b); ok {
+			switch {
+			case x < y:
+				return -1
+			case x > y:
+				return 1
+			}
+			return 0
+		}
+	}
+
+	if x, ok := a.(time.Time); ok {
+		if y, ok := b.(time.Time); ok {
+			return x.Compare(y)
+		}
+	}
+
+	return strings.Compare(fmt.Sprint(a), fmt.Sprint(b))
+}
+
+func number(v any) (float64, bool) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// like matches the SQL LIKE pattern, % for any text and _ for one character
+func like(value, pattern string) bool {
+	if pattern == "" {
+		return value == ""
+	}
+
+	switch pattern[0] {
+	case '%':
+		for i := 0; i <= len(value); i++ {
+			if like(value[i:], pattern[1:]) {
+				return true
+			}
+		}
+		return false
+	case '_':
+		return value != "" && like(value[1:], pattern[1:])
+	}
+	return value != "" && value[0] == pattern[0] && like(value[1:], pattern[1:])
+}
+
+// sortRows orders rows by the columns of order (1 ascending, -1 descending), by column name
+// as the order of a map is random
+func sortRows(rows []port.DbMap, order map[string]int) {
+	if len(order) == 0 {
+		return
+	}
+
+	columns := make([]string, 0, len(order))
+	for column := range order {
+		columns = append(columns, column)
+	}
+	slices.Sort(columns)
+
+	sort.SliceStable(rows, func(i, j int) bool {
+		for _, column := range columns {
+			c := compare(rows[i][column], rows[j][column])
+			if c != 0 {
+				return (c < 0) == (order[column] >= 0)
+			}
+		}
+		return false
+	})
+}
+
+func cloneRow(row port.DbMap, column []string) port.DbMap {
+	clone := make(port.DbMap, len(row))
+	for k, v := range row {
+		if len(column) == 0 || slices.Contains(column, k) {
+			clone[k] = v
+		}
+	}
+	return clone
+}
+
+func encodeRow(data any) (port.DbMap, error) {
+	switch v := data.(type) {
+	case port.DbMap:
+		return cloneRow(v, nil), nil
+	case map[string]any:
+		return cloneRow(v, nil), nil
+	}
+	return helper.MarshalDbMap(data)
+}
+
+func decodeRow(row port.DbMap, result any) error {
+	switch dst := result.(type) {
+	case *port.DbMap:
+		*dst = row
+		return nil
+	case *map[string]any:
+		*dst = row
+		return nil
+	}
+	return helper.UnmarshalDbMap(row, result)
+}
+
+// decodeRows fills a pointer to a slice of port.DbMap, maps or structs
+func decodeRows(rows []port.DbMap, results any) error {
+	v := reflect.ValueOf(results)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("results harus berupa pointer ke slice")
+	}
+
+	slice := v.Elem()
+	elem := slice.Type().Elem()
+	out := reflect.MakeSlice(slice.Type(), 0, len(rows))
+	for _, row := range rows {
+		item := reflect.New(elem)
+		if err := decodeRow(row, item.Interface()); err != nil {
+			return err
+		}
+		out = reflect.Append(out, item.Elem())
+	}
+	slice.Set(out)
+	return nil
+}
@@ -0,0 +1,15 @@
+package coretest_test
+
+import (
+	"testing"
+
+	"github.com/webcore-go/webcore/app/core/coretest"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/dbtest"
+)
+
+func TestMemoryDatabaseConformance(t *testing.T) {
+	dbtest.TestDatabase(t, func(t *testing.T) port.IDatabase {
+		return coretest.NewMemoryDatabase()
+	})
+}
@@ -0,0 +1,302 @@
+// Package coretest runs an application in process for the integration tests of modules: the
+// AppContext is started with the selected modules and in-memory libraries (database, cache,
+// pub/sub broker), and requests are served by the Fiber app without listening.
+//
+//	h := coretest.New(t, coretest.Options{Modules: []core.Module{orders.NewModule()}})
+//	h.DB.Seed("orders", port.DbMap{"id": 1, "status": "paid"})
+//	resp, err := h.As(coretest.User("u1", "orders.read")).Get(h.URL("/api/orders/1"))
+package coretest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/spf13/viper"
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+const (
+	// Driver is the database driver and the authentication type of the harness
+	Driver = "coretest"
+
+	// PrincipalHeader carries the principal of the requests sent by the clients of As
+	PrincipalHeader = "X-Coretest-Principal"
+
+	// BaseURL is the address of the application in the URLs given to the clients
+	BaseURL = "http://coretest.local"
+)
+
+// Options selects what the application runs
+type Options struct {
+	Modules []core.Module
+
+	// Loaders adds libraries, or replaces the fakes registered under the same name
+	// ("database:coretest", "memory", "pubsub", "authentication:coretest")
+	Loaders map[string]core.LibraryLoader
+
+	// Configure changes the configuration, the defaults of config.Config already set to
+	// use the fakes
+	Configure func(cfg *config.Config)
+
+	// Snapshots are the options of Harness.Snapshot
+	Snapshots SnapshotOptions
+}
+
+// Harness is an application started for a test, it is stopped when the test ends
+type Harness struct {
+	App     *core.App
+	Context *core.AppContext
+	Config  *config.Config
+
+	DB     *MemoryDatabase
+	Cache  *MemoryCache
+	Broker *MemoryBroker
+
+	// Logs captures the lines logged by the application, at app.logging.level ("warn")
+	Logs *logger.Capture
+
+	snapshots  SnapshotOptions
+	cancel     context.CancelFunc
+	restoreLog func()
+	mu         sync.Mutex
+	principals map[string]auth.IUserAuthInfo
+}
+
+// New starts the application with the modules of opts. Only one application exists at a
+// time, so tests using a harness must not run in parallel.
+func New(tb testing.TB, opts Options) *Harness {
+	tb.Helper()
+
+	if core.Instance() != nil {
+		tb.Fatal("coretest: an application is already running")
+	}
+
+	cfg, err := defaultConfig()
+	if err != nil {
+		tb.Fatalf("coretest: %v", err)
+	}
+	cfg.App.Environment = "test"
+	cfg.App.Logging.Level = "warn"
+	// Fiber refuses credentials for the wildcard origin of the defaults
+	cfg.App.CORS.AllowCredentials = false
+	cfg.Database.Driver = Driver
+	cfg.Database.Uri = "memory://" + Driver
+	cfg.Memory.Enabled = true
+	cfg.App.Features.Metrics = true // reported by the load scenarios
+	cfg.Auth.Type = Driver
+	if opts.Configure != nil {
+		opts.Configure(cfg)
+	}
+
+	// error responses carry stack traces in development only
+	out.SetEnvironment(cfg.App.Environment)
+
+	h := &Harness{
+		Config:     cfg,
+		DB:         NewMemoryDatabase(),
+		Logs:       logger.NewCapture(),
+		snapshots:  opts.Snapshots,
+		principals: make(map[string]auth.IUserAuthInfo),
+	}
+
+	loaders := map[string]core.LibraryLoader{}
+	for name, loader := range opts.Loaders {
+		loaders[name] = loader
+	}
+	fakes := map[string]core.LibraryLoader{
+		"database:" + Driver:       &fakeLoader{library: h.DB},
+		"memory":                   &fakeLoader{library: func() port.Library { return h.Cache }},
+		"pubsub":                   &fakeLoader{library: func() port.Library { return h.Broker }},
+		"authentication:" + Driver: &fakeLoader{library: &authenticator{harness: h}},
+	}
+	for name, loader := range fakes {
+		if _, ok := loaders[name]; !ok {
+			loaders[name] = loader
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	h.cancel = cancel
+	h.App = core.NewApp(ctx, cfg, loaders, opts.Modules)
+	h.Context = h.App.Context
+	h.restoreLog = logger.SetBackend(h.Logs)
+
+	// the cache and the broker follow the clock of the application
+	h.Cache = NewMemoryCache(h.Context.Clock)
+	h.Broker = NewMemoryBroker(h.Context.Clock)
+
+	// the core does not load the broker, modules find it as the "pubsub" singleton
+	if _, err := h.App.LibraryManager.LoadSingletonFromLoader(loaders["pubsub"], h.Context, cfg.PubSub); err != nil {
+		h.Close()
+		tb.Fatalf("coretest: failed to load pubsub: %v", err)
+	}
+
+	if err := h.App.Prepare(); err != nil {
+		h.Close()
+		tb.Fatalf("coretest: %v", err)
+	}
+	h.Context.Readiness.SetReady(true)
+
+	tb.Cleanup(h.Close)
+	return h
+}
+
+// Close stops the application, a new harness can be created afterwards
+func (h *Harness) Close() {
+	if h.App == nil {
+		return
+	}
+
+	h.App.Stop()
+	h.cancel()
+	h.restoreLog()
+	h.App = nil
+}
+
+// URL returns the absolute URL of path (ex: h.URL("/api/orders"))
+func (h *Harness) URL(path string) string {
+	return BaseURL + "/" + strings.TrimPrefix(path, "/")
+}
+
+// Client returns a client sending anonymous requests, rejected by the authentication of the
+// protected routes
+func (h *Harness) Client() *http.Client {
+	return &http.Client{Transport: &transport{harness: h}}
+}
+
+// As returns a client whose requests are authenticated as user
+func (h *Harness) As(user auth.IUserAuthInfo) *http.Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	token := fmt.Sprintf("principal-%d", len(h.principals)+1)
+	h.principals[token] = user
+	return &http.Client{Transport: &transport{harness: h, principal: token}}
+}
+
+// Do sends req as user, anonymously when user is nil
+func (h *Harness) Do(req *http.Request, user auth.IUserAuthInfo) (*http.Response, error) {
+	if user == nil {
+		return h.Client().Do(req)
+	}
+	return h.As(user).Do(req)
+}
+
+// User creates an RBAC principal with roles
+func User(id string, roles ...string) *auth.UserAuthInfoRBAC {
+	return &auth.UserAuthInfoRBAC{
+		UserId:   id,
+		Username: &id,
+		Roles:    roles,
+	}
+}
+
+// defaultConfig returns the configuration with the default values only, configuration files
+// and environment variables are ignored so the tests do not depend on the machine
+func defaultConfig() (*config.Config, error) {
+	cfg := &config.Config{}
+
+	v := viper.New()
+	for key, value := range cfg.SetDefaults() {
+		v.SetDefault(key, value)
+	}
+	if err := v.Unmarshal(cfg); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// transport hands the requests to the Fiber app
+type transport struct {
+	harness   *Harness
+	principal string
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.harness.App == nil {
+		return nil, fmt.Errorf("coretest: the application is stopped")
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Del(PrincipalHeader)
+	if t.principal != "" {
+		req.Header.Set(PrincipalHeader, t.principal)
+	}
+
+	return t.harness.Context.Web.Test(req, -1)
+}
+
+// fakeLoader returns the same library on every Init, library may be a function when the
+// library is created after the loaders are registered
+type fakeLoader struct {
+	name    string
+	library any
+}
+
+func (l *fakeLoader) SetName(name string) {
+	l.name = name
+}
+
+func (l *fakeLoader) Name() string {
+	return l.name
+}
+
+func (l *fakeLoader) Init(args ...any) (port.Library, error) {
+	library, ok := l.library.(port.Library)
+	if !ok {
+		library = l.library.(func() port.Library)()
+	}
+
+	err := library.Install(args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return library, nil
+}
+
+// authenticator authenticates the requests with the principal registered by Harness.As
+type authenticator struct {
+	harness *Harness
+}
+
+func (a *authenticator) Install(args ...any) error {
+	// Let out.Send hide fields tagged with expose from principals without the role
+	out.SetRolesResolver(auth.CurrentUserRoles)
+	return nil
+}
+
+func (a *authenticator) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (a *authenticator) GetAuthenticatonHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token := c.Get(PrincipalHeader)
+		if token == "" {
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "Principal header required"))
+		}
+
+		a.harness.mu.Lock()
+		user, ok := a.harness.principals[token]
+		a.harness.mu.Unlock()
+		if !ok {
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "Unknown principal"))
+		}
+
+		auth.SetCurrentUser(c, user)
+
+		return c.Next()
+	}
+}
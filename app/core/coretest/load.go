@@ -0,0 +1,242 @@
+package coretest
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/webcore-go/webcore/infra/middleware"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// EventLoadCompleted is published on the EventBus of the harness with the LoadReport of every
+// scenario
+const EventLoadCompleted = "loadtest.completed"
+
+// LoadScenario drives the routes of the application with concurrent requests, for a number
+// of requests or for a duration (soak tests)
+type LoadScenario struct {
+	Name string
+
+	// Request builds the i-th request, its URL is given by Harness.URL
+	Request func(i int) (*http.Request, error)
+	User    auth.IUserAuthInfo // anonymous when nil
+
+	Concurrency int           // concurrent clients, 10 by default
+	Requests    int           // requests sent, unless Duration is set
+	Duration    time.Duration // sends requests until it elapsed
+
+	// Expect checks a response, by default the requests answered with a status >= 400 fail
+	Expect func(resp *http.Response) error
+
+	// Assertions, the test fails when they are exceeded (0 to skip)
+	MaxErrorRate float64 // between 0 and 1
+	MaxP50       time.Duration
+	MaxP95       time.Duration
+	MaxP99       time.Duration
+}
+
+// LatencySummary gives the distribution of latencies
+type LatencySummary struct {
+	Count int           `json:"count"`
+	Mean  time.Duration `json:"mean"`
+	P50   time.Duration `json:"p50"`
+	P90   time.Duration `json:"p90"`
+	P95   time.Duration `json:"p95"`
+	P99   time.Duration `json:"p99"`
+	Max   time.Duration `json:"max"`
+}
+
+// LoadReport is the result of a LoadScenario
+type LoadReport struct {
+	Scenario   string                    `json:"scenario"`
+	Requests   int                       `json:"requests"`
+	Errors     int                       `json:"errors"`
+	ErrorRate  float64                   `json:"error_rate"`
+	Duration   time.Duration             `json:"duration"`
+	Throughput float64                   `json:"throughput"` // requests per second
+	Latency    LatencySummary            `json:"latency"`    // measured by the clients
+	Routes     map[string]LatencySummary `json:"routes"`     // measured by the metrics middleware, by method and route (ex: GET /api/orders/:id)
+	Status     map[int]int               `json:"status"`
+	Failures   []string                  `json:"failures,omitempty"` // first failures
+}
+
+const maxLoadFailures = 10
+
+// Load runs scenario, reports its result in the test log and on the EventBus, and fails the
+// test when an assertion of the scenario is exceeded
+func (h *Harness) Load(tb testing.TB, scenario LoadScenario) LoadReport {
+	tb.Helper()
+
+	if scenario.Request == nil {
+		tb.Fatalf("coretest: load scenario %s has no request", scenario.Name)
+	}
+	concurrency := scenario.Concurrency
+	if concurrency <= 0 {
+		concurrency = 10
+	}
+	if scenario.Requests <= 0 && scenario.Duration <= 0 {
+		scenario.Requests = 100
+	}
+	expect := scenario.Expect
+	if expect == nil {
+		expect = func(resp *http.Response) error {
+			if resp.StatusCode >= 400 {
+				return fmt.Errorf("status %d", resp.StatusCode)
+			}
+			return nil
+		}
+	}
+
+	client := h.Client()
+	if scenario.User != nil {
+		client = h.As(scenario.User)
+	}
+
+	var mu sync.Mutex
+	latencies := []time.Duration{}
+	routes := map[string][]time.Duration{}
+	report := LoadReport{Scenario: scenario.Name, Status: map[int]int{}}
+
+	// the server side latencies of the requests of the scenario, as seen by the middleware
+	removeObserver := middleware.ObserveMetrics(func(m middleware.RequestMetric) {
+		mu.Lock()
+		defer mu.Unlock()
+		key := m.Method + " " + m.Route
+		routes[key] = append(routes[key], m.Latency)
+	})
+	defer removeObserver()
+
+	fail := func(msg string) {
+		report.Errors++
+		if len(report.Failures) < maxLoadFailures {
+			report.Failures = append(report.Failures, msg)
+		}
+	}
+
+	var next atomic.Int64
+	start := time.Now()
+	deadline := start.Add(scenario.Duration)
+
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				i := int(next.Add(1) - 1)
+				if scenario.Duration > 0 {
+					if time.Now().After(deadline) {
+						return
+					}
+				} else if i >= scenario.Requests {
+					return
+				}
+
+				req, err := scenario.Request(i)
+				if err != nil {
+					mu.Lock()
+					report.Requests++
+					fail(fmt.Sprintf("request %d: %v", i, err))
+					mu.Unlock()
+					continue
+				}
+
+				sent := time.Now()
+				resp, err := client.Do(req)
+				if err == nil {
+					io.Copy(io.Discard, resp.Body)
+					resp.Body.Close()
+				}
+				latency := time.Since(sent)
+
+				mu.Lock()
+				report.Requests++
+				latencies = append(latencies, latency)
+				if err != nil {
+					fail(fmt.Sprintf("%s %s: %v", req.Method, req.URL.Path, err))
+				} else {
+					report.Status[resp.StatusCode]++
+					if err := expect(resp); err != nil {
+						fail(fmt.Sprintf("%s %s: %v", req.Method, req.URL.Path, err))
+					}
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	removeObserver()
+
+	report.Duration = time.Since(start)
+	if report.Requests > 0 {
+		report.ErrorRate = float64(report.Errors) / float64(report.Requests)
+	}
+	if report.Duration > 0 {
+		report.Throughput = float64(report.Requests) / report.Duration.Seconds()
+	}
+	report.Latency = summarizeLatencies(latencies)
+	report.Routes = map[string]LatencySummary{}
+	for route, values := range routes {
+		report.Routes[route] = summarizeLatencies(values)
+	}
+
+	tb.Logf("coretest: load %s: %d requests in %s (%.0f/s), %.2f%% errors, latency p50 %s p95 %s p99 %s max %s",
+		scenario.Name, report.Requests, report.Duration.Round(time.Millisecond), report.Throughput, report.ErrorRate*100,
+		report.Latency.P50, report.Latency.P95, report.Latency.P99, report.Latency.Max)
+	h.Context.EventBus.Publish(EventLoadCompleted, report)
+
+	if scenario.MaxErrorRate > 0 && report.ErrorRate > scenario.MaxErrorRate {
+		tb.Errorf("coretest: load %s: error rate %.2f%% exceeds %.2f%%, first failures: %v", scenario.Name, report.ErrorRate*100, scenario.MaxErrorRate*100, report.Failures)
+	}
+	for _, check := range []struct {
+		name      string
+		got, want time.Duration
+	}{
+		{"p50", report.Latency.P50, scenario.MaxP50},
+		{"p95", report.Latency.P95, scenario.MaxP95},
+		{"p99", report.Latency.P99, scenario.MaxP99},
+	} {
+		if check.want > 0 && check.got > check.want {
+			tb.Errorf("coretest: load %s: latency %s %s exceeds %s", scenario.Name, check.name, check.got, check.want)
+		}
+	}
+
+	return report
+}
+
+func summarizeLatencies(values []time.Duration) LatencySummary {
+	if len(values) == 0 {
+		return LatencySummary{}
+	}
+
+	sorted := slices.Clone(values)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, v := range sorted {
+		total += v
+	}
+
+	percentile := func(p float64) time.Duration {
+		i := int(math.Ceil(p*float64(len(sorted)))) - 1
+		return sorted[max(i, 0)]
+	}
+
+	return LatencySummary{
+		Count: len(sorted),
+		Mean:  total / time.Duration(len(sorted)),
+		P50:   percentile(0.50),
+		P90:   percentile(0.90),
+		P95:   percentile(0.95),
+		P99:   percentile(0.99),
+		Max:   sorted[len(sorted)-1],
+	}
+}
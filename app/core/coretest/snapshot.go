@@ -0,0 +1,229 @@
+package coretest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// UpdateSnapshotsEnv set to 1 rewrites the snapshots instead of comparing them
+// (ex: UPDATE_SNAPSHOTS=1 go test ./...)
+const UpdateSnapshotsEnv = "UPDATE_SNAPSHOTS"
+
+// SnapshotOptions tells what a snapshot records
+type SnapshotOptions struct {
+	Dir     string   // directory of the snapshots, testdata/snapshots by default
+	Headers []string // headers recorded, Content-Type by default
+	// Ignore replaces JSON fields by "<ignored>", with dotted paths where * is any key or
+	// index (ex: "data.token", "data.items.*.created_at")
+	Ignore []string
+}
+
+var (
+	snapshotUUID = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+	snapshotTime = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}(\.\d+)?(Z|[+-]\d{2}:\d{2})$`)
+)
+
+// Snapshot sends req as user (anonymously when nil) and matches the response with the
+// snapshot name, using the snapshot options of the harness. The body of the returned
+// response can be read again.
+func (h *Harness) Snapshot(tb testing.TB, name string, req *http.Request, user auth.IUserAuthInfo) *http.Response {
+	tb.Helper()
+
+	resp, err := h.Do(req, user)
+	if err != nil {
+		tb.Fatalf("coretest: %s %s: %v", req.Method, req.URL.Path, err)
+	}
+
+	MatchSnapshot(tb, name, resp, h.snapshots)
+	return resp
+}
+
+// MatchSnapshot compares the status, the headers and the body of resp with the snapshot
+// name of the test, the snapshot is created when it does not exist yet. JSON bodies are
+// indented with sorted keys, UUIDs and RFC 3339 times are replaced by placeholders.
+func MatchSnapshot(tb testing.TB, name string, resp *http.Response, opts SnapshotOptions) {
+	tb.Helper()
+
+	got, err := snapshotResponse(resp, opts)
+	if err != nil {
+		tb.Fatalf("coretest: snapshot %s: %v", name, err)
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		dir = filepath.Join("testdata", "snapshots")
+	}
+	file := filepath.Join(dir, snapshotFileName(tb.Name()), snapshotFileName(name)+".snap")
+
+	want, err := os.ReadFile(file)
+	if os.IsNotExist(err) || os.Getenv(UpdateSnapshotsEnv) == "1" {
+		if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+			tb.Fatalf("coretest: %v", err)
+		}
+		if err := os.WriteFile(file, got, 0o644); err != nil {
+			tb.Fatalf("coretest: %v", err)
+		}
+		tb.Logf("coretest: snapshot %s written", file)
+		return
+	}
+	if err != nil {
+		tb.Fatalf("coretest: %v", err)
+	}
+
+	if !bytes.Equal(want, got) {
+		tb.Errorf("coretest: response differs from snapshot %s (set %s=1 to update it):\n%s", file, UpdateSnapshotsEnv, lineDiff(string(want), string(got)))
+	}
+}
+
+// snapshotResponse renders resp and restores its body
+func snapshotResponse(resp *http.Response, opts SnapshotOptions) ([]byte, error) {
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var sb strings.Builder
+	if resp.Request != nil {
+		fmt.Fprintf(&sb, "%s %s\n", resp.Request.Method, resp.Request.URL.RequestURI())
+	}
+	fmt.Fprintf(&sb, "HTTP %d\n", resp.StatusCode)
+
+	headers := opts.Headers
+	if len(headers) == 0 {
+		headers = []string{"Content-Type"}
+	}
+	for _, header := range headers {
+		if value := resp.Header.Get(header); value != "" {
+			fmt.Fprintf(&sb, "%s: %s\n", http.CanonicalHeaderKey(header), value)
+		}
+	}
+	sb.WriteString("\n")
+
+	var doc any
+	if json.Valid(body) && json.Unmarshal(body, &doc) == nil {
+		for _, path := range opts.Ignore {
+			doc = ignorePath(doc, strings.Split(path, "."))
+		}
+		doc = normalizeVolatile(doc)
+
+		var indented bytes.Buffer
+		enc := json.NewEncoder(&indented)
+		enc.SetEscapeHTML(false)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		sb.Write(indented.Bytes())
+	} else {
+		sb.Write(body)
+	}
+	if !strings.HasSuffix(sb.String(), "\n") {
+		sb.WriteString("\n")
+	}
+
+	return []byte(sb.String()), nil
+}
+
+func ignorePath(doc any, path []string) any {
+	if len(path) == 0 {
+		return "<ignored>"
+	}
+
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			if path[0] == "*" || path[0] == key {
+				v[key] = ignorePath(value, path[1:])
+			}
+		}
+	case []any:
+		for i, value := range v {
+			if path[0] == "*" || path[0] == fmt.Sprint(i) {
+				v[i] = ignorePath(value, path[1:])
+			}
+		}
+	}
+	return doc
+}
+
+func normalizeVolatile(doc any) any {
+	switch v := doc.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = normalizeVolatile(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = normalizeVolatile(value)
+		}
+	case string:
+		switch {
+		case snapshotUUID.MatchString(v):
+			return "<uuid>"
+		case snapshotTime.MatchString(v):
+			return "<time>"
+		}
+	}
+	return doc
+}
+
+func snapshotFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, name)
+}
+
+// lineDiff lists the lines removed (-) and added (+) between want and got
+func lineDiff(want, got string) string {
+	a := strings.Split(want, "\n")
+	b := strings.Split(got, "\n")
+
+	// longest common subsequence of the lines
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var sb strings.Builder
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			sb.WriteString("  " + a[i] + "\n")
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			sb.WriteString("- " + a[i] + "\n")
+			i++
+		default:
+			sb.WriteString("+ " + b[j] + "\n")
+			j++
+		}
+	}
+	return sb.String()
+}
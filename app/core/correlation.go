@@ -0,0 +1,24 @@
+package core
+
+import (
+	"context"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// CorrelatePubSub returns pubsub whose messages carry the request ID and the traceparent of the
+// context they are published from, in the attributes helper.RequestIDAttribute and
+// helper.TraceParentAttribute. A receiver restores them for the handling of each message with
+// helper.CorrelationContext(ctx, message.GetAttributes()).
+func CorrelatePubSub(pubsub port.IPubSub) port.IPubSub {
+	return &correlatedPubSub{IPubSub: pubsub}
+}
+
+type correlatedPubSub struct {
+	port.IPubSub
+}
+
+func (p *correlatedPubSub) Publish(ctx context.Context, message any, attributes map[string]string) (string, error) {
+	return p.IPubSub.Publish(ctx, message, helper.CorrelationAttributes(ctx, attributes))
+}
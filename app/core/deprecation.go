@@ -0,0 +1,317 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Deprecation marks a route or a whole API version as deprecated. The responses carry the
+// Deprecation, Sunset and Link headers, and the callers are counted for the usage report.
+type Deprecation struct {
+	Since     time.Time // deprecated since, zero announces the deprecation without date
+	Sunset    time.Time // removal date, zero when it is not planned yet
+	Link      string    // documentation of the deprecation (ex: migration guide)
+	Successor string    // route or version replacing the deprecated one
+}
+
+// DeprecatedEndpoint is the usage of a deprecated route or version, in the report of Deprecations
+type DeprecatedEndpoint struct {
+	Method   string             `json:"method"` // empty for every method
+	Path     string             `json:"path"`
+	Since    *time.Time         `json:"since,omitempty"`
+	Sunset   *time.Time         `json:"sunset,omitempty"`
+	Calls    int64              `json:"calls"`
+	LastCall *time.Time         `json:"last_call,omitempty"`
+	Clients  []DeprecatedClient `json:"clients"` // most recent callers first
+}
+
+// DeprecatedClient is a caller of a deprecated endpoint: the authenticated user, or its IP
+// address ("ip:<address>") on public routes
+type DeprecatedClient struct {
+	Client    string    `json:"client"`
+	Calls     int64     `json:"calls"`
+	FirstCall time.Time `json:"first_call"`
+	LastCall  time.Time `json:"last_call"`
+}
+
+// deprecatedRoute is a deprecation of app.deprecation.rules or of Deprecate
+type deprecatedRoute struct {
+	method      string
+	path        string
+	deprecation Deprecation
+}
+
+// Deprecations announces the deprecated routes and versions to their callers and reports who
+// still calls them. With app.deprecation.enforce, the calls after the sunset date are refused
+// with 410 Gone.
+type Deprecations struct {
+	mu     sync.RWMutex
+	config config.DeprecationConfig
+	clock  helper.Clock
+	routes []deprecatedRoute
+	usage  map[string]*DeprecatedEndpoint
+}
+
+func NewDeprecations(cfg config.DeprecationConfig, clock helper.Clock) *Deprecations {
+	if cfg.MaxClients <= 0 {
+		cfg.MaxClients = 1000
+	}
+
+	return &Deprecations{
+		config: cfg,
+		clock:  clock,
+		usage:  make(map[string]*DeprecatedEndpoint),
+	}
+}
+
+// LoadRules adds the deprecations of app.deprecation.rules
+func (d *Deprecations) LoadRules() error {
+	for i, rule := range d.config.Rules {
+		if rule.Path == "" {
+			return fmt.Errorf("app.deprecation.rules[%d]: path is required", i)
+		}
+
+		deprecation, err := newDeprecation(rule.Since, rule.Sunset, rule.Link, rule.Successor)
+		if err != nil {
+			return fmt.Errorf("app.deprecation.rules[%d].%v", i, err)
+		}
+		d.Deprecate(rule.Method, rule.Path, deprecation)
+	}
+	return nil
+}
+
+// LoadVersions deprecates the routes below prefix (server.path_prefix) of the versions of
+// app.versioning.versions marked deprecated
+func (d *Deprecations) LoadVersions(prefix string, versions map[string]config.VersionConfig) error {
+	for _, version := range slices.Sorted(maps.Keys(versions)) {
+		cfg := versions[version]
+		if !cfg.Deprecated {
+			continue
+		}
+
+		deprecation, err := newDeprecation(cfg.Since, cfg.Sunset, cfg.Link, cfg.Successor)
+		if err != nil {
+			return fmt.Errorf("app.versioning.versions.%s.%v", version, err)
+		}
+		d.Deprecate("", prefix+"/"+version+"/*", deprecation)
+	}
+	return nil
+}
+
+// newDeprecation reads the dates of a deprecation of the configuration, the error names the
+// field at fault
+func newDeprecation(since string, sunset string, link string, successor string) (Deprecation, error) {
+	deprecation := Deprecation{Link: link, Successor: successor}
+	var err error
+	if deprecation.Since, err = parseDeprecationDate(since); err != nil {
+		return deprecation, fmt.Errorf("since: %v", err)
+	}
+	if deprecation.Sunset, err = parseDeprecationDate(sunset); err != nil {
+		return deprecation, fmt.Errorf("sunset: %v", err)
+	}
+	return deprecation, nil
+}
+
+// parseDeprecationDate reads a date (2006-01-02) or a time (RFC 3339), empty is the zero time
+func parseDeprecationDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// Deprecate marks the requests matching method (empty for every method) and path as deprecated.
+// The path is a route (ex: "/api/orders/:id") or a prefix ending with "/*" for a whole version
+// (ex: "/api/v1/*"). The routes of the modules rather set ModuleRoute.Deprecation.
+func (d *Deprecations) Deprecate(method string, path string, deprecation Deprecation) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.routes = append(d.routes, deprecatedRoute{method: strings.ToUpper(method), path: path, deprecation: deprecation})
+}
+
+// Middleware applies the deprecations of Deprecate to the requests of their paths
+func (d *Deprecations) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		route, ok := d.match(c.Method(), c.Path())
+		if !ok {
+			return c.Next()
+		}
+
+		if err := d.announce(c, route.deprecation); err != nil {
+			d.record(c, route.method, route.path, route.deprecation)
+			return err
+		}
+
+		// recorded once handled, the user is authenticated by then on the protected routes
+		err := c.Next()
+		d.record(c, route.method, route.path, route.deprecation)
+		return err
+	}
+}
+
+// Handler applies a deprecation to the route it is added to, AppendRouteToArray adds it to the
+// routes with a Deprecation
+func (d *Deprecations) Handler(deprecation Deprecation) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// the pattern of the route, not the path of the request
+		method, path := c.Method(), c.Route().Path
+		if err := d.announce(c, deprecation); err != nil {
+			d.record(c, method, path, deprecation)
+			return err
+		}
+
+		err := c.Next()
+		d.record(c, method, path, deprecation)
+		return err
+	}
+}
+
+func (d *Deprecations) match(method string, path string) (deprecatedRoute, bool) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	for _, route := range d.routes {
+		if route.method != "" && route.method != method {
+			continue
+		}
+		if matchRoutePath(route.path, path) {
+			return route, true
+		}
+	}
+	return deprecatedRoute{}, false
+}
+
+// matchRoutePath tells whether path matches the route pattern: ":name" matches a segment and a
+// trailing "*" the rest of the path
+func matchRoutePath(pattern string, path string) bool {
+	patternParts := strings.Split(strings.Trim(pattern, "/"), "/")
+	pathParts := strings.Split(strings.Trim(path, "/"), "/")
+
+	for i, part := range patternParts {
+		if part == "*" && i == len(patternParts)-1 {
+			return true
+		}
+		if i >= len(pathParts) {
+			return false
+		}
+		if strings.HasPrefix(part, ":") {
+			if pathParts[i] == "" {
+				return false
+			}
+			continue
+		}
+		if part != pathParts[i] {
+			return false
+		}
+	}
+	return len(patternParts) == len(pathParts)
+}
+
+// announce sets the headers of the deprecation, it returns 410 Gone when enforced after the sunset
+func (d *Deprecations) announce(c *fiber.Ctx, deprecation Deprecation) error {
+	if deprecation.Since.IsZero() {
+		c.Set("Deprecation", "true")
+	} else {
+		c.Set("Deprecation", "@"+strconv.FormatInt(deprecation.Since.Unix(), 10))
+	}
+	if !deprecation.Sunset.IsZero() {
+		c.Set("Sunset", deprecation.Sunset.UTC().Format(http.TimeFormat))
+	}
+	if deprecation.Link != "" {
+		c.Append("Link", "<"+deprecation.Link+`>; rel="deprecation"; type="text/html"`)
+	}
+	if deprecation.Successor != "" {
+		c.Append("Link", "<"+deprecation.Successor+`>; rel="successor-version"`)
+	}
+
+	if d.config.Enforce && !deprecation.Sunset.IsZero() && !d.clock.Now().Before(deprecation.Sunset) {
+		return fiber.NewError(fiber.StatusGone, "This endpoint was removed on "+deprecation.Sunset.UTC().Format(time.DateOnly))
+	}
+	return nil
+}
+
+// record counts the call of the client, its first call of the endpoint is logged
+func (d *Deprecations) record(c *fiber.Ctx, method string, path string, deprecation Deprecation) {
+	client := auth.CurrentUserID(c)
+	if client == "" {
+		client = "ip:" + c.IP()
+	}
+	now := d.clock.Now()
+
+	d.mu.Lock()
+	key := method + " " + path
+	endpoint, ok := d.usage[key]
+	if !ok {
+		endpoint = &DeprecatedEndpoint{Method: method, Path: strings.Clone(path)}
+		if !deprecation.Since.IsZero() {
+			endpoint.Since = &deprecation.Since
+		}
+		if !deprecation.Sunset.IsZero() {
+			endpoint.Sunset = &deprecation.Sunset
+		}
+		d.usage[key] = endpoint
+	}
+	endpoint.Calls++
+	endpoint.LastCall = &now
+
+	first := false
+	index := -1
+	for i := range endpoint.Clients {
+		if endpoint.Clients[i].Client == client {
+			index = i
+			break
+		}
+	}
+	if index >= 0 {
+		endpoint.Clients[index].Calls++
+		endpoint.Clients[index].LastCall = now
+	} else if len(endpoint.Clients) < d.config.MaxClients {
+		// the calls of the clients beyond max_clients are only counted on the endpoint
+		endpoint.Clients = append(endpoint.Clients, DeprecatedClient{Client: client, Calls: 1, FirstCall: now, LastCall: now})
+		first = true
+	}
+	d.mu.Unlock()
+
+	if first {
+		logger.Warn("Deprecated endpoint called", "method", c.Method(), "path", path, "client", client, "sunset", endpoint.Sunset)
+	}
+}
+
+// Report returns the usage of the deprecated endpoints called since the start, sorted by path
+func (d *Deprecations) Report() []DeprecatedEndpoint {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	report := make([]DeprecatedEndpoint, 0, len(d.usage))
+	for _, endpoint := range d.usage {
+		entry := *endpoint
+		entry.Clients = append([]DeprecatedClient{}, endpoint.Clients...)
+		sort.Slice(entry.Clients, func(i, j int) bool {
+			return entry.Clients[i].LastCall.After(entry.Clients[j].LastCall)
+		})
+		report = append(report, entry)
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Path != report[j].Path {
+			return report[i].Path < report[j].Path
+		}
+		return report[i].Method < report[j].Method
+	})
+	return report
+}
@@ -0,0 +1,256 @@
+package core
+
+import (
+	"bufio"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// Kinds of tailed entries
+const (
+	TailLog   = "log"
+	TailEvent = "event"
+)
+
+// TailEntry is a log line or an EventBus event streamed by DevTail
+type TailEntry struct {
+	ID      uint64         `json:"id"`
+	Kind    string         `json:"kind"`
+	Time    time.Time      `json:"time"`
+	Level   string         `json:"level,omitempty"`  // logs only
+	Module  string         `json:"module,omitempty"` // "module" attribute of logs, event name prefix before the first dot
+	Message string         `json:"message,omitempty"`
+	Attrs   map[string]any `json:"attrs,omitempty"`
+	Event   string         `json:"event,omitempty"`
+	Data    any            `json:"data,omitempty"`
+}
+
+type tailFilter struct {
+	kinds   []string
+	modules []string
+	level   slog.Level
+}
+
+type tailClient struct {
+	filter  tailFilter
+	entries chan []byte
+}
+
+// DevTail keeps the recent log lines and EventBus events and streams them as server-sent
+// events, so developers follow a module without tailing the container logs
+type DevTail struct {
+	mu      sync.Mutex
+	config  config.DevTailConfig
+	clock   helper.Clock
+	recent  []TailEntry
+	next    uint64
+	clients map[*tailClient]struct{}
+	done    chan struct{}
+	stop    sync.Once
+}
+
+func NewDevTail(cfg config.DevTailConfig, clock helper.Clock) *DevTail {
+	if cfg.Buffer <= 0 {
+		cfg.Buffer = 500
+	}
+
+	return &DevTail{
+		config:  cfg,
+		clock:   clock,
+		next:    1,
+		clients: make(map[*tailClient]struct{}),
+		done:    make(chan struct{}),
+	}
+}
+
+// Attach starts collecting the lines of the logger and the events of bus
+func (t *DevTail) Attach(bus *EventBus) {
+	logger.AddTap(func(entry logger.Entry) {
+		module, _ := entry.Attrs["module"].(string)
+		t.add(TailEntry{
+			Kind:    TailLog,
+			Time:    entry.Time,
+			Level:   strings.ToLower(entry.Level.String()),
+			Module:  module,
+			Message: entry.Message,
+			Attrs:   entry.Attrs,
+		})
+	})
+
+	bus.Tap(func(event string, data any) {
+		module, _, _ := strings.Cut(event, ".")
+		t.add(TailEntry{
+			Kind:   TailEvent,
+			Time:   t.clock.Now(),
+			Module: module,
+			Event:  event,
+			Data:   data,
+		})
+	})
+}
+
+// Stop ends the streams so the server can shut down
+func (t *DevTail) Stop() {
+	t.stop.Do(func() {
+		close(t.done)
+	})
+}
+
+func (t *DevTail) add(entry TailEntry) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	entry.ID = t.next
+	t.next++
+	if len(t.recent) >= t.config.Buffer {
+		t.recent = slices.Delete(t.recent, 0, len(t.recent)-t.config.Buffer+1)
+	}
+	t.recent = append(t.recent, entry)
+
+	if len(t.clients) == 0 {
+		return
+	}
+	message := tailMessage(entry)
+	for client := range t.clients {
+		if !client.filter.match(entry) {
+			continue
+		}
+		// slow clients lose entries rather than slowing down the application
+		select {
+		case client.entries <- message:
+		default:
+		}
+	}
+}
+
+// Handler streams the entries as server-sent events: the recent ones, or those after the
+// Last-Event-ID of a reconnecting client, then the new ones. The query filters them by
+// kind (log, event), module and minimum level of logs (ex: ?kind=log&module=orders&level=warn).
+func (t *DevTail) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		filter, err := parseTailFilter(c)
+		if err != nil {
+			return err
+		}
+
+		lastID, _ := strconv.ParseUint(c.Get("Last-Event-ID"), 10, 64)
+		client := &tailClient{filter: filter, entries: make(chan []byte, 256)}
+
+		t.mu.Lock()
+		backlog := [][]byte{}
+		for _, entry := range t.recent {
+			if entry.ID > lastID && filter.match(entry) {
+				backlog = append(backlog, tailMessage(entry))
+			}
+		}
+		t.clients[client] = struct{}{}
+		t.mu.Unlock()
+
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer func() {
+				t.mu.Lock()
+				delete(t.clients, client)
+				t.mu.Unlock()
+			}()
+
+			w.WriteString("retry: 2000\n\n")
+			for _, message := range backlog {
+				w.Write(message)
+			}
+			if w.Flush() != nil {
+				return
+			}
+
+			heartbeat := t.clock.NewTicker(15 * time.Second)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case <-t.done:
+					return
+				case message := <-client.entries:
+					w.Write(message)
+				case <-heartbeat.C():
+					// detects clients that went away
+					w.WriteString(": ping\n\n")
+				}
+				if w.Flush() != nil {
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+func parseTailFilter(c *fiber.Ctx) (tailFilter, error) {
+	filter := tailFilter{level: slog.LevelDebug}
+
+	for _, kind := range splitQuery(c.Query("kind")) {
+		if kind != TailLog && kind != TailEvent {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "kind must be log or event")
+		}
+		filter.kinds = append(filter.kinds, kind)
+	}
+	filter.modules = splitQuery(c.Query("module"))
+
+	if level := c.Query("level"); level != "" {
+		if err := filter.level.UnmarshalText([]byte(level)); err != nil {
+			return filter, fiber.NewError(fiber.StatusBadRequest, "level must be debug, info, warn or error")
+		}
+	}
+	return filter, nil
+}
+
+func (f tailFilter) match(entry TailEntry) bool {
+	if len(f.kinds) > 0 && !slices.Contains(f.kinds, entry.Kind) {
+		return false
+	}
+	if len(f.modules) > 0 && !slices.Contains(f.modules, entry.Module) {
+		return false
+	}
+	if entry.Kind == TailLog {
+		var level slog.Level
+		if level.UnmarshalText([]byte(entry.Level)) == nil && level < f.level {
+			return false
+		}
+	}
+	return true
+}
+
+// tailMessage encodes an entry as a server-sent event, event data that cannot be
+// encoded is sent as text
+func tailMessage(entry TailEntry) []byte {
+	data, err := helper.JSONMarshal(entry)
+	if err != nil {
+		entry.Data = fmt.Sprint(entry.Data)
+		entry.Attrs = nil
+		data, _ = helper.JSONMarshal(entry)
+	}
+	return fmt.Appendf(nil, "id: %d\nevent: %s\ndata: %s\n\n", entry.ID, entry.Kind, data)
+}
+
+func splitQuery(value string) []string {
+	values := []string{}
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,198 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Discovery keeps the instance registered in a service registry while the application runs.
+// Heartbeats report the readiness, so the instance only receives traffic while it is ready.
+type Discovery struct {
+	mu        sync.Mutex
+	config    config.DiscoveryConfig
+	readiness *Readiness
+	clock     helper.Clock
+	registry  port.IServiceRegistry
+	instance  *port.ServiceInstance
+	cancel    context.CancelFunc
+	wg        sync.WaitGroup
+}
+
+func NewDiscovery(cfg config.DiscoveryConfig, readiness *Readiness, clock helper.Clock) *Discovery {
+	if cfg.TTL <= 0 {
+		cfg.TTL = 15 * time.Second
+	}
+
+	return &Discovery{
+		config:    cfg,
+		readiness: readiness,
+		clock:     clock,
+	}
+}
+
+// SetRegistry sets the backend the instance is registered in
+func (d *Discovery) SetRegistry(registry port.IServiceRegistry) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.registry = registry
+}
+
+// Start registers the instance and sends heartbeats until Stop
+func (d *Discovery) Start(ctx context.Context, instance *port.ServiceInstance) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.registry == nil {
+		return fmt.Errorf("service registry is not set")
+	}
+	if err := d.registry.Register(ctx, instance); err != nil {
+		return err
+	}
+	d.instance = instance
+
+	changed := make(chan struct{}, 1)
+	d.readiness.OnChange(func(bool) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.wg.Add(1)
+	go d.run(ctx, d.registry, instance, changed)
+
+	logger.Info("Service registered", "id", instance.ID, "name", instance.Name, "address", instance.Address, "port", instance.Port)
+	return nil
+}
+
+// Stop ends the heartbeats and removes the instance from the registry
+func (d *Discovery) Stop() {
+	d.mu.Lock()
+	cancel, registry, instance := d.cancel, d.registry, d.instance
+	d.cancel = nil
+	d.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	d.wg.Wait()
+
+	ctx, done := context.WithTimeout(context.Background(), 5*time.Second)
+	defer done()
+	if err := registry.Deregister(ctx, instance.ID); err != nil {
+		logger.Warn("Service deregistration failed", "id", instance.ID, "error", err)
+		return
+	}
+	logger.Info("Service deregistered", "id", instance.ID)
+}
+
+// run sends a heartbeat three times per TTL and as soon as the readiness changes
+func (d *Discovery) run(ctx context.Context, registry port.IServiceRegistry, instance *port.ServiceInstance, changed <-chan struct{}) {
+	defer d.wg.Done()
+
+	ticker := d.clock.NewTicker(max(d.config.TTL/3, time.Second))
+	defer ticker.Stop()
+
+	for {
+		d.heartbeat(ctx, registry, instance)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		case <-changed:
+		}
+	}
+}
+
+func (d *Discovery) heartbeat(ctx context.Context, registry port.IServiceRegistry, instance *port.ServiceInstance) {
+	ready := d.readiness.Ready()
+	note := "ready"
+	if !ready {
+		note = "not ready"
+	}
+
+	err := registry.Heartbeat(ctx, instance.ID, ready, note)
+	if err == nil || ctx.Err() != nil {
+		return
+	}
+
+	// the registry may have lost the instance (ex: agent restarted), register it again
+	logger.Warn("Service discovery heartbeat failed", "id", instance.ID, "error", err)
+	if err := registry.Register(ctx, instance); err != nil {
+		logger.Warn("Service registration failed", "id", instance.ID, "error", err)
+		return
+	}
+	if err := registry.Heartbeat(ctx, instance.ID, ready, note); err != nil {
+		logger.Warn("Service discovery heartbeat failed", "id", instance.ID, "error", err)
+	}
+}
+
+// ServiceInstance describes this instance for service discovery from app.discovery
+func (a *AppContext) ServiceInstance() *port.ServiceInstance {
+	cfg := a.Config.App.Discovery
+
+	instance := &port.ServiceInstance{
+		ID:              cfg.ID,
+		Name:            cfg.Name,
+		Address:         cfg.Address,
+		Port:            cfg.Port,
+		Tags:            cfg.Tags,
+		TTL:             cfg.TTL,
+		DeregisterAfter: cfg.DeregisterAfter,
+		Meta: map[string]string{
+			"version":     a.Config.App.Version,
+			"environment": a.Config.App.Environment,
+		},
+	}
+	if instance.Name == "" {
+		instance.Name = a.Config.App.Name
+	}
+	if instance.Port == 0 {
+		instance.Port = a.Config.Server.Port
+	}
+	if instance.Address == "" {
+		instance.Address = localAddress()
+	}
+	if instance.ID == "" {
+		hostname, _ := os.Hostname()
+		instance.ID = fmt.Sprintf("%s-%s-%d", instance.Name, hostname, instance.Port)
+	}
+	if instance.TTL <= 0 {
+		instance.TTL = 15 * time.Second
+	}
+	if a.Config.App.GRPC.Enabled {
+		instance.Meta["grpc_port"] = strconv.Itoa(a.Config.App.GRPC.Port)
+	}
+	for key, value := range cfg.Meta {
+		instance.Meta[key] = value
+	}
+	return instance
+}
+
+// localAddress returns the first non-loopback IPv4 address, the hostname without one
+func localAddress() string {
+	addrs, err := net.InterfaceAddrs()
+	if err == nil {
+		for _, addr := range addrs {
+			if ipnet, ok := addr.(*net.IPNet); ok && ipnet.IP.IsGlobalUnicast() && ipnet.IP.To4() != nil {
+				return ipnet.IP.String()
+			}
+		}
+	}
+
+	hostname, _ := os.Hostname()
+	return hostname
+}
@@ -0,0 +1,407 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"net"
+	"net/smtp"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// Results of the checks of the doctor
+const (
+	CheckPass = "pass"
+	CheckFail = "fail"
+	CheckSkip = "skip"
+)
+
+// DiagnosticCheck is the result of a check of Diagnose
+type DiagnosticCheck struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"` // CheckPass, CheckFail or CheckSkip
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type diagnostic struct {
+	name string
+	run  func(ctx context.Context) (string, error) // detail of the check, skipped when it returns a skipCheck
+}
+
+// skipCheck is returned by the checks which cannot verify their dependency
+type skipCheck struct{ reason string }
+
+func (s skipCheck) Error() string { return s.reason }
+
+// Diagnose validates the configuration and connects to every configured dependency (database,
+// Redis, Kafka, PubSub, SMTP, object storage), each check is given timeout. It runs before the
+// libraries are loaded by the server, so every failing dependency is reported at once.
+func (a *App) Diagnose(ctx context.Context, timeout time.Duration) []DiagnosticCheck {
+	checks := []DiagnosticCheck{}
+	for _, check := range a.diagnostics() {
+		checkCtx, cancel := context.WithTimeout(ctx, timeout)
+		started := time.Now()
+		detail, err := check.run(checkCtx)
+		cancel()
+
+		result := DiagnosticCheck{Name: check.name, Status: CheckPass, Detail: detail, Duration: time.Since(started).Round(time.Millisecond).String()}
+		if skip, ok := err.(skipCheck); ok {
+			result.Status = CheckSkip
+			result.Detail = skip.reason
+		} else if err != nil {
+			result.Status = CheckFail
+			result.Detail = err.Error()
+		}
+		checks = append(checks, result)
+	}
+	return checks
+}
+
+func (a *App) diagnostics() []diagnostic {
+	cfg := a.Context.Config
+	notConfigured := func(ctx context.Context) (string, error) { return "", skipCheck{"not configured"} }
+
+	checks := []diagnostic{{name: "config", run: a.checkConfig}}
+
+	database := diagnostic{name: "database", run: notConfigured}
+	if cfg.Database.Host != "" || cfg.Database.Uri != "" {
+		database.run = func(ctx context.Context) (string, error) {
+			library, err := a.Context.StartDefaultSingletonInstance("database", a.Context.Context, cfg.Database)
+			if err != nil {
+				return "", err
+			}
+			if err := library.(port.IDatabase).Ping(ctx); err != nil {
+				return "", err
+			}
+			return cfg.Database.Driver + " " + dependencyAddress(cfg.Database.Host, cfg.Database.Port, 0), nil
+		}
+	}
+	checks = append(checks, database)
+
+	redis := diagnostic{name: "redis", run: notConfigured}
+	if cfg.Redis.Host != "" {
+		redis.run = func(ctx context.Context) (string, error) {
+			address := dependencyAddress(cfg.Redis.Host, cfg.Redis.Port, 6379)
+			return address, dialCheck(ctx, address)
+		}
+	}
+	checks = append(checks, redis)
+
+	kafka := diagnostic{name: "kafka", run: notConfigured}
+	if cfg.Kafka.Enabled && len(cfg.Kafka.Brokers) > 0 {
+		kafka.run = func(ctx context.Context) (string, error) {
+			for _, broker := range cfg.Kafka.Brokers {
+				if err := dialCheck(ctx, broker); err != nil {
+					return "", err
+				}
+			}
+			return strings.Join(cfg.Kafka.Brokers, ", "), nil
+		}
+	}
+	checks = append(checks, kafka)
+
+	pubsub := diagnostic{name: "pubsub", run: notConfigured}
+	if cfg.PubSub.Driver != "" {
+		pubsub.run = a.checkPubSub
+	}
+	checks = append(checks, pubsub)
+
+	mail := diagnostic{name: "smtp", run: notConfigured}
+	switch cfg.Mail.Driver {
+	case "":
+	case "smtp":
+		mail.run = a.checkSMTP
+	default:
+		mail.run = func(ctx context.Context) (string, error) {
+			return "", skipCheck{"mail driver " + cfg.Mail.Driver + " is not checked"}
+		}
+	}
+	checks = append(checks, mail)
+
+	storage := diagnostic{name: "storage", run: notConfigured}
+	if cfg.Storage.Driver != "" {
+		storage.run = func(ctx context.Context) (string, error) {
+			library, err := a.Context.StartDefaultSingletonInstance("storage", a.Context, cfg.Storage)
+			if err != nil {
+				return "", err
+			}
+			if _, err := library.(port.IObjectStorage).List(ctx, "", 1); err != nil {
+				return "", err
+			}
+			return strings.TrimSpace(cfg.Storage.Driver + " " + cfg.Storage.Bucket + cfg.Storage.Root), nil
+		}
+	}
+	checks = append(checks, storage)
+
+	transfers := diagnostic{name: "transfers", run: notConfigured}
+	if len(cfg.Transfers) > 0 {
+		transfers.run = a.checkTransfers
+	}
+	checks = append(checks, transfers)
+
+	return checks
+}
+
+// checkConfig validates the values of the configuration the libraries and the subsystems would
+// refuse once loaded
+func (a *App) checkConfig(ctx context.Context) (string, error) {
+	cfg := a.Context.Config
+	problems := []string{}
+	if a.err != nil {
+		problems = append(problems, a.err.Error())
+	}
+
+	if cfg.Server.Port <= 0 || cfg.Server.Port > 65535 {
+		problems = append(problems, fmt.Sprintf("server.port: %d is not a port", cfg.Server.Port))
+	}
+
+	supported := []struct {
+		key    string
+		value  string
+		values []string
+	}{
+		{"database.driver", cfg.Database.Driver, []string{"postgres", "mysql", "sqlite", "mongodb"}},
+		{"storage.driver", cfg.Storage.Driver, []string{"local", "s3", "gcs"}},
+		{"mail.driver", cfg.Mail.Driver, []string{"smtp", "ses", "sendgrid", "log"}},
+		{"search.driver", cfg.Search.Driver, []string{"elasticsearch", "meilisearch"}},
+	}
+	for _, option := range supported {
+		if option.value != "" && !slices.Contains(option.values, option.value) {
+			problems = append(problems, fmt.Sprintf("%s: %q is not one of %s", option.key, option.value, strings.Join(option.values, ", ")))
+		}
+	}
+	if (cfg.Database.Host != "" || cfg.Database.Uri != "") && cfg.Database.Driver == "" {
+		problems = append(problems, "database.driver is required with database.host or database.uri")
+	}
+	if cfg.Mail.Driver == "smtp" && cfg.Mail.Host == "" {
+		problems = append(problems, "mail.host is required by the smtp driver")
+	}
+	if cfg.App.Tasks.Enabled && !cfg.App.Queue.Enabled {
+		problems = append(problems, "app.tasks needs app.queue")
+	}
+
+	if cfg.App.Usage.Enabled {
+		if err := NewUsage(cfg.App.Usage, cfg.Auth.APIKeyHeader, a.Context.Clock).Validate(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	if err := NewDeprecations(cfg.App.Deprecation, a.Context.Clock).LoadRules(); err != nil {
+		problems = append(problems, err.Error())
+	}
+
+	if len(problems) > 0 {
+		return "", fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return "environment " + cfg.App.Environment, nil
+}
+
+func (a *App) checkPubSub(ctx context.Context) (string, error) {
+	cfg := a.Context.Config.PubSub
+	if cfg.Driver != "gpubsub" {
+		if _, ok := a.Context.Config.GetOtherItem(cfg.Driver); !ok {
+			return "", fmt.Errorf("configuration of the pubsub driver %s not found", cfg.Driver)
+		}
+		return "", skipCheck{"pubsub driver " + cfg.Driver + " is not checked"}
+	}
+
+	if cfg.ProjectID == "" || cfg.Topic == "" {
+		return "", fmt.Errorf("pubsub.project_id and pubsub.topic are required")
+	}
+	if cfg.CredentialsPath != "" {
+		if _, err := os.Stat(cfg.CredentialsPath); err != nil {
+			return "", fmt.Errorf("credentials: %v", err)
+		}
+	}
+
+	address := "pubsub.googleapis.com:443"
+	if emulator := os.Getenv("PUBSUB_EMULATOR_HOST"); emulator != "" {
+		address = emulator
+	}
+	return cfg.ProjectID + "/" + cfg.Topic + " " + address, dialCheck(ctx, address)
+}
+
+// checkTransfers checks what the drivers of the transfers need (ex: the OpenSSH client of sftp)
+// and reaches their servers
+func (a *App) checkTransfers(ctx context.Context) (string, error) {
+	partners := []string{}
+	for _, partner := range slices.Sorted(maps.Keys(a.Context.Config.Transfers)) {
+		cfg := a.Context.Config.Transfers[partner]
+		defaultPort := 21
+		switch {
+		case cfg.Driver == "sftp":
+			defaultPort = 22
+			binary := cfg.Binary
+			if binary == "" {
+				binary = "sftp"
+			}
+			if _, err := exec.LookPath(binary); err != nil {
+				return "", fmt.Errorf("%s: sftp executable not found, install the OpenSSH client: %v", partner, err)
+			}
+			if cfg.Password != "" {
+				return "", fmt.Errorf("%s: sftp does not authenticate with a password, use private_key", partner)
+			}
+		case cfg.ImplicitTLS:
+			defaultPort = 990
+		}
+
+		address := dependencyAddress(cfg.Host, cfg.Port, defaultPort)
+		if err := dialCheck(ctx, address); err != nil {
+			return "", fmt.Errorf("%s: %v", partner, err)
+		}
+		partners = append(partners, partner+" "+cfg.Driver+" "+address)
+	}
+	return strings.Join(partners, ", "), nil
+}
+
+// checkSMTP opens a session with the server, with its TLS mode, and quits
+func (a *App) checkSMTP(ctx context.Context) (string, error) {
+	cfg := a.Context.Config.Mail
+	address := dependencyAddress(cfg.Host, cfg.Port, 587)
+
+	dialer := &net.Dialer{}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+
+	var conn net.Conn
+	var err error
+	if cfg.TLS == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", address, &tls.Config{ServerName: cfg.Host})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return "", err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, cfg.Host)
+	if err != nil {
+		conn.Close()
+		return "", err
+	}
+	defer client.Close()
+
+	if err := client.Hello("localhost"); err != nil {
+		return "", err
+	}
+	if cfg.TLS == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return "", fmt.Errorf("%s does not support STARTTLS", address)
+		}
+		if err := client.StartTLS(&tls.Config{ServerName: cfg.Host}); err != nil {
+			return "", err
+		}
+	}
+	return address, client.Quit()
+}
+
+func dialCheck(ctx context.Context, address string) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
+
+// dependencyAddress joins host and port, defaultPort when port is not set
+func dependencyAddress(host string, port int, defaultPort int) string {
+	if port == 0 {
+		port = defaultPort
+	}
+	if port == 0 {
+		return host
+	}
+	return net.JoinHostPort(host, strconv.Itoa(port))
+}
+
+// PrintDiagnostics writes the banner of the application and the results of checks to w, it
+// returns the number of failed checks
+func (a *App) PrintDiagnostics(w io.Writer, checks []DiagnosticCheck) int {
+	cfg := a.Context.Config
+	fmt.Fprintf(w, "%s %s (%s, %s %s/%s)\n\n", cfg.App.Name, cfg.App.Version, cfg.App.Environment, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+
+	failed := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CHECK\tSTATUS\tDURATION\tDETAIL")
+	for _, check := range checks {
+		if check.Status == CheckFail {
+			failed++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", check.Name, strings.ToUpper(check.Status), check.Duration, check.Detail)
+	}
+	tw.Flush()
+
+	if failed > 0 {
+		fmt.Fprintf(w, "\n%d of %d checks failed\n", failed, len(checks))
+	} else {
+		fmt.Fprintf(w, "\nAll checks passed\n")
+	}
+	return failed
+}
+
+func doctorCommand() *Command {
+	var timeout time.Duration
+	var asJSON bool
+	return &Command{
+		Name:        "doctor",
+		Description: "Validate the configuration and check the connection to every configured dependency",
+		NoSetup:     true,
+		Flags: func(fs *flag.FlagSet) {
+			fs.DurationVar(&timeout, "timeout", 5*time.Second, "timeout of each check")
+			fs.BoolVar(&asJSON, "json", false, "print the results as JSON")
+		},
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			a := Instance()
+			checks := a.Diagnose(ctx, timeout)
+
+			failed := 0
+			if asJSON {
+				for _, check := range checks {
+					if check.Status == CheckFail {
+						failed++
+					}
+				}
+				encoder := json.NewEncoder(os.Stdout)
+				encoder.SetIndent("", "  ")
+				if err := encoder.Encode(checks); err != nil {
+					return err
+				}
+			} else {
+				failed = a.PrintDiagnostics(os.Stdout, checks)
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d checks failed", failed)
+			}
+			return nil
+		},
+	}
+}
+
+// startupCheck runs the checks of the doctor before the server loads its libraries, with
+// app.startup_check, and refuses to start when one fails
+func (a *App) startupCheck(ctx context.Context) error {
+	checks := a.Diagnose(ctx, 5*time.Second)
+	if failed := a.PrintDiagnostics(os.Stderr, checks); failed > 0 {
+		return fmt.Errorf("startup check: %d checks failed", failed)
+	}
+	return nil
+}
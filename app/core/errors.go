@@ -0,0 +1,39 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Error codes of the responses mapped from the errors of the framework, following the ones of
+// the handlers: 1 invalid request, 2 authentication and access, 3 validation, 4 limits
+const (
+	ErrorCodeNotFound    = 5
+	ErrorCodeConflict    = 6
+	ErrorCodeUnavailable = 7
+)
+
+// The errors of the framework returned by a handler are sent with their status, a module maps
+// its own with out.RegisterError and out.RegisterErrorType
+func init() {
+	out.RegisterError(port.ErrRecordNotFound, fiber.StatusNotFound, ErrorCodeNotFound, "NOT_FOUND")
+	out.RegisterError(port.ErrObjectNotFound, fiber.StatusNotFound, ErrorCodeNotFound, "NOT_FOUND")
+	out.RegisterError(port.ErrSecretNotFound, fiber.StatusNotFound, ErrorCodeNotFound, "NOT_FOUND")
+	out.RegisterError(port.ErrRemoteFileNotFound, fiber.StatusNotFound, ErrorCodeNotFound, "NOT_FOUND")
+	out.RegisterError(port.ErrDuplicateKey, fiber.StatusConflict, ErrorCodeConflict, "CONFLICT")
+	out.RegisterError(port.ErrVersionConflict, fiber.StatusConflict, ErrorCodeConflict, "CONFLICT")
+
+	out.RegisterError(auth.ErrAccessDenied, fiber.StatusForbidden, 2, "FORBIDDEN")
+	out.RegisterError(helper.ErrInvalidSignature, fiber.StatusForbidden, 2, "FORBIDDEN")
+	out.RegisterError(helper.ErrExpiredSignature, fiber.StatusForbidden, 2, "FORBIDDEN")
+
+	out.RegisterError(helper.ErrRateLimited, fiber.StatusTooManyRequests, 4, "RATE_LIMITED")
+	out.RegisterError(ErrQuotaExceeded, fiber.StatusTooManyRequests, 4, "QUOTA_EXCEEDED")
+
+	out.RegisterError(ErrCircuitOpen, fiber.StatusServiceUnavailable, ErrorCodeUnavailable, "SERVICE_UNAVAILABLE")
+	out.RegisterError(ErrBulkheadFull, fiber.StatusServiceUnavailable, ErrorCodeUnavailable, "SERVICE_UNAVAILABLE")
+	out.RegisterError(ErrTimeout, fiber.StatusGatewayTimeout, ErrorCodeUnavailable, "TIMEOUT")
+}
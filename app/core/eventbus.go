@@ -1,34 +1,261 @@
 package core
 
-// EventBus represents shared event bus
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// DispatchMode is how the events are handed to a subscriber
+type DispatchMode int
+
+const (
+	// DispatchSync calls the subscriber in the goroutine of Publish, before it returns
+	DispatchSync DispatchMode = iota
+	// DispatchAsync queues the events of the subscriber, handled in their order by its own
+	// goroutine so a slow subscriber does not hold the publisher
+	DispatchAsync
+)
+
+// SubscribeOptions are the delivery of the events to a subscriber, synchronous by default
+type SubscribeOptions struct {
+	Mode DispatchMode
+	// Buffer is the queue of an asynchronous subscriber, 64 events when zero
+	Buffer int
+	// DropWhenFull drops the events of an asynchronous subscriber whose queue is full instead
+	// of making Publish wait for room. A subscriber publishing its own event must drop them,
+	// its handler waiting for room in the queue only it drains.
+	DropWhenFull bool
+}
+
+// Topic is an event whose payloads are of type T, for Subscribe and Publish:
+//
+//	const EventOrderPaid core.Topic[OrderPaid] = "orders.paid"
+//
+//	core.Subscribe(bus, EventOrderPaid, func(e OrderPaid) { ... })
+//	core.Publish(bus, EventOrderPaid, OrderPaid{ID: id})
+type Topic[T any] string
+
+// Subscribe subscribes handler to the payloads of topic. A payload published on the event
+// with another type (ex: with EventBus.Publish) is not given to handler. It returns the
+// function unsubscribing handler.
+func Subscribe[T any](bus *EventBus, topic Topic[T], handler func(T), opts ...SubscribeOptions) func() {
+	return bus.Subscribe(string(topic), func(data any) {
+		payload, ok := data.(T)
+		if !ok {
+			logger.Warn("EventBus payload of another type", "event", string(topic), "type", fmt.Sprintf("%T", data), "expected", fmt.Sprintf("%T", *new(T)))
+			return
+		}
+		handler(payload)
+	}, opts...)
+}
+
+// Publish publishes payload on topic
+func Publish[T any](bus *EventBus, topic Topic[T], payload T) {
+	bus.Publish(string(topic), payload)
+}
+
+// subscriber is a handler of an event, with its queue when it is asynchronous
+type subscriber struct {
+	event   string
+	handler func(any)
+	drop    bool
+
+	queue chan any      // nil for a synchronous subscriber
+	done  chan struct{} // closed once the subscriber stops queueing the events
+	once  sync.Once
+}
+
+// EventBus dispatches the events of the application to their subscribers. A subscriber
+// panicking is logged and does not prevent the others from receiving the event.
+//
+// The delivery depends on the mode of the subscriber: a synchronous subscriber has handled the
+// event when Publish returns; an asynchronous one receives the events in their order, at most
+// once, those still queued being delivered by Close.
 type EventBus struct {
-	// This is a simplified implementation
-	// In a real scenario, you would use a proper message bus
-	subscribers map[string][]func(any)
+	mu          sync.RWMutex
+	subscribers map[string][]*subscriber
+	taps        []func(event string, data any)
+	closed      bool
+	running     sync.WaitGroup // goroutines of the asynchronous subscribers
 }
 
 // NewEventBus creates a new event bus instance
 func NewEventBus() *EventBus {
 	return &EventBus{
-		subscribers: make(map[string][]func(any)),
+		subscribers: make(map[string][]*subscriber),
 	}
 }
 
-// Subscribe subscribes to an event
-func (eb *EventBus) Subscribe(event string, handler func(any)) {
-	eb.subscribers[event] = append(eb.subscribers[event], handler)
+// Subscribe subscribes handler to event, synchronously unless opts says otherwise. It returns
+// the function unsubscribing handler, which lets an asynchronous subscriber handle the events
+// already queued.
+func (eb *EventBus) Subscribe(event string, handler func(any), opts ...SubscribeOptions) func() {
+	var opt SubscribeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	s := &subscriber{event: event, handler: handler, drop: opt.DropWhenFull}
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	if opt.Mode == DispatchAsync {
+		buffer := opt.Buffer
+		if buffer <= 0 {
+			buffer = 64
+		}
+		s.queue = make(chan any, buffer)
+		s.done = make(chan struct{})
+		if eb.closed {
+			// nothing is delivered once the bus is closed
+			s.close()
+		}
+		eb.running.Add(1)
+		go eb.run(s)
+	}
+	eb.subscribers[event] = append(eb.subscribers[event], s)
+
+	return func() {
+		eb.mu.Lock()
+		// a new slice, the one of a Publish in progress is left as it is
+		eb.subscribers[event] = slices.DeleteFunc(slices.Clone(eb.subscribers[event]), func(other *subscriber) bool { return other == s })
+		if len(eb.subscribers[event]) == 0 {
+			delete(eb.subscribers, event)
+		}
+		eb.mu.Unlock()
+		s.close()
+	}
+}
+
+// Tap registers fn to receive every published event, before its subscribers
+func (eb *EventBus) Tap(fn func(event string, data any)) {
+	eb.mu.Lock()
+	defer eb.mu.Unlock()
+	eb.taps = append(eb.taps, fn)
 }
 
 // Publish publishes an event
 func (eb *EventBus) Publish(event string, data any) {
-	if handlers, exists := eb.subscribers[event]; exists {
-		for _, handler := range handlers {
-			handler(data)
+	eb.mu.RLock()
+	taps := eb.taps
+	subscribers := eb.subscribers[event]
+	eb.mu.RUnlock()
+
+	for _, fn := range taps {
+		eb.call(event, "tap", func() { fn(event, data) })
+	}
+	for _, s := range subscribers {
+		if s.queue == nil {
+			eb.call(event, "subscriber", func() { s.handler(data) })
+		} else {
+			s.send(data)
+		}
+	}
+}
+
+// call runs fn, logging its panic instead of letting it reach the publisher
+func (eb *EventBus) call(event string, kind string, fn func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			logger.Error("EventBus "+kind+" panicked", "event", event, "panic", r, "stack", string(debug.Stack()))
+		}
+	}()
+	fn()
+}
+
+// run handles the queue of an asynchronous subscriber until it is closed and drained
+func (eb *EventBus) run(s *subscriber) {
+	defer eb.running.Done()
+	for {
+		select {
+		case data := <-s.queue:
+			eb.call(s.event, "subscriber", func() { s.handler(data) })
+		case <-s.done:
+			for {
+				select {
+				case data := <-s.queue:
+					eb.call(s.event, "subscriber", func() { s.handler(data) })
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// send queues data, waiting for room unless the subscriber drops the events when full. A
+// send waiting for room gives up once the subscriber is closed.
+func (s *subscriber) send(data any) {
+	select {
+	case <-s.done:
+		return
+	default:
+	}
+	if !s.drop {
+		select {
+		case s.queue <- data:
+		case <-s.done:
 		}
+		return
+	}
+	select {
+	case s.queue <- data:
+	default:
+		logger.Warn("EventBus subscriber queue full, event dropped", "event", s.event, "buffer", cap(s.queue))
+	}
+}
+
+// close stops queueing the events of an asynchronous subscriber, those queued are still handled
+func (s *subscriber) close() {
+	if s.queue == nil {
+		return
+	}
+	s.once.Do(func() { close(s.done) })
+}
+
+// Close stops the asynchronous subscribers once they handled the events queued, or when ctx
+// ends. The synchronous subscribers still receive the events published afterwards.
+func (eb *EventBus) Close(ctx context.Context) error {
+	eb.mu.Lock()
+	eb.closed = true
+	for _, subscribers := range eb.subscribers {
+		for _, s := range subscribers {
+			s.close()
+		}
+	}
+	eb.mu.Unlock()
+
+	drained := make(chan struct{})
+	go func() {
+		eb.running.Wait()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("event bus: events still queued: %w", ctx.Err())
 	}
 }
 
 // GetSubscribers returns the number of subscribers for an event
 func (eb *EventBus) GetSubscribers(event string) int {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
 	return len(eb.subscribers[event])
 }
+
+// Subscriptions returns the number of subscribers of every event
+func (eb *EventBus) Subscriptions() map[string]int {
+	eb.mu.RLock()
+	defer eb.mu.RUnlock()
+	subscriptions := make(map[string]int, len(eb.subscribers))
+	for event, handlers := range eb.subscribers {
+		subscriptions[event] = len(handlers)
+	}
+	return subscriptions
+}
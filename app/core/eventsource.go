@@ -0,0 +1,346 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Event is an event of a stream, given to the aggregates and published on the EventBus
+type Event struct {
+	port.EventRecord
+}
+
+// Decode decodes the JSON data of the event into v
+func (e Event) Decode(v any) error {
+	return helper.JSONUnmarshal(e.Data, v)
+}
+
+// EventStore appends the events of the aggregates to the streams of a port.IEventStore. With
+// app.event_store.publish, the appended events are published on the EventBus under their
+// type, once stored.
+type EventStore struct {
+	mu     sync.RWMutex
+	config config.EventStoreConfig
+	store  port.IEventStore
+	bus    *EventBus
+	clock  helper.Clock
+}
+
+// NewEventStore creates an event store appending to store
+func NewEventStore(cfg config.EventStoreConfig, store port.IEventStore, bus *EventBus, clock helper.Clock) *EventStore {
+	return &EventStore{
+		config: cfg,
+		store:  store,
+		bus:    bus,
+		clock:  clock,
+	}
+}
+
+// SetStore replaces the store holding the streams
+func (s *EventStore) SetStore(store port.IEventStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+}
+
+func (s *EventStore) current() (port.IEventStore, error) {
+	if !s.config.Enabled {
+		return nil, fmt.Errorf("event sourcing is not enabled, enable app.event_store")
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store, nil
+}
+
+// Append adds events to stream after its version expected (0 for a new stream) and returns
+// the new version of the stream. The ID, stream, version and date of the events are set.
+// It fails with port.ErrVersionConflict when another writer appended first.
+func (s *EventStore) Append(ctx context.Context, stream string, expected int64, events []port.EventRecord) (int64, error) {
+	store, err := s.current()
+	if err != nil {
+		return 0, err
+	}
+	if len(events) == 0 {
+		return expected, nil
+	}
+
+	now := s.clock.Now()
+	for i := range events {
+		if events[i].Type == "" {
+			return 0, fmt.Errorf("event of stream '%s' has no type", stream)
+		}
+		if events[i].ID, err = helper.GenerateUUID(); err != nil {
+			return 0, err
+		}
+		events[i].Stream = stream
+		events[i].Version = expected + int64(i) + 1
+		events[i].CreatedAt = now
+	}
+
+	if err := store.Append(ctx, stream, expected, events); err != nil {
+		return 0, err
+	}
+
+	if s.config.Publish {
+		for _, event := range events {
+			s.bus.Publish(event.Type, Event{event})
+		}
+	}
+	return events[len(events)-1].Version, nil
+}
+
+// Load returns the events of stream after version, in order
+func (s *EventStore) Load(ctx context.Context, stream string, after int64) ([]Event, error) {
+	store, err := s.current()
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := store.Load(ctx, stream, after)
+	if err != nil {
+		return nil, err
+	}
+	events := make([]Event, len(records))
+	for i, record := range records {
+		events[i] = Event{record}
+	}
+	return events, nil
+}
+
+// Aggregate is an entity whose State is folded from the events of its stream. Raise applies a
+// new event to the state, Aggregates.Save appends the raised events.
+type Aggregate[T any] struct {
+	ID      string
+	Version int64 // version of the last event applied, raised ones included
+	State   T
+
+	stream  string
+	stored  int64 // version of the stream when loaded or saved
+	pending []port.EventRecord
+	apply   func(state *T, event Event) error
+}
+
+// Raise applies an event of eventType with data to the state, it is appended on save
+func (a *Aggregate[T]) Raise(eventType string, data any) error {
+	raw, err := helper.JSONMarshal(data)
+	if err != nil {
+		return err
+	}
+
+	record := port.EventRecord{Stream: a.stream, Version: a.Version + 1, Type: eventType, Data: raw}
+	if err := a.apply(&a.State, Event{record}); err != nil {
+		return err
+	}
+	a.Version++
+	a.pending = append(a.pending, record)
+	return nil
+}
+
+// Changed tells whether events were raised since the aggregate was loaded or saved
+func (a *Aggregate[T]) Changed() bool {
+	return len(a.pending) > 0
+}
+
+// Aggregates loads and saves the aggregates of a type, in the streams "<name>-<id>". apply
+// folds an event into the state, for the stored events as for the raised ones:
+//
+//	orders := core.NewAggregates(m.context.EventStore, "order", func(order *Order, event core.Event) error {
+//		switch event.Type {
+//		case "order.placed":
+//			return event.Decode(order)
+//		case "order.cancelled":
+//			order.Status = "cancelled"
+//		}
+//		return nil
+//	})
+//
+// The state of T is JSON encoded in the snapshots taken every app.event_store.snapshot_every
+// events, a snapshot which does not decode anymore is ignored and the events are folded again.
+type Aggregates[T any] struct {
+	events *EventStore
+	name   string
+	apply  func(state *T, event Event) error
+}
+
+// NewAggregates creates the repository of the aggregates named name
+func NewAggregates[T any](events *EventStore, name string, apply func(state *T, event Event) error) *Aggregates[T] {
+	return &Aggregates[T]{events: events, name: name, apply: apply}
+}
+
+func (r *Aggregates[T]) stream(id string) string {
+	return r.name + "-" + id
+}
+
+// New returns an aggregate without event, saving it fails if its stream already exists
+func (r *Aggregates[T]) New(id string) *Aggregate[T] {
+	return &Aggregate[T]{ID: id, stream: r.stream(id), apply: r.apply}
+}
+
+// Load folds the events of the aggregate id from its last snapshot, the error wraps
+// port.ErrRecordNotFound when it has no event
+func (r *Aggregates[T]) Load(ctx context.Context, id string) (*Aggregate[T], error) {
+	store, err := r.events.current()
+	if err != nil {
+		return nil, err
+	}
+
+	aggregate := r.New(id)
+	stream := aggregate.stream
+	if r.events.config.SnapshotEvery > 0 {
+		snapshot, err := store.LoadSnapshot(ctx, stream)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot != nil {
+			if err := helper.JSONUnmarshal(snapshot.Data, &aggregate.State); err != nil {
+				logger.Warn("Aggregate snapshot ignored", "stream", stream, "error", err)
+				aggregate.State = *new(T)
+			} else {
+				aggregate.Version = snapshot.Version
+			}
+		}
+	}
+
+	events, err := r.events.Load(ctx, stream, aggregate.Version)
+	if err != nil {
+		return nil, err
+	}
+	for _, event := range events {
+		if err := r.apply(&aggregate.State, event); err != nil {
+			return nil, fmt.Errorf("apply event %d of '%s': %w", event.Version, stream, err)
+		}
+		aggregate.Version = event.Version
+	}
+
+	if aggregate.Version == 0 {
+		return nil, fmt.Errorf("aggregate '%s': %w", stream, port.ErrRecordNotFound)
+	}
+	aggregate.stored = aggregate.Version
+	return aggregate, nil
+}
+
+// Save appends the events raised on aggregate, port.ErrVersionConflict when its stream was
+// appended since it was loaded. A snapshot is taken when the events cross a multiple of
+// app.event_store.snapshot_every.
+func (r *Aggregates[T]) Save(ctx context.Context, aggregate *Aggregate[T]) error {
+	if !aggregate.Changed() {
+		return nil
+	}
+
+	stream := aggregate.stream
+	version, err := r.events.Append(ctx, stream, aggregate.stored, aggregate.pending)
+	if err != nil {
+		return err
+	}
+
+	every := int64(r.events.config.SnapshotEvery)
+	if every > 0 && version/every != aggregate.stored/every {
+		if err := r.snapshot(ctx, stream, version, aggregate.State); err != nil {
+			// the events are stored, the next snapshot will catch up
+			logger.Warn("Aggregate snapshot failed", "stream", stream, "error", err)
+		}
+	}
+
+	aggregate.stored = version
+	aggregate.pending = nil
+	return nil
+}
+
+func (r *Aggregates[T]) snapshot(ctx context.Context, stream string, version int64, state T) error {
+	store, err := r.events.current()
+	if err != nil {
+		return err
+	}
+	raw, err := helper.JSONMarshal(state)
+	if err != nil {
+		return err
+	}
+	return store.SaveSnapshot(ctx, &port.SnapshotRecord{
+		Stream:    stream,
+		Version:   version,
+		Data:      raw,
+		CreatedAt: r.events.clock.Now(),
+	})
+}
+
+// Update loads the aggregate id, runs fn on it and saves the raised events. On a version
+// conflict it loads the aggregate again and runs fn again, up to 3 times, so fn must only
+// decide from the aggregate.
+func (r *Aggregates[T]) Update(ctx context.Context, id string, fn func(aggregate *Aggregate[T]) error) error {
+	var err error
+	for range 3 {
+		var aggregate *Aggregate[T]
+		if aggregate, err = r.Load(ctx, id); err != nil {
+			return err
+		}
+		if err = fn(aggregate); err != nil {
+			return err
+		}
+		if err = r.Save(ctx, aggregate); !errors.Is(err, port.ErrVersionConflict) {
+			return err
+		}
+	}
+	return err
+}
+
+// MemoryEventStore keeps the streams in process memory, they are lost on restart
+type MemoryEventStore struct {
+	mu        sync.Mutex
+	streams   map[string][]port.EventRecord
+	snapshots map[string]port.SnapshotRecord
+}
+
+// NewMemoryEventStore creates an empty in-memory store
+func NewMemoryEventStore() *MemoryEventStore {
+	return &MemoryEventStore{
+		streams:   make(map[string][]port.EventRecord),
+		snapshots: make(map[string]port.SnapshotRecord),
+	}
+}
+
+func (m *MemoryEventStore) Append(ctx context.Context, stream string, expected int64, events []port.EventRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if int64(len(m.streams[stream])) != expected {
+		return port.ErrVersionConflict
+	}
+	m.streams[stream] = append(m.streams[stream], events...)
+	return nil
+}
+
+func (m *MemoryEventStore) Load(ctx context.Context, stream string, after int64) ([]port.EventRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	events := m.streams[stream]
+	if after >= int64(len(events)) {
+		return []port.EventRecord{}, nil
+	}
+	return append([]port.EventRecord{}, events[max(after, 0):]...), nil
+}
+
+func (m *MemoryEventStore) SaveSnapshot(ctx context.Context, snapshot *port.SnapshotRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots[snapshot.Stream] = *snapshot
+	return nil
+}
+
+func (m *MemoryEventStore) LoadSnapshot(ctx context.Context, stream string) (*port.SnapshotRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	snapshot, ok := m.snapshots[stream]
+	if !ok {
+		return nil, nil
+	}
+	return &snapshot, nil
+}
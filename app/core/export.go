@@ -0,0 +1,185 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/mask"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ExportCSV writes the rows of cursor decoded as T into w, one at a time, and closes the cursor.
+// Columns are titled like ReportSheetOf titles them, so the file can be imported back. The cells
+// are masked with the app.masking policy of the tenant of ctx.
+func ExportCSV[T any](ctx context.Context, cursor port.DbCursor, w io.Writer) (int, error) {
+	defer cursor.Close(ctx)
+
+	fields, err := exportFields[T]()
+	if err != nil {
+		return 0, err
+	}
+	policy := maskingPolicy(ctx)
+
+	records := csv.NewWriter(w)
+	header := make([]string, len(fields))
+	for i, field := range fields {
+		header[i] = field.title
+	}
+	if err := records.Write(header); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	record := make([]string, len(fields))
+	for cursor.Next(ctx) {
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return count, err
+		}
+		for i, cell := range maskRow(policy, fields, reportRow(fields, reflect.ValueOf(item))) {
+			record[i] = csvCell(cell)
+		}
+		if err := records.Write(record); err != nil {
+			return count, err
+		}
+
+		// flush regularly so the rows reach the client as they are read
+		count++
+		if count%1000 == 0 {
+			records.Flush()
+			if err := records.Error(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return count, err
+	}
+
+	records.Flush()
+	return count, records.Error()
+}
+
+// ExportSheet reads the rows of cursor decoded as T into a worksheet for Reporter.RenderXLSX
+// and closes the cursor, the cells masked like ExportCSV masks them
+func ExportSheet[T any](ctx context.Context, name string, cursor port.DbCursor) (*port.ReportSheet, error) {
+	defer cursor.Close(ctx)
+
+	fields, err := exportFields[T]()
+	if err != nil {
+		return nil, err
+	}
+	policy := maskingPolicy(ctx)
+
+	sheet := &port.ReportSheet{Name: name, Rows: [][]any{}}
+	for _, field := range fields {
+		sheet.Columns = append(sheet.Columns, port.ReportColumn{Title: field.title})
+	}
+
+	for cursor.Next(ctx) {
+		var item T
+		if err := cursor.Decode(&item); err != nil {
+			return nil, err
+		}
+		sheet.Rows = append(sheet.Rows, maskRow(policy, fields, reportRow(fields, reflect.ValueOf(item))))
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	return sheet, nil
+}
+
+// Export sends the rows of cursor (see helper.FindCursor) as a csv or xlsx attachment named
+// filename. CSV is streamed while the workbook is built in memory, prefer CSV for large exports.
+func Export[T any](c *fiber.Ctx, reporter *Reporter, format string, filename string, cursor port.DbCursor) error {
+	ctx := c.UserContext()
+
+	switch format {
+	case ImportCSV:
+		if _, err := exportFields[T](); err != nil {
+			cursor.Close(ctx)
+			return err
+		}
+		if !strings.HasSuffix(strings.ToLower(filename), ".csv") {
+			filename += ".csv"
+		}
+		c.Set(fiber.HeaderContentType, "text/csv; charset=utf-8")
+		c.Attachment(filename)
+
+		// the body is written after the handler returned, the request context may be canceled by then
+		ctx = context.WithoutCancel(ctx)
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			if _, err := ExportCSV[T](ctx, cursor, w); err != nil {
+				logger.Error("Export failed", "filename", filename, "error", err)
+			}
+			w.Flush()
+		})
+		return nil
+	case ImportXLSX:
+		sheet, err := ExportSheet[T](ctx, "Sheet1", cursor)
+		if err != nil {
+			return err
+		}
+		return reporter.Download(c, port.ReportXLSX, filename, &port.Report{Sheets: []port.ReportSheet{*sheet}})
+	}
+
+	cursor.Close(ctx)
+	return fmt.Errorf("unknown export format '%s'", format)
+}
+
+func exportFields[T any]() ([]reportField, error) {
+	elem := reflect.TypeFor[T]()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("export rows must be structs, got %s", elem)
+	}
+	return reportFields(elem), nil
+}
+
+// maskRow hides the cells of the fields masked by policy and the patterns of policy in the others
+func maskRow(policy *mask.Policy, fields []reportField, row []any) []any {
+	if policy == nil {
+		return row
+	}
+	for i, field := range fields {
+		switch {
+		case row[i] == nil:
+		case policy.Field(field.title, field.mask) || policy.Field(field.name, ""):
+			row[i] = policy.Hide(row[i])
+		default:
+			if s, ok := row[i].(string); ok {
+				row[i] = policy.String(s)
+			}
+		}
+	}
+	return row
+}
+
+// csvCell formats a cell of reportCell the way the importer reads it back
+func csvCell(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,649 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// EventFlagChanged is published on the EventBus with a FlagChange when a flag is saved or
+// deleted, or found changed by a reload, so the values computed from it can be dropped
+const EventFlagChanged = "flag.changed"
+
+// FlagChange is the data of EventFlagChanged
+type FlagChange struct {
+	Key  string            `json:"key"`
+	Flag *port.FeatureFlag `json:"flag,omitempty"` // nil when the flag was deleted
+}
+
+// FlagTarget is who a flag is evaluated for, the middleware of the flags fills it from the
+// authenticated user and the tenant header of the request
+type FlagTarget struct {
+	Principal  string
+	Tenant     string
+	Roles      []string
+	Attributes map[string]string // matched by the rules on other attributes (ex: "country")
+}
+
+type flagTargetKey struct{}
+
+// WithFlagTarget stores the target of the flags in ctx (ex: in a job, for the user it runs for)
+func WithFlagTarget(ctx context.Context, target FlagTarget) context.Context {
+	return context.WithValue(ctx, flagTargetKey{}, target)
+}
+
+// CurrentFlagTarget returns the target stored by WithFlagTarget, empty when there is none
+func CurrentFlagTarget(ctx context.Context) FlagTarget {
+	target, _ := ctx.Value(flagTargetKey{}).(FlagTarget)
+	return target
+}
+
+// Flag tells whether the flag key is on for the target of ctx, false when the flag does not
+// exist or app.flags is disabled:
+//
+//	if core.Flag(c.UserContext(), "new-checkout") {
+func Flag(ctx context.Context, key string) bool {
+	app := Instance()
+	if app == nil || !app.Context.Config.App.Flags.Enabled {
+		return false
+	}
+	return app.Context.Flags.Enabled(ctx, key)
+}
+
+// Flags evaluates the feature flags of a port.IFlagProvider. The flags are kept in memory and
+// reloaded every app.flags.refresh, the changes made through Save and Delete apply at once.
+type Flags struct {
+	mu       sync.RWMutex
+	config   config.FlagsConfig
+	provider port.IFlagProvider
+	bus      *EventBus
+	clock    helper.Clock
+	flags    map[string]port.FeatureFlag
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewFlags creates flags kept in memory until SetProvider
+func NewFlags(cfg config.FlagsConfig, bus *EventBus, clock helper.Clock) *Flags {
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = 30 * time.Second
+	}
+
+	return &Flags{
+		config:   cfg,
+		provider: NewMemoryFlagProvider(),
+		bus:      bus,
+		clock:    clock,
+		flags:    make(map[string]port.FeatureFlag),
+	}
+}
+
+// SetProvider replaces the provider of the flags, Reload reads them
+func (f *Flags) SetProvider(provider port.IFlagProvider) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.provider = provider
+}
+
+// Reload reads the flags of the provider and publishes the changes
+func (f *Flags) Reload(ctx context.Context) error {
+	f.mu.RLock()
+	provider := f.provider
+	f.mu.RUnlock()
+
+	flags, err := provider.Load(ctx)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]port.FeatureFlag, len(flags))
+	for _, flag := range flags {
+		loaded[flag.Key] = flag
+	}
+
+	f.mu.Lock()
+	previous := f.flags
+	f.flags = loaded
+	f.mu.Unlock()
+
+	keys := slices.Sorted(maps.Keys(loaded))
+	for _, key := range keys {
+		flag := loaded[key]
+		if old, ok := previous[key]; !ok || !sameFlag(old, flag) {
+			f.publish(key, &flag)
+		}
+	}
+	for _, key := range slices.Sorted(maps.Keys(previous)) {
+		if _, ok := loaded[key]; !ok {
+			f.publish(key, nil)
+		}
+	}
+	return nil
+}
+
+// sameFlag compares the encoded flags, the times read back from a provider may differ in
+// their location only
+func sameFlag(a, b port.FeatureFlag) bool {
+	encodedA, errA := json.Marshal(a)
+	encodedB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(encodedA) == string(encodedB)
+}
+
+// Start reloads the flags every app.flags.refresh until Stop
+func (f *Flags) Start(ctx context.Context) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.ctx != nil {
+		return
+	}
+	f.ctx, f.cancel = context.WithCancel(ctx)
+
+	f.wg.Add(1)
+	go f.refresh(f.ctx)
+}
+
+// Stop ends the reloads
+func (f *Flags) Stop() {
+	f.mu.Lock()
+	cancel := f.cancel
+	f.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	f.wg.Wait()
+}
+
+func (f *Flags) refresh(ctx context.Context) {
+	defer f.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-f.clock.After(f.config.Refresh):
+		}
+
+		// the flags loaded before stay in use
+		if err := f.Reload(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Feature flags reload failed", "error", err)
+		}
+	}
+}
+
+// Enabled evaluates the flag key for the target of ctx
+func (f *Flags) Enabled(ctx context.Context, key string) bool {
+	return f.Evaluate(key, CurrentFlagTarget(ctx))
+}
+
+// Evaluate tells whether the flag key is on for target. The principals are spread over the
+// rollout percentages by a hash of the flag and the principal, so a principal keeps its
+// answer while the rollout grows.
+func (f *Flags) Evaluate(key string, target FlagTarget) bool {
+	f.mu.RLock()
+	flag, ok := f.flags[key]
+	f.mu.RUnlock()
+
+	if !ok || !flag.Enabled {
+		return false
+	}
+
+	rollout := flag.Rollout
+	for _, rule := range flag.Rules {
+		if matchFlagRule(rule, target) {
+			rollout = rule.Rollout
+			break
+		}
+	}
+
+	switch {
+	case rollout >= 100:
+		return true
+	case rollout <= 0:
+		return false
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(flag.Key + ":" + target.Principal))
+	return int(h.Sum32()%100) < rollout
+}
+
+func matchFlagRule(rule port.FlagRule, target FlagTarget) bool {
+	switch rule.Attribute {
+	case "principal":
+		return target.Principal != "" && slices.Contains(rule.Values, target.Principal)
+	case "tenant":
+		return target.Tenant != "" && slices.Contains(rule.Values, target.Tenant)
+	case "role":
+		return slices.ContainsFunc(target.Roles, func(role string) bool {
+			return slices.Contains(rule.Values, role)
+		})
+	}
+
+	value, ok := target.Attributes[rule.Attribute]
+	return ok && slices.Contains(rule.Values, value)
+}
+
+// Get returns a flag
+func (f *Flags) Get(key string) (port.FeatureFlag, bool) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flag, ok := f.flags[key]
+	return flag, ok
+}
+
+// List returns the flags ordered by key
+func (f *Flags) List() []port.FeatureFlag {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	flags := make([]port.FeatureFlag, 0, len(f.flags))
+	for _, key := range slices.Sorted(maps.Keys(f.flags)) {
+		flags = append(flags, f.flags[key])
+	}
+	return flags
+}
+
+// Save creates or replaces a flag in the provider, the other instances see it on their next
+// reload
+func (f *Flags) Save(ctx context.Context, flag port.FeatureFlag) error {
+	if flag.Key == "" {
+		return fmt.Errorf("flag key is required")
+	}
+	flag.UpdatedAt = f.clock.Now().UTC()
+
+	f.mu.RLock()
+	provider := f.provider
+	f.mu.RUnlock()
+
+	if err := provider.Save(ctx, flag); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	f.flags[flag.Key] = flag
+	f.mu.Unlock()
+
+	f.publish(flag.Key, &flag)
+	return nil
+}
+
+// Delete removes a flag from the provider, it is off from then on
+func (f *Flags) Delete(ctx context.Context, key string) error {
+	f.mu.RLock()
+	provider := f.provider
+	f.mu.RUnlock()
+
+	if err := provider.Delete(ctx, key); err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	_, existed := f.flags[key]
+	delete(f.flags, key)
+	f.mu.Unlock()
+
+	if existed {
+		f.publish(key, nil)
+	}
+	return nil
+}
+
+func (f *Flags) publish(key string, flag *port.FeatureFlag) {
+	if f.bus != nil {
+		f.bus.Publish(EventFlagChanged, FlagChange{Key: key, Flag: flag})
+	}
+}
+
+// Middleware stores the FlagTarget of the request in its user context: the authenticated
+// user, its roles and the tenant of app.tenancy, or the one named by the app.flags.tenant_header
+// header without tenancy
+func (f *Flags) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		c.SetUserContext(WithFlagTarget(c.UserContext(), requestFlagTarget(c, f.config.TenantHeader)))
+		return c.Next()
+	}
+}
+
+// requestFlagTarget returns the target of the request: the authenticated user, its roles, the
+// tenant of app.tenancy or of tenantHeader, and the country of the GeoIP lookup
+func requestFlagTarget(c *fiber.Ctx, tenantHeader string) FlagTarget {
+	target := FlagTarget{
+		Principal: auth.CurrentUserID(c),
+		Roles:     auth.CurrentUserRoles(c),
+	}
+	if tenant := helper.CurrentTenant(c); tenant != nil {
+		target.Tenant = tenant.ID
+	} else if tenantHeader != "" {
+		target.Tenant = c.Get(tenantHeader)
+	}
+	if geo := helper.CurrentGeo(c); geo != nil && geo.Country != "" {
+		target.Attributes = map[string]string{"country": geo.Country}
+	}
+	return target
+}
+
+// flagRequest is the body of the admin endpoint saving a flag
+type flagRequest struct {
+	Description string          `json:"description"`
+	Enabled     bool            `json:"enabled"`
+	Rollout     int             `json:"rollout"`
+	Rules       []port.FlagRule `json:"rules"`
+}
+
+func (r *flagRequest) Validate() []out.FieldError {
+	var errors []out.FieldError
+	if r.Rollout < 0 || r.Rollout > 100 {
+		errors = append(errors, out.FieldError{Field: "rollout", Rule: "between", Message: "rollout must be between 0 and 100", Param: "0,100"})
+	}
+	for i, rule := range r.Rules {
+		field := fmt.Sprintf("rules[%d]", i)
+		if rule.Attribute == "" {
+			errors = append(errors, out.FieldError{Field: field + ".attribute", Rule: "required", Message: "attribute is required"})
+		}
+		if len(rule.Values) == 0 {
+			errors = append(errors, out.FieldError{Field: field + ".values", Rule: "required", Message: "values is required"})
+		}
+		if rule.Rollout < 0 || rule.Rollout > 100 {
+			errors = append(errors, out.FieldError{Field: field + ".rollout", Rule: "between", Message: "rollout must be between 0 and 100", Param: "0,100"})
+		}
+	}
+	return errors
+}
+
+// RegisterAdminRoutes serves the flags on router: list, read, save (PUT) and delete, and the
+// evaluation of a flag for ?principal=&tenant=&role=
+func (f *Flags) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/flags", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(f.List()))
+	})
+	router.Get("/flags/:key", func(c *fiber.Ctx) error {
+		flag, ok := f.Get(c.Params("key"))
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "Flag not found")
+		}
+
+		response := fiber.Map{"flag": flag}
+		if c.Query("principal") != "" || c.Query("tenant") != "" || c.Query("role") != "" {
+			target := FlagTarget{Principal: c.Query("principal"), Tenant: c.Query("tenant")}
+			if role := c.Query("role"); role != "" {
+				target.Roles = []string{role}
+			}
+			response["enabled"] = f.Evaluate(flag.Key, target)
+		}
+		return out.Send(c, out.SuccessData(response))
+	})
+	router.Put("/flags/:key", func(c *fiber.Ctx) error {
+		var request flagRequest
+		if err := helper.BindBody(c, &request); err != nil {
+			return err
+		}
+
+		flag := port.FeatureFlag{
+			Key:         strings.Clone(c.Params("key")), // kept after the request, fiber reuses its buffer
+			Description: request.Description,
+			Enabled:     request.Enabled,
+			Rollout:     request.Rollout,
+			Rules:       request.Rules,
+		}
+		if err := f.Save(c.UserContext(), flag); err != nil {
+			return err
+		}
+
+		saved, _ := f.Get(flag.Key)
+		logger.Info("Feature flag saved", "flag", flag.Key, "enabled", flag.Enabled, "rollout", flag.Rollout, "user", auth.CurrentUserID(c))
+		return out.Send(c, out.SuccessData(saved))
+	})
+	router.Delete("/flags/:key", func(c *fiber.Ctx) error {
+		key := c.Params("key")
+		if _, ok := f.Get(key); !ok {
+			return fiber.NewError(fiber.StatusNotFound, "Flag not found")
+		}
+		if err := f.Delete(c.UserContext(), key); err != nil {
+			return err
+		}
+
+		logger.Info("Feature flag deleted", "flag", key, "user", auth.CurrentUserID(c))
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+}
+
+// MemoryFlagProvider keeps the flags in memory, they are lost on restart
+type MemoryFlagProvider struct {
+	mu    sync.RWMutex
+	flags map[string]port.FeatureFlag
+}
+
+// NewMemoryFlagProvider creates a provider without flags
+func NewMemoryFlagProvider(flags ...port.FeatureFlag) *MemoryFlagProvider {
+	p := &MemoryFlagProvider{flags: make(map[string]port.FeatureFlag)}
+	for _, flag := range flags {
+		p.flags[flag.Key] = flag
+	}
+	return p
+}
+
+func (p *MemoryFlagProvider) Load(ctx context.Context) ([]port.FeatureFlag, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return slices.Collect(maps.Values(p.flags)), nil
+}
+
+func (p *MemoryFlagProvider) Save(ctx context.Context, flag port.FeatureFlag) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.flags[flag.Key] = flag
+	return nil
+}
+
+func (p *MemoryFlagProvider) Delete(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.flags, key)
+	return nil
+}
+
+// FileFlagProvider keeps the flags in a JSON file holding an array of port.FeatureFlag, the
+// file is written back by Save and Delete
+type FileFlagProvider struct {
+	mu   sync.Mutex
+	path string
+}
+
+// NewFileFlagProvider reads the flags of path, a missing file has no flag
+func NewFileFlagProvider(path string) *FileFlagProvider {
+	return &FileFlagProvider{path: path}
+}
+
+func (p *FileFlagProvider) Load(ctx context.Context) ([]port.FeatureFlag, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.read()
+}
+
+func (p *FileFlagProvider) Save(ctx context.Context, flag port.FeatureFlag) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	flags, err := p.read()
+	if err != nil {
+		return err
+	}
+	flags = slices.DeleteFunc(flags, func(f port.FeatureFlag) bool { return f.Key == flag.Key })
+	return p.write(append(flags, flag))
+}
+
+func (p *FileFlagProvider) Delete(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	flags, err := p.read()
+	if err != nil {
+		return err
+	}
+	return p.write(slices.DeleteFunc(flags, func(f port.FeatureFlag) bool { return f.Key == key }))
+}
+
+func (p *FileFlagProvider) read() ([]port.FeatureFlag, error) {
+	data, err := os.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var flags []port.FeatureFlag
+	if err := json.Unmarshal(data, &flags); err != nil {
+		return nil, fmt.Errorf("%s: %v", p.path, err)
+	}
+	return flags, nil
+}
+
+// write replaces the file at once, a reader never sees it half written
+func (p *FileFlagProvider) write(flags []port.FeatureFlag) error {
+	sort.Slice(flags, func(i, j int) bool { return flags[i].Key < flags[j].Key })
+	data, err := json.MarshalIndent(flags, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(p.path), filepath.Base(p.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), p.path)
+}
+
+// flagRow is a row of the table of the DatabaseFlagProvider
+type flagRow struct {
+	ID         string    `db:"id"`
+	Definition string    `db:"definition"` // JSON encoded port.FeatureFlag
+	UpdatedAt  time.Time `db:"updated_at"`
+}
+
+// DatabaseFlagProvider keeps the flags in a table with the columns id (the key of the flag),
+// definition (the JSON encoded flag) and updated_at
+type DatabaseFlagProvider struct {
+	database port.IDatabase
+	table    string
+}
+
+// NewDatabaseFlagProvider reads the flags of table
+func NewDatabaseFlagProvider(database port.IDatabase, table string) *DatabaseFlagProvider {
+	return &DatabaseFlagProvider{database: database, table: table}
+}
+
+func (p *DatabaseFlagProvider) Load(ctx context.Context) ([]port.FeatureFlag, error) {
+	var rows []flagRow
+	if err := p.database.Find(ctx, &rows, p.table, nil, nil, nil, 0, 0); err != nil {
+		return nil, err
+	}
+
+	flags := make([]port.FeatureFlag, 0, len(rows))
+	for _, row := range rows {
+		var flag port.FeatureFlag
+		if err := json.Unmarshal([]byte(row.Definition), &flag); err != nil {
+			return nil, fmt.Errorf("flag %s: %v", row.ID, err)
+		}
+		flag.Key = row.ID
+		flags = append(flags, flag)
+	}
+	return flags, nil
+}
+
+func (p *DatabaseFlagProvider) Save(ctx context.Context, flag port.FeatureFlag) error {
+	definition, err := json.Marshal(flag)
+	if err != nil {
+		return err
+	}
+
+	row := flagRow{ID: flag.Key, Definition: string(definition), UpdatedAt: flag.UpdatedAt}
+	updated, err := p.database.UpdateOne(ctx, p.table, p.filter(flag.Key), row)
+	if err != nil || updated > 0 {
+		return err
+	}
+	_, err = p.database.InsertOne(ctx, p.table, row)
+	return err
+}
+
+func (p *DatabaseFlagProvider) Delete(ctx context.Context, key string) error {
+	_, err := p.database.DeleteOne(ctx, p.table, p.filter(key))
+	return err
+}
+
+func (p *DatabaseFlagProvider) filter(key string) []port.DbExpression {
+	return []port.DbExpression{{Expr: "id", Op: "=", Args: []any{key}}}
+}
+
+// CacheFlagProvider keeps the flags under a key of a cache shared by the instances (ex: Redis),
+// the changes of two instances at the same time may overwrite each other
+type CacheFlagProvider struct {
+	mu    sync.Mutex
+	cache port.ICacheMemory
+	key   string
+}
+
+// NewCacheFlagProvider reads the flags stored under key
+func NewCacheFlagProvider(cache port.ICacheMemory, key string) *CacheFlagProvider {
+	return &CacheFlagProvider{cache: cache, key: key}
+}
+
+func (p *CacheFlagProvider) Load(ctx context.Context) ([]port.FeatureFlag, error) {
+	return slices.Collect(maps.Values(p.read())), nil
+}
+
+func (p *CacheFlagProvider) Save(ctx context.Context, flag port.FeatureFlag) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	flags := p.read()
+	flags[flag.Key] = flag
+	return p.cache.Set(p.key, flags, 0)
+}
+
+func (p *CacheFlagProvider) Delete(ctx context.Context, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	flags := p.read()
+	delete(flags, key)
+	return p.cache.Set(p.key, flags, 0)
+}
+
+func (p *CacheFlagProvider) read() map[string]port.FeatureFlag {
+	flags := map[string]port.FeatureFlag{}
+	if !p.cache.Get(p.key, &flags) || flags == nil {
+		flags = map[string]port.FeatureFlag{}
+	}
+	return flags
+}
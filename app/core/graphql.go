@@ -0,0 +1,122 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/graphql"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// ModuleGraphQL is implemented by modules contributing to the GraphQL schema
+type ModuleGraphQL interface {
+	Module
+
+	// GraphQL returns the schema fragment of the module and its resolvers
+	GraphQL() *graphql.Fragment
+}
+
+// setupGraphQL stitches the fragments of the modules and mounts the endpoint below the root group
+func (a *App) setupGraphQL() error {
+	names := a.ModuleManager.ListModules()
+	slices.Sort(names)
+
+	fragments := []*graphql.Fragment{}
+	for _, name := range names {
+		module, err := a.ModuleManager.GetModule(name)
+		if err != nil {
+			return err
+		}
+		if provider, ok := module.(ModuleGraphQL); ok {
+			if fragment := provider.GraphQL(); fragment != nil {
+				fragments = append(fragments, fragment)
+			}
+		}
+	}
+
+	if len(fragments) == 0 {
+		logger.Warn("GraphQL is enabled but no module provides a schema")
+		return nil
+	}
+
+	schema, err := graphql.NewSchema(fragments...)
+	if err != nil {
+		return err
+	}
+
+	handler := func(c *fiber.Ctx) error {
+		request := graphql.Request{}
+		if c.Method() == fiber.MethodGet {
+			request.Query = c.Query("query")
+			request.OperationName = c.Query("operationName")
+			if variables := c.Query("variables"); variables != "" {
+				if err := json.Unmarshal([]byte(variables), &request.Variables); err != nil {
+					return c.Status(fiber.StatusBadRequest).JSON(graphqlError("Invalid variables: " + err.Error()))
+				}
+			}
+		} else if err := c.BodyParser(&request); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(graphqlError("Invalid request body: " + err.Error()))
+		}
+
+		// mutations are not allowed over GET so they cannot be triggered by a link
+		if c.Method() == fiber.MethodGet && graphql.IsMutation(request) {
+			return c.Status(fiber.StatusMethodNotAllowed).JSON(graphqlError("Mutations must be sent with POST"))
+		}
+
+		result := schema.Execute(c.UserContext(), request, graphql.Options{
+			MaxDepth:      a.Context.Config.App.GraphQL.MaxDepth,
+			MaxComplexity: a.Context.Config.App.GraphQL.MaxComplexity,
+			Authorize:     a.graphqlAuthorizer(c),
+		})
+		if result.Data == nil {
+			c.Status(fiber.StatusBadRequest)
+		}
+		return c.JSON(result)
+	}
+
+	path := a.Context.Config.App.GraphQL.Path
+	a.Context.Root.Get(path, handler)
+	a.Context.Root.Post(path, handler)
+
+	logger.Info("GraphQL endpoint mounted", "path", path, "fragments", len(fragments), "types", len(schema.Types))
+	return nil
+}
+
+// graphqlAuthorizer maps the @auth directive to the authenticated user: @auth(roles: [...])
+// requires one of the roles, a bare @auth asks the authorizer for the "GRAPHQL Type.field" resource
+func (a *App) graphqlAuthorizer(c *fiber.Ctx) func(context.Context, *graphql.TypeDef, *graphql.FieldDef, *graphql.Directive) error {
+	return func(ctx context.Context, parent *graphql.TypeDef, field *graphql.FieldDef, directive *graphql.Directive) error {
+		user := auth.CurrentUser(c)
+		if user == nil {
+			return fmt.Errorf("Unauthenticated")
+		}
+
+		if roles, ok := directive.Argument("roles"); ok {
+			required, _ := roles.([]any)
+			if single, ok := roles.(string); ok {
+				required = []any{single}
+			}
+
+			userRoles := auth.CurrentUserRoles(c)
+			for _, role := range required {
+				if name, ok := role.(string); ok && slices.Contains(userRoles, name) {
+					return nil
+				}
+			}
+			return fmt.Errorf("Not authorized to access %s.%s", parent.Name, field.Name)
+		}
+
+		if a.Context.Authorizer != nil {
+			return a.Context.Authorizer.Check(user, "GRAPHQL", parent.Name+"."+field.Name)
+		}
+		return nil
+	}
+}
+
+func graphqlError(message string) *graphql.Result {
+	return &graphql.Result{Errors: []*graphql.Error{{Message: message}}}
+}
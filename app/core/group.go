@@ -0,0 +1,266 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// ModuleGroup is a Module composing the modules of a large domain. The routes its children
+// declare with AppContext.Router are mounted under the path of the group, /<group>/<child>,
+// behind the middleware of the group, and the children read the configuration of the group,
+// the section module.<group>, with GroupOf:
+//
+//	billing := core.NewModuleGroup("billing", "1.0.0", invoices.NewModule(), payments.NewModule()).
+//		Use(billingAudit).
+//		WithConfig(&billing.Config{})
+//
+// The group is registered in place of its children. It initializes them in the order given, a
+// child using a sibling comes after it, and relays their commands, seeders, consumers, start,
+// stop and health.
+type ModuleGroup struct {
+	name       string
+	version    string
+	prefix     string
+	children   []Module
+	middleware []fiber.Handler
+	config     config.ConfigObject
+}
+
+// NewModuleGroup creates the group name of children, mounted under /<name>
+func NewModuleGroup(name string, version string, children ...Module) *ModuleGroup {
+	return &ModuleGroup{
+		name:     name,
+		version:  version,
+		prefix:   "/" + name,
+		children: children,
+	}
+}
+
+// Prefix mounts the routes of the children under path in place of /<name>
+func (g *ModuleGroup) Prefix(path string) *ModuleGroup {
+	g.prefix = "/" + strings.Trim(path, "/")
+	return g
+}
+
+// Use adds middleware run before the routes of the children and their checks
+func (g *ModuleGroup) Use(handlers ...fiber.Handler) *ModuleGroup {
+	g.middleware = append(g.middleware, handlers...)
+	return g
+}
+
+// WithConfig sets the configuration shared by the children, loaded from module.<name> on Init
+func (g *ModuleGroup) WithConfig(cfg config.ConfigObject) *ModuleGroup {
+	g.config = cfg
+	return g
+}
+
+// Children returns the modules of the group
+func (g *ModuleGroup) Children() []Module {
+	return g.children
+}
+
+func (g *ModuleGroup) Name() string {
+	return g.name
+}
+
+func (g *ModuleGroup) Version() string {
+	return g.version
+}
+
+// Dependencies returns the dependencies of the children outside the group
+func (g *ModuleGroup) Dependencies() []string {
+	var dependencies []string
+	for _, child := range g.children {
+		for _, dependency := range child.Dependencies() {
+			if !g.contains(dependency) && !slices.Contains(dependencies, dependency) {
+				dependencies = append(dependencies, dependency)
+			}
+		}
+	}
+	return dependencies
+}
+
+func (g *ModuleGroup) Config() config.ConfigObject {
+	return g.config
+}
+
+func (g *ModuleGroup) Routes() []*ModuleRoute {
+	var routes []*ModuleRoute
+	for _, child := range g.children {
+		routes = append(routes, child.Routes()...)
+	}
+	return routes
+}
+
+func (g *ModuleGroup) Services() map[string]any {
+	services := map[string]any{}
+	for _, child := range g.children {
+		maps.Copy(services, child.Services())
+	}
+	return services
+}
+
+func (g *ModuleGroup) Repositories() map[string]any {
+	repositories := map[string]any{}
+	for _, child := range g.children {
+		maps.Copy(repositories, child.Repositories())
+	}
+	return repositories
+}
+
+// Init loads the configuration of the group and initializes the children
+func (g *ModuleGroup) Init(ctx *AppContext) error {
+	if g.config != nil {
+		if err := config.LoadDefaultConfigModule(g.name, g.config); err != nil {
+			return fmt.Errorf("load the configuration of group '%s': %v", g.name, err)
+		}
+	}
+
+	for _, child := range g.children {
+		ctx.setGroup(child.Name(), g)
+	}
+	for _, child := range g.children {
+		if err := child.Init(ctx); err != nil {
+			return fmt.Errorf("initialize module '%s': %v", child.Name(), err)
+		}
+	}
+	return nil
+}
+
+func (g *ModuleGroup) PostInit(ctx *AppContext) error {
+	for _, child := range g.children {
+		if extended, ok := child.(ModuleExtended); ok {
+			if err := extended.PostInit(ctx); err != nil {
+				return fmt.Errorf("post initialize module '%s': %v", child.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// OnStart starts the children in order, the ones already started are stopped when one fails
+func (g *ModuleGroup) OnStart(ctx context.Context) error {
+	for i, child := range g.children {
+		starter, ok := child.(ModuleStarter)
+		if !ok {
+			continue
+		}
+		if err := starter.OnStart(ctx); err != nil {
+			err = fmt.Errorf("start module '%s': %v", child.Name(), err)
+			return errors.Join(err, g.stop(ctx, g.children[:i]))
+		}
+	}
+	return nil
+}
+
+// OnStop stops the children in the reverse order of their start
+func (g *ModuleGroup) OnStop(ctx context.Context) error {
+	return g.stop(ctx, g.children)
+}
+
+func (g *ModuleGroup) stop(ctx context.Context, children []Module) error {
+	var errs []error
+	for _, child := range slices.Backward(children) {
+		if stopper, ok := child.(ModuleStopper); ok {
+			if err := stopper.OnStop(ctx); err != nil {
+				errs = append(errs, fmt.Errorf("module '%s': %w", child.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Health reports the children down, joined
+func (g *ModuleGroup) Health() error {
+	var errs []error
+	for _, child := range g.children {
+		if checker, ok := child.(ModuleHealthChecker); ok {
+			if err := checker.Health(); err != nil {
+				errs = append(errs, fmt.Errorf("module '%s': %w", child.Name(), err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *ModuleGroup) Commands() []*Command {
+	var commands []*Command
+	for _, child := range g.children {
+		if provider, ok := child.(ModuleCommands); ok {
+			commands = append(commands, provider.Commands()...)
+		}
+	}
+	return commands
+}
+
+func (g *ModuleGroup) Seed(ctx context.Context) error {
+	for _, child := range g.children {
+		if seeder, ok := child.(ModuleSeeder); ok {
+			logger.Info("Seeding", "module", child.Name(), "group", g.name)
+			if err := seeder.Seed(ctx); err != nil {
+				return fmt.Errorf("seed module '%s': %v", child.Name(), err)
+			}
+		}
+	}
+	return nil
+}
+
+// Consume runs the consumers of the children until they all return
+func (g *ModuleGroup) Consume(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make([]error, len(g.children))
+	for i, child := range g.children {
+		consumer, ok := child.(ModuleConsumer)
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := consumer.Consume(ctx); err != nil {
+				errs[i] = fmt.Errorf("consumer of module '%s': %v", child.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (g *ModuleGroup) Destroy() error {
+	var errs []error
+	for _, child := range slices.Backward(g.children) {
+		if err := child.Destroy(); err != nil {
+			errs = append(errs, fmt.Errorf("module '%s': %w", child.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (g *ModuleGroup) contains(name string) bool {
+	return slices.ContainsFunc(g.children, func(child Module) bool { return child.Name() == name })
+}
+
+// GroupOf returns the group of the module name, nil when it is not a child of a group
+func (a *AppContext) GroupOf(module string) *ModuleGroup {
+	a.groupMu.RLock()
+	defer a.groupMu.RUnlock()
+	return a.groups[module]
+}
+
+func (a *AppContext) setGroup(module string, group *ModuleGroup) {
+	a.groupMu.Lock()
+	defer a.groupMu.Unlock()
+	if a.groups == nil {
+		a.groups = map[string]*ModuleGroup{}
+	}
+	a.groups[module] = group
+}
@@ -0,0 +1,212 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Statuses of a LibraryHealth
+const (
+	HealthUp        = "up"
+	HealthDown      = "down"
+	HealthUnchecked = "unchecked" // the library has no check
+)
+
+// LibraryHealth is the result of the health check of a loaded library
+type LibraryHealth struct {
+	Library   string    `json:"library"`
+	Key       string    `json:"key,omitempty"` // empty for the singleton
+	Status    string    `json:"status"`        // HealthUp, HealthDown or HealthUnchecked
+	Error     string    `json:"error,omitempty"`
+	Duration  string    `json:"duration,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// ModuleHealth is the health reported by a ModuleHealthChecker
+type ModuleHealth struct {
+	Module string `json:"module"`
+	Status string `json:"status"` // HealthUp or HealthDown
+	Error  string `json:"error,omitempty"`
+}
+
+// LibraryInstance describes a loaded instance of a library
+type LibraryInstance struct {
+	Library   string         `json:"library"`
+	Key       string         `json:"key"`
+	Type      string         `json:"type"`
+	Connector bool           `json:"connector"` // implements port.Connector
+	LoadedAt  time.Time      `json:"loaded_at"`
+	Health    *LibraryHealth `json:"health,omitempty"` // last check, nil before the first one
+}
+
+// HealthReport checks the libraries of the application, see LibraryManager.Health
+func HealthReport(ctx context.Context) []LibraryHealth {
+	return Instance().LibraryManager.Health(ctx)
+}
+
+// Healthy reports whether no library of report is down
+func Healthy(report []LibraryHealth) bool {
+	return !slices.ContainsFunc(report, func(health LibraryHealth) bool { return health.Status == HealthDown })
+}
+
+// Health checks every loaded library at once, with Health when it is a port.HealthChecker or
+// Ping when it is a port.IDatabase, and returns their results by library and key. A check
+// still running when ctx ends is reported down.
+func (lm *LibraryManager) Health(ctx context.Context) []LibraryHealth {
+	checkedAt := time.Now()
+	lm.mu.RLock()
+	report := []LibraryHealth{}
+	libraries := []port.Library{}
+	for _, name := range slices.Sorted(maps.Keys(lm.Libraries)) {
+		for _, key := range slices.Sorted(maps.Keys(lm.Libraries[name])) {
+			report = append(report, LibraryHealth{Library: name, Key: key, Status: HealthUnchecked, CheckedAt: checkedAt})
+			libraries = append(libraries, lm.Libraries[name][key])
+		}
+	}
+	lm.mu.RUnlock()
+
+	type result struct {
+		index  int
+		health LibraryHealth
+	}
+	results := make(chan result, len(libraries))
+	pending := map[int]bool{}
+	for i, library := range libraries {
+		check := libraryCheck(library)
+		if check == nil {
+			continue
+		}
+
+		pending[i] = true
+		go func(health LibraryHealth) {
+			started := time.Now()
+			health.Status = HealthUp
+			if err := check(ctx); err != nil {
+				health.Status = HealthDown
+				health.Error = err.Error()
+			}
+			health.Duration = time.Since(started).Round(time.Millisecond).String()
+			results <- result{i, health}
+		}(report[i])
+	}
+
+wait:
+	for len(pending) > 0 {
+		select {
+		case r := <-results:
+			report[r.index] = r.health
+			delete(pending, r.index)
+		case <-ctx.Done():
+			// the checks ignoring ctx are left running
+			for i := range pending {
+				report[i].Status = HealthDown
+				report[i].Error = ctx.Err().Error()
+			}
+			break wait
+		}
+	}
+
+	lm.mu.Lock()
+	for _, health := range report {
+		id := instanceID(health.Library, health.Key)
+		if _, ok := lm.loadedAt[id]; ok {
+			lm.health[id] = health
+		}
+	}
+	lm.mu.Unlock()
+	return report
+}
+
+// Instances returns the loaded instances of the libraries by library and key, with the result
+// of their last health check
+func (lm *LibraryManager) Instances() []LibraryInstance {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+
+	result := []LibraryInstance{}
+	for _, name := range slices.Sorted(maps.Keys(lm.Libraries)) {
+		for _, key := range slices.Sorted(maps.Keys(lm.Libraries[name])) {
+			library := lm.Libraries[name][key]
+			_, connector := library.(port.Connector)
+			instance := LibraryInstance{
+				Library:   name,
+				Key:       key,
+				Type:      fmt.Sprintf("%T", library),
+				Connector: connector,
+				LoadedAt:  lm.loadedAt[instanceID(name, key)],
+			}
+			if health, ok := lm.health[instanceID(name, key)]; ok {
+				instance.Health = &health
+			}
+			result = append(result, instance)
+		}
+	}
+	return result
+}
+
+// RegisterAdminRoutes serves the loaded instances of the libraries on router, and their health
+// checked on demand
+func (lm *LibraryManager) RegisterAdminRoutes(router fiber.Router, timeout time.Duration) {
+	router.Get("/libraries", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(lm.Instances()))
+	})
+
+	router.Post("/libraries/health", func(c *fiber.Ctx) error {
+		var report []LibraryHealth
+		WithTimeout(c.UserContext(), timeout, func(ctx context.Context) error {
+			report = lm.Health(ctx)
+			return nil
+		})
+		return out.Send(c, out.SuccessData(report))
+	})
+}
+
+// libraryCheck returns the health check of library, nil when it has none
+func libraryCheck(library port.Library) func(ctx context.Context) error {
+	switch checker := library.(type) {
+	case port.HealthChecker:
+		return checker.Health
+	case port.IDatabase:
+		return checker.Ping
+	}
+	return nil
+}
+
+// healthHandler serves the health of the libraries and the modules on /healthz and /readyz.
+// The liveness does not fail on a library or a module down, restarting the instance would not
+// bring it back; the readiness fails with 503 on it and while the instance is not ready.
+func (a *App) healthHandler(readiness bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var report []LibraryHealth
+		err := WithTimeout(c.UserContext(), a.Context.Config.App.Health.Timeout, func(ctx context.Context) error {
+			report = a.LibraryManager.Health(ctx)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		modules := a.ModuleManager.Health()
+
+		status := "ok"
+		if !Healthy(report) || slices.ContainsFunc(modules, func(health ModuleHealth) bool { return health.Status == HealthDown }) {
+			status = "degraded"
+		}
+		if readiness && !a.Context.Readiness.Ready() {
+			status = "not ready"
+		}
+
+		code := fiber.StatusOK
+		if readiness && status != "ok" {
+			code = fiber.StatusServiceUnavailable
+		}
+		return c.Status(code).JSON(fiber.Map{"status": status, "libraries": report, "modules": modules})
+	}
+}
@@ -0,0 +1,136 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// HTTPCallJobType is the job queue type of the calls spilled by HTTPClient.Send
+const HTTPCallJobType = "http.call"
+
+// HTTPCall is a request which can be sent later from the job queue
+type HTTPCall struct {
+	Method  string            `json:"method"`
+	Path    string            `json:"path"` // relative to the base URL of the upstream
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    []byte            `json:"body,omitempty"`
+	Result  string            `json:"result,omitempty"` // job type enqueued with the HTTPCallResult once a spilled call was answered
+}
+
+// HTTPCallResult is the response of a spilled call, given to the job type named by HTTPCall.Result
+type HTTPCallResult struct {
+	Upstream string            `json:"upstream"`
+	Call     HTTPCall          `json:"call"`
+	Status   int               `json:"status"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	Body     []byte            `json:"body,omitempty"`
+}
+
+type httpCallJob struct {
+	Upstream string   `json:"upstream"`
+	Call     HTTPCall `json:"call"`
+}
+
+// Send sends call now when the rate limit allows it within max_wait. Otherwise, when the
+// upstream has a rate_limit.queue, the call is queued and sent as soon as the rate limit
+// allows it: the response is nil and the job ID is returned. Queued calls are retried on
+// network errors, 429 and 5xx answers.
+func (h *HTTPClient) Send(ctx context.Context, call HTTPCall) (*http.Response, string, error) {
+	req, err := h.callRequest(ctx, call)
+	if err != nil {
+		return nil, "", err
+	}
+	if h.limiter == nil || h.Config.RateLimit.Queue == "" {
+		resp, err := h.Do(req)
+		return resp, "", err
+	}
+
+	wait, ok := h.limiter.Reserve(h.Config.RateLimit.MaxWait)
+	if !ok {
+		id, err := h.queue.Enqueue(ctx, HTTPCallJobType, httpCallJob{Upstream: h.Upstream, Call: call}, EnqueueOptions{Queue: h.Config.RateLimit.Queue})
+		if err != nil {
+			return nil, "", err
+		}
+		h.spilled.Add(1)
+		return nil, id, nil
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	case <-h.clock.After(wait):
+	}
+	resp, err := h.do(req, h.Config.RateLimit.MaxWait, true)
+	return resp, "", err
+}
+
+func (h *HTTPClient) callRequest(ctx context.Context, call HTTPCall) (*http.Request, error) {
+	var body io.Reader
+	if call.Body != nil {
+		body = bytes.NewReader(call.Body)
+	}
+
+	req, err := h.NewRequest(ctx, call.Method, call.Path, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range call.Headers {
+		req.Header.Set(name, value)
+	}
+	return req, nil
+}
+
+// sendQueuedCall is the handler of HTTPCallJobType, it waits for the rate limit as long
+// as the job may run
+func (a *AppContext) sendQueuedCall(ctx context.Context, job httpCallJob) error {
+	client, err := a.HTTPClient(job.Upstream)
+	if err != nil {
+		return err
+	}
+
+	req, err := client.callRequest(ctx, job.Call)
+	if err != nil {
+		return err
+	}
+	resp, err := client.do(req, 0, false)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 16<<20))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("%s %s %s: %s", job.Upstream, job.Call.Method, job.Call.Path, resp.Status)
+	}
+	if resp.StatusCode >= 400 {
+		// retrying would get the same answer
+		logger.Error("Queued HTTP call rejected", "upstream", job.Upstream, "method", job.Call.Method, "path", job.Call.Path, "status", resp.StatusCode)
+	}
+
+	if job.Call.Result == "" {
+		return nil
+	}
+	result := HTTPCallResult{
+		Upstream: job.Upstream,
+		Call:     job.Call,
+		Status:   resp.StatusCode,
+		Headers:  map[string]string{},
+		Body:     body,
+	}
+	for name := range resp.Header {
+		result.Headers[name] = resp.Header.Get(name)
+	}
+	if _, err := a.Queue.Enqueue(ctx, job.Call.Result, result, EnqueueOptions{Queue: client.Config.RateLimit.Queue}); err != nil {
+		// the call is not sent twice for its result
+		logger.Error("Failed to queue HTTP call result", "upstream", job.Upstream, "result", job.Call.Result, "error", err)
+	}
+	return nil
+}
@@ -0,0 +1,343 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// ErrCircuitOpen is returned by HTTPClient while the upstream is considered down
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+const httpClientLibrary = "HTTPClient"
+
+// httpClientMu serializes the creation of clients, the library manager is not safe for concurrent use
+var httpClientMu sync.Mutex
+
+// HTTPClientStats are the counters of an upstream since the client was created
+type HTTPClientStats struct {
+	Upstream  string        `json:"upstream"`
+	Requests  int64         `json:"requests"`
+	Failures  int64         `json:"failures"`
+	Retries   int64         `json:"retries"`
+	Rejected  int64         `json:"rejected"`  // short-circuited while the breaker was open
+	Throttled int64         `json:"throttled"` // failed with ErrRateLimited
+	Spilled   int64         `json:"spilled"`   // queued by Send because of the rate limit
+	Latency   time.Duration `json:"avg_latency"`
+	Breaker   string        `json:"breaker"`
+}
+
+// HTTPClient is an outbound HTTP client bound to one upstream. It spaces the requests to
+// respect the rate limit of the partner, retries idempotent requests on network errors and
+// 429/502/503/504, and stops calling the upstream for a while after consecutive failures.
+// Clients are libraries keyed by upstream name, get them with AppContext.HTTPClient.
+type HTTPClient struct {
+	Upstream string
+	Config   config.HTTPClientConfig
+	client   *http.Client
+	clock    helper.Clock
+	breaker  *CircuitBreaker
+	limiter  *helper.TokenBucket // nil without rate limit
+	queue    *JobQueue
+	retry    RetryPolicy
+
+	requests  atomic.Int64
+	failures  atomic.Int64
+	retries   atomic.Int64
+	rejected  atomic.Int64
+	throttled atomic.Int64
+	spilled   atomic.Int64
+	latency   atomic.Int64 // total nanoseconds of the attempts
+}
+
+// HTTPClient returns the client of an upstream configured in http_clients, creating it on first use
+func (a *AppContext) HTTPClient(upstream string) (*HTTPClient, error) {
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+
+	libmanager := Instance().LibraryManager
+	if library, ok := libmanager.GetInstance(httpClientLibrary, upstream); ok {
+		return library.(*HTTPClient), nil
+	}
+
+	cfg, ok := a.Config.HTTPClients[upstream]
+	if !ok {
+		return nil, fmt.Errorf("HTTP client '%s' is not configured", upstream)
+	}
+
+	library, err := libmanager.LoadInstance(reflect.TypeOf(HTTPClient{}), upstream, a, cfg, upstream)
+	if err != nil {
+		return nil, err
+	}
+	return library.(*HTTPClient), nil
+}
+
+// HTTPClientStats returns the counters of every client created so far
+func (a *AppContext) HTTPClientStats() []HTTPClientStats {
+	httpClientMu.Lock()
+	defer httpClientMu.Unlock()
+
+	libmanager := Instance().LibraryManager
+	libmanager.mu.RLock()
+	clients := slices.Collect(maps.Values(libmanager.Libraries[httpClientLibrary]))
+	libmanager.mu.RUnlock()
+
+	result := []HTTPClientStats{}
+	for _, library := range clients {
+		result = append(result, library.(*HTTPClient).Stats())
+	}
+	return result
+}
+
+func (h *HTTPClient) Install(args ...any) error {
+	app := args[0].(*AppContext)
+	h.Config = args[1].(config.HTTPClientConfig)
+	h.Upstream = args[2].(string)
+	h.clock = app.Clock
+	h.queue = app.Queue
+
+	if h.Config.Timeout <= 0 {
+		h.Config.Timeout = 30 * time.Second
+	}
+	if h.Config.MaxIdleConns <= 0 {
+		h.Config.MaxIdleConns = 100
+	}
+	if h.Config.IdleConnTimeout <= 0 {
+		h.Config.IdleConnTimeout = 90 * time.Second
+	}
+	if h.Config.Retry.MaxAttempts <= 0 {
+		h.Config.Retry.MaxAttempts = 3
+	}
+	if h.Config.Retry.Backoff <= 0 {
+		h.Config.Retry.Backoff = 100 * time.Millisecond
+	}
+	if h.Config.Retry.MaxBackoff <= 0 {
+		h.Config.Retry.MaxBackoff = 5 * time.Second
+	}
+	h.retry = RetryPolicy{
+		Name:        "http:" + h.Upstream,
+		MaxAttempts: h.Config.Retry.MaxAttempts,
+		Backoff:     h.Config.Retry.Backoff,
+		MaxBackoff:  h.Config.Retry.MaxBackoff,
+	}
+	if name := h.Config.Retry.Policy; name != "" {
+		if _, ok := app.Config.App.Retry.Policies[name]; !ok {
+			return fmt.Errorf("http_clients.%s.retry.policy: '%s' is not in app.retry.policies", h.Upstream, name)
+		}
+		h.retry = app.Retries.Policy(name, h.retry)
+	}
+	if h.Config.Breaker.Threshold == 0 {
+		h.Config.Breaker.Threshold = 5
+	}
+	if h.Config.Breaker.Cooldown <= 0 {
+		h.Config.Breaker.Cooldown = 30 * time.Second
+	}
+	if h.Config.RateLimit.Rate > 0 {
+		if h.Config.RateLimit.MaxWait <= 0 {
+			h.Config.RateLimit.MaxWait = 10 * time.Second
+		}
+		h.limiter = helper.NewTokenBucket(h.Config.RateLimit.Rate, h.Config.RateLimit.Burst, h.clock)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = h.Config.MaxIdleConns
+	transport.MaxIdleConnsPerHost = h.Config.MaxIdleConns
+	transport.MaxConnsPerHost = h.Config.MaxConnsPerHost
+	transport.IdleConnTimeout = h.Config.IdleConnTimeout
+
+	h.client = &http.Client{Transport: transport, Timeout: h.Config.Timeout}
+	h.breaker = app.Resilience.CircuitBreaker("http:"+h.Upstream, h.Config.Breaker)
+	return nil
+}
+
+func (h *HTTPClient) Uninstall() error {
+	h.client.CloseIdleConnections()
+	return nil
+}
+
+// NewRequest creates a request for a path relative to the base URL of the upstream.
+// Bodies from bytes, strings or buffers can be replayed and are retried.
+func (h *HTTPClient) NewRequest(ctx context.Context, method string, path string, body io.Reader) (*http.Request, error) {
+	target := path
+	if h.Config.BaseURL != "" && !strings.Contains(path, "://") {
+		target = strings.TrimRight(h.Config.BaseURL, "/") + "/" + strings.TrimLeft(path, "/")
+	}
+	return http.NewRequestWithContext(ctx, method, target, body)
+}
+
+// Get is a shortcut for NewRequest and Do
+func (h *HTTPClient) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := h.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return h.Do(req)
+}
+
+// Post is a shortcut for NewRequest and Do, body is sent with contentType
+func (h *HTTPClient) Post(ctx context.Context, path string, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := h.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return h.Do(req)
+}
+
+// Do sends the request with the upstream headers, rate limit, retries and circuit breaker.
+// The last response is returned as is when retries are exhausted, like http.Client.Do.
+// It fails with helper.ErrRateLimited when the rate limit delays an attempt more than max_wait.
+func (h *HTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return h.do(req, h.Config.RateLimit.MaxWait, false)
+}
+
+// do sends the request waiting at most maxWait (0 for no limit) for each slot of the rate
+// limit, reserved tells the slot of the first attempt was already taken
+func (h *HTTPClient) do(req *http.Request, maxWait time.Duration, reserved bool) (*http.Response, error) {
+	for name, value := range h.Config.Headers {
+		if req.Header.Get(name) == "" {
+			req.Header.Set(name, value)
+		}
+	}
+	if requestID := helper.RequestID(req.Context()); requestID != "" && req.Header.Get("X-Request-ID") == "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+	if traceParent := helper.TraceParent(req.Context()); traceParent != "" && req.Header.Get("traceparent") == "" {
+		req.Header.Set("traceparent", traceParent)
+	}
+
+	retryable := h.retryable(req)
+
+	for attempt := 1; ; attempt++ {
+		if h.limiter != nil && (attempt > 1 || !reserved) {
+			if err := h.limiter.Wait(req.Context(), maxWait); err != nil {
+				if errors.Is(err, helper.ErrRateLimited) {
+					h.throttled.Add(1)
+					err = fmt.Errorf("%s: %w", h.Upstream, err)
+				}
+				return nil, err
+			}
+		}
+
+		if !h.breaker.Allow() {
+			h.rejected.Add(1)
+			return nil, fmt.Errorf("%s: %w", h.Upstream, ErrCircuitOpen)
+		}
+
+		if attempt > 1 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			req.Body = body
+		}
+
+		h.requests.Add(1)
+		start := h.clock.Now()
+		resp, err := h.client.Do(req)
+		h.latency.Add(int64(h.clock.Since(start)))
+
+		switch {
+		case err == nil && resp.StatusCode < 500:
+			h.breaker.Success()
+		case req.Context().Err() != nil:
+			// cancelled by the caller, says nothing about the upstream
+			h.breaker.Abort()
+		default:
+			h.failures.Add(1)
+			h.breaker.Failure()
+		}
+
+		if !retryable || req.Context().Err() != nil || (err == nil && !isRetryableStatus(resp.StatusCode)) {
+			return resp, err
+		}
+		failure := err
+		if failure == nil {
+			failure = fmt.Errorf("%s: status %d", h.Upstream, resp.StatusCode)
+		}
+		delay, retry := h.retry.Next(attempt, failure)
+		if !retry {
+			return resp, err
+		}
+
+		if resp != nil {
+			if after := retryAfter(resp); after > delay && (h.retry.MaxBackoff == 0 || after <= h.retry.MaxBackoff) {
+				delay = after
+			}
+			// drain so the connection can be reused
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			resp.Body.Close()
+		}
+
+		h.retries.Add(1)
+		logger.Debug("Retrying HTTP request", "upstream", h.Upstream, "method", req.Method, "url", req.URL.Redacted(), "attempt", attempt, "error", err)
+
+		select {
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		case <-h.clock.After(delay):
+		}
+	}
+}
+
+// Stats returns the counters of the client
+func (h *HTTPClient) Stats() HTTPClientStats {
+	stats := HTTPClientStats{
+		Upstream:  h.Upstream,
+		Requests:  h.requests.Load(),
+		Failures:  h.failures.Load(),
+		Retries:   h.retries.Load(),
+		Rejected:  h.rejected.Load(),
+		Throttled: h.throttled.Load(),
+		Spilled:   h.spilled.Load(),
+		Breaker:   h.breaker.State(),
+	}
+	if stats.Requests > 0 {
+		stats.Latency = time.Duration(h.latency.Load() / stats.Requests)
+	}
+	return stats
+}
+
+// retryable reports whether sending the request twice is safe and possible
+func (h *HTTPClient) retryable(req *http.Request) bool {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		return false
+	}
+
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return h.Config.Retry.NonIdempotent || req.Header.Get("Idempotency-Key") != ""
+}
+
+func isRetryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// retryAfter parses the Retry-After header given in seconds
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
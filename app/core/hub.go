@@ -0,0 +1,741 @@
+package core
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/websocket"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Message types exchanged with hub clients. Clients send subscribe, unsubscribe, publish,
+// direct and presence; the hub answers with subscribed, unsubscribed, message, presence,
+// join, leave and error.
+const (
+	HubSubscribe    = "subscribe"
+	HubUnsubscribe  = "unsubscribe"
+	HubPublish      = "publish"
+	HubDirect       = "direct"
+	HubPresence     = "presence"
+	HubSubscribed   = "subscribed"
+	HubUnsubscribed = "unsubscribed"
+	HubMessageType  = "message"
+	HubJoin         = "join"
+	HubLeave        = "leave"
+	HubError        = "error"
+)
+
+// HubMessage is a JSON frame of the hub protocol
+type HubMessage struct {
+	Type    string          `json:"type"`
+	Channel string          `json:"channel,omitempty"`
+	To      string          `json:"to,omitempty"`   // user of a direct message
+	From    string          `json:"from,omitempty"` // user who sent the message, empty for the server
+	Data    json.RawMessage `json:"data,omitempty"`
+	Members []string        `json:"members,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// HubAuthorizer decides whether client may perform action (subscribe, publish, direct
+// or presence) on target, a channel or the user of a direct message
+type HubAuthorizer func(client *HubClient, action string, target string) error
+
+// HubClient is a websocket connection of the hub
+type HubClient struct {
+	ID     string
+	UserID string // the client ID for anonymous connections
+	User   auth.IUserAuthInfo
+	Roles  []string
+	Tenant string // ID of the tenant of the connection request, empty without tenancy
+
+	target    FlagTarget   // of the connection request, evaluates the flags pushed to the client
+	tenant    *port.Tenant // of the connection request, reads the settings pushed to the client
+	conn      *websocket.Conn
+	send      chan []byte
+	closeOnce sync.Once
+	channels  map[string]bool // guarded by Hub.mu
+}
+
+// HubStats is served by the admin endpoint
+type HubStats struct {
+	Instance    string         `json:"instance"`
+	Broker      bool           `json:"broker"` // messages go through a shared broker
+	Connections int            `json:"connections"`
+	Users       int            `json:"users"`
+	Channels    map[string]int `json:"channels"` // local connections per channel
+}
+
+// hubEnvelope is published on the broker, Channel or User tells who receives Message
+type hubEnvelope struct {
+	Channel string      `json:"channel,omitempty"`
+	User    string      `json:"user,omitempty"`
+	Message *HubMessage `json:"message"`
+}
+
+// Hub relays messages between websocket clients: channels with presence and direct
+// messages to users. Messages always go through the broker, so clients connected to
+// different instances of the application reach each other without sticky sessions.
+type Hub struct {
+	mu            sync.RWMutex
+	config        config.HubConfig
+	instance      string
+	broker        port.IHubBroker
+	presence      port.IHubPresence
+	shared        bool
+	authorize     HubAuthorizer
+	authorization auth.IAuthorization
+	clients       map[*HubClient]bool
+	channels      map[string]map[*HubClient]bool
+	users         map[string]map[*HubClient]bool
+	ctx           context.Context
+	cancel        context.CancelFunc
+	wg            sync.WaitGroup
+}
+
+// NewHub creates a stopped hub delivering messages in-process
+func NewHub(cfg config.HubConfig) *Hub {
+	if cfg.Topic == "" {
+		cfg.Topic = "webcore:hub"
+	}
+	if cfg.PresenceTTL <= 0 {
+		cfg.PresenceTTL = time.Minute
+	}
+
+	local := NewLocalHubBroker()
+	return &Hub{
+		config:   cfg,
+		instance: randomHubID(),
+		broker:   local,
+		presence: local,
+		clients:  make(map[*HubClient]bool),
+		channels: make(map[string]map[*HubClient]bool),
+		users:    make(map[string]map[*HubClient]bool),
+		ctx:      context.Background(),
+	}
+}
+
+// SetBroker shares the hub with the other instances through broker, must be called before Start.
+// Presence stays per instance unless the broker implements port.IHubPresence.
+func (h *Hub) SetBroker(broker port.IHubBroker) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.broker = broker
+	h.shared = true
+	h.presence = NewLocalHubBroker()
+	if presence, ok := broker.(port.IHubPresence); ok {
+		h.presence = presence
+	}
+}
+
+// SetAuthorizer restricts what clients may do, every action is allowed without one
+// unless an authorization library is set
+func (h *Hub) SetAuthorizer(authorize HubAuthorizer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authorize = authorize
+}
+
+// SetAuthorization checks the actions of authenticated clients against the "HUB action:target"
+// resources of the authorization library when no HubAuthorizer is set
+func (h *Hub) SetAuthorization(authorization auth.IAuthorization) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.authorization = authorization
+}
+
+// Start subscribes to the broker
+func (h *Hub) Start(ctx context.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cancel != nil {
+		return
+	}
+
+	h.ctx, h.cancel = context.WithCancel(ctx)
+	broker, runCtx := h.broker, h.ctx
+
+	h.wg.Add(2)
+	go func() {
+		defer h.wg.Done()
+		for runCtx.Err() == nil {
+			err := broker.Subscribe(runCtx, h.config.Topic, h.receive)
+			if err != nil && runCtx.Err() == nil {
+				logger.Error("Hub subscription failed", "topic", h.config.Topic, "error", err)
+				select {
+				case <-runCtx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+		}
+	}()
+	go func() {
+		defer h.wg.Done()
+		h.refreshPresence(runCtx)
+	}()
+
+	logger.Info("Hub started", "instance", h.instance, "topic", h.config.Topic, "shared", h.shared)
+}
+
+// Stop disconnects every client and leaves the broker
+func (h *Hub) Stop() {
+	h.mu.Lock()
+	cancel := h.cancel
+	h.cancel = nil
+	clients := make([]*HubClient, 0, len(h.clients))
+	for client := range h.clients {
+		clients = append(clients, client)
+	}
+	h.mu.Unlock()
+
+	for _, client := range clients {
+		client.conn.WriteClose(websocket.CloseGoingAway, "server shutdown")
+		client.conn.Close()
+	}
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	h.wg.Wait()
+}
+
+// Handler upgrades requests to hub connections, the user is the one set by the authentication
+func (h *Hub) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !websocket.IsUpgrade(c) {
+			return fiber.NewError(fiber.StatusUpgradeRequired, "Websocket connection required")
+		}
+
+		client := &HubClient{
+			ID:       randomHubID(),
+			User:     auth.CurrentUser(c),
+			Roles:    auth.CurrentUserRoles(c),
+			send:     make(chan []byte, 64),
+			channels: make(map[string]bool),
+		}
+		client.UserID = auth.CurrentUserID(c)
+		if client.UserID == "" {
+			client.UserID = client.ID
+		}
+		if client.tenant = helper.CurrentTenant(c); client.tenant != nil {
+			client.Tenant = client.tenant.ID
+		}
+		client.target = CurrentFlagTarget(c.UserContext())
+		if client.target.Principal == "" {
+			client.target = requestFlagTarget(c, "")
+		}
+
+		return websocket.Upgrade(c, func(conn *websocket.Conn) {
+			client.conn = conn
+			h.serve(client)
+		}, websocket.Options{
+			ReadLimit:    h.config.ReadLimit,
+			PingInterval: h.config.PingInterval,
+			Origins:      h.config.Origins,
+		})
+	}
+}
+
+// Broadcast sends data to the subscribers of channel on every instance
+func (h *Hub) Broadcast(ctx context.Context, channel string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.publish(ctx, &hubEnvelope{Channel: channel, Message: &HubMessage{Type: HubMessageType, Channel: channel, Data: raw}})
+}
+
+// SendToUser sends data to every connection of user on every instance
+func (h *Hub) SendToUser(ctx context.Context, user string, data any) error {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return h.publish(ctx, &hubEnvelope{User: user, Message: &HubMessage{Type: HubMessageType, To: user, Data: raw}})
+}
+
+// Push sends to each subscriber of channel connected to this instance the data fn returns for
+// it, nothing when fn returns nil (ex: a value of its tenant, a flag evaluated for its user).
+// It is meant for the changes every instance observes by itself.
+func (h *Hub) Push(channel string, fn func(client *HubClient) any) {
+	h.mu.RLock()
+	clients := make([]*HubClient, 0, len(h.channels[channel]))
+	for client := range h.channels[channel] {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		data := fn(client)
+		if data == nil {
+			continue
+		}
+		raw, err := json.Marshal(data)
+		if err != nil {
+			logger.Warn("Hub push dropped", "channel", channel, "error", err)
+			return
+		}
+		message, err := json.Marshal(&HubMessage{Type: HubMessageType, Channel: channel, Data: raw})
+		if err != nil {
+			return
+		}
+		h.deliver(client, message)
+	}
+}
+
+// Members returns the users subscribed to channel on every instance
+func (h *Hub) Members(ctx context.Context, channel string) ([]string, error) {
+	members, err := h.getPresence().Members(ctx, channel)
+	if err != nil {
+		return nil, err
+	}
+
+	// members are "instance:user" so a user connected to several instances stays present
+	// until the last one leaves
+	seen := make(map[string]bool, len(members))
+	users := make([]string, 0, len(members))
+	for _, member := range members {
+		_, user, _ := strings.Cut(member, ":")
+		if !seen[user] {
+			seen[user] = true
+			users = append(users, user)
+		}
+	}
+	sort.Strings(users)
+	return users, nil
+}
+
+// Stats returns the local connections of the hub
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := HubStats{
+		Instance:    h.instance,
+		Broker:      h.shared,
+		Connections: len(h.clients),
+		Users:       len(h.users),
+		Channels:    make(map[string]int, len(h.channels)),
+	}
+	for channel, clients := range h.channels {
+		stats.Channels[channel] = len(clients)
+	}
+	return stats
+}
+
+func (h *Hub) serve(client *HubClient) {
+	h.mu.Lock()
+	h.clients[client] = true
+	if h.users[client.UserID] == nil {
+		h.users[client.UserID] = make(map[*HubClient]bool)
+	}
+	h.users[client.UserID][client] = true
+	h.mu.Unlock()
+
+	defer h.disconnect(client)
+
+	go func() {
+		for message := range client.send {
+			if err := client.conn.WriteMessage(websocket.TextMessage, message); err != nil {
+				client.conn.Close()
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := client.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+
+		message := &HubMessage{}
+		if err := json.Unmarshal(data, message); err != nil {
+			h.reply(client, &HubMessage{Type: HubError, Error: "Invalid message: " + err.Error()})
+			continue
+		}
+		if err := h.handle(client, message); err != nil {
+			h.reply(client, &HubMessage{Type: HubError, Channel: message.Channel, To: message.To, Error: err.Error()})
+		}
+	}
+}
+
+func (h *Hub) handle(client *HubClient, message *HubMessage) error {
+	ctx := h.context()
+
+	switch message.Type {
+	case HubSubscribe:
+		if message.Channel == "" {
+			return fmt.Errorf("Channel is required")
+		}
+		if err := h.allow(client, HubSubscribe, message.Channel); err != nil {
+			return err
+		}
+		return h.subscribe(ctx, client, message.Channel)
+	case HubUnsubscribe:
+		return h.unsubscribe(ctx, client, message.Channel, true)
+	case HubPublish:
+		if message.Channel == "" {
+			return fmt.Errorf("Channel is required")
+		}
+		if err := h.allow(client, HubPublish, message.Channel); err != nil {
+			return err
+		}
+		return h.publish(ctx, &hubEnvelope{Channel: message.Channel, Message: &HubMessage{
+			Type: HubMessageType, Channel: message.Channel, From: client.UserID, Data: message.Data,
+		}})
+	case HubDirect:
+		if message.To == "" {
+			return fmt.Errorf("Recipient is required")
+		}
+		if err := h.allow(client, HubDirect, message.To); err != nil {
+			return err
+		}
+		return h.publish(ctx, &hubEnvelope{User: message.To, Message: &HubMessage{
+			Type: HubMessageType, To: message.To, From: client.UserID, Data: message.Data,
+		}})
+	case HubPresence:
+		if err := h.allow(client, HubPresence, message.Channel); err != nil {
+			return err
+		}
+		members, err := h.Members(ctx, message.Channel)
+		if err != nil {
+			return err
+		}
+		h.reply(client, &HubMessage{Type: HubPresence, Channel: message.Channel, Members: members})
+	default:
+		return fmt.Errorf("Unknown message type %q", message.Type)
+	}
+	return nil
+}
+
+func (h *Hub) allow(client *HubClient, action string, target string) error {
+	h.mu.RLock()
+	authorize, authorization := h.authorize, h.authorization
+	h.mu.RUnlock()
+
+	if authorize != nil {
+		return authorize(client, action, target)
+	}
+	if authorization != nil && client.User != nil {
+		return authorization.Check(client.User, "HUB", action+":"+target)
+	}
+	return nil
+}
+
+// subscribe acknowledges before announcing the join, so the client receives its own join
+func (h *Hub) subscribe(ctx context.Context, client *HubClient, channel string) error {
+	h.mu.Lock()
+	if client.channels[channel] {
+		h.mu.Unlock()
+		h.reply(client, &HubMessage{Type: HubSubscribed, Channel: channel})
+		return nil
+	}
+	first := !h.userInChannel(client.UserID, channel)
+	client.channels[channel] = true
+	if h.channels[channel] == nil {
+		h.channels[channel] = make(map[*HubClient]bool)
+	}
+	h.channels[channel][client] = true
+	h.mu.Unlock()
+
+	h.reply(client, &HubMessage{Type: HubSubscribed, Channel: channel})
+	if !first {
+		return nil
+	}
+	if err := h.getPresence().Join(ctx, channel, h.member(client.UserID), h.config.PresenceTTL); err != nil {
+		return err
+	}
+	return h.publish(ctx, &hubEnvelope{Channel: channel, Message: &HubMessage{Type: HubJoin, Channel: channel, From: client.UserID}})
+}
+
+func (h *Hub) unsubscribe(ctx context.Context, client *HubClient, channel string, ack bool) error {
+	h.mu.Lock()
+	if !client.channels[channel] {
+		h.mu.Unlock()
+		if ack {
+			h.reply(client, &HubMessage{Type: HubUnsubscribed, Channel: channel})
+		}
+		return nil
+	}
+	delete(client.channels, channel)
+	delete(h.channels[channel], client)
+	if len(h.channels[channel]) == 0 {
+		delete(h.channels, channel)
+	}
+	last := !h.userInChannel(client.UserID, channel)
+	h.mu.Unlock()
+
+	if ack {
+		h.reply(client, &HubMessage{Type: HubUnsubscribed, Channel: channel})
+	}
+
+	if !last {
+		return nil
+	}
+	if err := h.getPresence().Leave(ctx, channel, h.member(client.UserID)); err != nil {
+		return err
+	}
+	return h.publish(ctx, &hubEnvelope{Channel: channel, Message: &HubMessage{Type: HubLeave, Channel: channel, From: client.UserID}})
+}
+
+// userInChannel reports whether a local connection of user is subscribed to channel, h.mu must be held
+func (h *Hub) userInChannel(user string, channel string) bool {
+	for client := range h.channels[channel] {
+		if client.UserID == user {
+			return true
+		}
+	}
+	return false
+}
+
+func (h *Hub) disconnect(client *HubClient) {
+	h.mu.Lock()
+	channels := make([]string, 0, len(client.channels))
+	for channel := range client.channels {
+		channels = append(channels, channel)
+	}
+	h.mu.Unlock()
+
+	// leave even when the hub is stopping, other instances would list the user until the presence expires
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(h.context()), 5*time.Second)
+	defer cancel()
+	for _, channel := range channels {
+		if err := h.unsubscribe(ctx, client, channel, false); err != nil {
+			logger.Warn("Hub failed to leave channel", "channel", channel, "user", client.UserID, "error", err)
+		}
+	}
+
+	h.mu.Lock()
+	delete(h.clients, client)
+	delete(h.users[client.UserID], client)
+	if len(h.users[client.UserID]) == 0 {
+		delete(h.users, client.UserID)
+	}
+	h.mu.Unlock()
+
+	client.closeOnce.Do(func() { close(client.send) })
+}
+
+func (h *Hub) publish(ctx context.Context, envelope *hubEnvelope) error {
+	data, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	return h.getBroker().Publish(ctx, h.config.Topic, data)
+}
+
+// receive delivers a message of the broker to the local clients
+func (h *Hub) receive(data []byte) {
+	envelope := &hubEnvelope{}
+	if err := json.Unmarshal(data, envelope); err != nil || envelope.Message == nil {
+		logger.Warn("Hub dropped an invalid message", "error", err)
+		return
+	}
+
+	message, err := json.Marshal(envelope.Message)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	recipients := h.channels[envelope.Channel]
+	if envelope.User != "" {
+		recipients = h.users[envelope.User]
+	}
+	clients := make([]*HubClient, 0, len(recipients))
+	for client := range recipients {
+		clients = append(clients, client)
+	}
+	h.mu.RUnlock()
+
+	for _, client := range clients {
+		h.deliver(client, message)
+	}
+}
+
+func (h *Hub) reply(client *HubClient, message *HubMessage) {
+	data, err := json.Marshal(message)
+	if err != nil {
+		return
+	}
+	h.deliver(client, data)
+}
+
+// deliver queues a message without blocking, clients too slow to keep up are disconnected
+func (h *Hub) deliver(client *HubClient, message []byte) {
+	defer func() {
+		// the client disconnected while the message was queued
+		recover()
+	}()
+
+	select {
+	case client.send <- message:
+	default:
+		logger.Warn("Hub disconnected a slow client", "client", client.ID, "user", client.UserID)
+		client.conn.WriteClose(websocket.ClosePolicyViolation, "slow consumer")
+		client.conn.Close()
+	}
+}
+
+// refreshPresence joins the channels again before the members expire
+func (h *Hub) refreshPresence(ctx context.Context) {
+	ticker := time.NewTicker(h.config.PresenceTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.mu.RLock()
+		members := make(map[string][]string, len(h.channels))
+		for channel, clients := range h.channels {
+			seen := make(map[string]bool)
+			for client := range clients {
+				if !seen[client.UserID] {
+					seen[client.UserID] = true
+					members[channel] = append(members[channel], client.UserID)
+				}
+			}
+		}
+		h.mu.RUnlock()
+
+		presence := h.getPresence()
+		for channel, users := range members {
+			for _, user := range users {
+				if err := presence.Join(ctx, channel, h.member(user), h.config.PresenceTTL); err != nil {
+					logger.Warn("Hub failed to refresh presence", "channel", channel, "error", err)
+				}
+			}
+		}
+	}
+}
+
+func (h *Hub) member(user string) string {
+	return h.instance + ":" + user
+}
+
+func (h *Hub) context() context.Context {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.ctx
+}
+
+func (h *Hub) getBroker() port.IHubBroker {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.broker
+}
+
+func (h *Hub) getPresence() port.IHubPresence {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.presence
+}
+
+func randomHubID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// LocalHubBroker is the in-process broker of a hub without shared broker
+type LocalHubBroker struct {
+	mu       sync.RWMutex
+	handlers map[string]map[*func([]byte)]bool
+	members  map[string]map[string]time.Time // channel -> member -> expiration
+}
+
+// NewLocalHubBroker creates an in-process broker
+func NewLocalHubBroker() *LocalHubBroker {
+	return &LocalHubBroker{
+		handlers: make(map[string]map[*func([]byte)]bool),
+		members:  make(map[string]map[string]time.Time),
+	}
+}
+
+func (b *LocalHubBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	b.mu.RLock()
+	handlers := make([]func([]byte), 0, len(b.handlers[topic]))
+	for handler := range b.handlers[topic] {
+		handlers = append(handlers, *handler)
+	}
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(message)
+	}
+	return nil
+}
+
+func (b *LocalHubBroker) Subscribe(ctx context.Context, topic string, handler func(message []byte)) error {
+	key := &handler
+
+	b.mu.Lock()
+	if b.handlers[topic] == nil {
+		b.handlers[topic] = make(map[*func([]byte)]bool)
+	}
+	b.handlers[topic][key] = true
+	b.mu.Unlock()
+
+	<-ctx.Done()
+
+	b.mu.Lock()
+	delete(b.handlers[topic], key)
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *LocalHubBroker) Join(ctx context.Context, channel string, member string, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.members[channel] == nil {
+		b.members[channel] = make(map[string]time.Time)
+	}
+	b.members[channel][member] = time.Now().Add(ttl)
+	return nil
+}
+
+func (b *LocalHubBroker) Leave(ctx context.Context, channel string, member string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	delete(b.members[channel], member)
+	if len(b.members[channel]) == 0 {
+		delete(b.members, channel)
+	}
+	return nil
+}
+
+func (b *LocalHubBroker) Members(ctx context.Context, channel string) ([]string, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	now := time.Now()
+	members := []string{}
+	for member, expire := range b.members[channel] {
+		if now.Before(expire) {
+			members = append(members, member)
+		}
+	}
+	sort.Strings(members)
+	return members, nil
+}
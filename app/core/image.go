@@ -0,0 +1,249 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/imaging"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ImageJobType is the job queue type used by ImageProcessor.VariantsAsync
+const ImageJobType = "image.variants"
+
+// ImageVariant describes a preset rendition stored next to its source image
+type ImageVariant struct {
+	Preset string `json:"preset"`
+	Key    string `json:"key"` // object storage key
+	imaging.Info
+}
+
+type imageJob struct {
+	Key     string   `json:"key"`
+	Presets []string `json:"presets,omitempty"`
+}
+
+// ImageProcessor resizes, crops and converts images with the configured presets, directly,
+// from the object storage or through the job queue for large batches
+type ImageProcessor struct {
+	config  config.ImageConfig
+	storage port.IObjectStorage
+	queue   *JobQueue
+	ready   []func(ctx context.Context, key string, variants []ImageVariant)
+}
+
+// NewImageProcessor creates an image processor and registers its variants job on queue
+func NewImageProcessor(cfg config.ImageConfig, queue *JobQueue) *ImageProcessor {
+	p := &ImageProcessor{
+		config: cfg,
+		queue:  queue,
+	}
+
+	HandleJob(queue, ImageJobType, func(ctx context.Context, job imageJob) error {
+		variants, err := p.Variants(ctx, job.Key, job.Presets...)
+		if err != nil {
+			return err
+		}
+		for _, fn := range p.ready {
+			fn(ctx, job.Key, variants)
+		}
+		return nil
+	})
+
+	return p
+}
+
+// SetStorage sets where the source images are read and the variants stored
+func (p *ImageProcessor) SetStorage(storage port.IObjectStorage) {
+	p.storage = storage
+}
+
+// OnReady registers a callback run after the variants of a queued image are stored
+func (p *ImageProcessor) OnReady(fn func(ctx context.Context, key string, variants []ImageVariant)) {
+	p.ready = append(p.ready, fn)
+}
+
+// Preset returns the options of a configured preset
+func (p *ImageProcessor) Preset(name string) (imaging.Options, error) {
+	preset, ok := p.config.Presets[name]
+	if !ok {
+		return imaging.Options{}, fmt.Errorf("unknown image preset '%s'", name)
+	}
+	return imaging.Options{
+		Width:   preset.Width,
+		Height:  preset.Height,
+		Mode:    preset.Mode,
+		Format:  preset.Format,
+		Quality: preset.Quality,
+	}, nil
+}
+
+// Process transforms src into w, EXIF orientation is applied and metadata dropped
+func (p *ImageProcessor) Process(src io.Reader, w io.Writer, opts imaging.Options) (*imaging.Info, error) {
+	return imaging.Process(src, w, p.options(opts), p.config.MaxPixels)
+}
+
+// ProcessPreset transforms src into w with a configured preset
+func (p *ImageProcessor) ProcessPreset(src io.Reader, w io.Writer, preset string) (*imaging.Info, error) {
+	opts, err := p.Preset(preset)
+	if err != nil {
+		return nil, err
+	}
+	return p.Process(src, w, opts)
+}
+
+// Variants renders the presets (all configured ones when none given) of the image stored
+// at key and stores each one under VariantKey
+func (p *ImageProcessor) Variants(ctx context.Context, key string, presets ...string) ([]ImageVariant, error) {
+	if p.storage == nil {
+		return nil, fmt.Errorf("image variants cannot be stored, no object storage configured")
+	}
+	presets, err := p.presets(presets)
+	if err != nil {
+		return nil, err
+	}
+
+	reader, _, err := p.storage.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	img, format, err := imaging.Decode(reader, p.config.MaxPixels)
+	reader.Close()
+	if err != nil {
+		return nil, fmt.Errorf("image '%s': %v", key, err)
+	}
+
+	variants := make([]ImageVariant, 0, len(presets))
+	for _, name := range presets {
+		opts, _ := p.Preset(name)
+		opts = p.options(opts)
+		if opts.Format == "" {
+			opts.Format = format
+		}
+
+		var buf bytes.Buffer
+		info, err := imaging.Encode(&buf, imaging.Transform(img, opts), opts.Format, opts.Quality)
+		if err != nil {
+			return nil, fmt.Errorf("image '%s' preset '%s': %v", key, name, err)
+		}
+
+		variant := ImageVariant{Preset: name, Key: VariantKey(key, name, info.Format), Info: *info}
+		_, err = p.storage.Put(ctx, variant.Key, &buf, port.PutOptions{
+			ContentType: info.ContentType,
+			Size:        info.Size,
+			Metadata:    map[string]string{"source": key, "preset": name},
+		})
+		if err != nil {
+			return nil, err
+		}
+		variants = append(variants, variant)
+	}
+	return variants, nil
+}
+
+// VariantsAsync queues one job per key rendering its presets, failures are retried by the
+// job queue. Use OnReady to learn when the variants are stored.
+func (p *ImageProcessor) VariantsAsync(ctx context.Context, keys []string, presets []string, opts ...EnqueueOptions) ([]string, error) {
+	if p.storage == nil {
+		return nil, fmt.Errorf("image variants cannot be queued, no object storage configured")
+	}
+	if _, err := p.presets(presets); err != nil {
+		return nil, err
+	}
+
+	opt := EnqueueOptions{Queue: p.config.Queue}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	ids := make([]string, 0, len(keys))
+	for _, key := range keys {
+		id, err := p.queue.Enqueue(ctx, ImageJobType, imageJob{Key: key, Presets: presets}, opt)
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// UploadWriter stores accepted images below prefix (image.prefix when empty) with their
+// metadata stripped, and queues the variants of presets. Non image uploads are stored as is.
+func (p *ImageProcessor) UploadWriter(prefix string, presets ...string) helper.UploadWriter {
+	if prefix == "" {
+		prefix = p.config.Prefix
+	}
+
+	return func(ctx context.Context, file *helper.UploadedFile, content io.Reader) (string, error) {
+		if p.storage == nil {
+			return "", fmt.Errorf("upload cannot be stored, no object storage configured")
+		}
+
+		data, err := io.ReadAll(content)
+		if err != nil {
+			return "", err
+		}
+		isImage := imaging.FormatOf(file.ContentType) != ""
+		if isImage {
+			if data, err = imaging.StripMetadata(data, p.config.Quality); err != nil {
+				return "", fmt.Errorf("image '%s': %v", file.Filename, err)
+			}
+		}
+
+		key, err := helper.StorageUploadWriter(p.storage, prefix)(ctx, &helper.UploadedFile{
+			Filename:    file.Filename,
+			ContentType: file.ContentType,
+			Size:        int64(len(data)),
+		}, bytes.NewReader(data))
+		if err != nil {
+			return "", err
+		}
+
+		if isImage && len(presets) > 0 {
+			if _, err := p.VariantsAsync(ctx, []string{key}, presets); err != nil {
+				return "", err
+			}
+		}
+		return key, nil
+	}
+}
+
+// VariantKey returns the object storage key of a preset of the image at key
+// (ex: "avatars/a1b2.png" with "thumbnail" as jpeg gives "avatars/a1b2_thumbnail.jpg")
+func VariantKey(key string, preset string, format string) string {
+	return strings.TrimSuffix(key, path.Ext(key)) + "_" + preset + imaging.Extension(format)
+}
+
+func (p *ImageProcessor) options(opts imaging.Options) imaging.Options {
+	if opts.Quality <= 0 {
+		opts.Quality = p.config.Quality
+	}
+	return opts
+}
+
+func (p *ImageProcessor) presets(names []string) ([]string, error) {
+	if len(names) == 0 {
+		for name := range p.config.Presets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("no image presets configured")
+		}
+		return names, nil
+	}
+
+	for _, name := range names {
+		if _, ok := p.config.Presets[name]; !ok {
+			return nil, fmt.Errorf("unknown image preset '%s'", name)
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,514 @@
+package core
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Import file formats
+const (
+	ImportCSV  = "csv"
+	ImportXLSX = "xlsx"
+)
+
+// ImportError is a rejected row of an import
+type ImportError struct {
+	Row     int    `json:"row"`             // row number in the file, the header included
+	Field   string `json:"field,omitempty"` // column title, or field of a FieldValidator failure
+	Message string `json:"message"`
+}
+
+// ImportResult summarizes an import
+type ImportResult struct {
+	Rows        int           `json:"rows"` // data rows read, blank rows are skipped
+	Imported    int           `json:"imported"`
+	Failed      int           `json:"failed"`
+	Errors      []ImportError `json:"errors,omitempty"`       // the first import.max_errors rejections
+	ErrorReport string        `json:"error_report,omitempty"` // object storage key of the CSV listing Errors
+}
+
+// ImportOptions describes how a file is read
+type ImportOptions struct {
+	Sheet     string // worksheet of xlsx files, the first one when empty
+	Comma     rune   // separator of csv files, detected between ',' ';' and tab when 0
+	ChunkSize int    // valid rows passed at once to the handler, import.chunk_size when 0
+}
+
+type importJob struct {
+	Key      string        `json:"key"`
+	Format   string        `json:"format"`
+	Filename string        `json:"filename"`
+	Options  ImportOptions `json:"options"`
+}
+
+// Importer maps the rows of uploaded CSV and XLSX files onto structs, validates them and
+// passes the valid ones by chunks to a handler, in the request or as a task on the job queue.
+// Columns are matched to fields by title like ReportSheetOf titles them (`report:"Title"`,
+// json tag or name, case insensitive) so an export can be imported back. Rows of a struct
+// implementing helper.FieldValidator are validated after the mapping.
+type Importer struct {
+	config  config.ImportConfig
+	tasks   *Tasks
+	storage port.IObjectStorage
+	xlsx    port.ISpreadsheetReader
+}
+
+// NewImporter creates an importer running the queued imports as tasks
+func NewImporter(cfg config.ImportConfig, tasks *Tasks) *Importer {
+	return &Importer{
+		config: cfg,
+		tasks:  tasks,
+	}
+}
+
+// SetStorage sets where queued imports keep the uploaded files and the error reports
+func (i *Importer) SetStorage(storage port.IObjectStorage) {
+	i.storage = storage
+}
+
+// SetXLSXReader sets the library reading xlsx workbooks
+func (i *Importer) SetXLSXReader(reader port.ISpreadsheetReader) {
+	i.xlsx = reader
+}
+
+// ReadImport reads the file in src and calls handler with the valid rows by chunks. Invalid rows
+// are reported in the result, an error of the handler stops the import and is returned.
+func ReadImport[T any](ctx context.Context, i *Importer, src io.ReaderAt, size int64, format string, opts ImportOptions, handler func(ctx context.Context, rows []T) error) (*ImportResult, error) {
+	return importRows(ctx, i, src, size, format, opts, handler, nil)
+}
+
+// HandleImport registers the handler of the imports named name, queued by Importer.Accept.
+// A failed attempt is retried from the first row, so the handler should upsert.
+func HandleImport[T any](i *Importer, name string, handler func(ctx context.Context, rows []T) error, policy ...RetryPolicy) {
+	HandleTask(i.tasks, "import:"+name, func(ctx context.Context, progress TaskProgress, job importJob) (any, error) {
+		if i.storage == nil {
+			return nil, fmt.Errorf("import cannot be read, no object storage configured")
+		}
+
+		file, size, err := i.download(ctx, job.Key)
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			file.Close()
+			os.Remove(file.Name())
+		}()
+
+		result, err := importRows(ctx, i, file, size, job.Format, job.Options, handler, progress)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(result.Errors) > 0 {
+			key := strings.TrimSuffix(job.Key, path.Ext(job.Key)) + ".errors.csv"
+			if err := i.storeErrors(ctx, key, result.Errors); err != nil {
+				return nil, err
+			}
+			result.ErrorReport = key
+		}
+
+		if err := i.storage.Delete(ctx, job.Key); err != nil {
+			logger.Warn("Failed to delete imported file", "key", job.Key, "error", err)
+		}
+		return result, nil
+	}, policy...)
+}
+
+// Accept stores the CSV or XLSX file sent in field and queues its import by the handler
+// registered as name, answering 202 Accepted with the task
+func (i *Importer) Accept(c *fiber.Ctx, name string, field string, opts ...ImportOptions) error {
+	if i.storage == nil {
+		return fmt.Errorf("import cannot be queued, no object storage configured")
+	}
+
+	job := importJob{}
+	if len(opts) > 0 {
+		job.Options = opts[0]
+	}
+
+	save := helper.StorageUploadWriter(i.storage, i.config.Prefix)
+	file, err := helper.ReceiveUpload(c, helper.UploadRules{Field: field, Required: true, MaxSize: i.config.MaxSize}, func(ctx context.Context, file *helper.UploadedFile, content io.Reader) (string, error) {
+		job.Format = importFormat(file.Filename)
+		if job.Format == "" {
+			return "", out.ValidationError([]out.FieldError{{Field: field, Rule: "format", Message: "file must be a csv or xlsx file", Param: "csv,xlsx"}})
+		}
+		return save(ctx, file, content)
+	})
+	if err != nil {
+		return err
+	}
+	job.Key = file.Location
+	job.Filename = file.Filename
+
+	return i.tasks.Accept(c, "import:"+name, job, EnqueueOptions{Queue: i.config.Queue})
+}
+
+// download copies the object at key into a temporary file, which the caller removes
+func (i *Importer) download(ctx context.Context, key string) (*os.File, int64, error) {
+	content, _, err := i.storage.Get(ctx, key)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer content.Close()
+
+	file, err := os.CreateTemp("", "import-*")
+	if err != nil {
+		return nil, 0, err
+	}
+
+	size, err := io.Copy(file, content)
+	if err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return nil, 0, err
+	}
+	return file, size, nil
+}
+
+func (i *Importer) storeErrors(ctx context.Context, key string, errors []ImportError) error {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write([]string{"row", "field", "message"})
+	for _, e := range errors {
+		w.Write([]string{strconv.Itoa(e.Row), e.Field, e.Message})
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return err
+	}
+
+	_, err := i.storage.Put(ctx, key, &buf, port.PutOptions{ContentType: "text/csv", Size: int64(buf.Len())})
+	return err
+}
+
+// readRows calls row with every row of the file and its number, counted from 1
+func (i *Importer) readRows(ctx context.Context, src io.ReaderAt, size int64, format string, opts ImportOptions, row func(number int, cells []string) error) error {
+	if i.config.MaxSize > 0 && size > i.config.MaxSize {
+		return fmt.Errorf("import file exceeds %d bytes", i.config.MaxSize)
+	}
+
+	number := 0
+	switch format {
+	case ImportCSV:
+		reader := bufio.NewReader(io.NewSectionReader(src, 0, size))
+		// skip the byte order mark written by spreadsheet applications
+		if bom, _ := reader.Peek(3); bytes.Equal(bom, []byte("\xef\xbb\xbf")) {
+			reader.Discard(3)
+		}
+
+		records := csv.NewReader(reader)
+		records.Comma = opts.Comma
+		if records.Comma == 0 {
+			records.Comma = csvSeparator(reader)
+		}
+		records.FieldsPerRecord = -1
+
+		for {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			cells, err := records.Read()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid csv file: %v", err)
+			}
+
+			number++
+			if err := row(number, cells); err != nil {
+				return err
+			}
+		}
+	case ImportXLSX:
+		if i.xlsx == nil {
+			return fmt.Errorf("XLSX imports are not configured")
+		}
+		return i.xlsx.ReadXLSX(ctx, src, size, opts.Sheet, func(cells []string) error {
+			number++
+			return row(number, cells)
+		})
+	}
+	return fmt.Errorf("unknown import format '%s'", format)
+}
+
+func importRows[T any](ctx context.Context, i *Importer, src io.ReaderAt, size int64, format string, opts ImportOptions, handler func(ctx context.Context, rows []T) error, progress TaskProgress) (*ImportResult, error) {
+	elem := reflect.TypeFor[T]()
+	if elem.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("import rows must be structs, got %s", elem)
+	}
+	fields := reportFields(elem)
+
+	chunkSize := opts.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = i.config.ChunkSize
+	}
+	if chunkSize <= 0 {
+		chunkSize = 500
+	}
+
+	// count the rows first so the progress of a task is meaningful, the header excluded
+	total := -1
+	if progress != nil {
+		if err := i.readRows(ctx, src, size, format, opts, func(number int, cells []string) error {
+			if !blankRow(cells) {
+				total++
+			}
+			return nil
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	result := &ImportResult{}
+	reject := func(e ImportError) {
+		if i.config.MaxErrors <= 0 || len(result.Errors) < i.config.MaxErrors {
+			result.Errors = append(result.Errors, e)
+		}
+	}
+
+	chunk := make([]T, 0, chunkSize)
+	flush := func() error {
+		if len(chunk) == 0 {
+			return nil
+		}
+		if err := handler(ctx, chunk); err != nil {
+			return err
+		}
+		result.Imported += len(chunk)
+		chunk = make([]T, 0, chunkSize)
+
+		if progress != nil && total > 0 {
+			progress(result.Rows*100/total, fmt.Sprintf("%d of %d rows read", result.Rows, total))
+		}
+		return nil
+	}
+
+	var columns []int // field of every column, -1 when ignored
+	err := i.readRows(ctx, src, size, format, opts, func(number int, cells []string) error {
+		if blankRow(cells) {
+			return nil
+		}
+		if columns == nil {
+			var err error
+			columns, err = importColumns(fields, cells)
+			return err
+		}
+		result.Rows++
+
+		var item T
+		value := reflect.ValueOf(&item).Elem()
+		failed := false
+		for c, text := range cells {
+			if c >= len(columns) || columns[c] < 0 {
+				continue
+			}
+			field := fields[columns[c]]
+			if err := setCell(value.FieldByIndex(field.index), strings.TrimSpace(text)); err != nil {
+				reject(ImportError{Row: number, Field: field.title, Message: err.Error()})
+				failed = true
+			}
+		}
+		if failed {
+			result.Failed++
+			return nil
+		}
+
+		if validator, ok := any(&item).(helper.FieldValidator); ok {
+			if errors := validator.Validate(); len(errors) > 0 {
+				for _, e := range errors {
+					reject(ImportError{Row: number, Field: e.Field, Message: e.Message})
+				}
+				result.Failed++
+				return nil
+			}
+		}
+
+		chunk = append(chunk, item)
+		if len(chunk) >= chunkSize {
+			return flush()
+		}
+		return nil
+	})
+	if err == nil {
+		err = flush()
+	}
+	if err != nil {
+		return result, err
+	}
+	if columns == nil {
+		return result, fmt.Errorf("the file is empty")
+	}
+	return result, nil
+}
+
+// importColumns maps the header cells onto fields by title
+func importColumns(fields []reportField, header []string) ([]int, error) {
+	columns := make([]int, len(header))
+	matched := false
+	for c, title := range header {
+		columns[c] = -1
+		title = strings.TrimSpace(title)
+		for f, field := range fields {
+			if title != "" && strings.EqualFold(title, field.title) {
+				columns[c] = f
+				matched = true
+				break
+			}
+		}
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("the header row of the file has none of the expected columns")
+	}
+	return columns, nil
+}
+
+// setCell converts the text of a cell into the type of field, empty cells keep the zero value
+func setCell(field reflect.Value, text string) error {
+	if text == "" {
+		return nil
+	}
+
+	if field.Kind() == reflect.Pointer {
+		value := reflect.New(field.Type().Elem())
+		if err := setCell(value.Elem(), text); err != nil {
+			return err
+		}
+		field.Set(value)
+		return nil
+	}
+
+	switch target := field.Addr().Interface().(type) {
+	case *time.Time:
+		t, err := parseCellTime(text)
+		if err != nil {
+			return err
+		}
+		*target = t
+		return nil
+	case *time.Duration:
+		d, err := time.ParseDuration(text)
+		if err != nil {
+			return fmt.Errorf("'%s' is not a duration", text)
+		}
+		*target = d
+		return nil
+	case encoding.TextUnmarshaler:
+		return target.UnmarshalText([]byte(text))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(text)
+	case reflect.Bool:
+		switch strings.ToLower(text) {
+		case "1", "true", "yes", "y":
+			field.SetBool(true)
+		case "0", "false", "no", "n":
+			field.SetBool(false)
+		default:
+			return fmt.Errorf("'%s' is not a boolean", text)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(strings.TrimSuffix(text, ".0"), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not an integer", text)
+		}
+		field.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(strings.TrimSuffix(text, ".0"), 10, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a positive integer", text)
+		}
+		field.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(text, field.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("'%s' is not a number", text)
+		}
+		field.SetFloat(n)
+	default:
+		return fmt.Errorf("cannot import into a %s field", field.Type())
+	}
+	return nil
+}
+
+var cellTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+// parseCellTime reads a date written as text or as an Excel serial date
+func parseCellTime(text string) (time.Time, error) {
+	for _, layout := range cellTimeLayouts {
+		if t, err := time.Parse(layout, text); err == nil {
+			return t, nil
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(text, 64); err == nil && serial > 0 && serial < 2958466 {
+		days, fraction := math.Modf(serial)
+		t := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(days))
+		return t.Add(time.Duration(math.Round(fraction*86400)) * time.Second), nil
+	}
+	return time.Time{}, fmt.Errorf("'%s' is not a date", text)
+}
+
+// csvSeparator guesses the separator from the header line: ',' ';' or tab
+func csvSeparator(reader *bufio.Reader) rune {
+	line, _ := reader.Peek(4096)
+	if end := bytes.IndexByte(line, '\n'); end >= 0 {
+		line = line[:end]
+	}
+
+	separator, most := ',', bytes.Count(line, []byte{','})
+	for _, candidate := range []rune{';', '\t'} {
+		if n := bytes.Count(line, []byte{byte(candidate)}); n > most {
+			separator, most = candidate, n
+		}
+	}
+	return separator
+}
+
+func blankRow(cells []string) bool {
+	for _, cell := range cells {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// importFormat returns the format of a file from its extension, empty when unsupported
+func importFormat(filename string) string {
+	switch strings.ToLower(path.Ext(filename)) {
+	case ".csv", ".txt":
+		return ImportCSV
+	case ".xlsx":
+		return ImportXLSX
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// InjectableLoader is a LibraryLoader taking its arguments as the fields of a Params struct
+// instead of the positions of args. The LibraryManager fills the exported fields by type from
+// the arguments of the load and the dependencies given to Provide, then calls InitWith in
+// place of Init:
+//
+//	type Params struct {
+//		Context *core.AppContext
+//		Config  config.AuthConfig
+//		Clock   helper.Clock `inject:"optional"`
+//	}
+//
+//	func (l *Loader) Params() any { return &Params{} }
+//
+//	func (l *Loader) InitWith(ctx context.Context, params any) (port.Library, error) {
+//		p := params.(*Params)
+//		...
+//	}
+//
+// A field is filled with the value of its exact type, else with the value assignable to it
+// (ex: an interface), the last one when several are given. The tag inject:"optional" leaves a
+// field zero when no value suits it, inject:"-" always leaves it zero.
+type InjectableLoader interface {
+	Params() any // a pointer to a new Params struct
+	InitWith(ctx context.Context, params any) (port.Library, error)
+}
+
+// DependencyError lists the fields of a Params struct the dependencies do not fill
+type DependencyError struct {
+	Params    string
+	Missing   []string // "<field> <type>" of the fields no value suits
+	Ambiguous []string // "<field> <type>" of the fields several types of value suit
+}
+
+func (e *DependencyError) Error() string {
+	var parts []string
+	if len(e.Missing) > 0 {
+		parts = append(parts, "missing "+strings.Join(e.Missing, ", "))
+	}
+	if len(e.Ambiguous) > 0 {
+		parts = append(parts, "ambiguous "+strings.Join(e.Ambiguous, ", "))
+	}
+	return fmt.Sprintf("dependencies of %s: %s", e.Params, strings.Join(parts, "; "))
+}
+
+// Provide adds values to the dependencies of the InjectableLoaders, after the ones given
+// before. The scopes inherit them and the arguments of a load come after them.
+func (lm *LibraryManager) Provide(values ...any) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	lm.provided = append(lm.provided, values...)
+}
+
+// dependencies returns the values given to Provide on the root manager down to lm
+func (lm *LibraryManager) dependencies() []any {
+	var values []any
+	if lm.parent != nil {
+		values = lm.parent.dependencies()
+	}
+
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+	return append(values, lm.provided...)
+}
+
+// InitInjected fills the Params of loader with values and initializes the library with them,
+// for the Init of an InjectableLoader called without the LibraryManager
+func InitInjected(ctx context.Context, loader InjectableLoader, values ...any) (port.Library, error) {
+	params := loader.Params()
+	if err := Inject(params, values...); err != nil {
+		return nil, err
+	}
+	return loader.InitWith(ctx, params)
+}
+
+// Inject fills the exported fields of the struct params points to by type from values, see
+// InjectableLoader. It returns a *DependencyError when a required field is left zero.
+func Inject(params any, values ...any) error {
+	target := reflect.ValueOf(params)
+	if target.Kind() != reflect.Pointer || target.IsNil() || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("params %T is not a pointer to a struct", params)
+	}
+	target = target.Elem()
+
+	depErr := &DependencyError{Params: target.Type().String()}
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		tag := field.Tag.Get("inject")
+		if !field.IsExported() || tag == "-" {
+			continue
+		}
+
+		value, ok, ambiguous := resolveDependency(field.Type, values)
+		switch {
+		case ambiguous:
+			depErr.Ambiguous = append(depErr.Ambiguous, field.Name+" "+field.Type.String())
+		case ok:
+			target.Field(i).Set(value)
+		case tag != "optional":
+			depErr.Missing = append(depErr.Missing, field.Name+" "+field.Type.String())
+		}
+	}
+
+	if len(depErr.Missing) > 0 || len(depErr.Ambiguous) > 0 {
+		return depErr
+	}
+	return nil
+}
+
+// resolveDependency returns the last value of values of type t, else the last one assignable
+// to t, which is ambiguous when values of several types are
+func resolveDependency(t reflect.Type, values []any) (value reflect.Value, ok bool, ambiguous bool) {
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] != nil && reflect.TypeOf(values[i]) == t {
+			return reflect.ValueOf(values[i]), true, false
+		}
+	}
+
+	for i := len(values) - 1; i >= 0; i-- {
+		if values[i] == nil || !reflect.TypeOf(values[i]).AssignableTo(t) {
+			continue
+		}
+		if !value.IsValid() {
+			value = reflect.ValueOf(values[i])
+		} else if value.Type() != reflect.TypeOf(values[i]) {
+			return reflect.Value{}, false, true
+		}
+	}
+	return value, value.IsValid(), false
+}
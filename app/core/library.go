@@ -1,9 +1,18 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"reflect"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/webcore-go/webcore/infra/config"
 	"github.com/webcore-go/webcore/infra/logger"
 	"github.com/webcore-go/webcore/port"
 )
@@ -14,9 +23,35 @@ type LibraryLoader interface {
 	Init(args ...any) (port.Library, error)
 }
 
+// ContextLibraryLoader is a LibraryLoader whose Init honors the cancellation of ctx, the
+// LibraryManager calls InitCtx in place of Init with the init timeout of the library
+type ContextLibraryLoader interface {
+	InitCtx(ctx context.Context, args ...any) (port.Library, error)
+}
+
+// DependentLoader is a LibraryLoader needing other libraries, named by loader (ex:
+// "authstorage:yaml") or by family (ex: "database" for any "database:<driver>"). They must be
+// loaded before the library, earlier or by its Init, and are unloaded after it.
+type DependentLoader interface {
+	DependsOn() []string
+}
+
 type LibraryManager struct {
+	mu        sync.RWMutex // guards Libraries against the cutovers of the upgrades
+	config    config.LibrariesConfig
+	sections  *config.Config           // of the named instances, see BindConfig
+	order     []string                 // names of the loaded libraries, in load order
+	loadedAt  map[string]time.Time     // by "<name>/<key>"
+	health    map[string]LibraryHealth // last health check, by "<name>/<key>"
 	Loaders   map[string]LibraryLoader
 	Libraries map[string]map[string]port.Library // Loaded libraries
+
+	parent   *LibraryManager // of a scope, resolving the libraries the scope does not hold
+	provided []any           // dependencies of the InjectableLoaders, see Provide
+
+	lazyMu       sync.Mutex // guards the manifests
+	manifests    map[string]*declaredLibrary
+	manifestArgs func(manifest LibraryManifest) ([]any, error) // set on start
 }
 
 func CreateLibraryManager(loaders map[string]LibraryLoader) *LibraryManager {
@@ -28,28 +63,374 @@ func CreateLibraryManager(loaders map[string]LibraryLoader) *LibraryManager {
 	return &LibraryManager{
 		Loaders:   loaders,
 		Libraries: make(map[string]map[string]port.Library),
+		loadedAt:  make(map[string]time.Time),
+		health:    make(map[string]LibraryHealth),
 	}
 }
 
+// Configure sets the init timeouts of app.libraries, before the libraries are loaded
+func (lm *LibraryManager) Configure(cfg config.LibrariesConfig) {
+	lm.config = cfg
+}
+
+// initTimeout returns the limit of the initialization of the library name, 0 for no limit
+func (lm *LibraryManager) initTimeout(name string) time.Duration {
+	if timeout, ok := lm.config.Timeouts[name]; ok {
+		return timeout
+	}
+	return lm.config.InitTimeout
+}
+
+// initLibrary runs init with a context cancelled after the init timeout of the library name.
+// An init ignoring the context is left running past the timeout, which fails with ErrTimeout,
+// and the library it returns later is closed.
+func (lm *LibraryManager) initLibrary(name string, init func(ctx context.Context) (port.Library, error)) (port.Library, error) {
+	init = recoverLibrary(name, withLifecycle(name, init))
+	timeout := lm.initTimeout(name)
+	if timeout <= 0 {
+		return init(context.Background())
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	type result struct {
+		library port.Library
+		err     error
+	}
+	done := make(chan result, 1)
+	go func() {
+		library, err := init(ctx)
+		done <- result{library, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.library, r.err
+	case <-ctx.Done():
+		go func() {
+			if r := <-done; r.err == nil && r.library != nil {
+				if err := closeLibrary(name, r.library); err != nil {
+					logger.Warn("Library initialized after its timeout not closed", "name", name, "error", err)
+				}
+			}
+		}()
+		return nil, fmt.Errorf("library '%s' not initialized within %s: %w", name, timeout, ErrTimeout)
+	}
+}
+
+// Phases of the lifecycle of a library reported by LifecycleError
+const (
+	PhaseInit         = "init" // a panic of the loader, the installation or the connection
+	PhasePreInstall   = "pre_install"
+	PhasePostConnect  = "post_connect"
+	PhasePause        = "pause"
+	PhaseDrain        = "drain"
+	PhasePreUninstall = "pre_uninstall"
+	PhaseDisconnect   = "disconnect"
+	PhaseUninstall    = "uninstall"
+)
+
+// LifecycleError is the failure of a library in a phase of its lifecycle. The failures of the
+// phases and of the libraries are joined with errors.Join, errors.As finds the first one.
+type LifecycleError struct {
+	Library string
+	Phase   string
+	Err     error
+}
+
+func (e *LifecycleError) Error() string {
+	return fmt.Sprintf("library '%s': %s: %v", e.Library, e.Phase, e.Err)
+}
+
+func (e *LifecycleError) Unwrap() error { return e.Err }
+
+// PanicError is a panic of a library recovered by the LibraryManager, reported in a
+// LifecycleError
+type PanicError struct {
+	Value any
+	Stack string
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// recoverLibrary turns a panic of init into a LifecycleError of the library name, so a library
+// cannot bring the application down while it loads
+func recoverLibrary(name string, init func(ctx context.Context) (port.Library, error)) func(ctx context.Context) (port.Library, error) {
+	return func(ctx context.Context) (library port.Library, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				library = nil
+				err = &LifecycleError{Library: name, Phase: PhaseInit, Err: &PanicError{Value: r, Stack: string(debug.Stack())}}
+			}
+		}()
+		return init(ctx)
+	}
+}
+
+// Optional reports whether the library name is in app.libraries.optional, by name or family
+func (lm *LibraryManager) Optional(name string) bool {
+	return slices.ContainsFunc(lm.config.Optional, func(optional string) bool { return matchLibrary(optional, name) })
+}
+
+// tolerate returns the failure err of the library name on start, or logs it and returns nil
+// when the library is optional, the application then starts without it
+func (lm *LibraryManager) tolerate(name string, optional bool, err error) error {
+	if err == nil || !(optional || lm.Optional(name)) {
+		return err
+	}
+	logger.Warn("Optional library not loaded", "name", name, "error", err)
+	return nil
+}
+
+// withLifecycle calls the PostConnect hook of the library created by init, the library is
+// closed when the hook fails. The failures of the phases are reported for the library name.
+func withLifecycle(name string, init func(ctx context.Context) (port.Library, error)) func(ctx context.Context) (port.Library, error) {
+	return func(ctx context.Context) (port.Library, error) {
+		library, err := init(ctx)
+		if err != nil {
+			var lifecycle *LifecycleError
+			if errors.As(err, &lifecycle) && lifecycle.Library == "" {
+				lifecycle.Library = name
+			}
+			return nil, err
+		}
+
+		if hook, ok := library.(port.PostConnector); ok {
+			if err := hook.PostConnect(ctx); err != nil {
+				if err := closeLibrary(name, library); err != nil {
+					logger.Warn("Library not closed after its PostConnect failed", "name", name, "error", err)
+				}
+				return nil, &LifecycleError{Library: name, Phase: PhasePostConnect, Err: err}
+			}
+		}
+		return library, nil
+	}
+}
+
+// InstallLibrary installs library with InstallCtx when it is a port.ContextInstaller, with
+// Install otherwise, after its PreInstall hook. Loaders implementing ContextLibraryLoader use
+// it in InitCtx.
+func InstallLibrary(ctx context.Context, library port.Library, args ...any) error {
+	if hook, ok := library.(port.PreInstaller); ok {
+		if err := hook.PreInstall(ctx, args...); err != nil {
+			return &LifecycleError{Phase: PhasePreInstall, Err: err}
+		}
+	}
+	if installer, ok := library.(port.ContextInstaller); ok {
+		return installer.InstallCtx(ctx, args...)
+	}
+	return library.Install(args...)
+}
+
+// ConnectLibrary connects library with ConnectCtx when it is a port.ContextConnector, with
+// Connect when it is a port.Connector, and does nothing otherwise
+func ConnectLibrary(ctx context.Context, library port.Library) error {
+	if connector, ok := library.(port.ContextConnector); ok {
+		return connector.ConnectCtx(ctx)
+	}
+	if connector, ok := library.(port.Connector); ok {
+		return connector.Connect()
+	}
+	return nil
+}
+
+// Destroy unloads the libraries in the reverse order of their loading, the dependents of a
+// library before it. It returns the failures joined, see LifecycleError.
 func (lm *LibraryManager) Destroy() error {
-	for name, libMap := range lm.Libraries {
-		for key, library := range libMap {
-			_, err := lm.unload(name, library, &libMap, key)
+	var errs []error
+	for _, name := range lm.unloadOrder() {
+		lm.mu.RLock()
+		libMap := lm.Libraries[name]
+		libraries := maps.Clone(libMap)
+		lm.mu.RUnlock()
+
+		for _, key := range slices.Sorted(maps.Keys(libraries)) {
+			_, err := lm.unload(name, libraries[key], &libMap, key)
 			if err != nil {
 				logger.Warn(err.Error())
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Quiesce stops the work of the loaded libraries before they are unloaded, in the order of
+// Destroy: the consumers and the other port.Pausable libraries are paused, then the
+// port.Drainer libraries finish their work in flight until ctx ends. It returns the failures
+// joined, see LifecycleError.
+func (lm *LibraryManager) Quiesce(ctx context.Context) error {
+	type loaded struct {
+		name    string
+		library port.Library
+	}
+	var libraries []loaded
+	lm.mu.RLock()
+	for _, name := range lm.unloadOrderLocked() {
+		for _, key := range slices.Sorted(maps.Keys(lm.Libraries[name])) {
+			libraries = append(libraries, loaded{name, lm.Libraries[name][key]})
+		}
+	}
+	lm.mu.RUnlock()
+
+	var errs []error
+	for _, l := range libraries {
+		if pausable, ok := l.library.(port.Pausable); ok {
+			if err := pausable.Pause(ctx); err != nil {
+				errs = append(errs, &LifecycleError{Library: l.name, Phase: PhasePause, Err: err})
+			}
+		}
+	}
+	for _, l := range libraries {
+		if drainer, ok := l.library.(port.Drainer); ok {
+			if err := drainer.Drain(ctx); err != nil {
+				errs = append(errs, &LifecycleError{Library: l.name, Phase: PhaseDrain, Err: err})
 			}
 		}
 	}
+	return errors.Join(errs...)
+}
+
+// dependsOn returns the dependencies declared by the loader of the library name
+func (lm *LibraryManager) dependsOn(name string) []string {
+	if loader, ok := lm.Loaders[name].(DependentLoader); ok {
+		return loader.DependsOn()
+	}
 	return nil
 }
 
+// matchLibrary reports whether the dependency dep names the library name, itself or its family
+func matchLibrary(dep string, name string) bool {
+	return name == dep || strings.HasPrefix(name, dep+":")
+}
+
+// InitOrder returns the names of the loaders sorted so that each one follows its dependencies,
+// it fails when the dependencies form a cycle
+func (lm *LibraryManager) InitOrder() ([]string, error) {
+	names := slices.Sorted(maps.Keys(lm.Loaders))
+	edges := make(map[string][]string, len(names))
+	for _, name := range names {
+		for _, dep := range lm.dependsOn(name) {
+			// a dependency without loader is only missing if the library is loaded
+			matches := slices.DeleteFunc(slices.Clone(names), func(other string) bool { return !matchLibrary(dep, other) })
+			edges[name] = append(edges[name], matches...)
+		}
+	}
+
+	const visiting, visited = 1, 2
+	state := make(map[string]int, len(names))
+	order := make([]string, 0, len(names))
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("library dependency cycle: %s", strings.Join(append(path, name), " -> "))
+		case visited:
+			return nil
+		}
+		state[name] = visiting
+		for _, dep := range edges[name] {
+			if err := visit(dep, append(path, name)); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+		order = append(order, name)
+		return nil
+	}
+	for _, name := range names {
+		if err := visit(name, nil); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// checkDependencies fails when a dependency of the library name is not loaded
+func (lm *LibraryManager) checkDependencies(name string) error {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+
+	for _, dep := range lm.dependsOn(name) {
+		if !lm.isLoaded(dep) {
+			return fmt.Errorf("library '%s' depends on '%s' which is not loaded", name, dep)
+		}
+	}
+	return nil
+}
+
+// isLoaded reports whether a library named by dep is loaded in lm or, for a scope, its parents,
+// lm.mu must be locked
+func (lm *LibraryManager) isLoaded(dep string) bool {
+	if slices.ContainsFunc(lm.order, func(loaded string) bool { return matchLibrary(dep, loaded) }) {
+		return true
+	}
+	if lm.parent == nil {
+		return false
+	}
+
+	lm.parent.mu.RLock()
+	defer lm.parent.mu.RUnlock()
+	return lm.parent.isLoaded(dep)
+}
+
+// loaded records the library name as loaded, lm.mu must be locked
+func (lm *LibraryManager) loaded(name string) {
+	if !slices.Contains(lm.order, name) {
+		lm.order = append(lm.order, name)
+	}
+}
+
+// unloadOrder returns the loaded libraries from the last loaded, each one before the libraries
+// it depends on
+func (lm *LibraryManager) unloadOrder() []string {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+	return lm.unloadOrderLocked()
+}
+
+// unloadOrderLocked is unloadOrder, lm.mu must be locked
+func (lm *LibraryManager) unloadOrderLocked() []string {
+	remaining := slices.Clone(lm.order)
+	for _, name := range slices.Sorted(maps.Keys(lm.Libraries)) {
+		if !slices.Contains(remaining, name) {
+			remaining = append(remaining, name)
+		}
+	}
+
+	order := make([]string, 0, len(remaining))
+	for len(remaining) > 0 {
+		for i := len(remaining) - 1; i >= 0; i-- {
+			name := remaining[i]
+			needed := slices.ContainsFunc(remaining, func(other string) bool {
+				return other != name && slices.ContainsFunc(lm.dependsOn(other), func(dep string) bool { return matchLibrary(dep, name) })
+			})
+			// i == 0 breaks a cycle
+			if !needed || i == 0 {
+				order = append(order, name)
+				remaining = slices.Delete(remaining, i, i+1)
+				break
+			}
+		}
+	}
+	return order
+}
+
 func (lm *LibraryManager) GetLoader(name string) (LibraryLoader, bool) {
 	loader, ok := lm.Loaders[name]
 	return loader, ok
 }
 
+// GetSingletonInstance returns the singleton of the library name, loading it on its first use
+// when it is declared by a lazy LibraryManifest
 func (lm *LibraryManager) GetSingletonInstance(name string) (port.Library, bool) {
-	return lm.GetLibrary(name, true, nil)
+	if library, ok := lm.GetLibrary(name, true, nil); ok {
+		return library, true
+	}
+	return lm.root().lazyLibrary(name)
 }
 
 func (lm *LibraryManager) GetInstance(name string, key string) (port.Library, bool) {
@@ -58,6 +439,9 @@ func (lm *LibraryManager) GetInstance(name string, key string) (port.Library, bo
 
 // GetLibrary retrieves a library instance
 func (lm *LibraryManager) GetLibrary(name string, singleton bool, key *string) (port.Library, bool) {
+	lm.mu.RLock()
+	defer lm.mu.RUnlock()
+
 	// Check if library type exists
 	libMap, ok := lm.Libraries[name]
 	if ok {
@@ -74,68 +458,104 @@ func (lm *LibraryManager) GetLibrary(name string, singleton bool, key *string) (
 		}
 	}
 
+	// A scope resolves the other libraries in its parent
+	if lm.parent != nil {
+		return lm.parent.GetLibrary(name, singleton, key)
+	}
 	return nil, false
 }
 
-func (lm *LibraryManager) LoadFromLoader(load LibraryLoader, name string, singleton bool, key *string, args ...any) (port.Library, error) {
-	// Check if library type exists
-	libMap, ok := lm.Libraries[name]
+// BindConfig gives the named instances their configuration from cfg, see instanceArgs
+func (lm *LibraryManager) BindConfig(cfg *config.Config) {
+	lm.sections = cfg
+}
+
+// instanceArgs gives the loader of the instance key of the library name its configuration,
+// declared in the instances of the section of the family of name (ex:
+// database.instances.analytics for "database:postgres"). It takes the place of the argument
+// of the type of the section, or follows args when there is none.
+func (lm *LibraryManager) instanceArgs(name string, key string, args []any) []any {
+	if lm.sections == nil {
+		return args
+	}
+	family, _, _ := strings.Cut(name, ":")
+	section, ok := lm.sections.InstanceSection(family, key)
 	if !ok {
-		library, err := load.Init(args...)
-		if err != nil {
-			return nil, err
+		return args
+	}
+
+	args = slices.Clone(args)
+	for i, arg := range args {
+		if reflect.TypeOf(arg) == reflect.TypeOf(section) {
+			args[i] = section
+			return args
 		}
+	}
+	return append(args, section)
+}
 
-		// Create library map for this type
-		libMap = make(map[string]port.Library)
+// initFromLoader initializes the library name with load, within its init timeout
+func (lm *LibraryManager) initFromLoader(load LibraryLoader, name string, args ...any) (port.Library, error) {
+	if loader, ok := load.(InjectableLoader); ok {
+		params := loader.Params()
+		if err := Inject(params, append(lm.dependencies(), args...)...); err != nil {
+			return nil, fmt.Errorf("library '%s': %w", name, err)
+		}
+		return lm.initLibrary(name, func(ctx context.Context) (port.Library, error) {
+			return loader.InitWith(ctx, params)
+		})
+	}
 
-		// Store instance
-		if singleton {
-			libMap["default"] = library
-		} else {
-			if key == nil {
-				d := "default"
-				key = &d
-			}
-			libMap[*key] = library
+	return lm.initLibrary(name, func(ctx context.Context) (port.Library, error) {
+		if loader, ok := load.(ContextLibraryLoader); ok {
+			return loader.InitCtx(ctx, args...)
 		}
+		return load.Init(args...)
+	})
+}
 
-		lm.Libraries[name] = libMap
-		// logger.Debug("LoadFromLoader: Map BARU untuk", "name", name)
-		return library, nil
+func (lm *LibraryManager) LoadFromLoader(load LibraryLoader, name string, singleton bool, key *string, args ...any) (port.Library, error) {
+	libKey := "default"
+	if !singleton && key != nil {
+		libKey = *key
 	}
 
-	if singleton {
-		// Check if instance exists
-		if ptr, ok := libMap["default"]; ok {
-			return ptr, nil
-		}
-	} else {
-		// Check if instance exists
-		if ptr, ok := libMap[*key]; ok {
-			return ptr, nil
-		}
+	// Check if instance exists
+	if library, ok := lm.GetLibrary(name, false, &libKey); ok {
+		return library, nil
 	}
 
-	library, err := load.Init(args...)
+	if libKey != "default" {
+		args = lm.instanceArgs(name, libKey, args)
+	}
+	library, err := lm.initFromLoader(load, name, args...)
 	if err != nil {
 		return nil, err
 	}
+	if err := lm.checkDependencies(name); err != nil {
+		closeLibrary(name, library)
+		return nil, err
+	}
 
 	// Store instance
-	if singleton {
-		lm.Libraries[name]["default"] = library
-		// logger.Debug("LoadFromLoader: Buat Instance BARU untuk", "name", name, "key", "default")
-	} else {
-		if key == nil {
-			d := "default"
-			key = &d
-		}
-		lm.Libraries[name][*key] = library
-		// logger.Debug("LoadFromLoader: Buat Instance BARU untuk", "name", name, "key", *key)
+	lm.store(name, libKey, library)
+	return library, nil
+}
+
+// store puts library under the key of the library name
+func (lm *LibraryManager) store(name string, key string, library port.Library) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+	if _, ok := lm.Libraries[name]; !ok {
+		lm.Libraries[name] = make(map[string]port.Library)
 	}
+	lm.Libraries[name][key] = library
+	lm.loaded(name)
+	lm.loadedAt[instanceID(name, key)] = time.Now()
+}
 
-	return library, nil
+func instanceID(name string, key string) string {
+	return name + "/" + key
 }
 
 func (lm *LibraryManager) LoadSingletonFromLoader(loader LibraryLoader, args ...any) (port.Library, error) {
@@ -165,6 +585,9 @@ func (lm *LibraryManager) UnloadInstance(libType reflect.Type, key string) (port
 // LoadLibrary creates or retrieves a library instance
 func (lm *LibraryManager) LoadLibrary(libType reflect.Type, singleton bool, key *string, args ...any) (port.Library, error) {
 	var zero port.Library
+	if libType == nil || libType.Kind() == reflect.Interface {
+		return zero, fmt.Errorf("library type %v is not concrete, load it by name", libType)
+	}
 
 	// Get the type name
 	if libType.Kind() == reflect.Ptr {
@@ -172,73 +595,54 @@ func (lm *LibraryManager) LoadLibrary(libType reflect.Type, singleton bool, key
 	}
 	name := libType.Name()
 
+	libKey := "default"
+	if !singleton && key != nil {
+		libKey = *key
+	}
+
 	// Check if library type exists
+	lm.mu.RLock()
 	libMap, ok := lm.Libraries[name]
+	existing, found := libMap[libKey]
+	lm.mu.RUnlock()
 	if !ok {
 		// Create new instance
 		lib := reflect.New(libType).Interface()
 		if library, ok := lib.(port.Library); ok {
-			err := library.Install(args...)
+			_, err := lm.initLibrary(name, func(ctx context.Context) (port.Library, error) {
+				if err := InstallLibrary(ctx, library, args...); err != nil {
+					return nil, err
+				}
+				return library, ConnectLibrary(ctx, library)
+			})
 			if err != nil {
 				return zero, err
 			}
 
-			if libConnector, ok2 := lib.(port.Connector); ok2 {
-				err = libConnector.Connect()
-				if err != nil {
-					return zero, err
-				}
-			}
-
-			// Create library map for this type
-			libMap = make(map[string]port.Library)
-			lm.Libraries[name] = libMap
-
 			// Store instance
-			if singleton {
-				libMap["default"] = library
-			} else {
-				if key == nil {
-					d := "default"
-					key = &d
-				}
-				libMap[*key] = library
-			}
+			lm.store(name, libKey, library)
 			return library, nil
 		}
 		return zero, fmt.Errorf("type %T does not implement Library interface", lib)
 	}
 
-	if singleton {
-		// Check if instance exists
-		if ptr, ok := libMap["default"]; ok {
-			return ptr, nil
-		}
-	} else {
-		// Check if instance exists
-		if ptr, ok := libMap[*key]; ok {
-			return ptr, nil
-		}
+	// Check if instance exists
+	if found {
+		return existing, nil
 	}
 
 	// Create new instance
 	lib := reflect.New(libType).Interface()
 	if library, ok := lib.(port.Library); ok {
-		err := library.Install(args...)
+		_, err := lm.initLibrary(name, func(ctx context.Context) (port.Library, error) {
+			return library, InstallLibrary(ctx, library, args...)
+		})
 		if err != nil {
 			return zero, err
 		}
 
 		// Store instance
-		if singleton {
-			libMap["default"] = library
-		} else {
-			if key == nil {
-				d := "default"
-				key = &d
-			}
-			libMap[*key] = library
-		}
+		lm.store(name, libKey, library)
 		return library, nil
 	}
 
@@ -247,6 +651,9 @@ func (lm *LibraryManager) LoadLibrary(libType reflect.Type, singleton bool, key
 
 func (lm *LibraryManager) UnloadLibrary(libType reflect.Type, singleton bool, key *string) (port.Library, error) {
 	var zero port.Library
+	if libType == nil || libType.Kind() == reflect.Interface {
+		return zero, fmt.Errorf("library type %v is not concrete, load it by name", libType)
+	}
 
 	// Get the type name
 	if libType.Kind() == reflect.Ptr {
@@ -254,12 +661,6 @@ func (lm *LibraryManager) UnloadLibrary(libType reflect.Type, singleton bool, ke
 	}
 	name := libType.Name()
 
-	// Check if library type exists
-	libMap, ok := lm.Libraries[name]
-	if !ok {
-		return zero, fmt.Errorf("library type %s not found", name)
-	}
-
 	// Determine the key to use
 	libKey := "default"
 	if !singleton {
@@ -269,9 +670,17 @@ func (lm *LibraryManager) UnloadLibrary(libType reflect.Type, singleton bool, ke
 		libKey = *key
 	}
 
-	// Check if instance exists
-	library, ok := libMap[libKey]
+	// Check if library type exists
+	lm.mu.RLock()
+	libMap, ok := lm.Libraries[name]
+	library, found := libMap[libKey]
+	lm.mu.RUnlock()
 	if !ok {
+		return zero, fmt.Errorf("library type %s not found", name)
+	}
+
+	// Check if instance exists
+	if !found {
 		return zero, fmt.Errorf("library instance with key %s not found", libKey)
 	}
 
@@ -279,68 +688,282 @@ func (lm *LibraryManager) UnloadLibrary(libType reflect.Type, singleton bool, ke
 }
 
 func (lm *LibraryManager) unload(name string, library port.Library, libMap *map[string]port.Library, libKey string) (port.Library, error) {
-	// If it's a connector, close the connection
-	if libConnector, ok := library.(port.Connector); ok {
-		err := libConnector.Disconnect()
-		if err != nil {
-			return nil, fmt.Errorf("failed to close connector: %v", err)
-		}
-	}
-
-	// Call destroy on the library
-	err := library.Uninstall()
-	if err != nil {
-		return nil, fmt.Errorf("failed to destroy library: %v", err)
+	if err := closeLibrary(name, library); err != nil {
+		return nil, err
 	}
 
 	// Remove the library from the map
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
 	delete(*libMap, libKey)
+	delete(lm.loadedAt, instanceID(name, libKey))
+	delete(lm.health, instanceID(name, libKey))
 
 	// If the libMap is empty, remove it entirely
 	if len(*libMap) == 0 {
 		delete(lm.Libraries, name)
+		lm.order = slices.DeleteFunc(lm.order, func(loaded string) bool { return loaded == name })
+	}
+
+	return library, nil
+}
+
+// UnloadNamedInstance closes the instance key of the library name and removes it
+func (lm *LibraryManager) UnloadNamedInstance(name string, key string) error {
+	lm.mu.RLock()
+	libMap, ok := lm.Libraries[name]
+	library, found := libMap[key]
+	lm.mu.RUnlock()
+	if !ok || !found {
+		return fmt.Errorf("library instance with key %s not found", key)
+	}
+
+	_, err := lm.unload(name, library, &libMap, key)
+	return err
+}
+
+// Reload replaces the instance key ("default" for the singleton) of the library name with a new
+// one initialized by its loader with args (ex: the rotated credentials of a database). The new
+// instance is put under key before the old one is disconnected and uninstalled, so the callers
+// getting the instance from the manager never miss it, and the old one keeps serving when the
+// new one fails to initialize.
+func (lm *LibraryManager) Reload(name string, key string, args ...any) (port.Library, error) {
+	load, ok := lm.GetLoader(name)
+	if !ok {
+		return nil, fmt.Errorf("LibraryLoader '%s' tidak ditemukan", name)
+	}
+	if _, ok := lm.GetInstance(name, key); !ok {
+		return nil, fmt.Errorf("library instance with key %s not found", key)
+	}
+
+	if key != "default" {
+		args = lm.instanceArgs(name, key, args)
+	}
+	library, err := lm.initFromLoader(load, name, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	lm.mu.Lock()
+	libMap, ok := lm.Libraries[name]
+	previous, found := libMap[key]
+	if ok && found {
+		libMap[key] = library
+		lm.loadedAt[instanceID(name, key)] = time.Now()
+		delete(lm.health, instanceID(name, key))
+	}
+	lm.mu.Unlock()
+	if !ok || !found {
+		// unloaded during the initialization
+		closeLibrary(name, library)
+		return nil, fmt.Errorf("library instance with key %s not found", key)
 	}
 
+	if err := closeLibrary(name, previous); err != nil {
+		logger.Warn("Previous library instance not closed", "name", name, "key", key, "error", err)
+	}
+	logger.Info("Library reloaded", "name", name, "key", key)
 	return library, nil
 }
 
+// closeLibrary finishes the work of the library name, then disconnects and uninstalls it
+func closeLibrary(name string, library port.Library) error {
+	var errs []error
+	if hook, ok := library.(port.PreUninstaller); ok {
+		if err := hook.PreUninstall(context.Background()); err != nil {
+			errs = append(errs, &LifecycleError{Library: name, Phase: PhasePreUninstall, Err: err})
+		}
+	}
+
+	// If it's a connector, close the connection
+	if libConnector, ok := library.(port.Connector); ok {
+		if err := libConnector.Disconnect(); err != nil {
+			return errors.Join(append(errs, &LifecycleError{Library: name, Phase: PhaseDisconnect, Err: err})...)
+		}
+	}
+
+	// Call destroy on the library
+	if err := library.Uninstall(); err != nil {
+		errs = append(errs, &LifecycleError{Library: name, Phase: PhaseUninstall, Err: err})
+	}
+	return errors.Join(errs...)
+}
+
+// SwapInstance moves the instance from of the library name to key and returns the instance it
+// replaces, which is left loaded for its caller to drain and close
+func (lm *LibraryManager) SwapInstance(name string, key string, from string) (port.Library, error) {
+	lm.mu.Lock()
+	defer lm.mu.Unlock()
+
+	libMap, ok := lm.Libraries[name]
+	if !ok {
+		return nil, fmt.Errorf("library type %s not found", name)
+	}
+	library, ok := libMap[from]
+	if !ok {
+		return nil, fmt.Errorf("library instance with key %s not found", from)
+	}
+
+	previous := libMap[key]
+	libMap[key] = library
+	delete(libMap, from)
+	lm.loadedAt[instanceID(name, key)] = lm.loadedAt[instanceID(name, from)]
+	delete(lm.loadedAt, instanceID(name, from))
+	delete(lm.health, instanceID(name, key))
+	delete(lm.health, instanceID(name, from))
+	return previous, nil
+}
+
 func GetLibraryLoader(name string) (LibraryLoader, bool) {
 	return Instance().LibraryManager.GetLoader(name)
 }
 
-// LoadLibrary is a convenience function that works with concrete types
+var (
+	libraryTypesMu sync.RWMutex
+	libraryTypes   = map[reflect.Type]string{}
+)
+
+// Register names the library T, concrete (ex: *mongo.MongoDatabase) or interface (ex:
+// port.IDatabase), for the typed helpers Load, LoadMulti, Unload and UnloadMulti. It is called
+// next to APP_LIBRARIES or in the init of the package of the library, a later call replaces the
+// name of T:
+//
+//	core.Register[*mongo.MongoDatabase]("database:mongodb")
+//	db, err := core.Load[*mongo.MongoDatabase](ctx, cfg.Database)
+func Register[T port.Library](name string) {
+	libraryTypesMu.Lock()
+	defer libraryTypesMu.Unlock()
+	libraryTypes[reflect.TypeFor[T]()] = name
+}
+
+// registeredName returns the name registered for the library T
+func registeredName[T port.Library]() (string, bool) {
+	libraryTypesMu.RLock()
+	defer libraryTypesMu.RUnlock()
+	name, ok := libraryTypes[reflect.TypeFor[T]()]
+	return name, ok
+}
+
+// libraryName returns the name of the library T: the first of args when it is a string naming
+// a loader, which is removed from args, or else the name registered for T. Unnamed concrete
+// types are loaded by reflection under the name of their type, false is returned for them.
+func libraryName[T port.Library](lm *LibraryManager, args []any) (string, []any, bool, error) {
+	if len(args) > 0 {
+		if name, ok := args[0].(string); ok {
+			if _, ok := lm.GetLoader(name); ok {
+				return name, args[1:], true, nil
+			}
+		}
+	}
+	if name, ok := registeredName[T](); ok {
+		return name, args, true, nil
+	}
+
+	libType := reflect.TypeFor[T]()
+	if libType.Kind() == reflect.Interface {
+		return "", nil, false, fmt.Errorf("library %s is not registered, call core.Register or give the name of its loader", libType)
+	}
+	return "", args, false, nil
+}
+
+// typedLibrary returns library as T, with an error instead of a panic when it is not one
+func typedLibrary[T port.Library](name string, library port.Library) (T, error) {
+	typed, ok := library.(T)
+	if !ok {
+		var zero T
+		return zero, fmt.Errorf("library '%s' is %T, not %s", name, library, reflect.TypeFor[T]())
+	}
+	return typed, nil
+}
+
+// LoadLibrary loads the library T, or returns it when it is loaded, see Load and LoadMulti
 func LoadLibrary[T port.Library](singleton bool, key *string, args ...any) (T, error) {
 	var zero T
-	libType := reflect.TypeOf(zero)
-	lib, err := Instance().LibraryManager.LoadLibrary(libType, singleton, key, args...)
+	lm := Instance().LibraryManager
+	name, args, named, err := libraryName[T](lm, args)
 	if err != nil {
 		return zero, err
 	}
-	return lib.(T), nil
+
+	if !named {
+		lib, err := lm.LoadLibrary(reflect.TypeFor[T](), singleton, key, args...)
+		if err != nil {
+			return zero, err
+		}
+		return typedLibrary[T](reflect.TypeFor[T]().String(), lib)
+	}
+
+	libKey := "default"
+	if !singleton && key != nil {
+		libKey = *key
+	}
+	lib, ok := lm.GetLibrary(name, false, &libKey)
+	if !ok {
+		loader, found := lm.GetLoader(name)
+		if !found {
+			return zero, fmt.Errorf("LibraryLoader '%s' tidak ditemukan", name)
+		}
+		if lib, err = lm.LoadFromLoader(loader, name, singleton, key, args...); err != nil {
+			return zero, err
+		}
+	}
+	return typedLibrary[T](name, lib)
 }
 
+// Load returns the singleton of the library T, loaded with args on first use. The library is
+// named by the first of args when it is the name of a loader, by Register otherwise:
+//
+//	db, err := core.Load[port.IDatabase]("database:mongodb", ctx, cfg.Database)
 func Load[T port.Library](args ...any) (T, error) {
 	return LoadLibrary[T](true, nil, args...)
 }
 
+// LoadMulti returns the instance key of the library T, loaded with args on first use
 func LoadMulti[T port.Library](key string, args ...any) (T, error) {
 	return LoadLibrary[T](false, &key, args...)
 }
 
+// UnloadLibrary closes the library T and removes it, see Unload and UnloadMulti
 func UnloadLibrary[T port.Library](singleton bool, key *string, args ...any) (T, error) {
 	var zero T
-	libType := reflect.TypeOf(zero)
-	lib, err := Instance().LibraryManager.UnloadLibrary(libType, singleton, key)
+	lm := Instance().LibraryManager
+	name, _, named, err := libraryName[T](lm, args)
+	if err != nil {
+		return zero, err
+	}
+
+	if !named {
+		lib, err := lm.UnloadLibrary(reflect.TypeFor[T](), singleton, key)
+		if err != nil {
+			return zero, err
+		}
+		return typedLibrary[T](reflect.TypeFor[T]().String(), lib)
+	}
+
+	libKey := "default"
+	if !singleton {
+		if key == nil {
+			return zero, fmt.Errorf("key is required for non-singleton libraries")
+		}
+		libKey = *key
+	}
+	lib, ok := lm.GetLibrary(name, false, &libKey)
+	if !ok {
+		return zero, fmt.Errorf("library instance with key %s not found", libKey)
+	}
+	typed, err := typedLibrary[T](name, lib)
 	if err != nil {
 		return zero, err
 	}
-	return lib.(T), nil
+	return typed, lm.UnloadNamedInstance(name, libKey)
 }
 
+// Unload closes the singleton of the library T, named like for Load
 func Unload[T port.Library](args ...any) (T, error) {
 	return UnloadLibrary[T](true, nil, args...)
 }
 
+// UnloadMulti closes the instance key of the library T
 func UnloadMulti[T port.Library](key string, args ...any) (T, error) {
 	return UnloadLibrary[T](false, &key, args...)
 }
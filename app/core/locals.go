@@ -0,0 +1,89 @@
+package core
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// RequestID is the ID of the request in its Locals, see LocalsMiddleware
+type RequestID string
+
+// localKey is the key of the Locals of type T, no string key collides with it
+type localKey[T any] struct{}
+
+// SetLocal stores v in the Locals of the request under its type T. A value of an interface
+// type is stored under the interface, given as T (ex: SetLocal[auth.IUserAuthInfo](c, user)).
+func SetLocal[T any](c *fiber.Ctx, v T) {
+	c.Locals(localKey[T]{}, v)
+}
+
+// GetLocal returns the value of type T stored by SetLocal, the zero value when there is none
+func GetLocal[T any](c *fiber.Ctx) T {
+	v, _ := c.Locals(localKey[T]{}).(T)
+	return v
+}
+
+// LookupLocal returns the value of type T stored by SetLocal and whether there is one
+func LookupLocal[T any](c *fiber.Ctx) (T, bool) {
+	v, ok := c.Locals(localKey[T]{}).(T)
+	return v, ok
+}
+
+// LocalsMiddleware stores in the Locals of each request, for GetLocal: the *AppContext, the
+// RequestID and a *logger.Logger adding the request ID and the trace of the request to its
+// lines, completed by LoggerFrom
+func (a *AppContext) LocalsMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		requestID := helper.RequestID(ctx)
+		log := logger.WithContext(ctx)
+		if requestID == "" {
+			requestID = c.Get(fiber.HeaderXRequestID)
+			if requestID != "" {
+				log = log.With("request_id", requestID)
+			}
+		}
+
+		SetLocal(c, a)
+		SetLocal(c, RequestID(requestID))
+		SetLocal(c, log)
+		return c.Next()
+	}
+}
+
+// userLocals stores the user authenticated by the protected routes in the Locals of the
+// request, as auth.IUserAuthInfo
+func userLocals(c *fiber.Ctx) error {
+	if user := auth.CurrentUser(c); user != nil {
+		SetLocal(c, user)
+	}
+	return c.Next()
+}
+
+// LoggerFrom returns the logger of the request, adding to each line its request ID, its trace,
+// its route, the ID of the authenticated user and its tenant. The fields are the ones known when
+// it is called: from a handler, its route and the user of the protected routes.
+//
+//	core.LoggerFrom(c).Info("Order paid", "order_id", order.ID)
+func LoggerFrom(c *fiber.Ctx) *logger.Logger {
+	log := GetLocal[*logger.Logger](c)
+	if log == nil {
+		log = logger.WithContext(c.UserContext())
+	}
+
+	args := []any{"route", c.Route().Path}
+	if userID := auth.CurrentUserID(c); userID != "" {
+		args = append(args, "user_id", userID)
+	}
+	if tenant := helper.CurrentTenant(c); tenant != nil {
+		args = append(args, "tenant", tenant.ID)
+	}
+	return log.With(args...)
+}
+
+// RequestLogger returns the logger of the request, see LoggerFrom
+func RequestLogger(c *fiber.Ctx) *logger.Logger {
+	return LoggerFrom(c)
+}
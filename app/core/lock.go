@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+)
+
+// LocalLocker is an in-process port.ILocker. It only guarantees mutual exclusion
+// inside a single instance; register a shared locker library for multi instance deployments.
+type LocalLocker struct {
+	mu    sync.Mutex
+	clock helper.Clock
+	locks map[string]time.Time // key -> expiration
+}
+
+// NewLocalLocker creates an in-process locker using the given clock for expirations
+func NewLocalLocker(clock helper.Clock) *LocalLocker {
+	return &LocalLocker{
+		clock: clock,
+		locks: make(map[string]time.Time),
+	}
+}
+
+func (l *LocalLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	if expire, ok := l.locks[key]; ok && now.Before(expire) {
+		return false, nil
+	}
+
+	// drop expired keys, callers like the scheduler never unlock them
+	for k, expire := range l.locks {
+		if !now.Before(expire) {
+			delete(l.locks, k)
+		}
+	}
+
+	l.locks[key] = now.Add(ttl)
+	return true, nil
+}
+
+func (l *LocalLocker) Unlock(ctx context.Context, key string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.locks, key)
+	return nil
+}
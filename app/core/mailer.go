@@ -0,0 +1,134 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// MailJobType is the job queue type used by Mailer.SendAsync
+const MailJobType = "mail.send"
+
+// Mailer renders templates, fills the default sender and hands messages to the
+// configured port.IMailer, directly or through the job queue
+type Mailer struct {
+	config   config.MailConfig
+	driver   port.IMailer
+	renderer port.ITemplateRenderer
+	queue    *JobQueue
+}
+
+// NewMailer creates a mailer without driver and registers its delivery job on queue
+func NewMailer(cfg config.MailConfig, queue *JobQueue) *Mailer {
+	m := &Mailer{
+		config: cfg,
+		queue:  queue,
+	}
+
+	HandleJob(queue, MailJobType, func(ctx context.Context, message port.MailMessage) error {
+		return m.deliver(ctx, &message)
+	})
+
+	return m
+}
+
+// SetDriver sets the library delivering the messages
+func (m *Mailer) SetDriver(driver port.IMailer) {
+	m.driver = driver
+}
+
+// SetRenderer sets the template engine used for MailMessage.Template
+func (m *Mailer) SetRenderer(renderer port.ITemplateRenderer) {
+	m.renderer = renderer
+}
+
+// Send renders and delivers message immediately
+func (m *Mailer) Send(ctx context.Context, message *port.MailMessage) error {
+	if err := m.prepare(message); err != nil {
+		return err
+	}
+	return m.deliver(ctx, message)
+}
+
+// SendBatch renders and delivers several messages, drivers may reuse a single connection
+func (m *Mailer) SendBatch(ctx context.Context, messages []*port.MailMessage) error {
+	if m.driver == nil {
+		return fmt.Errorf("mailer is not configured")
+	}
+
+	for _, message := range messages {
+		if err := m.prepare(message); err != nil {
+			return err
+		}
+	}
+	return m.driver.SendBatch(ctx, messages)
+}
+
+// SendAsync renders message now and queues its delivery, failures are retried by the job queue.
+// It returns the job ID.
+func (m *Mailer) SendAsync(ctx context.Context, message *port.MailMessage, opts ...EnqueueOptions) (string, error) {
+	if err := m.prepare(message); err != nil {
+		return "", err
+	}
+
+	opt := EnqueueOptions{Queue: m.config.Queue}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	return m.queue.Enqueue(ctx, MailJobType, message, opt)
+}
+
+func (m *Mailer) deliver(ctx context.Context, message *port.MailMessage) error {
+	if m.driver == nil {
+		return fmt.Errorf("mailer is not configured")
+	}
+	return m.driver.Send(ctx, message)
+}
+
+// prepare fills the sender and renders the template, so queued messages carry plain content
+func (m *Mailer) prepare(message *port.MailMessage) error {
+	if message.From == "" {
+		message.From = m.config.From
+	}
+
+	if message.Template == "" {
+		return nil
+	}
+
+	if m.renderer == nil {
+		return fmt.Errorf("mail template '%s' cannot be rendered, no template renderer", message.Template)
+	}
+
+	rendered := false
+	for _, part := range []struct {
+		ext    string
+		target *string
+	}{
+		{".html", &message.HTML},
+		{".txt", &message.Text},
+	} {
+		name := message.Template + part.ext
+		if *part.target != "" || !m.renderer.Has(name) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := m.renderer.Render(&buf, name, message.Data); err != nil {
+			return fmt.Errorf("mail template '%s': %v", name, err)
+		}
+		*part.target = buf.String()
+		rendered = true
+	}
+
+	if !rendered && message.HTML == "" && message.Text == "" {
+		return fmt.Errorf("mail template '%s' not found", message.Template)
+	}
+
+	message.Template = ""
+	message.Data = nil
+	return nil
+}
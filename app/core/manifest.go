@@ -0,0 +1,159 @@
+package core
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// LibraryManifest declares a singleton library loaded from the configuration instead of by
+// the code of AppContext.Start or of a module. The manifests are declared in deps next to
+// APP_LIBRARIES and given to the LibraryManager before the application starts:
+//
+//	var APP_MANIFESTS = []core.LibraryManifest{
+//		{Name: "kafka:producer", Config: "kafka", Lazy: true},
+//		{Name: "geoip", Loader: "geoip:maxmind", Config: "app.geoip"},
+//	}
+//
+//	app.LibraryManager.Declare(deps.APP_MANIFESTS...)
+type LibraryManifest struct {
+	Name   string // name of the library, given to GetSingletonInstance
+	Loader string // loader of APP_LIBRARIES, Name by default
+	Config string // section of the configuration given to the loader (ex: "kafka", "app.geoip")
+	Lazy   bool   // loaded on the first GetSingletonInstance instead of on start
+
+	// Optional logs the failure of the library on start instead of aborting it, like
+	// app.libraries.optional
+	Optional bool
+
+	// Args returns the arguments of the loader, by default the AppContext and the section
+	Args func(ctx *AppContext, section any) []any
+}
+
+// Declare adds manifests to the libraries loaded on start, or on first use when lazy
+func (lm *LibraryManager) Declare(manifests ...LibraryManifest) error {
+	lm.lazyMu.Lock()
+	defer lm.lazyMu.Unlock()
+
+	if lm.manifests == nil {
+		lm.manifests = make(map[string]*declaredLibrary)
+	}
+	for _, manifest := range manifests {
+		if manifest.Name == "" {
+			return fmt.Errorf("name of the library manifest is required")
+		}
+		if _, ok := lm.manifests[manifest.Name]; ok {
+			return fmt.Errorf("library '%s' is already declared", manifest.Name)
+		}
+		if manifest.Loader == "" {
+			manifest.Loader = manifest.Name
+		}
+		lm.manifests[manifest.Name] = &declaredLibrary{manifest: manifest}
+	}
+	return nil
+}
+
+// declaredLibrary is a manifest and the lock of its loading
+type declaredLibrary struct {
+	mu       sync.Mutex
+	manifest LibraryManifest
+}
+
+// startManifests checks the loaders and the sections of the manifests and loads the libraries
+// which are not lazy, the lazy ones are loaded with the arguments of args on their first use
+func (lm *LibraryManager) startManifests(args func(manifest LibraryManifest) ([]any, error)) error {
+	lm.lazyMu.Lock()
+	lm.manifestArgs = args
+	declared := maps.Clone(lm.manifests)
+	lm.lazyMu.Unlock()
+
+	for _, name := range slices.Sorted(maps.Keys(declared)) {
+		manifest := declared[name].manifest
+		if _, ok := lm.GetLoader(manifest.Loader); !ok {
+			return fmt.Errorf("LibraryLoader '%s' tidak ditemukan", manifest.Loader)
+		}
+		if _, err := args(manifest); err != nil {
+			return err
+		}
+		if manifest.Lazy {
+			continue
+		}
+
+		if _, err := lm.materialize(declared[name]); err != nil {
+			if err := lm.tolerate(name, manifest.Optional, err); err != nil {
+				return err
+			}
+			continue
+		}
+		logger.Info("Library loaded", "name", name)
+	}
+	return nil
+}
+
+// lazyLibrary loads the library name of a lazy manifest, false when name is not declared lazy
+// or the application is not started
+func (lm *LibraryManager) lazyLibrary(name string) (port.Library, bool) {
+	lm.lazyMu.Lock()
+	declared, ok := lm.manifests[name]
+	started := lm.manifestArgs != nil
+	lm.lazyMu.Unlock()
+	if !ok || !declared.manifest.Lazy || !started {
+		return nil, false
+	}
+
+	library, err := lm.materialize(declared)
+	if err != nil {
+		logger.Error("Lazy library not loaded", "name", name, "error", err)
+		return nil, false
+	}
+	logger.Info("Lazy library loaded", "name", name)
+	return library, true
+}
+
+// materialize loads the library of a manifest once, the concurrent first uses wait for it
+func (lm *LibraryManager) materialize(declared *declaredLibrary) (port.Library, error) {
+	declared.mu.Lock()
+	defer declared.mu.Unlock()
+
+	manifest := declared.manifest
+	if library, ok := lm.GetLibrary(manifest.Name, true, nil); ok {
+		return library, nil
+	}
+
+	loader, ok := lm.GetLoader(manifest.Loader)
+	if !ok {
+		return nil, fmt.Errorf("LibraryLoader '%s' tidak ditemukan", manifest.Loader)
+	}
+	lm.lazyMu.Lock()
+	manifestArgs := lm.manifestArgs
+	lm.lazyMu.Unlock()
+	args, err := manifestArgs(manifest)
+	if err != nil {
+		return nil, err
+	}
+	return lm.LoadFromLoader(loader, manifest.Name, true, nil, args...)
+}
+
+// manifestArgs returns the arguments of the loader of manifest, see LibraryManifest.Args
+func (a *AppContext) manifestArgs(manifest LibraryManifest) ([]any, error) {
+	var section any
+	if manifest.Config != "" {
+		var ok bool
+		section, ok = a.Config.Section(manifest.Config)
+		if !ok {
+			return nil, fmt.Errorf("library '%s': configuration section '%s' not found", manifest.Name, manifest.Config)
+		}
+	}
+
+	if manifest.Args != nil {
+		return manifest.Args(a, section), nil
+	}
+	if section == nil {
+		return []any{a}, nil
+	}
+	return []any{a, section}, nil
+}
@@ -0,0 +1,195 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/mask"
+)
+
+// MaskingDefault names the default policy of Masking, used for the data without tenant and the
+// tenants without their own policy
+const MaskingDefault = "*"
+
+// Masking hides the personal data of the logs, the audit lines and the exports with the policies
+// of app.masking. The exports and the audit lines use the policy of the tenant of the request,
+// the logs mix the tenants and mask what any policy masks. The policies are changed at runtime
+// with SetPolicy.
+type Masking struct {
+	mu       sync.RWMutex
+	configs  map[string]config.MaskingPolicyConfig // by tenant, MaskingDefault for the default policy
+	policies map[string]*mask.Policy
+	logs     *mask.Policy
+}
+
+func NewMasking(cfg config.MaskingConfig) *Masking {
+	configs := map[string]config.MaskingPolicyConfig{MaskingDefault: cfg.Default}
+	maps.Copy(configs, cfg.Tenants)
+	return &Masking{
+		configs:  configs,
+		policies: make(map[string]*mask.Policy),
+	}
+}
+
+// Load compiles the policies of the configuration and masks the logs with them
+func (m *Masking) Load() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for tenant, cfg := range m.configs {
+		policy, err := mask.Compile(cfg)
+		if err != nil {
+			return fmt.Errorf("app.masking policy '%s': %v", tenant, err)
+		}
+		m.policies[tenant] = policy
+	}
+	m.mergeLogs()
+
+	logger.SetMasker(maskingLogs{m})
+	return nil
+}
+
+// mergeLogs rebuilds the policy of the logs, m.mu must be locked
+func (m *Masking) mergeLogs() {
+	policies := make([]*mask.Policy, 0, len(m.policies))
+	for _, policy := range m.policies {
+		policies = append(policies, policy)
+	}
+	m.logs = mask.Merge(policies...)
+}
+
+// SetPolicy replaces the policy of tenant (MaskingDefault for the default one) on this instance
+func (m *Masking) SetPolicy(tenant string, cfg config.MaskingPolicyConfig) error {
+	policy, err := mask.Compile(cfg)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.configs[tenant] = cfg
+	m.policies[tenant] = policy
+	m.mergeLogs()
+	logger.Info("Masking policy changed", "tenant", tenant)
+	return nil
+}
+
+// DeletePolicy removes the policy of tenant, the default policy applies to it again
+func (m *Masking) DeletePolicy(tenant string) error {
+	if tenant == MaskingDefault {
+		return fmt.Errorf("the default masking policy cannot be deleted")
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.configs[tenant]; !ok {
+		return fmt.Errorf("masking policy '%s' not found", tenant)
+	}
+	delete(m.configs, tenant)
+	delete(m.policies, tenant)
+	m.mergeLogs()
+	logger.Info("Masking policy deleted", "tenant", tenant)
+	return nil
+}
+
+// Policies returns the policies by tenant, MaskingDefault for the default one
+func (m *Masking) Policies() map[string]config.MaskingPolicyConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return maps.Clone(m.configs)
+}
+
+// Policy returns the policy of the tenant of ctx, or the default one. It is nil, and masks
+// nothing, until Load.
+func (m *Masking) Policy(ctx context.Context) *mask.Policy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if tenant := helper.Tenant(ctx); tenant != nil {
+		if policy, ok := m.policies[tenant.ID]; ok {
+			return policy
+		}
+	}
+	return m.policies[MaskingDefault]
+}
+
+// Merged returns the policy masking what any policy masks, the one of the logs. It is nil until
+// Load.
+func (m *Masking) Merged() *mask.Policy {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.logs
+}
+
+// Value returns a copy of v masked with the policy of the tenant of ctx (see mask.Policy.Value)
+func (m *Masking) Value(ctx context.Context, v any) any {
+	return m.Policy(ctx).Value(v)
+}
+
+// Audit logs an audit line of action with its key-value pairs masked by the policy of the
+// tenant of ctx
+func (m *Masking) Audit(ctx context.Context, action string, args ...any) {
+	args = m.Policy(ctx).Attrs(args)
+	if tenant := helper.Tenant(ctx); tenant != nil {
+		args = append(args, "tenant", tenant.ID)
+	}
+	logger.Info("Audit: "+action, append(args, "request_id", helper.RequestID(ctx))...)
+}
+
+// RegisterAdminRoutes serves the policies on router: the list, the change of the policy of a
+// tenant on this instance (PUT with the fields of app.masking.default) and its removal
+func (m *Masking) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/masking", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(m.Policies()))
+	})
+
+	router.Put("/masking/:tenant", func(c *fiber.Ctx) error {
+		var body config.MaskingPolicyConfig
+		if err := c.BodyParser(&body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, "Invalid masking policy")
+		}
+		tenant := c.Params("tenant")
+		if err := m.SetPolicy(tenant, body); err != nil {
+			return fiber.NewError(fiber.StatusBadRequest, err.Error())
+		}
+		return out.Send(c, out.SuccessData(m.Policies()[tenant]))
+	})
+
+	router.Delete("/masking/:tenant", func(c *fiber.Ctx) error {
+		tenant := c.Params("tenant")
+		if tenant == MaskingDefault {
+			return fiber.NewError(fiber.StatusBadRequest, "The default masking policy cannot be deleted")
+		}
+		if err := m.DeletePolicy(tenant); err != nil {
+			return fiber.NewError(fiber.StatusNotFound, "Masking policy not found")
+		}
+		return out.Send(c, out.SuccessMessage("Masking policy deleted"))
+	})
+}
+
+// maskingPolicy returns the policy of the tenant of ctx, nil when app.masking is disabled
+func maskingPolicy(ctx context.Context) *mask.Policy {
+	app := Instance()
+	if app == nil || !app.Context.Config.App.Masking.Enabled {
+		return nil
+	}
+	return app.Context.Masking.Policy(ctx)
+}
+
+// maskingLogs masks the logs with every policy
+type maskingLogs struct {
+	m *Masking
+}
+
+func (l maskingLogs) MaskMessage(msg string) string { return l.m.Merged().String(msg) }
+func (l maskingLogs) MaskAttrs(args []any) []any    { return l.m.Merged().Attrs(args) }
+func (l maskingLogs) MaskValue(v any) any           { return l.m.Merged().Value(v) }
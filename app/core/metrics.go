@@ -0,0 +1,68 @@
+package core
+
+import (
+	"maps"
+	"slices"
+
+	"github.com/webcore-go/webcore/infra/metrics"
+	"github.com/webcore-go/webcore/infra/middleware"
+)
+
+// setupMetrics records the requests and collects the loaded libraries on metrics.Default,
+// served on app.metrics.path. The returned function unregisters them once the application
+// stops.
+func (a *App) setupMetrics() func() {
+	http := metrics.NewHTTP(a.Context.Config.App.Metrics.Buckets)
+	metrics.MustRegister(http, a.LibraryManager)
+	removeObserver := middleware.ObserveMetrics(func(m middleware.RequestMetric) {
+		http.Observe(m.Method, m.Route, m.Status, m.Latency)
+	})
+
+	return func() {
+		removeObserver()
+		metrics.Unregister(http)
+		metrics.Unregister(a.LibraryManager)
+	}
+}
+
+// Collect returns the metrics of the loaded libraries implementing metrics.Collector, with
+// the label library of their name and, for the instances by key, library_key of their key
+func (lm *LibraryManager) Collect() []metrics.Family {
+	type collector struct {
+		name      string
+		key       string
+		collector metrics.Collector
+	}
+	var collectors []collector
+	lm.mu.RLock()
+	for _, name := range slices.Sorted(maps.Keys(lm.Libraries)) {
+		for _, key := range slices.Sorted(maps.Keys(lm.Libraries[name])) {
+			if c, ok := lm.Libraries[name][key].(metrics.Collector); ok {
+				collectors = append(collectors, collector{name, key, c})
+			}
+		}
+	}
+	lm.mu.RUnlock()
+
+	var families []metrics.Family
+	for _, c := range collectors {
+		labels := []metrics.Label{{Name: "library", Value: c.name}}
+		if c.key != "" {
+			labels = append(labels, metrics.Label{Name: "library_key", Value: c.key})
+		}
+		for _, family := range c.collector.Collect() {
+			for i, m := range family.Metrics {
+				m.Labels = slices.Clip(m.Labels)
+				for _, label := range labels {
+					// a label of the library itself is kept
+					if !slices.ContainsFunc(m.Labels, func(l metrics.Label) bool { return l.Name == label.Name }) {
+						m.Labels = append(m.Labels, label)
+					}
+				}
+				family.Metrics[i] = m
+			}
+			families = append(families, family)
+		}
+	}
+	return families
+}
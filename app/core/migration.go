@@ -0,0 +1,369 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// MigrationTable is the table recording the applied migrations, by default
+const MigrationTable = "schema_migrations"
+
+// Migration is a pair of files <version>_<name>.up.sql and <version>_<name>.down.sql of the
+// migrations directory, the versions are numbers (ex: 20240131120000, the UTC time of creation)
+type Migration struct {
+	Version string
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationRecord is a row of the migration table
+type MigrationRecord struct {
+	Service   string    `json:"service" db:"service"`
+	Version   string    `json:"version" db:"version"`
+	Name      string    `json:"name" db:"name"`
+	Baseline  bool      `json:"baseline" db:"baseline"` // recorded by baseline, never executed
+	AppliedAt time.Time `json:"applied_at" db:"applied_at"`
+}
+
+// MigrationStatus is a migration of the directory or of the table
+type MigrationStatus struct {
+	Version   string
+	Name      string
+	Applied   bool
+	Baseline  bool
+	AppliedAt time.Time
+	Missing   bool // applied but its files are not in the directory anymore
+}
+
+const migrationTableSQL = `CREATE TABLE IF NOT EXISTS %s (
+	service VARCHAR(255) NOT NULL,
+	version VARCHAR(64) NOT NULL,
+	name VARCHAR(255) NOT NULL,
+	baseline BOOLEAN NOT NULL DEFAULT FALSE,
+	applied_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (service, version)
+)`
+
+var migrationFile = regexp.MustCompile(`^([0-9]+)_([a-zA-Z0-9_-]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the migrations of dir ordered by version, the other files are ignored
+func LoadMigrations(dir string) ([]Migration, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[string]*Migration{}
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if entry.IsDir() || match == nil {
+			continue
+		}
+
+		version, name, direction := match[1], match[2], match[3]
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration %s is used by %s and %s", version, m.Name, name)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return compareVersion(migrations[i].Version, migrations[j].Version) < 0
+	})
+	return migrations, nil
+}
+
+// compareVersion orders the versions as numbers of any length
+func compareVersion(a, b string) int {
+	a, b = strings.TrimLeft(a, "0"), strings.TrimLeft(b, "0")
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	return strings.Compare(a, b)
+}
+
+// Migrator applies the migrations of a directory through port.IDatabaseExec and records them in
+// the migration table, per service. With DryRun the statements are written to Out instead.
+type Migrator struct {
+	Database   port.IDatabase
+	Migrations []Migration
+	Service    string
+	Table      string // MigrationTable when empty
+	DryRun     bool
+	Out        io.Writer // os.Stdout when nil
+}
+
+func (m *Migrator) table() string {
+	if m.Table == "" {
+		return MigrationTable
+	}
+	return m.Table
+}
+
+func (m *Migrator) out() io.Writer {
+	if m.Out == nil {
+		return os.Stdout
+	}
+	return m.Out
+}
+
+// applied returns the records of the service by version, the migration table is created when
+// it is missing (also on dry runs, nothing else would be read)
+func (m *Migrator) applied(ctx context.Context) (map[string]MigrationRecord, error) {
+	if db, ok := m.Database.(port.IDatabaseExec); ok {
+		if err := db.Exec(ctx, fmt.Sprintf(migrationTableSQL, m.table())); err != nil {
+			return nil, fmt.Errorf("create %s: %v", m.table(), err)
+		}
+	}
+
+	var records []MigrationRecord
+	filter := []port.DbExpression{{Expr: "service", Op: "=", Args: []any{m.Service}}}
+	if err := m.Database.Find(ctx, &records, m.table(), nil, filter, nil, 0, 0); err != nil {
+		return nil, fmt.Errorf("read %s: %v", m.table(), err)
+	}
+
+	applied := make(map[string]MigrationRecord, len(records))
+	for _, record := range records {
+		applied[record.Version] = record
+	}
+	return applied, nil
+}
+
+// Status returns the migrations of the directory and the applied ones missing from it, ordered
+// by version
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.Migrations))
+	for _, migration := range m.Migrations {
+		record, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Version:   migration.Version,
+			Name:      migration.Name,
+			Applied:   ok,
+			Baseline:  record.Baseline,
+			AppliedAt: record.AppliedAt,
+		})
+		delete(applied, migration.Version)
+	}
+	for _, record := range applied {
+		statuses = append(statuses, MigrationStatus{
+			Version:   record.Version,
+			Name:      record.Name,
+			Applied:   true,
+			Baseline:  record.Baseline,
+			AppliedAt: record.AppliedAt,
+			Missing:   true,
+		})
+	}
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return compareVersion(statuses[i].Version, statuses[j].Version) < 0
+	})
+	return statuses, nil
+}
+
+// Up applies the pending migrations up to target included, all of them when target is empty,
+// and returns the number of migrations applied
+func (m *Migrator) Up(ctx context.Context, target string) (int, error) {
+	if target != "" && m.find(target) == nil {
+		return 0, fmt.Errorf("migration %s not found", target)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if target != "" && compareVersion(migration.Version, target) > 0 {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		if err := m.exec(ctx, "up", migration, migration.Up); err != nil {
+			return count, err
+		}
+		if err := m.record(ctx, migration, false); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the last steps applied migrations and returns the number of migrations rolled
+// back, it stops at a baseline since its schema was not created by the migrations
+func (m *Migrator) Down(ctx context.Context, steps int) (int, error) {
+	if steps <= 0 {
+		return 0, fmt.Errorf("steps must be positive")
+	}
+
+	statuses, err := m.Status(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(statuses) - 1; i >= 0 && count < steps; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+		if status.Missing {
+			return count, fmt.Errorf("migration %s_%s is applied but its files are missing", status.Version, status.Name)
+		}
+		if status.Baseline {
+			return count, fmt.Errorf("migration %s_%s is a baseline, it cannot be rolled back", status.Version, status.Name)
+		}
+
+		migration := m.find(status.Version)
+		if migration.Down == "" {
+			return count, fmt.Errorf("migration %s_%s has no down file", migration.Version, migration.Name)
+		}
+		if err := m.exec(ctx, "down", *migration, migration.Down); err != nil {
+			return count, err
+		}
+		if err := m.unrecord(ctx, *migration); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+// Baseline records the migrations up to version included as applied without executing them,
+// for a database created before the migrations, and returns the number of migrations recorded
+func (m *Migrator) Baseline(ctx context.Context, version string) (int, error) {
+	if m.find(version) == nil {
+		return 0, fmt.Errorf("migration %s not found", version)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, migration := range m.Migrations {
+		if compareVersion(migration.Version, version) > 0 {
+			break
+		}
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.record(ctx, migration, true); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func (m *Migrator) find(version string) *Migration {
+	for i := range m.Migrations {
+		if m.Migrations[i].Version == version {
+			return &m.Migrations[i]
+		}
+	}
+	return nil
+}
+
+// exec runs the SQL of a migration file, the files holding only comments are skipped
+func (m *Migrator) exec(ctx context.Context, direction string, migration Migration, statement string) error {
+	if m.DryRun {
+		fmt.Fprintf(m.out(), "-- %s %s_%s\n%s\n", direction, migration.Version, migration.Name, strings.TrimSpace(statement))
+		return nil
+	}
+	if isEmptySQL(statement) {
+		return nil
+	}
+
+	db, ok := m.Database.(port.IDatabaseExec)
+	if !ok {
+		return fmt.Errorf("database %s does not execute SQL statements", m.Database.GetName())
+	}
+	if err := db.Exec(ctx, statement); err != nil {
+		return fmt.Errorf("migration %s_%s %s: %v", migration.Version, migration.Name, direction, err)
+	}
+	return nil
+}
+
+func (m *Migrator) record(ctx context.Context, migration Migration, baseline bool) error {
+	if m.DryRun {
+		fmt.Fprintf(m.out(), "-- record %s_%s in %s\n", migration.Version, migration.Name, m.table())
+		return nil
+	}
+
+	record := MigrationRecord{
+		Service:   m.Service,
+		Version:   migration.Version,
+		Name:      migration.Name,
+		Baseline:  baseline,
+		AppliedAt: time.Now().UTC(),
+	}
+	if _, err := m.Database.InsertOne(ctx, m.table(), record); err != nil {
+		return fmt.Errorf("record migration %s: %v", migration.Version, err)
+	}
+	return nil
+}
+
+func (m *Migrator) unrecord(ctx context.Context, migration Migration) error {
+	if m.DryRun {
+		fmt.Fprintf(m.out(), "-- remove %s_%s from %s\n", migration.Version, migration.Name, m.table())
+		return nil
+	}
+
+	filter := []port.DbExpression{
+		{Expr: "service", Op: "=", Args: []any{m.Service}},
+		{Expr: "version", Op: "=", Args: []any{migration.Version}},
+	}
+	if _, err := m.Database.DeleteOne(ctx, m.table(), filter); err != nil {
+		return fmt.Errorf("remove migration %s: %v", migration.Version, err)
+	}
+	return nil
+}
+
+// isEmptySQL reports whether statement only holds blank lines and -- comments
+func isEmptySQL(statement string) bool {
+	for _, line := range strings.Split(statement, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+	return true
+}
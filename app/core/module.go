@@ -1,7 +1,10 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"plugin"
@@ -52,12 +55,55 @@ type ModuleExtended interface {
 	PostInit(ctx *AppContext) error
 }
 
+// ModuleStarter is a Module owning background work (ex: a consumer, a poller). OnStart is
+// called once the libraries and the modules are initialized, in the order of their
+// dependencies, with a context cancelled when the application stops: the goroutines it starts
+// end with it instead of outliving the application.
+type ModuleStarter interface {
+	Module
+
+	OnStart(ctx context.Context) error
+}
+
+// ModuleStopper is a Module waiting for its background work on shutdown. OnStop is called in
+// the reverse order of the start, before the libraries are quiesced, with the limit of
+// server.shutdown_timeout.
+type ModuleStopper interface {
+	Module
+
+	OnStop(ctx context.Context) error
+}
+
+// ModuleHealthChecker is a Module reporting the health of its background work on /healthz and
+// /readyz. Health is called on each probe, so it must answer at once (ex: from the state kept
+// by a consumer).
+type ModuleHealthChecker interface {
+	Module
+
+	Health() error
+}
+
 type ModuleRoute struct {
-	Method   string
-	Path     string
-	Handler  fiber.Handler
-	Handlers []fiber.Handler
-	Root     fiber.Router
+	Method      string
+	Path        string
+	Handler     fiber.Handler
+	Handlers    []fiber.Handler
+	Root        fiber.Router
+	Deprecation *Deprecation // announced on the responses of a deprecated route
+	Canary      *Canary      // second implementation serving part of the traffic
+
+	Module     string          // set by the ModuleRouter
+	Version    string          // API version of the route (ex: v2), set by the ModuleRouter
+	Summary    string          // description of the route in the registry
+	Tags       []string        // groups of the route in the registry
+	Roles      []string        // one of them is required, see auth.RouteRule
+	Permission string          // required, see auth.RouteRule
+	RateLimit  int64           // requests per minute of a client on this route, 0 for no own limit
+	Middleware []fiber.Handler // run before the handlers, after the checks above
+
+	Request  any // body of the request (ex: CreateOrder{}), described in the OpenAPI document
+	Query    any // struct of the query parameters, described in the OpenAPI document
+	Response any // data of the response envelope, described in the OpenAPI document
 }
 
 // ModuleManager manages module registration and loading
@@ -68,6 +114,8 @@ type ModuleManager struct {
 	loaded        bool
 	context       *AppContext
 	config        *config.ModuleConfig
+	started       []string           // modules started, in start order
+	stop          context.CancelFunc // of the context given to OnStart
 }
 
 // LoadedModule represents a loaded module and its metadata
@@ -80,6 +128,20 @@ type LoadedModule struct {
 	DependsOn []string
 }
 
+// enabledModules returns modules without the ones switched off by module.<name>.enabled, see
+// config.Config.ModuleEnabled
+func enabledModules(cfg *config.Config, modules []Module) []Module {
+	enabled := make([]Module, 0, len(modules))
+	for _, module := range modules {
+		if !cfg.ModuleEnabled(module.Name()) {
+			logger.Info("Module disabled", "name", module.Name(), "version", module.Version(), "key", "module."+module.Name()+".enabled")
+			continue
+		}
+		enabled = append(enabled, module)
+	}
+	return enabled
+}
+
 // CreateModuleManager creates a new central registry instance
 func CreateModuleManager(config *config.ModuleConfig, modules []Module) *ModuleManager {
 	manager := &ModuleManager{
@@ -105,16 +167,18 @@ func CreateModuleManager(config *config.ModuleConfig, modules []Module) *ModuleM
 func (lm *ModuleManager) Destroy() error {
 	lm.mu.Lock()
 	defer lm.mu.Unlock()
+	var errs []error
 	for _, module := range lm.modules {
 		if err := module.Destroy(); err != nil {
 			logger.Warn(err.Error())
+			errs = append(errs, fmt.Errorf("module '%s': %w", module.Name(), err))
 		}
 	}
 
 	lm.modules = make(map[string]Module)
 	lm.loaded = false
 	lm.context = nil
-	return nil
+	return errors.Join(errs...)
 }
 
 // IsLoaded checks if all modules have been initialized
@@ -215,6 +279,99 @@ func (r *ModuleManager) InitializeModulesWithDependencies() error {
 	return nil
 }
 
+// Start calls OnStart on the modules implementing ModuleStarter in the order of their
+// dependencies. When a module fails, those already started are stopped.
+func (r *ModuleManager) Start(ctx context.Context) error {
+	dependencyGraph, err := r.buildDependencyGraph()
+	if err != nil {
+		return err
+	}
+	order, err := r.buildDependencyOrder(dependencyGraph)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	runCtx, cancel := context.WithCancel(ctx)
+	r.stop = cancel
+	r.started = nil
+	r.mu.Unlock()
+
+	for _, name := range order {
+		module, err := r.GetModule(name)
+		if err != nil {
+			continue
+		}
+
+		if starter, ok := module.(ModuleStarter); ok {
+			if err := starter.OnStart(runCtx); err != nil {
+				err = fmt.Errorf("start module '%s': %v", name, err)
+				return errors.Join(err, r.Stop(ctx))
+			}
+			logger.Info("Module started", "name", name)
+		}
+
+		r.mu.Lock()
+		r.started = append(r.started, name)
+		r.mu.Unlock()
+	}
+	return nil
+}
+
+// Stop cancels the context given to OnStart and calls OnStop on the started modules
+// implementing ModuleStopper, in the reverse order of their start. It returns the failures
+// joined.
+func (r *ModuleManager) Stop(ctx context.Context) error {
+	r.mu.Lock()
+	started := r.started
+	stop := r.stop
+	r.started = nil
+	r.stop = nil
+	r.mu.Unlock()
+
+	if stop != nil {
+		stop()
+	}
+
+	var errs []error
+	for _, name := range slices.Backward(started) {
+		module, err := r.GetModule(name)
+		if err != nil {
+			continue
+		}
+		if stopper, ok := module.(ModuleStopper); ok {
+			if err := stopper.OnStop(ctx); err != nil {
+				logger.Warn("Module not stopped", "name", name, "error", err)
+				errs = append(errs, fmt.Errorf("module '%s': %w", name, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Health returns the health of the modules implementing ModuleHealthChecker, by module name
+func (r *ModuleManager) Health() []ModuleHealth {
+	r.mu.RLock()
+	modules := maps.Clone(r.modules)
+	r.mu.RUnlock()
+
+	report := []ModuleHealth{}
+	for _, name := range slices.Sorted(maps.Keys(modules)) {
+		checker, ok := modules[name].(ModuleHealthChecker)
+		if !ok {
+			continue
+		}
+
+		health := ModuleHealth{Module: name, Status: HealthUp}
+		if err := checker.Health(); err != nil {
+			health.Status = HealthDown
+			health.Error = err.Error()
+		}
+		report = append(report, health)
+	}
+	return report
+}
+
 // GetRoutes returns all routes from all registered modules
 func (r *ModuleManager) GetRoutes() []*ModuleRoute {
 	r.mu.RLock()
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// NotifyJobType is the job queue type used by Notifier.NotifyAsync
+const NotifyJobType = "notify.send"
+
+// NotifyMessage is the channel independent content of a notification
+type NotifyMessage struct {
+	Title string            `json:"title,omitempty"`
+	Body  string            `json:"body,omitempty"`
+	Data  map[string]string `json:"data,omitempty"`
+
+	// Template is rendered per channel: "<template>.<channel>.txt" for the body and
+	// "<template>.<channel>.title.txt" for the title, email uses the mailer templates.
+	// TemplateData must be JSON serializable when the message is queued.
+	Template     string `json:"template,omitempty"`
+	TemplateData any    `json:"template_data,omitempty"`
+
+	Channels []string `json:"channels,omitempty"` // overrides the user preference
+	All      bool     `json:"all,omitempty"`      // deliver on every channel instead of the first succeeding one
+}
+
+type notifyJob struct {
+	UserID  string        `json:"user_id"`
+	Message NotifyMessage `json:"message"`
+}
+
+// Notifier routes notifications to users through their preferred channels,
+// falling back to the next channel when a delivery fails
+type Notifier struct {
+	config      config.NotifyConfig
+	channels    map[string]port.INotifier
+	preferences port.INotificationPreferences
+	renderer    port.ITemplateRenderer
+	mailer      *Mailer
+	queue       *JobQueue
+}
+
+// NewNotifier creates a notifier delivering email through mailer and registers its job on queue
+func NewNotifier(cfg config.NotifyConfig, mailer *Mailer, queue *JobQueue) *Notifier {
+	n := &Notifier{
+		config:   cfg,
+		channels: make(map[string]port.INotifier),
+		mailer:   mailer,
+		queue:    queue,
+	}
+
+	HandleJob(queue, NotifyJobType, func(ctx context.Context, job notifyJob) error {
+		return n.Notify(ctx, job.UserID, job.Message)
+	})
+
+	return n
+}
+
+// RegisterChannel sets the library delivering a channel (sms, push, ...)
+func (n *Notifier) RegisterChannel(channel string, driver port.INotifier) {
+	n.channels[channel] = driver
+}
+
+// SetPreferences sets the provider of user contact points and channel preferences
+func (n *Notifier) SetPreferences(preferences port.INotificationPreferences) {
+	n.preferences = preferences
+}
+
+// SetRenderer sets the template engine used for NotifyMessage.Template
+func (n *Notifier) SetRenderer(renderer port.ITemplateRenderer) {
+	n.renderer = renderer
+}
+
+// Notify delivers message to the user following their channel preference
+func (n *Notifier) Notify(ctx context.Context, userID string, message NotifyMessage) error {
+	if n.preferences == nil {
+		return fmt.Errorf("no notification preferences provider registered")
+	}
+
+	recipient, err := n.preferences.GetRecipient(ctx, userID)
+	if err != nil {
+		return err
+	}
+
+	channels := message.Channels
+	if len(channels) == 0 {
+		channels = recipient.Channels
+	}
+	if len(channels) == 0 {
+		channels = n.config.DefaultChannels
+	}
+
+	errs := []error{}
+	delivered := false
+	for _, channel := range channels {
+		err := n.notifyChannel(ctx, channel, recipient, message)
+		if err == errNoAddress {
+			continue
+		}
+		if err != nil {
+			logger.Warn("Notification delivery failed", "user", userID, "channel", channel, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %v", channel, err))
+			continue
+		}
+
+		delivered = true
+		if !message.All {
+			return nil
+		}
+	}
+
+	if !delivered {
+		if len(errs) == 0 {
+			return fmt.Errorf("user '%s' cannot be reached on channels %v", userID, channels)
+		}
+		return errors.Join(errs...)
+	}
+	return nil
+}
+
+// NotifyAsync queues the notification, failures are retried by the job queue. It returns the job ID.
+func (n *Notifier) NotifyAsync(ctx context.Context, userID string, message NotifyMessage, opts ...EnqueueOptions) (string, error) {
+	opt := EnqueueOptions{Queue: n.config.Queue}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	return n.queue.Enqueue(ctx, NotifyJobType, notifyJob{UserID: userID, Message: message}, opt)
+}
+
+// Send delivers a notification on a single channel without looking up preferences
+func (n *Notifier) Send(ctx context.Context, channel string, notification *port.Notification) error {
+	driver, ok := n.channels[channel]
+	if !ok {
+		return fmt.Errorf("notification channel '%s' is not configured", channel)
+	}
+	return driver.Notify(ctx, notification)
+}
+
+var errNoAddress = errors.New("recipient has no address for channel")
+
+func (n *Notifier) notifyChannel(ctx context.Context, channel string, recipient *port.NotificationRecipient, message NotifyMessage) error {
+	if channel == port.NotifyChannelEmail {
+		if recipient.Email == "" {
+			return errNoAddress
+		}
+
+		return n.mailer.Send(ctx, &port.MailMessage{
+			To:       []string{recipient.Email},
+			Subject:  message.Title,
+			Text:     message.Body,
+			Template: message.Template,
+			Data:     message.TemplateData,
+		})
+	}
+
+	addresses := []string{}
+	switch channel {
+	case port.NotifyChannelSMS:
+		if recipient.Phone != "" {
+			addresses = append(addresses, recipient.Phone)
+		}
+	case port.NotifyChannelPush:
+		addresses = recipient.DeviceTokens
+	}
+	if len(addresses) == 0 {
+		return errNoAddress
+	}
+
+	title, body, err := n.render(channel, message)
+	if err != nil {
+		return err
+	}
+
+	// push goes to every device of the user, it succeeds when one device got it
+	errs := []error{}
+	for _, address := range addresses {
+		if err := n.Send(ctx, channel, &port.Notification{To: address, Title: title, Body: body, Data: message.Data}); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) < len(addresses) {
+		if len(errs) > 0 {
+			logger.Warn("Notification not sent to every device", "channel", channel, "user_id", recipient.UserID, "failed", len(errs), "error", errors.Join(errs...))
+		}
+		return nil
+	}
+	return errors.Join(errs...)
+}
+
+func (n *Notifier) render(channel string, message NotifyMessage) (string, string, error) {
+	title, body := message.Title, message.Body
+	if message.Template == "" {
+		return title, body, nil
+	}
+
+	if n.renderer == nil {
+		return "", "", fmt.Errorf("notification template '%s' cannot be rendered, no template renderer", message.Template)
+	}
+
+	for _, part := range []struct {
+		name   string
+		target *string
+	}{
+		{message.Template + "." + channel + ".txt", &body},
+		{message.Template + "." + channel + ".title.txt", &title},
+	} {
+		if !n.renderer.Has(part.name) {
+			continue
+		}
+
+		var buf bytes.Buffer
+		if err := n.renderer.Render(&buf, part.name, message.TemplateData); err != nil {
+			return "", "", fmt.Errorf("notification template '%s': %v", part.name, err)
+		}
+		*part.target = buf.String()
+	}
+
+	return title, body, nil
+}
@@ -0,0 +1,178 @@
+package core
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/openapi"
+)
+
+// securityScheme is the name of the scheme of auth.type in the OpenAPI document
+const securityScheme = "default"
+
+// OpenAPIDocument generates the OpenAPI 3.1 document of the routes of the modules from their
+// declaration: summary, tags, roles, deprecation and the schemas of Accepts, AcceptsQuery and
+// Returns. Its paths are relative to server.path_prefix, given as its server.
+func (r *ModuleManager) OpenAPIDocument() *openapi.Document {
+	doc := &openapi.Document{OpenAPI: "3.1.0", Paths: map[string]*openapi.PathItem{}}
+	if r.context == nil {
+		return doc
+	}
+
+	cfg := r.context.Config
+	doc.Info = openapi.Info{Title: cfg.App.Name, Version: cfg.App.Version}
+	prefix := strings.TrimRight(cfg.Server.PathPrefix, "/")
+	if prefix != "" {
+		doc.Servers = []openapi.Server{{URL: prefix}}
+	}
+
+	if scheme := authSecurityScheme(cfg.Auth.Type, cfg.Auth.APIKeyHeader); scheme != nil {
+		doc.Components = &openapi.Components{SecuritySchemes: map[string]*openapi.SecurityScheme{securityScheme: scheme}}
+	}
+
+	r.DescribeRoutes(doc, prefix)
+	return doc
+}
+
+// DescribeRoutes adds the routes of the modules below prefix to doc with their declaration,
+// the operations doc already describes are only completed
+func (r *ModuleManager) DescribeRoutes(doc *openapi.Document, prefix string) {
+	secured := doc.Components != nil && doc.Components.SecuritySchemes[securityScheme] != nil
+
+	for _, route := range r.GetRoutes() {
+		full := routePrefix(route.Root) + route.Path
+		path, ok := strings.CutPrefix(full, prefix)
+		if !ok || !strings.HasPrefix(path, "/") {
+			continue
+		}
+
+		op := doc.AddRoute(route.Method, path)
+		if op.Summary == "" {
+			op.Summary = route.Summary
+		}
+		if len(op.Tags) == 0 {
+			op.Tags = route.Tags
+			if len(op.Tags) == 0 && route.Module != "" {
+				op.Tags = []string{route.Module}
+			}
+		}
+		if op.Description == "" && (len(route.Roles) > 0 || route.Permission != "") {
+			op.Description = routeAccess(route)
+		}
+		if secured && op.Security == nil {
+			roles := route.Roles
+			if roles == nil {
+				roles = []string{}
+			}
+			op.Security = []map[string][]string{{securityScheme: roles}}
+		}
+
+		deprecated := route.Deprecation != nil
+		if !deprecated && r.context != nil {
+			_, deprecated = r.context.Deprecations.match(route.Method, full)
+		}
+		op.Deprecated = op.Deprecated || deprecated
+
+		if route.Query != nil {
+			for _, param := range doc.QueryParameters(route.Query) {
+				if !hasParameter(op.Parameters, param.Name, param.In) {
+					op.Parameters = append(op.Parameters, param)
+				}
+			}
+		}
+		if route.Request != nil && op.RequestBody == nil {
+			op.RequestBody = &openapi.RequestBody{
+				Required: true,
+				Content:  map[string]*openapi.MediaType{fiber.MIMEApplicationJSON: {Schema: doc.SchemaOf(route.Request)}},
+			}
+		}
+		if route.Response != nil {
+			if response := op.Responses["200"]; response != nil && response.Content == nil {
+				response.Content = map[string]*openapi.MediaType{fiber.MIMEApplicationJSON: {Schema: envelopeSchema(doc.SchemaOf(route.Response))}}
+			}
+		}
+	}
+}
+
+// authSecurityScheme returns the security scheme of auth.type, nil when the routes are public
+// or the type has no standard scheme
+func authSecurityScheme(authType string, apiKeyHeader string) *openapi.SecurityScheme {
+	switch authType {
+	case "jwt":
+		return &openapi.SecurityScheme{Type: "http", Scheme: "bearer"}
+	case "basic":
+		return &openapi.SecurityScheme{Type: "http", Scheme: "basic"}
+	case "apikey":
+		return &openapi.SecurityScheme{Type: "apiKey", Name: apiKeyHeader, In: "header"}
+	}
+	return nil
+}
+
+// routeAccess describes the roles and the permission required by route
+func routeAccess(route *ModuleRoute) string {
+	var parts []string
+	if len(route.Roles) > 0 {
+		parts = append(parts, "one of the roles "+strings.Join(route.Roles, ", "))
+	}
+	if route.Permission != "" {
+		parts = append(parts, "the permission "+route.Permission)
+	}
+	return "Requires " + strings.Join(parts, " and ") + "."
+}
+
+// envelopeSchema is the schema of the out.Response envelope carrying data
+func envelopeSchema(data *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{
+		Type: openapi.SchemaType{"object"},
+		Properties: map[string]*openapi.Schema{
+			"message": {Type: openapi.SchemaType{"string"}},
+			"data":    data,
+		},
+	}
+}
+
+func hasParameter(params []*openapi.Parameter, name string, in string) bool {
+	for _, param := range params {
+		if param.Name == name && param.In == in {
+			return true
+		}
+	}
+	return false
+}
+
+// registerOpenAPI serves the OpenAPI document of the module routes on /openapi.json and, with
+// app.openapi.ui, the Swagger UI reading it on /docs. The document is generated on the first
+// request, once the modules have mounted their routes.
+func (a *App) registerOpenAPI() {
+	var once sync.Once
+	var doc *openapi.Document
+	a.Context.Web.Get("/openapi.json", func(c *fiber.Ctx) error {
+		once.Do(func() { doc = a.ModuleManager.OpenAPIDocument() })
+		return c.JSON(doc)
+	})
+
+	if a.Context.Config.App.OpenAPI.UI {
+		a.Context.Web.Get("/docs", func(c *fiber.Ctx) error {
+			c.Type("html", "utf-8")
+			return c.SendString(swaggerUI)
+		})
+	}
+}
+
+const swaggerUI = `<!DOCTYPE html>
+<html>
+<head>
+  <meta charset="utf-8">
+  <title>API Documentation</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+  </script>
+</body>
+</html>
+`
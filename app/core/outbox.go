@@ -0,0 +1,386 @@
+package core
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Attributes of the messages relayed to a PubSub by the outbox
+const (
+	OutboxKeyAttribute   = "dedup_key" // ID of the message in the outbox, the same for each relay of the message
+	OutboxEventAttribute = "event"
+)
+
+// States of the messages of the outbox
+const (
+	OutboxPending = "pending"
+	OutboxFailed  = "failed" // relayed app.outbox.max_attempts times without success
+)
+
+// OutboxMessage is a message relayed to Kafka, the Kafka library encodes it (ex: as JSON). Its
+// ID is the dedup key of the consumers, the same for each relay of the message.
+type OutboxMessage struct {
+	ID      string            `json:"id"`
+	Event   string            `json:"event"`
+	Payload json.RawMessage   `json:"payload"`
+	Headers map[string]string `json:"headers,omitempty"` // request ID and traceparent of the publisher
+}
+
+// SetHeader sets the header key of the message (ex: the traceparent of the traced Kafka)
+func (m *OutboxMessage) SetHeader(key string, value string) {
+	if m.Headers == nil {
+		m.Headers = map[string]string{}
+	}
+	m.Headers[key] = value
+}
+
+// outboxRow is a row of the table of the outbox
+type outboxRow struct {
+	ID            string    `db:"id"`
+	Event         string    `db:"event"`
+	Payload       string    `db:"payload"`    // JSON encoded payload
+	Attributes    string    `db:"attributes"` // JSON encoded correlation of the publisher
+	Status        string    `db:"status"`
+	Attempts      int       `db:"attempts"`
+	LastError     string    `db:"last_error"`
+	NextAttemptAt time.Time `db:"next_attempt_at"`
+	CreatedAt     time.Time `db:"created_at"`
+}
+
+type outboxTxKey struct{}
+
+// outboxEvent is an event stored by a transaction, published on the EventBus once committed
+type outboxEvent struct {
+	event   string
+	payload any
+}
+
+// Outbox stores the events of the modules in the table app.outbox.table of the default
+// database, in the transaction of their changes, and relays them to the broker of
+// app.outbox.broker (a PubSub or a Kafka):
+//
+//	err := m.context.Outbox.Transaction(ctx, func(ctx context.Context) error {
+//		if _, err := db.InsertOne(ctx, "orders", order); err != nil {
+//			return err
+//		}
+//		return core.PublishTx(ctx, m.context.Outbox, EventOrderPaid, OrderPaid{ID: order.ID})
+//	})
+//
+// An event is relayed once its transaction is committed and never when it is rolled back. The
+// delivery is at least once: a message whose relay failed, or whose instance stopped while
+// relaying it, is relayed again, with the same dedup key (see Deduplicator). The events are
+// also published on the EventBus once committed, for the subscribers of the instance.
+type Outbox struct {
+	mu        sync.RWMutex
+	config    config.OutboxConfig
+	database  port.IDatabase
+	libraries *LibraryManager
+	bus       *EventBus
+	clock     helper.Clock
+	retry     RetryPolicy
+	wake      chan struct{}
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewOutbox creates the outbox of app.outbox, relaying once SetDatabase and Start are called
+func NewOutbox(cfg config.OutboxConfig, libraries *LibraryManager, bus *EventBus, clock helper.Clock) *Outbox {
+	return &Outbox{
+		config:    cfg,
+		libraries: libraries,
+		bus:       bus,
+		clock:     clock,
+		retry:     RetryPolicy{MaxAttempts: cfg.MaxAttempts, Backoff: cfg.Backoff, MaxBackoff: cfg.MaxBackoff},
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// SetDatabase sets the database holding the table of the outbox
+func (o *Outbox) SetDatabase(database port.IDatabase) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.database = database
+}
+
+func (o *Outbox) current() (port.IDatabase, error) {
+	if !o.config.Enabled {
+		return nil, fmt.Errorf("outbox is not enabled, enable app.outbox")
+	}
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.database == nil {
+		return nil, fmt.Errorf("outbox has no database")
+	}
+	return o.database, nil
+}
+
+// Transaction runs fn in a transaction of the database of the outbox, the database must
+// implement port.IDatabaseTx. The events published by fn with its ctx are stored in the
+// transaction, then published on the EventBus and relayed once it is committed.
+func (o *Outbox) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	database, err := o.current()
+	if err != nil {
+		return err
+	}
+	tx, ok := database.(port.IDatabaseTx)
+	if !ok {
+		return fmt.Errorf("database %s does not support transactions", database.GetDriver())
+	}
+
+	events := &[]outboxEvent{}
+	err = tx.Transaction(ctx, func(ctx context.Context) error {
+		return fn(context.WithValue(ctx, outboxTxKey{}, events))
+	})
+	if err != nil {
+		return err
+	}
+
+	o.committed(*events)
+	return nil
+}
+
+// Publish stores payload as a message of event, in the transaction of ctx when it is the one
+// of Transaction. Out of a transaction the message is stored at once.
+func (o *Outbox) Publish(ctx context.Context, event string, payload any) error {
+	database, err := o.current()
+	if err != nil {
+		return err
+	}
+
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	data, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return err
+	}
+	attributes, err := helper.JSONMarshal(helper.CorrelationAttributes(ctx, nil))
+	if err != nil {
+		return err
+	}
+
+	now := o.clock.Now()
+	row := outboxRow{
+		ID:            id,
+		Event:         event,
+		Payload:       string(data),
+		Attributes:    string(attributes),
+		Status:        OutboxPending,
+		NextAttemptAt: now,
+		CreatedAt:     now,
+	}
+	if _, err := database.InsertOne(ctx, o.config.Table, row); err != nil {
+		return err
+	}
+
+	if events, ok := ctx.Value(outboxTxKey{}).(*[]outboxEvent); ok {
+		*events = append(*events, outboxEvent{event: event, payload: payload})
+	} else {
+		o.committed([]outboxEvent{{event: event, payload: payload}})
+	}
+	return nil
+}
+
+// PublishTx stores payload as a message of topic in the outbox, see Outbox.Publish
+func PublishTx[T any](ctx context.Context, outbox *Outbox, topic Topic[T], payload T) error {
+	return outbox.Publish(ctx, string(topic), payload)
+}
+
+// committed publishes the stored events on the EventBus and wakes the relay
+func (o *Outbox) committed(events []outboxEvent) {
+	if len(events) == 0 {
+		return
+	}
+	for _, event := range events {
+		o.bus.Publish(event.event, event.payload)
+	}
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start relays the messages every app.outbox.interval and after each commit of the instance
+func (o *Outbox) Start(ctx context.Context) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.ctx != nil {
+		return
+	}
+	o.ctx, o.cancel = context.WithCancel(ctx)
+
+	o.wg.Add(1)
+	go o.run(o.ctx)
+}
+
+// Stop ends the relay, the messages not relayed yet are relayed on the next start or by
+// another instance
+func (o *Outbox) Stop() {
+	o.mu.Lock()
+	cancel := o.cancel
+	o.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	o.wg.Wait()
+}
+
+func (o *Outbox) run(ctx context.Context) {
+	defer o.wg.Done()
+
+	for {
+		// a full batch is followed at once by the next one
+		relayed, err := o.Relay(ctx)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Outbox relay failed", "error", err)
+		}
+		if o.config.BatchSize > 0 && relayed == o.config.BatchSize {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-o.wake:
+		case <-o.clock.After(o.config.Interval):
+		}
+	}
+}
+
+// Relay relays a batch of the pending messages due and returns how many it handled
+func (o *Outbox) Relay(ctx context.Context) (int, error) {
+	database, err := o.current()
+	if err != nil {
+		return 0, err
+	}
+
+	var rows []outboxRow
+	filter := []port.DbExpression{
+		{Expr: "status", Op: "=", Args: []any{OutboxPending}},
+		{Expr: "next_attempt_at", Op: "<=", Args: []any{o.clock.Now()}},
+	}
+	if err := database.Find(ctx, &rows, o.config.Table, nil, filter, map[string]int{"created_at": 1}, int64(o.config.BatchSize), 0); err != nil {
+		return 0, err
+	}
+
+	for i := range rows {
+		if ctx.Err() != nil {
+			return i, ctx.Err()
+		}
+		if err := o.relay(ctx, database, &rows[i]); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
+
+// relay claims the message for the lease, sends it and deletes it, or saves the failure
+func (o *Outbox) relay(ctx context.Context, database port.IDatabase, row *outboxRow) error {
+	// another instance claimed it first when its attempts changed
+	claim := port.DbMap{"attempts": row.Attempts + 1, "next_attempt_at": o.clock.Now().Add(o.config.Lease)}
+	claimed, err := database.UpdateOne(ctx, o.config.Table, []port.DbExpression{
+		{Expr: "id", Op: "=", Args: []any{row.ID}},
+		{Expr: "attempts", Op: "=", Args: []any{row.Attempts}},
+	}, claim)
+	if err != nil || claimed == 0 {
+		return err
+	}
+	row.Attempts++
+
+	sendErr := o.send(ctx, row)
+	filter := []port.DbExpression{{Expr: "id", Op: "=", Args: []any{row.ID}}}
+	if sendErr == nil {
+		_, err := database.DeleteOne(ctx, o.config.Table, filter)
+		return err
+	}
+
+	failure := port.DbMap{"last_error": sendErr.Error(), "next_attempt_at": o.clock.Now().Add(o.retry.Delay(row.Attempts))}
+	if o.retry.MaxAttempts > 0 && row.Attempts >= o.retry.MaxAttempts {
+		failure["status"] = OutboxFailed
+		logger.Error("Outbox message failed", "id", row.ID, "event", row.Event, "attempts", row.Attempts, "error", sendErr)
+	} else {
+		logger.Warn("Outbox message not relayed", "id", row.ID, "event", row.Event, "attempt", row.Attempts, "error", sendErr)
+	}
+	_, err = database.UpdateOne(ctx, o.config.Table, filter, failure)
+	return err
+}
+
+// send publishes the message on the broker of app.outbox.broker
+func (o *Outbox) send(ctx context.Context, row *outboxRow) error {
+	library, ok := o.libraries.GetSingletonInstance(o.config.Broker)
+	if !ok {
+		return fmt.Errorf("Library '%s' tidak ditemukan", o.config.Broker)
+	}
+
+	var attributes map[string]string
+	if row.Attributes != "" {
+		if err := helper.JSONUnmarshal([]byte(row.Attributes), &attributes); err != nil {
+			return err
+		}
+	}
+	ctx = helper.CorrelationContext(ctx, attributes)
+
+	switch broker := library.(type) {
+	case port.IPubSub:
+		if attributes == nil {
+			attributes = map[string]string{}
+		}
+		attributes[OutboxKeyAttribute] = row.ID
+		attributes[OutboxEventAttribute] = row.Event
+		_, err := broker.Publish(ctx, json.RawMessage(row.Payload), attributes)
+		return err
+	case port.IKafka:
+		topic := row.Event
+		if t, ok := o.config.Topics[row.Event]; ok {
+			topic = t
+		}
+		return broker.Publish(ctx, topic, &OutboxMessage{ID: row.ID, Event: row.Event, Payload: json.RawMessage(row.Payload), Headers: attributes})
+	}
+	return fmt.Errorf("Library '%s' bukan port.IPubSub atau port.IKafka", o.config.Broker)
+}
+
+// Deduplicator tells the consumers of the outbox the messages already handled, by their dedup
+// key kept in a cache for ttl:
+//
+//	if first, err := dedup.First(message.GetAttributes()[core.OutboxKeyAttribute]); err != nil || !first {
+//		return err == nil, err
+//	}
+type Deduplicator struct {
+	cache port.ICacheMemory
+	ttl   time.Duration
+}
+
+// NewDeduplicator creates a deduplicator remembering the keys in cache for ttl
+func NewDeduplicator(cache port.ICacheMemory, ttl time.Duration) *Deduplicator {
+	return &Deduplicator{cache: cache, ttl: ttl}
+}
+
+// First reports whether key is seen for the first time. With a cache implementing
+// port.ICacheCounter the instances agree on it; with the others two instances receiving the
+// same message at the same time may both see it first.
+func (d *Deduplicator) First(key string) (bool, error) {
+	key = "outbox:dedup:" + key
+	if counter, ok := d.cache.(port.ICacheCounter); ok {
+		count, err := counter.Increment(key, 1, d.ttl)
+		return count == 1, err
+	}
+
+	var seen bool
+	if d.cache.Get(key, &seen) && seen {
+		return false, nil
+	}
+	return true, d.cache.Set(key, true, d.ttl)
+}
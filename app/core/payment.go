@@ -0,0 +1,140 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+)
+
+// PaymentWebhookSource is the webhook source of the payment gateway,
+// the provider must post its events to <app.webhooks.path>/payments
+const PaymentWebhookSource = "payments"
+
+// Payments creates and refunds payments with the configured gateway and dispatches the
+// status updates it sends through the webhook receiver
+type Payments struct {
+	mu       sync.RWMutex
+	config   config.PaymentConfig
+	gateway  port.IPaymentGateway
+	handlers []func(ctx context.Context, event *port.PaymentEvent) error
+}
+
+// NewPayments creates payments without gateway and registers its webhook source
+func NewPayments(cfg config.PaymentConfig, webhooks *Webhooks) *Payments {
+	p := &Payments{config: cfg}
+
+	webhooks.Register(PaymentWebhookSource,
+		func(headers map[string]string, body []byte) (string, error) {
+			gateway, err := p.driver()
+			if err != nil {
+				return "", err
+			}
+			return gateway.VerifyWebhook(headers, body)
+		},
+		func(ctx context.Context, delivery *WebhookDelivery) error {
+			return p.dispatch(ctx, delivery.Body)
+		})
+
+	return p
+}
+
+// SetDriver sets the payment gateway
+func (p *Payments) SetDriver(gateway port.IPaymentGateway) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.gateway = gateway
+}
+
+// OnEvent registers a handler of the payment status updates. A failing handler makes the
+// webhook job retry, running every handler again, so they must be idempotent.
+func (p *Payments) OnEvent(fn func(ctx context.Context, event *port.PaymentEvent) error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handlers = append(p.handlers, fn)
+}
+
+func (p *Payments) CreatePayment(ctx context.Context, request *port.PaymentRequest) (*port.Payment, error) {
+	gateway, err := p.driver()
+	if err != nil {
+		return nil, err
+	}
+	if request.Amount <= 0 {
+		return nil, fmt.Errorf("payment amount must be positive")
+	}
+	if request.Currency == "" {
+		request.Currency = p.config.Currency
+	}
+	return gateway.CreatePayment(ctx, request)
+}
+
+func (p *Payments) GetPayment(ctx context.Context, id string) (*port.Payment, error) {
+	gateway, err := p.driver()
+	if err != nil {
+		return nil, err
+	}
+	return gateway.GetPayment(ctx, id)
+}
+
+// CapturePayment collects an authorized payment, a zero amount captures all of it
+func (p *Payments) CapturePayment(ctx context.Context, id string, amount int64) (*port.Payment, error) {
+	gateway, err := p.driver()
+	if err != nil {
+		return nil, err
+	}
+	return gateway.CapturePayment(ctx, id, amount)
+}
+
+func (p *Payments) CancelPayment(ctx context.Context, id string) (*port.Payment, error) {
+	gateway, err := p.driver()
+	if err != nil {
+		return nil, err
+	}
+	return gateway.CancelPayment(ctx, id)
+}
+
+// Refund refunds a payment, the completion is reported through OnEvent
+func (p *Payments) Refund(ctx context.Context, request *port.RefundRequest) (*port.Refund, error) {
+	gateway, err := p.driver()
+	if err != nil {
+		return nil, err
+	}
+	if request.Amount < 0 {
+		return nil, fmt.Errorf("refund amount cannot be negative")
+	}
+	return gateway.Refund(ctx, request)
+}
+
+func (p *Payments) dispatch(ctx context.Context, body []byte) error {
+	gateway, err := p.driver()
+	if err != nil {
+		return err
+	}
+
+	event, err := gateway.ParseWebhook(body)
+	if err != nil || event == nil {
+		return err
+	}
+
+	p.mu.RLock()
+	handlers := p.handlers
+	p.mu.RUnlock()
+
+	for _, fn := range handlers {
+		if err := fn(ctx, event); err != nil {
+			return fmt.Errorf("payment event '%s': %v", event.ID, err)
+		}
+	}
+	return nil
+}
+
+func (p *Payments) driver() (port.IPaymentGateway, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.gateway == nil {
+		return nil, fmt.Errorf("payments are not configured")
+	}
+	return p.gateway, nil
+}
@@ -0,0 +1,420 @@
+package core
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Events published on the EventBus with the PrivacyReport of every export and erasure
+const (
+	EventPrivacyExported = "privacy.exported"
+	EventPrivacyErased   = "privacy.erased"
+)
+
+// Privacy request types
+const (
+	PrivacyExport  = "export"
+	PrivacyErasure = "erasure"
+)
+
+// DataOwner declares the personal data a module keeps about a data subject (ex: a user ID)
+type DataOwner struct {
+	Name string
+	// Export returns the data of the subject, written as <Name>.json in the archive. Nil
+	// when the module keeps nothing about the subject.
+	Export func(ctx context.Context, subject string) (any, error)
+	// Erase deletes or anonymizes the data of the subject and returns the records erased. It
+	// must succeed again once done, a failed erasure is retried for every owner.
+	Erase func(ctx context.Context, subject string) (int64, error)
+}
+
+// PrivacyOwnerReport is the part of a data owner in an export or an erasure
+type PrivacyOwnerReport struct {
+	Owner    string `json:"owner"`
+	Records  int64  `json:"records,omitempty"` // erased records
+	Size     int64  `json:"size,omitempty"`    // bytes of the exported data
+	Skipped  bool   `json:"skipped,omitempty"` // the owner does not export or erase
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// PrivacyReport is the audit record of an export or an erasure of the data of a subject
+type PrivacyReport struct {
+	ID          string               `json:"id"`
+	Type        string               `json:"type"` // PrivacyExport or PrivacyErasure
+	Subject     string               `json:"subject"`
+	RequestedBy string               `json:"requested_by,omitempty"`
+	Owners      []PrivacyOwnerReport `json:"owners"`
+	Key         string               `json:"key,omitempty"` // object storage key of the export archive
+	URL         string               `json:"url,omitempty"` // temporary download link of the archive
+	Complete    bool                 `json:"complete"`
+	Started     time.Time            `json:"started"`
+	Duration    string               `json:"duration"`
+}
+
+type privacyJob struct {
+	ID          string `json:"id"`
+	Subject     string `json:"subject"`
+	RequestedBy string `json:"requested_by"`
+}
+
+// Privacy orchestrates the requests of data subjects across the data owners registered by
+// the modules: the export of all their data in a ZIP archive of the object storage, and
+// their erasure. Both run as tasks and leave a report kept in the object storage.
+type Privacy struct {
+	mu      sync.Mutex
+	config  config.PrivacyConfig
+	tasks   *Tasks
+	bus     *EventBus
+	clock   helper.Clock
+	storage port.IObjectStorage
+	owners  map[string]DataOwner
+	reports []PrivacyReport
+}
+
+// NewPrivacy creates the orchestration without data owners and registers its tasks
+func NewPrivacy(cfg config.PrivacyConfig, tasks *Tasks, bus *EventBus, clock helper.Clock) *Privacy {
+	if cfg.Prefix == "" {
+		cfg.Prefix = "privacy"
+	}
+	if cfg.LinkExpiry <= 0 {
+		cfg.LinkExpiry = 24 * time.Hour
+	}
+	if cfg.History <= 0 {
+		cfg.History = 100
+	}
+
+	p := &Privacy{
+		config: cfg,
+		tasks:  tasks,
+		bus:    bus,
+		clock:  clock,
+		owners: make(map[string]DataOwner),
+	}
+
+	HandleTask(tasks, "privacy:export", func(ctx context.Context, progress TaskProgress, job privacyJob) (any, error) {
+		return p.export(ctx, job, progress)
+	})
+	HandleTask(tasks, "privacy:erasure", func(ctx context.Context, progress TaskProgress, job privacyJob) (any, error) {
+		return p.erase(ctx, job, progress)
+	})
+
+	return p
+}
+
+// SetStorage sets where the export archives and the reports are stored
+func (p *Privacy) SetStorage(storage port.IObjectStorage) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.storage = storage
+}
+
+// Register adds a data owner, usually from the Init of a module
+func (p *Privacy) Register(owner DataOwner) error {
+	if owner.Name == "" {
+		return fmt.Errorf("data owner name is required")
+	}
+	if owner.Export == nil && owner.Erase == nil {
+		return fmt.Errorf("data owner '%s' needs an export or an erasure", owner.Name)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, exists := p.owners[owner.Name]; exists {
+		return fmt.Errorf("data owner '%s' already registered", owner.Name)
+	}
+	p.owners[owner.Name] = owner
+	return nil
+}
+
+// Owners returns the registered data owners sorted by name
+func (p *Privacy) Owners() []DataOwner {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	owners := make([]DataOwner, 0, len(p.owners))
+	for _, owner := range p.owners {
+		owners = append(owners, owner)
+	}
+	sort.Slice(owners, func(i, j int) bool { return owners[i].Name < owners[j].Name })
+	return owners
+}
+
+// Reports returns the latest reports of this instance, most recent first. Every report is also
+// stored in the object storage under <prefix>/reports/.
+func (p *Privacy) Reports() []PrivacyReport {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	reports := make([]PrivacyReport, len(p.reports))
+	for i, report := range p.reports {
+		reports[len(p.reports)-1-i] = report
+	}
+	return reports
+}
+
+// Export queues the export of the data of subject, requestedBy is recorded in the report and
+// may read the task
+func (p *Privacy) Export(ctx context.Context, subject string, requestedBy string) (*Task, error) {
+	return p.enqueue(ctx, "privacy:export", subject, requestedBy)
+}
+
+// Erase queues the erasure of the data of subject, requestedBy is recorded in the report and
+// may read the task
+func (p *Privacy) Erase(ctx context.Context, subject string, requestedBy string) (*Task, error) {
+	return p.enqueue(ctx, "privacy:erasure", subject, requestedBy)
+}
+
+func (p *Privacy) enqueue(ctx context.Context, taskType string, subject string, requestedBy string) (*Task, error) {
+	if subject == "" {
+		return nil, fmt.Errorf("data subject is required")
+	}
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+	job := privacyJob{ID: id, Subject: subject, RequestedBy: requestedBy}
+	return p.tasks.Enqueue(ctx, taskType, job, requestedBy, EnqueueOptions{Queue: p.config.Queue})
+}
+
+// AcceptExport queues the export of subject requested by the current user and answers 202 Accepted with the task
+func (p *Privacy) AcceptExport(c *fiber.Ctx, subject string) error {
+	return p.accept(c, "privacy:export", subject)
+}
+
+// AcceptErasure queues the erasure of subject requested by the current user and answers 202 Accepted with the task
+func (p *Privacy) AcceptErasure(c *fiber.Ctx, subject string) error {
+	return p.accept(c, "privacy:erasure", subject)
+}
+
+func (p *Privacy) accept(c *fiber.Ctx, taskType string, subject string) error {
+	if subject == "" {
+		return fiber.NewError(fiber.StatusBadRequest, "data subject is required")
+	}
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return err
+	}
+	job := privacyJob{ID: id, Subject: subject, RequestedBy: auth.CurrentUserID(c)}
+	return p.tasks.Accept(c, taskType, job, EnqueueOptions{Queue: p.config.Queue})
+}
+
+// export writes the data of every owner in a ZIP archive stored under <prefix>/exports/<id>.zip.
+// An owner failing fails the attempt, the archive is only stored complete.
+func (p *Privacy) export(ctx context.Context, job privacyJob, progress TaskProgress) (*PrivacyReport, error) {
+	storage := p.objectStorage()
+	if storage == nil {
+		return nil, fmt.Errorf("data export cannot be stored, no object storage configured")
+	}
+
+	report := p.newReport(PrivacyExport, job)
+	owners := p.Owners()
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	var failed error
+	for i, owner := range owners {
+		progress(i*100/max(len(owners), 1), "Exporting "+owner.Name)
+
+		started := p.clock.Now()
+		part := PrivacyOwnerReport{Owner: owner.Name, Skipped: owner.Export == nil}
+		if owner.Export != nil {
+			size, err := p.exportOwner(ctx, archive, owner, job.Subject)
+			part.Size = size
+			if err != nil {
+				part.Error = err.Error()
+				failed = fmt.Errorf("data owner '%s' failed to export: %v", owner.Name, err)
+			}
+		}
+		part.Duration = p.clock.Since(started).String()
+		report.Owners = append(report.Owners, part)
+
+		if failed != nil {
+			break
+		}
+	}
+
+	if failed == nil {
+		failed = p.writeManifest(archive, report)
+	}
+	if err := archive.Close(); err != nil && failed == nil {
+		failed = err
+	}
+
+	if failed == nil {
+		report.Key = p.key("exports", report.ID+".zip")
+		_, err := storage.Put(ctx, report.Key, &buf, port.PutOptions{
+			ContentType: "application/zip",
+			Size:        int64(buf.Len()),
+			Metadata:    map[string]string{"subject": job.Subject},
+		})
+		if err != nil {
+			failed = err
+		} else if url, err := storage.SignedURL(ctx, report.Key, "GET", p.config.LinkExpiry); err == nil {
+			report.URL = url
+		} else {
+			logger.Warn("Data export link not signed", "key", report.Key, "error", err)
+		}
+	}
+
+	report.Complete = failed == nil
+	p.finish(ctx, EventPrivacyExported, report)
+	if failed != nil {
+		return nil, failed
+	}
+	return report, nil
+}
+
+func (p *Privacy) exportOwner(ctx context.Context, archive *zip.Writer, owner DataOwner, subject string) (int64, error) {
+	data, err := owner.Export(ctx, subject)
+	if err != nil || data == nil {
+		return 0, err
+	}
+
+	content, err := helper.JSONMarshalIndent(data, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+	w, err := archive.Create(owner.Name + ".json")
+	if err != nil {
+		return 0, err
+	}
+	n, err := w.Write(content)
+	return int64(n), err
+}
+
+// writeManifest describes the archive in manifest.json, for the subject reading it
+func (p *Privacy) writeManifest(archive *zip.Writer, report *PrivacyReport) error {
+	files := []string{}
+	for _, part := range report.Owners {
+		if part.Size > 0 {
+			files = append(files, part.Owner+".json")
+		}
+	}
+	content, err := helper.JSONMarshalIndent(map[string]any{
+		"id":          report.ID,
+		"subject":     report.Subject,
+		"exported_at": report.Started,
+		"files":       files,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	w, err := archive.Create("manifest.json")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// erase runs the erasure of every owner, a failing owner does not stop the others and fails
+// the attempt once they all ran
+func (p *Privacy) erase(ctx context.Context, job privacyJob, progress TaskProgress) (*PrivacyReport, error) {
+	report := p.newReport(PrivacyErasure, job)
+	owners := p.Owners()
+
+	failed := []string{}
+	for i, owner := range owners {
+		if ctx.Err() != nil {
+			break
+		}
+		progress(i*100/max(len(owners), 1), "Erasing "+owner.Name)
+
+		started := p.clock.Now()
+		part := PrivacyOwnerReport{Owner: owner.Name, Skipped: owner.Erase == nil}
+		if owner.Erase != nil {
+			records, err := owner.Erase(ctx, job.Subject)
+			part.Records = records
+			if err != nil {
+				part.Error = err.Error()
+				failed = append(failed, owner.Name)
+			}
+		}
+		part.Duration = p.clock.Since(started).String()
+		report.Owners = append(report.Owners, part)
+	}
+
+	report.Complete = len(failed) == 0 && ctx.Err() == nil
+	p.finish(ctx, EventPrivacyErased, report)
+	if len(failed) > 0 {
+		return nil, fmt.Errorf("data erasure failed for %v", failed)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+func (p *Privacy) newReport(requestType string, job privacyJob) *PrivacyReport {
+	return &PrivacyReport{
+		ID:          job.ID,
+		Type:        requestType,
+		Subject:     job.Subject,
+		RequestedBy: job.RequestedBy,
+		Owners:      []PrivacyOwnerReport{},
+		Started:     p.clock.Now(),
+	}
+}
+
+// finish records the report of an attempt: in the history, in the object storage under
+// <prefix>/reports/<id>-<started>.json so every attempt stays auditable, and on the EventBus
+func (p *Privacy) finish(ctx context.Context, event string, report *PrivacyReport) {
+	report.Duration = p.clock.Since(report.Started).String()
+
+	if storage := p.objectStorage(); storage != nil {
+		content, err := helper.JSONMarshalIndent(report, "", "  ")
+		if err == nil {
+			key := p.key("reports", fmt.Sprintf("%s-%d.json", report.ID, report.Started.UnixMilli()))
+			_, err = storage.Put(ctx, key, bytes.NewReader(content), port.PutOptions{
+				ContentType: "application/json",
+				Size:        int64(len(content)),
+			})
+		}
+		if err != nil {
+			logger.Error("Privacy report not stored", "id", report.ID, "type", report.Type, "error", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.reports = append(p.reports, *report)
+	if len(p.reports) > p.config.History {
+		p.reports = p.reports[len(p.reports)-p.config.History:]
+	}
+	p.mu.Unlock()
+
+	logger.Info("Privacy request processed",
+		"id", report.ID,
+		"type", report.Type,
+		"subject", report.Subject,
+		"requested_by", report.RequestedBy,
+		"complete", report.Complete,
+		"duration", report.Duration)
+
+	p.bus.Publish(event, *report)
+}
+
+func (p *Privacy) objectStorage() port.IObjectStorage {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.storage
+}
+
+func (p *Privacy) key(folder string, name string) string {
+	return strings.TrimSuffix(p.config.Prefix, "/") + "/" + folder + "/" + name
+}
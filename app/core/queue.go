@@ -0,0 +1,518 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/retry"
+	"github.com/webcore-go/webcore/port"
+)
+
+// RetryPolicy controls how failed jobs of a type are retried, see retry.Policy
+type RetryPolicy = retry.Policy
+
+// EnqueueOptions customizes a single job
+type EnqueueOptions struct {
+	Queue       string // defaults to "default"
+	Priority    int    // higher runs first
+	Delay       time.Duration
+	MaxAttempts int // overrides the retry policy of the job type
+}
+
+type jobHandler struct {
+	retry RetryPolicy
+	run   func(ctx context.Context, payload []byte) error
+}
+
+// JobQueue dispatches background jobs stored in a port.IQueueStore to worker pools
+type JobQueue struct {
+	mu       sync.RWMutex
+	config   config.QueueConfig
+	store    port.IQueueStore
+	clock    helper.Clock
+	handlers map[string]*jobHandler
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewJobQueue creates a stopped queue
+func NewJobQueue(cfg config.QueueConfig, store port.IQueueStore, clock helper.Clock) *JobQueue {
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = time.Second
+	}
+	if cfg.Lease <= 0 {
+		cfg.Lease = 10 * time.Minute
+	}
+
+	return &JobQueue{
+		config:   cfg,
+		store:    store,
+		clock:    clock,
+		handlers: make(map[string]*jobHandler),
+	}
+}
+
+// SetStore replaces the store holding the jobs, must be called before Start
+func (q *JobQueue) SetStore(store port.IQueueStore) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.store = store
+}
+
+// HandleJob registers the handler of a job type. The payload given to Enqueue is
+// JSON encoded and decoded back into T before the handler is called.
+func HandleJob[T any](q *JobQueue, jobType string, handler func(ctx context.Context, payload T) error, policy ...RetryPolicy) {
+	retry := q.defaultRetry()
+	if len(policy) > 0 {
+		retry = policy[0]
+	}
+	if retry.Name == "" {
+		retry.Name = "job:" + jobType
+	}
+	if retry.MaxAttempts <= 0 {
+		// the attempts are stored with the job, they need a limit
+		retry.MaxAttempts = q.config.MaxAttempts
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.handlers[jobType] = &jobHandler{
+		retry: retry,
+		run: func(ctx context.Context, payload []byte) error {
+			var data T
+			if err := helper.JSONUnmarshal(payload, &data); err != nil {
+				return fmt.Errorf("invalid payload: %v", err)
+			}
+			return handler(ctx, data)
+		},
+	}
+}
+
+// Enqueue stores a job and returns its ID
+func (q *JobQueue) Enqueue(ctx context.Context, jobType string, payload any, opts ...EnqueueOptions) (string, error) {
+	var opt EnqueueOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Queue == "" {
+		opt.Queue = "default"
+	}
+
+	data, err := helper.JSONMarshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	maxAttempts := opt.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = q.retryPolicy(jobType).MaxAttempts
+	}
+
+	now := q.clock.Now()
+	job := &port.QueueJob{
+		ID:          id,
+		Queue:       opt.Queue,
+		Type:        jobType,
+		Payload:     data,
+		Priority:    opt.Priority,
+		State:       port.JobStatePending,
+		MaxAttempts: maxAttempts,
+		RunAt:       now.Add(opt.Delay),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := q.getStore().Push(ctx, job); err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Start launches the worker pools configured per queue
+func (q *JobQueue) Start(ctx context.Context) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.cancel != nil {
+		return
+	}
+
+	var workerCtx context.Context
+	workerCtx, q.cancel = context.WithCancel(ctx)
+	for queue, workers := range q.config.Workers {
+		for i := 0; i < workers; i++ {
+			q.wg.Add(1)
+			go q.work(workerCtx, queue)
+		}
+	}
+
+	logger.Info("Job queue started", "workers", q.config.Workers)
+}
+
+// Stop cancels the workers and waits for the jobs in progress to return
+func (q *JobQueue) Stop() {
+	q.mu.Lock()
+	cancel := q.cancel
+	q.cancel = nil
+	q.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	q.wg.Wait()
+}
+
+// Stats returns the number of jobs per state for every configured queue
+func (q *JobQueue) Stats(ctx context.Context) (map[string]map[string]int64, error) {
+	queues := make([]string, 0, len(q.config.Workers))
+	for queue := range q.config.Workers {
+		queues = append(queues, queue)
+	}
+	sort.Strings(queues)
+
+	result := make(map[string]map[string]int64, len(queues))
+	for _, queue := range queues {
+		stats, err := q.getStore().Stats(ctx, queue)
+		if err != nil {
+			return nil, err
+		}
+		result[queue] = stats
+	}
+	return result, nil
+}
+
+// List returns jobs of a queue filtered by state (empty for every state)
+func (q *JobQueue) List(ctx context.Context, queue string, state string, limit int) ([]port.QueueJob, error) {
+	return q.getStore().List(ctx, queue, state, limit)
+}
+
+func (q *JobQueue) getStore() port.IQueueStore {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.store
+}
+
+func (q *JobQueue) getHandler(jobType string) (*jobHandler, bool) {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	handler, ok := q.handlers[jobType]
+	return handler, ok
+}
+
+func (q *JobQueue) retryPolicy(jobType string) RetryPolicy {
+	if handler, ok := q.getHandler(jobType); ok {
+		return handler.retry
+	}
+	return q.defaultRetry()
+}
+
+// defaultRetry is the policy of app.queue, for the job types registered without their own
+func (q *JobQueue) defaultRetry() RetryPolicy {
+	return RetryPolicy{MaxAttempts: q.config.MaxAttempts, Backoff: q.config.Backoff, MaxBackoff: q.config.MaxBackoff}
+}
+
+func (q *JobQueue) work(ctx context.Context, queue string) {
+	defer q.wg.Done()
+
+	for {
+		if ctx.Err() != nil {
+			return
+		}
+
+		job, err := q.getStore().Reserve(ctx, queue, q.clock.Now(), q.config.Lease)
+		if err != nil {
+			logger.Error("Failed to reserve job", "queue", queue, "error", err)
+		}
+
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.clock.After(q.config.PollInterval):
+			}
+			continue
+		}
+
+		q.process(ctx, job)
+	}
+}
+
+// process runs the job reserved with its attempt counted, a job reserved again after its
+// instance was lost has its lost attempts counted too. The lease of the job is renewed while
+// its handler runs, the outcome is only saved while the job holds its reservation.
+func (q *JobQueue) process(ctx context.Context, job *port.QueueJob) {
+	attempt := job.Attempts
+	var err error
+	handler, ok := q.getHandler(job.Type)
+	switch {
+	case job.Attempts > max(job.MaxAttempts, 1):
+		ok = false
+		job.Attempts--
+		err = fmt.Errorf("job lost with its instance after %d attempts", job.Attempts)
+	case !ok:
+		err = fmt.Errorf("no handler registered for job type '%s'", job.Type)
+	default:
+		jobCtx, cancel := context.WithCancel(ctx)
+		stop := q.heartbeat(jobCtx, job, cancel)
+		err = func() (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic: %v", r)
+				}
+			}()
+			return handler.run(context.WithValue(jobCtx, jobKey{}, job), job.Payload)
+		}()
+		stop()
+		lost := jobCtx.Err() != nil && ctx.Err() == nil
+		cancel()
+		if lost {
+			// the outcome belongs to the worker holding the reservation
+			return
+		}
+	}
+
+	now := q.clock.Now()
+	job.UpdatedAt = now
+	job.ReservedUntil = time.Time{}
+
+	switch {
+	case ok && err != nil && ctx.Err() != nil:
+		// stopped by Stop, the attempt is not counted and the job runs again at once
+		job.State = port.JobStatePending
+		job.Attempts--
+		job.RunAt = now
+		job.LastError = err.Error()
+		logger.Warn("Job interrupted by the queue stopping", "id", job.ID, "type", job.Type, "error", err)
+	case err == nil:
+		job.State = port.JobStateDone
+		job.LastError = ""
+	case ok && job.Attempts < job.MaxAttempts && q.retryAt(handler, job, err, now):
+		job.State = port.JobStatePending
+		job.LastError = err.Error()
+		logger.Warn("Job failed, retrying", "id", job.ID, "type", job.Type, "attempt", job.Attempts, "retry_at", job.RunAt, "error", err)
+	default:
+		job.State = port.JobStateFailed
+		job.LastError = err.Error()
+		logger.Error("Job failed", "id", job.ID, "type", job.Type, "attempts", job.Attempts, "error", err)
+	}
+
+	// the job outcome must be saved even when the queue is stopping
+	if err := q.getStore().Update(context.WithoutCancel(ctx), job, attempt); errors.Is(err, port.ErrJobLost) {
+		logger.Warn("Job outcome not saved, another worker reserved it", "id", job.ID, "type", job.Type, "attempt", attempt)
+	} else if err != nil {
+		logger.Error("Failed to update job", "id", job.ID, "error", err)
+	}
+}
+
+// heartbeat renews the lease of the job every third of app.queue.lease until the returned
+// function is called, lost is called when another worker reserved the job meanwhile
+func (q *JobQueue) heartbeat(ctx context.Context, job *port.QueueJob, lost func()) func() {
+	reservation := *job
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-q.clock.After(q.config.Lease / 3):
+			}
+
+			err := q.getStore().Renew(ctx, &reservation, q.clock.Now().Add(q.config.Lease))
+			if errors.Is(err, port.ErrJobLost) {
+				logger.Warn("Job reserved by another worker, its handler is cancelled", "id", job.ID, "type", job.Type)
+				lost()
+				return
+			}
+			if err != nil && ctx.Err() == nil {
+				logger.Warn("Failed to renew the lease of the job", "id", job.ID, "error", err)
+			}
+		}
+	}()
+
+	return func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+type jobKey struct{}
+
+// JobAttempt returns the attempt number (from 1) and the allowed attempts of the job
+// run by the handler receiving ctx, zeros outside of a job
+func JobAttempt(ctx context.Context) (int, int) {
+	if job, ok := ctx.Value(jobKey{}).(*port.QueueJob); ok {
+		return job.Attempts, job.MaxAttempts
+	}
+	return 0, 0
+}
+
+// retryAt schedules the next attempt of the failed job with the policy of its type, the
+// attempts of the job replacing the ones of the policy. It is false when the policy stops: the
+// error is permanent or its budget is spent.
+func (q *JobQueue) retryAt(handler *jobHandler, job *port.QueueJob, err error, now time.Time) bool {
+	policy := handler.retry
+	policy.MaxAttempts = job.MaxAttempts
+	delay, ok := policy.Next(job.Attempts, err)
+	if ok {
+		job.RunAt = now.Add(delay)
+	}
+	return ok
+}
+
+// MemoryQueueStore keeps jobs in process memory, jobs are lost on restart.
+// Finished jobs are only counted, not kept.
+type MemoryQueueStore struct {
+	mu   sync.Mutex
+	jobs map[string]*port.QueueJob
+	done map[string]int64 // queue -> finished jobs
+}
+
+// NewMemoryQueueStore creates an empty in-memory store
+func NewMemoryQueueStore() *MemoryQueueStore {
+	return &MemoryQueueStore{
+		jobs: make(map[string]*port.QueueJob),
+		done: make(map[string]int64),
+	}
+}
+
+func (m *MemoryQueueStore) Push(ctx context.Context, job *port.QueueJob) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *MemoryQueueStore) Reserve(ctx context.Context, queue string, now time.Time, lease time.Duration) (*port.QueueJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var found *port.QueueJob
+	for _, job := range m.jobs {
+		if job.Queue != queue || job.State != port.JobStatePending || job.RunAt.After(now) {
+			continue
+		}
+		if found == nil || job.Priority > found.Priority ||
+			(job.Priority == found.Priority && job.RunAt.Before(found.RunAt)) {
+			found = job
+		}
+	}
+
+	if found == nil {
+		for _, job := range m.jobs {
+			if job.Queue == queue && job.State == port.JobStateRunning && job.ReservedUntil.Before(now) {
+				found = job
+				break
+			}
+		}
+	}
+	if found == nil {
+		return nil, nil
+	}
+
+	found.State = port.JobStateRunning
+	found.Attempts++
+	found.ReservedUntil = now.Add(lease)
+	found.UpdatedAt = now
+	copied := *found
+	return &copied, nil
+}
+
+// held returns the job while it holds the reservation of attempt, m.mu must be locked
+func (m *MemoryQueueStore) held(job *port.QueueJob, attempt int) (*port.QueueJob, error) {
+	stored, ok := m.jobs[job.ID]
+	if !ok || stored.State != port.JobStateRunning || stored.Attempts != attempt {
+		return nil, port.ErrJobLost
+	}
+	return stored, nil
+}
+
+func (m *MemoryQueueStore) Renew(ctx context.Context, job *port.QueueJob, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.held(job, job.Attempts)
+	if err != nil {
+		return err
+	}
+	stored.ReservedUntil = until
+	job.ReservedUntil = until
+	return nil
+}
+
+func (m *MemoryQueueStore) Update(ctx context.Context, job *port.QueueJob, attempt int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, err := m.held(job, attempt); err != nil {
+		return err
+	}
+
+	if job.State == port.JobStateDone {
+		delete(m.jobs, job.ID)
+		m.done[job.Queue]++
+		return nil
+	}
+
+	copied := *job
+	m.jobs[job.ID] = &copied
+	return nil
+}
+
+func (m *MemoryQueueStore) Stats(ctx context.Context, queue string) (map[string]int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stats := map[string]int64{
+		port.JobStatePending: 0,
+		port.JobStateRunning: 0,
+		port.JobStateDone:    m.done[queue],
+		port.JobStateFailed:  0,
+	}
+	for _, job := range m.jobs {
+		if job.Queue == queue {
+			stats[job.State]++
+		}
+	}
+	return stats, nil
+}
+
+func (m *MemoryQueueStore) List(ctx context.Context, queue string, state string, limit int) ([]port.QueueJob, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []port.QueueJob{}
+	for _, job := range m.jobs {
+		if job.Queue == queue && (state == "" || job.State == state) {
+			result = append(result, *job)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].CreatedAt.Before(result[j].CreatedAt) })
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result, nil
+}
@@ -0,0 +1,375 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ErrQuotaExceeded is returned by Quotas.Consume when the quota of the feature is used up
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// Subjects of the feature quotas
+const (
+	QuotaPerTenant    = "tenant"
+	QuotaPerPrincipal = "principal"
+)
+
+// FeatureQuota is the consumption of a feature quota in its current period
+type FeatureQuota struct {
+	Feature   string    `json:"feature"`
+	Limit     int64     `json:"limit"`
+	Used      int64     `json:"used"`
+	Remaining int64     `json:"remaining"`
+	Reset     time.Time `json:"reset"`
+}
+
+// quotaCounter counts the units of the quotas by key
+type quotaCounter interface {
+	Increment(key string, delta int64, ttl time.Duration) (int64, error)
+}
+
+// Quotas limits the use of the features of app.quotas.features (ex: 100 reports a day) per
+// tenant or per principal. The routes of a feature take a unit per successful request, the
+// modules take units with Consume. The counters are kept in the cache of app.quotas.store,
+// shared by the instances, or in memory.
+type Quotas struct {
+	mu      sync.RWMutex
+	config  config.QuotasConfig
+	flags   *Flags
+	clock   helper.Clock
+	counter quotaCounter
+}
+
+func NewQuotas(cfg config.QuotasConfig, flags *Flags, clock helper.Clock) *Quotas {
+	return &Quotas{
+		config:  cfg,
+		flags:   flags,
+		clock:   clock,
+		counter: newLocalQuotaCounter(clock),
+	}
+}
+
+// Validate checks the windows, subjects and routes of the features
+func (q *Quotas) Validate() error {
+	for name, feature := range q.config.Features {
+		if feature.Window <= 0 {
+			return fmt.Errorf("app.quotas.features.%s: window is required", name)
+		}
+		if feature.Per != "" && feature.Per != QuotaPerTenant && feature.Per != QuotaPerPrincipal {
+			return fmt.Errorf("app.quotas.features.%s: per must be tenant or principal, got %q", name, feature.Per)
+		}
+		for _, route := range feature.Routes {
+			if method, path, ok := strings.Cut(route, " "); !ok || method == "" || !strings.HasPrefix(path, "/") {
+				return fmt.Errorf("app.quotas.features.%s: invalid route %q, expected \"METHOD /path\"", name, route)
+			}
+		}
+	}
+	return nil
+}
+
+// SetStore counts in a cache shared by the instances, atomically when it implements
+// port.ICacheCounter
+func (q *Quotas) SetStore(cache port.ICacheMemory) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if counter, ok := cache.(port.ICacheCounter); ok {
+		q.counter = counter
+	} else {
+		q.counter = &cacheQuotaCounter{cache: cache}
+	}
+}
+
+func (q *Quotas) getCounter() quotaCounter {
+	q.mu.RLock()
+	defer q.mu.RUnlock()
+	return q.counter
+}
+
+// quotaSubject is who a quota is counted for in the current period
+type quotaSubject struct {
+	feature string
+	limit   int64
+	key     string
+	reset   time.Time
+	window  time.Duration
+}
+
+// subject returns the quota of feature for the tenant and the principal of ctx, false when it
+// does not apply: unknown feature, no limit, no tenant or principal, or its flag is off
+func (q *Quotas) subject(ctx context.Context, feature string) (quotaSubject, bool) {
+	cfg, ok := q.config.Features[feature]
+	if !ok {
+		return quotaSubject{}, false
+	}
+
+	target := CurrentFlagTarget(ctx)
+	if cfg.Flag != "" && !q.flags.Evaluate(cfg.Flag, target) {
+		return quotaSubject{}, false
+	}
+
+	limit := cfg.Limit
+	tenant := helper.Tenant(ctx)
+	if tenant != nil {
+		if value, ok := tenant.Settings["quota."+feature]; ok {
+			if override, ok := quotaLimit(value); ok {
+				limit = override
+			} else {
+				logger.Warn("Quota setting of the tenant ignored", "tenant", tenant.ID, "feature", feature, "value", value)
+			}
+		}
+	}
+	if limit <= 0 {
+		return quotaSubject{}, false
+	}
+
+	var id string
+	switch cfg.Per {
+	case QuotaPerPrincipal:
+		id = target.Principal
+	default:
+		if tenant != nil {
+			id = tenant.ID
+		}
+	}
+	if id == "" {
+		return quotaSubject{}, false
+	}
+
+	start := q.clock.Now().UTC().Truncate(cfg.Window)
+	return quotaSubject{
+		feature: feature,
+		limit:   limit,
+		key:     fmt.Sprintf("quota:%s:%s:%d", feature, id, start.Unix()),
+		reset:   start.Add(cfg.Window),
+		window:  cfg.Window,
+	}, true
+}
+
+// quotaLimit reads the limit of a "quota.<feature>" setting of a tenant
+func quotaLimit(value any) (int64, bool) {
+	switch v := value.(type) {
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	case float64:
+		return int64(v), true
+	case string:
+		limit, err := strconv.ParseInt(v, 10, 64)
+		return limit, err == nil
+	}
+	return 0, false
+}
+
+func (q *Quotas) increment(subject quotaSubject, delta int64) (int64, error) {
+	// the counter outlives its period a little, the clocks of the instances differ
+	return q.getCounter().Increment(subject.key, delta, subject.window+time.Minute)
+}
+
+func (s quotaSubject) quota(used int64) FeatureQuota {
+	return FeatureQuota{
+		Feature:   s.feature,
+		Limit:     s.limit,
+		Used:      min(used, s.limit),
+		Remaining: max(s.limit-used, 0),
+		Reset:     s.reset,
+	}
+}
+
+// Consume takes n units of the quota of feature for the tenant or the principal of ctx, and
+// fails with ErrQuotaExceeded, without taking them, when fewer are left. A feature without
+// quota for ctx is not limited.
+func (q *Quotas) Consume(ctx context.Context, feature string, n int64) (FeatureQuota, error) {
+	subject, ok := q.subject(ctx, feature)
+	if !ok {
+		return FeatureQuota{Feature: feature}, nil
+	}
+
+	used, err := q.increment(subject, n)
+	if err != nil {
+		return FeatureQuota{Feature: feature}, err
+	}
+	if used > subject.limit {
+		used, err = q.increment(subject, -n)
+		if err != nil {
+			logger.Warn("Quota units not given back", "feature", feature, "error", err)
+		}
+		return subject.quota(used), fmt.Errorf("%s: %w", feature, ErrQuotaExceeded)
+	}
+	return subject.quota(used), nil
+}
+
+// Release gives back n units taken by Consume for work which did not happen
+func (q *Quotas) Release(ctx context.Context, feature string, n int64) error {
+	subject, ok := q.subject(ctx, feature)
+	if !ok {
+		return nil
+	}
+	_, err := q.increment(subject, -n)
+	return err
+}
+
+// Status returns the consumption of the features limited for the tenant or the principal of
+// ctx, by feature name
+func (q *Quotas) Status(ctx context.Context) ([]FeatureQuota, error) {
+	result := []FeatureQuota{}
+	for _, feature := range slices.Sorted(maps.Keys(q.config.Features)) {
+		subject, ok := q.subject(ctx, feature)
+		if !ok {
+			continue
+		}
+		used, err := q.increment(subject, 0)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, subject.quota(used))
+	}
+	return result, nil
+}
+
+// Middleware takes a unit of the features whose app.quotas routes match the request, given back
+// when the request fails. It follows the authentication and the tenancy.
+func (q *Quotas) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		for _, feature := range slices.Sorted(maps.Keys(q.config.Features)) {
+			if slices.ContainsFunc(q.config.Features[feature].Routes, func(route string) bool {
+				method, path, _ := strings.Cut(route, " ")
+				return method == c.Method() && matchRoutePath(path, c.Path())
+			}) {
+				return q.Handler(feature)(c)
+			}
+		}
+		return c.Next()
+	}
+}
+
+// Handler takes a unit of the quota of feature for the route it is added to, given back when
+// the request fails. The requests past the quota are answered 429 with the QUOTA_EXCEEDED error
+// and the consumption of the quota.
+func (q *Quotas) Handler(feature string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if CurrentFlagTarget(ctx).Principal == "" {
+			ctx = WithFlagTarget(ctx, requestFlagTarget(c, ""))
+		}
+
+		quota, err := q.Consume(ctx, feature, 1)
+		if errors.Is(err, ErrQuotaExceeded) {
+			setQuotaHeaders(c, quota)
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(int64(max(quota.Reset.Sub(q.clock.Now()), time.Second)/time.Second), 10))
+			response := out.Error(fiber.StatusTooManyRequests, 4, "QUOTA_EXCEEDED",
+				fmt.Sprintf("Quota of %s exceeded until %s", feature, quota.Reset.UTC().Format(time.RFC3339)))
+			response.Data = quota
+			return response
+		}
+		if err != nil {
+			// the requests are not refused because the cache is unavailable
+			logger.Warn("Quota not checked", "feature", feature, "error", err)
+			return c.Next()
+		}
+		if quota.Limit > 0 {
+			setQuotaHeaders(c, quota)
+		}
+
+		err = c.Next()
+		if quota.Limit > 0 && (err != nil || c.Response().StatusCode() >= fiber.StatusBadRequest) {
+			if err := q.Release(ctx, feature, 1); err != nil {
+				logger.Warn("Quota unit not given back", "feature", feature, "error", err)
+			}
+		}
+		return err
+	}
+}
+
+func setQuotaHeaders(c *fiber.Ctx, quota FeatureQuota) {
+	c.Set("X-Quota-Limit", strconv.FormatInt(quota.Limit, 10))
+	c.Set("X-Quota-Remaining", strconv.FormatInt(quota.Remaining, 10))
+	c.Set("X-Quota-Reset", quota.Reset.UTC().Format(time.RFC3339))
+}
+
+// StatusHandler serves the consumption of the quotas of the requester
+func (q *Quotas) StatusHandler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.UserContext()
+		if CurrentFlagTarget(ctx).Principal == "" {
+			ctx = WithFlagTarget(ctx, requestFlagTarget(c, ""))
+		}
+
+		quotas, err := q.Status(ctx)
+		if err != nil {
+			return err
+		}
+		return out.Send(c, out.SuccessData(quotas))
+	}
+}
+
+// localQuotaCounter counts in process memory, for a single instance
+type localQuotaCounter struct {
+	mu       sync.Mutex
+	clock    helper.Clock
+	counters map[string]localQuotaCount
+}
+
+type localQuotaCount struct {
+	value   int64
+	expires time.Time
+}
+
+func newLocalQuotaCounter(clock helper.Clock) *localQuotaCounter {
+	return &localQuotaCounter{clock: clock, counters: make(map[string]localQuotaCount)}
+}
+
+func (l *localQuotaCounter) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	count, ok := l.counters[key]
+	if !ok || !now.Before(count.expires) {
+		// the counters of the ended periods are dropped as the new ones are created
+		for k, c := range l.counters {
+			if !now.Before(c.expires) {
+				delete(l.counters, k)
+			}
+		}
+		count = localQuotaCount{expires: now.Add(ttl)}
+	}
+	count.value += delta
+	l.counters[key] = count
+	return count.value, nil
+}
+
+// cacheQuotaCounter counts with Get and Set in a cache without atomic counters, the instances
+// sharing a counter may lose a few units when they change it at the same time
+type cacheQuotaCounter struct {
+	mu    sync.Mutex
+	cache port.ICacheMemory
+}
+
+func (c *cacheQuotaCounter) Increment(key string, delta int64, ttl time.Duration) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var value int64
+	c.cache.Get(key, &value)
+	if delta == 0 {
+		return value, nil
+	}
+	value += delta
+	return value, c.cache.Set(key, value, ttl)
+}
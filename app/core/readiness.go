@@ -0,0 +1,45 @@
+package core
+
+import "sync"
+
+// Readiness tells whether the instance accepts traffic: not while starting, once the server
+// listens, and not anymore when it drains before stopping. It is served on /health/ready and
+// followed by the gRPC health service and the service discovery registration.
+type Readiness struct {
+	mu        sync.Mutex
+	ready     bool
+	listeners []func(ready bool)
+}
+
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// SetReady changes the state and calls the listeners when it changed
+func (r *Readiness) SetReady(ready bool) {
+	r.mu.Lock()
+	if r.ready == ready {
+		r.mu.Unlock()
+		return
+	}
+	r.ready = ready
+	listeners := append([]func(bool){}, r.listeners...)
+	r.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn(ready)
+	}
+}
+
+func (r *Readiness) Ready() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ready
+}
+
+// OnChange registers a listener called with the new state on every change
+func (r *Readiness) OnChange(fn func(ready bool)) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.listeners = append(r.listeners, fn)
+}
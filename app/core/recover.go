@@ -0,0 +1,55 @@
+package core
+
+import (
+	"fmt"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// RecoverMiddleware recovers the panics of the requests: it logs the panic with its stack,
+// reports it to ErrorReporter (else to the remote log) and answers a 500 out.ErrorTrace, whose
+// stack is shown in development
+func (a *AppContext) RecoverMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			r := recover()
+			if r == nil {
+				return
+			}
+
+			stack := string(debug.Stack())
+			panicErr, ok := r.(error)
+			if !ok {
+				panicErr = fmt.Errorf("%v", r)
+			}
+			c.Locals("StackTrace", stack)
+
+			requestID := helper.RequestID(c.UserContext())
+			logger.With("request_id", requestID).Error("Panic occurred", "method", c.Method(), "path", c.Path(), "error", panicErr, "stack", stack)
+
+			if a.ErrorReporter != nil {
+				a.ErrorReporter.ReportPanic(c.UserContext(), panicErr, stack, map[string]string{
+					"request_id": requestID,
+					"method":     c.Method(),
+					"path":       c.Path(),
+					"route":      c.Route().Path,
+				})
+			} else {
+				logger.RemoteCaptureError(panicErr)
+			}
+
+			response := out.ErrorTrace(fiber.StatusInternalServerError, 1, "INTERNAL_ERROR", "Internal server error", c)
+			if out.Environment == "development" {
+				details := panicErr.Error()
+				response.Details = &details
+			}
+			err = out.Send(c, response)
+		}()
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,391 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/mask"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ReportJobType is the job queue type used by Reporter.RenderAsync
+const ReportJobType = "report.render"
+
+var reportContentTypes = map[string]string{
+	port.ReportPDF:  "application/pdf",
+	port.ReportXLSX: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// ReportResult describes a report stored by a queued render
+type ReportResult struct {
+	ID     string `json:"id"`
+	Format string `json:"format"`
+	Key    string `json:"key"` // object storage key
+	Size   int64  `json:"size"`
+}
+
+type reportJob struct {
+	ID     string      `json:"id"`
+	Format string      `json:"format"`
+	Key    string      `json:"key"`
+	Report port.Report `json:"report"`
+}
+
+// Reporter renders reports with the configured PDF and spreadsheet drivers, directly,
+// as a download or through the job queue into the object storage
+type Reporter struct {
+	config   config.ReportConfig
+	pdf      port.IPDFRenderer
+	xlsx     port.ISpreadsheetRenderer
+	renderer port.ITemplateRenderer
+	storage  port.IObjectStorage
+	queue    *JobQueue
+	ready    []func(ctx context.Context, result ReportResult)
+}
+
+// NewReporter creates a reporter without drivers and registers its render job on queue
+func NewReporter(cfg config.ReportConfig, queue *JobQueue) *Reporter {
+	r := &Reporter{
+		config: cfg,
+		queue:  queue,
+	}
+
+	HandleJob(queue, ReportJobType, func(ctx context.Context, job reportJob) error {
+		return r.store(ctx, &job)
+	})
+
+	return r
+}
+
+// SetPDFDriver sets the library converting HTML into PDF
+func (r *Reporter) SetPDFDriver(driver port.IPDFRenderer) {
+	r.pdf = driver
+}
+
+// SetXLSXDriver sets the library writing workbooks
+func (r *Reporter) SetXLSXDriver(driver port.ISpreadsheetRenderer) {
+	r.xlsx = driver
+}
+
+// SetRenderer sets the template engine used for Report.Template
+func (r *Reporter) SetRenderer(renderer port.ITemplateRenderer) {
+	r.renderer = renderer
+}
+
+// SetStorage sets where RenderAsync stores the reports
+func (r *Reporter) SetStorage(storage port.IObjectStorage) {
+	r.storage = storage
+}
+
+// OnReady registers a callback run after a queued report is stored (ex: to email a link)
+func (r *Reporter) OnReady(fn func(ctx context.Context, result ReportResult)) {
+	r.ready = append(r.ready, fn)
+}
+
+// RenderPDF renders the template of report and converts it into PDF
+func (r *Reporter) RenderPDF(ctx context.Context, report *port.Report, w io.Writer) error {
+	if err := r.prepare(port.ReportPDF, report); err != nil {
+		return err
+	}
+	return r.render(ctx, port.ReportPDF, report, w)
+}
+
+// RenderXLSX writes the sheets of report as an xlsx workbook
+func (r *Reporter) RenderXLSX(ctx context.Context, report *port.Report, w io.Writer) error {
+	if err := r.prepare(port.ReportXLSX, report); err != nil {
+		return err
+	}
+	return r.render(ctx, port.ReportXLSX, report, w)
+}
+
+// Download renders report and sends it as an attachment named filename
+func (r *Reporter) Download(c *fiber.Ctx, format string, filename string, report *port.Report) error {
+	if err := r.prepare(format, report); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	if err := r.render(c.UserContext(), format, report, &buf); err != nil {
+		return err
+	}
+
+	if !strings.HasSuffix(strings.ToLower(filename), "."+format) {
+		filename += "." + format
+	}
+	c.Set(fiber.HeaderContentType, reportContentTypes[format])
+	c.Attachment(filename)
+	return c.Send(buf.Bytes())
+}
+
+// RenderAsync renders the template of report now and queues the conversion, the report
+// is stored under the returned key once the job ran. Failures are retried by the job queue.
+func (r *Reporter) RenderAsync(ctx context.Context, format string, report *port.Report, opts ...EnqueueOptions) (*ReportResult, error) {
+	if r.storage == nil {
+		return nil, fmt.Errorf("report cannot be queued, no object storage configured")
+	}
+	if err := r.prepare(format, report); err != nil {
+		return nil, err
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	job := reportJob{
+		ID:     hex.EncodeToString(id),
+		Format: format,
+		Report: *report,
+	}
+	job.Key = strings.TrimSuffix(r.config.Prefix, "/") + "/" + job.ID + "." + format
+
+	opt := EnqueueOptions{Queue: r.config.Queue}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if _, err := r.queue.Enqueue(ctx, ReportJobType, job, opt); err != nil {
+		return nil, err
+	}
+
+	return &ReportResult{ID: job.ID, Format: format, Key: job.Key}, nil
+}
+
+func (r *Reporter) store(ctx context.Context, job *reportJob) error {
+	if r.storage == nil {
+		return fmt.Errorf("report cannot be stored, no object storage configured")
+	}
+
+	var buf bytes.Buffer
+	if err := r.render(ctx, job.Format, &job.Report, &buf); err != nil {
+		return err
+	}
+
+	info, err := r.storage.Put(ctx, job.Key, &buf, port.PutOptions{
+		ContentType: reportContentTypes[job.Format],
+		Size:        int64(buf.Len()),
+	})
+	if err != nil {
+		return err
+	}
+
+	result := ReportResult{ID: job.ID, Format: job.Format, Key: job.Key, Size: info.Size}
+	for _, fn := range r.ready {
+		fn(ctx, result)
+	}
+	return nil
+}
+
+func (r *Reporter) render(ctx context.Context, format string, report *port.Report, w io.Writer) error {
+	switch format {
+	case port.ReportPDF:
+		if r.pdf == nil {
+			return fmt.Errorf("PDF reports are not configured")
+		}
+		if r.config.Timeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, r.config.Timeout)
+			defer cancel()
+		}
+		return r.pdf.RenderPDF(ctx, report.HTML, report.PDF, w)
+	case port.ReportXLSX:
+		if r.xlsx == nil {
+			return fmt.Errorf("XLSX reports are not configured")
+		}
+		return r.xlsx.RenderXLSX(ctx, report.Sheets, w)
+	}
+	return fmt.Errorf("unknown report format '%s'", format)
+}
+
+// prepare renders the template and builds the sheets, so queued reports carry plain content
+func (r *Reporter) prepare(format string, report *port.Report) error {
+	switch format {
+	case port.ReportPDF:
+		if report.PDF.PageSize == "" {
+			report.PDF.PageSize = r.config.PageSize
+		}
+		if report.HTML == "" {
+			if report.Template == "" {
+				return fmt.Errorf("report has neither template nor HTML")
+			}
+			if r.renderer == nil {
+				return fmt.Errorf("report template '%s' cannot be rendered, no template renderer", report.Template)
+			}
+
+			var buf bytes.Buffer
+			if err := r.renderer.Render(&buf, report.Template, report.Data); err != nil {
+				return fmt.Errorf("report template '%s': %v", report.Template, err)
+			}
+			report.HTML = buf.String()
+		}
+	case port.ReportXLSX:
+		if len(report.Sheets) == 0 {
+			sheet, err := ReportSheetOf("Sheet1", report.Data)
+			if err != nil {
+				return err
+			}
+			report.Sheets = []port.ReportSheet{*sheet}
+		}
+	default:
+		return fmt.Errorf("unknown report format '%s'", format)
+	}
+
+	report.Template = ""
+	report.Data = nil
+	return nil
+}
+
+// ReportSheetOf builds a sheet from a slice of structs or maps. Struct fields are titled
+// by their report tag (`report:"Title"`, "-" to skip), json tag or name; map keys are sorted.
+func ReportSheetOf(name string, rows any) (*port.ReportSheet, error) {
+	v := reflect.ValueOf(rows)
+	for v.Kind() == reflect.Pointer {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return nil, fmt.Errorf("report data must be a slice, got %T", rows)
+	}
+
+	sheet := &port.ReportSheet{Name: name, Rows: make([][]any, 0, v.Len())}
+
+	elem := v.Type().Elem()
+	for elem.Kind() == reflect.Pointer {
+		elem = elem.Elem()
+	}
+
+	switch elem.Kind() {
+	case reflect.Struct:
+		fields := reportFields(elem)
+		for _, field := range fields {
+			sheet.Columns = append(sheet.Columns, port.ReportColumn{Title: field.title})
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			sheet.Rows = append(sheet.Rows, reportRow(fields, v.Index(i)))
+		}
+	case reflect.Map:
+		keys := map[string]bool{}
+		for i := 0; i < v.Len(); i++ {
+			for _, key := range reflect.Indirect(v.Index(i)).MapKeys() {
+				keys[fmt.Sprint(key.Interface())] = true
+			}
+		}
+		titles := make([]string, 0, len(keys))
+		for key := range keys {
+			titles = append(titles, key)
+		}
+		sort.Strings(titles)
+		for _, title := range titles {
+			sheet.Columns = append(sheet.Columns, port.ReportColumn{Title: title})
+		}
+
+		for i := 0; i < v.Len(); i++ {
+			item := reflect.Indirect(v.Index(i))
+			row := make([]any, len(titles))
+			for j, title := range titles {
+				if value := item.MapIndex(reflect.ValueOf(title).Convert(item.Type().Key())); value.IsValid() {
+					row[j] = reportCell(value)
+				}
+			}
+			sheet.Rows = append(sheet.Rows, row)
+		}
+	default:
+		return nil, fmt.Errorf("report rows must be structs or maps, got %s", elem)
+	}
+
+	return sheet, nil
+}
+
+type reportField struct {
+	title string
+	name  string // name of the struct field
+	mask  string // mask tag of the field
+	index []int
+	typ   reflect.Type
+}
+
+// reportFields returns the titled fields of a struct type, in declaration order
+func reportFields(t reflect.Type) []reportField {
+	fields := []reportField{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		if title := reportTitle(field); title != "" {
+			fields = append(fields, reportField{title: title, name: field.Name, mask: field.Tag.Get(mask.Tag), index: field.Index, typ: field.Type})
+		}
+	}
+	return fields
+}
+
+// reportRow returns the cells of fields of a struct, or of a pointer to it
+func reportRow(fields []reportField, item reflect.Value) []any {
+	item = reflect.Indirect(item)
+	row := make([]any, len(fields))
+	if item.IsValid() {
+		for i, field := range fields {
+			if value, err := item.FieldByIndexErr(field.index); err == nil {
+				row[i] = reportCell(value)
+			}
+		}
+	}
+	return row
+}
+
+func reportTitle(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("report"); ok {
+		if tag == "-" {
+			return ""
+		}
+		return tag
+	}
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// reportCell converts a value into a string, number, boolean, time.Time or nil
+func reportCell(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	if t, ok := v.Interface().(time.Time); ok {
+		return t
+	}
+	if stringer, ok := v.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	switch {
+	case v.CanInt():
+		return v.Int()
+	case v.CanUint():
+		return v.Uint()
+	case v.CanFloat():
+		return v.Float()
+	case v.Kind() == reflect.Bool:
+		return v.Bool()
+	case v.Kind() == reflect.String:
+		return v.String()
+	}
+	return fmt.Sprint(v.Interface())
+}
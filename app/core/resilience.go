@@ -0,0 +1,621 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ErrBulkheadFull is returned when every slot of a bulkhead stayed busy during its max_wait
+var ErrBulkheadFull = errors.New("bulkhead is full")
+
+// ErrTimeout is returned when a call of a policy runs longer than its timeout
+var ErrTimeout = errors.New("call timed out")
+
+// EventBreakerStateChanged is published on the EventBus with a BreakerStateChange when a
+// circuit breaker opens, lets a trial call through or closes again
+const EventBreakerStateChanged = "breaker.state_changed"
+
+// States of a CircuitBreaker
+const (
+	BreakerClosed   = "closed"
+	BreakerOpen     = "open"
+	BreakerHalfOpen = "half-open"
+)
+
+// BreakerStateChange is the data of EventBreakerStateChanged
+type BreakerStateChange struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ResilienceStats are the counters of a policy or of a circuit breaker used on its own
+type ResilienceStats struct {
+	Name     string `json:"name"`
+	Breaker  string `json:"breaker,omitempty"` // state of the circuit breaker
+	Calls    int64  `json:"calls"`
+	Failures int64  `json:"failures"`
+	Rejected int64  `json:"rejected"`  // short-circuited while the breaker was open
+	Full     int64  `json:"full"`      // refused by the bulkhead
+	TimedOut int64  `json:"timed_out"` // cut by the timeout
+	Active   int64  `json:"active"`    // calls running in the bulkhead
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// CircuitBreaker opens after threshold consecutive failures, then lets a single trial call
+// through once the cooldown elapsed (half-open): its success closes the circuit, its failure
+// opens it again.
+type CircuitBreaker struct {
+	Name   string
+	Config config.BreakerConfig
+	clock  helper.Clock
+	bus    *EventBus
+
+	mu       sync.Mutex
+	current  string
+	failures int
+	openedAt time.Time
+	trial    bool
+
+	calls    atomic.Int64
+	failed   atomic.Int64
+	rejected atomic.Int64
+}
+
+// NewCircuitBreaker creates a closed circuit breaker, bus may be nil
+func NewCircuitBreaker(name string, cfg config.BreakerConfig, clock helper.Clock, bus *EventBus) *CircuitBreaker {
+	if cfg.Threshold == 0 {
+		cfg.Threshold = 5
+	}
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = 30 * time.Second
+	}
+	return &CircuitBreaker{Name: name, Config: cfg, clock: clock, bus: bus, current: BreakerClosed}
+}
+
+// Allow reports whether a call may be made, a call allowed must be followed by Success,
+// Failure or Abort
+func (b *CircuitBreaker) Allow() bool {
+	b.mu.Lock()
+	if b.Config.Threshold < 0 || b.openedAt.IsZero() {
+		b.mu.Unlock()
+		b.calls.Add(1)
+		return true
+	}
+	if b.trial || b.clock.Since(b.openedAt) < b.Config.Cooldown {
+		b.mu.Unlock()
+		b.rejected.Add(1)
+		return false
+	}
+
+	b.trial = true
+	from := b.set(BreakerHalfOpen)
+	b.mu.Unlock()
+
+	b.calls.Add(1)
+	b.notify(from, BreakerHalfOpen)
+	return true
+}
+
+// Success records a successful call, it closes the circuit
+func (b *CircuitBreaker) Success() {
+	b.mu.Lock()
+	b.failures = 0
+	b.openedAt = time.Time{}
+	b.trial = false
+	from := b.set(BreakerClosed)
+	b.mu.Unlock()
+
+	b.notify(from, BreakerClosed)
+}
+
+// Failure records a failed call and reports whether it opened the circuit
+func (b *CircuitBreaker) Failure() bool {
+	b.failed.Add(1)
+
+	b.mu.Lock()
+	if b.Config.Threshold < 0 {
+		b.mu.Unlock()
+		return false
+	}
+
+	b.failures++
+	if !b.trial && (!b.openedAt.IsZero() || b.failures < b.Config.Threshold) {
+		b.mu.Unlock()
+		return false
+	}
+
+	b.openedAt = b.clock.Now()
+	b.trial = false
+	from := b.set(BreakerOpen)
+	b.mu.Unlock()
+
+	logger.Warn("Circuit breaker opened", "name", b.Name, "cooldown", b.Config.Cooldown)
+	b.notify(from, BreakerOpen)
+	return true
+}
+
+// Abort releases the trial of a half-open circuit without deciding its state, for the calls
+// cancelled by their caller which say nothing about the dependency
+func (b *CircuitBreaker) Abort() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+// State returns closed, open or half-open
+func (b *CircuitBreaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case b.openedAt.IsZero():
+		return BreakerClosed
+	case b.trial || b.clock.Since(b.openedAt) >= b.Config.Cooldown:
+		return BreakerHalfOpen
+	default:
+		return BreakerOpen
+	}
+}
+
+// Execute calls fn unless the circuit is open, it fails with ErrCircuitOpen then
+func (b *CircuitBreaker) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if !b.Allow() {
+		return fmt.Errorf("%s: %w", b.Name, ErrCircuitOpen)
+	}
+
+	err := fn(ctx)
+	b.Record(ctx, err)
+	return err
+}
+
+// Record decides the state of the circuit with the result of an allowed call
+func (b *CircuitBreaker) Record(ctx context.Context, err error) {
+	switch {
+	case err == nil:
+		b.Success()
+	case ctx.Err() != nil && !errors.Is(err, ErrTimeout):
+		b.Abort()
+	default:
+		b.Failure()
+	}
+}
+
+// Stats returns the counters of the breaker
+func (b *CircuitBreaker) Stats() ResilienceStats {
+	return ResilienceStats{
+		Name:     b.Name,
+		Breaker:  b.State(),
+		Calls:    b.calls.Load(),
+		Failures: b.failed.Load(),
+		Rejected: b.rejected.Load(),
+	}
+}
+
+// set changes the state and returns the previous one, b.mu must be held
+func (b *CircuitBreaker) set(state string) string {
+	from := b.current
+	b.current = state
+	return from
+}
+
+func (b *CircuitBreaker) notify(from string, to string) {
+	if from == to || b.bus == nil {
+		return
+	}
+	b.bus.Publish(EventBreakerStateChanged, BreakerStateChange{Name: b.Name, From: from, To: to})
+}
+
+// Bulkhead limits the calls to a dependency running at once, so a slow dependency holds a
+// bounded number of goroutines and connections instead of all of them
+type Bulkhead struct {
+	Name   string
+	Config config.BulkheadConfig
+	clock  helper.Clock
+	slots  chan struct{}
+	full   atomic.Int64
+}
+
+// NewBulkhead creates a bulkhead of cfg.MaxConcurrent slots
+func NewBulkhead(name string, cfg config.BulkheadConfig, clock helper.Clock) *Bulkhead {
+	return &Bulkhead{Name: name, Config: cfg, clock: clock, slots: make(chan struct{}, cfg.MaxConcurrent)}
+}
+
+// Acquire takes a slot, waiting at most max_wait for one, it must be released with Release
+func (b *Bulkhead) Acquire(ctx context.Context) error {
+	select {
+	case b.slots <- struct{}{}:
+		return nil
+	default:
+	}
+
+	if b.Config.MaxWait > 0 {
+		select {
+		case b.slots <- struct{}{}:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-b.clock.After(b.Config.MaxWait):
+		}
+	}
+
+	b.full.Add(1)
+	return fmt.Errorf("%s: %w", b.Name, ErrBulkheadFull)
+}
+
+// Release frees the slot taken by Acquire
+func (b *Bulkhead) Release() {
+	<-b.slots
+}
+
+// Execute calls fn in a slot of the bulkhead
+func (b *Bulkhead) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if err := b.Acquire(ctx); err != nil {
+		return err
+	}
+	defer b.Release()
+	return fn(ctx)
+}
+
+// Stats returns the counters of the bulkhead
+func (b *Bulkhead) Stats() ResilienceStats {
+	return ResilienceStats{
+		Name:   b.Name,
+		Full:   b.full.Load(),
+		Active: int64(len(b.slots)),
+		Limit:  b.Config.MaxConcurrent,
+	}
+}
+
+// WithTimeout calls fn with a context cancelled after timeout (no limit when 0) and fails with
+// ErrTimeout when fn returned an error because of it. fn must honor the context.
+func WithTimeout(ctx context.Context, timeout time.Duration, fn func(ctx context.Context) error) error {
+	if timeout <= 0 {
+		return fn(ctx)
+	}
+
+	ctx, cancel := context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+	defer cancel()
+
+	err := fn(ctx)
+	if err != nil && context.Cause(ctx) == ErrTimeout && !errors.Is(err, ErrTimeout) {
+		return fmt.Errorf("%w: %v", ErrTimeout, err)
+	}
+	return err
+}
+
+// Guard runs the calls to a dependency: a Policy, or the faults of a ChaosTarget
+type Guard interface {
+	Execute(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+// Policy guards the calls to a dependency with a circuit breaker, a bulkhead and a timeout,
+// each one optional. Get them with AppContext.Resilience.Policy:
+//
+//	policy := m.context.Resilience.Policy("inventory")
+//	err := policy.Execute(ctx, func(ctx context.Context) error { return client.Reserve(ctx, item) })
+type Policy struct {
+	Name     string
+	Breaker  *CircuitBreaker // nil when disabled
+	Bulkhead *Bulkhead       // nil without concurrency limit
+	Timeout  time.Duration
+	timedOut atomic.Int64
+}
+
+// Execute calls fn through the bulkhead, the circuit breaker and the timeout of the policy.
+// Every error of fn is a failure of the dependency, except the cancellation of ctx by the
+// caller.
+func (p *Policy) Execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	if p.Breaker != nil && !p.Breaker.Allow() {
+		return fmt.Errorf("%s: %w", p.Name, ErrCircuitOpen)
+	}
+
+	if p.Bulkhead != nil {
+		if err := p.Bulkhead.Acquire(ctx); err != nil {
+			if p.Breaker != nil {
+				// the dependency was not called
+				p.Breaker.Abort()
+			}
+			return err
+		}
+		defer p.Bulkhead.Release()
+	}
+
+	err := WithTimeout(ctx, p.Timeout, fn)
+	if errors.Is(err, ErrTimeout) {
+		p.timedOut.Add(1)
+	}
+	if p.Breaker != nil {
+		p.Breaker.Record(ctx, err)
+	}
+	return err
+}
+
+// Stats returns the counters of the policy
+func (p *Policy) Stats() ResilienceStats {
+	stats := ResilienceStats{Name: p.Name, TimedOut: p.timedOut.Load()}
+	if p.Breaker != nil {
+		breaker := p.Breaker.Stats()
+		stats.Breaker = breaker.Breaker
+		stats.Calls = breaker.Calls
+		stats.Failures = breaker.Failures
+		stats.Rejected = breaker.Rejected
+	}
+	if p.Bulkhead != nil {
+		bulkhead := p.Bulkhead.Stats()
+		stats.Full = bulkhead.Full
+		stats.Active = bulkhead.Active
+		stats.Limit = bulkhead.Limit
+	}
+	return stats
+}
+
+// Call is Guard.Execute for the functions returning a value
+func Call[T any](ctx context.Context, p Guard, fn func(ctx context.Context) (T, error)) (T, error) {
+	var result T
+	err := p.Execute(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = fn(ctx)
+		return err
+	})
+	return result, err
+}
+
+// Resilience holds the policies of app.resilience.policies and the circuit breakers of the
+// HTTP clients, GET {admin}/resilience lists their counters
+type Resilience struct {
+	mu       sync.Mutex
+	config   config.ResilienceConfig
+	bus      *EventBus
+	clock    helper.Clock
+	policies map[string]*Policy
+	breakers map[string]*CircuitBreaker
+}
+
+// NewResilience creates the registry of the policies of cfg
+func NewResilience(cfg config.ResilienceConfig, bus *EventBus, clock helper.Clock) *Resilience {
+	return &Resilience{
+		config:   cfg,
+		bus:      bus,
+		clock:    clock,
+		policies: make(map[string]*Policy),
+		breakers: make(map[string]*CircuitBreaker),
+	}
+}
+
+// Policy returns the policy of app.resilience.policies.<name>, created on first use. A policy
+// which is not configured only has a circuit breaker with the default threshold and cooldown.
+func (r *Resilience) Policy(name string) *Policy {
+	return r.policyOf(name, r.config.Policies[name])
+}
+
+func (r *Resilience) policyOf(name string, cfg config.ResiliencePolicyConfig) *Policy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if policy, ok := r.policies[name]; ok {
+		return policy
+	}
+
+	policy := &Policy{Name: name, Timeout: cfg.Timeout}
+	if cfg.Breaker.Threshold >= 0 {
+		policy.Breaker = NewCircuitBreaker(name, cfg.Breaker, r.clock, r.bus)
+	}
+	if cfg.Bulkhead.MaxConcurrent > 0 {
+		policy.Bulkhead = NewBulkhead(name, cfg.Bulkhead, r.clock)
+	}
+
+	r.policies[name] = policy
+	return policy
+}
+
+// CircuitBreaker returns the breaker called name, created with cfg on first use
+func (r *Resilience) CircuitBreaker(name string, cfg config.BreakerConfig) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if breaker, ok := r.breakers[name]; ok {
+		return breaker
+	}
+
+	breaker := NewCircuitBreaker(name, cfg, r.clock, r.bus)
+	r.breakers[name] = breaker
+	return breaker
+}
+
+// Stats returns the counters of the policies and breakers created so far, by name
+func (r *Resilience) Stats() []ResilienceStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	result := make([]ResilienceStats, 0, len(r.policies)+len(r.breakers))
+	for _, policy := range r.policies {
+		result = append(result, policy.Stats())
+	}
+	for _, breaker := range r.breakers {
+		result = append(result, breaker.Stats())
+	}
+	slices.SortFunc(result, func(a, b ResilienceStats) int { return strings.Compare(a.Name, b.Name) })
+	return result
+}
+
+// GuardDatabase returns db whose queries go through policy. Missing rows and duplicate keys
+// are answers of the database, they do not count as failures.
+func GuardDatabase(db port.IDatabase, policy Guard) port.IDatabase {
+	guarded := &guardedDatabase{IDatabase: db, policy: policy}
+	if _, ok := db.(port.IDatabaseExec); ok {
+		return &guardedExecDatabase{guarded}
+	}
+	return guarded
+}
+
+type guardedDatabase struct {
+	port.IDatabase
+	policy Guard
+}
+
+// execute runs fn through the policy and returns its error
+func (d *guardedDatabase) execute(ctx context.Context, fn func(ctx context.Context) error) error {
+	var result error
+	err := d.policy.Execute(ctx, func(ctx context.Context) error {
+		result = fn(ctx)
+		if errors.Is(result, port.ErrRecordNotFound) || errors.Is(result, port.ErrDuplicateKey) {
+			return nil
+		}
+		return result
+	})
+	if err != nil {
+		return err
+	}
+	return result
+}
+
+func (d *guardedDatabase) Ping(ctx context.Context) error {
+	return d.execute(ctx, d.IDatabase.Ping)
+}
+
+func (d *guardedDatabase) Count(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Count(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+func (d *guardedDatabase) Find(ctx context.Context, results any, table string, column []string, filter []port.DbExpression, sort map[string]int, limit int64, skip int64) error {
+	return d.execute(ctx, func(ctx context.Context) error {
+		return d.IDatabase.Find(ctx, results, table, column, filter, sort, limit, skip)
+	})
+}
+
+func (d *guardedDatabase) FindOne(ctx context.Context, result any, table string, column []string, filter []port.DbExpression, sort map[string]int) error {
+	return d.execute(ctx, func(ctx context.Context) error {
+		return d.IDatabase.FindOne(ctx, result, table, column, filter, sort)
+	})
+}
+
+func (d *guardedDatabase) InsertOne(ctx context.Context, table string, data any) (id any, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		id, err = d.IDatabase.InsertOne(ctx, table, data)
+		return err
+	})
+	return id, err
+}
+
+func (d *guardedDatabase) Update(ctx context.Context, table string, filter []port.DbExpression, data any) (count int64, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Update(ctx, table, filter, data)
+		return err
+	})
+	return count, err
+}
+
+func (d *guardedDatabase) UpdateOne(ctx context.Context, table string, filter []port.DbExpression, data any) (count int64, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.UpdateOne(ctx, table, filter, data)
+		return err
+	})
+	return count, err
+}
+
+func (d *guardedDatabase) Delete(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Delete(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+func (d *guardedDatabase) DeleteOne(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.DeleteOne(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+// FindCursor opens the cursor through the policy, the rows are read without it
+func (d *guardedDatabase) FindCursor(ctx context.Context, table string, column []string, filter []port.DbExpression, sort map[string]int) (cursor port.DbCursor, err error) {
+	err = d.execute(ctx, func(ctx context.Context) (err error) {
+		cursor, err = helper.FindCursor(ctx, d.IDatabase, table, column, filter, sort)
+		return err
+	})
+	return cursor, err
+}
+
+// guardedExecDatabase keeps IDatabaseExec of the SQL databases
+type guardedExecDatabase struct {
+	*guardedDatabase
+}
+
+func (d *guardedExecDatabase) Exec(ctx context.Context, statement string) error {
+	return d.execute(ctx, func(ctx context.Context) error {
+		return d.IDatabase.(port.IDatabaseExec).Exec(ctx, statement)
+	})
+}
+
+// GuardPubSub returns pubsub whose publications go through policy
+func GuardPubSub(pubsub port.IPubSub, policy Guard) port.IPubSub {
+	return &guardedPubSub{IPubSub: pubsub, policy: policy}
+}
+
+type guardedPubSub struct {
+	port.IPubSub
+	policy Guard
+}
+
+func (p *guardedPubSub) Publish(ctx context.Context, message any, attributes map[string]string) (string, error) {
+	return Call(ctx, p.policy, func(ctx context.Context) (string, error) {
+		return p.IPubSub.Publish(ctx, message, attributes)
+	})
+}
+
+// GuardKafka returns kafka whose publications go through policy
+func GuardKafka(kafka port.IKafka, policy Guard) port.IKafka {
+	return &guardedKafka{IKafka: kafka, policy: policy}
+}
+
+type guardedKafka struct {
+	port.IKafka
+	policy Guard
+}
+
+func (k *guardedKafka) Publish(ctx context.Context, topic string, message any) error {
+	return k.policy.Execute(ctx, func(ctx context.Context) error {
+		return k.IKafka.Publish(ctx, topic, message)
+	})
+}
+
+// GuardHubBroker returns broker whose publications go through policy, for Hub.SetBroker
+func GuardHubBroker(broker port.IHubBroker, policy Guard) port.IHubBroker {
+	guarded := &guardedHubBroker{IHubBroker: broker, policy: policy}
+	if presence, ok := broker.(port.IHubPresence); ok {
+		return struct {
+			*guardedHubBroker
+			port.IHubPresence
+		}{guarded, presence}
+	}
+	return guarded
+}
+
+type guardedHubBroker struct {
+	port.IHubBroker
+	policy Guard
+}
+
+func (b *guardedHubBroker) Publish(ctx context.Context, topic string, message []byte) error {
+	return b.policy.Execute(ctx, func(ctx context.Context) error {
+		return b.IHubBroker.Publish(ctx, topic, message)
+	})
+}
@@ -0,0 +1,274 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// EventRetentionPurged is published on the EventBus with the RetentionReport of every purge
+const EventRetentionPurged = "retention.purged"
+
+// RetentionRule declares how long the rows of a table (or documents of a collection) are kept
+type RetentionRule struct {
+	Name     string
+	Table    string
+	Column   string              // time column compared to the age (ex: "created_at")
+	MaxAge   time.Duration       // rows older than this are deleted
+	Filter   []port.DbExpression // optional condition narrowing the expired rows (ex: only closed accounts)
+	Key      string              // column identifying a row, "id" when empty
+	Database port.IDatabase      // nil uses the default database library
+}
+
+// RetentionReport is the audit record of a purge of a rule
+type RetentionReport struct {
+	Rule     string    `json:"rule"`
+	Table    string    `json:"table"`
+	Cutoff   time.Time `json:"cutoff"`
+	DryRun   bool      `json:"dry_run"`
+	Matched  int64     `json:"matched"` // expired rows found, only counted on dry runs
+	Deleted  int64     `json:"deleted"`
+	Batches  int       `json:"batches"`
+	Complete bool      `json:"complete"` // false when max_deletes stopped the purge before all the expired rows were deleted
+	Started  time.Time `json:"started"`
+	Duration string    `json:"duration"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Retention purges the expired rows of the rules declared by the modules, in batches
+// separated by a pause so the purge does not compete with the traffic
+type Retention struct {
+	mu       sync.Mutex
+	config   config.RetentionConfig
+	clock    helper.Clock
+	bus      *EventBus
+	database port.IDatabase
+	rules    map[string]RetentionRule
+	reports  []RetentionReport
+}
+
+func NewRetention(cfg config.RetentionConfig, bus *EventBus, clock helper.Clock) *Retention {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 1000
+	}
+	if cfg.History <= 0 {
+		cfg.History = 100
+	}
+
+	return &Retention{
+		config: cfg,
+		clock:  clock,
+		bus:    bus,
+		rules:  make(map[string]RetentionRule),
+	}
+}
+
+// SetDatabase sets the database of the rules without their own
+func (r *Retention) SetDatabase(db port.IDatabase) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.database = db
+}
+
+// Register adds a rule, usually from the Init of a module
+func (r *Retention) Register(rule RetentionRule) error {
+	if rule.Name == "" {
+		return fmt.Errorf("retention rule name is required")
+	}
+	if rule.Table == "" || rule.Column == "" {
+		return fmt.Errorf("retention rule '%s' needs a table and a time column", rule.Name)
+	}
+	if rule.MaxAge <= 0 {
+		return fmt.Errorf("retention rule '%s' needs a positive max age", rule.Name)
+	}
+	if rule.Key == "" {
+		rule.Key = "id"
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, exists := r.rules[rule.Name]; exists {
+		return fmt.Errorf("retention rule '%s' already registered", rule.Name)
+	}
+	r.rules[rule.Name] = rule
+	return nil
+}
+
+// Rules returns the registered rules sorted by name
+func (r *Retention) Rules() []RetentionRule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	rules := make([]RetentionRule, 0, len(r.rules))
+	for _, rule := range r.rules {
+		rules = append(rules, rule)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name < rules[j].Name })
+	return rules
+}
+
+// Reports returns the latest purge reports, most recent first
+func (r *Retention) Reports() []RetentionReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reports := make([]RetentionReport, len(r.reports))
+	for i, report := range r.reports {
+		reports[len(r.reports)-1-i] = report
+	}
+	return reports
+}
+
+// Job returns the scheduled job purging every rule
+func (r *Retention) Job() Job {
+	return Job{
+		Name:     "retention",
+		Schedule: r.config.Schedule,
+		Run: func(ctx context.Context) error {
+			_, err := r.Run(ctx, r.config.DryRun)
+			return err
+		},
+	}
+}
+
+// Run purges every rule one after the other, the error tells the rules which failed
+func (r *Retention) Run(ctx context.Context, dryRun bool) ([]RetentionReport, error) {
+	reports := []RetentionReport{}
+	failed := []string{}
+	for _, rule := range r.Rules() {
+		if ctx.Err() != nil {
+			break
+		}
+
+		report := r.purge(ctx, rule, dryRun)
+		if report.Error != "" {
+			failed = append(failed, rule.Name)
+		}
+		reports = append(reports, report)
+	}
+
+	if len(failed) > 0 {
+		return reports, fmt.Errorf("retention failed for %v", failed)
+	}
+	return reports, ctx.Err()
+}
+
+// Purge purges the rule called name
+func (r *Retention) Purge(ctx context.Context, name string, dryRun bool) (*RetentionReport, error) {
+	r.mu.Lock()
+	rule, ok := r.rules[name]
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("retention rule '%s' not found", name)
+	}
+
+	report := r.purge(ctx, rule, dryRun)
+	return &report, nil
+}
+
+func (r *Retention) purge(ctx context.Context, rule RetentionRule, dryRun bool) RetentionReport {
+	started := r.clock.Now()
+	report := RetentionReport{
+		Rule:    rule.Name,
+		Table:   rule.Table,
+		Cutoff:  started.Add(-rule.MaxAge),
+		DryRun:  dryRun,
+		Started: started,
+	}
+
+	var err error
+	db := rule.Database
+	if db == nil {
+		r.mu.Lock()
+		db = r.database
+		r.mu.Unlock()
+	}
+	if db == nil {
+		err = fmt.Errorf("no database for retention rule '%s'", rule.Name)
+	} else {
+		filter := append([]port.DbExpression{{Expr: rule.Column, Op: "<", Args: []any{report.Cutoff}}}, rule.Filter...)
+		if dryRun {
+			report.Matched, err = db.Count(ctx, rule.Table, filter)
+			report.Complete = err == nil
+		} else {
+			err = r.delete(ctx, db, rule, filter, &report)
+		}
+	}
+
+	report.Duration = r.clock.Since(started).String()
+	if err != nil {
+		report.Error = err.Error()
+		logger.Error("Retention purge failed", "rule", rule.Name, "table", rule.Table, "deleted", report.Deleted, "error", err)
+	} else {
+		logger.Info("Retention purge", "rule", rule.Name, "table", rule.Table, "cutoff", report.Cutoff,
+			"dry_run", dryRun, "matched", report.Matched, "deleted", report.Deleted, "complete", report.Complete)
+	}
+
+	r.mu.Lock()
+	r.reports = append(r.reports, report)
+	if len(r.reports) > r.config.History {
+		r.reports = r.reports[len(r.reports)-r.config.History:]
+	}
+	r.mu.Unlock()
+
+	r.bus.Publish(EventRetentionPurged, report)
+	return report
+}
+
+// delete removes the expired rows by batches of keys, oldest first
+func (r *Retention) delete(ctx context.Context, db port.IDatabase, rule RetentionRule, filter []port.DbExpression, report *RetentionReport) error {
+	for {
+		limit := int64(r.config.BatchSize)
+		if r.config.MaxDeletes > 0 {
+			if report.Deleted >= r.config.MaxDeletes {
+				return nil
+			}
+			limit = min(limit, r.config.MaxDeletes-report.Deleted)
+		}
+
+		var rows []port.DbMap
+		if err := db.Find(ctx, &rows, rule.Table, []string{rule.Key}, filter, map[string]int{rule.Column: 1}, limit, 0); err != nil {
+			return err
+		}
+		if len(rows) == 0 {
+			report.Complete = true
+			return nil
+		}
+
+		keys := make([]any, 0, len(rows))
+		for _, row := range rows {
+			keys = append(keys, row[rule.Key])
+		}
+		deleted, err := db.Delete(ctx, rule.Table, []port.DbExpression{{Expr: rule.Key, Op: "IN", Args: keys}})
+		if err != nil {
+			return err
+		}
+		report.Deleted += deleted
+		report.Batches++
+
+		// a short batch was the last one
+		if int64(len(rows)) < limit {
+			report.Complete = true
+			return nil
+		}
+		if deleted == 0 {
+			return fmt.Errorf("no row of table '%s' deleted by key '%s'", rule.Table, rule.Key)
+		}
+
+		if r.config.Pause > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-r.clock.After(r.config.Pause):
+			}
+		}
+	}
+}
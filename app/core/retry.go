@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/retry"
+)
+
+// Policies of app.retry.policies replacing the built-in retries
+const (
+	RetryDatabase = "database" // connection of the default database and of the databases of the tenants
+	RetryConsumer = "consumer" // restart of a failed consumer by the consume command
+	RetryWebhook  = "webhook"  // processing of the received webhooks by the job queue
+)
+
+// Retries holds the policies of app.retry.policies by name. Each policy is compiled once so
+// its budget is shared by its users, the modules get theirs with Policy:
+//
+//	policy := m.context.Retries.Policy("inventory", core.RetryPolicy{MaxAttempts: 3, Backoff: time.Second})
+//	err := policy.Do(ctx, m.context.Clock, func(ctx context.Context) error { return client.Reserve(ctx, item) })
+type Retries struct {
+	mu       sync.Mutex
+	config   config.RetryConfig
+	clock    helper.Clock
+	policies map[string]RetryPolicy
+}
+
+// NewRetries creates the registry of the policies of cfg
+func NewRetries(cfg config.RetryConfig, clock helper.Clock) *Retries {
+	return &Retries{
+		config:   cfg,
+		clock:    clock,
+		policies: make(map[string]RetryPolicy),
+	}
+}
+
+// Validate checks the strategies and jitters of the policies
+func (r *Retries) Validate() error {
+	for name, cfg := range r.config.Policies {
+		if _, err := retry.Compile(name, cfg, r.clock); err != nil {
+			return fmt.Errorf("app.retry.policies.%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+// Policy returns the policy of app.retry.policies.<name>, or fallback named name when it is
+// not configured
+func (r *Retries) Policy(name string, fallback RetryPolicy) RetryPolicy {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if policy, ok := r.policies[name]; ok {
+		return policy
+	}
+
+	policy := fallback
+	policy.Name = name
+	if cfg, ok := r.config.Policies[name]; ok {
+		compiled, err := retry.Compile(name, cfg, r.clock)
+		if err != nil {
+			logger.Warn("Retry policy ignored", "name", name, "error", err)
+		} else {
+			policy = compiled
+		}
+	}
+
+	r.policies[name] = policy
+	return policy
+}
+
+// Do calls fn with the policy name, or fallback, until it succeeds or the policy stops
+func (r *Retries) Do(ctx context.Context, name string, fallback RetryPolicy, fn func(ctx context.Context) error) error {
+	return r.Policy(name, fallback).Do(ctx, r.clock, fn)
+}
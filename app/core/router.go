@@ -0,0 +1,278 @@
+package core
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/middleware"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// ModuleRouter declares the routes of a module with their checks and their description, then
+// mounts them under /<version>/<module> of the protected routes, the version being the one of
+// Version or app.versioning.default:
+//
+//	router := ctx.Router(m.Name())
+//	router.Get("/orders", m.handler.List).Describe("List the orders", "orders")
+//	router.Post("/orders", m.handler.Create).RequireRoles("admin", "sales").LimitRate(30).
+//		Accepts(CreateOrder{}).Returns(Order{})
+//	router.Version("v2").Get("/orders", m.handler.ListV2)
+//	m.routes = router.Mount()
+//
+// The routes of a child of a ModuleGroup are mounted under /<version>/<group>/<module>, behind
+// the middleware of the group. The routes mounted are listed by ModuleManager.RouteRegistry and described in the OpenAPI
+// document of ModuleManager.OpenAPIDocument.
+type ModuleRouter struct {
+	context *AppContext
+	module  string
+	version string
+	group   *ModuleGroup
+	routes  *[]*ModuleRoute // shared with the routers of the versions
+}
+
+// RouteInfo describes a route of a module in the registry
+type RouteInfo struct {
+	Module     string   `json:"module"`
+	Method     string   `json:"method"`
+	Path       string   `json:"path"`
+	Summary    string   `json:"summary,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+	Roles      []string `json:"roles,omitempty"`
+	Permission string   `json:"permission,omitempty"`
+	RateLimit  int64    `json:"rate_limit,omitempty"`
+	Version    string   `json:"version,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+	Canary     bool     `json:"canary,omitempty"`
+}
+
+// Router returns a ModuleRouter for the routes of the module name
+func (a *AppContext) Router(module string) *ModuleRouter {
+	return &ModuleRouter{
+		context: a,
+		module:  module,
+		version: a.Config.App.Versioning.Default,
+		group:   a.GroupOf(module),
+		routes:  &[]*ModuleRoute{},
+	}
+}
+
+// Version returns a router declaring the routes of version (ex: v2), mounted with the routes
+// of r
+func (r *ModuleRouter) Version(version string) *ModuleRouter {
+	versioned := *r
+	versioned.version = version
+	return &versioned
+}
+
+// Add declares the route method path served by handlers
+func (r *ModuleRouter) Add(method string, path string, handlers ...fiber.Handler) *ModuleRoute {
+	route := &ModuleRoute{Method: method, Path: path, Handlers: handlers, Module: r.module, Version: r.version}
+	*r.routes = append(*r.routes, route)
+	return route
+}
+
+func (r *ModuleRouter) Get(path string, handlers ...fiber.Handler) *ModuleRoute {
+	return r.Add(fiber.MethodGet, path, handlers...)
+}
+
+func (r *ModuleRouter) Post(path string, handlers ...fiber.Handler) *ModuleRoute {
+	return r.Add(fiber.MethodPost, path, handlers...)
+}
+
+func (r *ModuleRouter) Put(path string, handlers ...fiber.Handler) *ModuleRoute {
+	return r.Add(fiber.MethodPut, path, handlers...)
+}
+
+func (r *ModuleRouter) Patch(path string, handlers ...fiber.Handler) *ModuleRoute {
+	return r.Add(fiber.MethodPatch, path, handlers...)
+}
+
+func (r *ModuleRouter) Delete(path string, handlers ...fiber.Handler) *ModuleRoute {
+	return r.Add(fiber.MethodDelete, path, handlers...)
+}
+
+// Mount adds the routes declared to the server under /<version>/<module> of the protected routes,
+// or /<version>/<group>/<module> in a group, and returns them, for the Routes of the module
+func (r *ModuleRouter) Mount() []*ModuleRoute {
+	roots := map[string]fiber.Router{}
+
+	var routes []*ModuleRoute
+	for _, route := range *r.routes {
+		root, ok := roots[route.Version]
+		if !ok {
+			path := "/" + r.module
+			var middleware []fiber.Handler
+			if r.group != nil {
+				path = r.group.prefix + path
+				middleware = r.group.middleware
+			}
+			if route.Version != "" {
+				path = "/" + route.Version + path
+			}
+			root = r.context.Root.Group(path, middleware...)
+			roots[route.Version] = root
+		}
+
+		route.Root = root
+		routes = AppendRouteToArray(routes, route)
+	}
+	return routes
+}
+
+// Describe sets the summary and the tags of the route in the registry
+func (r *ModuleRoute) Describe(summary string, tags ...string) *ModuleRoute {
+	r.Summary = summary
+	r.Tags = tags
+	return r
+}
+
+// Accepts documents the body of the request, see openapi.Document.SchemaOf
+func (r *ModuleRoute) Accepts(body any) *ModuleRoute {
+	r.Request = body
+	return r
+}
+
+// AcceptsQuery documents the query parameters, the fields of a struct with query tags
+func (r *ModuleRoute) AcceptsQuery(params any) *ModuleRoute {
+	r.Query = params
+	return r
+}
+
+// Returns documents the data of the response envelope (out.SuccessData)
+func (r *ModuleRoute) Returns(data any) *ModuleRoute {
+	r.Response = data
+	return r
+}
+
+// Deprecate announces the deprecation on the responses of the route, see Deprecation. The
+// whole versions are deprecated in app.versioning.versions.
+func (r *ModuleRoute) Deprecate(deprecation Deprecation) *ModuleRoute {
+	r.Deprecation = &deprecation
+	return r
+}
+
+// RequireRoles lets only the users having one of roles call the route, checked by the
+// authorization library in place of its rules for the route
+func (r *ModuleRoute) RequireRoles(roles ...string) *ModuleRoute {
+	r.Roles = roles
+	return r
+}
+
+// RequirePermission lets only the users having permission call the route, see RequireRoles
+func (r *ModuleRoute) RequirePermission(permission string) *ModuleRoute {
+	r.Permission = permission
+	return r
+}
+
+// LimitRate limits each client to perMinute requests a minute on the route, on top of the
+// global limit of app.rate_limit
+func (r *ModuleRoute) LimitRate(perMinute int64) *ModuleRoute {
+	r.RateLimit = perMinute
+	return r
+}
+
+// Use adds middleware run before the handlers of the route
+func (r *ModuleRoute) Use(handlers ...fiber.Handler) *ModuleRoute {
+	r.Middleware = append(r.Middleware, handlers...)
+	return r
+}
+
+// routeMiddleware returns the checks of route followed by its middleware: the rate limit
+// first, so the refused clients cost nothing, then the roles and the permission unless the
+// authorization library checks them with the authentication (auth.IRouteAuthorization)
+func routeMiddleware(route *ModuleRoute) []fiber.Handler {
+	var handlers []fiber.Handler
+	if route.RateLimit > 0 {
+		handlers = append(handlers, middleware.NewRateLimit(middleware.RateLimitConfig{
+			Window: time.Minute,
+			Limit:  route.RateLimit,
+		}))
+	}
+	if (len(route.Roles) > 0 || route.Permission != "") && !routeAuthorization() {
+		rule := routeRule(route)
+		handlers = append(handlers, func(c *fiber.Ctx) error {
+			if err := rule.IsUserPermitted(auth.CurrentUser(c)); err != nil {
+				return err
+			}
+			return c.Next()
+		})
+	}
+	return append(handlers, route.Middleware...)
+}
+
+// routeAuthorization reports whether the authorization library of the application checks the
+// access declared on the routes
+func routeAuthorization() bool {
+	app := Instance()
+	if app == nil {
+		return false
+	}
+	_, ok := app.Context.Authorizer.(auth.IRouteAuthorization)
+	return ok
+}
+
+// routeRule is the access declared on route
+func routeRule(route *ModuleRoute) auth.RouteRule {
+	return auth.RouteRule{
+		Method:     route.Method,
+		Path:       routePrefix(route.Root) + route.Path,
+		Roles:      route.Roles,
+		Permission: route.Permission,
+	}
+}
+
+// RouteRules returns the access declared on the routes of the modules, given to the
+// authorization library when it is an auth.IRouteAuthorization
+func (r *ModuleManager) RouteRules() []auth.RouteRule {
+	rules := []auth.RouteRule{}
+	for _, route := range r.GetRoutes() {
+		if len(route.Roles) > 0 || route.Permission != "" {
+			rules = append(rules, routeRule(route))
+		}
+	}
+	return rules
+}
+
+// routePrefix returns the path of the group root, empty for the server itself
+func routePrefix(root fiber.Router) string {
+	if group, ok := root.(*fiber.Group); ok {
+		return group.Prefix
+	}
+	return ""
+}
+
+// RouteRegistry describes the routes of the modules, by path and method
+func (r *ModuleManager) RouteRegistry() []RouteInfo {
+	registry := []RouteInfo{}
+	for _, route := range r.GetRoutes() {
+		path := routePrefix(route.Root) + route.Path
+		deprecated := route.Deprecation != nil
+		if !deprecated && r.context != nil {
+			// deprecated with its version
+			_, deprecated = r.context.Deprecations.match(route.Method, path)
+		}
+
+		registry = append(registry, RouteInfo{
+			Module:     route.Module,
+			Method:     route.Method,
+			Path:       path,
+			Summary:    route.Summary,
+			Tags:       route.Tags,
+			Roles:      route.Roles,
+			Permission: route.Permission,
+			RateLimit:  route.RateLimit,
+			Version:    route.Version,
+			Deprecated: deprecated,
+			Canary:     route.Canary != nil,
+		})
+	}
+	slices.SortFunc(registry, func(a, b RouteInfo) int {
+		if a.Path != b.Path {
+			return strings.Compare(a.Path, b.Path)
+		}
+		return strings.Compare(a.Method, b.Method)
+	})
+	return registry
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// RunOptions are the parts of a service given to Run, mostly the variables of deps
+type RunOptions struct {
+	Context   context.Context          // parent of the context of the application, context.Background by default
+	Config    *config.Config           // read from config.yaml and the environment when nil
+	Libraries map[string]LibraryLoader // APP_LIBRARIES
+	Manifests []LibraryManifest        // APP_MANIFESTS, the libraries loaded from the configuration
+	Packages  []Module                 // APP_PACKAGES
+	Args      []string                 // command and flags, os.Args[1:] by default
+
+	// Setup is called with the application before the command runs (ex: RegisterCommand)
+	Setup func(app *App) error
+}
+
+// Run is the whole main of a service: it reads the configuration, creates the application with
+// the libraries and the modules of deps, and runs the command of the arguments, serve by
+// default, over HTTPS when server.tls_cert and server.tls_key are set. SIGINT and SIGTERM stop
+// the command, then the application shuts down gracefully.
+//
+//	func main() {
+//		if err := core.Run(core.RunOptions{
+//			Libraries: deps.APP_LIBRARIES,
+//			Manifests: deps.APP_MANIFESTS,
+//			Packages:  deps.APP_PACKAGES,
+//		}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+func Run(opts RunOptions) error {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	cfg := opts.Config
+	if cfg == nil {
+		cfg = &config.Config{}
+		if err := config.LoadDefaultConfig(cfg); err != nil {
+			return fmt.Errorf("failed to load the configuration: %v", err)
+		}
+	}
+
+	args := opts.Args
+	if args == nil {
+		args = os.Args[1:]
+	}
+
+	app := NewApp(ctx, cfg, opts.Libraries, opts.Packages)
+	if err := app.LibraryManager.Declare(opts.Manifests...); err != nil {
+		app.Stop()
+		return err
+	}
+	if opts.Setup != nil {
+		if err := opts.Setup(app); err != nil {
+			app.Stop()
+			return err
+		}
+	}
+
+	return app.Run(args)
+}
@@ -0,0 +1,519 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Events published on the EventBus with the port.SagaRecord of a finished saga
+const (
+	EventSagaCompleted   = "saga.completed"
+	EventSagaCompensated = "saga.compensated"
+	EventSagaFailed      = "saga.failed"
+)
+
+// SagaStep is a step of a saga. Steps may run again after a crash or a retry, so actions and
+// compensations should be idempotent. A step without compensation is skipped while compensating.
+type SagaStep[T any] struct {
+	Name       string
+	Action     func(ctx context.Context, data *T) error
+	Compensate func(ctx context.Context, data *T) error
+	Timeout    time.Duration // 0 uses app.saga.step_timeout
+}
+
+// Saga is a workflow of steps. When a step keeps failing, or the saga exceeds its timeout,
+// the completed steps are compensated in reverse order. Changes of data made by the steps
+// are persisted after each of them.
+type Saga[T any] struct {
+	Name    string
+	Steps   []SagaStep[T]
+	Timeout time.Duration // 0 means no timeout
+	Retry   *RetryPolicy  // retries of a step or compensation, nil uses the app.saga policy
+}
+
+// sagaFunc runs a step on the JSON encoded data and returns it updated
+type sagaFunc func(ctx context.Context, data []byte) ([]byte, error)
+
+type sagaStep struct {
+	name       string
+	timeout    time.Duration
+	action     sagaFunc
+	compensate sagaFunc
+}
+
+type sagaDefinition struct {
+	timeout time.Duration
+	retry   RetryPolicy
+	steps   []sagaStep
+}
+
+// Sagas coordinates sagas persisted in a port.ISagaStore. The instance executing a saga holds
+// a lease renewed on every save, sagas whose lease expired (ex: after a crash) are resumed
+// from their last saved step by any instance.
+type Sagas struct {
+	mu          sync.RWMutex
+	config      config.SagaConfig
+	store       port.ISagaStore
+	clock       helper.Clock
+	bus         *EventBus
+	definitions map[string]*sagaDefinition
+	ctx         context.Context
+	cancel      context.CancelFunc
+	wg          sync.WaitGroup
+}
+
+// NewSagas creates a stopped coordinator
+func NewSagas(cfg config.SagaConfig, store port.ISagaStore, bus *EventBus, clock helper.Clock) *Sagas {
+	if cfg.Lease <= 0 {
+		cfg.Lease = 2 * time.Minute
+	}
+	if cfg.PollInterval <= 0 {
+		cfg.PollInterval = 15 * time.Second
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = 1
+	}
+
+	return &Sagas{
+		config:      cfg,
+		store:       store,
+		clock:       clock,
+		bus:         bus,
+		definitions: make(map[string]*sagaDefinition),
+	}
+}
+
+// SetStore replaces the store holding the sagas, must be called before Start
+func (s *Sagas) SetStore(store port.ISagaStore) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.store = store
+}
+
+// RegisterSaga registers a saga, usually from the Init of a module. The data given to
+// Sagas.Begin is JSON encoded and decoded back into T for every step.
+func RegisterSaga[T any](s *Sagas, saga Saga[T]) error {
+	if saga.Name == "" {
+		return fmt.Errorf("saga name is required")
+	}
+	if len(saga.Steps) == 0 {
+		return fmt.Errorf("saga '%s' has no step", saga.Name)
+	}
+
+	definition := &sagaDefinition{
+		timeout: saga.Timeout,
+		retry: RetryPolicy{
+			MaxAttempts: s.config.MaxAttempts,
+			Backoff:     s.config.Backoff,
+			MaxBackoff:  s.config.MaxBackoff,
+		},
+	}
+	if saga.Retry != nil {
+		definition.retry = *saga.Retry
+	}
+
+	for i, step := range saga.Steps {
+		if step.Action == nil {
+			return fmt.Errorf("saga '%s': step %d has no action", saga.Name, i)
+		}
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step%d", i+1)
+		}
+		if step.Timeout <= 0 {
+			step.Timeout = s.config.StepTimeout
+		}
+		definition.steps = append(definition.steps, sagaStep{
+			name:       step.Name,
+			timeout:    step.Timeout,
+			action:     sagaStepFunc(step.Action),
+			compensate: sagaStepFunc(step.Compensate),
+		})
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.definitions[saga.Name]; exists {
+		return fmt.Errorf("saga '%s' already registered", saga.Name)
+	}
+	s.definitions[saga.Name] = definition
+	return nil
+}
+
+func sagaStepFunc[T any](fn func(ctx context.Context, data *T) error) sagaFunc {
+	if fn == nil {
+		return nil
+	}
+
+	return func(ctx context.Context, raw []byte) ([]byte, error) {
+		var data T
+		if err := helper.JSONUnmarshal(raw, &data); err != nil {
+			return nil, fmt.Errorf("invalid saga data: %v", err)
+		}
+		if err := fn(ctx, &data); err != nil {
+			return nil, err
+		}
+		return helper.JSONMarshal(data)
+	}
+}
+
+// Begin saves a new saga and executes it in the background, it returns the saga ID
+func (s *Sagas) Begin(ctx context.Context, name string, data any) (string, error) {
+	s.mu.RLock()
+	definition, ok := s.definitions[name]
+	running := s.ctx
+	store := s.store
+	s.mu.RUnlock()
+
+	if !ok {
+		return "", fmt.Errorf("saga '%s' not registered", name)
+	}
+	if running == nil {
+		return "", fmt.Errorf("sagas are not started, enable app.saga")
+	}
+
+	raw, err := helper.JSONMarshal(data)
+	if err != nil {
+		return "", err
+	}
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+
+	now := s.clock.Now()
+	record := &port.SagaRecord{
+		ID:          id,
+		Saga:        name,
+		State:       port.SagaRunning,
+		Data:        raw,
+		LockedUntil: now.Add(s.config.Lease),
+		Version:     1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if definition.timeout > 0 {
+		record.Deadline = now.Add(definition.timeout)
+	}
+	if err := store.Insert(ctx, record); err != nil {
+		return "", err
+	}
+
+	s.execute(running, record)
+	return id, nil
+}
+
+// Get returns the state of a saga, nil when it does not exist
+func (s *Sagas) Get(ctx context.Context, id string) (*port.SagaRecord, error) {
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+	return store.Get(ctx, id)
+}
+
+// Start lets sagas begin and resumes the stalled ones every poll interval, until ctx is done
+// or Stop is called
+func (s *Sagas) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go s.recover(s.ctx)
+}
+
+// Stop interrupts the running sagas and waits for them, their lease expires and they are
+// resumed on the next start or by another instance
+func (s *Sagas) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Sagas) recover(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		s.mu.RLock()
+		store := s.store
+		s.mu.RUnlock()
+
+		stalled, err := store.Stalled(ctx, s.clock.Now(), 100)
+		if err != nil && ctx.Err() == nil {
+			logger.Error("Saga recovery failed", "error", err)
+		}
+		for i := range stalled {
+			record := &stalled[i]
+
+			// claim the saga, another instance may be resuming it too
+			record.LockedUntil = s.clock.Now().Add(s.config.Lease)
+			if claimed, err := s.save(ctx, record); err != nil || !claimed {
+				continue
+			}
+			logger.Info("Saga resumed", "saga", record.Saga, "id", record.ID, "state", record.State, "step", record.Step)
+			s.execute(ctx, record)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.config.PollInterval):
+		}
+	}
+}
+
+func (s *Sagas) execute(ctx context.Context, record *port.SagaRecord) {
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		s.advance(ctx, record)
+	}()
+}
+
+// advance executes the saga until it finishes, the instance loses its lease or ctx is done
+func (s *Sagas) advance(ctx context.Context, record *port.SagaRecord) {
+	s.mu.RLock()
+	definition, ok := s.definitions[record.Saga]
+	s.mu.RUnlock()
+	if !ok {
+		// left for an instance knowing the saga
+		logger.Error("Saga not registered", "saga", record.Saga, "id", record.ID)
+		return
+	}
+
+	for ctx.Err() == nil {
+		switch record.State {
+		case port.SagaRunning:
+			if record.Step >= len(definition.steps) {
+				s.finish(ctx, record, port.SagaCompleted, EventSagaCompleted)
+				return
+			}
+			if !record.Deadline.IsZero() && s.clock.Now().After(record.Deadline) {
+				record.LastError = "saga timed out"
+				s.compensate(record)
+				break
+			}
+
+			step := definition.steps[record.Step]
+			data, err := s.run(ctx, step.action, step.timeout, record.Data)
+			if err == nil {
+				record.Data = data
+				record.Step++
+				record.Attempts = 0
+				record.LastError = ""
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			record.Attempts++
+			record.LastError = fmt.Sprintf("%s: %v", step.name, err)
+			logger.Warn("Saga step failed", "saga", record.Saga, "id", record.ID, "step", step.name, "attempt", record.Attempts, "error", err)
+			if record.Attempts >= definition.retry.MaxAttempts {
+				s.compensate(record)
+			} else if !s.backoff(ctx, record, definition.retry) {
+				return
+			}
+		case port.SagaCompensating:
+			if record.Step < 0 {
+				s.finish(ctx, record, port.SagaCompensated, EventSagaCompensated)
+				return
+			}
+
+			step := definition.steps[record.Step]
+			if step.compensate == nil {
+				record.Step--
+				break
+			}
+
+			data, err := s.run(ctx, step.compensate, step.timeout, record.Data)
+			if err == nil {
+				record.Data = data
+				record.Step--
+				record.Attempts = 0
+				break
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			record.Attempts++
+			record.LastError = fmt.Sprintf("compensate %s: %v", step.name, err)
+			logger.Warn("Saga compensation failed", "saga", record.Saga, "id", record.ID, "step", step.name, "attempt", record.Attempts, "error", err)
+			if record.Attempts >= definition.retry.MaxAttempts {
+				logger.Error("Saga failed, compensation needs a manual intervention", "saga", record.Saga, "id", record.ID, "step", step.name, "error", err)
+				s.finish(ctx, record, port.SagaFailed, EventSagaFailed)
+				return
+			}
+			if !s.backoff(ctx, record, definition.retry) {
+				return
+			}
+		default:
+			return
+		}
+
+		record.LockedUntil = s.clock.Now().Add(s.config.Lease)
+		saved, err := s.save(ctx, record)
+		if err != nil {
+			// the lease expires and the saga is resumed from its last saved step
+			logger.Error("Saga state not saved", "saga", record.Saga, "id", record.ID, "error", err)
+			return
+		}
+		if !saved {
+			logger.Warn("Saga taken over by another instance", "saga", record.Saga, "id", record.ID)
+			return
+		}
+	}
+}
+
+// compensate switches to the compensation of the steps completed before the current one
+func (s *Sagas) compensate(record *port.SagaRecord) {
+	logger.Warn("Saga compensating", "saga", record.Saga, "id", record.ID, "step", record.Step, "error", record.LastError)
+	record.State = port.SagaCompensating
+	record.Step--
+	record.Attempts = 0
+}
+
+// backoff saves the attempt then waits before the retry, false when the saga must stop
+func (s *Sagas) backoff(ctx context.Context, record *port.SagaRecord, policy RetryPolicy) bool {
+	delay := policy.Delay(record.Attempts)
+	record.LockedUntil = s.clock.Now().Add(s.config.Lease + delay)
+	if saved, err := s.save(ctx, record); err != nil || !saved {
+		return false
+	}
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-s.clock.After(delay):
+		return true
+	}
+}
+
+func (s *Sagas) finish(ctx context.Context, record *port.SagaRecord, state string, event string) {
+	record.State = state
+	record.LockedUntil = time.Time{}
+	if saved, err := s.save(ctx, record); err != nil || !saved {
+		logger.Error("Saga state not saved", "saga", record.Saga, "id", record.ID, "state", state, "error", err)
+		return
+	}
+
+	logger.Info("Saga finished", "saga", record.Saga, "id", record.ID, "state", state)
+	s.bus.Publish(event, *record)
+}
+
+func (s *Sagas) run(ctx context.Context, fn sagaFunc, timeout time.Duration, data []byte) (result []byte, err error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return fn(ctx, data)
+}
+
+// save writes the record if nobody saved it since it was read
+func (s *Sagas) save(ctx context.Context, record *port.SagaRecord) (bool, error) {
+	s.mu.RLock()
+	store := s.store
+	s.mu.RUnlock()
+
+	version := record.Version
+	record.Version++
+	record.UpdatedAt = s.clock.Now()
+
+	// the state is saved even when the saga is being stopped
+	saved, err := store.Update(context.WithoutCancel(ctx), record, version)
+	if err != nil || !saved {
+		record.Version = version
+	}
+	return saved, err
+}
+
+// MemorySagaStore keeps sagas in process memory, they are lost on restart
+type MemorySagaStore struct {
+	mu    sync.Mutex
+	sagas map[string]*port.SagaRecord
+}
+
+// NewMemorySagaStore creates an empty in-memory store
+func NewMemorySagaStore() *MemorySagaStore {
+	return &MemorySagaStore{
+		sagas: make(map[string]*port.SagaRecord),
+	}
+}
+
+func (m *MemorySagaStore) Insert(ctx context.Context, saga *port.SagaRecord) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.sagas[saga.ID]; exists {
+		return fmt.Errorf("saga '%s' already exists", saga.ID)
+	}
+	copied := *saga
+	m.sagas[saga.ID] = &copied
+	return nil
+}
+
+func (m *MemorySagaStore) Update(ctx context.Context, saga *port.SagaRecord, version int64) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, ok := m.sagas[saga.ID]
+	if !ok || stored.Version != version {
+		return false, nil
+	}
+	copied := *saga
+	m.sagas[saga.ID] = &copied
+	return true, nil
+}
+
+func (m *MemorySagaStore) Get(ctx context.Context, id string) (*port.SagaRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	saga, ok := m.sagas[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *saga
+	return &copied, nil
+}
+
+func (m *MemorySagaStore) Stalled(ctx context.Context, now time.Time, limit int) ([]port.SagaRecord, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	result := []port.SagaRecord{}
+	for _, saga := range m.sagas {
+		if (saga.State == port.SagaRunning || saga.State == port.SagaCompensating) && saga.LockedUntil.Before(now) {
+			result = append(result, *saga)
+			if limit > 0 && len(result) >= limit {
+				break
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,302 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// OverlapPolicy decides what happens when a job is due while its previous run is still going
+type OverlapPolicy int
+
+const (
+	// OverlapSkip drops the tick (default)
+	OverlapSkip OverlapPolicy = iota
+	// OverlapAllow starts a new run next to the running one
+	OverlapAllow
+	// OverlapWait delays the tick until the running one finishes
+	OverlapWait
+)
+
+// JobFunc is the work executed by a scheduled job. The context is cancelled on
+// timeout or when the scheduler stops.
+type JobFunc func(ctx context.Context) error
+
+// Job describes a cron job registered by a module
+type Job struct {
+	Name     string
+	Schedule string        // cron expression, see helper.ParseCron
+	Timeout  time.Duration // 0 means no timeout
+	Overlap  OverlapPolicy
+	Local    bool // run on every instance instead of only the one holding the lock
+	Run      JobFunc
+}
+
+// JobStatus is the state and counters of a job, served by the admin endpoint
+type JobStatus struct {
+	Name         string     `json:"name"`
+	Schedule     string     `json:"schedule"`
+	Running      int        `json:"running"`
+	NextRun      *time.Time `json:"next_run,omitempty"`
+	LastRun      *time.Time `json:"last_run,omitempty"`
+	LastDuration string     `json:"last_duration,omitempty"`
+	LastError    string     `json:"last_error,omitempty"`
+	Runs         int64      `json:"runs"`
+	Failures     int64      `json:"failures"`
+	Timeouts     int64      `json:"timeouts"`
+	Skipped      int64      `json:"skipped"`   // ticks dropped by the overlap policy
+	Contended    int64      `json:"contended"` // ticks executed by another instance
+}
+
+type scheduledJob struct {
+	job      Job
+	schedule helper.CronSchedule
+	status   JobStatus
+}
+
+// Scheduler runs cron jobs. Unless a job is Local, every tick is guarded by the
+// locker so only one instance of the application executes it.
+type Scheduler struct {
+	mu     sync.Mutex
+	clock  helper.Clock
+	locker port.ILocker
+	jobs   map[string]*scheduledJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewScheduler creates a stopped scheduler
+func NewScheduler(clock helper.Clock, locker port.ILocker) *Scheduler {
+	return &Scheduler{
+		clock:  clock,
+		locker: locker,
+		jobs:   make(map[string]*scheduledJob),
+	}
+}
+
+// SetLocker replaces the lock used to elect the instance running each tick
+func (s *Scheduler) SetLocker(locker port.ILocker) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locker = locker
+}
+
+// Register adds a job. Jobs registered after Start are scheduled immediately.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return fmt.Errorf("job name is required")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job '%s' has no run function", job.Name)
+	}
+
+	schedule, err := helper.ParseCron(job.Schedule)
+	if err != nil {
+		return fmt.Errorf("job '%s': %v", job.Name, err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.jobs[job.Name]; exists {
+		return fmt.Errorf("job '%s' already registered", job.Name)
+	}
+
+	entry := &scheduledJob{
+		job:      job,
+		schedule: schedule,
+		status:   JobStatus{Name: job.Name, Schedule: job.Schedule},
+	}
+	s.jobs[job.Name] = entry
+
+	if s.ctx != nil {
+		s.wg.Add(1)
+		go s.loop(s.ctx, entry)
+	}
+
+	return nil
+}
+
+// Start schedules every registered job until ctx is done or Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil {
+		return
+	}
+
+	s.ctx, s.cancel = context.WithCancel(ctx)
+	for _, entry := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(s.ctx, entry)
+	}
+
+	logger.Info("Scheduler started", "jobs", len(s.jobs))
+	if _, local := s.locker.(*LocalLocker); local && s.shared() {
+		logger.Warn("Scheduler lock is in-process, every instance runs the jobs, set app.scheduler.locker to a shared lock (ex: \"lock:database\")")
+	}
+}
+
+// shared reports whether a job is guarded by the locker, s.mu held
+func (s *Scheduler) shared() bool {
+	for _, entry := range s.jobs {
+		if !entry.job.Local {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop cancels running jobs and waits for them to return
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.ctx, s.cancel = nil, nil
+	s.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	s.wg.Wait()
+}
+
+// Status returns the status of every job sorted by name
+func (s *Scheduler) Status() []JobStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]JobStatus, 0, len(s.jobs))
+	for _, entry := range s.jobs {
+		result = append(result, entry.status)
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func (s *Scheduler) loop(ctx context.Context, entry *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		now := s.clock.Now()
+		next := entry.schedule.Next(now)
+		if next.IsZero() {
+			logger.Warn("Job has no next activation", "job", entry.job.Name)
+			return
+		}
+
+		s.mu.Lock()
+		entry.status.NextRun = &next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(now)):
+		}
+
+		if entry.job.Overlap == OverlapWait {
+			// blocking the loop delays the following ticks until this run is done
+			s.dispatch(ctx, entry, next)
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.dispatch(ctx, entry, next)
+		}()
+	}
+}
+
+func (s *Scheduler) dispatch(ctx context.Context, entry *scheduledJob, tick time.Time) {
+	// reserve the run slot first so concurrent ticks see it while the lock is negotiated
+	s.mu.Lock()
+	if entry.job.Overlap == OverlapSkip && entry.status.Running > 0 {
+		entry.status.Skipped++
+		s.mu.Unlock()
+		logger.Debug("Job skipped, previous run still in progress", "job", entry.job.Name)
+		return
+	}
+	entry.status.Running++
+	locker := s.locker
+	s.mu.Unlock()
+
+	if !entry.job.Local && locker != nil {
+		// the key is bound to the tick and kept until the next one so instances
+		// with a slightly late clock do not run the same tick again
+		key := fmt.Sprintf("scheduler:%s:%d", entry.job.Name, tick.Unix())
+		ttl := entry.schedule.Next(tick).Sub(tick)
+		acquired, err := locker.TryLock(ctx, key, ttl)
+		if err != nil || !acquired {
+			s.mu.Lock()
+			entry.status.Running--
+			if err == nil {
+				entry.status.Contended++
+			}
+			s.mu.Unlock()
+
+			if err != nil {
+				logger.Error("Failed to acquire job lock", "job", entry.job.Name, "error", err)
+			}
+			return
+		}
+	}
+
+	s.run(ctx, entry)
+}
+
+func (s *Scheduler) run(ctx context.Context, entry *scheduledJob) {
+	start := s.clock.Now()
+
+	runCtx := ctx
+	if entry.job.Timeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, entry.job.Timeout)
+		defer cancel()
+	}
+
+	err := func() (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = fmt.Errorf("panic: %v", r)
+			}
+		}()
+		return entry.job.Run(runCtx)
+	}()
+
+	duration := s.clock.Since(start)
+	timedOut := entry.job.Timeout > 0 && runCtx.Err() == context.DeadlineExceeded
+
+	s.mu.Lock()
+	entry.status.Running--
+	entry.status.Runs++
+	entry.status.LastRun = &start
+	entry.status.LastDuration = duration.String()
+	entry.status.LastError = ""
+	if timedOut {
+		entry.status.Timeouts++
+	}
+	if err != nil {
+		entry.status.Failures++
+		entry.status.LastError = err.Error()
+	}
+	s.mu.Unlock()
+
+	if err != nil {
+		logger.Error("Job failed", "job", entry.job.Name, "duration", duration, "timeout", timedOut, "error", err)
+		return
+	}
+
+	logger.Debug("Job finished", "job", entry.job.Name, "duration", duration)
+}
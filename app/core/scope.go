@@ -0,0 +1,79 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Scope creates a child LibraryManager for a request or a tenant. It loads with the loaders of
+// lm and holds its own instances (ex: the database handle of a tenant), the libraries it does
+// not hold are resolved in lm. Destroy unloads the instances of the scope only, lm is left
+// as is.
+func (lm *LibraryManager) Scope() *LibraryManager {
+	scope := CreateLibraryManager(nil)
+	scope.Loaders = lm.Loaders
+	scope.config = lm.config
+	scope.sections = lm.sections
+	scope.parent = lm
+	return scope
+}
+
+// Parent returns the manager a scope was created from, nil for the manager of the application
+func (lm *LibraryManager) Parent() *LibraryManager {
+	return lm.parent
+}
+
+// root returns the manager of the application, which holds the manifests
+func (lm *LibraryManager) root() *LibraryManager {
+	for lm.parent != nil {
+		lm = lm.parent
+	}
+	return lm
+}
+
+type libraryScopeKey struct{}
+
+// WithLibraryScope stores scope in ctx (ex: in a job, for the tenant it runs for)
+func WithLibraryScope(ctx context.Context, scope *LibraryManager) context.Context {
+	return context.WithValue(ctx, libraryScopeKey{}, scope)
+}
+
+// LibraryScope returns the scope stored by WithLibraryScope or by ScopeMiddleware, or the
+// LibraryManager of the application when there is none
+func LibraryScope(ctx context.Context) *LibraryManager {
+	if scope, ok := ctx.Value(libraryScopeKey{}).(*LibraryManager); ok {
+		return scope
+	}
+	return Instance().LibraryManager
+}
+
+// ScopeMiddleware gives each request a scope of lm, found with LibraryScope in the context of
+// the request. The instances loaded in the scope are unloaded once the request is handled, so
+// they must not be used by work outliving it.
+func (lm *LibraryManager) ScopeMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		scope := lm.Scope()
+		c.SetUserContext(WithLibraryScope(c.UserContext(), scope))
+		defer func() {
+			if err := scope.Destroy(); err != nil {
+				logger.Warn("Request scope not unloaded", "path", c.Path(), "error", err)
+			}
+		}()
+		return c.Next()
+	}
+}
+
+// LoadScoped returns the instance key of the library name in the scope of ctx, loaded with
+// args on first use. It is unloaded with the scope.
+func LoadScoped(ctx context.Context, name string, key string, args ...any) (port.Library, error) {
+	scope := LibraryScope(ctx)
+	loader, ok := scope.GetLoader(name)
+	if !ok {
+		return nil, fmt.Errorf("LibraryLoader '%s' tidak ditemukan", name)
+	}
+	return scope.LoadInstanceFromLoader(loader, key, args...)
+}
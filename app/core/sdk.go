@@ -0,0 +1,152 @@
+package core
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/openapi"
+)
+
+// ClientDocument returns the OpenAPI document of the client SDKs: the document of spec (typed
+// operations and schemas) completed with the declaration of the module routes (see
+// ModuleManager.DescribeRoutes) and the routes below server.path_prefix it does not describe.
+// Its paths are relative to the path prefix, the routes of the admin endpoints are left out
+// unless admin is set.
+func (a *AppContext) ClientDocument(spec string, admin bool) (*openapi.Document, error) {
+	prefix := strings.TrimRight(a.Config.Server.PathPrefix, "/")
+
+	doc := &openapi.Document{OpenAPI: "3.1.0"}
+	if spec != "" {
+		loaded, err := openapi.LoadDocument(spec)
+		if err != nil {
+			return nil, err
+		}
+		doc = loaded
+
+		// the paths of the document are relative to the path prefix, as the ones of the routes
+		paths := make(map[string]*openapi.PathItem, len(doc.Paths))
+		for template, item := range doc.Paths {
+			if trimmed, ok := strings.CutPrefix(template, prefix+"/"); ok && prefix != "" {
+				template = "/" + trimmed
+			}
+			paths[template] = item
+		}
+		doc.Paths = paths
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = a.Config.App.Name
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = a.Config.App.Version
+	}
+
+	adminPath := ""
+	if a.Config.App.Admin.Enabled && !admin {
+		adminPath = prefix + a.Config.App.Admin.Path
+	}
+
+	if app := Instance(); app != nil && app.Context == a {
+		app.ModuleManager.DescribeRoutes(doc, prefix)
+	}
+
+	for _, route := range a.Web.GetRoutes(true) {
+		switch route.Method {
+		case "GET", "POST", "PUT", "PATCH", "DELETE":
+		default:
+			continue
+		}
+
+		path, ok := strings.CutPrefix(route.Path, prefix)
+		if !ok || path == "" || !strings.HasPrefix(path, "/") {
+			continue
+		}
+		if adminPath != "" && (route.Path == adminPath || strings.HasPrefix(route.Path, adminPath+"/")) {
+			continue
+		}
+
+		op := doc.AddRoute(route.Method, path)
+		if _, deprecated := a.Deprecations.match(route.Method, route.Path); deprecated {
+			op.Deprecated = true
+		}
+	}
+
+	return doc, nil
+}
+
+func sdkCommand() *Command {
+	var output, pkg, spec string
+	var admin bool
+	return &Command{
+		Name:  "sdk",
+		Usage: "[go | ts]",
+		Description: "Generate a typed client of the routes, in Go (by default) or TypeScript, from the routes " +
+			"of the modules, their declared types and the schemas of app.openapi.spec",
+		Flags: func(fs *flag.FlagSet) {
+			fs.StringVar(&output, "out", "", "file of the client, printed when empty")
+			fs.StringVar(&pkg, "package", "client", "package of the Go client")
+			fs.StringVar(&spec, "spec", "", "OpenAPI document (JSON) describing the operations, app.openapi.spec by default")
+			fs.BoolVar(&admin, "admin", false, "also generate the admin endpoints")
+		},
+		Run: func(ctx context.Context, app *AppContext, args []string) error {
+			lang := "go"
+			if len(args) > 1 {
+				return fmt.Errorf("expected: sdk [go | ts]")
+			}
+			if len(args) == 1 {
+				lang = args[0]
+			}
+			if spec == "" {
+				spec = app.Config.App.OpenAPI.Spec
+			}
+
+			doc, err := app.ClientDocument(spec, admin)
+			if err != nil {
+				return err
+			}
+
+			var code []byte
+			switch lang {
+			case "go":
+				code, err = openapi.GenerateGoClient(doc, pkg)
+			case "ts", "typescript":
+				code, err = openapi.GenerateTypeScriptClient(doc)
+			default:
+				return fmt.Errorf("unknown language %q, expected go or ts", lang)
+			}
+			if err != nil {
+				return err
+			}
+
+			if output == "" {
+				_, err = os.Stdout.Write(code)
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(output), 0755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(output, code, 0644); err != nil {
+				return err
+			}
+
+			logger.Info("Client generated", "language", lang, "file", output, "operations", countOperations(doc))
+			return nil
+		},
+	}
+}
+
+func countOperations(doc *openapi.Document) int {
+	count := 0
+	for _, item := range doc.Paths {
+		for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+			if item.Operation(method) != nil {
+				count++
+			}
+		}
+	}
+	return count
+}
@@ -0,0 +1,397 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// SearchSyncJobType is the job queue type synchronizing changed rows into their index
+const SearchSyncJobType = "search.sync"
+
+// SearchSource declares a table (or collection) kept in sync with a search index
+type SearchSource struct {
+	Name     string
+	Index    string // index name, prefixed by search.prefix, Name when empty
+	Table    string
+	Key      string                            // column identifying a row, used as document ID, "id" when empty
+	Columns  []string                          // columns read from the table, all when empty
+	Filter   []port.DbExpression               // rows outside the filter are removed from the index (ex: only published articles)
+	Events   []string                          // EventBus events of changed rows, their data is the key, a map or a struct with db tags holding it
+	Document func(row port.DbMap) (any, error) // body of the document, the row when nil. A nil body removes the document.
+	Database port.IDatabase                    // nil uses the default database library
+}
+
+// SearchBackfillResult is the result of a backfill task
+type SearchBackfillResult struct {
+	Indexed int64 `json:"indexed"`
+	Removed int64 `json:"removed"` // rows whose Document was nil
+}
+
+type searchSyncJob struct {
+	Source string `json:"source"`
+	Keys   []any  `json:"keys"`
+}
+
+type searchBackfillJob struct {
+	Source string `json:"source"`
+}
+
+// SearchSync keeps external search indexes up to date. Changes only carry the keys of the
+// rows, the job reloads them so the index converges to the table whatever the order or the
+// retries of the jobs: found rows are indexed, missing or filtered out rows are removed.
+type SearchSync struct {
+	mu       sync.RWMutex
+	config   config.SearchConfig
+	queue    *JobQueue
+	tasks    *Tasks
+	bus      *EventBus
+	driver   port.ISearchIndex
+	database port.IDatabase
+	sources  map[string]SearchSource
+}
+
+// NewSearchSync creates a synchronization without driver and registers its jobs on queue
+func NewSearchSync(cfg config.SearchConfig, queue *JobQueue, tasks *Tasks, bus *EventBus) *SearchSync {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 500
+	}
+
+	s := &SearchSync{
+		config:  cfg,
+		queue:   queue,
+		tasks:   tasks,
+		bus:     bus,
+		sources: make(map[string]SearchSource),
+	}
+
+	HandleJob(queue, SearchSyncJobType, func(ctx context.Context, job searchSyncJob) error {
+		return s.Sync(ctx, job.Source, job.Keys...)
+	})
+	HandleTask(tasks, "search:backfill", func(ctx context.Context, progress TaskProgress, job searchBackfillJob) (any, error) {
+		return s.Backfill(ctx, job.Source, progress)
+	})
+
+	return s
+}
+
+// SetDriver sets the library writing the indexes
+func (s *SearchSync) SetDriver(driver port.ISearchIndex) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.driver = driver
+}
+
+// SetDatabase sets the database of the sources without their own
+func (s *SearchSync) SetDatabase(db port.IDatabase) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.database = db
+}
+
+// Register adds a source and subscribes to its events, usually from the Init of a module
+func (s *SearchSync) Register(source SearchSource) error {
+	if source.Name == "" {
+		return fmt.Errorf("search source name is required")
+	}
+	if source.Table == "" {
+		return fmt.Errorf("search source '%s' needs a table", source.Name)
+	}
+	if source.Index == "" {
+		source.Index = source.Name
+	}
+	if source.Key == "" {
+		source.Key = "id"
+	}
+	if len(source.Columns) > 0 && !slices.Contains(source.Columns, source.Key) {
+		source.Columns = append(slices.Clone(source.Columns), source.Key)
+	}
+
+	s.mu.Lock()
+	if _, exists := s.sources[source.Name]; exists {
+		s.mu.Unlock()
+		return fmt.Errorf("search source '%s' already registered", source.Name)
+	}
+	s.sources[source.Name] = source
+	s.mu.Unlock()
+
+	for _, event := range source.Events {
+		s.bus.Subscribe(event, func(data any) {
+			key, err := searchKey(data, source.Key)
+			if err != nil {
+				logger.Warn("Search sync ignored event", "source", source.Name, "event", event, "error", err)
+				return
+			}
+			if err := s.Changed(context.Background(), source.Name, key); err != nil {
+				logger.Error("Search sync failed to queue change", "source", source.Name, "event", event, "error", err)
+			}
+		})
+	}
+	return nil
+}
+
+// Sources returns the registered sources sorted by name
+func (s *SearchSync) Sources() []SearchSource {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sources := make([]SearchSource, 0, len(s.sources))
+	for _, source := range s.sources {
+		sources = append(sources, source)
+	}
+	sort.Slice(sources, func(i, j int) bool { return sources[i].Name < sources[j].Name })
+	return sources
+}
+
+// Changed queues the synchronization of the rows of source with these keys, call it from
+// repositories after a write when the source has no events
+func (s *SearchSync) Changed(ctx context.Context, source string, keys ...any) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	if _, err := s.source(source); err != nil {
+		return err
+	}
+
+	_, err := s.queue.Enqueue(ctx, SearchSyncJobType, searchSyncJob{Source: source, Keys: keys}, EnqueueOptions{Queue: s.config.Queue})
+	return err
+}
+
+// Sync indexes the rows of source with these keys now, and removes from the index those
+// which no longer exist or no longer match the filter
+func (s *SearchSync) Sync(ctx context.Context, name string, keys ...any) error {
+	source, err := s.source(name)
+	if err != nil {
+		return err
+	}
+	driver, db, err := s.connections(source)
+	if err != nil {
+		return err
+	}
+
+	// keys went through JSON in the job payload
+	keys = slices.Clone(keys)
+	for i, key := range keys {
+		if f, ok := key.(float64); ok && f == math.Trunc(f) {
+			keys[i] = int64(f)
+		}
+	}
+
+	filter := append([]port.DbExpression{{Expr: source.Key, Op: "IN", Args: keys}}, source.Filter...)
+	var rows []port.DbMap
+	if err := db.Find(ctx, &rows, source.Table, source.Columns, filter, nil, 0, 0); err != nil {
+		return err
+	}
+
+	docs, removed, err := s.documents(source, rows)
+	if err != nil {
+		return err
+	}
+
+	found := map[string]bool{}
+	for _, doc := range docs {
+		found[doc.ID] = true
+	}
+	for _, id := range removed {
+		found[id] = true
+	}
+	for _, key := range keys {
+		if id := searchID(key); !found[id] {
+			removed = append(removed, id)
+		}
+	}
+
+	index := s.config.Prefix + source.Index
+	if err := driver.Index(ctx, index, docs); err != nil {
+		return err
+	}
+	return driver.Delete(ctx, index, removed)
+}
+
+// Backfill indexes every row of source matching its filter, by batches of search.batch_size.
+// Documents of rows deleted while no synchronization ran stay in the index, rebuild it into
+// an empty index to remove them.
+func (s *SearchSync) Backfill(ctx context.Context, name string, progress TaskProgress) (*SearchBackfillResult, error) {
+	source, err := s.source(name)
+	if err != nil {
+		return nil, err
+	}
+	driver, db, err := s.connections(source)
+	if err != nil {
+		return nil, err
+	}
+
+	total, err := db.Count(ctx, source.Table, source.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	cursor, err := helper.FindCursor(ctx, db, source.Table, source.Columns, source.Filter, map[string]int{source.Key: 1})
+	if err != nil {
+		return nil, err
+	}
+	defer cursor.Close(ctx)
+
+	result := &SearchBackfillResult{}
+	index := s.config.Prefix + source.Index
+	rows := make([]port.DbMap, 0, s.config.BatchSize)
+	flush := func() error {
+		docs, removed, err := s.documents(source, rows)
+		if err != nil {
+			return err
+		}
+		if err := driver.Index(ctx, index, docs); err != nil {
+			return err
+		}
+		if err := driver.Delete(ctx, index, removed); err != nil {
+			return err
+		}
+
+		result.Indexed += int64(len(docs))
+		result.Removed += int64(len(removed))
+		rows = rows[:0]
+		if progress != nil && total > 0 {
+			done := result.Indexed + result.Removed
+			progress(int(done*100/max(total, done)), fmt.Sprintf("%d of %d rows", done, total))
+		}
+		return nil
+	}
+
+	for cursor.Next(ctx) {
+		row := port.DbMap{}
+		if err := cursor.Decode(&row); err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+
+		if len(rows) >= s.config.BatchSize {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, err
+	}
+	if len(rows) > 0 {
+		if err := flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Info("Search backfill finished", "source", source.Name, "index", index, "indexed", result.Indexed, "removed", result.Removed)
+	return result, nil
+}
+
+// AcceptBackfill queues the backfill of source as a task and answers 202 Accepted
+func (s *SearchSync) AcceptBackfill(c *fiber.Ctx, source string) error {
+	if _, err := s.source(source); err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	return s.tasks.Accept(c, "search:backfill", searchBackfillJob{Source: source}, EnqueueOptions{Queue: s.config.Queue})
+}
+
+func (s *SearchSync) source(name string) (SearchSource, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	source, ok := s.sources[name]
+	if !ok {
+		return source, fmt.Errorf("search source '%s' not found", name)
+	}
+	return source, nil
+}
+
+func (s *SearchSync) connections(source SearchSource) (port.ISearchIndex, port.IDatabase, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.driver == nil {
+		return nil, nil, fmt.Errorf("search is not configured")
+	}
+	db := source.Database
+	if db == nil {
+		db = s.database
+	}
+	if db == nil {
+		return nil, nil, fmt.Errorf("no database for search source '%s'", source.Name)
+	}
+	return s.driver, db, nil
+}
+
+// documents converts the rows into documents, and the IDs of those to remove
+func (s *SearchSync) documents(source SearchSource, rows []port.DbMap) ([]port.SearchDocument, []string, error) {
+	docs := make([]port.SearchDocument, 0, len(rows))
+	removed := []string{}
+	for _, row := range rows {
+		id := searchID(row[source.Key])
+
+		var body any = row
+		if source.Document != nil {
+			var err error
+			if body, err = source.Document(row); err != nil {
+				return nil, nil, fmt.Errorf("document %s of '%s': %v", id, source.Name, err)
+			}
+		}
+
+		if body == nil {
+			removed = append(removed, id)
+			continue
+		}
+		docs = append(docs, port.SearchDocument{ID: id, Body: body})
+	}
+	return docs, removed, nil
+}
+
+// searchKey reads the key of the changed row from the data of an event
+func searchKey(data any, key string) (any, error) {
+	switch v := data.(type) {
+	case nil:
+		return nil, fmt.Errorf("event has no data")
+	case port.DbMap:
+		return mapKey(v, key)
+	case map[string]any:
+		return mapKey(v, key)
+	}
+
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return value.Interface(), nil
+	}
+
+	row, err := helper.MarshalDbMap(value.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return mapKey(row, key)
+}
+
+func mapKey(row map[string]any, key string) (any, error) {
+	value, ok := row[key]
+	if !ok || value == nil {
+		return nil, fmt.Errorf("event data has no '%s'", key)
+	}
+	return value, nil
+}
+
+// searchID formats a key as document ID
+func searchID(key any) string {
+	// ex: MongoDB ObjectID
+	if hex, ok := key.(interface{ Hex() string }); ok {
+		return hex.Hex()
+	}
+	return fmt.Sprint(key)
+}
@@ -0,0 +1,348 @@
+package core
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ErrNoSecrets is returned by Secrets without secrets library (secrets.driver is empty)
+var ErrNoSecrets = errors.New("secrets manager is not configured")
+
+// EventSecretRotated is published on the EventBus with a SecretRotation when a secret read
+// before got a new version
+const EventSecretRotated = "secret.rotated"
+
+// SecretRotation is the data of EventSecretRotated, the value is not published
+type SecretRotation struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// Secrets reads the secrets of the port.ISecrets library of secrets.driver. The secrets read are
+// kept in memory, their leases renewed before they expire and their versions checked every
+// secrets.refresh, the callbacks of OnRotate run when a secret changes.
+type Secrets struct {
+	mu       sync.Mutex
+	config   config.SecretsConfig
+	provider port.ISecrets
+	bus      *EventBus
+	clock    helper.Clock
+	secrets  map[string]*port.Secret
+	rotate   map[string][]func(secret *port.Secret)
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewSecrets creates secrets without provider until SetProvider
+func NewSecrets(cfg config.SecretsConfig, bus *EventBus, clock helper.Clock) *Secrets {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Refresh <= 0 {
+		cfg.Refresh = 5 * time.Minute
+	}
+	if cfg.RenewBefore <= 0 {
+		cfg.RenewBefore = time.Minute
+	}
+
+	return &Secrets{
+		config:  cfg,
+		bus:     bus,
+		clock:   clock,
+		secrets: make(map[string]*port.Secret),
+		rotate:  make(map[string][]func(secret *port.Secret)),
+	}
+}
+
+// SetProvider sets the secrets library
+func (s *Secrets) SetProvider(provider port.ISecrets) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.provider = provider
+}
+
+// Get returns the secret name, read from the provider on first use
+func (s *Secrets) Get(ctx context.Context, name string) (*port.Secret, error) {
+	s.mu.Lock()
+	secret, ok := s.secrets[name]
+	provider := s.provider
+	s.mu.Unlock()
+
+	if ok {
+		return secret, nil
+	}
+	if provider == nil {
+		return nil, ErrNoSecrets
+	}
+
+	secret, err := s.read(ctx, provider, name)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if cached, ok := s.secrets[name]; ok {
+		return cached, nil
+	}
+	s.secrets[name] = secret
+	return secret, nil
+}
+
+// Value returns the value of a reference: "name" for the value of a secret, "name#field" for
+// one of its fields
+func (s *Secrets) Value(ctx context.Context, ref string) (string, error) {
+	name, field, _ := strings.Cut(ref, "#")
+	secret, err := s.Get(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	value, ok := secret.Field(field)
+	if !ok {
+		return "", fmt.Errorf("secret '%s' has no field '%s'", name, field)
+	}
+	return value, nil
+}
+
+// OnRotate calls fn with the new version of the secret name each time it changes. Modules
+// keeping a value derived from a secret (ex: a signing key) refresh it there.
+func (s *Secrets) OnRotate(name string, fn func(secret *port.Secret)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rotate[name] = append(s.rotate[name], fn)
+}
+
+// Resolver returns the resolver of the configuration references, see config.SecretPrefix
+func (s *Secrets) Resolver() func(ref string) (string, error) {
+	return func(ref string) (string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), s.config.Timeout)
+		defer cancel()
+		return s.Value(ctx, ref)
+	}
+}
+
+// AddFieldKeys adds the key held by the secret secrets.field_key to fieldCipher. The new
+// versions of the secret become the current key, the previous ones still decrypt.
+func (s *Secrets) AddFieldKeys(ctx context.Context, fieldCipher *helper.FieldCipher) error {
+	secret, err := s.Get(ctx, s.config.FieldKey)
+	if err != nil {
+		return err
+	}
+	if err := addFieldKey(fieldCipher, secret); err != nil {
+		return err
+	}
+
+	s.OnRotate(s.config.FieldKey, func(secret *port.Secret) {
+		if err := addFieldKey(fieldCipher, secret); err != nil {
+			logger.Error("Rotated field key refused", "secret", secret.Name, "error", err)
+		}
+	})
+	return nil
+}
+
+func addFieldKey(fieldCipher *helper.FieldCipher, secret *port.Secret) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret.Value))
+	if err != nil {
+		return fmt.Errorf("field key '%s': %v", secret.Name, err)
+	}
+
+	id := secret.Version
+	if id == "" {
+		id = "1"
+	}
+	return fieldCipher.AddKey(id, key, true)
+}
+
+// AddSigningKeys adds the key held by the secret secrets.signing_key to signer. The new
+// versions of the secret sign the new values, the previous ones still verify.
+func (s *Secrets) AddSigningKeys(ctx context.Context, signer *helper.ValueSigner) error {
+	secret, err := s.Get(ctx, s.config.SigningKey)
+	if err != nil {
+		return err
+	}
+	if err := addSigningKey(signer, secret); err != nil {
+		return err
+	}
+
+	s.OnRotate(s.config.SigningKey, func(secret *port.Secret) {
+		if err := addSigningKey(signer, secret); err != nil {
+			logger.Error("Rotated signing key refused", "secret", secret.Name, "error", err)
+		}
+	})
+	return nil
+}
+
+func addSigningKey(signer *helper.ValueSigner, secret *port.Secret) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(secret.Value))
+	if err != nil {
+		return fmt.Errorf("signing key '%s': %v", secret.Name, err)
+	}
+
+	id := secret.Version
+	if id == "" {
+		id = "1"
+	}
+	return signer.AddKey(id, key, true)
+}
+
+// Start renews the leases and checks the rotations every secrets.refresh until Stop
+func (s *Secrets) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx != nil || s.provider == nil {
+		return
+	}
+	s.ctx, s.cancel = context.WithCancel(ctx)
+
+	s.wg.Add(1)
+	go s.refresh(s.ctx)
+}
+
+// Stop ends the renewals
+func (s *Secrets) Stop() {
+	s.mu.Lock()
+	cancel := s.cancel
+	s.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+	}
+	s.wg.Wait()
+}
+
+func (s *Secrets) refresh(ctx context.Context) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(s.config.Refresh):
+		}
+
+		s.Check(ctx)
+	}
+}
+
+// Check renews the leases expiring before the next check and reads the other secrets again,
+// a secret which cannot be read keeps its previous version
+func (s *Secrets) Check(ctx context.Context) {
+	s.mu.Lock()
+	provider := s.provider
+	names := slices.Sorted(maps.Keys(s.secrets))
+	s.mu.Unlock()
+
+	if provider == nil {
+		return
+	}
+
+	for _, name := range names {
+		if ctx.Err() != nil {
+			return
+		}
+
+		s.mu.Lock()
+		previous := s.secrets[name]
+		s.mu.Unlock()
+
+		if previous.Renewable && !previous.ExpiresAt.IsZero() {
+			if previous.ExpiresAt.Sub(s.clock.Now()) > s.config.Refresh+s.config.RenewBefore {
+				continue
+			}
+			renewed, err := s.renew(ctx, provider, previous)
+			if err == nil {
+				s.store(name, renewed, false)
+				continue
+			}
+			// a lease which cannot be extended anymore is replaced by new credentials
+			logger.Warn("Secret lease renewal failed", "secret", name, "error", err)
+		}
+
+		secret, err := s.read(ctx, provider, name)
+		if err != nil {
+			if ctx.Err() == nil {
+				logger.Error("Secret refresh failed", "secret", name, "error", err)
+			}
+			continue
+		}
+		s.store(name, secret, secret.Version != previous.Version || secret.Value != previous.Value || secret.LeaseID != previous.LeaseID)
+	}
+}
+
+// store replaces a secret, rotated calls the callbacks of OnRotate
+func (s *Secrets) store(name string, secret *port.Secret, rotated bool) {
+	s.mu.Lock()
+	s.secrets[name] = secret
+	callbacks := slices.Clone(s.rotate[name])
+	s.mu.Unlock()
+
+	if !rotated {
+		return
+	}
+
+	logger.Info("Secret rotated", "secret", name, "version", secret.Version)
+	for _, fn := range callbacks {
+		fn(secret)
+	}
+	if s.bus != nil {
+		s.bus.Publish(EventSecretRotated, SecretRotation{Name: name, Version: secret.Version})
+	}
+}
+
+func (s *Secrets) read(ctx context.Context, provider port.ISecrets, name string) (*port.Secret, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+
+	secret, err := provider.GetSecret(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("secret '%s': %w", name, err)
+	}
+	return secret, nil
+}
+
+func (s *Secrets) renew(ctx context.Context, provider port.ISecrets, secret *port.Secret) (*port.Secret, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.config.Timeout)
+	defer cancel()
+	return provider.RenewSecret(ctx, secret)
+}
+
+// loadSecrets loads the library of secrets.driver and resolves the references of cfg
+func loadSecrets(ctx context.Context, cfg *config.Config, libmanager *LibraryManager, secrets *Secrets) error {
+	if cfg.Secrets.Driver == "" {
+		return nil
+	}
+
+	loader, ok := libmanager.GetLoader("secrets:" + cfg.Secrets.Driver)
+	if !ok {
+		return fmt.Errorf("LibraryLoader 'secrets:%s' tidak ditemukan", cfg.Secrets.Driver)
+	}
+	library, err := libmanager.LoadSingletonFromLoader(loader, ctx, cfg.Secrets)
+	if err != nil {
+		return err
+	}
+	provider, ok := library.(port.ISecrets)
+	if !ok {
+		return fmt.Errorf("Library 'secrets:%s' bukan port.ISecrets", cfg.Secrets.Driver)
+	}
+	secrets.SetProvider(provider)
+	logger.Info("Library Secrets loaded", "driver", cfg.Secrets.Driver)
+
+	// the configurations of the modules are loaded later, they are resolved as well
+	config.SetSecretResolver(secrets.Resolver())
+	return config.ResolveSecrets(cfg)
+}
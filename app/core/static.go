@@ -0,0 +1,128 @@
+package core
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// precompressedFiles are the encodings looked up next to a file, by preference
+var precompressedFiles = []struct{ encoding, suffix string }{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// StaticFiles serves the files of cfg.Root under cfg.Path (ex: the build of a frontend), with
+// cfg.Index for the directories. With cfg.SPA the unknown paths without extension outside of
+// apiPrefix answer the index, so the router of the frontend handles them. The index is never
+// cached, the other files are cached cfg.MaxAge. The requests it does not answer go on to the
+// next handlers.
+func StaticFiles(cfg config.StaticConfig, apiPrefix string) fiber.Handler {
+	mount := "/" + strings.Trim(cfg.Path, "/")
+	apiPrefix = strings.TrimRight(apiPrefix, "/")
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		rel, ok := strings.CutPrefix(c.Path(), mount)
+		if !ok || (rel != "" && mount != "/" && !strings.HasPrefix(rel, "/")) {
+			return c.Next()
+		}
+		if apiPrefix != "" && (c.Path() == apiPrefix || strings.HasPrefix(c.Path(), apiPrefix+"/")) {
+			return c.Next()
+		}
+
+		// path.Clean drops the ".." leaving the root
+		name := filepath.Join(cfg.Root, filepath.FromSlash(path.Clean("/"+rel)))
+		info, err := os.Stat(name)
+		if err == nil && info.IsDir() {
+			name = filepath.Join(name, cfg.Index)
+			info, err = os.Stat(name)
+		}
+		if err != nil || info.IsDir() {
+			if !cfg.SPA || path.Ext(rel) != "" {
+				return c.Next()
+			}
+			name = filepath.Join(cfg.Root, cfg.Index)
+		}
+
+		return sendStatic(c, cfg, name)
+	}
+}
+
+// sendStatic sends the file name, or the precompressed file next to it the client accepts,
+// with its cache headers
+func sendStatic(c *fiber.Ctx, cfg config.StaticConfig, name string) error {
+	switch {
+	case filepath.Base(name) == cfg.Index:
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+	case cfg.MaxAge > 0:
+		cacheControl := fmt.Sprintf("public, max-age=%d", int(cfg.MaxAge.Seconds()))
+		if cfg.Immutable {
+			cacheControl += ", immutable"
+		}
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+	}
+
+	// SendFile drops Accept-Encoding, restored for the compression of the other files
+	accept := c.Get(fiber.HeaderAcceptEncoding)
+	defer c.Request().Header.Set(fiber.HeaderAcceptEncoding, accept)
+
+	if cfg.Precompressed {
+		c.Vary(fiber.HeaderAcceptEncoding)
+		for _, file := range precompressedFiles {
+			if !acceptsEncoding(accept, file.encoding) {
+				continue
+			}
+			if info, err := os.Stat(name + file.suffix); err != nil || info.IsDir() {
+				continue
+			}
+
+			if err := c.SendFile(name+file.suffix, false); err != nil {
+				return err
+			}
+			c.Type(strings.TrimPrefix(filepath.Ext(name), "."))
+			c.Set(fiber.HeaderContentEncoding, file.encoding)
+			return nil
+		}
+	}
+
+	return c.SendFile(name, false)
+}
+
+// acceptsEncoding reports whether the Accept-Encoding header accept allows encoding
+func acceptsEncoding(accept string, encoding string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(name), encoding) {
+			continue
+		}
+		// q=0 refuses the encoding
+		q := strings.ReplaceAll(strings.TrimSpace(params), " ", "")
+		return q != "q=0" && q != "q=0.0" && q != "q=0.00" && q != "q=0.000"
+	}
+	return false
+}
+
+// setupStatic serves the files of app.static after every other route, its SPA fallback only
+// answers the paths they leave
+func (a *App) setupStatic() error {
+	cfg := a.Context.Config.App.Static
+	if cfg.Index == "" {
+		cfg.Index = "index.html"
+	}
+	info, err := os.Stat(cfg.Root)
+	if err != nil || !info.IsDir() {
+		return fmt.Errorf("app.static.root: '%s' is not a directory", cfg.Root)
+	}
+
+	a.Context.Web.Use(StaticFiles(cfg, a.Context.Config.Server.PathPrefix))
+	return nil
+}
@@ -0,0 +1,278 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Task states
+const (
+	TaskPending   = "pending" // queued, or waiting for a retry after a failed attempt
+	TaskRunning   = "running"
+	TaskSucceeded = "succeeded"
+	TaskFailed    = "failed"
+)
+
+// Task is the status of a long-running operation, exposed on <app.tasks.path>/<id>
+type Task struct {
+	ID         string     `json:"id"`
+	Type       string     `json:"type"`
+	State      string     `json:"state"`
+	Progress   int        `json:"progress"` // percent
+	Message    string     `json:"message,omitempty"`
+	Result     any        `json:"result,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	Attempts   int        `json:"attempts"`
+	Owner      string     `json:"owner,omitempty"` // user allowed to read the status, anyone when empty
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// TaskProgress reports how far a task is, percent between 0 and 100
+type TaskProgress func(percent int, message string)
+
+type taskJob[T any] struct {
+	TaskID  string `json:"task_id"`
+	Payload T      `json:"payload"`
+}
+
+type taskStore interface {
+	Set(key string, value any, ttl time.Duration) error
+	Get(key string, outvalue any) bool
+}
+
+// Tasks runs long operations on the job queue and keeps their status, progress and result
+// so clients poll a single endpoint instead of one scheme per module
+type Tasks struct {
+	mu       sync.RWMutex
+	config   config.TasksConfig
+	location string
+	queue    *JobQueue
+	clock    helper.Clock
+	store    taskStore
+}
+
+// NewTasks creates tasks kept in-process, prefix is the path prefix of the status endpoint
+func NewTasks(cfg config.TasksConfig, prefix string, queue *JobQueue, clock helper.Clock) *Tasks {
+	return &Tasks{
+		config:   cfg,
+		location: prefix + cfg.Path + "/",
+		queue:    queue,
+		clock:    clock,
+		store:    newLocalTaskStore(clock),
+	}
+}
+
+// SetStore keeps the tasks in a cache shared by the instances
+func (t *Tasks) SetStore(store port.ICacheMemory) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.store = store
+}
+
+// HandleTask registers the handler of a task type. Its result is stored as the task result
+// and an error fails the attempt, retried according to the policy like any job.
+func HandleTask[T any](t *Tasks, taskType string, handler func(ctx context.Context, progress TaskProgress, payload T) (any, error), policy ...RetryPolicy) {
+	HandleJob(t.queue, "task:"+taskType, func(ctx context.Context, job taskJob[T]) error {
+		task, err := t.Get(ctx, job.TaskID)
+		if err != nil {
+			return err
+		}
+		if task == nil {
+			// expired before a worker got to it, nobody polls it anymore
+			return nil
+		}
+
+		attempt, maxAttempts := JobAttempt(ctx)
+		task.State = TaskRunning
+		task.Attempts = attempt
+		task.Error = ""
+		if err := t.save(task); err != nil {
+			return err
+		}
+
+		progress := func(percent int, message string) {
+			task.Progress = min(max(percent, 0), 100)
+			task.Message = message
+			_ = t.save(task)
+		}
+
+		result, err := handler(ctx, progress, job.Payload)
+		if err != nil {
+			task.Error = err.Error()
+			task.State = TaskPending
+			if attempt >= maxAttempts {
+				task.State = TaskFailed
+				task.finish(t.clock.Now())
+			}
+			_ = t.save(task)
+			return err
+		}
+
+		task.State = TaskSucceeded
+		task.Progress = 100
+		task.Result = result
+		task.finish(t.clock.Now())
+		return t.save(task)
+	}, policy...)
+}
+
+// Enqueue creates a task readable by owner (empty for anyone) and queues its job
+func (t *Tasks) Enqueue(ctx context.Context, taskType string, payload any, owner string, opts ...EnqueueOptions) (*Task, error) {
+	id, err := helper.GenerateUUID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := t.clock.Now()
+	task := &Task{
+		ID:        id,
+		Type:      taskType,
+		State:     TaskPending,
+		Owner:     owner,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	if err := t.save(task); err != nil {
+		return nil, err
+	}
+
+	opt := EnqueueOptions{Queue: t.config.Queue}
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if _, err := t.queue.Enqueue(ctx, "task:"+taskType, taskJob[any]{TaskID: id, Payload: payload}, opt); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// Accept queues a task owned by the current user and answers 202 Accepted with the task,
+// the Location header points to its status
+func (t *Tasks) Accept(c *fiber.Ctx, taskType string, payload any, opts ...EnqueueOptions) error {
+	task, err := t.Enqueue(c.UserContext(), taskType, payload, auth.CurrentUserID(c), opts...)
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderLocation, t.location+task.ID)
+	response := out.SuccessData(task)
+	response.HttpCode = fiber.StatusAccepted
+	return out.Send(c, response)
+}
+
+// Get returns a task, nil when it does not exist or expired
+func (t *Tasks) Get(ctx context.Context, id string) (*Task, error) {
+	t.mu.RLock()
+	store := t.store
+	t.mu.RUnlock()
+
+	var task Task
+	if !store.Get(taskKey(id), &task) {
+		return nil, nil
+	}
+	return &task, nil
+}
+
+// Handler answers the status of the task in the :id parameter, tasks of other users are not found
+func (t *Tasks) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		task, err := t.Get(c.UserContext(), c.Params("id"))
+		if err != nil {
+			return err
+		}
+		if task == nil || (task.Owner != "" && task.Owner != auth.CurrentUserID(c)) {
+			return fiber.NewError(fiber.StatusNotFound, "Task not found")
+		}
+		return out.Send(c, out.SuccessData(task))
+	}
+}
+
+func (t *Tasks) save(task *Task) error {
+	t.mu.RLock()
+	store := t.store
+	t.mu.RUnlock()
+
+	task.UpdatedAt = t.clock.Now()
+	if err := store.Set(taskKey(task.ID), task, t.config.TTL); err != nil {
+		return fmt.Errorf("failed to save task %s: %v", task.ID, err)
+	}
+	return nil
+}
+
+func (task *Task) finish(now time.Time) {
+	task.FinishedAt = &now
+}
+
+func taskKey(id string) string {
+	return "task:" + id
+}
+
+// localTaskStore keeps tasks JSON encoded in memory, like a cache library would
+type localTaskStore struct {
+	mu      sync.Mutex
+	clock   helper.Clock
+	entries map[string]localTaskEntry
+	purged  time.Time
+}
+
+type localTaskEntry struct {
+	data    []byte
+	expires time.Time
+}
+
+func newLocalTaskStore(clock helper.Clock) *localTaskStore {
+	return &localTaskStore{
+		clock:   clock,
+		entries: make(map[string]localTaskEntry),
+	}
+}
+
+func (s *localTaskStore) Set(key string, value any, ttl time.Duration) error {
+	data, err := helper.JSONMarshal(value)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// drop expired tasks from time to time, progress updates are frequent
+	now := s.clock.Now()
+	if now.Sub(s.purged) >= time.Minute {
+		for k, entry := range s.entries {
+			if !entry.expires.IsZero() && !now.Before(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.purged = now
+	}
+
+	entry := localTaskEntry{data: data}
+	if ttl > 0 {
+		entry.expires = now.Add(ttl)
+	}
+	s.entries[key] = entry
+	return nil
+}
+
+func (s *localTaskStore) Get(key string, outvalue any) bool {
+	s.mu.Lock()
+	entry, ok := s.entries[key]
+	s.mu.Unlock()
+
+	if !ok || (!entry.expires.IsZero() && !s.clock.Now().Before(entry.expires)) {
+		return false
+	}
+	return helper.JSONUnmarshal(entry.data, outvalue) == nil
+}
@@ -0,0 +1,117 @@
+package core
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// ConfigTenantStore is the port.ITenantStore of the tenants declared in app.tenancy.tenants
+type ConfigTenantStore struct {
+	tenants map[string]*port.Tenant
+}
+
+// NewConfigTenantStore creates a store of the tenants of the configuration
+func NewConfigTenantStore(tenants map[string]config.TenantConfig) *ConfigTenantStore {
+	store := &ConfigTenantStore{tenants: make(map[string]*port.Tenant, len(tenants))}
+	for id, tenant := range tenants {
+		store.tenants[id] = &port.Tenant{
+			ID:        id,
+			Name:      tenant.Name,
+			Database:  tenant.Database,
+			Schema:    tenant.Schema,
+			RateLimit: tenant.RateLimit,
+			Settings:  tenant.Settings,
+		}
+	}
+	return store
+}
+
+func (s *ConfigTenantStore) Get(ctx context.Context, id string) (*port.Tenant, error) {
+	return s.tenants[id], nil
+}
+
+// TenantDatabase returns the database of the tenant of ctx: its own database (connected on first
+// use with the configuration of the default one), the default database on its schema, or the
+// default database without tenant. Repositories of multi-tenant modules get it per request:
+//
+//	db, err := m.context.TenantDatabase(c.UserContext())
+func (a *AppContext) TenantDatabase(ctx context.Context) (port.IDatabase, error) {
+	library, ok := a.GetDefaultSingletonInstance("database")
+	if !ok {
+		return nil, fmt.Errorf("Library 'database' tidak ditemukan")
+	}
+
+	tenant := helper.Tenant(ctx)
+	if tenant == nil || (tenant.Database == "" && tenant.Schema == "") {
+		return a.guardDatabase(library.(port.IDatabase), "database"), nil
+	}
+
+	key := "tenant:" + tenant.ID
+	if library, ok := a.GetDefaultInstance("database", key); ok {
+		return a.guardDatabase(library.(port.IDatabase), "database:"+key), nil
+	}
+
+	// two requests of a new tenant open a single connection
+	a.tenantMu.Lock()
+	defer a.tenantMu.Unlock()
+	if library, ok := a.GetDefaultInstance("database", key); ok {
+		return a.guardDatabase(library.(port.IDatabase), "database:"+key), nil
+	}
+
+	cfg := a.Config.Database
+	cfg.SlaveHosts = nil
+	if tenant.Database != "" {
+		cfg.Uri = tenant.Database
+	}
+	if tenant.Schema != "" {
+		cfg.SchemaName = tenant.Schema
+	}
+
+	library, err := a.startDatabase(ctx, key, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("database of tenant '%s': %v", tenant.ID, err)
+	}
+
+	logger.Info("Library Database loaded", "driver", cfg.Driver, "tenant", tenant.ID)
+	return a.guardDatabase(library.(port.IDatabase), "database:"+key), nil
+}
+
+// startDatabase loads the database library of cfg, under key for a tenant or as the default
+// database when key is empty. A failed connection is tried again when app.retry has a
+// "database" policy.
+func (a *AppContext) startDatabase(ctx context.Context, key string, cfg config.DatabaseConfig) (port.Library, error) {
+	var library port.Library
+	err := a.Retries.Do(ctx, RetryDatabase, RetryPolicy{MaxAttempts: 1}, func(ctx context.Context) error {
+		var err error
+		if key == "" {
+			library, err = a.StartDefaultSingletonInstance("database", a.Context, cfg)
+		} else {
+			library, err = a.StartDefaultInstance("database", key, a.Context, cfg)
+		}
+		if err != nil {
+			logger.Warn("Database connection failed", "driver", cfg.Driver, "key", key, "error", err)
+		}
+		return err
+	})
+	return library, err
+}
+
+// guardDatabase applies the "database" policy of app.resilience to db when it is configured,
+// the databases of the tenants have their own breaker and bulkhead named after them. The faults
+// of app.chaos come first, so the policy sees them.
+func (a *AppContext) guardDatabase(db port.IDatabase, name string) port.IDatabase {
+	if a.Chaos.Enabled() {
+		db = GuardDatabase(db, a.Chaos.Target(name))
+	}
+
+	cfg, ok := a.Config.App.Resilience.Policies["database"]
+	if !ok {
+		return db
+	}
+	return GuardDatabase(db, a.Resilience.policyOf(name, cfg))
+}
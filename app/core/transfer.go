@@ -0,0 +1,148 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// fileTransferMu serializes the creation of connections, the library manager is not safe for concurrent use
+var fileTransferMu sync.Mutex
+
+// FileTransfer is a file-transfer connection to a partner configured in transfers, served by
+// the "transfer:<driver>" library. Failed operations are retried unless the remote file does
+// not exist: uploads only when the content can be rewound, downloads only while nothing was
+// written yet. Get connections with AppContext.FileTransfer, for example in a scheduled job.
+type FileTransfer struct {
+	Name   string
+	driver port.IFileTransfer
+	retry  config.TransferRetryConfig
+	clock  helper.Clock
+}
+
+// FileTransfer returns the connection configured as name in transfers, loading its driver on first use
+func (a *AppContext) FileTransfer(name string) (*FileTransfer, error) {
+	cfg, ok := a.Config.Transfers[name]
+	if !ok {
+		return nil, fmt.Errorf("File transfer '%s' is not configured", name)
+	}
+
+	library := "transfer:" + cfg.Driver
+	fileTransferMu.Lock()
+	instance, err := a.StartInstance(library, name, a, cfg)
+	fileTransferMu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	driver, ok := instance.(port.IFileTransfer)
+	if !ok {
+		return nil, fmt.Errorf("Library '%s' bukan port.IFileTransfer", library)
+	}
+
+	if cfg.Retry.MaxAttempts <= 0 {
+		cfg.Retry.MaxAttempts = 3
+	}
+	if cfg.Retry.Backoff <= 0 {
+		cfg.Retry.Backoff = time.Second
+	}
+	if cfg.Retry.MaxBackoff <= 0 {
+		cfg.Retry.MaxBackoff = 30 * time.Second
+	}
+
+	return &FileTransfer{Name: name, driver: driver, retry: cfg.Retry, clock: a.Clock}, nil
+}
+
+func (t *FileTransfer) List(ctx context.Context, dir string) ([]port.RemoteFile, error) {
+	var files []port.RemoteFile
+	err := t.do(ctx, "list", dir, func() (bool, error) {
+		var err error
+		files, err = t.driver.List(ctx, dir)
+		return true, err
+	})
+	return files, err
+}
+
+func (t *FileTransfer) Get(ctx context.Context, path string, w io.Writer) error {
+	counter := &countingWriter{w: w}
+	return t.do(ctx, "get", path, func() (bool, error) {
+		err := t.driver.Get(ctx, path, counter)
+		return counter.n == 0, err
+	})
+}
+
+func (t *FileTransfer) Put(ctx context.Context, path string, content io.Reader) error {
+	seeker, rewindable := content.(io.Seeker)
+	var start int64
+	if rewindable {
+		var err error
+		if start, err = seeker.Seek(0, io.SeekCurrent); err != nil {
+			rewindable = false
+		}
+	}
+
+	attempt := 0
+	return t.do(ctx, "put", path, func() (bool, error) {
+		attempt++
+		if attempt > 1 {
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				return false, err
+			}
+		}
+		return rewindable, t.driver.Put(ctx, path, content)
+	})
+}
+
+func (t *FileTransfer) Move(ctx context.Context, from string, to string) error {
+	return t.do(ctx, "move", from, func() (bool, error) {
+		return true, t.driver.Move(ctx, from, to)
+	})
+}
+
+func (t *FileTransfer) Delete(ctx context.Context, path string) error {
+	return t.do(ctx, "delete", path, func() (bool, error) {
+		return true, t.driver.Delete(ctx, path)
+	})
+}
+
+// do runs an operation until it succeeds, attempts are exhausted or it says it cannot be retried
+func (t *FileTransfer) do(ctx context.Context, op string, path string, fn func() (bool, error)) error {
+	policy := RetryPolicy{Backoff: t.retry.Backoff, MaxBackoff: t.retry.MaxBackoff}
+
+	for attempt := 1; ; attempt++ {
+		retryable, err := fn()
+		if err == nil {
+			return nil
+		}
+		if !retryable || attempt >= t.retry.MaxAttempts || ctx.Err() != nil || errors.Is(err, port.ErrRemoteFileNotFound) {
+			return fmt.Errorf("%s %s %s: %w", t.Name, op, path, err)
+		}
+
+		logger.Warn("Retrying file transfer", "name", t.Name, "op", op, "path", path, "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-t.clock.After(policy.Delay(attempt)):
+		}
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
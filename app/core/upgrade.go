@@ -0,0 +1,286 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// EventLibraryUpgraded is published on the EventBus with the LibraryUpgrade once cut over
+const EventLibraryUpgraded = "library.upgraded"
+
+// States of a LibraryUpgrade
+const (
+	UpgradeStaged  = "staged"  // both versions run side by side
+	UpgradeCutover = "cutover" // the old version is paused, then drained
+	UpgradeDone    = "done"
+	UpgradeAborted = "aborted"
+)
+
+// LibraryUpgrade is the rolling upgrade of an instance of a keyed library
+type LibraryUpgrade struct {
+	Library    string     `json:"library"`
+	Key        string     `json:"key"`
+	Version    string     `json:"version"` // of the new instance
+	State      string     `json:"state"`
+	Error      string     `json:"error,omitempty"` // of the last failed cutover
+	StagedAt   time.Time  `json:"staged_at"`
+	SwitchedAt *time.Time `json:"switched_at,omitempty"`
+}
+
+// Upgrades runs two versions of an instance of a keyed library side by side during a deploy
+// (ex: the old and the new consumer group of a topic), their messages and data must suit both.
+// The module owning the instance stages the new version with Stage, the cutover, asked on the
+// admin API or by app.upgrades.cutover, pauses the old version (port.Pausable), resumes the new
+// one, puts it under the key of the instance, then drains (port.Drainer) and closes the old one.
+// The users of the instance follow the cutover when they get it from the LibraryManager for
+// each use instead of keeping it.
+type Upgrades struct {
+	mu       sync.Mutex
+	config   config.UpgradesConfig
+	manager  *LibraryManager
+	bus      *EventBus
+	clock    helper.Clock
+	upgrades map[string]*LibraryUpgrade // by "<library>/<key>"
+	stop     chan struct{}
+	stopOnce sync.Once
+}
+
+// NewUpgrades creates the coordinator of the upgrades of the libraries of manager
+func NewUpgrades(cfg config.UpgradesConfig, manager *LibraryManager, bus *EventBus, clock helper.Clock) *Upgrades {
+	return &Upgrades{
+		config:   cfg,
+		manager:  manager,
+		bus:      bus,
+		clock:    clock,
+		upgrades: make(map[string]*LibraryUpgrade),
+		stop:     make(chan struct{}),
+	}
+}
+
+func upgradeID(library string, key string) string {
+	return library + "/" + key
+}
+
+// stagedKey is the key of the new version of the instance key until the cutover
+func stagedKey(key string, version string) string {
+	return key + "@" + version
+}
+
+// Stage loads version of the instance key of library next to the current one, args are given
+// to the loader like for LoadInstance. When app.upgrades.cutover names this version, the
+// cutover follows after app.upgrades.delay.
+func (u *Upgrades) Stage(library string, key string, version string, args ...any) (port.Library, error) {
+	if version == "" {
+		return nil, fmt.Errorf("version of the upgrade is required")
+	}
+	loader, ok := u.manager.GetLoader(library)
+	if !ok {
+		return nil, fmt.Errorf("LibraryLoader '%s' tidak ditemukan", library)
+	}
+	if _, ok := u.manager.GetInstance(library, key); !ok {
+		return nil, fmt.Errorf("instance '%s' of library '%s' is not loaded", key, library)
+	}
+
+	id := upgradeID(library, key)
+	u.mu.Lock()
+	if current, ok := u.upgrades[id]; ok && (current.State == UpgradeStaged || current.State == UpgradeCutover) {
+		u.mu.Unlock()
+		return nil, fmt.Errorf("instance '%s' of library '%s' is already upgrading to %s", key, library, current.Version)
+	}
+	u.upgrades[id] = &LibraryUpgrade{
+		Library:  library,
+		Key:      key,
+		Version:  version,
+		State:    UpgradeStaged,
+		StagedAt: u.clock.Now(),
+	}
+	u.mu.Unlock()
+
+	instance, err := u.manager.LoadInstanceFromLoader(loader, stagedKey(key, version), args...)
+	if err != nil {
+		u.mu.Lock()
+		delete(u.upgrades, id)
+		u.mu.Unlock()
+		return nil, err
+	}
+	logger.Info("Library upgrade staged", "library", library, "key", key, "version", version)
+
+	if u.config.Cutover[id] == version {
+		go u.scheduleCutover(library, key)
+	}
+	return instance, nil
+}
+
+// scheduleCutover cuts over after app.upgrades.delay, unless the app stops first
+func (u *Upgrades) scheduleCutover(library string, key string) {
+	if u.config.Delay > 0 {
+		select {
+		case <-u.stop:
+			return
+		case <-u.clock.After(u.config.Delay):
+		}
+	}
+
+	if err := u.Cutover(context.Background(), library, key); err != nil {
+		logger.Error("Library cutover failed", "library", library, "key", key, "error", err)
+	}
+}
+
+// Cutover switches the instance key of library to its staged version. When the old version
+// cannot be paused or the new one started, the old one keeps running and the upgrade stays
+// staged with the error.
+func (u *Upgrades) Cutover(ctx context.Context, library string, key string) error {
+	u.mu.Lock()
+	upgrade, ok := u.upgrades[upgradeID(library, key)]
+	if !ok || upgrade.State != UpgradeStaged {
+		u.mu.Unlock()
+		return fmt.Errorf("no upgrade of instance '%s' of library '%s' is staged", key, library)
+	}
+	upgrade.State = UpgradeCutover
+	upgrade.Error = ""
+	version := upgrade.Version
+	u.mu.Unlock()
+
+	err := u.cutover(ctx, library, key, version)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if err != nil {
+		upgrade.State = UpgradeStaged
+		upgrade.Error = err.Error()
+		return err
+	}
+
+	now := u.clock.Now()
+	upgrade.State = UpgradeDone
+	upgrade.SwitchedAt = &now
+	logger.Info("Library upgraded", "library", library, "key", key, "version", version)
+	u.bus.Publish(EventLibraryUpgraded, *upgrade)
+	return nil
+}
+
+func (u *Upgrades) cutover(ctx context.Context, library string, key string, version string) error {
+	staged := stagedKey(key, version)
+	current, ok := u.manager.GetInstance(library, key)
+	if !ok {
+		return fmt.Errorf("instance '%s' of library '%s' is not loaded", key, library)
+	}
+	next, ok := u.manager.GetInstance(library, staged)
+	if !ok {
+		return fmt.Errorf("version %s of instance '%s' of library '%s' is not loaded", version, key, library)
+	}
+
+	// the old version stops taking work
+	if pausable, ok := current.(port.Pausable); ok {
+		if err := pausable.Pause(ctx); err != nil {
+			return fmt.Errorf("pause the current version: %v", err)
+		}
+	}
+
+	// the new version takes it
+	if pausable, ok := next.(port.Pausable); ok {
+		if err := pausable.Resume(ctx); err != nil {
+			if pausable, ok := current.(port.Pausable); ok {
+				if err := pausable.Resume(ctx); err != nil {
+					logger.Error("Current library version not resumed", "library", library, "key", key, "error", err)
+				}
+			}
+			return fmt.Errorf("start version %s: %v", version, err)
+		}
+	}
+
+	previous, err := u.manager.SwapInstance(library, key, staged)
+	if err != nil {
+		return err
+	}
+
+	// the work in flight of the old version ends before it is closed
+	if drainer, ok := previous.(port.Drainer); ok {
+		if err := WithTimeout(ctx, u.config.DrainTimeout, drainer.Drain); err != nil {
+			logger.Warn("Previous library version not drained", "library", library, "key", key, "error", err)
+		}
+	}
+	if err := closeLibrary(library, previous); err != nil {
+		logger.Warn("Previous library version not closed", "library", library, "key", key, "error", err)
+	}
+	return nil
+}
+
+// Abort unloads the staged version of the instance key of library, the current one goes on
+func (u *Upgrades) Abort(library string, key string) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	upgrade, ok := u.upgrades[upgradeID(library, key)]
+	if !ok || upgrade.State != UpgradeStaged {
+		return fmt.Errorf("no upgrade of instance '%s' of library '%s' is staged", key, library)
+	}
+	if err := u.manager.UnloadNamedInstance(library, stagedKey(key, upgrade.Version)); err != nil {
+		return err
+	}
+
+	upgrade.State = UpgradeAborted
+	logger.Info("Library upgrade aborted", "library", library, "key", key, "version", upgrade.Version)
+	return nil
+}
+
+// List returns the upgrades of this instance by library and key
+func (u *Upgrades) List() []LibraryUpgrade {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	result := make([]LibraryUpgrade, 0, len(u.upgrades))
+	for _, id := range slices.Sorted(maps.Keys(u.upgrades)) {
+		result = append(result, *u.upgrades[id])
+	}
+	return result
+}
+
+// Stop cancels the cutovers waiting for app.upgrades.delay
+func (u *Upgrades) Stop() {
+	u.stopOnce.Do(func() { close(u.stop) })
+}
+
+// RegisterAdminRoutes serves the upgrades on router: the list, the cutover and the abort of the
+// staged version of an instance
+func (u *Upgrades) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/upgrades", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(u.List()))
+	})
+
+	router.Post("/upgrades/:library/:key/cutover", func(c *fiber.Ctx) error {
+		library, key := c.Params("library"), c.Params("key")
+		if err := u.Cutover(c.UserContext(), library, key); err != nil {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return out.Send(c, out.SuccessData(u.find(library, key)))
+	})
+
+	router.Delete("/upgrades/:library/:key", func(c *fiber.Ctx) error {
+		if err := u.Abort(c.Params("library"), c.Params("key")); err != nil {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return out.Send(c, out.SuccessMessage("Library upgrade aborted"))
+	})
+}
+
+func (u *Upgrades) find(library string, key string) LibraryUpgrade {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if upgrade, ok := u.upgrades[upgradeID(library, key)]; ok {
+		return *upgrade
+	}
+	return LibraryUpgrade{}
+}
@@ -0,0 +1,540 @@
+package core
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// usageKey identifies the totals of a period
+type usageKey struct {
+	window    string
+	start     time.Time
+	principal string
+	apiKey    string
+	route     string
+}
+
+// usageCounter is the total of a period counted by the instance and the part already saved
+type usageCounter struct {
+	total port.UsageRecord
+	saved port.UsageRecord
+	end   time.Time
+}
+
+// quotaUsage is the usage of a principal in the current period of its quota
+type quotaUsage struct {
+	start    time.Time
+	loadedAt time.Time
+	requests int64
+	bytes    int64
+}
+
+// Usage counts the requests and bytes of the authenticated clients per route, rolled up in
+// the periods of the windows of app.usage.windows. The totals are saved in the usage store
+// every app.usage.flush_interval, where they are queried, and the quotas of the principals
+// refuse their requests with 429 once reached.
+type Usage struct {
+	mu       sync.Mutex
+	config   config.UsageConfig
+	apiKey   string // header of the API keys
+	store    port.IUsageStore
+	clock    helper.Clock
+	instance string
+	windows  map[string]time.Duration
+	counters map[usageKey]*usageCounter
+	quotas   map[string]*quotaUsage
+	ctx      context.Context
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+// NewUsage creates the usage counters kept in memory until SetStore
+func NewUsage(cfg config.UsageConfig, apiKeyHeader string, clock helper.Clock) *Usage {
+	if len(cfg.Windows) == 0 {
+		cfg.Windows = []string{"1h"}
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 10 * time.Second
+	}
+	if cfg.Retention <= 0 {
+		cfg.Retention = 7 * 24 * time.Hour
+	}
+	if apiKeyHeader == "" {
+		apiKeyHeader = "X-API-Key"
+	}
+
+	hostname, _ := os.Hostname()
+	return &Usage{
+		config:   cfg,
+		apiKey:   apiKeyHeader,
+		store:    NewMemoryUsageStore(clock, cfg.Retention),
+		clock:    clock,
+		instance: fmt.Sprintf("%s-%d", hostname, os.Getpid()),
+		windows:  map[string]time.Duration{},
+		counters: map[usageKey]*usageCounter{},
+		quotas:   map[string]*quotaUsage{},
+	}
+}
+
+// Validate parses the windows and checks the quotas refer to one of them
+func (u *Usage) Validate() error {
+	for _, window := range u.config.Windows {
+		d, err := time.ParseDuration(window)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("app.usage.windows: invalid window %q", window)
+		}
+		u.windows[window] = d
+	}
+	for principal, quota := range u.config.Quotas {
+		if _, ok := u.windows[quota.Window]; !ok {
+			return fmt.Errorf("app.usage.quotas.%s: window %q is not one of app.usage.windows", principal, quota.Window)
+		}
+	}
+	return nil
+}
+
+// SetStore replaces the store of the usage, must be called before Start
+func (u *Usage) SetStore(store port.IUsageStore) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	u.store = store
+}
+
+// Start saves the totals every app.usage.flush_interval until Stop
+func (u *Usage) Start(ctx context.Context) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if u.ctx != nil {
+		return
+	}
+	u.ctx, u.cancel = context.WithCancel(ctx)
+
+	u.wg.Add(1)
+	go u.run(u.ctx)
+}
+
+// Stop ends the flushes and saves the last totals
+func (u *Usage) Stop() {
+	u.mu.Lock()
+	cancel := u.cancel
+	u.mu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+	cancel()
+	u.wg.Wait()
+
+	ctx, done := context.WithTimeout(context.Background(), 10*time.Second)
+	defer done()
+	if err := u.Flush(ctx); err != nil {
+		logger.Error("Usage flush failed", "error", err)
+	}
+}
+
+func (u *Usage) run(ctx context.Context) {
+	defer u.wg.Done()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-u.clock.After(u.config.FlushInterval):
+		}
+
+		if err := u.Flush(ctx); err != nil && ctx.Err() == nil {
+			logger.Error("Usage flush failed", "error", err)
+		}
+	}
+}
+
+// Flush saves the totals changed since the last flush, the totals of the ended periods are
+// forgotten once saved
+func (u *Usage) Flush(ctx context.Context) error {
+	u.mu.Lock()
+	store := u.store
+	keys := []usageKey{}
+	records := []port.UsageRecord{}
+	for key, counter := range u.counters {
+		if counter.total != counter.saved {
+			keys = append(keys, key)
+			records = append(records, counter.total)
+		}
+	}
+	u.mu.Unlock()
+
+	if len(records) > 0 {
+		if err := store.Save(ctx, records); err != nil {
+			return err
+		}
+	}
+
+	now := u.clock.Now()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for i, key := range keys {
+		if counter, ok := u.counters[key]; ok {
+			counter.saved = records[i]
+		}
+	}
+	for key, counter := range u.counters {
+		if counter.total == counter.saved && !now.Before(counter.end) {
+			delete(u.counters, key)
+		}
+	}
+	return nil
+}
+
+// Middleware counts the requests of the protected routes and enforces the quotas of app.usage.quotas,
+// it follows the authentication
+func (u *Usage) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := auth.CurrentUserID(c)
+		apiKey := ""
+		if key := c.Get(u.apiKey); key != "" {
+			apiKey = fingerprint(key)
+		}
+
+		if quota, ok := u.quotaOf(principal); ok {
+			used, reset, err := u.quotaUsage(c.UserContext(), principal, quota)
+			if err != nil {
+				// the requests are not refused because the store is unavailable
+				logger.Warn("Usage quota not checked", "principal", principal, "error", err)
+			} else {
+				if quota.Requests > 0 {
+					c.Set("X-Quota-Limit", strconv.FormatInt(quota.Requests, 10))
+					c.Set("X-Quota-Remaining", strconv.FormatInt(max(quota.Requests-used.requests, 0), 10))
+				}
+				c.Set("X-Quota-Reset", reset.UTC().Format(time.RFC3339))
+
+				if (quota.Requests > 0 && used.requests >= quota.Requests) || (quota.Bytes > 0 && used.bytes >= quota.Bytes) {
+					c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(int64(max(reset.Sub(u.clock.Now()), time.Second)/time.Second), 10))
+					return fiber.NewError(fiber.StatusTooManyRequests, "Usage quota exceeded until "+reset.UTC().Format(time.RFC3339))
+				}
+			}
+		}
+
+		err := c.Next()
+
+		status := c.Response().StatusCode()
+		if err != nil {
+			// the status is set by the error handler afterwards
+			status = fiber.StatusInternalServerError
+			var e *fiber.Error
+			var response *out.Response
+			if errors.As(err, &e) {
+				status = e.Code
+			} else if errors.As(err, &response) && response.HttpCode != 0 {
+				status = response.HttpCode
+			}
+		}
+
+		u.Record(port.UsageRecord{
+			Principal: principal,
+			APIKey:    apiKey,
+			Route:     c.Method() + " " + c.Route().Path,
+			Requests:  1,
+			Errors:    boolCount(status >= 400),
+			BytesIn:   int64(len(c.Request().Body())),
+			BytesOut:  int64(len(c.Response().Body())),
+		})
+		return err
+	}
+}
+
+// fingerprint identifies an API key without keeping it
+func fingerprint(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
+
+func boolCount(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Record adds the counters of record (Requests, Errors, BytesIn, BytesOut) to the current period
+// of every window, for its principal, API key and route
+func (u *Usage) Record(record port.UsageRecord) {
+	now := u.clock.Now()
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for window, d := range u.windows {
+		start := now.UTC().Truncate(d)
+		key := usageKey{window: window, start: start, principal: record.Principal, apiKey: record.APIKey, route: record.Route}
+		counter, ok := u.counters[key]
+		if !ok {
+			counter = &usageCounter{
+				total: port.UsageRecord{
+					Window:    window,
+					Start:     start,
+					Principal: strings.Clone(record.Principal),
+					APIKey:    record.APIKey,
+					Route:     strings.Clone(record.Route),
+					Instance:  u.instance,
+				},
+				end: start.Add(d),
+			}
+			counter.saved = counter.total
+			u.counters[key] = counter
+		}
+		counter.total.Requests += record.Requests
+		counter.total.Errors += record.Errors
+		counter.total.BytesIn += record.BytesIn
+		counter.total.BytesOut += record.BytesOut
+	}
+
+	if usage, ok := u.quotas[record.Principal]; ok {
+		usage.requests += record.Requests
+		usage.bytes += record.BytesIn + record.BytesOut
+	}
+}
+
+// quotaOf returns the quota of principal, or the "*" one, false when it has none
+func (u *Usage) quotaOf(principal string) (config.QuotaConfig, bool) {
+	if principal == "" {
+		return config.QuotaConfig{}, false
+	}
+	quota, ok := u.config.Quotas[principal]
+	if !ok {
+		quota, ok = u.config.Quotas["*"]
+	}
+	return quota, ok && (quota.Requests > 0 || quota.Bytes > 0)
+}
+
+// quotaUsage returns the usage of principal in the current period of its quota and the end of
+// the period. It is read from the store once per flush interval, with the totals the instance did
+// not save yet, and followed by Record in between.
+func (u *Usage) quotaUsage(ctx context.Context, principal string, quota config.QuotaConfig) (quotaUsage, time.Time, error) {
+	now := u.clock.Now()
+	d := u.windows[quota.Window]
+	start := now.UTC().Truncate(d)
+
+	u.mu.Lock()
+	usage, ok := u.quotas[principal]
+	if ok && usage.start.Equal(start) && now.Sub(usage.loadedAt) < u.config.FlushInterval {
+		result := *usage
+		u.mu.Unlock()
+		return result, start.Add(d), nil
+	}
+	store := u.store
+	u.mu.Unlock()
+
+	records, err := store.Query(ctx, port.UsageQuery{Window: quota.Window, From: start, To: start, Principal: principal})
+	if err != nil {
+		return quotaUsage{}, time.Time{}, err
+	}
+
+	loaded := quotaUsage{start: start, loadedAt: now}
+	for _, record := range records {
+		loaded.requests += record.Requests
+		loaded.bytes += record.BytesIn + record.BytesOut
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	for key, counter := range u.counters {
+		if key.window == quota.Window && key.start.Equal(start) && key.principal == principal {
+			loaded.requests += counter.total.Requests - counter.saved.Requests
+			loaded.bytes += counter.total.BytesIn - counter.saved.BytesIn + counter.total.BytesOut - counter.saved.BytesOut
+		}
+	}
+	u.quotas[principal] = &loaded
+	return loaded, start.Add(d), nil
+}
+
+// Query returns the saved usage matching query, the totals not flushed yet are left out. The
+// records are added together by the fields of groupBy ("principal", "api_key", "route",
+// "start"), every record is returned without groupBy.
+func (u *Usage) Query(ctx context.Context, query port.UsageQuery, groupBy ...string) ([]port.UsageRecord, error) {
+	if query.Window == "" {
+		query.Window = u.config.Windows[0]
+	}
+
+	u.mu.Lock()
+	store := u.store
+	u.mu.Unlock()
+
+	records, err := store.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	if len(groupBy) > 0 {
+		records = groupUsage(records, groupBy)
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		if !records[i].Start.Equal(records[j].Start) {
+			return records[i].Start.After(records[j].Start)
+		}
+		return records[i].Requests > records[j].Requests
+	})
+	return records, nil
+}
+
+func groupUsage(records []port.UsageRecord, groupBy []string) []port.UsageRecord {
+	keep := map[string]bool{}
+	for _, field := range groupBy {
+		keep[field] = true
+	}
+
+	groups := map[usageKey]*port.UsageRecord{}
+	order := []usageKey{}
+	for _, record := range records {
+		key := usageKey{window: record.Window}
+		if keep["start"] {
+			key.start = record.Start
+		}
+		if keep["principal"] {
+			key.principal = record.Principal
+		}
+		if keep["api_key"] {
+			key.apiKey = record.APIKey
+		}
+		if keep["route"] {
+			key.route = record.Route
+		}
+
+		group, ok := groups[key]
+		if !ok {
+			group = &port.UsageRecord{Window: key.window, Start: key.start, Principal: key.principal, APIKey: key.apiKey, Route: key.route}
+			groups[key] = group
+			order = append(order, key)
+		}
+		group.Requests += record.Requests
+		group.Errors += record.Errors
+		group.BytesIn += record.BytesIn
+		group.BytesOut += record.BytesOut
+	}
+
+	result := make([]port.UsageRecord, 0, len(order))
+	for _, key := range order {
+		result = append(result, *groups[key])
+	}
+	return result
+}
+
+// RegisterAdminRoutes serves the usage on router: ?window=&from=&to=&principal=&api_key=&route=
+// and by= the comma separated fields the records are added together by
+func (u *Usage) RegisterAdminRoutes(router fiber.Router) {
+	router.Get("/usage", func(c *fiber.Ctx) error {
+		query := port.UsageQuery{
+			Window:    c.Query("window"),
+			Principal: c.Query("principal"),
+			APIKey:    c.Query("api_key"),
+			Route:     c.Query("route"),
+		}
+		if query.Window != "" {
+			if _, ok := u.windows[query.Window]; !ok {
+				return fiber.NewError(fiber.StatusBadRequest, "window is not one of app.usage.windows")
+			}
+		}
+		for name, target := range map[string]*time.Time{"from": &query.From, "to": &query.To} {
+			if value := c.Query(name); value != "" {
+				t, err := time.Parse(time.RFC3339, value)
+				if err != nil {
+					return fiber.NewError(fiber.StatusBadRequest, name+" must be an RFC 3339 time")
+				}
+				*target = t
+			}
+		}
+
+		var groupBy []string
+		if by := c.Query("by"); by != "" {
+			groupBy = strings.Split(by, ",")
+		}
+
+		records, err := u.Query(c.UserContext(), query, groupBy...)
+		if err != nil {
+			return err
+		}
+		return out.Send(c, out.SuccessData(records))
+	})
+}
+
+// MemoryUsageStore keeps the usage in memory for the retention, it is lost on restart
+type MemoryUsageStore struct {
+	mu        sync.Mutex
+	clock     helper.Clock
+	retention time.Duration
+	records   map[usageKey]map[string]port.UsageRecord // by instance
+}
+
+func NewMemoryUsageStore(clock helper.Clock, retention time.Duration) *MemoryUsageStore {
+	return &MemoryUsageStore{
+		clock:     clock,
+		retention: retention,
+		records:   map[usageKey]map[string]port.UsageRecord{},
+	}
+}
+
+func (s *MemoryUsageStore) Save(ctx context.Context, records []port.UsageRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, record := range records {
+		key := usageKey{window: record.Window, start: record.Start, principal: record.Principal, apiKey: record.APIKey, route: record.Route}
+		if s.records[key] == nil {
+			s.records[key] = map[string]port.UsageRecord{}
+		}
+		s.records[key][record.Instance] = record
+	}
+
+	// the periods past the retention are forgotten
+	cutoff := s.clock.Now().Add(-s.retention)
+	for key := range s.records {
+		if key.start.Before(cutoff) {
+			delete(s.records, key)
+		}
+	}
+	return nil
+}
+
+func (s *MemoryUsageStore) Query(ctx context.Context, query port.UsageQuery) ([]port.UsageRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := []port.UsageRecord{}
+	for key, instances := range s.records {
+		if (query.Window != "" && key.window != query.Window) ||
+			(query.Principal != "" && key.principal != query.Principal) ||
+			(query.APIKey != "" && key.apiKey != query.APIKey) ||
+			(query.Route != "" && key.route != query.Route) ||
+			key.start.Before(query.From) ||
+			(!query.To.IsZero() && key.start.After(query.To)) {
+			continue
+		}
+
+		total := port.UsageRecord{Window: key.window, Start: key.start, Principal: key.principal, APIKey: key.apiKey, Route: key.route}
+		for _, record := range instances {
+			total.Requests += record.Requests
+			total.Errors += record.Errors
+			total.BytesIn += record.BytesIn
+			total.BytesOut += record.BytesOut
+		}
+		result = append(result, total)
+	}
+	return result, nil
+}
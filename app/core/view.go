@@ -0,0 +1,29 @@
+package core
+
+import (
+	"io/fs"
+
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/i18n"
+	"github.com/webcore-go/webcore/infra/view"
+)
+
+// SetViews uses the templates of fsys (a directory or an embed.FS) for out.Render,
+// the mailer, the notifier and the reporter. Call it before Start to replace the configured directory.
+func (a *AppContext) SetViews(fsys fs.FS) error {
+	engine := view.New(fsys, view.Options{
+		Layout: a.Config.View.Layout,
+		Reload: a.Config.View.Reload || a.Config.App.Environment == "development",
+		Funcs:  i18n.Funcs(),
+	})
+	if err := engine.Load(); err != nil {
+		return err
+	}
+
+	a.Views = engine
+	out.SetViewRenderer(engine)
+	a.Mailer.SetRenderer(engine)
+	a.Notifier.SetRenderer(engine)
+	a.Reporter.SetRenderer(engine)
+	return nil
+}
@@ -0,0 +1,176 @@
+package core
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/retry"
+	"github.com/webcore-go/webcore/port"
+)
+
+// WebhookJobType is the job queue type running the handlers of received webhooks
+const WebhookJobType = "webhook.receive"
+
+// WebhookVerifier authenticates a delivery (ex: checks its signature) and returns its
+// event id, empty when the source has none so deliveries are not de-duplicated
+type WebhookVerifier func(headers map[string]string, body []byte) (string, error)
+
+// WebhookHandler processes a verified delivery, failures are retried by the job queue
+type WebhookHandler func(ctx context.Context, delivery *WebhookDelivery) error
+
+// WebhookDelivery is a verified webhook call
+type WebhookDelivery struct {
+	Source     string            `json:"source"`
+	EventID    string            `json:"event_id,omitempty"`
+	Headers    map[string]string `json:"headers"`
+	Body       []byte            `json:"body"`
+	ReceivedAt time.Time         `json:"received_at"`
+}
+
+type webhookSource struct {
+	verify  WebhookVerifier
+	handler WebhookHandler
+}
+
+// Webhooks receives webhooks of third parties on <path>/<source>. Deliveries are verified
+// and acknowledged right away, de-duplicated by event id and handled through the job queue.
+type Webhooks struct {
+	mu      sync.RWMutex
+	config  config.WebhooksConfig
+	queue   *JobQueue
+	locker  port.ILocker
+	clock   helper.Clock
+	sources map[string]*webhookSource
+}
+
+// NewWebhooks creates a receiver de-duplicating with locker and registers its job on queue,
+// retried with the "webhook" policy of retries or else like the other jobs
+func NewWebhooks(cfg config.WebhooksConfig, queue *JobQueue, retries *Retries, locker port.ILocker, clock helper.Clock) *Webhooks {
+	w := &Webhooks{
+		config:  cfg,
+		queue:   queue,
+		locker:  locker,
+		clock:   clock,
+		sources: make(map[string]*webhookSource),
+	}
+
+	HandleJob(queue, WebhookJobType, func(ctx context.Context, delivery WebhookDelivery) error {
+		source, ok := w.source(delivery.Source)
+		if !ok {
+			return retry.Permanent(fmt.Errorf("unknown webhook source '%s'", delivery.Source))
+		}
+		return source.handler(ctx, &delivery)
+	}, retries.Policy(RetryWebhook, queue.defaultRetry()))
+
+	return w
+}
+
+// SetLocker replaces the lock used to de-duplicate deliveries
+func (w *Webhooks) SetLocker(locker port.ILocker) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.locker = locker
+}
+
+// Register accepts the webhooks of source, replacing a previous registration
+func (w *Webhooks) Register(source string, verify WebhookVerifier, handler WebhookHandler) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.sources[source] = &webhookSource{verify: verify, handler: handler}
+}
+
+// Handler receives the deliveries, mounted on <path>/:source
+func (w *Webhooks) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Params("source")
+		source, ok := w.source(name)
+		if !ok {
+			return fiber.NewError(fiber.StatusNotFound, "Unknown webhook source")
+		}
+
+		headers := map[string]string{}
+		c.Request().Header.VisitAll(func(key, value []byte) {
+			headers[http.CanonicalHeaderKey(string(key))] = string(value)
+		})
+		body := append([]byte(nil), c.Body()...)
+
+		eventID, err := source.verify(headers, body)
+		if err != nil {
+			logger.Warn("Webhook rejected", "source", name, "ip", c.IP(), "error", err)
+			return fiber.NewError(fiber.StatusUnauthorized, "Invalid webhook signature")
+		}
+
+		w.mu.RLock()
+		locker := w.locker
+		w.mu.RUnlock()
+
+		ctx := c.UserContext()
+		lockKey := "webhook:" + name + ":" + eventID
+		if eventID != "" {
+			first, err := locker.TryLock(ctx, lockKey, w.config.DedupTTL)
+			if err != nil {
+				return err
+			}
+			if !first {
+				// already received, the provider retried before seeing our answer
+				return c.SendStatus(fiber.StatusOK)
+			}
+		}
+
+		delivery := WebhookDelivery{
+			Source:     name,
+			EventID:    eventID,
+			Headers:    headers,
+			Body:       body,
+			ReceivedAt: w.clock.Now(),
+		}
+		if _, err := w.queue.Enqueue(ctx, WebhookJobType, delivery, EnqueueOptions{Queue: w.config.Queue}); err != nil {
+			if eventID != "" {
+				// let the retry of the provider through
+				_ = locker.Unlock(ctx, lockKey)
+			}
+			return err
+		}
+
+		return c.SendStatus(fiber.StatusAccepted)
+	}
+}
+
+func (w *Webhooks) source(name string) (*webhookSource, bool) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	source, ok := w.sources[name]
+	return source, ok
+}
+
+// HMACWebhookVerifier checks the hex HMAC-SHA256 of the body sent in signatureHeader
+// (an optional "sha256=" prefix is accepted) and reads the event id from idHeader
+func HMACWebhookVerifier(secret string, signatureHeader string, idHeader string) WebhookVerifier {
+	signatureHeader = http.CanonicalHeaderKey(signatureHeader)
+	idHeader = http.CanonicalHeaderKey(idHeader)
+
+	return func(headers map[string]string, body []byte) (string, error) {
+		signature, err := hex.DecodeString(strings.TrimPrefix(headers[signatureHeader], "sha256="))
+		if err != nil || len(signature) == 0 {
+			return "", fmt.Errorf("missing or malformed %s header", signatureHeader)
+		}
+
+		mac := hmac.New(sha256.New, []byte(secret))
+		mac.Write(body)
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return "", fmt.Errorf("signature mismatch")
+		}
+		return headers[idHeader], nil
+	}
+}
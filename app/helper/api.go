@@ -28,6 +28,8 @@ type Sort struct {
 }
 
 // NewPaginatedResponse creates a paginated response
+//
+// Deprecated: use out.Paginated, whose page is described in the meta block of the response
 func NewPaginatedResponse(data any, pagination Pagination) out.Response {
 	return out.Response{
 		Data: map[string]any{
@@ -0,0 +1,45 @@
+package helper
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/out"
+)
+
+// FieldValidator is implemented by request structs that validate their own fields
+type FieldValidator interface {
+	Validate() []out.FieldError
+}
+
+// BindBody parses the request body into dst and validates it when dst implements FieldValidator.
+// The returned error is an *out.Response (400 on malformed body, 422 listing the invalid fields)
+// that can be returned directly from a handler.
+func BindBody(c *fiber.Ctx, dst any) error {
+	if err := c.BodyParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid request body", err)
+	}
+
+	return Validate(dst)
+}
+
+// BindQuery parses the query string into dst and validates it when dst implements FieldValidator
+func BindQuery(c *fiber.Ctx, dst any) error {
+	if err := c.QueryParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid query parameters", err)
+	}
+
+	return Validate(dst)
+}
+
+// Validate runs FieldValidator.Validate on v and wraps the failures in a 422 *out.Response
+func Validate(v any) error {
+	validator, ok := v.(FieldValidator)
+	if !ok {
+		return nil
+	}
+
+	if errors := validator.Validate(); len(errors) > 0 {
+		return out.ValidationError(errors)
+	}
+
+	return nil
+}
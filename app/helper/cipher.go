@@ -0,0 +1,120 @@
+package helper
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// EncryptedPrefix starts the values encrypted by a FieldCipher
+const EncryptedPrefix = "enc:"
+
+// ErrUnknownKey is returned when a value was encrypted with a key the cipher does not have
+var ErrUnknownKey = errors.New("unknown encryption key")
+
+// FieldCipher encrypts the sensitive fields of the records (ex: national ID, bank account)
+// with AES-256-GCM before they are stored. Values are "enc:<key id>:<base64>", so the keys
+// can be rotated: new values use the current key, the older keys still decrypt.
+type FieldCipher struct {
+	mu      sync.RWMutex
+	keys    map[string]cipher.AEAD
+	current string
+}
+
+// NewFieldCipher creates a cipher without key
+func NewFieldCipher() *FieldCipher {
+	return &FieldCipher{keys: make(map[string]cipher.AEAD)}
+}
+
+// AddKey adds a 32 bytes key, current makes it the key of the new values
+func (f *FieldCipher) AddKey(id string, key []byte, current bool) error {
+	if id == "" || strings.Contains(id, ":") {
+		return fmt.Errorf("invalid key id %q", id)
+	}
+	if len(key) != 32 {
+		return fmt.Errorf("key %s: AES-256 needs 32 bytes, got %d", id, len(key))
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys[id] = aead
+	if current || f.current == "" {
+		f.current = id
+	}
+	return nil
+}
+
+// Enabled reports whether the cipher has a key
+func (f *FieldCipher) Enabled() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.current != ""
+}
+
+// Encrypt encrypts value with the current key, the empty string stays empty
+func (f *FieldCipher) Encrypt(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	f.mu.RLock()
+	id := f.current
+	aead := f.keys[id]
+	f.mu.RUnlock()
+	if aead == nil {
+		return "", ErrUnknownKey
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(value)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	// the key id is authenticated, a value cannot be moved to another key
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(id))
+	return EncryptedPrefix + id + ":" + base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt decrypts a value of Encrypt. Values without EncryptedPrefix are returned as is, so
+// the rows written before a field was encrypted stay readable.
+func (f *FieldCipher) Decrypt(value string) (string, error) {
+	rest, ok := strings.CutPrefix(value, EncryptedPrefix)
+	if !ok {
+		return value, nil
+	}
+
+	id, data, ok := strings.Cut(rest, ":")
+	if !ok {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+
+	f.mu.RLock()
+	aead := f.keys[id]
+	f.mu.RUnlock()
+	if aead == nil {
+		return "", fmt.Errorf("%w: %s", ErrUnknownKey, id)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(data)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return "", fmt.Errorf("invalid encrypted value")
+	}
+	plain, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], []byte(id))
+	if err != nil {
+		return "", err
+	}
+	return string(plain), nil
+}
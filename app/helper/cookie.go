@@ -0,0 +1,83 @@
+package helper
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// cookiePurpose binds a signed cookie to its name, its value cannot be replayed in another cookie
+func cookiePurpose(name string) string {
+	return "cookie:" + name
+}
+
+// cookieTTL is the lifetime of cookie, 0 for a session cookie
+func cookieTTL(cookie *fiber.Cookie, now time.Time) time.Duration {
+	switch {
+	case cookie.MaxAge > 0:
+		return time.Duration(cookie.MaxAge) * time.Second
+	case !cookie.Expires.IsZero():
+		return max(cookie.Expires.Sub(now), time.Second)
+	}
+	return 0
+}
+
+// SetSignedCookie sets cookie with its value signed by signer, readable by the client but not
+// alterable. The signature expires with the cookie.
+func SetSignedCookie(c *fiber.Ctx, signer *ValueSigner, cookie *fiber.Cookie) error {
+	value, err := signer.Sign(cookiePurpose(cookie.Name), cookie.Value, cookieTTL(cookie, signer.clock.Now()))
+	if err != nil {
+		return err
+	}
+
+	signed := *cookie
+	signed.Value = value
+	c.Cookie(&signed)
+	return nil
+}
+
+// SignedCookie returns the value of the cookie name set by SetSignedCookie, ErrInvalidSignature
+// when it was altered and ErrExpiredSignature when it expired. A missing cookie is an empty value.
+func SignedCookie(c *fiber.Ctx, signer *ValueSigner, name string) (string, error) {
+	value := c.Cookies(name)
+	if value == "" {
+		return "", nil
+	}
+	return signer.Verify(cookiePurpose(name), value)
+}
+
+// SetEncryptedCookie sets cookie with its value encrypted by cipher, unreadable by the client
+// (ex: the state of an OAuth flow, a cart of a guest)
+func SetEncryptedCookie(c *fiber.Ctx, cipher *FieldCipher, cookie *fiber.Cookie) error {
+	value, err := cipher.Encrypt(cookie.Name + "=" + cookie.Value)
+	if err != nil {
+		return err
+	}
+
+	encrypted := *cookie
+	encrypted.Value = strings.TrimPrefix(value, EncryptedPrefix)
+	c.Cookie(&encrypted)
+	return nil
+}
+
+// EncryptedCookie returns the value of the cookie name set by SetEncryptedCookie. A missing
+// cookie is an empty value.
+func EncryptedCookie(c *fiber.Ctx, cipher *FieldCipher, name string) (string, error) {
+	value := c.Cookies(name)
+	if value == "" {
+		return "", nil
+	}
+
+	plain, err := cipher.Decrypt(EncryptedPrefix + value)
+	if err != nil {
+		return "", err
+	}
+	// the name is encrypted with the value, a value cannot be replayed in another cookie
+	value, ok := strings.CutPrefix(plain, name+"=")
+	if !ok {
+		return "", fmt.Errorf("cookie %s: invalid encrypted value", name)
+	}
+	return value, nil
+}
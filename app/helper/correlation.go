@@ -0,0 +1,108 @@
+package helper
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+)
+
+// Attributes of the messages carrying the correlation of the request that published them
+const (
+	RequestIDAttribute   = "request_id"
+	TraceParentAttribute = "traceparent"
+)
+
+type traceParentKey struct{}
+
+// WithTraceParent stores the W3C traceparent of the request in ctx so outgoing calls and
+// published messages continue its trace
+func WithTraceParent(ctx context.Context, traceParent string) context.Context {
+	return context.WithValue(ctx, traceParentKey{}, traceParent)
+}
+
+// TraceParent returns the traceparent stored by WithTraceParent, empty when there is none
+func TraceParent(ctx context.Context) string {
+	traceParent, _ := ctx.Value(traceParentKey{}).(string)
+	return traceParent
+}
+
+// ParseTraceParent returns the trace ID and the flags of a W3C traceparent header
+// ("00-<trace-id>-<parent-id>-<flags>"), false when it is malformed
+func ParseTraceParent(header string) (traceID string, flags string, ok bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return "", "", false
+	}
+	if !isHex(parts[1], 32) || !isHex(parts[2], 16) || !isHex(parts[3], 2) {
+		return "", "", false
+	}
+	if parts[1] == strings.Repeat("0", 32) || parts[2] == strings.Repeat("0", 16) {
+		return "", "", false
+	}
+	return parts[1], parts[3], true
+}
+
+// NewTraceParent returns a traceparent continuing the trace traceID (a new one when empty)
+// from a new span of this service
+func NewTraceParent(traceID string, flags string) string {
+	if traceID == "" {
+		traceID = RandomHex(16)
+	}
+	if flags == "" {
+		flags = "01"
+	}
+	return "00-" + traceID + "-" + RandomHex(8) + "-" + flags
+}
+
+// RandomHex returns n random bytes in hexadecimal
+func RandomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// CorrelationAttributes adds the request ID and the traceparent of ctx to the attributes of a
+// published message, keeping the ones already set
+func CorrelationAttributes(ctx context.Context, attributes map[string]string) map[string]string {
+	requestID, traceParent := RequestID(ctx), TraceParent(ctx)
+	if requestID == "" && traceParent == "" {
+		return attributes
+	}
+
+	result := make(map[string]string, len(attributes)+2)
+	if requestID != "" {
+		result[RequestIDAttribute] = requestID
+	}
+	if traceParent != "" {
+		result[TraceParentAttribute] = traceParent
+	}
+	for key, value := range attributes {
+		result[key] = value
+	}
+	return result
+}
+
+// CorrelationContext stores in ctx the request ID and the traceparent of the attributes of a
+// received message, for the logs and the calls of its handling
+func CorrelationContext(ctx context.Context, attributes map[string]string) context.Context {
+	if requestID := attributes[RequestIDAttribute]; requestID != "" {
+		ctx = WithRequestID(ctx, requestID)
+	}
+	if traceID, flags, ok := ParseTraceParent(attributes[TraceParentAttribute]); ok {
+		ctx = WithTraceParent(ctx, NewTraceParent(traceID, flags))
+	}
+	return ctx
+}
+
+func isHex(s string, length int) bool {
+	if len(s) != length {
+		return false
+	}
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
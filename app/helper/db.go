@@ -1,10 +1,12 @@
 package helper
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"strings"
 
+	"github.com/webcore-go/webcore/app/out"
 	"github.com/webcore-go/webcore/port"
 )
 
@@ -111,3 +113,96 @@ func UnmarshalDbMap(data port.DbMap, out any) error {
 	}
 	return nil
 }
+
+// FindPage fills results with the rows of table matching filter on page and returns the number
+// of rows matching filter, for out.Paginated
+func FindPage(ctx context.Context, db port.IDatabase, results any, table string, column []string, filter []port.DbExpression, sort map[string]int, page out.Page) (int64, error) {
+	total, err := db.Count(ctx, table, filter)
+	if err != nil {
+		return 0, err
+	}
+	if err := db.Find(ctx, results, table, column, filter, sort, page.Limit(), page.Skip()); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// FindCursor iterates over the rows of table matching filter. It uses the cursor of databases
+// implementing port.IDatabaseCursor and pages through Find for the others, in the latter
+// case Decode fills port.DbMap values or structs by their db tags.
+func FindCursor(ctx context.Context, db port.IDatabase, table string, column []string, filter []port.DbExpression, sort map[string]int) (port.DbCursor, error) {
+	if streaming, ok := db.(port.IDatabaseCursor); ok {
+		return streaming.FindCursor(ctx, table, column, filter, sort)
+	}
+
+	return &pageCursor{
+		db:     db,
+		table:  table,
+		column: column,
+		filter: filter,
+		sort:   sort,
+		index:  -1,
+	}, nil
+}
+
+const cursorPageSize = 500
+
+// pageCursor reads cursorPageSize rows at a time with Find
+type pageCursor struct {
+	db     port.IDatabase
+	table  string
+	column []string
+	filter []port.DbExpression
+	sort   map[string]int
+	page   []port.DbMap
+	index  int
+	skip   int64
+	done   bool
+	err    error
+}
+
+func (c *pageCursor) Next(ctx context.Context) bool {
+	if c.err != nil {
+		return false
+	}
+
+	c.index++
+	if c.index < len(c.page) {
+		return true
+	}
+	if c.done {
+		return false
+	}
+
+	c.page = nil
+	if err := c.db.Find(ctx, &c.page, c.table, c.column, c.filter, c.sort, cursorPageSize, c.skip); err != nil {
+		c.err = err
+		return false
+	}
+	c.skip += int64(len(c.page))
+	c.done = len(c.page) < cursorPageSize
+	c.index = 0
+	return len(c.page) > 0
+}
+
+func (c *pageCursor) Decode(result any) error {
+	if c.index < 0 || c.index >= len(c.page) {
+		return fmt.Errorf("cursor has no current row")
+	}
+
+	if dst, ok := result.(*port.DbMap); ok {
+		*dst = c.page[c.index]
+		return nil
+	}
+	return UnmarshalDbMap(c.page[c.index], result)
+}
+
+func (c *pageCursor) Err() error {
+	return c.err
+}
+
+func (c *pageCursor) Close(ctx context.Context) error {
+	c.page = nil
+	c.done = true
+	return nil
+}
@@ -0,0 +1,31 @@
+package helper
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/port"
+)
+
+// GeoLocalsKey is the fiber Locals key of the GeoIP middleware result
+const GeoLocalsKey = "geo"
+
+type geoKey struct{}
+
+// WithGeo stores the location of the client in ctx
+func WithGeo(ctx context.Context, info *port.GeoInfo) context.Context {
+	return context.WithValue(ctx, geoKey{}, info)
+}
+
+// Geo returns the location stored by WithGeo, nil when there is none
+func Geo(ctx context.Context) *port.GeoInfo {
+	info, _ := ctx.Value(geoKey{}).(*port.GeoInfo)
+	return info
+}
+
+// CurrentGeo returns the location of the client resolved by the GeoIP middleware,
+// nil when it is disabled
+func CurrentGeo(c *fiber.Ctx) *port.GeoInfo {
+	info, _ := c.Locals(GeoLocalsKey).(*port.GeoInfo)
+	return info
+}
@@ -0,0 +1,107 @@
+package helper
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// ParseListLine parses a line of a Unix style long listing ("ls -l"), as answered by the FTP
+// LIST command and by the ls command of sftp. Dates without a year are in the last 12 months
+// before now. It returns false for lines that are not entries (ex: "total 12").
+func ParseListLine(line string, now time.Time) (port.RemoteFile, bool) {
+	var file port.RemoteFile
+
+	fields, offsets := listFields(line)
+	if len(fields) < 6 || len(fields[0]) < 10 {
+		return file, false
+	}
+
+	// the owner and group columns are not always there, find the date instead
+	for i := 2; i+3 < len(fields); i++ {
+		month, ok := listMonths[strings.ToLower(fields[i])]
+		if !ok {
+			continue
+		}
+		day, err := strconv.Atoi(fields[i+1])
+		if err != nil || day < 1 || day > 31 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[i-1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		modTime, ok := listTime(month, day, fields[i+2], now)
+		if !ok {
+			continue
+		}
+
+		name := strings.TrimRight(line[offsets[i+3]:], "\r\n")
+		if fields[0][0] == 'l' {
+			name, _, _ = strings.Cut(name, " -> ")
+		}
+
+		file.Name = name
+		file.Size = size
+		file.ModTime = modTime
+		file.IsDir = fields[0][0] == 'd'
+		return file, true
+	}
+	return file, false
+}
+
+var listMonths = map[string]time.Month{
+	"jan": time.January, "feb": time.February, "mar": time.March, "apr": time.April,
+	"may": time.May, "jun": time.June, "jul": time.July, "aug": time.August,
+	"sep": time.September, "oct": time.October, "nov": time.November, "dec": time.December,
+}
+
+// listTime reads "15:04" (recent files) or "2006" (older files) after the month and day
+func listTime(month time.Month, day int, value string, now time.Time) (time.Time, bool) {
+	if hour, minute, ok := strings.Cut(value, ":"); ok {
+		h, err1 := strconv.Atoi(hour)
+		m, err2 := strconv.Atoi(minute)
+		if err1 != nil || err2 != nil {
+			return time.Time{}, false
+		}
+		t := time.Date(now.Year(), month, day, h, m, 0, 0, time.UTC)
+		if t.After(now.AddDate(0, 0, 1)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+		return t, true
+	}
+
+	year, err := strconv.Atoi(value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC), true
+}
+
+// listFields splits a line on blanks like strings.Fields and keeps where each field starts,
+// the file name runs until the end of the line and may contain blanks
+func listFields(line string) ([]string, []int) {
+	var fields []string
+	var offsets []int
+
+	start := -1
+	for i, r := range line {
+		blank := r == ' ' || r == '\t'
+		switch {
+		case !blank && start < 0:
+			start = i
+		case blank && start >= 0:
+			fields = append(fields, line[start:i])
+			offsets = append(offsets, start)
+			start = -1
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, line[start:])
+		offsets = append(offsets, start)
+	}
+	return fields, offsets
+}
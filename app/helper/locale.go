@@ -0,0 +1,35 @@
+package helper
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/i18n"
+)
+
+// LocaleLocalsKey is the fiber Locals key of the locale resolved by the Locale middleware
+const LocaleLocalsKey = "locale"
+
+type localeKey struct{}
+
+// WithLocale stores the locale of the request in ctx
+func WithLocale(ctx context.Context, locale *i18n.Locale) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// Locale returns the locale stored by WithLocale, English when there is none
+func Locale(ctx context.Context) *i18n.Locale {
+	if locale, ok := ctx.Value(localeKey{}).(*i18n.Locale); ok {
+		return locale
+	}
+	return i18n.Default("")
+}
+
+// CurrentLocale returns the locale of the request resolved by the Locale middleware,
+// English when it is disabled
+func CurrentLocale(c *fiber.Ctx) *i18n.Locale {
+	if locale, ok := c.Locals(LocaleLocalsKey).(*i18n.Locale); ok {
+		return locale
+	}
+	return i18n.Default("")
+}
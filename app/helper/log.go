@@ -1,6 +1,7 @@
 package helper
 
 import (
+	"context"
 	"io"
 	"os"
 	"strings"
@@ -30,3 +31,16 @@ func FiberLoggerOutput(str string) io.Writer {
 	}
 	return output
 }
+
+type requestIDKey struct{}
+
+// WithRequestID stores the request ID in ctx so outgoing calls can propagate it
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestID returns the request ID stored by WithRequestID, empty when there is none
+func RequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
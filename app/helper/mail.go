@@ -0,0 +1,210 @@
+package helper
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// BuildMailMessage encodes message as a RFC 5322 / MIME document ready for SMTP or raw API delivery.
+// Bcc recipients are not written in the headers.
+func BuildMailMessage(message *port.MailMessage) ([]byte, error) {
+	if message.From == "" {
+		return nil, fmt.Errorf("mail sender is required")
+	}
+	if len(MailRecipients(message)) == 0 {
+		return nil, fmt.Errorf("mail has no recipient")
+	}
+
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", message.From)
+	if len(message.To) > 0 {
+		header.Set("To", strings.Join(message.To, ", "))
+	}
+	if len(message.Cc) > 0 {
+		header.Set("Cc", strings.Join(message.Cc, ", "))
+	}
+	if message.ReplyTo != "" {
+		header.Set("Reply-To", message.ReplyTo)
+	}
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", message.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("MIME-Version", "1.0")
+	if id, err := GenerateID(); err == nil {
+		header.Set("Message-ID", "<"+strings.TrimRight(id, "=")+"@"+mailDomain(message.From)+">")
+	}
+	for name, value := range message.Headers {
+		header.Set(name, value)
+	}
+
+	inline := []port.MailAttachment{}
+	attached := []port.MailAttachment{}
+	for _, attachment := range message.Attachments {
+		if attachment.ContentID != "" {
+			inline = append(inline, attachment)
+		} else {
+			attached = append(attached, attachment)
+		}
+	}
+
+	body := func(w *bytes.Buffer) (string, error) { return writeMailBody(w, message) }
+	if len(inline) > 0 {
+		body = wrapMultipart("related", body, inline)
+	}
+	if len(attached) > 0 {
+		body = wrapMultipart("mixed", body, attached)
+	}
+
+	var content bytes.Buffer
+	contentType, err := body(&content)
+	if err != nil {
+		return nil, err
+	}
+	header.Set("Content-Type", contentType)
+	if !strings.HasPrefix(contentType, "multipart/") {
+		header.Set("Content-Transfer-Encoding", "quoted-printable")
+	}
+
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		// strip line breaks so values cannot inject headers
+		value := strings.NewReplacer("\r", "", "\n", "").Replace(header.Get(name))
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	buf.WriteString("\r\n")
+	buf.Write(content.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// MailRecipients returns the envelope recipients (To, Cc and Bcc) as bare addresses
+func MailRecipients(message *port.MailMessage) []string {
+	result := []string{}
+	for _, list := range [][]string{message.To, message.Cc, message.Bcc} {
+		for _, recipient := range list {
+			if address, err := mail.ParseAddress(recipient); err == nil {
+				result = append(result, address.Address)
+			} else {
+				result = append(result, recipient)
+			}
+		}
+	}
+	return result
+}
+
+// writeMailBody writes the text and/or html parts, returning the content type of what was written
+func writeMailBody(w *bytes.Buffer, message *port.MailMessage) (string, error) {
+	if message.Text == "" || message.HTML == "" {
+		if message.HTML != "" {
+			return "text/html; charset=utf-8", writeQuotedPrintable(w, message.HTML)
+		}
+		return "text/plain; charset=utf-8", writeQuotedPrintable(w, message.Text)
+	}
+
+	writer := multipart.NewWriter(w)
+	for _, part := range []struct{ contentType, content string }{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		pw, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return "", err
+		}
+		if err := writeQuotedPrintable(pw, part.content); err != nil {
+			return "", err
+		}
+	}
+
+	return "multipart/alternative; boundary=" + writer.Boundary(), writer.Close()
+}
+
+func wrapMultipart(kind string, inner func(w *bytes.Buffer) (string, error), attachments []port.MailAttachment) func(w *bytes.Buffer) (string, error) {
+	return func(w *bytes.Buffer) (string, error) {
+		writer := multipart.NewWriter(w)
+
+		var content bytes.Buffer
+		contentType, err := inner(&content)
+		if err != nil {
+			return "", err
+		}
+
+		header := textproto.MIMEHeader{"Content-Type": {contentType}}
+		if !strings.HasPrefix(contentType, "multipart/") {
+			header.Set("Content-Transfer-Encoding", "quoted-printable")
+		}
+		pw, err := writer.CreatePart(header)
+		if err != nil {
+			return "", err
+		}
+		pw.Write(content.Bytes())
+
+		for _, attachment := range attachments {
+			contentType := attachment.ContentType
+			if contentType == "" {
+				contentType = "application/octet-stream"
+			}
+
+			header := textproto.MIMEHeader{
+				"Content-Type":              {mime.FormatMediaType(contentType, map[string]string{"name": attachment.Filename})},
+				"Content-Transfer-Encoding": {"base64"},
+			}
+			if attachment.ContentID != "" {
+				header.Set("Content-ID", "<"+attachment.ContentID+">")
+				header.Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.Filename}))
+			} else {
+				header.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename}))
+			}
+
+			pw, err := writer.CreatePart(header)
+			if err != nil {
+				return "", err
+			}
+
+			encoded := base64.StdEncoding.EncodeToString(attachment.Content)
+			for len(encoded) > 76 {
+				pw.Write([]byte(encoded[:76] + "\r\n"))
+				encoded = encoded[76:]
+			}
+			pw.Write([]byte(encoded + "\r\n"))
+		}
+
+		return "multipart/" + kind + "; boundary=" + writer.Boundary(), writer.Close()
+	}
+}
+
+func writeQuotedPrintable(w io.Writer, content string) error {
+	qp := quotedprintable.NewWriter(w)
+	if _, err := qp.Write([]byte(content)); err != nil {
+		return err
+	}
+	return qp.Close()
+}
+
+func mailDomain(from string) string {
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.Address
+	}
+	if _, domain, ok := strings.Cut(from, "@"); ok {
+		return domain
+	}
+	return "localhost"
+}
@@ -0,0 +1,257 @@
+package helper
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// MapTag is the struct tag used to pair fields with a different name, e.g. `map:"user_id"`.
+// Use `map:"-"` to never copy a field.
+var MapTag = "map"
+
+type converterKey struct {
+	from reflect.Type
+	to   reflect.Type
+}
+
+var mapConverters sync.Map // converterKey -> func(reflect.Value) (reflect.Value, error)
+
+// RegisterConverter registers a conversion used by Map whenever a TFrom value must be copied into a TTo field
+func RegisterConverter[TFrom any, TTo any](fn func(TFrom) (TTo, error)) {
+	key := converterKey{
+		from: reflect.TypeOf((*TFrom)(nil)).Elem(),
+		to:   reflect.TypeOf((*TTo)(nil)).Elem(),
+	}
+	mapConverters.Store(key, func(v reflect.Value) (reflect.Value, error) {
+		result, err := fn(v.Interface().(TFrom))
+		if err != nil {
+			return reflect.Value{}, err
+		}
+		return reflect.ValueOf(&result).Elem(), nil
+	})
+}
+
+// Map copies the fields of src into a new TDst. Fields are paired by map tag or by
+// name (case insensitive); nested structs, pointers, slices and maps are mapped recursively.
+func Map[TSrc any, TDst any](src TSrc) (TDst, error) {
+	var dst TDst
+	err := MapInto(src, &dst)
+	return dst, err
+}
+
+// MapSlice maps every element of src into a TDst
+func MapSlice[TSrc any, TDst any](src []TSrc) ([]TDst, error) {
+	if src == nil {
+		return nil, nil
+	}
+
+	result := make([]TDst, len(src))
+	for i, item := range src {
+		if err := MapInto(item, &result[i]); err != nil {
+			return nil, fmt.Errorf("[%d]: %v", i, err)
+		}
+	}
+	return result, nil
+}
+
+// MapInto copies src into the value pointed by dst
+func MapInto(src any, dst any) error {
+	dv := reflect.ValueOf(dst)
+	if dv.Kind() != reflect.Pointer || dv.IsNil() {
+		return fmt.Errorf("expected pointer destination, got %T", dst)
+	}
+
+	sv := reflect.ValueOf(src)
+	if !sv.IsValid() {
+		return nil
+	}
+
+	return mapValue(sv, dv.Elem(), "")
+}
+
+func mapValue(src reflect.Value, dst reflect.Value, path string) error {
+	if conv, ok := mapConverters.Load(converterKey{from: src.Type(), to: dst.Type()}); ok {
+		result, err := conv.(func(reflect.Value) (reflect.Value, error))(src)
+		if err != nil {
+			return fmt.Errorf("%s: %v", pathOrRoot(path), err)
+		}
+		dst.Set(result)
+		return nil
+	}
+
+	// Unwrap source pointers and interfaces, nil leaves the destination untouched
+	if src.Kind() == reflect.Pointer || src.Kind() == reflect.Interface {
+		if src.IsNil() {
+			return nil
+		}
+		return mapValue(src.Elem(), dst, path)
+	}
+
+	if dst.Kind() == reflect.Pointer {
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return mapValue(src, dst.Elem(), path)
+	}
+
+	switch {
+	case src.Kind() == reflect.Struct && dst.Kind() == reflect.Struct && src.Type() != dst.Type():
+		return mapStruct(src, dst, path)
+	case src.Kind() == reflect.Slice && dst.Kind() == reflect.Slice && src.Type() != dst.Type():
+		if src.IsNil() {
+			return nil
+		}
+		result := reflect.MakeSlice(dst.Type(), src.Len(), src.Len())
+		for i := 0; i < src.Len(); i++ {
+			if err := mapValue(src.Index(i), result.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(result)
+		return nil
+	case src.Kind() == reflect.Map && dst.Kind() == reflect.Map && src.Type() != dst.Type():
+		if src.IsNil() {
+			return nil
+		}
+		result := reflect.MakeMapWithSize(dst.Type(), src.Len())
+		iter := src.MapRange()
+		for iter.Next() {
+			key := reflect.New(dst.Type().Key()).Elem()
+			if err := mapValue(iter.Key(), key, path); err != nil {
+				return err
+			}
+			value := reflect.New(dst.Type().Elem()).Elem()
+			if err := mapValue(iter.Value(), value, fmt.Sprintf("%s[%v]", path, iter.Key().Interface())); err != nil {
+				return err
+			}
+			result.SetMapIndex(key, value)
+		}
+		dst.Set(result)
+		return nil
+	case src.Type().AssignableTo(dst.Type()):
+		dst.Set(src)
+		return nil
+	case isScalarKind(src.Kind()) && isScalarKind(dst.Kind()) && src.Type().ConvertibleTo(dst.Type()):
+		// avoid int -> string rune conversion surprises
+		if dst.Kind() == reflect.String && src.Kind() != reflect.String {
+			return fmt.Errorf("%s: cannot map %s to %s without a converter", pathOrRoot(path), src.Type(), dst.Type())
+		}
+		dst.Set(src.Convert(dst.Type()))
+		return nil
+	}
+
+	return fmt.Errorf("%s: cannot map %s to %s without a converter", pathOrRoot(path), src.Type(), dst.Type())
+}
+
+func mapStruct(src reflect.Value, dst reflect.Value, path string) error {
+	sources := mapFieldIndex(src.Type())
+
+	// the fields promoted from embedded structs are mapped like the others
+	for _, field := range reflect.VisibleFields(dst.Type()) {
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := mapFieldName(field)
+		if skip {
+			continue
+		}
+
+		index, ok := sources[name]
+		if !ok {
+			continue
+		}
+
+		sf, err := src.FieldByIndexErr(index)
+		if err != nil {
+			// nil embedded pointer in the source
+			continue
+		}
+
+		df, ok := fieldByIndexAlloc(dst, field.Index)
+		if !ok {
+			continue
+		}
+
+		fieldPath := field.Name
+		if path != "" {
+			fieldPath = path + "." + field.Name
+		}
+
+		if err := mapValue(sf, df, fieldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fieldByIndexAlloc returns the field index of v, allocating the nil embedded pointers on the
+// way. It is false when the field cannot be set (ex: promoted from an unexported struct).
+func fieldByIndexAlloc(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, v.CanSet()
+}
+
+// mapFieldIndex returns the exported fields of t, embedded and promoted ones included, keyed by
+// mapping name. A name found at several depths is the shallowest field.
+func mapFieldIndex(t reflect.Type) map[string][]int {
+	index := map[string][]int{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() {
+			continue
+		}
+
+		name, skip := mapFieldName(field)
+		if skip {
+			continue
+		}
+
+		if existing, exists := index[name]; !exists || len(field.Index) < len(existing) {
+			index[name] = field.Index
+		}
+	}
+	return index
+}
+
+func mapFieldName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get(MapTag)
+	if tag == "-" {
+		return "", true
+	}
+	if tag != "" {
+		return strings.ToLower(tag), false
+	}
+	return strings.ToLower(field.Name), false
+}
+
+func isScalarKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+func pathOrRoot(path string) string {
+	if path == "" {
+		return "value"
+	}
+	return path
+}
@@ -0,0 +1,82 @@
+package helper
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRateLimited is returned when no slot of a rate limit frees up in the allowed wait
+var ErrRateLimited = errors.New("rate limit exceeded")
+
+// TokenBucket allows rate events per second on average, and bursts of up to burst events
+// after an idle period
+type TokenBucket struct {
+	mu     sync.Mutex
+	clock  Clock
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// NewTokenBucket creates a full bucket
+func NewTokenBucket(rate float64, burst int, clock Clock) *TokenBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &TokenBucket{
+		clock:  clock,
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   clock.Now(),
+	}
+}
+
+// Reserve takes a slot and returns how long to wait before using it. When the wait would
+// exceed max (0 for no limit) nothing is taken and false is returned.
+func (b *TokenBucket) Reserve(max time.Duration) (time.Duration, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = min(b.burst, b.tokens+elapsed.Seconds()*b.rate)
+	}
+	b.last = now
+
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0, true
+	}
+
+	wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	if max > 0 && wait > max {
+		b.tokens++
+		return wait, false
+	}
+	return wait, true
+}
+
+// Wait blocks until a slot is available, or fails with ErrRateLimited when it would take
+// longer than max (0 for no limit)
+func (b *TokenBucket) Wait(ctx context.Context, max time.Duration) error {
+	wait, ok := b.Reserve(max)
+	if !ok {
+		return ErrRateLimited
+	}
+	if wait == 0 {
+		return nil
+	}
+
+	select {
+	case <-ctx.Done():
+		// the slot is lost, the limit stays respected
+		return ctx.Err()
+	case <-b.clock.After(wait):
+		return nil
+	}
+}
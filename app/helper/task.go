@@ -4,6 +4,11 @@ import "time"
 
 // Retry retries a function with exponential backoff
 func Retry(fn func() error, maxRetries int, initialDelay time.Duration) error {
+	return RetryWithClock(NewSystemClock(), fn, maxRetries, initialDelay)
+}
+
+// RetryWithClock is Retry waiting between attempts on the given clock
+func RetryWithClock(clock Clock, fn func() error, maxRetries int, initialDelay time.Duration) error {
 	var err error
 	delay := initialDelay
 
@@ -13,7 +18,7 @@ func Retry(fn func() error, maxRetries int, initialDelay time.Duration) error {
 		}
 
 		if i < maxRetries-1 {
-			time.Sleep(delay)
+			clock.Sleep(delay)
 			delay *= 2 // Exponential backoff
 		}
 	}
@@ -0,0 +1,70 @@
+package helper
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/port"
+)
+
+// TenantLocalsKey is the fiber Locals key of the tenant resolved by the tenancy middleware
+const TenantLocalsKey = "tenant"
+
+type tenantKey struct{}
+
+// WithTenant stores the tenant of the request in ctx (ex: in a job, for the tenant it runs for)
+func WithTenant(ctx context.Context, tenant *port.Tenant) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// Tenant returns the tenant stored by WithTenant, nil when there is none
+func Tenant(ctx context.Context) *port.Tenant {
+	tenant, _ := ctx.Value(tenantKey{}).(*port.Tenant)
+	return tenant
+}
+
+// CurrentTenant returns the tenant resolved by the tenancy middleware, nil when the request
+// has none or app.tenancy is disabled
+func CurrentTenant(c *fiber.Ctx) *port.Tenant {
+	tenant, _ := c.Locals(TenantLocalsKey).(*port.Tenant)
+	return tenant
+}
+
+// TenantKey prefixes a cache key with the tenant of ctx, so tenants never read the entries of
+// each other. The key is unchanged without tenant.
+func TenantKey(ctx context.Context, key string) string {
+	if tenant := Tenant(ctx); tenant != nil {
+		return "tenant:" + tenant.ID + ":" + key
+	}
+	return key
+}
+
+// TenantSetting returns the setting key of the tenant of ctx converted to T, fallback (usually
+// the value of the application configuration) when the tenant does not override it:
+//
+//	pageSize := helper.TenantSetting(ctx, "module.orders.page_size", m.config.PageSize)
+func TenantSetting[T any](ctx context.Context, key string, fallback T) T {
+	tenant := Tenant(ctx)
+	if tenant == nil {
+		return fallback
+	}
+	value, ok := tenant.Settings[key]
+	if !ok {
+		return fallback
+	}
+	if v, ok := value.(T); ok {
+		return v
+	}
+
+	// the settings read from a configuration file or JSON have their own types (ex: float64)
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fallback
+	}
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fallback
+	}
+	return v
+}
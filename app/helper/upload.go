@@ -0,0 +1,212 @@
+package helper
+
+import (
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/port"
+)
+
+// UploadRules describes what an uploaded file must satisfy before it is accepted
+type UploadRules struct {
+	Field        string   // multipart form field name
+	Required     bool     // reject the request when no file was sent
+	MaxSize      int64    // in bytes, 0 means unlimited
+	AllowedTypes []string // detected MIME types, wildcards like "image/*" are allowed
+	MinWidth     int      // image only, 0 means unchecked
+	MinHeight    int
+	MaxWidth     int
+	MaxHeight    int
+	Scanner      port.IFileScanner // optional content scanner invoked before saving
+}
+
+// UploadedFile describes an accepted upload
+type UploadedFile struct {
+	Field       string `json:"field"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width,omitempty"`
+	Height      int    `json:"height,omitempty"`
+	Location    string `json:"location,omitempty"` // returned by the UploadWriter
+}
+
+// UploadWriter stores the accepted content (disk, object storage, ...) and returns its location
+type UploadWriter func(ctx context.Context, file *UploadedFile, content io.Reader) (string, error)
+
+// ReceiveUpload validates the file sent in rules.Field and streams it to save.
+// Rejections are returned as *out.Response errors listing the violated rules.
+func ReceiveUpload(c *fiber.Ctx, rules UploadRules, save UploadWriter) (*UploadedFile, error) {
+	header, err := c.FormFile(rules.Field)
+	if err != nil {
+		if rules.Required {
+			return nil, out.ValidationError([]out.FieldError{{Field: rules.Field, Rule: "required", Message: "file is required"}})
+		}
+		return nil, nil
+	}
+
+	return receiveFile(c.UserContext(), header, rules, rules.Field, save)
+}
+
+// ReceiveUploads validates and saves every file sent in rules.Field
+func ReceiveUploads(c *fiber.Ctx, rules UploadRules, save UploadWriter) ([]*UploadedFile, error) {
+	form, err := c.MultipartForm()
+	if err != nil {
+		return nil, out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid multipart form", err)
+	}
+
+	headers := form.File[rules.Field]
+	if len(headers) == 0 && rules.Required {
+		return nil, out.ValidationError([]out.FieldError{{Field: rules.Field, Rule: "required", Message: "file is required"}})
+	}
+
+	files := make([]*UploadedFile, 0, len(headers))
+	for i, header := range headers {
+		file, err := receiveFile(c.UserContext(), header, rules, out.FieldPath(rules.Field, i), save)
+		if err != nil {
+			return files, err
+		}
+		files = append(files, file)
+	}
+
+	return files, nil
+}
+
+func receiveFile(ctx context.Context, header *multipart.FileHeader, rules UploadRules, field string, save UploadWriter) (*UploadedFile, error) {
+	if rules.MaxSize > 0 && header.Size > rules.MaxSize {
+		return nil, out.ValidationError([]out.FieldError{{Field: field, Rule: "maxSize", Message: fmt.Sprintf("file must not exceed %d bytes", rules.MaxSize), Param: strconv.FormatInt(rules.MaxSize, 10)}})
+	}
+
+	content, err := header.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer content.Close()
+
+	// Detect the type from the content instead of trusting the client header
+	sniff := make([]byte, 512)
+	n, err := io.ReadFull(content, sniff)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	contentType := http.DetectContentType(sniff[:n])
+	if mediaType, _, ok := strings.Cut(contentType, ";"); ok {
+		contentType = mediaType
+	}
+
+	file := &UploadedFile{
+		Field:       field,
+		Filename:    path.Base(strings.ReplaceAll(header.Filename, "\\", "/")),
+		ContentType: contentType,
+		Size:        header.Size,
+	}
+
+	if len(rules.AllowedTypes) > 0 && !mimeAllowed(contentType, rules.AllowedTypes) {
+		return nil, out.ValidationError([]out.FieldError{{Field: field, Rule: "mimeType", Message: fmt.Sprintf("file type %s is not allowed", contentType), Param: strings.Join(rules.AllowedTypes, ",")}})
+	}
+
+	if strings.HasPrefix(contentType, "image/") && (rules.MinWidth > 0 || rules.MinHeight > 0 || rules.MaxWidth > 0 || rules.MaxHeight > 0) {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		cfg, _, err := image.DecodeConfig(content)
+		if err != nil {
+			return nil, out.ValidationError([]out.FieldError{{Field: field, Rule: "image", Message: "file is not a readable image"}})
+		}
+		file.Width, file.Height = cfg.Width, cfg.Height
+
+		if errs := checkDimensions(field, cfg.Width, cfg.Height, rules); len(errs) > 0 {
+			return nil, out.ValidationError(errs)
+		}
+	}
+
+	if rules.Scanner != nil {
+		if _, err := content.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+
+		if err := rules.Scanner.Scan(ctx, file.Filename, content); err != nil {
+			return nil, out.ValidationError([]out.FieldError{{Field: field, Rule: "scan", Message: err.Error()}})
+		}
+	}
+
+	if _, err := content.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	if save != nil {
+		location, err := save(ctx, file, content)
+		if err != nil {
+			return nil, err
+		}
+		file.Location = location
+	}
+
+	return file, nil
+}
+
+func checkDimensions(field string, width int, height int, rules UploadRules) []out.FieldError {
+	errs := []out.FieldError{}
+	if rules.MinWidth > 0 && width < rules.MinWidth {
+		errs = append(errs, out.FieldError{Field: field, Rule: "minWidth", Message: fmt.Sprintf("image width must be at least %dpx", rules.MinWidth), Param: strconv.Itoa(rules.MinWidth)})
+	}
+	if rules.MinHeight > 0 && height < rules.MinHeight {
+		errs = append(errs, out.FieldError{Field: field, Rule: "minHeight", Message: fmt.Sprintf("image height must be at least %dpx", rules.MinHeight), Param: strconv.Itoa(rules.MinHeight)})
+	}
+	if rules.MaxWidth > 0 && width > rules.MaxWidth {
+		errs = append(errs, out.FieldError{Field: field, Rule: "maxWidth", Message: fmt.Sprintf("image width must be at most %dpx", rules.MaxWidth), Param: strconv.Itoa(rules.MaxWidth)})
+	}
+	if rules.MaxHeight > 0 && height > rules.MaxHeight {
+		errs = append(errs, out.FieldError{Field: field, Rule: "maxHeight", Message: fmt.Sprintf("image height must be at most %dpx", rules.MaxHeight), Param: strconv.Itoa(rules.MaxHeight)})
+	}
+	return errs
+}
+
+func mimeAllowed(contentType string, allowed []string) bool {
+	for _, a := range allowed {
+		if a == contentType || a == "*/*" {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(a, "/*"); ok && strings.HasPrefix(contentType, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// StorageUploadWriter saves accepted uploads into object storage below prefix, under a
+// generated name keeping the original extension. The object key is used as location.
+func StorageUploadWriter(storage port.IObjectStorage, prefix string) UploadWriter {
+	return func(ctx context.Context, file *UploadedFile, content io.Reader) (string, error) {
+		id, err := GenerateUUID()
+		if err != nil {
+			return "", err
+		}
+
+		key := strings.TrimSuffix(prefix, "/") + "/" + id + strings.ToLower(path.Ext(file.Filename))
+		key = strings.TrimPrefix(key, "/")
+
+		_, err = storage.Put(ctx, key, content, port.PutOptions{
+			ContentType: file.ContentType,
+			Size:        file.Size,
+			Metadata:    map[string]string{"filename": file.Filename},
+		})
+		if err != nil {
+			return "", err
+		}
+		return key, nil
+	}
+}
@@ -1,6 +1,7 @@
 package out
 
 import (
+	"fmt"
 	"runtime/debug"
 	"strings"
 
@@ -15,13 +16,41 @@ func SetEnvironment(env string) {
 
 // Response represents a standard API response
 type Response struct {
-	HttpCode   int      `json:"httpCode,omitempty"`
-	ErrorCode  int      `json:"errorCode,omitempty"`
-	ErrorName  string   `json:"errorName,omitempty"`
-	Message    string   `json:"message,omitempty"`
-	Data       any      `json:"data,omitempty"`
-	StackTrace []string `json:"stack,omitempty"`
-	Details    *string  `json:"details,omitempty"`
+	HttpCode   int          `json:"httpCode,omitempty"`
+	RequestID  string       `json:"requestId,omitempty"` // ID of the request, to correlate with the logs
+	ErrorCode  int          `json:"errorCode,omitempty"`
+	ErrorName  string       `json:"errorName,omitempty"`
+	Message    string       `json:"message,omitempty"`
+	Data       any          `json:"data,omitempty"`
+	Meta       *Meta        `json:"meta,omitempty"` // page of a paginated list
+	StackTrace []string     `json:"stack,omitempty"`
+	Details    *string      `json:"details,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes a validation failure of a single request field
+type FieldError struct {
+	Field   string `json:"field"`           // path of the field, e.g. items[0].name
+	Rule    string `json:"rule"`            // violated rule, e.g. required, min, email
+	Message string `json:"message"`         // human readable message
+	Param   string `json:"param,omitempty"` // rule parameter, e.g. 3 for min=3
+}
+
+// FieldPath joins field names and slice indexes into a path such as items[0].name
+func FieldPath(parts ...any) string {
+	var sb strings.Builder
+	for _, part := range parts {
+		switch p := part.(type) {
+		case int:
+			fmt.Fprintf(&sb, "[%d]", p)
+		default:
+			if sb.Len() > 0 {
+				sb.WriteByte('.')
+			}
+			fmt.Fprint(&sb, p)
+		}
+	}
+	return sb.String()
 }
 
 func newResponse(response *Response) *Response {
@@ -103,9 +132,22 @@ func ErrorTrace(httpCode int, errorCode int, errorName string, message string, c
 
 	return newResponse(&Response{
 		HttpCode:   httpCode,
+		RequestID:  c.GetRespHeader(fiber.HeaderXRequestID),
 		ErrorCode:  errorCode,
 		ErrorName:  errorName,
 		Message:    message,
 		StackTrace: stack,
 	})
 }
+
+// ValidationError creates a 422 response listing the invalid fields
+func ValidationError(errors []FieldError) *Response {
+	return newResponse(&Response{
+		HttpCode:   422,
+		ErrorCode:  3,
+		ErrorName:  "VALIDATION_ERROR",
+		Message:    "Validation failed",
+		Errors:     errors,
+		StackTrace: []string{},
+	})
+}
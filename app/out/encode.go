@@ -0,0 +1,309 @@
+package out
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// member is a member of a JSON object, objects keep the order of their members
+type member struct {
+	name  string
+	value any
+}
+
+type object []member
+
+// responseTree returns the JSON value of response, its names and omissions following the json
+// tags: an object, []any, string, stdjson.Number, bool or nil
+func responseTree(response *Response) (any, error) {
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return decodeTree(dec)
+}
+
+func decodeTree(dec *stdjson.Decoder) (any, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch token {
+	case stdjson.Delim('{'):
+		obj := object{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, member{key.(string), value})
+		}
+		_, err = dec.Token()
+		return obj, err
+	case stdjson.Delim('['):
+		list := []any{}
+		for dec.More() {
+			value, err := decodeTree(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err = dec.Token()
+		return list, err
+	}
+	return token, nil
+}
+
+// encodeXML writes the response in a <response> element, the members of the objects as
+// elements and the values of the lists as <item> elements
+func encodeXML(response *Response) ([]byte, error) {
+	tree, err := responseTree(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	writeXML(&buf, "response", tree)
+	return buf.Bytes(), nil
+}
+
+func writeXML(buf *bytes.Buffer, name string, value any) {
+	if value == nil {
+		fmt.Fprintf(buf, "<%s/>", name)
+		return
+	}
+
+	fmt.Fprintf(buf, "<%s>", name)
+	switch v := value.(type) {
+	case object:
+		for _, m := range v {
+			writeXML(buf, xmlName(m.name), m.value)
+		}
+	case []any:
+		for _, item := range v {
+			writeXML(buf, "item", item)
+		}
+	default:
+		xml.EscapeText(buf, []byte(fmt.Sprint(v)))
+	}
+	fmt.Fprintf(buf, "</%s>", name)
+}
+
+// xmlName turns a member name into an element name, its invalid characters replaced by _
+func xmlName(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		valid := r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' ||
+			i > 0 && (r == '-' || r == '.' || r >= '0' && r <= '9')
+		if !valid {
+			r = '_'
+		}
+		sb.WriteRune(r)
+	}
+	if sb.Len() == 0 || strings.HasPrefix(strings.ToLower(sb.String()), "xml") {
+		return "_" + sb.String()
+	}
+	return sb.String()
+}
+
+// encodeMsgPack writes the response in MessagePack, as a map of its JSON members
+func encodeMsgPack(response *Response) ([]byte, error) {
+	tree, err := responseTree(response)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := writeMsgPack(&buf, tree); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeMsgPack(buf *bytes.Buffer, value any) error {
+	switch v := value.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if v {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case stdjson.Number:
+		if i, err := v.Int64(); err == nil {
+			writeMsgPackInt(buf, i)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		buf.WriteByte(0xcb)
+		writeBigEndian(buf, math.Float64bits(f), 8)
+	case string:
+		writeMsgPackHeader(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		buf.WriteString(v)
+	case []any:
+		writeMsgPackHeader(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgPack(buf, item); err != nil {
+				return err
+			}
+		}
+	case object:
+		writeMsgPackHeader(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		for _, m := range v {
+			writeMsgPackHeader(buf, len(m.name), 0xa0, 32, 0xd9, 0xda, 0xdb)
+			buf.WriteString(m.name)
+			if err := writeMsgPack(buf, m.value); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported value %T", value)
+	}
+	return nil
+}
+
+// writeMsgPackHeader writes the length n of a string, list or map: in the fixed type under
+// fixMax, else in the 8 (when the type has one), 16 or 32 bits type
+func writeMsgPackHeader(buf *bytes.Buffer, n int, fixed byte, fixMax int, type8 byte, type16 byte, type32 byte) {
+	switch {
+	case n < fixMax:
+		buf.WriteByte(fixed | byte(n))
+	case type8 != 0 && n <= math.MaxUint8:
+		buf.WriteByte(type8)
+		buf.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		buf.WriteByte(type16)
+		writeBigEndian(buf, uint64(n), 2)
+	default:
+		buf.WriteByte(type32)
+		writeBigEndian(buf, uint64(n), 4)
+	}
+}
+
+func writeMsgPackInt(buf *bytes.Buffer, i int64) {
+	switch {
+	case i >= 0 && i < 128, i < 0 && i >= -32:
+		buf.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint8:
+		buf.WriteByte(0xcc)
+		buf.WriteByte(byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		buf.WriteByte(0xcd)
+		writeBigEndian(buf, uint64(i), 2)
+	case i >= 0 && i <= math.MaxUint32:
+		buf.WriteByte(0xce)
+		writeBigEndian(buf, uint64(i), 4)
+	case i >= 0:
+		buf.WriteByte(0xcf)
+		writeBigEndian(buf, uint64(i), 8)
+	case i >= math.MinInt8:
+		buf.WriteByte(0xd0)
+		buf.WriteByte(byte(i))
+	case i >= math.MinInt16:
+		buf.WriteByte(0xd1)
+		writeBigEndian(buf, uint64(i), 2)
+	case i >= math.MinInt32:
+		buf.WriteByte(0xd2)
+		writeBigEndian(buf, uint64(i), 4)
+	default:
+		buf.WriteByte(0xd3)
+		writeBigEndian(buf, uint64(i), 8)
+	}
+}
+
+func writeBigEndian(buf *bytes.Buffer, v uint64, size int) {
+	for shift := (size - 1) * 8; shift >= 0; shift -= 8 {
+		buf.WriteByte(byte(v >> shift))
+	}
+}
+
+// encodeText writes the response for a reader: the error and its invalid fields, else the
+// message and the data, its objects and lists one value per line
+func encodeText(response *Response) ([]byte, error) {
+	var buf bytes.Buffer
+	if response.ErrorName != "" {
+		fmt.Fprintf(&buf, "%s: %s\n", response.ErrorName, response.Message)
+		for _, e := range response.Errors {
+			fmt.Fprintf(&buf, "%s: %s\n", e.Field, e.Message)
+		}
+		return buf.Bytes(), nil
+	}
+
+	if response.Message != "" {
+		fmt.Fprintln(&buf, response.Message)
+	}
+	if response.Data == nil {
+		return buf.Bytes(), nil
+	}
+
+	tree, err := responseTree(&Response{Data: response.Data})
+	if err != nil {
+		return nil, err
+	}
+	for _, m := range tree.(object) {
+		if m.name == "data" {
+			writeText(&buf, m.value, "")
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func writeText(w io.Writer, value any, indent string) {
+	switch v := value.(type) {
+	case object:
+		for _, m := range v {
+			if isScalar(m.value) {
+				fmt.Fprintf(w, "%s%s: %s\n", indent, m.name, textScalar(m.value))
+			} else {
+				fmt.Fprintf(w, "%s%s:\n", indent, m.name)
+				writeText(w, m.value, indent+"  ")
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if isScalar(item) {
+				fmt.Fprintf(w, "%s- %s\n", indent, textScalar(item))
+			} else {
+				fmt.Fprintf(w, "%s-\n", indent)
+				writeText(w, item, indent+"  ")
+			}
+		}
+	default:
+		fmt.Fprintf(w, "%s%s\n", indent, textScalar(v))
+	}
+}
+
+func isScalar(value any) bool {
+	switch value.(type) {
+	case object, []any:
+		return false
+	}
+	return true
+}
+
+func textScalar(value any) string {
+	if value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
@@ -0,0 +1,77 @@
+package out
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrorMapper returns the Response sent for err, nil when err is not its concern
+type ErrorMapper func(err error) *Response
+
+// FieldErrors is the error of an invalid input, sent as a ValidationError. It lets the layers
+// below the handlers report the invalid fields by returning an error.
+type FieldErrors []FieldError
+
+func (e FieldErrors) Error() string {
+	if len(e) == 0 {
+		return "validation failed"
+	}
+	return e[0].Field + ": " + e[0].Message
+}
+
+var (
+	errorMappersMu sync.RWMutex
+	errorMappers   []ErrorMapper
+)
+
+// RegisterErrorMapper adds mapper to the mappers of ErrorResponse. The last registered is
+// tried first, so a module overrides the mappings of the framework.
+func RegisterErrorMapper(mapper ErrorMapper) {
+	errorMappersMu.Lock()
+	defer errorMappersMu.Unlock()
+	errorMappers = append(errorMappers, mapper)
+}
+
+// RegisterError maps the errors matching target with errors.Is to a Response with httpCode,
+// errorCode and errorName, whose message is the one of the error
+func RegisterError(target error, httpCode int, errorCode int, errorName string) {
+	RegisterErrorMapper(func(err error) *Response {
+		if errors.Is(err, target) {
+			return Error(httpCode, errorCode, errorName, err.Error())
+		}
+		return nil
+	})
+}
+
+// RegisterErrorType maps the errors of type T, found with errors.As, with mapper
+func RegisterErrorType[T error](mapper func(err T) *Response) {
+	RegisterErrorMapper(func(err error) *Response {
+		var target T
+		if errors.As(err, &target) {
+			return mapper(target)
+		}
+		return nil
+	})
+}
+
+// ErrorResponse returns the Response sent for err: the *Response it wraps, the ValidationError
+// of its FieldErrors, else the one of the last registered mapper handling it, nil when none does
+func ErrorResponse(err error) *Response {
+	var response *Response
+	if errors.As(err, &response) {
+		return response
+	}
+	var fields FieldErrors
+	if errors.As(err, &fields) {
+		return ValidationError(fields)
+	}
+
+	errorMappersMu.RLock()
+	defer errorMappersMu.RUnlock()
+	for i := len(errorMappers) - 1; i >= 0; i-- {
+		if response := errorMappers[i](err); response != nil {
+			return response
+		}
+	}
+	return nil
+}
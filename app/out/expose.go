@@ -0,0 +1,230 @@
+package out
+
+import (
+	"encoding"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ExposeTag is the struct tag listing the roles allowed to see a field, e.g. `expose:"admin,auditor"`.
+// Fields without the tag are always serialized.
+var ExposeTag = "expose"
+
+// rolesResolver returns the roles of the principal of the current request
+var rolesResolver func(c *fiber.Ctx) []string
+
+// maskedTypes caches whether a type (or any type nested in it) carries expose tags
+var maskedTypes sync.Map // reflect.Type -> bool
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// SetRolesResolver registers the function used by Send to find the roles of the current principal
+func SetRolesResolver(resolver func(c *fiber.Ctx) []string) {
+	rolesResolver = resolver
+}
+
+// Expose returns a copy of data without the fields the given roles are not allowed to see.
+// Data whose types carry no expose tag is returned untouched.
+func Expose(data any, roles []string) any {
+	if data == nil {
+		return nil
+	}
+
+	v := reflect.ValueOf(data)
+	if !needsMasking(v.Type()) {
+		return data
+	}
+
+	return exposeValue(v, roles)
+}
+
+// ExposeFor applies Expose with the roles of the principal of the current request
+func ExposeFor(c *fiber.Ctx, data any) any {
+	var roles []string
+	if rolesResolver != nil {
+		roles = rolesResolver(c)
+	}
+	return Expose(data, roles)
+}
+
+func exposeValue(v reflect.Value, roles []string) any {
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return exposeValue(v.Elem(), roles)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return nil
+		}
+		if !needsMasking(v.Type().Elem()) {
+			return v.Interface()
+		}
+		result := make([]any, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			result[i] = exposeValue(v.Index(i), roles)
+		}
+		return result
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+		if !needsMasking(v.Type().Elem()) {
+			return v.Interface()
+		}
+		result := make(map[string]any, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			result[mapKeyString(iter.Key())] = exposeValue(iter.Value(), roles)
+		}
+		return result
+	case reflect.Struct:
+		if !needsMasking(v.Type()) {
+			return v.Interface()
+		}
+		result := map[string]any{}
+		exposeStruct(v, roles, result)
+		return result
+	default:
+		return v.Interface()
+	}
+}
+
+func exposeStruct(v reflect.Value, roles []string, result map[string]any) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		embedded := flattened(field)
+		if !field.IsExported() && !embedded {
+			continue
+		}
+
+		if tag, ok := field.Tag.Lookup(ExposeTag); ok && !hasAnyRole(roles, tag) {
+			continue
+		}
+
+		name, omitEmpty, skip := jsonFieldName(field)
+		if skip {
+			continue
+		}
+
+		fv := v.Field(i)
+
+		// embedded structs without a json name are flattened like encoding/json does
+		if embedded {
+			inner := fv
+			if inner.Kind() == reflect.Pointer {
+				if inner.IsNil() {
+					continue
+				}
+				inner = inner.Elem()
+			}
+			exposeStruct(inner, roles, result)
+			continue
+		}
+
+		if omitEmpty && fv.IsZero() {
+			continue
+		}
+
+		result[name] = exposeValue(fv, roles)
+	}
+}
+
+// flattened reports whether field is an embedded struct without a json name, whose fields are
+// promoted like encoding/json does, even when its type is unexported
+func flattened(field reflect.StructField) bool {
+	t := field.Type
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return field.Anonymous && field.Tag.Get("json") == "" && t.Kind() == reflect.Struct
+}
+
+func jsonFieldName(field reflect.StructField) (string, bool, bool) {
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, true
+	}
+
+	parts := strings.Split(tag, ",")
+	name := parts[0]
+	if name == "" {
+		name = field.Name
+	}
+
+	return name, slices.Contains(parts[1:], "omitempty"), false
+}
+
+func hasAnyRole(roles []string, tag string) bool {
+	for _, required := range strings.Split(tag, ",") {
+		if slices.Contains(roles, strings.TrimSpace(required)) {
+			return true
+		}
+	}
+	return false
+}
+
+func mapKeyString(key reflect.Value) string {
+	if key.Kind() == reflect.String {
+		return key.String()
+	}
+	raw, _ := json.Marshal(key.Interface())
+	return strings.Trim(string(raw), `"`)
+}
+
+// needsMasking reports whether values of t may contain fields hidden by expose tags
+func needsMasking(t reflect.Type) bool {
+	if cached, ok := maskedTypes.Load(t); ok {
+		return cached.(bool)
+	}
+
+	// only the top level result is cached, intermediate results may be incomplete on recursive types
+	result := needsMaskingSeen(t, map[reflect.Type]bool{})
+	maskedTypes.Store(t, result)
+	return result
+}
+
+func needsMaskingSeen(t reflect.Type, seen map[reflect.Type]bool) bool {
+	if seen[t] {
+		return false
+	}
+	seen[t] = true
+
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		return false
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Array, reflect.Map:
+		return needsMaskingSeen(t.Elem(), seen)
+	case reflect.Interface:
+		// the dynamic type is only known at runtime
+		return true
+	case reflect.Struct:
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			embedded := flattened(field)
+			if !field.IsExported() && !embedded {
+				continue
+			}
+			if _, ok := field.Tag.Lookup(ExposeTag); ok {
+				return true
+			}
+			if needsMaskingSeen(field.Type, seen) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
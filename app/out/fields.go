@@ -0,0 +1,166 @@
+package out
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+)
+
+// FieldsQuery is the query parameter used to request a sparse fieldset, e.g. ?fields=id,name,author.name
+var FieldsQuery = "fields"
+
+// fieldAllowlists holds the selectable fields per Data element type
+var fieldAllowlists sync.Map // reflect.Type -> map[string]bool
+
+// AllowFields restricts the fields of T that clients may select with ?fields=.
+// Types without an allowlist accept any field present in their JSON representation.
+func AllowFields[T any](fields ...string) {
+	allowed := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		allowed[f] = true
+	}
+	fieldAllowlists.Store(baseType(reflect.TypeOf((*T)(nil)).Elem()), allowed)
+}
+
+// ParseFields returns the requested sparse fieldset, or nil when none was requested
+func ParseFields(c *fiber.Ctx) []string {
+	query := c.Query(FieldsQuery)
+	if query == "" {
+		return nil
+	}
+
+	fields := []string{}
+	for _, f := range strings.Split(query, ",") {
+		f = strings.TrimSpace(f)
+		if f != "" {
+			fields = append(fields, f)
+		}
+	}
+	return fields
+}
+
+// SelectFields reduces data (struct, map or slice of them) to the given fields.
+// Nested fields are addressed with dots (author.name). Fields outside the
+// allowlist registered with AllowFields are ignored.
+func SelectFields(data any, fields []string) (any, error) {
+	if data == nil || len(fields) == 0 {
+		return data, nil
+	}
+
+	return selectFields(data, allowedFields(reflect.TypeOf(data), fields))
+}
+
+// allowedFields drops the fields not in the allowlist registered for t
+func allowedFields(t reflect.Type, fields []string) []string {
+	allowed, ok := fieldAllowlists.Load(baseType(t))
+	if !ok {
+		return fields
+	}
+
+	permitted := make([]string, 0, len(fields))
+	for _, f := range fields {
+		if allowed.(map[string]bool)[f] || allowed.(map[string]bool)[strings.SplitN(f, ".", 2)[0]] {
+			permitted = append(permitted, f)
+		}
+	}
+	return permitted
+}
+
+func selectFields(data any, fields []string) (any, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+
+	var generic any
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	if err := decoder.Decode(&generic); err != nil {
+		return nil, err
+	}
+
+	return pickFields(generic, buildFieldTree(fields)), nil
+}
+
+type fieldTree map[string]fieldTree
+
+func buildFieldTree(fields []string) fieldTree {
+	tree := fieldTree{}
+	for _, f := range fields {
+		node := tree
+		for _, part := range strings.Split(f, ".") {
+			child, ok := node[part]
+			if !ok {
+				child = fieldTree{}
+				node[part] = child
+			}
+			node = child
+		}
+	}
+	return tree
+}
+
+func pickFields(value any, tree fieldTree) any {
+	if len(tree) == 0 {
+		return value
+	}
+
+	switch v := value.(type) {
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = pickFields(item, tree)
+		}
+		return result
+	case map[string]any:
+		result := make(map[string]any, len(tree))
+		for key, sub := range tree {
+			if item, ok := v[key]; ok {
+				result[key] = pickFields(item, sub)
+			}
+		}
+		return result
+	default:
+		return value
+	}
+}
+
+// baseType unwraps pointers, slices and arrays down to the element type
+func baseType(t reflect.Type) reflect.Type {
+	for t != nil && (t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+		t = t.Elem()
+	}
+	return t
+}
+
+// Send writes the response as JSON with HttpCode as the status code. Fields of Data
+// hidden from the current principal by expose tags are removed, then the sparse
+// fieldset requested by the client is applied. RequestID defaults to the X-Request-ID answered.
+// The response is JSON, or the format of SetFormats the Accept header of the client prefers.
+func Send(c *fiber.Ctx, response *Response) error {
+	status := response.HttpCode
+	if status == 0 {
+		status = fiber.StatusOK
+	}
+	if response.RequestID == "" {
+		response.RequestID = c.GetRespHeader(fiber.HeaderXRequestID)
+	}
+
+	// the allowlist is resolved on the original type since Expose may turn Data into maps
+	dataType := reflect.TypeOf(response.Data)
+	response.Data = ExposeFor(c, response.Data)
+
+	if fields := ParseFields(c); len(fields) > 0 && response.Data != nil {
+		data, err := selectFields(response.Data, allowedFields(dataType, fields))
+		if err != nil {
+			return err
+		}
+		response.Data = data
+	}
+
+	return write(c, status, response)
+}
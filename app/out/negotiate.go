@@ -0,0 +1,105 @@
+package out
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Encoder serializes a Response in the media type of its format
+type Encoder func(response *Response) ([]byte, error)
+
+// format is an encoding of the Responses and the media types it answers
+type format struct {
+	encoder    Encoder
+	mediaTypes []string
+}
+
+var (
+	formatsMu sync.RWMutex
+	formats   = map[string]*format{
+		"xml":     {encodeXML, []string{fiber.MIMEApplicationXML, fiber.MIMETextXML}},
+		"msgpack": {encodeMsgPack, []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}},
+		"text":    {encodeText, []string{fiber.MIMETextPlain}},
+	}
+	// offers are the media types Send negotiates after JSON, with their format
+	offers       []string
+	offerFormats map[string]*format
+)
+
+// RegisterEncoder adds the format name (ex: "protobuf") serializing the Responses with encoder,
+// answered to the clients accepting one of mediaTypes. It replaces the format of the same name.
+// The format is negotiated once listed by SetFormats (app.formats).
+func RegisterEncoder(name string, encoder Encoder, mediaTypes ...string) {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+	formats[name] = &format{encoder: encoder, mediaTypes: mediaTypes}
+}
+
+// SetFormats sets the formats Send negotiates with the Accept header of the client, JSON being
+// always served and the default. An unknown format fails.
+func SetFormats(names ...string) error {
+	formatsMu.Lock()
+	defer formatsMu.Unlock()
+
+	var enabled []string
+	enabledFormats := map[string]*format{}
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "json" || name == "" {
+			continue
+		}
+		f, ok := formats[name]
+		if !ok {
+			return fmt.Errorf("unknown response format '%s'", name)
+		}
+		for _, mediaType := range f.mediaTypes {
+			if _, ok := enabledFormats[mediaType]; !ok {
+				enabled = append(enabled, mediaType)
+				enabledFormats[mediaType] = f
+			}
+		}
+	}
+
+	offers, offerFormats = enabled, enabledFormats
+	return nil
+}
+
+// negotiate returns the media type and the format of the response the client accepts best,
+// nil for JSON
+func negotiate(c *fiber.Ctx) (string, *format) {
+	formatsMu.RLock()
+	defer formatsMu.RUnlock()
+	if len(offers) == 0 {
+		return "", nil
+	}
+
+	c.Vary(fiber.HeaderAccept)
+	mediaType := c.Accepts(append([]string{fiber.MIMEApplicationJSON}, offers...)...)
+	return mediaType, offerFormats[mediaType]
+}
+
+// write sends response with status in the format negotiated with the client, or as a problem
+// document (SetProblemDetails)
+func write(c *fiber.Ctx, status int, response *Response) error {
+	if isProblem(response) {
+		return c.Status(status).JSON(NewProblem(c, response), MIMEApplicationProblemJSON)
+	}
+
+	mediaType, f := negotiate(c)
+	if f == nil {
+		return c.Status(status).JSON(response)
+	}
+
+	body, err := f.encoder(response)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(mediaType, "text/") {
+		mediaType += "; charset=utf-8"
+	}
+	c.Set(fiber.HeaderContentType, mediaType)
+	return c.Status(status).Send(body)
+}
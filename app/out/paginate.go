@@ -0,0 +1,92 @@
+package out
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Meta is the block of a paginated Response describing its page
+type Meta struct {
+	Page       int   `json:"page"`
+	PerPage    int   `json:"perPage"`
+	Total      int64 `json:"total"`
+	TotalPages int64 `json:"totalPages"`
+	HasNext    bool  `json:"hasNext"`
+}
+
+// Page is the page of a list requested by the client, see ParsePagination
+type Page struct {
+	Page    int // from 1
+	PerPage int
+}
+
+// Limit returns the number of rows of the page, the limit of IDatabase.Find
+func (p Page) Limit() int64 {
+	return int64(p.PerPage)
+}
+
+// Skip returns the number of rows before the page, the skip of IDatabase.Find
+func (p Page) Skip() int64 {
+	return int64(p.Page-1) * int64(p.PerPage)
+}
+
+var (
+	defaultPerPage = 20
+	maxPerPage     = 100
+)
+
+// SetPaginationLimits sets the size of the pages when the client gives none and the largest
+// size it may ask for (app.pagination)
+func SetPaginationLimits(defaultSize int, maxSize int) {
+	if defaultSize > 0 {
+		defaultPerPage = defaultSize
+	}
+	if maxSize > 0 {
+		maxPerPage = maxSize
+	}
+	defaultPerPage = min(defaultPerPage, maxPerPage)
+}
+
+// ParsePagination returns the page of the query parameters page and per_page, the default
+// size when per_page is missing and the largest size when it asks more. The error is a 400
+// *Response that can be returned directly from a handler.
+func ParsePagination(c *fiber.Ctx) (Page, error) {
+	page := Page{Page: 1, PerPage: defaultPerPage}
+
+	var fields []FieldError
+	if value := c.Query("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			fields = append(fields, FieldError{Field: "page", Rule: "min", Message: "page must be a number from 1", Param: "1"})
+		}
+		page.Page = n
+	}
+	if value := c.Query("per_page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			fields = append(fields, FieldError{Field: "per_page", Rule: "min", Message: "per_page must be a number from 1", Param: "1"})
+		}
+		page.PerPage = min(n, maxPerPage)
+	}
+
+	if len(fields) > 0 {
+		response := Error(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid pagination")
+		response.Errors = fields
+		return Page{}, response
+	}
+	return page, nil
+}
+
+// Paginated creates a success response with the data of a page and its Meta
+func Paginated(data any, page int, perPage int, total int64) *Response {
+	meta := &Meta{Page: page, PerPage: perPage, Total: total}
+	if perPage > 0 {
+		meta.TotalPages = (total + int64(perPage) - 1) / int64(perPage)
+		meta.HasNext = int64(page) < meta.TotalPages
+	}
+	return &Response{
+		Data: data,
+		Meta: meta,
+	}
+}
@@ -0,0 +1,71 @@
+package out
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// MIMEApplicationProblemJSON is the media type of the RFC 9457 problem documents
+const MIMEApplicationProblemJSON = "application/problem+json"
+
+// Problem is the RFC 9457 problem document of an error Response. The error code and name, the
+// request ID, the invalid fields and the stack of the Response are kept as extension members.
+type Problem struct {
+	Type       string       `json:"type"`
+	Title      string       `json:"title"`
+	Status     int          `json:"status"`
+	Detail     string       `json:"detail,omitempty"`
+	Instance   string       `json:"instance,omitempty"`
+	ErrorCode  int          `json:"errorCode,omitempty"`
+	ErrorName  string       `json:"errorName,omitempty"`
+	RequestID  string       `json:"requestId,omitempty"`
+	Errors     []FieldError `json:"errors,omitempty"`
+	Details    *string      `json:"details,omitempty"`
+	StackTrace []string     `json:"stack,omitempty"`
+}
+
+var (
+	problemDetails  bool
+	problemTypeBase string
+)
+
+// SetProblemDetails makes Send answer the error Responses as problem documents when enabled.
+// Their type is typeBase followed by the error name (ex: https://errors.example.com/not-found),
+// about:blank when typeBase is empty (app.problem_details).
+func SetProblemDetails(enabled bool, typeBase string) {
+	problemDetails = enabled
+	problemTypeBase = typeBase
+}
+
+// NewProblem returns the problem document of the error response of the request c
+func NewProblem(c *fiber.Ctx, response *Response) *Problem {
+	status := response.HttpCode
+	if status == 0 {
+		status = fiber.StatusInternalServerError
+	}
+
+	problem := &Problem{
+		Type:       "about:blank",
+		Title:      http.StatusText(status),
+		Status:     status,
+		Detail:     response.Message,
+		Instance:   c.Path(),
+		ErrorCode:  response.ErrorCode,
+		ErrorName:  response.ErrorName,
+		RequestID:  response.RequestID,
+		Errors:     response.Errors,
+		Details:    response.Details,
+		StackTrace: response.StackTrace,
+	}
+	if problemTypeBase != "" && response.ErrorName != "" {
+		problem.Type = problemTypeBase + strings.ReplaceAll(strings.ToLower(response.ErrorName), "_", "-")
+	}
+	return problem
+}
+
+// isProblem reports whether response is an error Send answers as a problem document
+func isProblem(response *Response) bool {
+	return problemDetails && (response.ErrorCode != 0 || response.HttpCode >= fiber.StatusBadRequest)
+}
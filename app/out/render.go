@@ -0,0 +1,45 @@
+package out
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/port"
+)
+
+// viewRenderer renders the views of Render
+var viewRenderer port.ITemplateRenderer
+
+// pageRenderer is implemented by renderers supporting layouts
+type pageRenderer interface {
+	RenderPage(w io.Writer, name string, data any, layout ...string) error
+}
+
+// SetViewRenderer registers the template engine used by Render
+func SetViewRenderer(renderer port.ITemplateRenderer) {
+	viewRenderer = renderer
+}
+
+// Render responds with the HTML view rendered with data, inside the default layout
+// unless a layout is given (an empty layout renders the view bare)
+func Render(c *fiber.Ctx, view string, data any, layout ...string) error {
+	if viewRenderer == nil {
+		return fmt.Errorf("view '%s' cannot be rendered, no template renderer", view)
+	}
+
+	var buf bytes.Buffer
+	var err error
+	if pages, ok := viewRenderer.(pageRenderer); ok {
+		err = pages.RenderPage(&buf, view, data, layout...)
+	} else {
+		err = viewRenderer.Render(&buf, view, data)
+	}
+	if err != nil {
+		return err
+	}
+
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	return c.Send(buf.Bytes())
+}
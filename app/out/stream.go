@@ -0,0 +1,254 @@
+package out
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrClientDisconnected is reported to StreamOptions.OnDone when the client goes away
+// before the whole body has been sent
+var ErrClientDisconnected = errors.New("client disconnected before stream completed")
+
+// StreamOptions configures Stream and StreamFile
+type StreamOptions struct {
+	// Size is the total size of the content, 0 means detect (io.Seeker) and -1 means unknown (chunked)
+	Size int64
+	// ModTime is sent as Last-Modified when not zero
+	ModTime time.Time
+	// Inline serves the content with "Content-Disposition: inline" instead of attachment
+	Inline bool
+	// OnProgress is called after every chunk written to the client
+	OnProgress func(written int64, total int64)
+	// OnDone is called once when the stream finishes, err is nil on success
+	OnDone func(written int64, err error)
+}
+
+// Stream writes reader to the client without buffering the whole content in memory.
+// When reader implements io.Seeker and the size is known, single range requests
+// (Range: bytes=start-end) are answered with 206 Partial Content.
+// If reader implements io.Closer it is closed when the stream ends or the client disconnects,
+// which lets producers writing into an io.Pipe stop early.
+func Stream(c *fiber.Ctx, reader io.Reader, contentType string, filename string, opts ...StreamOptions) error {
+	var opt StreamOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	size := opt.Size
+	seeker, seekable := reader.(io.Seeker)
+	if size == 0 && seekable {
+		end, err := seeker.Seek(0, io.SeekEnd)
+		if err != nil {
+			return err
+		}
+		if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		size = end
+	} else if size == 0 {
+		size = -1
+	}
+
+	if contentType == "" && filename != "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	if contentType == "" {
+		contentType = fiber.MIMEOctetStream
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+
+	if filename != "" {
+		disposition := "attachment"
+		if opt.Inline {
+			disposition = "inline"
+		}
+		c.Set(fiber.HeaderContentDisposition, fmt.Sprintf(`%s; filename="%s"`, disposition, strings.ReplaceAll(filename, `"`, "")))
+	}
+
+	if !opt.ModTime.IsZero() {
+		c.Set(fiber.HeaderLastModified, opt.ModTime.UTC().Format(http.TimeFormat))
+	}
+
+	length := size
+	if seekable && size >= 0 {
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+
+		if header := c.Get(fiber.HeaderRange); header != "" {
+			start, end, ok := parseByteRange(header, size)
+			if !ok {
+				closeReader(reader)
+				c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", size))
+				return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+			}
+
+			if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+				closeReader(reader)
+				return err
+			}
+
+			length = end - start + 1
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, end, size))
+			c.Status(fiber.StatusPartialContent)
+		}
+	}
+
+	if c.Method() == fiber.MethodHead {
+		closeReader(reader)
+		if length >= 0 {
+			c.Set(fiber.HeaderContentLength, strconv.FormatInt(length, 10))
+		}
+		return nil
+	}
+
+	body := &streamReader{
+		reader:     reader,
+		total:      length,
+		onProgress: opt.OnProgress,
+		onDone:     opt.OnDone,
+	}
+	if length >= 0 {
+		body.reader = io.LimitReader(reader, length)
+		body.closer, _ = reader.(io.Closer)
+	}
+
+	// fasthttp reads the body while writing it to the connection and closes it
+	// when the write fails, so Close doubles as the client disconnect signal
+	c.Context().Response.SetBodyStream(body, int(length))
+	return nil
+}
+
+// StreamFile streams a file from disk with range support, using its name as the download filename
+func StreamFile(c *fiber.Ctx, path string, opts ...StreamOptions) error {
+	file, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fiber.ErrNotFound
+		}
+		return err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	var opt StreamOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt.Size = info.Size()
+	if opt.ModTime.IsZero() {
+		opt.ModTime = info.ModTime()
+	}
+
+	return Stream(c, file, "", filepath.Base(path), opt)
+}
+
+// streamReader tracks progress of a streamed body and reports completion exactly once
+type streamReader struct {
+	reader     io.Reader
+	closer     io.Closer
+	total      int64
+	written    int64
+	finished   bool
+	once       sync.Once
+	onProgress func(written int64, total int64)
+	onDone     func(written int64, err error)
+}
+
+func (s *streamReader) Read(p []byte) (int, error) {
+	n, err := s.reader.Read(p)
+	if n > 0 {
+		s.written += int64(n)
+		if s.onProgress != nil {
+			s.onProgress(s.written, s.total)
+		}
+	}
+	if err == io.EOF {
+		s.finished = true
+	}
+	return n, err
+}
+
+func (s *streamReader) Close() error {
+	var err error
+	s.once.Do(func() {
+		if s.closer != nil {
+			err = s.closer.Close()
+		} else {
+			err = closeReader(s.reader)
+		}
+
+		if s.onDone != nil {
+			var result error
+			if !s.finished && (s.total < 0 || s.written < s.total) {
+				result = ErrClientDisconnected
+			}
+			s.onDone(s.written, result)
+		}
+	})
+	return err
+}
+
+func closeReader(reader io.Reader) error {
+	if closer, ok := reader.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// parseByteRange parses a single "bytes=start-end" range against the content size.
+// Multiple ranges are not supported and are reported as unsatisfiable.
+func parseByteRange(header string, size int64) (int64, int64, bool) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") || size == 0 {
+		return 0, 0, false
+	}
+
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, false
+	}
+
+	if first == "" {
+		// suffix range: last N bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, false
+		}
+		if n > size {
+			n = size
+		}
+		return size - n, size - 1, true
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 || start >= size {
+		return 0, 0, false
+	}
+
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, false
+		}
+		if end >= size {
+			end = size - 1
+		}
+	}
+
+	return start, end, true
+}
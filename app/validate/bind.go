@@ -0,0 +1,54 @@
+package validate
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/out"
+)
+
+// Bind fills dst with the path parameters (params tags), the query string (query tags) and the
+// body of the request (json tags, form tags for the forms), then checks it with Struct. The
+// error can be returned directly from a handler: a 400 *out.Response when the request cannot be
+// parsed, the out.FieldErrors answered as a 422 when dst is invalid.
+//
+//	var req UpdateOrder
+//	if err := validate.Bind(c, &req); err != nil {
+//		return err
+//	}
+func Bind(c *fiber.Ctx, dst any) error {
+	if err := c.ParamsParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid path parameters", err)
+	}
+	if err := c.QueryParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid query parameters", err)
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(dst); err != nil {
+			return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid request body", err)
+		}
+	}
+	return Struct(dst)
+}
+
+// Body fills dst with the body of the request and checks it, see Bind
+func Body(c *fiber.Ctx, dst any) error {
+	if err := c.BodyParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid request body", err)
+	}
+	return Struct(dst)
+}
+
+// Query fills dst with the query string of the request and checks it, see Bind
+func Query(c *fiber.Ctx, dst any) error {
+	if err := c.QueryParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid query parameters", err)
+	}
+	return Struct(dst)
+}
+
+// Params fills dst with the path parameters of the request and checks it, see Bind
+func Params(c *fiber.Ctx, dst any) error {
+	if err := c.ParamsParser(dst); err != nil {
+		return out.ErrorDetail(fiber.StatusBadRequest, 1, "BAD_REQUEST", "Invalid path parameters", err)
+	}
+	return Struct(dst)
+}
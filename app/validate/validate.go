@@ -0,0 +1,338 @@
+// Package validate checks the structs received by the handlers against their validate tags and
+// reports the invalid fields as out.FieldErrors, answered as a 422 out.ValidationError.
+//
+// The tags follow the syntax of go-playground/validator, rules separated by commas and their
+// parameter after '=':
+//
+//	type CreateOrder struct {
+//		Email    string      `json:"email" validate:"required,email"`
+//		Quantity int         `json:"quantity" validate:"gte=1,lte=100"`
+//		Status   string      `json:"status" validate:"omitempty,oneof=draft placed"`
+//		Items    []OrderItem `json:"items" validate:"required,min=1,dive"`
+//	}
+//
+// Another engine (ex: go-playground/validator itself) replaces the tags with SetEngine.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+)
+
+// Tag is the struct tag listing the rules of a field
+var Tag = "validate"
+
+// Engine checks v and returns its invalid fields, none when it is valid
+type Engine interface {
+	Struct(v any) []out.FieldError
+}
+
+// Rule reports whether the value of a field satisfies the rule given param, the text after '='
+type Rule func(field reflect.Value, param string) bool
+
+type rule struct {
+	check   Rule
+	message string // {field}, {param} and {unit} are replaced by the field, the parameter and the unit of its size
+}
+
+var (
+	mu     sync.RWMutex
+	engine Engine = tagEngine{}
+	rules         = map[string]rule{}
+)
+
+// SetEngine replaces the engine checking the validate tags, nil restores it
+func SetEngine(e Engine) {
+	mu.Lock()
+	defer mu.Unlock()
+	if e == nil {
+		e = tagEngine{}
+	}
+	engine = e
+}
+
+// RegisterRule adds the rule name to the validate tags. In message, the error of the rule,
+// {field} and {param} are replaced by the field and the parameter (ex: "{field} must be a
+// product code").
+func RegisterRule(name string, check Rule, message string) {
+	mu.Lock()
+	defer mu.Unlock()
+	rules[name] = rule{check: check, message: message}
+}
+
+// Struct checks v with the engine, then with its Validate method when it implements
+// helper.FieldValidator. The error is the out.FieldErrors of the invalid fields, nil when v is
+// valid, sent as a 422 when a handler returns it.
+func Struct(v any) error {
+	mu.RLock()
+	e := engine
+	mu.RUnlock()
+
+	errs := e.Struct(v)
+	if validator, ok := v.(helper.FieldValidator); ok {
+		errs = append(errs, validator.Validate()...)
+	}
+	if len(errs) > 0 {
+		return out.FieldErrors(errs)
+	}
+	return nil
+}
+
+// tagEngine checks the validate tags of the structs
+type tagEngine struct{}
+
+func (tagEngine) Struct(v any) []out.FieldError {
+	var errs []out.FieldError
+	checkStruct(reflect.ValueOf(v), nil, &errs)
+	return errs
+}
+
+func checkStruct(v reflect.Value, path []any, errs *[]out.FieldError) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct || v.Type() == reflect.TypeOf(time.Time{}) {
+		return
+	}
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fieldPath := append(slices.Clip(path), fieldName(field))
+		if field.Anonymous && field.Tag.Get("json") == "" {
+			fieldPath = path
+		}
+		checkField(v.Field(i), field.Tag.Get(Tag), fieldPath, errs)
+	}
+}
+
+// checkField checks the rules of tag on v, the ones after dive on each of its elements
+func checkField(v reflect.Value, tag string, path []any, errs *[]out.FieldError) {
+	if tag == "-" {
+		return
+	}
+
+	names := splitRules(tag)
+	dive := slices.Index(names, "dive")
+	own, elements := names, []string(nil)
+	if dive >= 0 {
+		own, elements = names[:dive], names[dive+1:]
+	}
+
+	for _, name := range own {
+		if name == "omitempty" {
+			if isEmpty(v) {
+				return
+			}
+			continue
+		}
+
+		ruleName, param, _ := strings.Cut(name, "=")
+		if ok, message := apply(v, ruleName, param, out.FieldPath(path...)); !ok {
+			*errs = append(*errs, out.FieldError{Field: out.FieldPath(path...), Rule: ruleName, Message: message, Param: param})
+			// the other rules of an empty required field only repeat it
+			if ruleName == "required" {
+				return
+			}
+		}
+	}
+
+	value := indirect(v)
+	switch {
+	case dive >= 0 && (value.Kind() == reflect.Slice || value.Kind() == reflect.Array):
+		for i := 0; i < value.Len(); i++ {
+			checkField(value.Index(i), strings.Join(elements, ","), append(slices.Clip(path), i), errs)
+		}
+	case dive >= 0 && value.Kind() == reflect.Map:
+		for _, key := range value.MapKeys() {
+			checkField(value.MapIndex(key), strings.Join(elements, ","), append(slices.Clip(path), fmt.Sprint(key.Interface())), errs)
+		}
+	default:
+		checkStruct(v, path, errs)
+	}
+}
+
+// splitRules splits a tag into its rules
+func splitRules(tag string) []string {
+	var names []string
+	for _, name := range strings.Split(tag, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// apply checks the rule name on v, returning the message of its failure
+func apply(v reflect.Value, name string, param string, field string) (bool, string) {
+	r, ok := builtinRules[name]
+	if !ok {
+		mu.RLock()
+		r, ok = rules[name]
+		mu.RUnlock()
+	}
+	if !ok {
+		return false, fmt.Sprintf("%s has the unknown rule %s", field, name)
+	}
+	if r.check(indirect(v), param) {
+		return true, ""
+	}
+	return false, strings.NewReplacer("{field}", field, "{param}", param, "{unit}", unit(v)).Replace(r.message)
+}
+
+// unit is the word after the parameter of the size rules: characters, items or nothing
+func unit(v reflect.Value) string {
+	switch indirect(v).Kind() {
+	case reflect.String:
+		return " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return " items"
+	}
+	return ""
+}
+
+// fieldName returns the name of the field in the errors: its json name, else the name it is
+// bound from in the query, the path or the form
+func fieldName(field reflect.StructField) string {
+	for _, key := range []string{"json", "query", "params", "form"} {
+		if name, _, _ := strings.Cut(field.Tag.Get(key), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+func indirect(v reflect.Value) reflect.Value {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return v
+		}
+		v = v.Elem()
+	}
+	return v
+}
+
+func isEmpty(v reflect.Value) bool {
+	if !v.IsValid() {
+		return true
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return v.IsNil()
+	case reflect.Slice, reflect.Map:
+		return v.IsNil() || v.Len() == 0
+	}
+	return v.IsZero()
+}
+
+// size returns the length of a string, slice or map and the value of a number, false for the
+// other kinds
+func size(v reflect.Value) (float64, bool) {
+	v = indirect(v)
+	switch v.Kind() {
+	case reflect.String:
+		return float64(utf8.RuneCountInString(v.String())), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return float64(v.Len()), true
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+// compare checks the size of v against param with cmp
+func compare(cmp func(a, b float64) bool) Rule {
+	return func(v reflect.Value, param string) bool {
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return false
+		}
+		n, ok := size(v)
+		if !ok {
+			return isEmpty(v)
+		}
+		return cmp(n, limit)
+	}
+}
+
+// matches checks the string of v with check, an empty string is left to required
+func matches(check func(s string) bool) Rule {
+	return func(v reflect.Value, _ string) bool {
+		v = indirect(v)
+		if v.Kind() != reflect.String {
+			return false
+		}
+		return v.String() == "" || check(v.String())
+	}
+}
+
+var (
+	alphaPattern    = regexp.MustCompile(`^[a-zA-Z]+$`)
+	alphanumPattern = regexp.MustCompile(`^[a-zA-Z0-9]+$`)
+	numericPattern  = regexp.MustCompile(`^[-+]?[0-9]+(\.[0-9]+)?$`)
+	uuidPattern     = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+)
+
+// builtinRules are the rules of the tags
+var builtinRules = map[string]rule{
+	"required": {func(v reflect.Value, _ string) bool { return !isEmpty(v) }, "{field} is required"},
+	"min":      {compare(func(a, b float64) bool { return a >= b }), "{field} must be at least {param}{unit}"},
+	"max":      {compare(func(a, b float64) bool { return a <= b }), "{field} must be at most {param}{unit}"},
+	"len":      {compare(func(a, b float64) bool { return a == b }), "{field} must be exactly {param}{unit}"},
+	"gte":      {compare(func(a, b float64) bool { return a >= b }), "{field} must be at least {param}{unit}"},
+	"lte":      {compare(func(a, b float64) bool { return a <= b }), "{field} must be at most {param}{unit}"},
+	"gt":       {compare(func(a, b float64) bool { return a > b }), "{field} must be more than {param}{unit}"},
+	"lt":       {compare(func(a, b float64) bool { return a < b }), "{field} must be less than {param}{unit}"},
+	"eq": {func(v reflect.Value, param string) bool {
+		return fmt.Sprint(indirect(v).Interface()) == param
+	}, "{field} must be {param}"},
+	"ne": {func(v reflect.Value, param string) bool {
+		return fmt.Sprint(indirect(v).Interface()) != param
+	}, "{field} must not be {param}"},
+	"oneof": {func(v reflect.Value, param string) bool {
+		if isEmpty(v) {
+			return true
+		}
+		return slices.Contains(strings.Fields(param), fmt.Sprint(indirect(v).Interface()))
+	}, "{field} must be one of {param}"},
+	"email": {matches(func(s string) bool {
+		address, err := mail.ParseAddress(s)
+		return err == nil && address.Address == s
+	}), "{field} must be a valid email address"},
+	"url": {matches(func(s string) bool {
+		u, err := url.ParseRequestURI(s)
+		return err == nil && u.Scheme != "" && u.Host != ""
+	}), "{field} must be a valid URL"},
+	"uuid":     {matches(uuidPattern.MatchString), "{field} must be a valid UUID"},
+	"alpha":    {matches(alphaPattern.MatchString), "{field} must contain only letters"},
+	"alphanum": {matches(alphanumPattern.MatchString), "{field} must contain only letters and digits"},
+	"numeric":  {matches(numericPattern.MatchString), "{field} must be a number"},
+	"datetime": {func(v reflect.Value, param string) bool {
+		return matches(func(s string) bool {
+			_, err := time.Parse(param, s)
+			return err == nil
+		})(v, param)
+	}, "{field} must be a date in the format {param}"},
+}
@@ -0,0 +1,286 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"time"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+// data is given to the templates
+type data struct {
+	Project    string // module path of the project
+	ImportPath string // import path of the module or the library
+	Package    string
+
+	// modules
+	Module    string // name of the module
+	ConfigKey string // key of the module under module. in the configuration
+	EnvPrefix string // prefix of the environment variables of the module
+	Entity    string
+	EntityVar string
+	Resource  string // path of the entity in the routes of the module
+	Table     string
+
+	// handlers
+	Handler     string
+	HandlerName string
+	HandlerVar  string
+	HandlerPath string
+
+	// libraries
+	Type string
+	Key  string
+
+	// migrations
+	Migration string
+	Version   string
+}
+
+// file is a file to generate
+type file struct {
+	path     string // relative to the project
+	template string
+	shared   bool // kept when it exists, it is shared by the files of a package
+}
+
+type generator struct {
+	project *project
+	opts    options
+	out     io.Writer
+}
+
+// newModule writes modules/<name> and adds it to APP_PACKAGES
+func (g *generator) newModule(name string) error {
+	if err := checkName("module", name); err != nil {
+		return err
+	}
+
+	entityName := g.opts.entity
+	if entityName == "" {
+		entityName = singular(snake(name))
+	}
+	if err := checkName("entity", entityName); err != nil {
+		return err
+	}
+
+	d := g.moduleData(name)
+	g.setEntity(&d, entityName)
+
+	dir := filepath.Join("modules", name)
+	if _, err := os.Stat(g.project.join(dir)); err == nil && !g.opts.force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", dir)
+	}
+
+	entityFile := snake(entityName) + ".go"
+	files := []file{
+		{path: filepath.Join(dir, "module.go"), template: "module.go.tmpl"},
+		{path: filepath.Join(dir, "config", "config.go"), template: "config.go.tmpl"},
+		{path: filepath.Join(dir, "entity", entityFile), template: "entity.go.tmpl"},
+		{path: filepath.Join(dir, "repository", entityFile), template: "repository.go.tmpl"},
+		{path: filepath.Join(dir, "repository", "filter.go"), template: "filter.go.tmpl", shared: true},
+		{path: filepath.Join(dir, "service", entityFile), template: "service.go.tmpl"},
+		{path: filepath.Join(dir, "handler", entityFile), template: "handler.go.tmpl"},
+	}
+	if err := g.writeFiles(files, d); err != nil {
+		return err
+	}
+
+	if err := g.registerPackage(d.ImportPath, d.Package); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(g.out, "module %s: routes under %s/%s, configuration under module.%s\n", name, "<server.path_prefix>", name, d.ConfigKey)
+	return nil
+}
+
+// newHandler writes modules/<module>/handler/<name>.go
+func (g *generator) newHandler(module, name string) error {
+	if err := g.checkModule(module); err != nil {
+		return err
+	}
+	if err := checkName("handler", name); err != nil {
+		return err
+	}
+
+	d := g.moduleData(module)
+	d.HandlerName = snake(name)
+	d.Handler = exported(name) + "Handler"
+	d.HandlerVar = unexported(name) + "Handler"
+	d.HandlerPath = strings.ReplaceAll(snake(name), "_", "-")
+
+	path := filepath.Join("modules", module, "handler", snake(name)+".go")
+	if err := g.writeFiles([]file{{path: path, template: "handler_basic.go.tmpl"}}, d); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(g.out, "register the routes of %s in registerRoutes of modules/%s/module.go\n", d.Handler, module)
+	return nil
+}
+
+// newRepository writes the repository and the entity of entityName in modules/<module>
+func (g *generator) newRepository(module, entityName string) error {
+	if err := g.checkModule(module); err != nil {
+		return err
+	}
+	if err := checkName("entity", entityName); err != nil {
+		return err
+	}
+
+	d := g.moduleData(module)
+	g.setEntity(&d, entityName)
+
+	dir := filepath.Join("modules", module)
+	entityFile := snake(entityName) + ".go"
+	files := []file{
+		{path: filepath.Join(dir, "entity", entityFile), template: "entity.go.tmpl", shared: true},
+		{path: filepath.Join(dir, "repository", entityFile), template: "repository.go.tmpl"},
+		{path: filepath.Join(dir, "repository", "filter.go"), template: "filter.go.tmpl", shared: true},
+	}
+	if err := g.writeFiles(files, d); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(g.out, "create repository.New%sRepository in Init of modules/%s/module.go\n", d.Entity, module)
+	return nil
+}
+
+// newLibrary writes libraries/<name> and adds its loader to APP_LIBRARIES
+func (g *generator) newLibrary(name string) error {
+	if err := checkName("library", name); err != nil {
+		return err
+	}
+
+	key := g.opts.key
+	if key == "" {
+		key = snake(name)
+	}
+
+	dir := filepath.Join("libraries", name)
+	d := data{
+		Project:    g.project.path,
+		ImportPath: g.project.importPath(dir),
+		Package:    packageName(name),
+		Type:       exported(name),
+		Key:        key,
+	}
+
+	if _, err := os.Stat(g.project.join(dir)); err == nil && !g.opts.force {
+		return fmt.Errorf("%s already exists, use -force to overwrite it", dir)
+	}
+
+	files := []file{
+		{path: filepath.Join(dir, snake(name)+".go"), template: "library.go.tmpl"},
+		{path: filepath.Join(dir, "loader.go"), template: "loader.go.tmpl"},
+	}
+	if err := g.writeFiles(files, d); err != nil {
+		return err
+	}
+
+	return g.registerLibrary(key, d.ImportPath, d.Package, d.Type+"Loader")
+}
+
+// newMigration writes the up and down files of a migration, versioned by the current time
+func (g *generator) newMigration(name string) error {
+	if err := checkName("migration", name); err != nil {
+		return err
+	}
+
+	d := data{
+		Project:   g.project.path,
+		Migration: snake(name),
+		Version:   time.Now().UTC().Format("20060102150405"),
+	}
+
+	prefix := filepath.Join(g.opts.dir, d.Version+"_"+d.Migration)
+	files := []file{
+		{path: prefix + ".up.sql", template: "migration.up.sql.tmpl"},
+		{path: prefix + ".down.sql", template: "migration.down.sql.tmpl"},
+	}
+	return g.writeFiles(files, d)
+}
+
+func (g *generator) moduleData(name string) data {
+	return data{
+		Project:    g.project.path,
+		ImportPath: g.project.importPath("modules", name),
+		Package:    packageName(name),
+		Module:     name,
+		ConfigKey:  strings.ToLower(name),
+		EnvPrefix:  "MODULE_" + strings.ToUpper(snake(name)),
+	}
+}
+
+func (g *generator) setEntity(d *data, name string) {
+	d.Entity = exported(name)
+	d.EntityVar = unexported(name)
+	d.Table = plural(snake(name))
+	d.Resource = strings.ReplaceAll(d.Table, "_", "-")
+}
+
+// checkModule fails when modules/<name> was not generated
+func (g *generator) checkModule(name string) error {
+	if _, err := os.Stat(g.project.join("modules", name, "module.go")); err != nil {
+		return fmt.Errorf("module %s not found in %s", name, g.project.join("modules"))
+	}
+	return nil
+}
+
+// writeFiles renders all the files before writing them, so nothing is written on error
+func (g *generator) writeFiles(files []file, d data) error {
+	contents := make([][]byte, len(files))
+	for i, f := range files {
+		if _, err := os.Stat(g.project.join(f.path)); err == nil && !g.opts.force {
+			if f.shared {
+				continue
+			}
+			return fmt.Errorf("%s already exists, use -force to overwrite it", f.path)
+		}
+
+		content, err := render(f.template, d)
+		if err != nil {
+			return fmt.Errorf("%s: %v", f.path, err)
+		}
+		contents[i] = content
+	}
+
+	for i, f := range files {
+		if contents[i] == nil {
+			continue
+		}
+
+		path := g.project.join(f.path)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, contents[i], 0o644); err != nil {
+			return err
+		}
+		fmt.Fprintln(g.out, "created", f.path)
+	}
+	return nil
+}
+
+// render executes a template, the Go files are formatted
+func render(name string, d data) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, name, d); err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(name, ".go.tmpl") {
+		return format.Source(buf.Bytes())
+	}
+	return buf.Bytes(), nil
+}
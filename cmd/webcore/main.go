@@ -0,0 +1,126 @@
+// Command webcore generates the skeletons of an application built on WebCore, and registers
+// them in deps/packages.go and deps/libraries.go:
+//
+//	go install github.com/webcore-go/webcore/cmd/webcore@latest
+//
+//	webcore new module orders            # modules/orders, added to APP_PACKAGES
+//	webcore new handler orders report    # modules/orders/handler/report.go
+//	webcore new repository orders item   # modules/orders/repository/item.go and its entity
+//	webcore new library sms              # libraries/sms, added to APP_LIBRARIES as "sms"
+//	webcore new migration add_orders     # migrations/<timestamp>_add_orders.up.sql and .down.sql
+//
+// The project is the Go module holding the working directory (or -root), existing files are
+// never overwritten unless -force is given.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage: webcore new <kind> [flags] <args>
+
+Kinds:
+  module <name>                 module with config, entity, repository, service and handler
+  handler <module> <name>       HTTP handler in an existing module
+  repository <module> <entity>  repository and entity in an existing module
+  library <name>                library and its loader
+  migration <name>              up and down SQL migration
+
+Flags:
+`
+
+// options are the flags of the generators
+type options struct {
+	root   string
+	force  bool
+	entity string
+	key    string
+	dir    string
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "webcore:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout io.Writer) error {
+	var opts options
+	fs := flag.NewFlagSet("webcore", flag.ContinueOnError)
+	fs.StringVar(&opts.root, "root", ".", "directory inside the project")
+	fs.BoolVar(&opts.force, "force", false, "overwrite the existing files")
+	fs.StringVar(&opts.entity, "entity", "", "entity of a new module, the singular of the module name by default")
+	fs.StringVar(&opts.key, "key", "", "key of a new library in APP_LIBRARIES, its name by default")
+	fs.StringVar(&opts.dir, "dir", "migrations", "directory of the migrations, relative to the project")
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), usage)
+		fs.PrintDefaults()
+	}
+
+	positional, err := parseInterspersed(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 2 || positional[0] != "new" {
+		fs.Usage()
+		return fmt.Errorf("expected: webcore new <kind> <args>")
+	}
+
+	project, err := findProject(opts.root)
+	if err != nil {
+		return err
+	}
+	g := &generator{project: project, opts: opts, out: stdout}
+
+	kind, rest := positional[1], positional[2:]
+	switch kind {
+	case "module":
+		if len(rest) != 1 {
+			return fmt.Errorf("expected: webcore new module <name>")
+		}
+		return g.newModule(rest[0])
+	case "handler":
+		if len(rest) != 2 {
+			return fmt.Errorf("expected: webcore new handler <module> <name>")
+		}
+		return g.newHandler(rest[0], rest[1])
+	case "repository":
+		if len(rest) != 2 {
+			return fmt.Errorf("expected: webcore new repository <module> <entity>")
+		}
+		return g.newRepository(rest[0], rest[1])
+	case "library":
+		if len(rest) != 1 {
+			return fmt.Errorf("expected: webcore new library <name>")
+		}
+		return g.newLibrary(rest[0])
+	case "migration":
+		if len(rest) != 1 {
+			return fmt.Errorf("expected: webcore new migration <name>")
+		}
+		return g.newMigration(rest[0])
+	default:
+		fs.Usage()
+		return fmt.Errorf("unknown kind %q", kind)
+	}
+}
+
+// parseInterspersed parses the flags placed before, between or after the positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
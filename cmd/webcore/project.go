@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// project is the Go module of the application
+type project struct {
+	dir  string // directory of go.mod
+	path string // module path
+}
+
+// findProject looks for go.mod in dir and its parents
+func findProject(dir string) (*project, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		path, err := modulePath(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			return &project{dir: dir, path: path}, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, fmt.Errorf("go.mod not found, run the command inside the project or set -root")
+		}
+		dir = parent
+	}
+}
+
+func modulePath(gomod string) (string, error) {
+	f, err := os.Open(gomod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if rest, ok := strings.CutPrefix(line, "module"); ok && rest != "" && (rest[0] == ' ' || rest[0] == '\t') {
+			path := strings.TrimSpace(rest)
+			if unquoted, err := strconv.Unquote(path); err == nil {
+				path = unquoted
+			}
+			return path, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", fmt.Errorf("%s has no module directive", gomod)
+}
+
+// join returns the path of elem in the project
+func (p *project) join(elem ...string) string {
+	return filepath.Join(append([]string{p.dir}, elem...)...)
+}
+
+// importPath returns the import path of the package in dir, relative to the project
+func (p *project) importPath(dir ...string) string {
+	return p.path + "/" + filepath.ToSlash(filepath.Join(dir...))
+}
+
+var validName = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]*$`)
+
+// checkName accepts the names made of letters, digits, - and _, starting with a letter
+func checkName(kind, name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid %s name %q: use letters, digits, - and _, starting with a letter", kind, name)
+	}
+	return nil
+}
+
+// words splits a name on -, _ and the case changes (ex: "order-item", "OrderItem")
+func words(name string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if r == '-' || r == '_' {
+			if len(current) > 0 {
+				result = append(result, string(current))
+				current = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+			result = append(result, string(current))
+			current = nil
+		}
+		current = append(current, unicode.ToLower(r))
+	}
+	if len(current) > 0 {
+		result = append(result, string(current))
+	}
+	return result
+}
+
+// packageName is the name of a Go package (ex: "order-item" -> "orderitem")
+func packageName(name string) string {
+	return strings.Join(words(name), "")
+}
+
+// exported is the exported Go identifier of a name (ex: "order-item" -> "OrderItem")
+func exported(name string) string {
+	var sb strings.Builder
+	for _, word := range words(name) {
+		switch word {
+		case "id", "url", "api", "http", "json", "sql", "sms":
+			sb.WriteString(strings.ToUpper(word))
+		default:
+			sb.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return sb.String()
+}
+
+// unexported is the unexported Go identifier of a name (ex: "order-item" -> "orderItem")
+func unexported(name string) string {
+	parts := words(name)
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[0] + exported(strings.Join(parts[1:], "_"))
+}
+
+// snake is the name of tables, files and configuration keys (ex: "OrderItem" -> "order_item")
+func snake(name string) string {
+	return strings.Join(words(name), "_")
+}
+
+// singular removes the plural of the last word (ex: "orders" -> "order", "categories" -> "category")
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies") && len(name) > 4:
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "sses"), strings.HasSuffix(name, "xes"), strings.HasSuffix(name, "ches"), strings.HasSuffix(name, "shes"):
+		return strings.TrimSuffix(name, "es")
+	case strings.HasSuffix(name, "s") && !strings.HasSuffix(name, "ss") && len(name) > 3:
+		return strings.TrimSuffix(name, "s")
+	}
+	return name
+}
+
+// plural adds the plural to the last word (ex: "order" -> "orders", "category" -> "categories")
+func plural(name string) string {
+	switch {
+	case strings.HasSuffix(name, "s"), strings.HasSuffix(name, "x"), strings.HasSuffix(name, "ch"), strings.HasSuffix(name, "sh"):
+		return name + "es"
+	case strings.HasSuffix(name, "y") && len(name) > 1 && !strings.ContainsRune("aeiou", rune(name[len(name)-2])):
+		return strings.TrimSuffix(name, "y") + "ies"
+	}
+	return name + "s"
+}
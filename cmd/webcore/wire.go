@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// registerPackage adds <name>.NewModule() to APP_PACKAGES in deps/packages.go
+func (g *generator) registerPackage(importPath, name string) error {
+	return g.register("packages.go", "APP_PACKAGES", importPath, name, func(alias string) string {
+		return alias + ".NewModule()"
+	})
+}
+
+// registerLibrary adds "<key>": &<name>.<loader>{} to APP_LIBRARIES in deps/libraries.go
+func (g *generator) registerLibrary(key, importPath, name, loader string) error {
+	return g.register("libraries.go", "APP_LIBRARIES", importPath, name, func(alias string) string {
+		return strconv.Quote(key) + ": &" + alias + "." + loader + "{}"
+	})
+}
+
+// register imports importPath in the file of deps and appends the element given by entry to
+// the composite literal of the variable varName, the file is created when it does not exist
+func (g *generator) register(fileName, varName, importPath, name string, entry func(alias string) string) error {
+	rel := filepath.Join("deps", fileName)
+	path := g.project.join(rel)
+
+	src, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		if src, err = render(fileName+".tmpl", data{}); err != nil {
+			return err
+		}
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return err
+		}
+	} else if err != nil {
+		return err
+	}
+
+	updated, changed, err := addEntry(src, varName, importPath, name, entry)
+	if err != nil {
+		return fmt.Errorf("%s: %v", rel, err)
+	}
+	if !changed {
+		fmt.Fprintf(g.out, "%s already registered in %s\n", importPath, rel)
+		return nil
+	}
+
+	if err := os.WriteFile(path, updated, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(g.out, "registered %s in %s\n", importPath, rel)
+	return nil
+}
+
+// addEntry edits src, it reports false when importPath is already used by varName
+func addEntry(src []byte, varName, importPath, name string, entry func(alias string) string) ([]byte, bool, error) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, false, err
+	}
+	offset := func(pos token.Pos) int {
+		return fset.Position(pos).Offset
+	}
+
+	lit := findCompositeLit(f, varName)
+	if lit == nil {
+		return nil, false, fmt.Errorf("var %s = ...{} not found", varName)
+	}
+
+	// the package may be imported already, under its name or an alias
+	alias, imported := "", false
+	used := map[string]bool{}
+	for _, spec := range f.Imports {
+		specPath, _ := strconv.Unquote(spec.Path.Value)
+		specName := specPath[strings.LastIndex(specPath, "/")+1:]
+		if spec.Name != nil {
+			specName = spec.Name.Name
+		}
+		if specPath == importPath {
+			alias, imported = specName, true
+		}
+		used[specName] = true
+	}
+	if !imported {
+		alias = name
+		for i := 2; used[alias]; i++ {
+			alias = name + strconv.Itoa(i)
+		}
+	}
+
+	if imported {
+		for _, elt := range lit.Elts {
+			found := false
+			ast.Inspect(elt, func(n ast.Node) bool {
+				if sel, ok := n.(*ast.SelectorExpr); ok {
+					if ident, ok := sel.X.(*ast.Ident); ok && ident.Name == alias {
+						found = true
+					}
+				}
+				return !found
+			})
+			if found {
+				return src, false, nil
+			}
+		}
+	}
+
+	// the edits are applied from the end of the file so the offsets stay valid
+	replace := func(start, end int, text string) {
+		src = append(src[:start:start], append([]byte(text), src[end:]...)...)
+	}
+
+	// after the line of the last element, or after the opening brace
+	at := offset(lit.Lbrace) + 1
+	if n := len(lit.Elts); n > 0 {
+		at = offset(lit.Elts[n-1].End())
+	}
+	rbrace := offset(lit.Rbrace)
+	if i := strings.IndexByte(string(src[at:]), '\n'); i >= 0 && at+i < rbrace {
+		replace(at+i+1, at+i+1, "\t"+entry(alias)+",\n")
+	} else if strings.HasPrefix(strings.TrimSpace(string(src[at:rbrace])), ",") || len(lit.Elts) == 0 {
+		// literal on a single line
+		replace(rbrace, rbrace, entry(alias)+",")
+	} else {
+		replace(rbrace, rbrace, ", "+entry(alias))
+	}
+
+	if !imported {
+		spec := strconv.Quote(importPath)
+		if alias != name || name != importPath[strings.LastIndex(importPath, "/")+1:] {
+			spec = alias + " " + spec
+		}
+
+		switch decl := firstImportDecl(f); {
+		case decl == nil:
+			// after the package clause
+			at := offset(f.Name.End())
+			replace(at, at, "\n\nimport "+spec+"\n")
+		case decl.Lparen.IsValid():
+			at := offset(decl.Rparen)
+			replace(at, at, "\t"+spec+"\n")
+		default:
+			old := string(src[offset(decl.Specs[0].Pos()):offset(decl.End())])
+			replace(offset(decl.Pos()), offset(decl.End()), "import (\n\t"+old+"\n\t"+spec+"\n)")
+		}
+	}
+
+	formatted, err := format.Source(src)
+	if err != nil {
+		return nil, false, err
+	}
+	return formatted, true, nil
+}
+
+func findCompositeLit(f *ast.File, varName string) *ast.CompositeLit {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.VAR {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			vspec := spec.(*ast.ValueSpec)
+			for i, ident := range vspec.Names {
+				if ident.Name != varName || i >= len(vspec.Values) {
+					continue
+				}
+				if lit, ok := vspec.Values[i].(*ast.CompositeLit); ok {
+					return lit
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func firstImportDecl(f *ast.File) *ast.GenDecl {
+	for _, decl := range f.Decls {
+		if gen, ok := decl.(*ast.GenDecl); ok && gen.Tok == token.IMPORT {
+			return gen
+		}
+	}
+	return nil
+}
@@ -0,0 +1,159 @@
+// Package awsv4 implements AWS Signature Version 4, shared by the drivers talking
+// to AWS compatible REST APIs (S3, SES, ...)
+package awsv4
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	UnsignedPayload = "UNSIGNED-PAYLOAD"
+	EmptyPayload    = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" // sha256("")
+	AmzDateFormat   = "20060102T150405Z"
+)
+
+// Signer signs requests for one service and region
+type Signer struct {
+	AccessKey string
+	SecretKey string
+	Region    string
+	Service   string // ex: "s3", "ses"
+}
+
+// PayloadHash returns the hex encoded SHA256 of a request body
+func PayloadHash(body []byte) string {
+	hash := sha256.Sum256(body)
+	return hex.EncodeToString(hash[:])
+}
+
+// Sign adds the Authorization header to req
+func (s Signer) Sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.UTC().Format(AmzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	names := []string{"host"}
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			names = append(names, lower)
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		CanonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := s.scope(now)
+	signature := s.signature(now, stringToSign(amzDate, scope, canonicalRequest))
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, s.AccessKey, scope, signedHeaders, signature))
+}
+
+// Presign returns target with a query string signature valid for expires
+func (s Signer) Presign(method string, target *url.URL, expires time.Duration, now time.Time) string {
+	amzDate := now.UTC().Format(AmzDateFormat)
+	scope := s.scope(now)
+
+	query := target.Query()
+	query.Set("X-Amz-Algorithm", signAlgorithm)
+	query.Set("X-Amz-Credential", s.AccessKey+"/"+scope)
+	query.Set("X-Amz-Date", amzDate)
+	query.Set("X-Amz-Expires", fmt.Sprint(int64(expires.Seconds())))
+	query.Set("X-Amz-SignedHeaders", "host")
+
+	canonicalRequest := strings.Join([]string{
+		method,
+		target.EscapedPath(),
+		CanonicalQuery(query),
+		"host:" + target.Host + "\n",
+		"host",
+		UnsignedPayload,
+	}, "\n")
+
+	signature := s.signature(now, stringToSign(amzDate, scope, canonicalRequest))
+	return target.Scheme + "://" + target.Host + target.EscapedPath() + "?" + CanonicalQuery(query) + "&X-Amz-Signature=" + signature
+}
+
+func (s Signer) scope(now time.Time) string {
+	return now.UTC().Format("20060102") + "/" + s.Region + "/" + s.Service + "/aws4_request"
+}
+
+func (s Signer) signature(now time.Time, toSign string) string {
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), now.UTC().Format("20060102"))
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, s.Service)
+	key = hmacSHA256(key, "aws4_request")
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func stringToSign(amzDate string, scope string, canonicalRequest string) string {
+	hash := sha256.Sum256([]byte(canonicalRequest))
+	return signAlgorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(hash[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// CanonicalQuery encodes query sorted by key as required by SigV4
+func CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	parts := []string{}
+	for _, key := range keys {
+		values := append([]string{}, query[key]...)
+		sort.Strings(values)
+		for _, value := range values {
+			parts = append(parts, URIEncode(key, true)+"="+URIEncode(value, true))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// URIEncode escapes everything except the unreserved characters, as required by SigV4
+func URIEncode(value string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9',
+			c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -14,12 +14,27 @@ func (c *Config) SetEnvBindings() map[string]string {
 		"app.logging.level":                   "APP_LOGGING_LEVEL",
 		"app.logging.format":                  "APP_LOGGING_FORMAT",
 		"app.logging.output":                  "APP_LOGGING_OUTPUT",
+		"app.logging.backend":                 "APP_LOGGING_BACKEND",
 		"app.logging.remote.driver":           "APP_LOGGING_REMOTE_DRIVER",
 		"app.logging.remote.uri":              "APP_LOGGING_REMOTE_URI",
 		"app.logging.remote.sample_rate":      "APP_LOGGING_REMOTE_SAMPLE_RATE",
 		"app.logging.remote.structured":       "APP_LOGGING_REMOTE_STRUCTURED",
 		"app.logging.remote.default_tags":     "APP_LOGGING_REMOTE_DEFAULT_TAGS",
 		"app.logging.remote.default_contexts": "APP_LOGGING_REMOTE_DEFAULT_CONTEXTS",
+		"app.access_log.enabled":              "APP_ACCESS_LOG_ENABLED",
+		"app.access_log.sample_rate":          "APP_ACCESS_LOG_SAMPLE_RATE",
+		"app.access_log.keep_errors":          "APP_ACCESS_LOG_KEEP_ERRORS",
+		"app.access_log.body":                 "APP_ACCESS_LOG_BODY",
+		"app.access_log.max_body":             "APP_ACCESS_LOG_MAX_BODY",
+		"app.access_log.redact":               "APP_ACCESS_LOG_REDACT",
+		"app.metrics.enabled":                 "APP_METRICS_ENABLED",
+		"app.metrics.path":                    "APP_METRICS_PATH",
+		"app.metrics.buckets":                 "APP_METRICS_BUCKETS",
+		"app.tracing.enabled":                 "APP_TRACING_ENABLED",
+		"app.tracing.exporter":                "APP_TRACING_EXPORTER",
+		"app.tracing.endpoint":                "APP_TRACING_ENDPOINT",
+		"app.tracing.service_name":            "APP_TRACING_SERVICE_NAME",
+		"app.tracing.sample_rate":             "APP_TRACING_SAMPLE_RATE",
 		"app.security_headers":                "APP_SECURITY_HEADERS",
 		"app.additional_headers":              "APP_ADDITIONAL_HEADERS",
 		"app.cors.allow_origins":              "APP_CORS_ALLOW_ORIGINS",
@@ -30,15 +45,181 @@ func (c *Config) SetEnvBindings() map[string]string {
 		"app.cors.max_age":                    "APP_CORS_MAX_AGE",
 		"app.rate_limit.enabled":              "APP_RATE_LIMIT_ENABLED",
 		"app.rate_limit.max":                  "APP_RATE_LIMIT_MAX",
+		"app.geoip.enabled":                   "APP_GEOIP_ENABLED",
+		"app.geoip.provider":                  "APP_GEOIP_PROVIDER",
+		"app.geoip.database":                  "APP_GEOIP_DATABASE",
+		"app.geoip.asn_database":              "APP_GEOIP_ASN_DATABASE",
+		"app.geoip.language":                  "APP_GEOIP_LANGUAGE",
+		"app.compression.enabled":             "APP_COMPRESSION_ENABLED",
+		"app.compression.level":               "APP_COMPRESSION_LEVEL",
+		"app.etag.enabled":                    "APP_ETAG_ENABLED",
+		"app.etag.weak":                       "APP_ETAG_WEAK",
+		"app.openapi.spec":                    "APP_OPENAPI_SPEC",
+		"app.openapi.validate_request":        "APP_OPENAPI_VALIDATE_REQUEST",
+		"app.openapi.validate_response":       "APP_OPENAPI_VALIDATE_RESPONSE",
+		"app.openapi.serve":                   "APP_OPENAPI_SERVE",
+		"app.openapi.ui":                      "APP_OPENAPI_UI",
+		"app.scheduler.enabled":               "APP_SCHEDULER_ENABLED",
+		"app.scheduler.locker":                "APP_SCHEDULER_LOCKER",
+		"app.admin.enabled":                   "APP_ADMIN_ENABLED",
+		"app.admin.path":                      "APP_ADMIN_PATH",
+		"app.admin.roles":                     "APP_ADMIN_ROLES",
+		"app.queue.enabled":                   "APP_QUEUE_ENABLED",
+		"app.queue.store":                     "APP_QUEUE_STORE",
+		"app.queue.workers":                   "APP_QUEUE_WORKERS",
+		"app.queue.poll_interval":             "APP_QUEUE_POLL_INTERVAL",
+		"app.queue.lease":                     "APP_QUEUE_LEASE",
+		"app.queue.max_attempts":              "APP_QUEUE_MAX_ATTEMPTS",
+		"app.queue.backoff":                   "APP_QUEUE_BACKOFF",
+		"app.queue.max_backoff":               "APP_QUEUE_MAX_BACKOFF",
+		"app.graphql.enabled":                 "APP_GRAPHQL_ENABLED",
+		"app.graphql.path":                    "APP_GRAPHQL_PATH",
+		"app.graphql.max_depth":               "APP_GRAPHQL_MAX_DEPTH",
+		"app.graphql.max_complexity":          "APP_GRAPHQL_MAX_COMPLEXITY",
+		"app.hub.enabled":                     "APP_HUB_ENABLED",
+		"app.hub.path":                        "APP_HUB_PATH",
+		"app.hub.broker":                      "APP_HUB_BROKER",
+		"app.hub.topic":                       "APP_HUB_TOPIC",
+		"app.hub.presence_ttl":                "APP_HUB_PRESENCE_TTL",
+		"app.hub.ping_interval":               "APP_HUB_PING_INTERVAL",
+		"app.hub.read_limit":                  "APP_HUB_READ_LIMIT",
+		"app.hub.origins":                     "APP_HUB_ORIGINS",
+		"app.webhooks.enabled":                "APP_WEBHOOKS_ENABLED",
+		"app.webhooks.path":                   "APP_WEBHOOKS_PATH",
+		"app.webhooks.queue":                  "APP_WEBHOOKS_QUEUE",
+		"app.webhooks.dedup_ttl":              "APP_WEBHOOKS_DEDUP_TTL",
+		"app.webhooks.locker":                 "APP_WEBHOOKS_LOCKER",
+		"app.tasks.enabled":                   "APP_TASKS_ENABLED",
+		"app.tasks.path":                      "APP_TASKS_PATH",
+		"app.tasks.store":                     "APP_TASKS_STORE",
+		"app.tasks.ttl":                       "APP_TASKS_TTL",
+		"app.tasks.queue":                     "APP_TASKS_QUEUE",
+		"app.grpc.enabled":                    "APP_GRPC_ENABLED",
+		"app.grpc.port":                       "APP_GRPC_PORT",
+		"app.grpc.reflection":                 "APP_GRPC_REFLECTION",
+		"app.discovery.enabled":               "APP_DISCOVERY_ENABLED",
+		"app.discovery.backend":               "APP_DISCOVERY_BACKEND",
+		"app.discovery.server":                "APP_DISCOVERY_SERVER",
+		"app.discovery.token":                 "APP_DISCOVERY_TOKEN",
+		"app.discovery.name":                  "APP_DISCOVERY_NAME",
+		"app.discovery.id":                    "APP_DISCOVERY_ID",
+		"app.discovery.address":               "APP_DISCOVERY_ADDRESS",
+		"app.discovery.port":                  "APP_DISCOVERY_PORT",
+		"app.discovery.tags":                  "APP_DISCOVERY_TAGS",
+		"app.discovery.ttl":                   "APP_DISCOVERY_TTL",
+		"app.discovery.deregister_after":      "APP_DISCOVERY_DEREGISTER_AFTER",
+		"app.dev_tail.enabled":                "APP_DEV_TAIL_ENABLED",
+		"app.dev_tail.path":                   "APP_DEV_TAIL_PATH",
+		"app.dev_tail.buffer":                 "APP_DEV_TAIL_BUFFER",
+		"app.retention.enabled":               "APP_RETENTION_ENABLED",
+		"app.retention.schedule":              "APP_RETENTION_SCHEDULE",
+		"app.retention.dry_run":               "APP_RETENTION_DRY_RUN",
+		"app.retention.batch_size":            "APP_RETENTION_BATCH_SIZE",
+		"app.retention.pause":                 "APP_RETENTION_PAUSE",
+		"app.retention.max_deletes":           "APP_RETENTION_MAX_DELETES",
+		"app.retention.history":               "APP_RETENTION_HISTORY",
+		"app.saga.enabled":                    "APP_SAGA_ENABLED",
+		"app.saga.store":                      "APP_SAGA_STORE",
+		"app.saga.lease":                      "APP_SAGA_LEASE",
+		"app.saga.poll_interval":              "APP_SAGA_POLL_INTERVAL",
+		"app.saga.step_timeout":               "APP_SAGA_STEP_TIMEOUT",
+		"app.saga.max_attempts":               "APP_SAGA_MAX_ATTEMPTS",
+		"app.saga.backoff":                    "APP_SAGA_BACKOFF",
+		"app.saga.max_backoff":                "APP_SAGA_MAX_BACKOFF",
+		"app.flags.enabled":                   "APP_FLAGS_ENABLED",
+		"app.flags.provider":                  "APP_FLAGS_PROVIDER",
+		"app.flags.file":                      "APP_FLAGS_FILE",
+		"app.flags.table":                     "APP_FLAGS_TABLE",
+		"app.flags.key":                       "APP_FLAGS_KEY",
+		"app.flags.refresh":                   "APP_FLAGS_REFRESH",
+		"app.flags.tenant_header":             "APP_FLAGS_TENANT_HEADER",
+		"app.tenancy.enabled":                 "APP_TENANCY_ENABLED",
+		"app.tenancy.resolvers":               "APP_TENANCY_RESOLVERS",
+		"app.tenancy.header":                  "APP_TENANCY_HEADER",
+		"app.tenancy.domain":                  "APP_TENANCY_DOMAIN",
+		"app.tenancy.required":                "APP_TENANCY_REQUIRED",
+		"app.tenancy.platform":                "APP_TENANCY_PLATFORM",
+		"app.tenancy.store":                   "APP_TENANCY_STORE",
+		"app.deprecation.enforce":             "APP_DEPRECATION_ENFORCE",
+		"app.deprecation.max_clients":         "APP_DEPRECATION_MAX_CLIENTS",
+		"app.versioning.default":              "APP_VERSIONING_DEFAULT",
+		"app.usage.enabled":                   "APP_USAGE_ENABLED",
+		"app.usage.store":                     "APP_USAGE_STORE",
+		"app.usage.windows":                   "APP_USAGE_WINDOWS",
+		"app.usage.flush_interval":            "APP_USAGE_FLUSH_INTERVAL",
+		"app.usage.retention":                 "APP_USAGE_RETENTION",
+		"app.privacy.enabled":                 "APP_PRIVACY_ENABLED",
+		"app.privacy.prefix":                  "APP_PRIVACY_PREFIX",
+		"app.privacy.queue":                   "APP_PRIVACY_QUEUE",
+		"app.privacy.link_expiry":             "APP_PRIVACY_LINK_EXPIRY",
+		"app.privacy.history":                 "APP_PRIVACY_HISTORY",
+		"app.canary.header":                   "APP_CANARY_HEADER",
+		"app.i18n.enabled":                    "APP_I18N_ENABLED",
+		"app.i18n.default":                    "APP_I18N_DEFAULT",
+		"app.i18n.locales":                    "APP_I18N_LOCALES",
+		"app.i18n.query":                      "APP_I18N_QUERY",
+		"app.masking.enabled":                 "APP_MASKING_ENABLED",
+		"app.client.enabled":                  "APP_CLIENT_ENABLED",
+		"app.client.path":                     "APP_CLIENT_PATH",
+		"app.client.channel":                  "APP_CLIENT_CHANNEL",
+		"app.client.flags":                    "APP_CLIENT_FLAGS",
+		"app.chaos.enabled":                   "APP_CHAOS_ENABLED",
+		"app.chaos.environments":              "APP_CHAOS_ENVIRONMENTS",
+		"app.console.enabled":                 "APP_CONSOLE_ENABLED",
+		"app.console.socket":                  "APP_CONSOLE_SOCKET",
+		"app.console.token":                   "APP_CONSOLE_TOKEN",
+		"app.event_store.enabled":             "APP_EVENT_STORE_ENABLED",
+		"app.event_store.store":               "APP_EVENT_STORE_STORE",
+		"app.event_store.snapshot_every":      "APP_EVENT_STORE_SNAPSHOT_EVERY",
+		"app.event_store.publish":             "APP_EVENT_STORE_PUBLISH",
+		"app.outbox.enabled":                  "APP_OUTBOX_ENABLED",
+		"app.outbox.table":                    "APP_OUTBOX_TABLE",
+		"app.outbox.broker":                   "APP_OUTBOX_BROKER",
+		"app.outbox.interval":                 "APP_OUTBOX_INTERVAL",
+		"app.outbox.batch_size":               "APP_OUTBOX_BATCH_SIZE",
+		"app.outbox.max_attempts":             "APP_OUTBOX_MAX_ATTEMPTS",
+		"app.capture.enabled":                 "APP_CAPTURE_ENABLED",
+		"app.capture.sample":                  "APP_CAPTURE_SAMPLE",
+		"app.capture.paths":                   "APP_CAPTURE_PATHS",
+		"app.capture.prefix":                  "APP_CAPTURE_PREFIX",
+		"app.capture.max_body":                "APP_CAPTURE_MAX_BODY",
+		"app.capture.redact_headers":          "APP_CAPTURE_REDACT_HEADERS",
+		"app.quotas.enabled":                  "APP_QUOTAS_ENABLED",
+		"app.quotas.store":                    "APP_QUOTAS_STORE",
+		"app.quotas.path":                     "APP_QUOTAS_PATH",
+		"app.upgrades.drain_timeout":          "APP_UPGRADES_DRAIN_TIMEOUT",
+		"app.upgrades.delay":                  "APP_UPGRADES_DELAY",
+		"app.libraries.init_timeout":          "APP_LIBRARIES_INIT_TIMEOUT",
+		"app.libraries.debug":                 "APP_LIBRARIES_DEBUG",
+		"app.libraries.request_scope":         "APP_LIBRARIES_REQUEST_SCOPE",
+		"app.libraries.optional":              "APP_LIBRARIES_OPTIONAL",
+		"app.health.timeout":                  "APP_HEALTH_TIMEOUT",
+		"app.static.enabled":                  "APP_STATIC_ENABLED",
+		"app.static.path":                     "APP_STATIC_PATH",
+		"app.static.root":                     "APP_STATIC_ROOT",
+		"app.static.index":                    "APP_STATIC_INDEX",
+		"app.static.spa":                      "APP_STATIC_SPA",
+		"app.static.max_age":                  "APP_STATIC_MAX_AGE",
+		"app.static.immutable":                "APP_STATIC_IMMUTABLE",
+		"app.static.precompressed":            "APP_STATIC_PRECOMPRESSED",
+		"app.pagination.default_per_page":     "APP_PAGINATION_DEFAULT_PER_PAGE",
+		"app.pagination.max_per_page":         "APP_PAGINATION_MAX_PER_PAGE",
+		"app.problem_details.enabled":         "APP_PROBLEM_DETAILS_ENABLED",
+		"app.problem_details.type_base":       "APP_PROBLEM_DETAILS_TYPE_BASE",
+		"app.formats":                         "APP_FORMATS",
+		"app.startup_check":                   "APP_STARTUP_CHECK",
 		"app.module.base_path":                "APP_MODULE_BASE_PATH",
 		"app.module.disabled":                 "APP_MODULE_DISABLED",
 
 		// Server
-		"server.host":          "SERVER_HOST",
-		"server.port":          "SERVER_PORT",
-		"server.path":          "SERVER_PATH",
-		"server.read_timeout":  "SERVER_READ_TIMEOUT",
-		"server.write_timeout": "SERVER_WRITE_TIMEOUT",
+		"server.host":             "SERVER_HOST",
+		"server.port":             "SERVER_PORT",
+		"server.path":             "SERVER_PATH",
+		"server.read_timeout":     "SERVER_READ_TIMEOUT",
+		"server.write_timeout":    "SERVER_WRITE_TIMEOUT",
+		"server.shutdown_timeout": "SERVER_SHUTDOWN_TIMEOUT",
+		"server.tls_cert":         "SERVER_TLS_CERT",
+		"server.tls_key":          "SERVER_TLS_KEY",
 
 		// Auth
 		"auth.directory":            "AUTH_DIRECTORY",
@@ -122,5 +303,112 @@ func (c *Config) SetEnvBindings() map[string]string {
 		"pubsub.producer.enableordering": "PUBSUB_PRODUCER_ENABLEORDERING",
 		"pubsub.producer.batchsize":      "PUBSUB_PRODUCER_BATCHSIZE",
 		"pubsub.producer.attributes":     "PUBSUB_PRODUCER_ATTRIBUTES",
+
+		// Storage
+		"storage.driver":      "STORAGE_DRIVER",
+		"storage.bucket":      "STORAGE_BUCKET",
+		"storage.region":      "STORAGE_REGION",
+		"storage.endpoint":    "STORAGE_ENDPOINT",
+		"storage.access_key":  "STORAGE_ACCESS_KEY",
+		"storage.secret_key":  "STORAGE_SECRET_KEY",
+		"storage.path_style":  "STORAGE_PATH_STYLE",
+		"storage.root":        "STORAGE_ROOT",
+		"storage.public_path": "STORAGE_PUBLIC_PATH",
+		"storage.base_url":    "STORAGE_BASE_URL",
+		"storage.signing_key": "STORAGE_SIGNING_KEY",
+
+		// Mail
+		"mail.driver":     "MAIL_DRIVER",
+		"mail.from":       "MAIL_FROM",
+		"mail.host":       "MAIL_HOST",
+		"mail.port":       "MAIL_PORT",
+		"mail.username":   "MAIL_USERNAME",
+		"mail.password":   "MAIL_PASSWORD",
+		"mail.tls":        "MAIL_TLS",
+		"mail.api_key":    "MAIL_API_KEY",
+		"mail.region":     "MAIL_REGION",
+		"mail.access_key": "MAIL_ACCESS_KEY",
+		"mail.secret_key": "MAIL_SECRET_KEY",
+		"mail.directory":  "MAIL_DIRECTORY",
+		"mail.queue":      "MAIL_QUEUE",
+
+		// Notify
+		"notify.sms.driver":       "NOTIFY_SMS_DRIVER",
+		"notify.sms.account_sid":  "NOTIFY_SMS_ACCOUNT_SID",
+		"notify.sms.auth_token":   "NOTIFY_SMS_AUTH_TOKEN",
+		"notify.sms.from":         "NOTIFY_SMS_FROM",
+		"notify.push.driver":      "NOTIFY_PUSH_DRIVER",
+		"notify.push.credentials": "NOTIFY_PUSH_CREDENTIALS",
+		"notify.push.project_id":  "NOTIFY_PUSH_PROJECT_ID",
+		"notify.push.key_path":    "NOTIFY_PUSH_KEY_PATH",
+		"notify.push.key_id":      "NOTIFY_PUSH_KEY_ID",
+		"notify.push.team_id":     "NOTIFY_PUSH_TEAM_ID",
+		"notify.push.topic":       "NOTIFY_PUSH_TOPIC",
+		"notify.push.production":  "NOTIFY_PUSH_PRODUCTION",
+		"notify.default_channels": "NOTIFY_DEFAULT_CHANNELS",
+		"notify.queue":            "NOTIFY_QUEUE",
+
+		// View
+		"view.directory": "VIEW_DIRECTORY",
+		"view.layout":    "VIEW_LAYOUT",
+		"view.reload":    "VIEW_RELOAD",
+
+		// Report
+		"report.pdf":       "REPORT_PDF",
+		"report.xlsx":      "REPORT_XLSX",
+		"report.binary":    "REPORT_BINARY",
+		"report.page_size": "REPORT_PAGE_SIZE",
+		"report.timeout":   "REPORT_TIMEOUT",
+		"report.queue":     "REPORT_QUEUE",
+		"report.prefix":    "REPORT_PREFIX",
+
+		// Import
+		"import.queue":      "IMPORT_QUEUE",
+		"import.prefix":     "IMPORT_PREFIX",
+		"import.chunk_size": "IMPORT_CHUNK_SIZE",
+		"import.max_size":   "IMPORT_MAX_SIZE",
+		"import.max_errors": "IMPORT_MAX_ERRORS",
+
+		// Search
+		"search.driver":     "SEARCH_DRIVER",
+		"search.url":        "SEARCH_URL",
+		"search.username":   "SEARCH_USERNAME",
+		"search.password":   "SEARCH_PASSWORD",
+		"search.api_key":    "SEARCH_API_KEY",
+		"search.prefix":     "SEARCH_PREFIX",
+		"search.queue":      "SEARCH_QUEUE",
+		"search.batch_size": "SEARCH_BATCH_SIZE",
+
+		// Image
+		"image.quality":    "IMAGE_QUALITY",
+		"image.max_pixels": "IMAGE_MAX_PIXELS",
+		"image.queue":      "IMAGE_QUEUE",
+		"image.prefix":     "IMAGE_PREFIX",
+
+		// Payment
+		"payment.driver":         "PAYMENT_DRIVER",
+		"payment.api_key":        "PAYMENT_API_KEY",
+		"payment.webhook_secret": "PAYMENT_WEBHOOK_SECRET",
+		"payment.base_url":       "PAYMENT_BASE_URL",
+		"payment.currency":       "PAYMENT_CURRENCY",
+		"payment.timeout":        "PAYMENT_TIMEOUT",
+
+		// Secrets
+		"secrets.driver":       "SECRETS_DRIVER",
+		"secrets.address":      "SECRETS_ADDRESS",
+		"secrets.token":        "SECRETS_TOKEN",
+		"secrets.namespace":    "SECRETS_NAMESPACE",
+		"secrets.mount":        "SECRETS_MOUNT",
+		"secrets.region":       "SECRETS_REGION",
+		"secrets.access_key":   "SECRETS_ACCESS_KEY",
+		"secrets.secret_key":   "SECRETS_SECRET_KEY",
+		"secrets.project_id":   "SECRETS_PROJECT_ID",
+		"secrets.credentials":  "SECRETS_CREDENTIALS",
+		"secrets.endpoint":     "SECRETS_ENDPOINT",
+		"secrets.timeout":      "SECRETS_TIMEOUT",
+		"secrets.refresh":      "SECRETS_REFRESH",
+		"secrets.renew_before": "SECRETS_RENEW_BEFORE",
+		"secrets.field_key":    "SECRETS_FIELD_KEY",
+		"secrets.signing_key":  "SECRETS_SIGNING_KEY",
 	}
 }
@@ -1,6 +1,10 @@
 package config
 
 import (
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
@@ -16,40 +20,513 @@ type Config struct {
 	Kafka    KafkaConfig    `mapstructure:"kafka"`
 	PubSub   PubSubConfig   `mapstructure:"pubsub"`
 	Auth     AuthConfig     `mapstructure:"auth"`
-	Others   map[string]ConfigObject
+	Storage  StorageConfig  `mapstructure:"storage"`
+	Mail     MailConfig     `mapstructure:"mail"`
+	Notify   NotifyConfig   `mapstructure:"notify"`
+	View     ViewConfig     `mapstructure:"view"`
+	Report   ReportConfig   `mapstructure:"report"`
+	Import   ImportConfig   `mapstructure:"import"`
+	Search   SearchConfig   `mapstructure:"search"`
+	Image    ImageConfig    `mapstructure:"image"`
+	Payment  PaymentConfig  `mapstructure:"payment"`
+	Secrets  SecretsConfig  `mapstructure:"secrets"`
+
+	HTTPClients map[string]HTTPClientConfig `mapstructure:"http_clients"` // outbound clients keyed by upstream name
+	Transfers   map[string]TransferConfig   `mapstructure:"transfers"`    // file-transfer connections keyed by partner name
+	Modules     map[string]ModuleSwitch     `mapstructure:"module"`       // switches of the module sections, see ModuleEnabled
+	Others      map[string]ConfigObject
 }
 
 type AppConfig struct {
-	Name              string          `mapstructure:"name"`
-	Version           string          `mapstructure:"version"`
-	Environment       string          `mapstructure:"environment"`
-	Features          FeaturesConfig  `mapstructure:"features"`
-	Logging           LoggingConfig   `mapstructure:"logging"`
-	CORS              CORSConfig      `mapstructure:"cors"`
-	RateLimit         RateLimitConfig `mapstructure:"rate_limit"`
-	SecurityHeaders   bool            `mapstructure:"security_headers"`
-	AdditionalHeaders []string        `mapstructure:"additional_headers"`
-	Module            ModuleConfig    `mapstructure:"module"`
+	Name              string            `mapstructure:"name"`
+	Version           string            `mapstructure:"version"`
+	Environment       string            `mapstructure:"environment"`
+	Features          FeaturesConfig    `mapstructure:"features"`
+	Logging           LoggingConfig     `mapstructure:"logging"`
+	AccessLog         AccessLogConfig   `mapstructure:"access_log"`
+	Metrics           MetricsConfig     `mapstructure:"metrics"`
+	Tracing           TracingConfig     `mapstructure:"tracing"`
+	CORS              CORSConfig        `mapstructure:"cors"`
+	RateLimit         RateLimitConfig   `mapstructure:"rate_limit"`
+	GeoIP             GeoIPConfig       `mapstructure:"geoip"`
+	Compression       CompressionConfig `mapstructure:"compression"`
+	ETag              ETagConfig        `mapstructure:"etag"`
+	OpenAPI           OpenAPIConfig     `mapstructure:"openapi"`
+	Scheduler         SchedulerConfig   `mapstructure:"scheduler"`
+	Admin             AdminConfig       `mapstructure:"admin"`
+	Queue             QueueConfig       `mapstructure:"queue"`
+	GraphQL           GraphQLConfig     `mapstructure:"graphql"`
+	Hub               HubConfig         `mapstructure:"hub"`
+	Webhooks          WebhooksConfig    `mapstructure:"webhooks"`
+	Tasks             TasksConfig       `mapstructure:"tasks"`
+	GRPC              GRPCConfig        `mapstructure:"grpc"`
+	Discovery         DiscoveryConfig   `mapstructure:"discovery"`
+	DevTail           DevTailConfig     `mapstructure:"dev_tail"`
+	Retention         RetentionConfig   `mapstructure:"retention"`
+	Saga              SagaConfig        `mapstructure:"saga"`
+	Flags             FlagsConfig       `mapstructure:"flags"`
+	Tenancy           TenancyConfig     `mapstructure:"tenancy"`
+	Resilience        ResilienceConfig  `mapstructure:"resilience"`
+	Retry             RetryConfig       `mapstructure:"retry"`
+	Deprecation       DeprecationConfig `mapstructure:"deprecation"`
+	Versioning        VersioningConfig  `mapstructure:"versioning"`
+	Usage             UsageConfig       `mapstructure:"usage"`
+	Privacy           PrivacyConfig     `mapstructure:"privacy"`
+	Canary            CanaryConfig      `mapstructure:"canary"`
+	I18n              I18nConfig        `mapstructure:"i18n"`
+	Masking           MaskingConfig     `mapstructure:"masking"`
+	Client            ClientConfig      `mapstructure:"client"`
+	Chaos             ChaosConfig       `mapstructure:"chaos"`
+	Console           ConsoleConfig     `mapstructure:"console"`
+	EventStore        EventStoreConfig  `mapstructure:"event_store"`
+	Outbox            OutboxConfig      `mapstructure:"outbox"`
+	Capture           CaptureConfig     `mapstructure:"capture"`
+	Quotas            QuotasConfig      `mapstructure:"quotas"`
+	Upgrades          UpgradesConfig    `mapstructure:"upgrades"`
+	Libraries         LibrariesConfig   `mapstructure:"libraries"`
+	Health            HealthConfig      `mapstructure:"health"`
+	Static            StaticConfig      `mapstructure:"static"`
+	Pagination        PaginationConfig  `mapstructure:"pagination"`
+	ProblemDetails    ProblemConfig     `mapstructure:"problem_details"`
+	Formats           []string          `mapstructure:"formats"`       // formats of the responses negotiated with the Accept header, JSON by default: json, xml, msgpack, text and the ones of out.RegisterEncoder
+	StartupCheck      bool              `mapstructure:"startup_check"` // run the checks of the doctor command before serving, and refuse to start when one fails
+	SecurityHeaders   bool              `mapstructure:"security_headers"`
+	AdditionalHeaders []string          `mapstructure:"additional_headers"`
+	Module            ModuleConfig      `mapstructure:"module"`
 }
 
 type RateLimitConfig struct {
+	Enabled   bool           `mapstructure:"enabled"`
+	Max       int            `mapstructure:"max"`
+	Countries map[string]int `mapstructure:"countries"` // max per country code overriding max, needs app.geoip
+}
+
+type GeoIPConfig struct {
+	Enabled     bool   `mapstructure:"enabled"`
+	Provider    string `mapstructure:"provider"`     // supported: "maxmind"
+	Database    string `mapstructure:"database"`     // country or city database (ex: GeoLite2-City.mmdb)
+	ASNDatabase string `mapstructure:"asn_database"` // optional ASN database (ex: GeoLite2-ASN.mmdb)
+	Language    string `mapstructure:"language"`     // of country and city names
+}
+
+type CompressionConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	Level   int  `mapstructure:"level"` // -1 disabled, 0 default, 1 best speed, 2 best compression
+}
+
+type ETagConfig struct {
 	Enabled bool `mapstructure:"enabled"`
-	Max     int  `mapstructure:"max"`
+	Weak    bool `mapstructure:"weak"` // generate weak (W/"...") validators instead of strong ones
+}
+
+type OpenAPIConfig struct {
+	Spec             string `mapstructure:"spec"`              // path of the OpenAPI document (JSON)
+	ValidateRequest  bool   `mapstructure:"validate_request"`  // reject requests not matching the contract with 400
+	ValidateResponse bool   `mapstructure:"validate_response"` // development only, report responses not matching the contract with 500
+	Serve            bool   `mapstructure:"serve"`             // serve the document generated from the module routes on /openapi.json
+	UI               bool   `mapstructure:"ui"`                // with serve, the Swagger UI on /docs
+}
+
+type SchedulerConfig struct {
+	Enabled bool   `mapstructure:"enabled"`
+	Locker  string `mapstructure:"locker"` // library name of the distributed lock (ex: "lock:database"), empty uses an in-process lock
+}
+
+type AdminConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Path    string   `mapstructure:"path"`  // mounted below the authenticated root group
+	Roles   []string `mapstructure:"roles"` // the user has one of them (RBAC roles or ABAC groups)
+}
+
+type QueueConfig struct {
+	Enabled      bool           `mapstructure:"enabled"`
+	Store        string         `mapstructure:"store"`         // library name of the job store (ex: "queue:database"), empty keeps jobs in memory
+	Workers      map[string]int `mapstructure:"workers"`       // queue name -> number of workers
+	PollInterval time.Duration  `mapstructure:"poll_interval"` // wait between polls of an empty queue
+	Lease        time.Duration  `mapstructure:"lease"`         // a job still running past it was lost with its instance and runs again
+	MaxAttempts  int            `mapstructure:"max_attempts"`  // default retry policy
+	Backoff      time.Duration  `mapstructure:"backoff"`       // delay before the first retry, doubled on each attempt
+	MaxBackoff   time.Duration  `mapstructure:"max_backoff"`
+}
+
+type GraphQLConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Path          string `mapstructure:"path"`           // mounted below the authenticated root group
+	MaxDepth      int    `mapstructure:"max_depth"`      // deepest allowed selection, 0 for no limit
+	MaxComplexity int    `mapstructure:"max_complexity"` // highest query cost (fields, multiplied by first/last/limit of lists), 0 for no limit
+}
+
+type HubConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Path         string        `mapstructure:"path"`          // websocket endpoint below the authenticated root group
+	Broker       string        `mapstructure:"broker"`        // library name of the broker shared by the instances (ex: "hub:redis"), empty keeps the hub in-process
+	Topic        string        `mapstructure:"topic"`         // broker topic of the hub messages
+	PresenceTTL  time.Duration `mapstructure:"presence_ttl"`  // members of a crashed instance expire after this delay
+	PingInterval time.Duration `mapstructure:"ping_interval"` // idle connections are pinged, silent ones dropped after two intervals
+	ReadLimit    int64         `mapstructure:"read_limit"`    // largest message accepted from a client in bytes
+	Origins      []string      `mapstructure:"origins"`       // allowed browser origins, empty allows the same host only
+}
+
+type WebhooksConfig struct {
+	Enabled  bool          `mapstructure:"enabled"`
+	Path     string        `mapstructure:"path"`      // public endpoint below the path prefix, deliveries are posted to <path>/<source>
+	Queue    string        `mapstructure:"queue"`     // job queue running the handlers, app.queue must be enabled
+	DedupTTL time.Duration `mapstructure:"dedup_ttl"` // deliveries of an already received event id are ignored during this delay
+	Locker   string        `mapstructure:"locker"`    // library name of the shared lock de-duplicating deliveries across instances, empty keeps it in-process
+}
+
+type TasksConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Path    string        `mapstructure:"path"`  // status endpoint below the authenticated root group, <path>/<id>
+	Store   string        `mapstructure:"store"` // library name of the cache shared by the instances (ex: "cache:redis"), empty keeps tasks in-process
+	TTL     time.Duration `mapstructure:"ttl"`   // how long the status of a task is kept after its last update
+	Queue   string        `mapstructure:"queue"` // job queue running the tasks, app.queue must be enabled
+}
+
+type GRPCConfig struct {
+	Enabled    bool `mapstructure:"enabled"`
+	Port       int  `mapstructure:"port"`       // cleartext HTTP/2 listener on server.host serving the gRPC health checking protocol
+	Reflection bool `mapstructure:"reflection"` // serve the server reflection protocol (grpcurl, Postman)
+}
+
+type DiscoveryConfig struct {
+	Enabled         bool              `mapstructure:"enabled"`
+	Backend         string            `mapstructure:"backend"` // supported: "consul"
+	Server          string            `mapstructure:"server"`  // agent of the backend (ex: "http://127.0.0.1:8500")
+	Token           string            `mapstructure:"token"`   // ACL token of the backend
+	Name            string            `mapstructure:"name"`    // service name, defaults to app.name
+	ID              string            `mapstructure:"id"`      // instance id, defaults to <name>-<hostname>-<port>
+	Address         string            `mapstructure:"address"` // advertised address, defaults to the first non-loopback IP
+	Port            int               `mapstructure:"port"`    // advertised port, defaults to server.port
+	Tags            []string          `mapstructure:"tags"`
+	Meta            map[string]string `mapstructure:"meta"`             // added to version, environment and grpc_port
+	TTL             time.Duration     `mapstructure:"ttl"`              // the instance turns critical without a heartbeat during this delay
+	DeregisterAfter time.Duration     `mapstructure:"deregister_after"` // critical instances are removed after this delay
+}
+
+type DevTailConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // only served when app.environment is development
+	Path    string `mapstructure:"path"`    // server-sent events endpoint below the authenticated root group
+	Buffer  int    `mapstructure:"buffer"`  // recent log lines and events replayed to new clients
+}
+
+type RetentionConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Schedule   string        `mapstructure:"schedule"`    // cron expression of the purge job, app.scheduler must be enabled
+	DryRun     bool          `mapstructure:"dry_run"`     // only count the expired rows, nothing is deleted
+	BatchSize  int           `mapstructure:"batch_size"`  // rows deleted per statement
+	Pause      time.Duration `mapstructure:"pause"`       // delay between two batches, limits the load on the database
+	MaxDeletes int64         `mapstructure:"max_deletes"` // rows deleted per rule and run, 0 means no limit
+	History    int           `mapstructure:"history"`     // purge reports kept for the admin endpoint
+}
+
+type SagaConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Store        string        `mapstructure:"store"`         // library name of the saga store (ex: "saga:database"), empty keeps sagas in memory
+	Lease        time.Duration `mapstructure:"lease"`         // a saga not saved for this long is resumed by another instance, must exceed the step timeout
+	PollInterval time.Duration `mapstructure:"poll_interval"` // wait between two searches of stalled sagas
+	StepTimeout  time.Duration `mapstructure:"step_timeout"`  // default limit of a step or compensation
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // default retry policy of the steps
+	Backoff      time.Duration `mapstructure:"backoff"`       // delay before the first retry, doubled on each attempt
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`
+}
+
+type FlagsConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	Provider     string        `mapstructure:"provider"`      // "file", "database" or the library name of a cache (ex: "redis"), empty keeps the flags in memory
+	File         string        `mapstructure:"file"`          // JSON file of the file provider
+	Table        string        `mapstructure:"table"`         // table of the database provider
+	Key          string        `mapstructure:"key"`           // key of the flags in the cache provider
+	Refresh      time.Duration `mapstructure:"refresh"`       // reload interval, the changes made by other instances are seen after it
+	TenantHeader string        `mapstructure:"tenant_header"` // header naming the tenant of a request, for the targeting rules
+}
+
+type TenancyConfig struct {
+	Enabled   bool                    `mapstructure:"enabled"`
+	Resolvers []string                `mapstructure:"resolvers"` // tried in order: "header", "subdomain", "path" (first segment after server.path_prefix), "claim" (tenant of the authenticated user)
+	Header    string                  `mapstructure:"header"`    // header of the header resolver
+	Domain    string                  `mapstructure:"domain"`    // domain under which the subdomain resolver reads the tenant (ex: "example.com")
+	Required  bool                    `mapstructure:"required"`  // the protected routes refuse the requests without tenant
+	Platform  []string                `mapstructure:"platform"`  // roles of the users claiming no tenant allowed on every tenant (ex: operators), the others are refused
+	Store     string                  `mapstructure:"store"`     // library name of a port.ITenantStore, empty uses tenants
+	Tenants   map[string]TenantConfig `mapstructure:"tenants"`   // by tenant ID
+}
+
+type TenantConfig struct {
+	Name      string         `mapstructure:"name"`
+	Database  string         `mapstructure:"database"`   // URI of the database of the tenant
+	Schema    string         `mapstructure:"schema"`     // schema of the tenant in the default database
+	RateLimit int            `mapstructure:"rate_limit"` // requests per minute
+	Settings  map[string]any `mapstructure:"settings"`   // configuration overrides (ex: module.orders.page_size: 50)
+}
+
+type ResilienceConfig struct {
+	Policies map[string]ResiliencePolicyConfig `mapstructure:"policies"` // by name, "database" guards the default database, the others are used with AppContext.Resilience.Policy
+}
+
+type ResiliencePolicyConfig struct {
+	Breaker  BreakerConfig  `mapstructure:"breaker"`
+	Bulkhead BulkheadConfig `mapstructure:"bulkhead"`
+	Timeout  time.Duration  `mapstructure:"timeout"` // limit of a call, 0 for no limit
+}
+
+type RetryConfig struct {
+	Policies map[string]RetryPolicyConfig `mapstructure:"policies"` // by name: "database", "consumer" and "webhook" replace the built-in retries, the HTTP clients name theirs in retry.policy
+}
+
+type RetryPolicyConfig struct {
+	Strategy     string        `mapstructure:"strategy"`      // "exponential" (default) or "fixed"
+	MaxAttempts  int           `mapstructure:"max_attempts"`  // attempts with the first one, 0 for no limit
+	Backoff      time.Duration `mapstructure:"backoff"`       // delay before the first retry, doubled on each attempt by the exponential strategy
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"`   // longest delay, 0 for no limit
+	Jitter       float64       `mapstructure:"jitter"`        // part of the delay drawn at random, from 0 to 1
+	Budget       int           `mapstructure:"budget"`        // retries allowed per budget_window to the users of the policy, 0 for no limit
+	BudgetWindow time.Duration `mapstructure:"budget_window"` // defaults to 1m
+}
+
+type BulkheadConfig struct {
+	MaxConcurrent int           `mapstructure:"max_concurrent"` // calls running at once, 0 for no limit
+	MaxWait       time.Duration `mapstructure:"max_wait"`       // wait for a free slot before failing with ErrBulkheadFull, 0 fails at once
+}
+
+type DeprecationConfig struct {
+	Enforce    bool                    `mapstructure:"enforce"`     // refuse the calls after the sunset date with 410 Gone
+	MaxClients int                     `mapstructure:"max_clients"` // callers reported per endpoint, the calls of the others are only counted
+	Rules      []DeprecationRuleConfig `mapstructure:"rules"`
+}
+
+type DeprecationRuleConfig struct {
+	Method    string `mapstructure:"method"`    // empty for every method
+	Path      string `mapstructure:"path"`      // route (ex: "/api/orders/:id") or version prefix (ex: "/api/v1/*")
+	Since     string `mapstructure:"since"`     // date (2006-01-02) or RFC 3339 time
+	Sunset    string `mapstructure:"sunset"`    // date (2006-01-02) or RFC 3339 time of the removal
+	Link      string `mapstructure:"link"`      // documentation of the deprecation
+	Successor string `mapstructure:"successor"` // route or version replacing the deprecated one
+}
+
+type VersioningConfig struct {
+	Default  string                   `mapstructure:"default"`  // version of the module routes declared without one (ex: v1), unversioned when empty
+	Versions map[string]VersionConfig `mapstructure:"versions"` // by version (ex: v1), the deprecated ones announce it on all their routes
+}
+
+type VersionConfig struct {
+	Deprecated bool   `mapstructure:"deprecated"`
+	Since      string `mapstructure:"since"`     // date (2006-01-02) or RFC 3339 time
+	Sunset     string `mapstructure:"sunset"`    // date (2006-01-02) or RFC 3339 time of the removal
+	Link       string `mapstructure:"link"`      // documentation of the migration
+	Successor  string `mapstructure:"successor"` // version replacing this one (ex: /api/v2)
+}
+
+type UsageConfig struct {
+	Enabled       bool                   `mapstructure:"enabled"`
+	Store         string                 `mapstructure:"store"`          // library of the usage store, kept in memory when empty
+	Windows       []string               `mapstructure:"windows"`        // aggregation windows (ex: ["1h", "24h"])
+	FlushInterval time.Duration          `mapstructure:"flush_interval"` // interval the counters are saved in the store
+	Retention     time.Duration          `mapstructure:"retention"`      // periods kept by the memory store
+	Quotas        map[string]QuotaConfig `mapstructure:"quotas"`         // by principal, "*" for the others
+}
+
+type QuotaConfig struct {
+	Window   string `mapstructure:"window"`   // one of the windows of app.usage.windows
+	Requests int64  `mapstructure:"requests"` // requests per period, 0 for no limit
+	Bytes    int64  `mapstructure:"bytes"`    // bytes received and sent per period, 0 for no limit
+}
+
+type QuotasConfig struct {
+	Enabled  bool                          `mapstructure:"enabled"`
+	Store    string                        `mapstructure:"store"`    // cache library of the counters shared by the instances (ex: "redis"), memory when empty
+	Path     string                        `mapstructure:"path"`     // endpoint of the quotas of the requester, below the authenticated root group
+	Features map[string]FeatureQuotaConfig `mapstructure:"features"` // by feature name
+}
+
+type FeatureQuotaConfig struct {
+	Window time.Duration `mapstructure:"window"` // length of the periods (ex: 24h)
+	Limit  int64         `mapstructure:"limit"`  // units per period, 0 for no limit; a tenant overrides it with its "quota.<feature>" setting
+	Per    string        `mapstructure:"per"`    // "tenant" (default) or "principal"
+	Routes []string      `mapstructure:"routes"` // "METHOD /path" taking a unit per successful request (ex: "POST /api/reports")
+	Flag   string        `mapstructure:"flag"`   // the quota applies when this flag is on for the requester, always when empty
+}
+
+type LibrariesConfig struct {
+	InitTimeout  time.Duration            `mapstructure:"init_timeout"`  // limit of the installation and connection of a library, 0 for no limit
+	Timeouts     map[string]time.Duration `mapstructure:"timeouts"`      // by library name (ex: database:postgres: 2m), in place of init_timeout
+	Debug        bool                     `mapstructure:"debug"`         // serve the loaded libraries on /debug/libraries, without authentication
+	RequestScope bool                     `mapstructure:"request_scope"` // give each request a scope unloading its instances once handled, see LibraryManager.Scope
+	Optional     []string                 `mapstructure:"optional"`      // libraries, by name or family (ex: database, kafka:producer), whose failure on start is logged instead of aborting it
+}
+
+type HealthConfig struct {
+	Timeout time.Duration `mapstructure:"timeout"` // limit of the health checks of the libraries on /healthz and /readyz
+}
+
+type StaticConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Path          string        `mapstructure:"path"`          // URL path the files are served under (ex: "/", "/app")
+	Root          string        `mapstructure:"root"`          // directory of the files (ex: the build of the frontend)
+	Index         string        `mapstructure:"index"`         // file of the directories and of the SPA fallback
+	SPA           bool          `mapstructure:"spa"`           // answer the index for the unknown paths without extension
+	MaxAge        time.Duration `mapstructure:"max_age"`       // Cache-Control of the files other than the index, none when 0
+	Immutable     bool          `mapstructure:"immutable"`     // the files never change under their name (ex: hashed bundles)
+	Precompressed bool          `mapstructure:"precompressed"` // serve the .br or .gz file next to a file to the clients accepting it
+}
+
+type ProblemConfig struct {
+	Enabled  bool   `mapstructure:"enabled"`   // answer the errors as RFC 9457 problem documents (application/problem+json)
+	TypeBase string `mapstructure:"type_base"` // URI the error name is appended to in the type of the problems, about:blank when empty
+}
+
+type PaginationConfig struct {
+	DefaultPerPage int `mapstructure:"default_per_page"` // size of the pages when the client gives no per_page
+	MaxPerPage     int `mapstructure:"max_per_page"`     // largest per_page, a larger one is reduced to it
+}
+
+type UpgradesConfig struct {
+	DrainTimeout time.Duration     `mapstructure:"drain_timeout"` // wait for the old version to finish its work in flight on a cutover
+	Delay        time.Duration     `mapstructure:"delay"`         // time both versions run side by side before the switch configured in cutover
+	Cutover      map[string]string `mapstructure:"cutover"`       // version cut over to once staged, by "<library>/<key>" (ex: kafka:consumer/orders: v2)
+}
+
+type PrivacyConfig struct {
+	Enabled    bool          `mapstructure:"enabled"`
+	Prefix     string        `mapstructure:"prefix"`      // object storage folder of the export archives and the reports
+	Queue      string        `mapstructure:"queue"`       // job queue running the exports and erasures, app.queue must be enabled
+	LinkExpiry time.Duration `mapstructure:"link_expiry"` // validity of the download link of an export
+	History    int           `mapstructure:"history"`     // reports kept for the admin endpoint
+}
+
+type CanaryConfig struct {
+	Header string              `mapstructure:"header"` // header forcing the variant of a request ("canary" or "stable"), empty to disable
+	Routes []CanaryRouteConfig `mapstructure:"routes"`
+}
+
+type CanaryRouteConfig struct {
+	Name    string `mapstructure:"name"`    // name of the canary (ex: "GET /api/orders/:id")
+	Percent int    `mapstructure:"percent"` // replaces the percent declared by the module
+}
+
+type I18nConfig struct {
+	Enabled bool     `mapstructure:"enabled"`
+	Default string   `mapstructure:"default"` // locale of the requests matching none of Locales
+	Locales []string `mapstructure:"locales"` // locales offered to the clients (ex: ["en", "id"]), every built-in locale when empty
+	Query   string   `mapstructure:"query"`   // query parameter choosing the locale before Accept-Language, empty to disable
+}
+
+type MaskingConfig struct {
+	Enabled bool                           `mapstructure:"enabled"`
+	Default MaskingPolicyConfig            `mapstructure:"default"`
+	Tenants map[string]MaskingPolicyConfig `mapstructure:"tenants"` // by tenant ID, replaces Default for the data of the tenant
+}
+
+type ClientConfig struct {
+	Enabled  bool           `mapstructure:"enabled"`
+	Path     string         `mapstructure:"path"`     // endpoint of the flags and settings of the requester, below the authenticated root group
+	Channel  string         `mapstructure:"channel"`  // hub channel the frontends subscribe to for the changes, app.hub must be enabled
+	Flags    []string       `mapstructure:"flags"`    // keys of the flags exposed to the frontends, a trailing * matches a prefix (ex: "ui.*")
+	Settings map[string]any `mapstructure:"settings"` // values exposed to the frontends, the tenants override them with their "client.<key>" settings
+}
+
+type ChaosConfig struct {
+	Enabled      bool               `mapstructure:"enabled"`      // only in one of environments
+	Environments []string           `mapstructure:"environments"` // values of app.environment allowed to inject faults (ex: ["development", "staging"])
+	Faults       []ChaosFaultConfig `mapstructure:"faults"`
+}
+
+type ChaosFaultConfig struct {
+	Name      string        `mapstructure:"name"`
+	Target    string        `mapstructure:"target"`     // "http", or a guarded library: "database" (with the databases of the tenants), "database:<tenant>", or the name given to Chaos.Target
+	Path      string        `mapstructure:"path"`       // http: prefix of the disturbed paths, every path when empty
+	Latency   time.Duration `mapstructure:"latency"`    // added to every call
+	Jitter    time.Duration `mapstructure:"jitter"`     // random latency added up to
+	ErrorRate float64       `mapstructure:"error_rate"` // share of the calls failing, from 0 to 1
+	Status    int           `mapstructure:"status"`     // http: status of the failures, 503 when 0
+	DropRate  float64       `mapstructure:"drop_rate"`  // share of the calls whose connection is dropped, from 0 to 1
+}
+
+type ConsoleConfig struct {
+	Enabled bool   `mapstructure:"enabled"` // only served when app.environment is development
+	Socket  string `mapstructure:"socket"`  // Unix socket of the console, readable by the user of the process only
+	Token   string `mapstructure:"token"`   // sent first by the clients, required
+}
+
+type EventStoreConfig struct {
+	Enabled       bool   `mapstructure:"enabled"`
+	Store         string `mapstructure:"store"`          // library name of the event store (ex: "eventstore:database"), empty keeps the streams in memory
+	SnapshotEvery int    `mapstructure:"snapshot_every"` // events between two snapshots of an aggregate, no snapshot when 0
+	Publish       bool   `mapstructure:"publish"`        // publish the appended events on the EventBus under their type
+}
+
+type OutboxConfig struct {
+	Enabled     bool              `mapstructure:"enabled"`
+	Table       string            `mapstructure:"table"`        // table of the messages, in the default database
+	Broker      string            `mapstructure:"broker"`       // library name of the port.IPubSub or port.IKafka the messages are relayed to (ex: "pubsub")
+	Topics      map[string]string `mapstructure:"topics"`       // Kafka topic of an event, the name of the event when missing
+	Interval    time.Duration     `mapstructure:"interval"`     // wait between two searches of the messages to relay, the commits of the instance relay at once
+	BatchSize   int               `mapstructure:"batch_size"`   // messages relayed per search
+	Lease       time.Duration     `mapstructure:"lease"`        // a message claimed for this long without being relayed is relayed again, by any instance
+	MaxAttempts int               `mapstructure:"max_attempts"` // relays of a message before it is left failed in the table, 0 for no limit
+	Backoff     time.Duration     `mapstructure:"backoff"`      // delay before the first retry, doubled on each attempt
+	MaxBackoff  time.Duration     `mapstructure:"max_backoff"`
+}
+
+type CaptureConfig struct {
+	Enabled       bool     `mapstructure:"enabled"`
+	Sample        float64  `mapstructure:"sample"`         // share of the requests captured, from 0 to 1
+	Paths         []string `mapstructure:"paths"`          // prefixes of the captured paths, every path when empty
+	Prefix        string   `mapstructure:"prefix"`         // key prefix of the captures in the object storage of storage.driver
+	MaxBody       int      `mapstructure:"max_body"`       // larger bodies are not stored, nor their request replayed
+	RedactHeaders []string `mapstructure:"redact_headers"` // headers stored redacted with Authorization, Cookie and auth.api_key_header, the replay sets its own
+}
+
+type MaskingPolicyConfig struct {
+	Fields   []string `mapstructure:"fields" json:"fields"`     // field and attribute names masked (ex: "email", "phone"), whatever their case and separators
+	Tags     []string `mapstructure:"tags" json:"tags"`         // values of the mask struct tag masked (ex: "pii" for `mask:"pii"`)
+	Patterns []string `mapstructure:"patterns" json:"patterns"` // regular expressions masked inside every string (ex: card numbers)
+	Keep     int      `mapstructure:"keep" json:"keep"`         // trailing characters left visible, none when 0
 }
 
 type FeaturesConfig struct {
 	Logging   bool `mapstructure:"logging"`
 	Recovery  bool `mapstructure:"recovery"`
 	Metrics   bool `mapstructure:"metrics"`
-	Tracing   bool `mapstructure:"tracing"`
+	Tracing   bool `mapstructure:"tracing"` // unused, the request ID and traceparent are always propagated, see app.tracing for the spans
 	Profiling bool `mapstructure:"profiling"`
 }
 
 type LoggingConfig struct {
-	Level  string              `mapstructure:"level"`
-	Format string              `mapstructure:"format"`
-	Output string              `mapstructure:"output"`
-	Remote RemoteLoggingConfig `mapstructure:"remote"`
+	Level   string              `mapstructure:"level"`
+	Format  string              `mapstructure:"format"`
+	Output  string              `mapstructure:"output"`
+	Backend string              `mapstructure:"backend"` // library "logger:<backend>" writing the lines (slog, zap, zerolog), the default slog logger when empty
+	Remote  RemoteLoggingConfig `mapstructure:"remote"`
+}
+
+type AccessLogConfig struct {
+	Enabled    bool               `mapstructure:"enabled"`
+	SampleRate float64            `mapstructure:"sample_rate"` // fraction of the requests logged, from 0 to 1
+	Routes     map[string]float64 `mapstructure:"routes"`      // sample rate by route, "<method> <path>" or "<path>" (ex: "GET /api/v1/orders/:id": 0.1, /health: 0)
+	KeepErrors bool               `mapstructure:"keep_errors"` // log the 5xx responses whatever the sample rate
+	Body       bool               `mapstructure:"body"`        // log the JSON bodies of the requests and the responses
+	MaxBody    int                `mapstructure:"max_body"`    // bytes of the largest body logged
+	Redact     []string           `mapstructure:"redact"`      // fields masked in the bodies, with the credentials (password, token, secret...)
+}
+
+type MetricsConfig struct {
+	Enabled bool      `mapstructure:"enabled"`
+	Path    string    `mapstructure:"path"`    // served in the Prometheus text format, without authentication
+	Buckets []float64 `mapstructure:"buckets"` // upper bounds in seconds of the histogram of the request latencies
+}
+
+type TracingConfig struct {
+	Enabled       bool              `mapstructure:"enabled"`
+	Exporter      string            `mapstructure:"exporter"`       // loaded as the library "tracing:<exporter>" (ex: otlp)
+	Endpoint      string            `mapstructure:"endpoint"`       // base URL of the OTLP/HTTP collector, the spans are posted to <endpoint>/v1/traces
+	Headers       map[string]string `mapstructure:"headers"`        // sent with each export (ex: the API key of a vendor)
+	ServiceName   string            `mapstructure:"service_name"`   // app.name when empty
+	SampleRate    float64           `mapstructure:"sample_rate"`    // fraction of the traces started here that are recorded, the others follow the decision of their caller
+	BatchSize     int               `mapstructure:"batch_size"`     // spans by export
+	QueueSize     int               `mapstructure:"queue_size"`     // spans waiting for their export, the next ones are dropped
+	FlushInterval time.Duration     `mapstructure:"flush_interval"` // longest wait of an ended span before its export
+	Timeout       time.Duration     `mapstructure:"timeout"`        // limit of an export
 }
 
 type RemoteLoggingConfig struct {
@@ -71,11 +548,14 @@ type CORSConfig struct {
 }
 
 type ServerConfig struct {
-	Host         string        `mapstructure:"host"`
-	Port         int           `mapstructure:"port"`
-	PathPrefix   string        `mapstructure:"path"`
-	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+	Host            string        `mapstructure:"host"`
+	Port            int           `mapstructure:"port"`
+	PathPrefix      string        `mapstructure:"path"`
+	ReadTimeout     time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration `mapstructure:"write_timeout"`
+	ShutdownTimeout time.Duration `mapstructure:"shutdown_timeout"` // wait for the requests in flight and the libraries on stop
+	TLSCert         string        `mapstructure:"tls_cert"`         // certificate file, served over HTTPS with tls_key when both are set
+	TLSKey          string        `mapstructure:"tls_key"`
 }
 
 type DatabaseConfig struct {
@@ -94,6 +574,10 @@ type DatabaseConfig struct {
 	MaxOpenConns    int               `mapstructure:"max_open_conns"`
 	ConnMaxLifetime time.Duration     `mapstructure:"conn_max_lifetime"`
 	SlaveHosts      []DatabaseConfig  `mapstructure:"slave_hosts"`
+
+	// Instances are the named databases loaded with LoadInstance, with the fields they set in
+	// place of the ones above (ex: database.instances.analytics.name)
+	Instances map[string]DatabaseConfig `mapstructure:"instances"`
 }
 
 type MemoryConfig struct {
@@ -118,6 +602,200 @@ type KafkaConfig struct {
 	AutoOffsetReset string   `mapstructure:"offset_reset"`
 }
 
+type StorageConfig struct {
+	Driver     string `mapstructure:"driver"` // supported: "local", "s3", "gcs"
+	Bucket     string `mapstructure:"bucket"`
+	Region     string `mapstructure:"region"`
+	Endpoint   string `mapstructure:"endpoint"` // S3 compatible endpoint, ex: "https://s3.amazonaws.com", "http://minio:9000"
+	AccessKey  string `mapstructure:"access_key"`
+	SecretKey  string `mapstructure:"secret_key"`
+	PathStyle  bool   `mapstructure:"path_style"`  // use endpoint/bucket/key instead of bucket.endpoint/key
+	Root       string `mapstructure:"root"`        // local: directory holding the objects
+	PublicPath string `mapstructure:"public_path"` // local: route serving signed URLs
+	BaseURL    string `mapstructure:"base_url"`    // local: public URL of the application, prepended to signed URLs
+	SigningKey string `mapstructure:"signing_key"` // local: secret used to sign URLs
+}
+
+type MailConfig struct {
+	Driver    string `mapstructure:"driver"` // supported: "smtp", "ses", "sendgrid", "log"
+	From      string `mapstructure:"from"`   // default sender
+	Host      string `mapstructure:"host"`
+	Port      int    `mapstructure:"port"`
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	TLS       string `mapstructure:"tls"` // smtp: "starttls", "tls" (implicit) or "none"
+	APIKey    string `mapstructure:"api_key"`
+	Region    string `mapstructure:"region"`
+	AccessKey string `mapstructure:"access_key"`
+	SecretKey string `mapstructure:"secret_key"`
+	Directory string `mapstructure:"directory"` // log: also write every message as .eml into this directory
+	Queue     string `mapstructure:"queue"`     // job queue used by SendAsync
+}
+
+type NotifyConfig struct {
+	SMS             SMSConfig  `mapstructure:"sms"`
+	Push            PushConfig `mapstructure:"push"`
+	DefaultChannels []string   `mapstructure:"default_channels"` // used when the user has no preference
+	Queue           string     `mapstructure:"queue"`            // job queue used by NotifyAsync
+}
+
+type SMSConfig struct {
+	Driver     string `mapstructure:"driver"` // supported: "twilio"
+	AccountSID string `mapstructure:"account_sid"`
+	AuthToken  string `mapstructure:"auth_token"`
+	From       string `mapstructure:"from"`
+}
+
+type PushConfig struct {
+	Driver      string `mapstructure:"driver"`      // supported: "fcm", "apns"
+	Credentials string `mapstructure:"credentials"` // fcm: service account JSON file
+	ProjectID   string `mapstructure:"project_id"`  // fcm: defaults to the service account project
+	KeyPath     string `mapstructure:"key_path"`    // apns: .p8 signing key
+	KeyID       string `mapstructure:"key_id"`
+	TeamID      string `mapstructure:"team_id"`
+	Topic       string `mapstructure:"topic"` // apns: application bundle id
+	Production  bool   `mapstructure:"production"`
+}
+
+type ViewConfig struct {
+	Directory string `mapstructure:"directory"` // templates of out.Render and the mailer, skipped when missing
+	Layout    string `mapstructure:"layout"`    // default layout of out.Render (ex: "main" for layouts/main.html)
+	Reload    bool   `mapstructure:"reload"`    // re-read templates on every render, always on in development
+}
+
+type ReportConfig struct {
+	PDF      string        `mapstructure:"pdf"`       // supported: "wkhtmltopdf", "chromium"
+	XLSX     string        `mapstructure:"xlsx"`      // supported: "xlsx"
+	Binary   string        `mapstructure:"binary"`    // executable of the PDF driver, looked up in PATH when empty
+	PageSize string        `mapstructure:"page_size"` // default page size of PDF reports
+	Timeout  time.Duration `mapstructure:"timeout"`   // limit of a PDF conversion
+	Queue    string        `mapstructure:"queue"`     // job queue used by RenderAsync
+	Prefix   string        `mapstructure:"prefix"`    // object storage key prefix of reports rendered by RenderAsync
+}
+
+type ImportConfig struct {
+	Queue     string `mapstructure:"queue"`      // job queue running the import tasks, app.tasks must be enabled
+	Prefix    string `mapstructure:"prefix"`     // object storage key prefix of uploaded files and their error reports
+	ChunkSize int    `mapstructure:"chunk_size"` // valid rows passed at once to the import handler
+	MaxSize   int64  `mapstructure:"max_size"`   // largest accepted file in bytes
+	MaxErrors int    `mapstructure:"max_errors"` // rejected rows listed in the result and the error report, the others are only counted
+}
+
+type SearchConfig struct {
+	Driver    string `mapstructure:"driver"` // supported: "elasticsearch", "meilisearch"
+	URL       string `mapstructure:"url"`    // base URL of the search engine (ex: http://localhost:9200)
+	Username  string `mapstructure:"username"`
+	Password  string `mapstructure:"password"`
+	APIKey    string `mapstructure:"api_key"`    // elasticsearch API key or meilisearch master/admin key
+	Prefix    string `mapstructure:"prefix"`     // prepended to every index name (ex: "staging_")
+	Queue     string `mapstructure:"queue"`      // job queue running the synchronization, app.queue must be enabled
+	BatchSize int    `mapstructure:"batch_size"` // documents sent per request by the backfill
+}
+
+type ImageConfig struct {
+	Quality   int                          `mapstructure:"quality"`    // JPEG quality of processed images
+	MaxPixels int                          `mapstructure:"max_pixels"` // bigger images are rejected before decoding
+	Queue     string                       `mapstructure:"queue"`      // job queue of batch processing
+	Prefix    string                       `mapstructure:"prefix"`     // object storage key prefix of ImageProcessor.UploadWriter
+	Presets   map[string]ImagePresetConfig `mapstructure:"presets"`    // variants by name (ex: "thumbnail")
+}
+
+type ImagePresetConfig struct {
+	Width   int    `mapstructure:"width"`   // 0 keeps the aspect ratio from height
+	Height  int    `mapstructure:"height"`  // 0 keeps the aspect ratio from width
+	Mode    string `mapstructure:"mode"`    // "fit" (default), "fill" crops to the box, "resize" stretches
+	Format  string `mapstructure:"format"`  // jpeg, png or gif, defaults to the format of the source
+	Quality int    `mapstructure:"quality"` // defaults to image.quality
+}
+
+type PaymentConfig struct {
+	Driver        string        `mapstructure:"driver"`         // supported: "stripe"
+	APIKey        string        `mapstructure:"api_key"`        // secret key of the provider
+	WebhookSecret string        `mapstructure:"webhook_secret"` // signing secret of the webhook endpoint
+	BaseURL       string        `mapstructure:"base_url"`       // API of a compatible provider or mock, defaults to the provider's
+	Currency      string        `mapstructure:"currency"`       // used when a payment has none
+	Timeout       time.Duration `mapstructure:"timeout"`
+}
+
+type SecretsConfig struct {
+	Driver      string        `mapstructure:"driver"`       // supported: "vault", "aws", "gcp"
+	Address     string        `mapstructure:"address"`      // vault: URL of the server
+	Token       string        `mapstructure:"token"`        // vault: token of the application
+	Namespace   string        `mapstructure:"namespace"`    // vault: namespace of Vault Enterprise
+	Mount       string        `mapstructure:"mount"`        // vault: mount of the KV v2 engine, names starting with "/" are read as is (ex: /database/creds/app)
+	Region      string        `mapstructure:"region"`       // aws
+	AccessKey   string        `mapstructure:"access_key"`   // aws
+	SecretKey   string        `mapstructure:"secret_key"`   // aws
+	ProjectID   string        `mapstructure:"project_id"`   // gcp, defaults to the project of the credentials
+	Credentials string        `mapstructure:"credentials"`  // gcp: JSON file of the service account
+	Endpoint    string        `mapstructure:"endpoint"`     // aws and gcp: API of a compatible service or mock
+	Timeout     time.Duration `mapstructure:"timeout"`      // of the calls to the secrets manager
+	Refresh     time.Duration `mapstructure:"refresh"`      // interval of the checks for rotated secrets
+	RenewBefore time.Duration `mapstructure:"renew_before"` // leases are renewed this long before they expire
+	FieldKey    string        `mapstructure:"field_key"`    // secret holding the base64 AES-256 key of the encrypted fields
+	SigningKey  string        `mapstructure:"signing_key"`  // secret holding the base64 key (32 bytes or more) of the signed values
+}
+
+type HTTPClientConfig struct {
+	BaseURL         string              `mapstructure:"base_url"`
+	Timeout         time.Duration       `mapstructure:"timeout"` // per attempt, defaults to 30s
+	Headers         map[string]string   `mapstructure:"headers"` // sent with every request
+	MaxIdleConns    int                 `mapstructure:"max_idle_conns"`
+	MaxConnsPerHost int                 `mapstructure:"max_conns_per_host"` // 0 for no limit
+	IdleConnTimeout time.Duration       `mapstructure:"idle_conn_timeout"`
+	Retry           HTTPRetryConfig     `mapstructure:"retry"`
+	Breaker         BreakerConfig       `mapstructure:"breaker"`
+	RateLimit       HTTPRateLimitConfig `mapstructure:"rate_limit"`
+	Batch           HTTPBatchConfig     `mapstructure:"batch"`
+}
+
+type HTTPRateLimitConfig struct {
+	Rate    float64       `mapstructure:"rate"`     // requests per second allowed by the partner for this instance, 0 for no limit
+	Burst   int           `mapstructure:"burst"`    // requests sent at once after an idle period, defaults to 1
+	MaxWait time.Duration `mapstructure:"max_wait"` // longest wait for a slot, longer waits fail with ErrRateLimited or spill to the queue, defaults to 10s
+	Queue   string        `mapstructure:"queue"`    // job queue receiving the calls of HTTPClient.Send spilled by the rate limit, app.queue must be enabled
+}
+
+type HTTPBatchConfig struct {
+	MaxSize int           `mapstructure:"max_size"` // keys sent per call by a Batcher, 0 for no limit
+	Wait    time.Duration `mapstructure:"wait"`     // time collecting keys before the call, defaults to 10ms
+}
+
+type HTTPRetryConfig struct {
+	MaxAttempts   int           `mapstructure:"max_attempts"` // 1 disables retries, defaults to 3
+	Backoff       time.Duration `mapstructure:"backoff"`      // delay before the first retry, doubled on each attempt
+	MaxBackoff    time.Duration `mapstructure:"max_backoff"`
+	NonIdempotent bool          `mapstructure:"non_idempotent"` // also retry POST/PATCH without Idempotency-Key
+	Policy        string        `mapstructure:"policy"`         // policy of app.retry.policies replacing max_attempts, backoff and max_backoff
+}
+
+type BreakerConfig struct {
+	Threshold int           `mapstructure:"threshold"` // consecutive failures opening the circuit, -1 disables, defaults to 5
+	Cooldown  time.Duration `mapstructure:"cooldown"`  // time the circuit stays open before a trial request
+}
+
+type TransferConfig struct {
+	Driver      string              `mapstructure:"driver"` // supported: "sftp", "ftp", "ftps"
+	Host        string              `mapstructure:"host"`
+	Port        int                 `mapstructure:"port"` // defaults to 22 for sftp, 21 for ftp and ftps, 990 with implicit_tls
+	Username    string              `mapstructure:"username"`
+	Password    string              `mapstructure:"password"`     // ftp and ftps only, sftp authenticates with private_key
+	PrivateKey  string              `mapstructure:"private_key"`  // sftp: path of the identity file
+	KnownHosts  string              `mapstructure:"known_hosts"`  // sftp: host keys checked strictly, defaults to the user's known_hosts
+	Binary      string              `mapstructure:"binary"`       // sftp: executable of the OpenSSH client, looked up in PATH when empty
+	ImplicitTLS bool                `mapstructure:"implicit_tls"` // ftps: TLS from the first byte instead of AUTH TLS
+	Insecure    bool                `mapstructure:"insecure"`     // ftps: skip the verification of the server certificate
+	Root        string              `mapstructure:"root"`         // remote directory paths are relative to
+	Timeout     time.Duration       `mapstructure:"timeout"`      // connect and idle limit, defaults to 30s
+	Retry       TransferRetryConfig `mapstructure:"retry"`
+}
+
+type TransferRetryConfig struct {
+	MaxAttempts int           `mapstructure:"max_attempts"` // 1 disables retries, defaults to 3
+	Backoff     time.Duration `mapstructure:"backoff"`      // delay before the first retry, doubled on each attempt
+	MaxBackoff  time.Duration `mapstructure:"max_backoff"`
+}
+
 type GoogleCredential struct {
 	Type                    string `mapstructure:"type" json:"type"`
 	ProjectID               string `mapstructure:"project_id" json:"project_id"`
@@ -190,6 +868,28 @@ type ModuleConfig struct {
 	BasePath string   `mapstructure:"base_path"`
 }
 
+// ModuleSwitch is read from the section module.<name> of a module, next to its own configuration
+type ModuleSwitch struct {
+	Enabled *bool `mapstructure:"enabled"` // nil keeps the module enabled
+}
+
+// ModuleEnabled reports whether the module name is enabled by module.<name>.enabled, true when
+// the key is not set. MODULE_<NAME>_ENABLED, the name upper-cased with its dashes as
+// underscores, overrides the file.
+func (c *Config) ModuleEnabled(name string) bool {
+	env := "MODULE_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_ENABLED"
+	if value, ok := os.LookupEnv(env); ok {
+		if enabled, err := strconv.ParseBool(value); err == nil {
+			return enabled
+		}
+	}
+
+	if toggle, ok := c.Modules[name]; ok && toggle.Enabled != nil {
+		return *toggle.Enabled
+	}
+	return true
+}
+
 func (c *Config) GetOthers() map[string]ConfigObject {
 	return c.Others
 }
@@ -207,6 +907,102 @@ func (c *Config) GetOtherItem(key string) (ConfigObject, bool) {
 	return item, ok
 }
 
+// Section returns the value of the section path of the configuration, its keys joined with dots
+// like in the configuration file (ex: "kafka", "app.geoip"), or the item of Others named path
+func (c *Config) Section(path string) (any, bool) {
+	if item, ok := c.GetOtherItem(path); ok {
+		return item, true
+	}
+
+	v := reflect.ValueOf(*c)
+	for _, key := range strings.Split(path, ".") {
+		switch v.Kind() {
+		case reflect.Struct:
+			field, ok := sectionField(v, key)
+			if !ok {
+				return nil, false
+			}
+			v = field
+		case reflect.Map:
+			if v.Type().Key().Kind() != reflect.String {
+				return nil, false
+			}
+			v = v.MapIndex(reflect.ValueOf(key))
+			if !v.IsValid() {
+				return nil, false
+			}
+		default:
+			return nil, false
+		}
+	}
+	return v.Interface(), true
+}
+
+// InstanceSection returns the configuration of the instance key of the section path, declared
+// in its instances map (ex: "database.instances.analytics"): the section with the fields set
+// for the instance in place of its own. False when the section has no such instance.
+func (c *Config) InstanceSection(path string, key string) (any, bool) {
+	section, ok := c.Section(path)
+	if !ok {
+		return nil, false
+	}
+
+	v := reflect.ValueOf(section)
+	pointer := v.Kind() == reflect.Pointer
+	if pointer {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+
+	instances, ok := sectionField(v, "instances")
+	if !ok || instances.Kind() != reflect.Map || instances.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	instance := instances.MapIndex(reflect.ValueOf(key))
+	if instance.Kind() == reflect.Pointer && !instance.IsNil() {
+		instance = instance.Elem()
+	}
+	if !instance.IsValid() || instance.Type() != v.Type() {
+		return nil, false
+	}
+
+	merged := reflect.New(v.Type()).Elem()
+	merged.Set(v)
+	for i := 0; i < instance.NumField(); i++ {
+		if merged.Type().Field(i).IsExported() && !instance.Field(i).IsZero() {
+			merged.Field(i).Set(instance.Field(i))
+		}
+	}
+	if field, ok := sectionField(merged, "instances"); ok {
+		field.SetZero()
+	}
+
+	if pointer {
+		return merged.Addr().Interface(), true
+	}
+	return merged.Interface(), true
+}
+
+// sectionField returns the field of v whose mapstructure key is key
+func sectionField(v reflect.Value, key string) (reflect.Value, bool) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
 func (c *Config) GetFiberConfig(errorHandler fiber.ErrorHandler) fiber.Config {
 	return fiber.Config{
 		ReadTimeout:   c.Server.ReadTimeout,
@@ -14,12 +14,33 @@ func (c *Config) SetDefaults() map[string]any {
 		"app.logging.level":                   "info",
 		"app.logging.format":                  "json",
 		"app.logging.output":                  "stdout",
+		"app.logging.backend":                 "",
 		"app.logging.remote.driver":           "sentry",
 		"app.logging.remote.uri":              "",
 		"app.logging.remote.sample_rate":      1.0,
 		"app.logging.remote.structured":       true,
 		"app.logging.remote.default_tags":     map[string]string{},
 		"app.logging.remote.default_contexts": map[string]map[string]any{},
+		"app.access_log.enabled":              false,
+		"app.access_log.sample_rate":          1.0,
+		"app.access_log.routes":               map[string]float64{},
+		"app.access_log.keep_errors":          true,
+		"app.access_log.body":                 false,
+		"app.access_log.max_body":             4096,
+		"app.access_log.redact":               []string{"password", "token", "access_token", "refresh_token", "secret", "authorization"},
+		"app.metrics.enabled":                 false,
+		"app.metrics.path":                    "/metrics",
+		"app.metrics.buckets":                 []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		"app.tracing.enabled":                 false,
+		"app.tracing.exporter":                "otlp",
+		"app.tracing.endpoint":                "http://localhost:4318",
+		"app.tracing.headers":                 map[string]string{},
+		"app.tracing.service_name":            "",
+		"app.tracing.sample_rate":             1.0,
+		"app.tracing.batch_size":              512,
+		"app.tracing.queue_size":              2048,
+		"app.tracing.flush_interval":          "5s",
+		"app.tracing.timeout":                 "10s",
 		"app.security_headers":                false,
 		"app.additional_headers":              []string{},
 		"app.cors.allow_origins":              []string{"*"},
@@ -30,15 +51,183 @@ func (c *Config) SetDefaults() map[string]any {
 		"app.cors.max_age":                    "24h", // 24 hours
 		"app.rate_limit.enabled":              false,
 		"app.rate_limit.max":                  1000,
+		"app.geoip.enabled":                   false,
+		"app.geoip.provider":                  "maxmind",
+		"app.geoip.database":                  "",
+		"app.geoip.asn_database":              "",
+		"app.geoip.language":                  "en",
+		"app.compression.enabled":             false,
+		"app.compression.level":               0,
+		"app.etag.enabled":                    false,
+		"app.etag.weak":                       false,
+		"app.openapi.spec":                    "",
+		"app.openapi.validate_request":        false,
+		"app.openapi.validate_response":       false,
+		"app.openapi.serve":                   false,
+		"app.openapi.ui":                      false,
+		"app.scheduler.enabled":               false,
+		"app.scheduler.locker":                "",
+		"app.admin.enabled":                   false,
+		"app.admin.path":                      "/admin",
+		"app.admin.roles":                     []string{"admin"},
+		"app.queue.enabled":                   false,
+		"app.queue.store":                     "",
+		"app.queue.workers":                   map[string]int{"default": 4},
+		"app.queue.poll_interval":             "1s",
+		"app.queue.lease":                     "10m",
+		"app.queue.max_attempts":              5,
+		"app.queue.backoff":                   "5s",
+		"app.queue.max_backoff":               "1h",
+		"app.graphql.enabled":                 false,
+		"app.graphql.path":                    "/graphql",
+		"app.graphql.max_depth":               10,
+		"app.graphql.max_complexity":          1000,
+		"app.hub.enabled":                     false,
+		"app.hub.path":                        "/ws",
+		"app.hub.broker":                      "",
+		"app.hub.topic":                       "webcore:hub",
+		"app.hub.presence_ttl":                "1m",
+		"app.hub.ping_interval":               "30s",
+		"app.hub.read_limit":                  65536,
+		"app.hub.origins":                     []string{},
+		"app.webhooks.enabled":                false,
+		"app.webhooks.path":                   "/webhooks",
+		"app.webhooks.queue":                  "default",
+		"app.webhooks.dedup_ttl":              "24h",
+		"app.webhooks.locker":                 "",
+		"app.tasks.enabled":                   false,
+		"app.tasks.path":                      "/tasks",
+		"app.tasks.store":                     "",
+		"app.tasks.ttl":                       "24h",
+		"app.tasks.queue":                     "default",
+		"app.grpc.enabled":                    false,
+		"app.grpc.port":                       9090,
+		"app.grpc.reflection":                 false,
+		"app.discovery.enabled":               false,
+		"app.discovery.backend":               "consul",
+		"app.discovery.server":                "http://127.0.0.1:8500",
+		"app.discovery.token":                 "",
+		"app.discovery.name":                  "",
+		"app.discovery.id":                    "",
+		"app.discovery.address":               "",
+		"app.discovery.port":                  0,
+		"app.discovery.ttl":                   "15s",
+		"app.discovery.deregister_after":      "1m",
+		"app.dev_tail.enabled":                false,
+		"app.dev_tail.path":                   "/dev/tail",
+		"app.dev_tail.buffer":                 500,
+		"app.retention.enabled":               false,
+		"app.retention.schedule":              "0 3 * * *",
+		"app.retention.dry_run":               false,
+		"app.retention.batch_size":            1000,
+		"app.retention.pause":                 "1s",
+		"app.retention.max_deletes":           0,
+		"app.retention.history":               100,
+		"app.saga.enabled":                    false,
+		"app.saga.store":                      "",
+		"app.saga.lease":                      "2m",
+		"app.saga.poll_interval":              "15s",
+		"app.saga.step_timeout":               "30s",
+		"app.saga.max_attempts":               3,
+		"app.saga.backoff":                    "1s",
+		"app.saga.max_backoff":                "30s",
+		"app.flags.enabled":                   false,
+		"app.flags.provider":                  "",
+		"app.flags.file":                      "flags.json",
+		"app.flags.table":                     "feature_flags",
+		"app.flags.key":                       "feature_flags",
+		"app.flags.refresh":                   "30s",
+		"app.flags.tenant_header":             "X-Tenant-ID",
+		"app.tenancy.enabled":                 false,
+		"app.tenancy.resolvers":               []string{"header"},
+		"app.tenancy.header":                  "X-Tenant-ID",
+		"app.tenancy.domain":                  "",
+		"app.tenancy.required":                false,
+		"app.tenancy.platform":                []string{},
+		"app.tenancy.store":                   "",
+		"app.deprecation.enforce":             false,
+		"app.deprecation.max_clients":         1000,
+		"app.versioning.default":              "",
+		"app.usage.enabled":                   false,
+		"app.usage.store":                     "",
+		"app.usage.windows":                   []string{"1h", "24h"},
+		"app.usage.flush_interval":            "10s",
+		"app.usage.retention":                 "168h",
+		"app.privacy.enabled":                 false,
+		"app.privacy.prefix":                  "privacy",
+		"app.privacy.queue":                   "",
+		"app.privacy.link_expiry":             "24h",
+		"app.privacy.history":                 100,
+		"app.canary.header":                   "X-Canary",
+		"app.i18n.enabled":                    false,
+		"app.i18n.default":                    "en",
+		"app.i18n.locales":                    []string{},
+		"app.i18n.query":                      "locale",
+		"app.masking.enabled":                 false,
+		"app.client.enabled":                  false,
+		"app.client.path":                     "/client",
+		"app.client.channel":                  "client",
+		"app.client.flags":                    []string{},
+		"app.chaos.enabled":                   false,
+		"app.chaos.environments":              []string{"development"},
+		"app.console.enabled":                 false,
+		"app.console.socket":                  "console.sock",
+		"app.event_store.enabled":             false,
+		"app.event_store.store":               "",
+		"app.event_store.snapshot_every":      100,
+		"app.event_store.publish":             false,
+		"app.outbox.enabled":                  false,
+		"app.outbox.table":                    "outbox",
+		"app.outbox.broker":                   "pubsub",
+		"app.outbox.topics":                   map[string]string{},
+		"app.outbox.interval":                 "5s",
+		"app.outbox.batch_size":               100,
+		"app.outbox.lease":                    "1m",
+		"app.outbox.max_attempts":             10,
+		"app.outbox.backoff":                  "5s",
+		"app.outbox.max_backoff":              "10m",
+		"app.capture.enabled":                 false,
+		"app.capture.sample":                  0.01,
+		"app.capture.paths":                   []string{},
+		"app.capture.prefix":                  "captures/",
+		"app.capture.max_body":                65536,
+		"app.capture.redact_headers":          []string{},
+		"app.quotas.enabled":                  false,
+		"app.quotas.store":                    "",
+		"app.quotas.path":                     "/quotas",
+		"app.upgrades.drain_timeout":          "30s",
+		"app.upgrades.delay":                  "0s",
+		"app.libraries.init_timeout":          "1m",
+		"app.libraries.debug":                 false,
+		"app.libraries.request_scope":         false,
+		"app.libraries.optional":              []string{},
+		"app.health.timeout":                  "5s",
+		"app.static.enabled":                  false,
+		"app.static.path":                     "/",
+		"app.static.root":                     "./public",
+		"app.static.index":                    "index.html",
+		"app.static.spa":                      false,
+		"app.static.max_age":                  "0s",
+		"app.static.immutable":                false,
+		"app.static.precompressed":            false,
+		"app.pagination.default_per_page":     20,
+		"app.pagination.max_per_page":         100,
+		"app.problem_details.enabled":         false,
+		"app.problem_details.type_base":       "",
+		"app.formats":                         []string{"json"},
+		"app.startup_check":                   false,
 		"app.module.base_path":                "./libs",
 		"app.module.disabled":                 []string{},
 
 		// Server
-		"server.host":          "0.0.0.0",
-		"server.port":          7272,
-		"server.path":          "/api",
-		"server.read_timeout":  "30s",
-		"server.write_timeout": "30s",
+		"server.host":             "0.0.0.0",
+		"server.port":             7272,
+		"server.path":             "/api",
+		"server.read_timeout":     "30s",
+		"server.write_timeout":    "30s",
+		"server.shutdown_timeout": "30s",
+		"server.tls_cert":         "",
+		"server.tls_key":          "",
 
 		// Auth
 		"auth.directory":            ".",
@@ -105,5 +294,91 @@ func (c *Config) SetDefaults() map[string]any {
 		"pubsub.producer.enableordering": false,
 		"pubsub.producer.batchsize":      100,
 		"pubsub.producer.attributes":     make(map[string]string),
+
+		// Storage
+		"storage.driver":      "",
+		"storage.bucket":      "",
+		"storage.region":      "us-east-1",
+		"storage.endpoint":    "",
+		"storage.path_style":  false,
+		"storage.root":        "./storage",
+		"storage.public_path": "/storage",
+		"storage.base_url":    "",
+		"storage.signing_key": "",
+
+		// Mail
+		"mail.driver":    "",
+		"mail.from":      "",
+		"mail.host":      "",
+		"mail.port":      587,
+		"mail.tls":       "starttls",
+		"mail.region":    "us-east-1",
+		"mail.directory": "",
+		"mail.queue":     "default",
+
+		// Notify
+		"notify.sms.driver":       "",
+		"notify.push.driver":      "",
+		"notify.push.production":  false,
+		"notify.default_channels": []string{"push", "sms", "email"},
+		"notify.queue":            "default",
+
+		// View
+		"view.directory": "./views",
+		"view.layout":    "",
+		"view.reload":    false,
+
+		// Report
+		"report.pdf":       "",
+		"report.xlsx":      "",
+		"report.binary":    "",
+		"report.page_size": "A4",
+		"report.timeout":   "1m",
+		"report.queue":     "default",
+		"report.prefix":    "reports",
+
+		// Import
+		"import.queue":      "default",
+		"import.prefix":     "imports",
+		"import.chunk_size": 500,
+		"import.max_size":   20971520,
+		"import.max_errors": 1000,
+
+		// Search
+		"search.driver":     "",
+		"search.url":        "",
+		"search.username":   "",
+		"search.password":   "",
+		"search.api_key":    "",
+		"search.prefix":     "",
+		"search.queue":      "default",
+		"search.batch_size": 500,
+
+		// Image
+		"image.quality":    85,
+		"image.max_pixels": 50000000,
+		"image.queue":      "default",
+		"image.prefix":     "images",
+		"image.presets": map[string]any{
+			"thumbnail": map[string]any{"width": 200, "height": 200, "mode": "fill"},
+		},
+
+		// Payment
+		"payment.driver":         "",
+		"payment.api_key":        "",
+		"payment.webhook_secret": "",
+		"payment.base_url":       "",
+		"payment.currency":       "usd",
+		"payment.timeout":        "30s",
+
+		// Secrets
+		"secrets.driver":       "",
+		"secrets.mount":        "secret",
+		"secrets.region":       "us-east-1",
+		"secrets.timeout":      "10s",
+		"secrets.refresh":      "5m",
+		"secrets.renew_before": "1m",
+		"secrets.field_key":    "",
+		"secrets.signing_key":  "",
 	}
 }
@@ -159,7 +159,7 @@ func LoadConfig[T ConfigObject](prefix string, c T, file string, ext string, pat
 		}
 	}
 
-	return nil
+	return ResolveSecrets(c)
 }
 
 func getKeyPrefix(prefix string, ismodule bool) string {
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// SecretPrefix marks the values read from the secrets manager instead of the configuration:
+// "secret://db" is the secret db, "secret://db#password" its field password
+const SecretPrefix = "secret://"
+
+var (
+	secretMu       sync.RWMutex
+	secretResolver func(ref string) (string, error)
+)
+
+// SetSecretResolver sets the function reading the references of SecretPrefix (without the
+// prefix), the configurations loaded afterwards are resolved with it
+func SetSecretResolver(resolve func(ref string) (string, error)) {
+	secretMu.Lock()
+	defer secretMu.Unlock()
+	secretResolver = resolve
+}
+
+// ResolveSecrets replaces the references of SecretPrefix in the strings of c, a pointer, with
+// the secrets they name. It does nothing without resolver.
+func ResolveSecrets(c any) error {
+	secretMu.RLock()
+	resolve := secretResolver
+	secretMu.RUnlock()
+
+	if resolve == nil {
+		return nil
+	}
+	return resolveValue(reflect.ValueOf(c), resolve)
+}
+
+func resolveValue(v reflect.Value, resolve func(ref string) (string, error)) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+		return resolveValue(v.Elem(), resolve)
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		// the value of an interface is not addressable, resolve a copy and put it back
+		elem := v.Elem()
+		if elem.Kind() == reflect.Pointer {
+			return resolveValue(elem, resolve)
+		}
+		value := reflect.New(elem.Type()).Elem()
+		value.Set(elem)
+		if err := resolveValue(value, resolve); err != nil {
+			return err
+		}
+		if v.CanSet() {
+			v.Set(value)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Type().Field(i).IsExported() {
+				continue
+			}
+			if err := resolveValue(v.Field(i), resolve); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			value := reflect.New(v.Type().Elem()).Elem()
+			value.Set(v.MapIndex(key))
+			if err := resolveValue(value, resolve); err != nil {
+				return err
+			}
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), resolve); err != nil {
+				return err
+			}
+		}
+	case reflect.String:
+		ref, ok := strings.CutPrefix(v.String(), SecretPrefix)
+		if !ok || !v.CanSet() {
+			return nil
+		}
+		value, err := resolve(ref)
+		if err != nil {
+			return fmt.Errorf("%s%s: %v", SecretPrefix, ref, err)
+		}
+		v.SetString(value)
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package graphql
+
+// Document is a parsed request: operations and fragments
+type Document struct {
+	Operations []*Operation
+	Fragments  map[string]*FragmentDef
+}
+
+type Operation struct {
+	Type         string // query, mutation or subscription
+	Name         string
+	Variables    []*VariableDef
+	Directives   []*Directive
+	SelectionSet []Selection
+	Loc          Location
+}
+
+type VariableDef struct {
+	Name       string
+	Type       *TypeRef
+	Default    any
+	HasDefault bool
+}
+
+// Selection is a *Field, *FragmentSpread or *InlineFragment
+type Selection interface{}
+
+type Field struct {
+	Alias        string
+	Name         string
+	Arguments    []*Argument
+	Directives   []*Directive
+	SelectionSet []Selection
+	Loc          Location
+}
+
+// ResponseKey is the key of the field in the result, the alias when there is one
+func (f *Field) ResponseKey() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+type FragmentSpread struct {
+	Name       string
+	Directives []*Directive
+	Loc        Location
+}
+
+type InlineFragment struct {
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+type FragmentDef struct {
+	Name          string
+	TypeCondition string
+	Directives    []*Directive
+	SelectionSet  []Selection
+	Loc           Location
+}
+
+type Argument struct {
+	Name  string
+	Value any
+}
+
+type Directive struct {
+	Name      string
+	Arguments []*Argument
+}
+
+// Argument returns the literal value of an argument of the directive
+func (d *Directive) Argument(name string) (any, bool) {
+	for _, argument := range d.Arguments {
+		if argument.Name == name {
+			return argument.Value, true
+		}
+	}
+	return nil, false
+}
+
+// TypeRef is a named type, or a list when Elem is set
+type TypeRef struct {
+	Name    string
+	Elem    *TypeRef
+	NonNull bool
+}
+
+func (t *TypeRef) String() string {
+	s := t.Name
+	if t.Elem != nil {
+		s = "[" + t.Elem.String() + "]"
+	}
+	if t.NonNull {
+		s += "!"
+	}
+	return s
+}
+
+// NamedType returns the innermost type name of lists
+func (t *TypeRef) NamedType() string {
+	for t.Elem != nil {
+		t = t.Elem
+	}
+	return t.Name
+}
+
+// Literal values are nil, bool, int64, float64, string, EnumValue, Variable, []any and map[string]any
+type (
+	EnumValue string
+	Variable  string
+)
+
+// Kinds of type definitions
+const (
+	KindScalar = "SCALAR"
+	KindObject = "OBJECT"
+	KindInput  = "INPUT_OBJECT"
+	KindEnum   = "ENUM"
+)
+
+// SchemaDocument is a parsed schema fragment (SDL)
+type SchemaDocument struct {
+	Types []*TypeDef
+}
+
+type TypeDef struct {
+	Kind        string
+	Name        string
+	Description string
+	Fields      []*FieldDef      // object
+	InputFields []*InputValueDef // input object
+	Values      []string         // enum
+	Extend      bool
+}
+
+// Field returns the definition of a field of an object type
+func (t *TypeDef) Field(name string) *FieldDef {
+	for _, field := range t.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+type FieldDef struct {
+	Name        string
+	Description string
+	Args        []*InputValueDef
+	Type        *TypeRef
+	Directives  []*Directive
+}
+
+// Directive returns the directive applied to the field definition
+func (f *FieldDef) Directive(name string) *Directive {
+	for _, directive := range f.Directives {
+		if directive.Name == name {
+			return directive
+		}
+	}
+	return nil
+}
+
+type InputValueDef struct {
+	Name        string
+	Description string
+	Type        *TypeRef
+	Default     any
+	HasDefault  bool
+}
@@ -0,0 +1,692 @@
+package graphql
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/goccy/go-json"
+)
+
+// Error is a GraphQL error as returned in the "errors" of a response
+type Error struct {
+	Message    string         `json:"message"`
+	Locations  []Location     `json:"locations,omitempty"`
+	Path       []any          `json:"path,omitempty"`
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Request is the body of a GraphQL HTTP request
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Result is the response of a request, Data is absent when the request was invalid
+// and null when a non-null root field failed
+type Result struct {
+	Data   any      `json:"data,omitempty"`
+	Errors []*Error `json:"errors,omitempty"`
+}
+
+// Options limits and secures the execution of a request
+type Options struct {
+	MaxDepth      int // 0 for no limit
+	MaxComplexity int // 0 for no limit, every field costs 1 and list arguments first/last/limit multiply their children
+	// Authorize is called before resolving a field whose definition has the @auth directive,
+	// the field is null with an error when it fails. Fields with @auth are denied when nil.
+	Authorize func(ctx context.Context, parent *TypeDef, field *FieldDef, directive *Directive) error
+}
+
+// Execute runs a request against the schema
+func (s *Schema) Execute(ctx context.Context, request Request, opts Options) *Result {
+	doc, err := Parse(request.Query)
+	if err != nil {
+		return &Result{Errors: []*Error{asError(err)}}
+	}
+
+	operation, err := selectOperation(doc, request.OperationName)
+	if err != nil {
+		return &Result{Errors: []*Error{asError(err)}}
+	}
+
+	var root *TypeDef
+	switch operation.Type {
+	case "query":
+		root = s.Types["Query"]
+	case "mutation":
+		root = s.Types["Mutation"]
+		if root == nil {
+			return &Result{Errors: []*Error{{Message: "Schema is not configured for mutations", Locations: []Location{operation.Loc}}}}
+		}
+	default:
+		return &Result{Errors: []*Error{{Message: "Subscriptions are not supported", Locations: []Location{operation.Loc}}}}
+	}
+
+	variables, errs := s.coerceVariables(operation, request.Variables)
+	if len(errs) > 0 {
+		return &Result{Errors: errs}
+	}
+
+	v := &validator{schema: s, doc: doc, operation: operation, variables: variables, opts: opts, visiting: map[string]bool{}}
+	complexity := v.selections(operation.SelectionSet, root, 1)
+	if opts.MaxComplexity > 0 && complexity > opts.MaxComplexity {
+		v.errorf(operation.Loc, "Query complexity %d exceeds the maximum of %d", complexity, opts.MaxComplexity)
+	}
+	if len(v.errors) > 0 {
+		return &Result{Errors: v.errors}
+	}
+
+	e := &execution{schema: s, doc: doc, variables: variables, opts: opts}
+	ctx = withLoaders(ctx)
+	data, _ := e.selectionSet(ctx, operation.SelectionSet, root, nil, nil, operation.Type == "mutation")
+
+	result := &Result{Data: json.RawMessage("null"), Errors: e.errors}
+	if data != nil {
+		result.Data = data
+	}
+	return result
+}
+
+func asError(err error) *Error {
+	if gqlErr, ok := err.(*Error); ok {
+		return gqlErr
+	}
+	return &Error{Message: err.Error()}
+}
+
+func selectOperation(doc *Document, name string) (*Operation, error) {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return nil, &Error{Message: "Must provide operation name if query contains multiple operations"}
+		}
+		return doc.Operations[0], nil
+	}
+
+	for _, operation := range doc.Operations {
+		if operation.Name == name {
+			return operation, nil
+		}
+	}
+	return nil, &Error{Message: fmt.Sprintf("Unknown operation named %q", name)}
+}
+
+// IsMutation reports whether the operation the request runs is a mutation,
+// invalid requests are left to Execute to report
+func IsMutation(request Request) bool {
+	doc, err := Parse(request.Query)
+	if err != nil {
+		return false
+	}
+	operation, err := selectOperation(doc, request.OperationName)
+	return err == nil && operation.Type == "mutation"
+}
+
+func (s *Schema) coerceVariables(operation *Operation, values map[string]any) (map[string]any, []*Error) {
+	result := make(map[string]any, len(operation.Variables))
+	errs := []*Error{}
+	for _, def := range operation.Variables {
+		typ, ok := s.Types[def.Type.NamedType()]
+		if !ok || typ.Kind == KindObject {
+			errs = append(errs, &Error{Message: fmt.Sprintf("Variable $%s cannot be of type %s", def.Name, def.Type)})
+			continue
+		}
+
+		raw, present := values[def.Name]
+		literal := false
+		if !present && def.HasDefault {
+			raw, present, literal = def.Default, true, true
+		}
+		if !present {
+			if def.Type.NonNull {
+				errs = append(errs, &Error{Message: fmt.Sprintf("Variable $%s of required type %s was not provided", def.Name, def.Type)})
+			}
+			continue
+		}
+
+		value, err := s.coerceInput(raw, def.Type, nil, literal, "$"+def.Name)
+		if err != nil {
+			errs = append(errs, &Error{Message: "Variable " + err.Error()})
+			continue
+		}
+		result[def.Name] = value
+	}
+	return result, errs
+}
+
+// validator checks the selections against the schema and computes depth and complexity
+type validator struct {
+	schema    *Schema
+	doc       *Document
+	operation *Operation
+	variables map[string]any
+	opts      Options
+	visiting  map[string]bool
+	errors    []*Error
+	tooDeep   bool
+}
+
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errors = append(v.errors, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+func (v *validator) selections(set []Selection, typ *TypeDef, depth int) int {
+	complexity := 0
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *Field:
+			complexity += v.field(sel, typ, depth)
+		case *FragmentSpread:
+			v.directives(sel.Directives, sel.Loc)
+			fragment, ok := v.doc.Fragments[sel.Name]
+			if !ok {
+				v.errorf(sel.Loc, "Unknown fragment %q", sel.Name)
+				continue
+			}
+			if v.visiting[sel.Name] {
+				v.errorf(sel.Loc, "Cannot spread fragment %q within itself", sel.Name)
+				continue
+			}
+			if fragment.TypeCondition != typ.Name {
+				v.errorf(sel.Loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q", sel.Name, typ.Name, fragment.TypeCondition)
+				continue
+			}
+			v.visiting[sel.Name] = true
+			complexity += v.selections(fragment.SelectionSet, typ, depth)
+			delete(v.visiting, sel.Name)
+		case *InlineFragment:
+			v.directives(sel.Directives, sel.Loc)
+			if sel.TypeCondition != "" && sel.TypeCondition != typ.Name {
+				v.errorf(sel.Loc, "Fragment cannot be spread here as objects of type %q can never be of type %q", typ.Name, sel.TypeCondition)
+				continue
+			}
+			complexity += v.selections(sel.SelectionSet, typ, depth)
+		}
+	}
+	return complexity
+}
+
+func (v *validator) field(field *Field, typ *TypeDef, depth int) int {
+	v.directives(field.Directives, field.Loc)
+
+	if v.opts.MaxDepth > 0 && depth > v.opts.MaxDepth && !v.tooDeep {
+		v.tooDeep = true
+		v.errorf(field.Loc, "Query depth exceeds the maximum of %d", v.opts.MaxDepth)
+	}
+
+	if field.Name == "__typename" {
+		if len(field.SelectionSet) > 0 {
+			v.errorf(field.Loc, "Field \"__typename\" must not have a selection")
+		}
+		return 0
+	}
+
+	def := typ.Field(field.Name)
+	if def == nil {
+		v.errorf(field.Loc, "Cannot query field %q on type %q", field.Name, typ.Name)
+		return 0
+	}
+
+	args := v.arguments(field, def)
+
+	output := v.schema.Types[def.Type.NamedType()]
+	if output.Kind == KindObject {
+		if len(field.SelectionSet) == 0 {
+			v.errorf(field.Loc, "Field %q of type %q must have a selection of subfields", field.Name, def.Type)
+			return 1
+		}
+	} else if len(field.SelectionSet) > 0 {
+		v.errorf(field.Loc, "Field %q must not have a selection since type %q has no subfields", field.Name, def.Type)
+		return 1
+	}
+
+	children := v.selections(field.SelectionSet, output, depth+1)
+
+	// list sizes requested by the client scale the cost of the children
+	multiplier := 1
+	for _, name := range []string{"first", "last", "limit"} {
+		if n, ok := args[name].(int); ok && n > 1 {
+			multiplier = n
+			break
+		}
+	}
+	return 1 + children*multiplier
+}
+
+func (v *validator) arguments(field *Field, def *FieldDef) map[string]any {
+	for _, argument := range field.Arguments {
+		if !slicesContainsInput(def.Args, argument.Name) {
+			v.errorf(field.Loc, "Unknown argument %q on field %q", argument.Name, field.Name)
+		}
+		v.checkVariables(argument.Value, field.Loc)
+	}
+
+	args, err := v.schema.coerceArguments(def.Args, field.Arguments, v.variables)
+	if err != nil {
+		v.errorf(field.Loc, "Argument %s", err.Error())
+	}
+	return args
+}
+
+func (v *validator) directives(directives []*Directive, loc Location) {
+	for _, directive := range directives {
+		if directive.Name != "include" && directive.Name != "skip" {
+			v.errorf(loc, "Unknown directive \"@%s\"", directive.Name)
+			continue
+		}
+		if _, ok := directive.Argument("if"); !ok {
+			v.errorf(loc, "Directive \"@%s\" argument \"if\" of type \"Boolean!\" is required", directive.Name)
+		}
+		for _, argument := range directive.Arguments {
+			v.checkVariables(argument.Value, loc)
+		}
+	}
+}
+
+// checkVariables reports the variables used in a value but not defined by the operation
+func (v *validator) checkVariables(value any, loc Location) {
+	switch val := value.(type) {
+	case Variable:
+		if !slices.ContainsFunc(v.operation.Variables, func(def *VariableDef) bool { return def.Name == string(val) }) {
+			v.errorf(loc, "Variable \"$%s\" is not defined", val)
+		}
+	case []any:
+		for _, item := range val {
+			v.checkVariables(item, loc)
+		}
+	case map[string]any:
+		for _, item := range val {
+			v.checkVariables(item, loc)
+		}
+	}
+}
+
+func (s *Schema) coerceArguments(defs []*InputValueDef, arguments []*Argument, variables map[string]any) (map[string]any, error) {
+	args := make(map[string]any, len(defs))
+	for _, def := range defs {
+		var raw any
+		present := false
+		for _, argument := range arguments {
+			if argument.Name == def.Name {
+				raw, present = argument.Value, true
+				break
+			}
+		}
+		if variable, ok := raw.(Variable); ok && present {
+			if _, provided := variables[string(variable)]; !provided {
+				present = false
+			}
+		}
+		if !present && def.HasDefault {
+			raw, present = def.Default, true
+		}
+		if !present {
+			if def.Type.NonNull {
+				return nil, fmt.Errorf("%q of type %q is required but not provided", def.Name, def.Type)
+			}
+			continue
+		}
+
+		value, err := s.coerceInput(raw, def.Type, variables, true, def.Name)
+		if err != nil {
+			return nil, err
+		}
+		args[def.Name] = value
+	}
+	return args, nil
+}
+
+type execution struct {
+	schema    *Schema
+	doc       *Document
+	variables map[string]any
+	opts      Options
+
+	mu     sync.Mutex
+	errors []*Error
+}
+
+func (e *execution) addError(err error, field *Field, path []any) {
+	gqlErr := &Error{Message: err.Error()}
+	if original, ok := err.(*Error); ok {
+		copied := *original
+		gqlErr = &copied
+	}
+	gqlErr.Locations = []Location{field.Loc}
+	gqlErr.Path = path
+
+	e.mu.Lock()
+	e.errors = append(e.errors, gqlErr)
+	e.mu.Unlock()
+}
+
+// collectFields groups the selected fields by response key, following fragments and @skip/@include
+func (e *execution) collectFields(set []Selection, typ *TypeDef, keys *[]string, groups map[string][]*Field) {
+	for _, selection := range set {
+		switch sel := selection.(type) {
+		case *Field:
+			if !e.included(sel.Directives) {
+				continue
+			}
+			key := sel.ResponseKey()
+			if _, ok := groups[key]; !ok {
+				*keys = append(*keys, key)
+			}
+			groups[key] = append(groups[key], sel)
+		case *FragmentSpread:
+			if e.included(sel.Directives) {
+				e.collectFields(e.doc.Fragments[sel.Name].SelectionSet, typ, keys, groups)
+			}
+		case *InlineFragment:
+			if e.included(sel.Directives) {
+				e.collectFields(sel.SelectionSet, typ, keys, groups)
+			}
+		}
+	}
+}
+
+func (e *execution) included(directives []*Directive) bool {
+	for _, directive := range directives {
+		value, _ := directive.Argument("if")
+		if variable, ok := value.(Variable); ok {
+			value = e.variables[string(variable)]
+		}
+		condition, _ := value.(bool)
+		if (directive.Name == "skip" && condition) || (directive.Name == "include" && !condition) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectionSet resolves the fields of an object. It returns false when a non-null
+// field is null, the object then becomes null in its parent.
+func (e *execution) selectionSet(ctx context.Context, set []Selection, typ *TypeDef, source any, path []any, serial bool) (orderedObject, bool) {
+	keys := []string{}
+	groups := map[string][]*Field{}
+	e.collectFields(set, typ, &keys, groups)
+
+	result := make(orderedObject, len(keys))
+	valid := make([]bool, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		fields := groups[key]
+		fieldPath := append(slices.Clone(path), key)
+		run := func() {
+			value, ok := e.field(ctx, typ, source, fields, fieldPath)
+			result[i] = objectField{key: key, value: value}
+			valid[i] = ok
+		}
+
+		// fields resolved by a function run concurrently so loaders can batch them
+		if !serial && fields[0].Name != "__typename" && e.schema.resolver(typ.Name, fields[0].Name) != nil {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				run()
+			}()
+		} else {
+			run()
+		}
+	}
+	wg.Wait()
+
+	for _, ok := range valid {
+		if !ok {
+			return nil, false
+		}
+	}
+	return result, true
+}
+
+func (e *execution) field(ctx context.Context, typ *TypeDef, source any, fields []*Field, path []any) (any, bool) {
+	field := fields[0]
+	if field.Name == "__typename" {
+		return typ.Name, true
+	}
+
+	def := typ.Field(field.Name)
+	fail := func(err error) (any, bool) {
+		e.addError(err, field, path)
+		return nil, !def.Type.NonNull
+	}
+
+	if directive := def.Directive("auth"); directive != nil {
+		if e.opts.Authorize == nil {
+			return fail(fmt.Errorf("Not authorized to access %s.%s", typ.Name, def.Name))
+		}
+		if err := e.opts.Authorize(ctx, typ, def, directive); err != nil {
+			return fail(err)
+		}
+	}
+
+	args, err := e.schema.coerceArguments(def.Args, field.Arguments, e.variables)
+	if err != nil {
+		return fail(err)
+	}
+
+	value, err := e.resolve(ResolveParams{Context: ctx, Source: source, Args: args, Field: def, Parent: typ})
+	if err != nil {
+		return fail(err)
+	}
+
+	// fields selected several times under the same key merge their selections
+	var set []Selection
+	for _, f := range fields {
+		set = append(set, f.SelectionSet...)
+	}
+	return e.complete(ctx, def.Type, field, set, value, path)
+}
+
+func (e *execution) resolve(p ResolveParams) (value any, err error) {
+	resolver := e.schema.resolver(p.Parent.Name, p.Field.Name)
+	if resolver == nil {
+		return defaultResolve(p.Source, p.Field.Name), nil
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("resolver %s.%s panicked: %v", p.Parent.Name, p.Field.Name, r)
+		}
+	}()
+	return resolver(p)
+}
+
+// complete converts a resolved value to the type of the field. It returns false when
+// the value is null (or failed) in a non-null position, the parent then becomes null.
+func (e *execution) complete(ctx context.Context, typ *TypeRef, field *Field, set []Selection, value any, path []any) (any, bool) {
+	result, ok := e.completeNullable(ctx, typ, field, set, value, path)
+	if !typ.NonNull {
+		if !ok {
+			return nil, true
+		}
+		return result, true
+	}
+
+	if ok && result == nil {
+		e.addError(fmt.Errorf("Cannot return null for non-nullable field %s", field.Name), field, path)
+		ok = false
+	}
+	return result, ok
+}
+
+func (e *execution) completeNullable(ctx context.Context, typ *TypeRef, field *Field, set []Selection, value any, path []any) (any, bool) {
+	if isNil(value) {
+		return nil, true
+	}
+
+	if typ.Elem != nil {
+		list := reflect.ValueOf(value)
+		for list.Kind() == reflect.Pointer || list.Kind() == reflect.Interface {
+			list = list.Elem()
+		}
+		if list.Kind() != reflect.Slice && list.Kind() != reflect.Array {
+			e.addError(fmt.Errorf("Expected a list for field %s, got %T", field.Name, value), field, path)
+			return nil, false
+		}
+
+		items := make([]any, list.Len())
+		valid := make([]bool, list.Len())
+		object := e.schema.Types[typ.NamedType()].Kind == KindObject
+
+		var wg sync.WaitGroup
+		for i := 0; i < list.Len(); i++ {
+			run := func() {
+				items[i], valid[i] = e.complete(ctx, typ.Elem, field, set, list.Index(i).Interface(), append(slices.Clone(path), i))
+			}
+			// items are completed concurrently so their nested fields share loader batches
+			if object && list.Len() > 1 {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					run()
+				}()
+			} else {
+				run()
+			}
+		}
+		wg.Wait()
+
+		for _, ok := range valid {
+			if !ok {
+				return nil, false
+			}
+		}
+		return items, true
+	}
+
+	def := e.schema.Types[typ.Name]
+	if def.Kind == KindObject {
+		object, ok := e.selectionSet(ctx, set, def, value, path, false)
+		if !ok {
+			return nil, false
+		}
+		return object, true
+	}
+
+	serialized, err := e.schema.serializeScalar(def, value)
+	if err != nil {
+		e.addError(err, field, path)
+		return nil, false
+	}
+	return serialized, true
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return v.IsNil()
+	}
+	return false
+}
+
+// fieldIndexes caches the struct field used for each GraphQL field name
+var fieldIndexes sync.Map // reflect.Type -> map[string][]int
+
+// defaultResolve reads name from a map or a struct (json tag, then case-insensitive field name)
+func defaultResolve(source any, name string) any {
+	v := reflect.ValueOf(source)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return nil
+		}
+		item := v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key()))
+		if !item.IsValid() {
+			return nil
+		}
+		return item.Interface()
+	case reflect.Struct:
+		indexes, ok := fieldIndexes.Load(v.Type())
+		if !ok {
+			indexes, _ = fieldIndexes.LoadOrStore(v.Type(), structFieldIndexes(v.Type()))
+		}
+		index, ok := indexes.(map[string][]int)[name]
+		if !ok {
+			for key, candidate := range indexes.(map[string][]int) {
+				if strings.EqualFold(key, name) {
+					index, ok = candidate, true
+					break
+				}
+			}
+		}
+		if !ok {
+			return nil
+		}
+		field, err := v.FieldByIndexErr(index)
+		if err != nil {
+			return nil
+		}
+		return field.Interface()
+	}
+	return nil
+}
+
+func structFieldIndexes(t reflect.Type) map[string][]int {
+	indexes := map[string][]int{}
+	for _, field := range reflect.VisibleFields(t) {
+		if !field.IsExported() || field.Anonymous {
+			continue
+		}
+		name := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			name = tag
+		}
+		if _, exists := indexes[name]; !exists {
+			indexes[name] = field.Index
+		}
+	}
+	return indexes
+}
+
+// orderedObject keeps the fields of a result in the order of the selection
+type orderedObject []objectField
+
+type objectField struct {
+	key   string
+	value any
+}
+
+func (o orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
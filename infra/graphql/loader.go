@@ -0,0 +1,236 @@
+package graphql
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// BatchFunc fetches several keys at once, keys missing from the result resolve to the zero value
+type BatchFunc[K comparable, V any] func(ctx context.Context, keys []K) (map[K]V, error)
+
+// LoaderOptions tunes the batching of a Loader
+type LoaderOptions struct {
+	Wait     time.Duration // time to collect keys before fetching, defaults to 2ms
+	MaxBatch int           // fetch as soon as this many keys are collected, 0 for no limit
+}
+
+// Loader batches and caches the loads of sibling resolvers (the N+1 problem):
+// every Load issued within the wait window is fetched with a single BatchFunc call.
+// Loaders are meant to live for one request, see UseLoader.
+type Loader[K comparable, V any] struct {
+	fetch BatchFunc[K, V]
+	opts  LoaderOptions
+
+	mu    sync.Mutex
+	cache map[K]*loaderResult[V]
+	batch *loaderBatch[K, V]
+}
+
+type loaderResult[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+type loaderBatch[K comparable, V any] struct {
+	keys    []K
+	results map[K]*loaderResult[V]
+}
+
+// NewLoader creates a loader fetching with fetch
+func NewLoader[K comparable, V any](fetch BatchFunc[K, V], opts ...LoaderOptions) *Loader[K, V] {
+	var opt LoaderOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Wait <= 0 {
+		opt.Wait = 2 * time.Millisecond
+	}
+
+	return &Loader[K, V]{
+		fetch: fetch,
+		opts:  opt,
+		cache: make(map[K]*loaderResult[V]),
+	}
+}
+
+// Load returns the value of key, waiting for the batch it belongs to
+func (l *Loader[K, V]) Load(ctx context.Context, key K) (V, error) {
+	l.mu.Lock()
+	result, ok := l.cache[key]
+	if !ok {
+		result = &loaderResult[V]{done: make(chan struct{})}
+		l.cache[key] = result
+
+		if l.batch == nil {
+			l.batch = &loaderBatch[K, V]{results: make(map[K]*loaderResult[V])}
+			batch := l.batch
+			time.AfterFunc(l.opts.Wait, func() { l.dispatch(ctx, batch) })
+		}
+		l.batch.keys = append(l.batch.keys, key)
+		l.batch.results[key] = result
+
+		if l.opts.MaxBatch > 0 && len(l.batch.keys) >= l.opts.MaxBatch {
+			batch := l.batch
+			l.batch = nil
+			go l.dispatch(ctx, batch)
+		}
+	}
+	l.mu.Unlock()
+
+	select {
+	case <-result.done:
+		return result.value, result.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+// LoadMany loads several keys, values are in the order of keys
+func (l *Loader[K, V]) LoadMany(ctx context.Context, keys []K) ([]V, error) {
+	values := make([]V, len(keys))
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			values[i], errs[i] = l.Load(ctx, key)
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// Prime stores a value in the cache, for instance one fetched by another query
+func (l *Loader[K, V]) Prime(key K, value V) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[key]; !ok {
+		result := &loaderResult[V]{done: make(chan struct{}), value: value}
+		close(result.done)
+		l.cache[key] = result
+	}
+}
+
+func (l *Loader[K, V]) dispatch(ctx context.Context, batch *loaderBatch[K, V]) {
+	l.mu.Lock()
+	if l.batch == batch {
+		l.batch = nil
+	}
+	// a batch dispatched for MaxBatch is also reached by its timer
+	keys := batch.keys
+	batch.keys = nil
+	l.mu.Unlock()
+
+	if len(keys) == 0 {
+		return
+	}
+
+	values, err := l.fetch(ctx, keys)
+	for _, key := range keys {
+		result := batch.results[key]
+		result.value, result.err = values[key], err
+		close(result.done)
+	}
+
+	// failed keys are not cached so a later load retries them
+	if err != nil {
+		l.mu.Lock()
+		for _, key := range keys {
+			if l.cache[key] == batch.results[key] {
+				delete(l.cache, key)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
+
+type loadersKey struct{}
+
+type loaderRegistry struct {
+	mu      sync.Mutex
+	loaders map[string]any
+}
+
+func withLoaders(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(loadersKey{}).(*loaderRegistry); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, loadersKey{}, &loaderRegistry{loaders: make(map[string]any)})
+}
+
+// UseLoader returns the loader named name of the current request, created with fetch on first use.
+// Outside of Execute a new loader is returned on every call.
+func UseLoader[K comparable, V any](ctx context.Context, name string, fetch BatchFunc[K, V], opts ...LoaderOptions) *Loader[K, V] {
+	registry, ok := ctx.Value(loadersKey{}).(*loaderRegistry)
+	if !ok {
+		return NewLoader(fetch, opts...)
+	}
+
+	registry.mu.Lock()
+	defer registry.mu.Unlock()
+
+	if loader, ok := registry.loaders[name].(*Loader[K, V]); ok {
+		return loader
+	}
+	loader := NewLoader(fetch, opts...)
+	registry.loaders[name] = loader
+	return loader
+}
+
+// DatabaseBatch fetches rows of table whose column is one of the keys, key returns the
+// column value of a row. Use it for to-one relations (ex: the author of posts).
+func DatabaseBatch[K comparable, V any](db port.IDatabase, table string, column string, key func(V) K) BatchFunc[K, V] {
+	return func(ctx context.Context, keys []K) (map[K]V, error) {
+		rows, err := findIn[K, V](ctx, db, table, column, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make(map[K]V, len(rows))
+		for _, row := range rows {
+			result[key(row)] = row
+		}
+		return result, nil
+	}
+}
+
+// DatabaseGroupBatch is DatabaseBatch for to-many relations (ex: the posts of users)
+func DatabaseGroupBatch[K comparable, V any](db port.IDatabase, table string, column string, key func(V) K) BatchFunc[K, []V] {
+	return func(ctx context.Context, keys []K) (map[K][]V, error) {
+		rows, err := findIn[K, V](ctx, db, table, column, keys)
+		if err != nil {
+			return nil, err
+		}
+
+		result := make(map[K][]V, len(keys))
+		for _, row := range rows {
+			result[key(row)] = append(result[key(row)], row)
+		}
+		return result, nil
+	}
+}
+
+func findIn[K comparable, V any](ctx context.Context, db port.IDatabase, table string, column string, keys []K) ([]V, error) {
+	args := make([]any, len(keys))
+	for i, key := range keys {
+		args[i] = key
+	}
+
+	rows := []V{}
+	err := db.Find(ctx, &rows, table, nil, []port.DbExpression{{Expr: column, Op: "IN", Args: args}}, nil, 0, 0)
+	return rows, err
+}
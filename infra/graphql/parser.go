@@ -0,0 +1,602 @@
+package graphql
+
+import (
+	"strconv"
+)
+
+type parser struct {
+	lexer *lexer
+	token token
+}
+
+func newParser(src string) (*parser, error) {
+	p := &parser{lexer: newLexer(src)}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+func (p *parser) advance() error {
+	token, err := p.lexer.next()
+	if err != nil {
+		return err
+	}
+	p.token = token
+	return nil
+}
+
+func (p *parser) peek(kind tokenKind, value string) bool {
+	return p.token.kind == kind && (value == "" || p.token.value == value)
+}
+
+// skip consumes the token when it matches
+func (p *parser) skip(kind tokenKind, value string) (bool, error) {
+	if !p.peek(kind, value) {
+		return false, nil
+	}
+	return true, p.advance()
+}
+
+func (p *parser) expect(kind tokenKind, value string) (token, error) {
+	current := p.token
+	if !p.peek(kind, value) {
+		expected := value
+		if expected == "" {
+			expected = map[tokenKind]string{tokenName: "name", tokenString: "string"}[kind]
+		}
+		return current, p.unexpected(expected)
+	}
+	return current, p.advance()
+}
+
+func (p *parser) unexpected(expected string) error {
+	found := p.token.value
+	if p.token.kind == tokenEOF {
+		found = "<EOF>"
+	}
+	return p.lexer.errorf(p.token.loc, "expected %s, found %q", expected, found)
+}
+
+func (p *parser) name() (string, error) {
+	token, err := p.expect(tokenName, "")
+	return token.value, err
+}
+
+// Parse parses a query document
+func Parse(query string) (*Document, error) {
+	p, err := newParser(query)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{Fragments: make(map[string]*FragmentDef)}
+	for !p.peek(tokenEOF, "") {
+		switch {
+		case p.peek(tokenPunct, "{"):
+			loc := p.token.loc
+			selections, err := p.selectionSet()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, &Operation{Type: "query", SelectionSet: selections, Loc: loc})
+		case p.peek(tokenName, "query"), p.peek(tokenName, "mutation"), p.peek(tokenName, "subscription"):
+			operation, err := p.operation()
+			if err != nil {
+				return nil, err
+			}
+			doc.Operations = append(doc.Operations, operation)
+		case p.peek(tokenName, "fragment"):
+			fragment, err := p.fragmentDefinition()
+			if err != nil {
+				return nil, err
+			}
+			if _, ok := doc.Fragments[fragment.Name]; ok {
+				return nil, &Error{Message: "There can be only one fragment named \"" + fragment.Name + "\"", Locations: []Location{fragment.Loc}}
+			}
+			doc.Fragments[fragment.Name] = fragment
+		default:
+			return nil, p.unexpected("definition")
+		}
+	}
+
+	if len(doc.Operations) == 0 {
+		return nil, &Error{Message: "Document does not contain any operation"}
+	}
+	return doc, nil
+}
+
+func (p *parser) operation() (*Operation, error) {
+	operation := &Operation{Type: p.token.value, Loc: p.token.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if p.peek(tokenName, "") {
+		if operation.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+
+	if ok, err := p.skip(tokenPunct, "("); err != nil {
+		return nil, err
+	} else if ok {
+		for !p.peek(tokenPunct, ")") {
+			variable, err := p.variableDefinition()
+			if err != nil {
+				return nil, err
+			}
+			operation.Variables = append(operation.Variables, variable)
+		}
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+	}
+
+	if operation.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if operation.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (p *parser) variableDefinition() (*VariableDef, error) {
+	if _, err := p.expect(tokenPunct, "$"); err != nil {
+		return nil, err
+	}
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(tokenPunct, ":"); err != nil {
+		return nil, err
+	}
+	typ, err := p.typeRef()
+	if err != nil {
+		return nil, err
+	}
+
+	variable := &VariableDef{Name: name, Type: typ}
+	if ok, err := p.skip(tokenPunct, "="); err != nil {
+		return nil, err
+	} else if ok {
+		if variable.Default, err = p.value(true); err != nil {
+			return nil, err
+		}
+		variable.HasDefault = true
+	}
+	return variable, nil
+}
+
+func (p *parser) fragmentDefinition() (*FragmentDef, error) {
+	fragment := &FragmentDef{Loc: p.token.loc}
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if fragment.Name, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Name == "on" {
+		return nil, p.lexer.errorf(fragment.Loc, "fragment cannot be named \"on\"")
+	}
+	if _, err := p.expect(tokenName, "on"); err != nil {
+		return nil, err
+	}
+	if fragment.TypeCondition, err = p.name(); err != nil {
+		return nil, err
+	}
+	if fragment.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if fragment.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return fragment, nil
+}
+
+func (p *parser) selectionSet() ([]Selection, error) {
+	if _, err := p.expect(tokenPunct, "{"); err != nil {
+		return nil, err
+	}
+
+	selections := []Selection{}
+	for !p.peek(tokenPunct, "}") {
+		selection, err := p.selection()
+		if err != nil {
+			return nil, err
+		}
+		selections = append(selections, selection)
+	}
+	if len(selections) == 0 {
+		return nil, p.unexpected("selection")
+	}
+	return selections, p.advance()
+}
+
+func (p *parser) selection() (Selection, error) {
+	if !p.peek(tokenPunct, "...") {
+		return p.field()
+	}
+
+	loc := p.token.loc
+	if err := p.advance(); err != nil {
+		return nil, err
+	}
+
+	if p.peek(tokenName, "") && p.token.value != "on" {
+		spread := &FragmentSpread{Loc: loc}
+		var err error
+		if spread.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if spread.Directives, err = p.directives(false); err != nil {
+			return nil, err
+		}
+		return spread, nil
+	}
+
+	inline := &InlineFragment{Loc: loc}
+	var err error
+	if ok, err := p.skip(tokenName, "on"); err != nil {
+		return nil, err
+	} else if ok {
+		if inline.TypeCondition, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	if inline.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if inline.SelectionSet, err = p.selectionSet(); err != nil {
+		return nil, err
+	}
+	return inline, nil
+}
+
+func (p *parser) field() (*Field, error) {
+	field := &Field{Loc: p.token.loc}
+
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if ok, err := p.skip(tokenPunct, ":"); err != nil {
+		return nil, err
+	} else if ok {
+		field.Alias = name
+		if name, err = p.name(); err != nil {
+			return nil, err
+		}
+	}
+	field.Name = name
+
+	if field.Arguments, err = p.arguments(false); err != nil {
+		return nil, err
+	}
+	if field.Directives, err = p.directives(false); err != nil {
+		return nil, err
+	}
+	if p.peek(tokenPunct, "{") {
+		if field.SelectionSet, err = p.selectionSet(); err != nil {
+			return nil, err
+		}
+	}
+	return field, nil
+}
+
+func (p *parser) arguments(constant bool) ([]*Argument, error) {
+	if ok, err := p.skip(tokenPunct, "("); err != nil || !ok {
+		return nil, err
+	}
+
+	arguments := []*Argument{}
+	for !p.peek(tokenPunct, ")") {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ":"); err != nil {
+			return nil, err
+		}
+		value, err := p.value(constant)
+		if err != nil {
+			return nil, err
+		}
+		arguments = append(arguments, &Argument{Name: name, Value: value})
+	}
+	return arguments, p.advance()
+}
+
+func (p *parser) directives(constant bool) ([]*Directive, error) {
+	directives := []*Directive{}
+	for p.peek(tokenPunct, "@") {
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		arguments, err := p.arguments(constant)
+		if err != nil {
+			return nil, err
+		}
+		directives = append(directives, &Directive{Name: name, Arguments: arguments})
+	}
+	return directives, nil
+}
+
+func (p *parser) typeRef() (*TypeRef, error) {
+	var typ *TypeRef
+	if ok, err := p.skip(tokenPunct, "["); err != nil {
+		return nil, err
+	} else if ok {
+		elem, err := p.typeRef()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, "]"); err != nil {
+			return nil, err
+		}
+		typ = &TypeRef{Elem: elem}
+	} else {
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		typ = &TypeRef{Name: name}
+	}
+
+	if ok, err := p.skip(tokenPunct, "!"); err != nil {
+		return nil, err
+	} else if ok {
+		typ.NonNull = true
+	}
+	return typ, nil
+}
+
+func (p *parser) value(constant bool) (any, error) {
+	token := p.token
+	switch {
+	case p.peek(tokenPunct, "$") && !constant:
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		name, err := p.name()
+		return Variable(name), err
+	case p.peek(tokenPunct, "["):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		list := []any{}
+		for !p.peek(tokenPunct, "]") {
+			item, err := p.value(constant)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, p.advance()
+	case p.peek(tokenPunct, "{"):
+		if err := p.advance(); err != nil {
+			return nil, err
+		}
+		object := map[string]any{}
+		for !p.peek(tokenPunct, "}") {
+			name, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokenPunct, ":"); err != nil {
+				return nil, err
+			}
+			if object[name], err = p.value(constant); err != nil {
+				return nil, err
+			}
+		}
+		return object, p.advance()
+	case token.kind == tokenInt:
+		value, err := strconv.ParseInt(token.value, 10, 64)
+		if err != nil {
+			return nil, p.lexer.errorf(token.loc, "invalid int %s", token.value)
+		}
+		return value, p.advance()
+	case token.kind == tokenFloat:
+		value, err := strconv.ParseFloat(token.value, 64)
+		if err != nil {
+			return nil, p.lexer.errorf(token.loc, "invalid float %s", token.value)
+		}
+		return value, p.advance()
+	case token.kind == tokenString:
+		return token.value, p.advance()
+	case token.kind == tokenName:
+		var value any
+		switch token.value {
+		case "true":
+			value = true
+		case "false":
+			value = false
+		case "null":
+			value = nil
+		default:
+			value = EnumValue(token.value)
+		}
+		return value, p.advance()
+	}
+	return nil, p.unexpected("value")
+}
+
+// ParseSchema parses a schema fragment written in the GraphQL SDL. Object, input,
+// enum and scalar types are supported, with "extend type" to add fields to a type
+// defined in another fragment.
+func ParseSchema(sdl string) (*SchemaDocument, error) {
+	p, err := newParser(sdl)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &SchemaDocument{}
+	for !p.peek(tokenEOF, "") {
+		description := ""
+		if p.token.kind == tokenString {
+			description = p.token.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		extend, err := p.skip(tokenName, "extend")
+		if err != nil {
+			return nil, err
+		}
+
+		keyword, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+
+		typ := &TypeDef{Description: description, Extend: extend}
+		if typ.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+
+		switch keyword {
+		case "type":
+			typ.Kind = KindObject
+			if _, err := p.directives(true); err != nil {
+				return nil, err
+			}
+			if typ.Fields, err = p.fieldDefinitions(); err != nil {
+				return nil, err
+			}
+		case "input":
+			typ.Kind = KindInput
+			if _, err := p.directives(true); err != nil {
+				return nil, err
+			}
+			if typ.InputFields, err = p.inputValueDefinitions("{", "}"); err != nil {
+				return nil, err
+			}
+		case "enum":
+			typ.Kind = KindEnum
+			if _, err := p.directives(true); err != nil {
+				return nil, err
+			}
+			if _, err := p.expect(tokenPunct, "{"); err != nil {
+				return nil, err
+			}
+			for !p.peek(tokenPunct, "}") {
+				if p.token.kind == tokenString {
+					if err := p.advance(); err != nil {
+						return nil, err
+					}
+				}
+				value, err := p.name()
+				if err != nil {
+					return nil, err
+				}
+				if _, err := p.directives(true); err != nil {
+					return nil, err
+				}
+				typ.Values = append(typ.Values, value)
+			}
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		case "scalar":
+			typ.Kind = KindScalar
+			if _, err := p.directives(true); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, p.lexer.errorf(p.token.loc, "unsupported definition %q", keyword)
+		}
+
+		doc.Types = append(doc.Types, typ)
+	}
+	return doc, nil
+}
+
+func (p *parser) fieldDefinitions() ([]*FieldDef, error) {
+	if _, err := p.expect(tokenPunct, "{"); err != nil {
+		return nil, err
+	}
+
+	fields := []*FieldDef{}
+	for !p.peek(tokenPunct, "}") {
+		field := &FieldDef{}
+		if p.token.kind == tokenString {
+			field.Description = p.token.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		if field.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if p.peek(tokenPunct, "(") {
+			if field.Args, err = p.inputValueDefinitions("(", ")"); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := p.expect(tokenPunct, ":"); err != nil {
+			return nil, err
+		}
+		if field.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if field.Directives, err = p.directives(true); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, p.advance()
+}
+
+func (p *parser) inputValueDefinitions(open string, close string) ([]*InputValueDef, error) {
+	if _, err := p.expect(tokenPunct, open); err != nil {
+		return nil, err
+	}
+
+	values := []*InputValueDef{}
+	for !p.peek(tokenPunct, close) {
+		value := &InputValueDef{}
+		if p.token.kind == tokenString {
+			value.Description = p.token.value
+			if err := p.advance(); err != nil {
+				return nil, err
+			}
+		}
+
+		var err error
+		if value.Name, err = p.name(); err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenPunct, ":"); err != nil {
+			return nil, err
+		}
+		if value.Type, err = p.typeRef(); err != nil {
+			return nil, err
+		}
+		if ok, err := p.skip(tokenPunct, "="); err != nil {
+			return nil, err
+		} else if ok {
+			if value.Default, err = p.value(true); err != nil {
+				return nil, err
+			}
+			value.HasDefault = true
+		}
+		if _, err := p.directives(true); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, p.advance()
+}
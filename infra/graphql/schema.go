@@ -0,0 +1,391 @@
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// ResolveParams are given to a resolver
+type ResolveParams struct {
+	Context context.Context
+	Source  any            // value returned by the resolver of the parent field
+	Args    map[string]any // coerced arguments, defaults applied
+	Field   *FieldDef
+	Parent  *TypeDef
+}
+
+// Bind decodes the arguments into target (a pointer to a struct with json tags)
+func (p ResolveParams) Bind(target any) error {
+	data, err := json.Marshal(p.Args)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, target)
+}
+
+// Resolver returns the value of a field. Fields without a resolver read the
+// map key or struct field (json tag or case-insensitive name) of the source.
+type Resolver func(p ResolveParams) (any, error)
+
+// Fragment is the part of the schema contributed by a module: SDL definitions and
+// the resolvers of their fields keyed by "Type.field" (ex: "Query.users")
+type Fragment struct {
+	SDL       string
+	Resolvers map[string]Resolver
+}
+
+// Schema is the stitched schema of every fragment, roots are Query and Mutation
+type Schema struct {
+	Types     map[string]*TypeDef
+	resolvers map[string]Resolver
+}
+
+var builtinScalars = []string{"Int", "Float", "String", "Boolean", "ID"}
+
+// NewSchema merges the fragments into one schema. A type may be defined once and
+// extended by other fragments; Query and Mutation may be declared by every fragment.
+func NewSchema(fragments ...*Fragment) (*Schema, error) {
+	schema := &Schema{
+		Types:     make(map[string]*TypeDef),
+		resolvers: make(map[string]Resolver),
+	}
+	for _, name := range builtinScalars {
+		schema.Types[name] = &TypeDef{Kind: KindScalar, Name: name}
+	}
+
+	extensions := []*TypeDef{}
+	for _, fragment := range fragments {
+		doc, err := ParseSchema(fragment.SDL)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, typ := range doc.Types {
+			root := typ.Name == "Query" || typ.Name == "Mutation"
+			_, exists := schema.Types[typ.Name]
+			switch {
+			case typ.Extend || (root && exists):
+				extensions = append(extensions, typ)
+			case exists:
+				return nil, fmt.Errorf("graphql: type %s is defined more than once", typ.Name)
+			default:
+				copied := *typ
+				copied.Fields = append([]*FieldDef{}, typ.Fields...)
+				schema.Types[typ.Name] = &copied
+			}
+		}
+
+		for key, resolver := range fragment.Resolvers {
+			if _, ok := schema.resolvers[key]; ok {
+				return nil, fmt.Errorf("graphql: resolver %s is registered more than once", key)
+			}
+			schema.resolvers[key] = resolver
+		}
+	}
+
+	for _, extension := range extensions {
+		typ, ok := schema.Types[extension.Name]
+		if !ok {
+			if extension.Name != "Query" && extension.Name != "Mutation" {
+				return nil, fmt.Errorf("graphql: cannot extend unknown type %s", extension.Name)
+			}
+			typ = &TypeDef{Kind: KindObject, Name: extension.Name}
+			schema.Types[extension.Name] = typ
+		}
+		if typ.Kind != extension.Kind {
+			return nil, fmt.Errorf("graphql: type %s extended with a different kind", extension.Name)
+		}
+
+		for _, field := range extension.Fields {
+			if typ.Field(field.Name) != nil {
+				return nil, fmt.Errorf("graphql: field %s.%s is defined more than once", typ.Name, field.Name)
+			}
+			typ.Fields = append(typ.Fields, field)
+		}
+		typ.InputFields = append(typ.InputFields, extension.InputFields...)
+		typ.Values = append(typ.Values, extension.Values...)
+	}
+
+	if err := schema.check(); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// check verifies the references between types and the resolvers
+func (s *Schema) check() error {
+	if s.Types["Query"] == nil {
+		return fmt.Errorf("graphql: schema has no Query type")
+	}
+
+	for _, typ := range s.Types {
+		for _, field := range typ.Fields {
+			output, ok := s.Types[field.Type.NamedType()]
+			if !ok {
+				return fmt.Errorf("graphql: unknown type %s of %s.%s", field.Type.NamedType(), typ.Name, field.Name)
+			}
+			if output.Kind == KindInput {
+				return fmt.Errorf("graphql: %s.%s cannot return the input type %s", typ.Name, field.Name, output.Name)
+			}
+			for _, arg := range field.Args {
+				if err := s.checkInput(arg, typ.Name+"."+field.Name); err != nil {
+					return err
+				}
+			}
+		}
+		for _, input := range typ.InputFields {
+			if err := s.checkInput(input, typ.Name); err != nil {
+				return err
+			}
+		}
+	}
+
+	for key := range s.resolvers {
+		typeName, fieldName, _ := strings.Cut(key, ".")
+		typ, ok := s.Types[typeName]
+		if !ok || typ.Field(fieldName) == nil {
+			return fmt.Errorf("graphql: resolver %s has no field in the schema", key)
+		}
+	}
+	return nil
+}
+
+func (s *Schema) checkInput(value *InputValueDef, owner string) error {
+	typ, ok := s.Types[value.Type.NamedType()]
+	if !ok {
+		return fmt.Errorf("graphql: unknown type %s of %s(%s)", value.Type.NamedType(), owner, value.Name)
+	}
+	if typ.Kind == KindObject {
+		return fmt.Errorf("graphql: %s(%s) cannot use the output type %s", owner, value.Name, typ.Name)
+	}
+	return nil
+}
+
+func (s *Schema) resolver(typeName string, fieldName string) Resolver {
+	return s.resolvers[typeName+"."+fieldName]
+}
+
+// coerceInput converts a literal, or a JSON variable value when literal is false, into
+// the Go value given to resolvers: int, float64, string, bool, []any or map[string]any
+func (s *Schema) coerceInput(value any, typ *TypeRef, variables map[string]any, literal bool, path string) (any, error) {
+	if variable, ok := value.(Variable); ok && literal {
+		value, ok = variables[string(variable)]
+		if !ok || value == nil {
+			if typ.NonNull {
+				return nil, fmt.Errorf("%s: variable $%s of required type %s was not provided", path, variable, typ)
+			}
+			return nil, nil
+		}
+		// variables are already coerced
+		return value, nil
+	}
+
+	if value == nil {
+		if typ.NonNull {
+			return nil, fmt.Errorf("%s: expected %s, found null", path, typ)
+		}
+		return nil, nil
+	}
+
+	if typ.Elem != nil {
+		list, ok := value.([]any)
+		if !ok {
+			// a single value is accepted where a list is expected
+			list = []any{value}
+		}
+		result := make([]any, len(list))
+		for i, item := range list {
+			coerced, err := s.coerceInput(item, typ.Elem, variables, literal, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			result[i] = coerced
+		}
+		return result, nil
+	}
+
+	def := s.Types[typ.Name]
+	switch def.Kind {
+	case KindInput:
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: expected %s, found %v", path, typ, value)
+		}
+		result := make(map[string]any, len(def.InputFields))
+		for name := range object {
+			if !slicesContainsInput(def.InputFields, name) {
+				return nil, fmt.Errorf("%s: field %s is not defined by %s", path, name, def.Name)
+			}
+		}
+		for _, field := range def.InputFields {
+			raw, present := object[field.Name]
+			fieldLiteral := literal
+			if !present {
+				if field.HasDefault {
+					// defaults are SDL literals
+					raw, present, fieldLiteral = field.Default, true, true
+				} else if field.Type.NonNull {
+					return nil, fmt.Errorf("%s.%s: expected %s, not provided", path, field.Name, field.Type)
+				}
+			}
+			if !present {
+				continue
+			}
+			coerced, err := s.coerceInput(raw, field.Type, variables, fieldLiteral, path+"."+field.Name)
+			if err != nil {
+				return nil, err
+			}
+			result[field.Name] = coerced
+		}
+		return result, nil
+	case KindEnum:
+		var name string
+		switch v := value.(type) {
+		case EnumValue:
+			name = string(v)
+		case string:
+			if literal {
+				return nil, fmt.Errorf("%s: enum %s expects a bare name, found string", path, def.Name)
+			}
+			name = v
+		default:
+			return nil, fmt.Errorf("%s: expected %s, found %v", path, def.Name, value)
+		}
+		for _, allowed := range def.Values {
+			if allowed == name {
+				return name, nil
+			}
+		}
+		return nil, fmt.Errorf("%s: %q is not a value of %s", path, name, def.Name)
+	}
+
+	switch typ.Name {
+	case "Int":
+		switch v := value.(type) {
+		case int64:
+			if v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		case float64:
+			// JSON numbers of variables
+			if v == math.Trunc(v) && v >= math.MinInt32 && v <= math.MaxInt32 {
+				return int(v), nil
+			}
+		}
+	case "Float":
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		}
+	case "String":
+		if v, ok := value.(string); ok {
+			return v, nil
+		}
+	case "Boolean":
+		if v, ok := value.(bool); ok {
+			return v, nil
+		}
+	case "ID":
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case int64:
+			return strconv.FormatInt(v, 10), nil
+		case float64:
+			if v == math.Trunc(v) {
+				return strconv.FormatInt(int64(v), 10), nil
+			}
+		}
+	default:
+		// custom scalars are passed as is
+		if _, ok := value.(EnumValue); !ok {
+			return value, nil
+		}
+	}
+	return nil, fmt.Errorf("%s: expected %s, found %v", path, typ, value)
+}
+
+func slicesContainsInput(fields []*InputValueDef, name string) bool {
+	for _, field := range fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// serializeScalar converts a resolved value into the JSON value of a scalar or enum
+func (s *Schema) serializeScalar(def *TypeDef, value any) (any, error) {
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		v = v.Elem()
+	}
+
+	if def.Kind == KindEnum {
+		name := fmt.Sprint(v.Interface())
+		for _, allowed := range def.Values {
+			if allowed == name {
+				return name, nil
+			}
+		}
+		return nil, fmt.Errorf("%q is not a value of %s", name, def.Name)
+	}
+
+	switch def.Name {
+	case "Int":
+		switch {
+		case v.CanInt():
+			if n := v.Int(); n >= math.MinInt32 && n <= math.MaxInt32 {
+				return n, nil
+			}
+		case v.CanUint():
+			if n := v.Uint(); n <= math.MaxInt32 {
+				return int64(n), nil
+			}
+		case v.CanFloat():
+			if f := v.Float(); f == math.Trunc(f) && f >= math.MinInt32 && f <= math.MaxInt32 {
+				return int64(f), nil
+			}
+		}
+		return nil, fmt.Errorf("Int cannot represent %v", value)
+	case "Float":
+		switch {
+		case v.CanFloat():
+			return v.Float(), nil
+		case v.CanInt():
+			return float64(v.Int()), nil
+		case v.CanUint():
+			return float64(v.Uint()), nil
+		}
+		return nil, fmt.Errorf("Float cannot represent %v", value)
+	case "String", "ID":
+		switch {
+		case v.Kind() == reflect.String:
+			return v.String(), nil
+		case def.Name == "ID" && v.CanInt():
+			return strconv.FormatInt(v.Int(), 10), nil
+		case def.Name == "ID" && v.CanUint():
+			return strconv.FormatUint(v.Uint(), 10), nil
+		}
+		if stringer, ok := value.(fmt.Stringer); ok {
+			return stringer.String(), nil
+		}
+		return nil, fmt.Errorf("%s cannot represent %v", def.Name, value)
+	case "Boolean":
+		if v.Kind() == reflect.Bool {
+			return v.Bool(), nil
+		}
+		return nil, fmt.Errorf("Boolean cannot represent %v", value)
+	}
+
+	// custom scalars are serialized by the JSON encoder
+	return value, nil
+}
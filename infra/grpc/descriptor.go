@@ -0,0 +1,83 @@
+package grpc
+
+import "encoding/binary"
+
+// Field types of FieldDescriptorProto
+const (
+	fieldInt32   = 5
+	fieldString  = 9
+	fieldMessage = 11
+	fieldBytes   = 12
+	fieldEnum    = 14
+)
+
+// field describes a message field for the hand written file descriptors served by reflection
+type field struct {
+	name     string
+	number   int
+	kind     int
+	typeName string // fully qualified with a leading dot, for messages and enums
+	repeated bool
+	oneof    int // 1-based index of the oneof of the message, 0 outside oneofs
+}
+
+func (f field) descriptor() []byte {
+	label := uint64(1) // LABEL_OPTIONAL
+	if f.repeated {
+		label = 3 // LABEL_REPEATED
+	}
+
+	var b []byte
+	b = AppendString(b, 1, f.name)
+	b = AppendVarint(b, 3, uint64(f.number))
+	b = AppendVarint(b, 4, label)
+	b = AppendVarint(b, 5, uint64(f.kind))
+	b = AppendString(b, 6, f.typeName)
+	if f.oneof > 0 {
+		// oneof_index is zero-based, encode it even when it is 0
+		b = binary.AppendUvarint(b, 9<<3|WireVarint)
+		b = binary.AppendUvarint(b, uint64(f.oneof-1))
+	}
+	return b
+}
+
+// messageDescriptor encodes a DescriptorProto
+func messageDescriptor(name string, oneofs []string, fields ...field) []byte {
+	var b []byte
+	b = AppendString(b, 1, name)
+	for _, f := range fields {
+		b = AppendBytes(b, 2, f.descriptor())
+	}
+	for _, oneof := range oneofs {
+		b = AppendBytes(b, 8, AppendString(nil, 1, oneof))
+	}
+	return b
+}
+
+// enumDescriptor encodes an EnumDescriptorProto numbering values from 0
+func enumDescriptor(name string, values ...string) []byte {
+	var b []byte
+	b = AppendString(b, 1, name)
+	for number, value := range values {
+		var v []byte
+		v = AppendString(v, 1, value)
+		v = AppendVarint(v, 2, uint64(number))
+		b = AppendBytes(b, 2, v)
+	}
+	return b
+}
+
+// methodDescriptor encodes a MethodDescriptorProto
+func methodDescriptor(name string, input string, output string, clientStreaming bool, serverStreaming bool) []byte {
+	var b []byte
+	b = AppendString(b, 1, name)
+	b = AppendString(b, 2, input)
+	b = AppendString(b, 3, output)
+	if clientStreaming {
+		b = AppendVarint(b, 5, 1)
+	}
+	if serverStreaming {
+		b = AppendVarint(b, 6, 1)
+	}
+	return b
+}
@@ -0,0 +1,266 @@
+package grpc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Status codes of the gRPC protocol
+const (
+	OK                 = 0
+	Canceled           = 1
+	Unknown            = 2
+	InvalidArgument    = 3
+	DeadlineExceeded   = 4
+	NotFound           = 5
+	ResourceExhausted  = 8
+	FailedPrecondition = 9
+	Unimplemented      = 12
+	Internal           = 13
+	Unavailable        = 14
+)
+
+// maxMessageSize is the largest message accepted from a client, like the gRPC default
+const maxMessageSize = 4 << 20
+
+// Status is an error answered with its gRPC status code
+type Status struct {
+	Code    int
+	Message string
+}
+
+func (s *Status) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", s.Code, s.Message)
+}
+
+// Errorf creates an error answered with code
+func Errorf(code int, format string, args ...any) error {
+	return &Status{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+// Handler serves a method, it reads the request messages and sends the responses on the
+// stream. Unary methods receive one message and send one.
+type Handler func(ctx context.Context, stream *Stream) error
+
+// Stream carries the protobuf encoded messages of a call
+type Stream struct {
+	Metadata http.Header // request headers
+	body     io.Reader
+	w        http.ResponseWriter
+	rc       *http.ResponseController
+	sent     bool
+}
+
+// Recv reads the next message, io.EOF after the last one
+func (s *Stream) Recv() ([]byte, error) {
+	var prefix [5]byte
+	if _, err := io.ReadFull(s.body, prefix[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return nil, Errorf(Internal, "truncated message")
+		}
+		return nil, err
+	}
+	if prefix[0] != 0 {
+		return nil, Errorf(Unimplemented, "compressed messages are not supported")
+	}
+
+	size := binary.BigEndian.Uint32(prefix[1:])
+	if size > maxMessageSize {
+		return nil, Errorf(ResourceExhausted, "message of %d bytes exceeds the limit", size)
+	}
+	msg := make([]byte, size)
+	if _, err := io.ReadFull(s.body, msg); err != nil {
+		return nil, Errorf(Internal, "truncated message")
+	}
+	return msg, nil
+}
+
+// Send writes a message and flushes it to the client
+func (s *Stream) Send(msg []byte) error {
+	var prefix [5]byte
+	binary.BigEndian.PutUint32(prefix[1:], uint32(len(msg)))
+
+	s.sent = true
+	if _, err := s.w.Write(prefix[:]); err != nil {
+		return err
+	}
+	if _, err := s.w.Write(msg); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// Server serves gRPC methods over cleartext HTTP/2 (h2c) with the standard library,
+// without compression. Services register their methods with Handle.
+type Server struct {
+	mu       sync.RWMutex
+	methods  map[string]Handler
+	services map[string]bool
+	files    map[string][]byte // serialized file descriptors by file name, for reflection
+	symbols  map[string]string // fully qualified symbol -> file name
+	http     *http.Server
+}
+
+func NewServer() *Server {
+	s := &Server{
+		methods:  make(map[string]Handler),
+		services: make(map[string]bool),
+		files:    make(map[string][]byte),
+		symbols:  make(map[string]string),
+	}
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	s.http = &http.Server{Handler: s, Protocols: protocols, ReadHeaderTimeout: 10 * time.Second}
+	return s
+}
+
+// Handle registers a method of a fully qualified service (ex: "grpc.health.v1.Health", "Check")
+func (s *Server) Handle(service string, method string, handler Handler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.methods["/"+service+"/"+method] = handler
+	s.services[service] = true
+}
+
+// RegisterFile makes a serialized FileDescriptorProto and the symbols it defines
+// (services, methods, messages, enums) available to reflection clients
+func (s *Server) RegisterFile(name string, descriptor []byte, symbols ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.files[name] = descriptor
+	for _, symbol := range symbols {
+		s.symbols[symbol] = name
+	}
+}
+
+// Services returns the names of the registered services, sorted
+func (s *Server) Services() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	services := make([]string, 0, len(s.services))
+	for service := range s.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return services
+}
+
+// Serve accepts connections until Shutdown
+func (s *Server) Serve(listener net.Listener) error {
+	err := s.http.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// Shutdown stops accepting calls and waits for the running ones until ctx is done
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.http.Shutdown(ctx)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || r.ProtoMajor != 2 || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC requests only", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	s.mu.RLock()
+	handler, ok := s.methods[r.URL.Path]
+	s.mu.RUnlock()
+
+	w.Header().Set("Content-Type", "application/grpc")
+	stream := &Stream{Metadata: r.Header, body: r.Body, w: w, rc: http.NewResponseController(w)}
+
+	var err error
+	if !ok {
+		err = Errorf(Unimplemented, "unknown method %s", r.URL.Path)
+	} else {
+		ctx := r.Context()
+		if timeout, ok := parseTimeout(r.Header.Get("Grpc-Timeout")); ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		err = handler(ctx, stream)
+		if err != nil && ctx.Err() != nil {
+			err = Errorf(DeadlineExceeded, "%v", ctx.Err())
+			if errors.Is(ctx.Err(), context.Canceled) {
+				err = Errorf(Canceled, "%v", ctx.Err())
+			}
+		}
+	}
+
+	code, message := OK, ""
+	if err != nil {
+		var status *Status
+		if errors.As(err, &status) {
+			code, message = status.Code, status.Message
+		} else {
+			code, message = Unknown, err.Error()
+		}
+	}
+
+	if !stream.sent {
+		// trailers-only response
+		w.Header().Set("Grpc-Status", strconv.Itoa(code))
+		if message != "" {
+			w.Header().Set("Grpc-Message", encodeMessage(message))
+		}
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	w.Header().Set(http.TrailerPrefix+"Grpc-Status", strconv.Itoa(code))
+	if message != "" {
+		w.Header().Set(http.TrailerPrefix+"Grpc-Message", encodeMessage(message))
+	}
+}
+
+// parseTimeout reads the grpc-timeout header (ex: "100m", "5S")
+func parseTimeout(value string) (time.Duration, bool) {
+	if len(value) < 2 {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+	if err != nil || n < 0 {
+		return 0, false
+	}
+
+	units := map[byte]time.Duration{
+		'H': time.Hour, 'M': time.Minute, 'S': time.Second,
+		'm': time.Millisecond, 'u': time.Microsecond, 'n': time.Nanosecond,
+	}
+	unit, ok := units[value[len(value)-1]]
+	if !ok {
+		return 0, false
+	}
+	return time.Duration(n) * unit, true
+}
+
+// encodeMessage percent-encodes grpc-message: bytes outside printable ASCII and '%'
+func encodeMessage(message string) string {
+	var b strings.Builder
+	for i := 0; i < len(message); i++ {
+		c := message[i]
+		if c < 0x20 || c > 0x7E || c == '%' {
+			fmt.Fprintf(&b, "%%%02X", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
@@ -0,0 +1,155 @@
+package grpc
+
+import (
+	"context"
+	"sync"
+)
+
+// Serving statuses of the gRPC health checking protocol
+const (
+	StatusUnknown        = 0
+	StatusServing        = 1
+	StatusNotServing     = 2
+	StatusServiceUnknown = 3 // only sent by Watch
+)
+
+const healthService = "grpc.health.v1.Health"
+
+// HealthServer implements grpc.health.v1.Health, used by load balancers, Kubernetes gRPC
+// probes and service registries. The empty service name is the status of the whole server.
+type HealthServer struct {
+	mu       sync.Mutex
+	statuses map[string]int
+	watchers map[string]map[chan int]bool
+}
+
+func NewHealthServer() *HealthServer {
+	return &HealthServer{
+		statuses: map[string]int{"": StatusNotServing},
+		watchers: make(map[string]map[chan int]bool),
+	}
+}
+
+// SetServingStatus changes the status of a service and notifies its watchers
+func (h *HealthServer) SetServingStatus(service string, status int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if current, ok := h.statuses[service]; ok && current == status {
+		return
+	}
+	h.statuses[service] = status
+	for watcher := range h.watchers[service] {
+		// keep only the latest status for slow watchers
+		select {
+		case <-watcher:
+		default:
+		}
+		watcher <- status
+	}
+}
+
+// Register adds the health service to server
+func (h *HealthServer) Register(server *Server) {
+	server.Handle(healthService, "Check", h.check)
+	server.Handle(healthService, "Watch", h.watch)
+	server.RegisterFile(healthFile, healthDescriptor(),
+		"grpc.health.v1.Health", "grpc.health.v1.Health.Check", "grpc.health.v1.Health.Watch",
+		"grpc.health.v1.HealthCheckRequest", "grpc.health.v1.HealthCheckResponse",
+		"grpc.health.v1.HealthCheckResponse.ServingStatus")
+}
+
+func (h *HealthServer) check(ctx context.Context, stream *Stream) error {
+	service, err := readHealthRequest(stream)
+	if err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	status, ok := h.statuses[service]
+	h.mu.Unlock()
+	if !ok {
+		return Errorf(NotFound, "unknown service %q", service)
+	}
+	return stream.Send(AppendVarint(nil, 1, uint64(status)))
+}
+
+// watch sends the status of the service, then every change until the client leaves
+func (h *HealthServer) watch(ctx context.Context, stream *Stream) error {
+	service, err := readHealthRequest(stream)
+	if err != nil {
+		return err
+	}
+
+	updates := make(chan int, 1)
+	h.mu.Lock()
+	status, ok := h.statuses[service]
+	if !ok {
+		status = StatusServiceUnknown
+	}
+	if h.watchers[service] == nil {
+		h.watchers[service] = make(map[chan int]bool)
+	}
+	h.watchers[service][updates] = true
+	h.mu.Unlock()
+
+	defer func() {
+		h.mu.Lock()
+		delete(h.watchers[service], updates)
+		h.mu.Unlock()
+	}()
+
+	for {
+		if err := stream.Send(AppendVarint(nil, 1, uint64(status))); err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case status = <-updates:
+		}
+	}
+}
+
+func readHealthRequest(stream *Stream) (string, error) {
+	msg, err := stream.Recv()
+	if err != nil {
+		return "", Errorf(InvalidArgument, "missing request")
+	}
+
+	var service string
+	err = ParseMessage(msg, func(field int, value uint64, data []byte) error {
+		if field == 1 {
+			service = string(data)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", Errorf(InvalidArgument, "%v", err)
+	}
+	return service, nil
+}
+
+const healthFile = "grpc/health/v1/health.proto"
+
+// healthDescriptor is the FileDescriptorProto of grpc/health/v1/health.proto
+func healthDescriptor() []byte {
+	var file []byte
+	file = AppendString(file, 1, healthFile)
+	file = AppendString(file, 2, "grpc.health.v1")
+	file = AppendBytes(file, 4, messageDescriptor("HealthCheckRequest", nil, field{name: "service", number: 1, kind: fieldString}))
+
+	var response []byte
+	response = AppendString(response, 1, "HealthCheckResponse")
+	response = AppendBytes(response, 2, field{name: "status", number: 1, kind: fieldEnum, typeName: ".grpc.health.v1.HealthCheckResponse.ServingStatus"}.descriptor())
+	response = AppendBytes(response, 4, enumDescriptor("ServingStatus", "UNKNOWN", "SERVING", "NOT_SERVING", "SERVICE_UNKNOWN"))
+	file = AppendBytes(file, 4, response)
+
+	var service []byte
+	service = AppendString(service, 1, "Health")
+	service = AppendBytes(service, 2, methodDescriptor("Check", ".grpc.health.v1.HealthCheckRequest", ".grpc.health.v1.HealthCheckResponse", false, false))
+	service = AppendBytes(service, 2, methodDescriptor("Watch", ".grpc.health.v1.HealthCheckRequest", ".grpc.health.v1.HealthCheckResponse", false, true))
+	file = AppendBytes(file, 6, service)
+
+	return AppendString(file, 12, "proto3")
+}
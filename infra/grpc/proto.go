@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// Protobuf wire types
+const (
+	WireVarint = 0
+	WireBytes  = 2
+)
+
+// AppendVarint appends a varint field, proto3 omits zero values
+func AppendVarint(b []byte, field int, value uint64) []byte {
+	if value == 0 {
+		return b
+	}
+	b = binary.AppendUvarint(b, uint64(field)<<3|WireVarint)
+	return binary.AppendUvarint(b, value)
+}
+
+// AppendBytes appends a length-delimited field: string, bytes or embedded message
+func AppendBytes(b []byte, field int, value []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field)<<3|WireBytes)
+	b = binary.AppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// AppendString appends a string field, proto3 omits empty strings
+func AppendString(b []byte, field int, value string) []byte {
+	if value == "" {
+		return b
+	}
+	return AppendBytes(b, field, []byte(value))
+}
+
+// ParseMessage calls fn for each field of an encoded message, value holds varints and
+// data the content of length-delimited fields. Fixed-size fields are skipped.
+func ParseMessage(msg []byte, fn func(field int, value uint64, data []byte) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return fmt.Errorf("malformed protobuf message")
+		}
+		msg = msg[n:]
+		field := int(key >> 3)
+
+		var value uint64
+		var data []byte
+		switch key & 7 {
+		case WireVarint:
+			if value, n = binary.Uvarint(msg); n <= 0 {
+				return fmt.Errorf("malformed protobuf varint")
+			}
+			msg = msg[n:]
+		case WireBytes:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || size > uint64(len(msg)-n) {
+				return fmt.Errorf("malformed protobuf field %d", field)
+			}
+			data = msg[n : n+int(size)]
+			msg = msg[n+int(size):]
+		case 1: // 64-bit
+			if len(msg) < 8 {
+				return fmt.Errorf("malformed protobuf field %d", field)
+			}
+			msg = msg[8:]
+			continue
+		case 5: // 32-bit
+			if len(msg) < 4 {
+				return fmt.Errorf("malformed protobuf field %d", field)
+			}
+			msg = msg[4:]
+			continue
+		default:
+			return fmt.Errorf("unsupported protobuf wire type %d", key&7)
+		}
+
+		if err := fn(field, value, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package grpc
+
+import (
+	"context"
+	"io"
+)
+
+// reflection packages, v1alpha is still the only one known by many clients
+var reflectionPackages = map[string]string{
+	"grpc.reflection.v1":      "grpc/reflection/v1/reflection.proto",
+	"grpc.reflection.v1alpha": "grpc_reflection_v1alpha/reflection.proto",
+}
+
+// RegisterReflection adds the server reflection service (v1 and v1alpha) to server so tools
+// like grpcurl or Postman discover its services without the .proto files
+func RegisterReflection(server *Server) {
+	for pkg, file := range reflectionPackages {
+		server.Handle(pkg+".ServerReflection", "ServerReflectionInfo", func(ctx context.Context, stream *Stream) error {
+			return reflectionInfo(server, stream)
+		})
+
+		symbols := []string{pkg + ".ServerReflection", pkg + ".ServerReflection.ServerReflectionInfo"}
+		for _, message := range reflectionMessages {
+			symbols = append(symbols, pkg+"."+message)
+		}
+		server.RegisterFile(file, reflectionDescriptor(pkg, file), symbols...)
+	}
+}
+
+// reflectionInfo answers each ServerReflectionRequest of the stream
+func reflectionInfo(server *Server, stream *Stream) error {
+	for {
+		request, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		var host string
+		var answer []byte
+		err = ParseMessage(request, func(field int, value uint64, data []byte) error {
+			switch field {
+			case 1:
+				host = string(data)
+			case 3: // file_by_filename
+				answer = server.fileResponse(string(data), "")
+			case 4: // file_containing_symbol
+				answer = server.fileResponse("", string(data))
+			case 5, 6: // file_containing_extension, all_extension_numbers_of_type
+				answer = errorResponse(NotFound, "extensions are not supported")
+			case 7: // list_services
+				var services []byte
+				for _, name := range server.Services() {
+					services = AppendBytes(services, 1, AppendString(nil, 1, name))
+				}
+				answer = AppendBytes(nil, 6, services)
+			}
+			return nil
+		})
+		if err != nil {
+			return Errorf(InvalidArgument, "%v", err)
+		}
+		if answer == nil {
+			answer = errorResponse(InvalidArgument, "unsupported request")
+		}
+
+		var response []byte
+		response = AppendString(response, 1, host)
+		response = AppendBytes(response, 2, request)
+		response = append(response, answer...)
+		if err := stream.Send(response); err != nil {
+			return err
+		}
+	}
+}
+
+// fileResponse answers the descriptor of a file, by name or by a symbol it defines
+func (s *Server) fileResponse(name string, symbol string) []byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if symbol != "" {
+		var ok bool
+		if name, ok = s.symbols[symbol]; !ok {
+			return errorResponse(NotFound, "symbol not found: "+symbol)
+		}
+	}
+	descriptor, ok := s.files[name]
+	if !ok {
+		return errorResponse(NotFound, "file not found: "+name)
+	}
+	return AppendBytes(nil, 4, AppendBytes(nil, 1, descriptor))
+}
+
+func errorResponse(code int, message string) []byte {
+	var b []byte
+	b = AppendVarint(b, 1, uint64(code))
+	b = AppendString(b, 2, message)
+	return AppendBytes(nil, 7, b)
+}
+
+var reflectionMessages = []string{
+	"ServerReflectionRequest", "ExtensionRequest", "ServerReflectionResponse", "FileDescriptorResponse",
+	"ExtensionNumberResponse", "ListServiceResponse", "ServiceResponse", "ErrorResponse",
+}
+
+// reflectionDescriptor is the FileDescriptorProto of the reflection service in package pkg
+func reflectionDescriptor(pkg string, name string) []byte {
+	prefix := "." + pkg + "."
+
+	var file []byte
+	file = AppendString(file, 1, name)
+	file = AppendString(file, 2, pkg)
+	file = AppendBytes(file, 4, messageDescriptor("ServerReflectionRequest", []string{"message_request"},
+		field{name: "host", number: 1, kind: fieldString},
+		field{name: "file_by_filename", number: 3, kind: fieldString, oneof: 1},
+		field{name: "file_containing_symbol", number: 4, kind: fieldString, oneof: 1},
+		field{name: "file_containing_extension", number: 5, kind: fieldMessage, typeName: prefix + "ExtensionRequest", oneof: 1},
+		field{name: "all_extension_numbers_of_type", number: 6, kind: fieldString, oneof: 1},
+		field{name: "list_services", number: 7, kind: fieldString, oneof: 1}))
+	file = AppendBytes(file, 4, messageDescriptor("ExtensionRequest", nil,
+		field{name: "containing_type", number: 1, kind: fieldString},
+		field{name: "extension_number", number: 2, kind: fieldInt32}))
+	file = AppendBytes(file, 4, messageDescriptor("ServerReflectionResponse", []string{"message_response"},
+		field{name: "valid_host", number: 1, kind: fieldString},
+		field{name: "original_request", number: 2, kind: fieldMessage, typeName: prefix + "ServerReflectionRequest"},
+		field{name: "file_descriptor_response", number: 4, kind: fieldMessage, typeName: prefix + "FileDescriptorResponse", oneof: 1},
+		field{name: "all_extension_numbers_response", number: 5, kind: fieldMessage, typeName: prefix + "ExtensionNumberResponse", oneof: 1},
+		field{name: "list_services_response", number: 6, kind: fieldMessage, typeName: prefix + "ListServiceResponse", oneof: 1},
+		field{name: "error_response", number: 7, kind: fieldMessage, typeName: prefix + "ErrorResponse", oneof: 1}))
+	file = AppendBytes(file, 4, messageDescriptor("FileDescriptorResponse", nil,
+		field{name: "file_descriptor_proto", number: 1, kind: fieldBytes, repeated: true}))
+	file = AppendBytes(file, 4, messageDescriptor("ExtensionNumberResponse", nil,
+		field{name: "base_type_name", number: 1, kind: fieldString},
+		field{name: "extension_number", number: 2, kind: fieldInt32, repeated: true}))
+	file = AppendBytes(file, 4, messageDescriptor("ListServiceResponse", nil,
+		field{name: "service", number: 1, kind: fieldMessage, typeName: prefix + "ServiceResponse", repeated: true}))
+	file = AppendBytes(file, 4, messageDescriptor("ServiceResponse", nil,
+		field{name: "name", number: 1, kind: fieldString}))
+	file = AppendBytes(file, 4, messageDescriptor("ErrorResponse", nil,
+		field{name: "error_code", number: 1, kind: fieldInt32},
+		field{name: "error_message", number: 2, kind: fieldString}))
+
+	var service []byte
+	service = AppendString(service, 1, "ServerReflection")
+	service = AppendBytes(service, 2, methodDescriptor("ServerReflectionInfo", prefix+"ServerReflectionRequest", prefix+"ServerReflectionResponse", true, true))
+	file = AppendBytes(file, 6, service)
+
+	return AppendString(file, 12, "proto3")
+}
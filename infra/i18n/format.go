@@ -0,0 +1,162 @@
+package i18n
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Currency describes how the amounts of a currency are written
+type Currency struct {
+	Code     string
+	Symbol   string
+	Decimals int
+}
+
+var currencies = map[string]Currency{
+	"USD": {"USD", "$", 2},
+	"EUR": {"EUR", "€", 2},
+	"GBP": {"GBP", "£", 2},
+	"IDR": {"IDR", "Rp", 0},
+	"SGD": {"SGD", "S$", 2},
+	"MYR": {"MYR", "RM", 2},
+	"AUD": {"AUD", "A$", 2},
+	"JPY": {"JPY", "¥", 0},
+	"CNY": {"CNY", "CN¥", 2},
+	"BRL": {"BRL", "R$", 2},
+	"CHF": {"CHF", "CHF", 2},
+	"INR": {"INR", "₹", 2},
+}
+
+// RegisterCurrency adds or replaces a currency of FormatCurrency
+func RegisterCurrency(currency Currency) {
+	localesMu.Lock()
+	defer localesMu.Unlock()
+	currencies[strings.ToUpper(currency.Code)] = currency
+}
+
+func lookupCurrency(code string) Currency {
+	localesMu.RLock()
+	defer localesMu.RUnlock()
+
+	code = strings.ToUpper(code)
+	if currency, ok := currencies[code]; ok {
+		return currency
+	}
+	return Currency{Code: code, Symbol: code, Decimals: 2}
+}
+
+// FormatNumber writes value with decimals digits after the separator, grouped by thousands
+func (l *Locale) FormatNumber(value float64, decimals int) string {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	}
+
+	digits := strconv.FormatFloat(math.Abs(value), 'f', max(decimals, 0), 64)
+	integer, fraction, _ := strings.Cut(digits, ".")
+
+	var b strings.Builder
+	if value < 0 && strings.Trim(digits, "0.") != "" {
+		b.WriteString("-")
+	}
+	for i, digit := range integer {
+		if i > 0 && (len(integer)-i)%3 == 0 {
+			b.WriteString(l.Group)
+		}
+		b.WriteRune(digit)
+	}
+	if fraction != "" {
+		b.WriteString(l.Decimal)
+		b.WriteString(fraction)
+	}
+	return b.String()
+}
+
+// FormatInteger writes value grouped by thousands
+func (l *Locale) FormatInteger(value int64) string {
+	return l.FormatNumber(float64(value), 0)
+}
+
+// FormatPercent writes ratio (0.125 for 12.5%) as a percentage with decimals digits
+func (l *Locale) FormatPercent(ratio float64, decimals int) string {
+	number := l.FormatNumber(ratio*100, decimals)
+	if l.PercentSpace {
+		return number + " %"
+	}
+	return number + "%"
+}
+
+// FormatCurrency writes amount in the currency of the ISO 4217 code (ex: "IDR"), with the
+// digits of the currency and the symbol placed as the locale does
+func (l *Locale) FormatCurrency(amount float64, code string) string {
+	currency := lookupCurrency(code)
+	number := l.FormatNumber(math.Abs(amount), currency.Decimals)
+
+	space := ""
+	if l.CurrencySpace {
+		space = " "
+	}
+	formatted := number + space + currency.Symbol
+	if l.CurrencyPrefix {
+		formatted = currency.Symbol + space + number
+	}
+	if amount < 0 && strings.Trim(number, "0"+l.Decimal+l.Group) != "" {
+		formatted = "-" + formatted
+	}
+	return formatted
+}
+
+// FormatDate writes the date of t in a style: DateShort, DateMedium, DateLong or DateFull
+// (DateMedium when unknown)
+func (l *Locale) FormatDate(t time.Time, style string) string {
+	layout, ok := l.Dates[style]
+	if !ok {
+		layout = l.Dates[DateMedium]
+	}
+	return l.Format(t, layout)
+}
+
+// FormatDateTime writes the date of t in a style followed by its time
+func (l *Locale) FormatDateTime(t time.Time, style string) string {
+	return l.FormatDate(t, style) + " " + l.Format(t, l.Time)
+}
+
+// FormatTime writes the time of t
+func (l *Locale) FormatTime(t time.Time) string {
+	return l.Format(t, l.Time)
+}
+
+// Format is time.Format with the month and day names of the locale
+func (l *Locale) Format(t time.Time, layout string) string {
+	var b strings.Builder
+	for layout != "" {
+		// the next name in the layout, the longest of the names starting at the same place
+		at, name := -1, ""
+		for _, token := range []string{"January", "Jan", "Monday", "Mon"} {
+			if i := strings.Index(layout, token); i >= 0 && (at < 0 || i < at || (i == at && len(token) > len(name))) {
+				at, name = i, token
+			}
+		}
+		if at < 0 {
+			b.WriteString(t.Format(layout))
+			break
+		}
+
+		if at > 0 {
+			b.WriteString(t.Format(layout[:at]))
+		}
+		switch name {
+		case "January":
+			b.WriteString(l.Months[t.Month()-1])
+		case "Jan":
+			b.WriteString(l.ShortMonths[t.Month()-1])
+		case "Monday":
+			b.WriteString(l.Days[t.Weekday()])
+		case "Mon":
+			b.WriteString(l.ShortDays[t.Weekday()])
+		}
+		layout = layout[at+len(name):]
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package i18n
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// Funcs returns the template functions formatting with a locale, given as a *Locale or a tag:
+//
+//	{{ formatNumber .Locale 1234.5 2 }}        1.234,50
+//	{{ formatCurrency .Locale 150000 "IDR" }}  Rp150.000
+//	{{ formatPercent .Locale 0.125 1 }}        12,5%
+//	{{ formatDate .Locale .CreatedAt "long" }} 2 Januari 2025
+//	{{ formatDateTime .Locale .CreatedAt "short" }}
+func Funcs() map[string]any {
+	return map[string]any{
+		"formatNumber": func(locale any, value any, decimals int) (string, error) {
+			number, err := toFloat(value)
+			if err != nil {
+				return "", err
+			}
+			return toLocale(locale).FormatNumber(number, decimals), nil
+		},
+		"formatCurrency": func(locale any, value any, code string) (string, error) {
+			number, err := toFloat(value)
+			if err != nil {
+				return "", err
+			}
+			return toLocale(locale).FormatCurrency(number, code), nil
+		},
+		"formatPercent": func(locale any, value any, decimals int) (string, error) {
+			number, err := toFloat(value)
+			if err != nil {
+				return "", err
+			}
+			return toLocale(locale).FormatPercent(number, decimals), nil
+		},
+		"formatDate": func(locale any, value any, style string) (string, error) {
+			t, ok := toTime(value)
+			if !ok {
+				return "", nil
+			}
+			return toLocale(locale).FormatDate(t, style), nil
+		},
+		"formatDateTime": func(locale any, value any, style string) (string, error) {
+			t, ok := toTime(value)
+			if !ok {
+				return "", nil
+			}
+			return toLocale(locale).FormatDateTime(t, style), nil
+		},
+	}
+}
+
+func toLocale(locale any) *Locale {
+	switch l := locale.(type) {
+	case *Locale:
+		if l != nil {
+			return l
+		}
+	case string:
+		return Default(l)
+	}
+	return Default("")
+}
+
+func toFloat(value any) (float64, error) {
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	}
+	return 0, fmt.Errorf("i18n: %T is not a number", value)
+}
+
+// toTime accepts a time.Time or a *time.Time, a nil or zero time is not written
+func toTime(value any) (time.Time, bool) {
+	switch t := value.(type) {
+	case time.Time:
+		return t, !t.IsZero()
+	case *time.Time:
+		if t != nil {
+			return *t, !t.IsZero()
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,145 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Date styles of Locale.FormatDate
+const (
+	DateShort  = "short"  // 02/01/2006
+	DateMedium = "medium" // 2 Jan 2006
+	DateLong   = "long"   // 2 January 2006
+	DateFull   = "full"   // Monday, 2 January 2006
+)
+
+// Locale holds the conventions of a language and region for numbers, currencies and dates.
+// The date layouts are Go layouts, their month and day names are translated.
+type Locale struct {
+	Tag     string // BCP 47 tag (ex: "en", "id", "pt-BR")
+	Name    string // name of the language in itself (ex: "Bahasa Indonesia")
+	Decimal string // decimal separator
+	Group   string // thousands separator
+
+	CurrencyPrefix bool // symbol before the amount
+	CurrencySpace  bool // space between the symbol and the amount
+	PercentSpace   bool // space before the percent sign
+
+	Dates       map[string]string // layout of each date style
+	Time        string            // layout of the time of FormatDateTime
+	Months      [12]string
+	ShortMonths [12]string
+	Days        [7]string // from Sunday
+	ShortDays   [7]string
+}
+
+var (
+	localesMu sync.RWMutex
+	locales   = map[string]*Locale{}
+)
+
+func init() {
+	for _, locale := range builtinLocales() {
+		Register(locale)
+	}
+}
+
+// Register adds or replaces a locale, it is then found by Lookup and Match
+func Register(locale *Locale) {
+	localesMu.Lock()
+	defer localesMu.Unlock()
+	locales[normalizeTag(locale.Tag)] = locale
+}
+
+// Tags returns the tags of the registered locales, sorted
+func Tags() []string {
+	localesMu.RLock()
+	defer localesMu.RUnlock()
+
+	tags := make([]string, 0, len(locales))
+	for _, locale := range locales {
+		tags = append(tags, locale.Tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// Lookup returns the locale of tag, or the one of its language (ex: "de" for "de-AT"), false
+// when none is registered
+func Lookup(tag string) (*Locale, bool) {
+	tag = normalizeTag(tag)
+	if tag == "" {
+		return nil, false
+	}
+
+	localesMu.RLock()
+	defer localesMu.RUnlock()
+
+	if locale, ok := locales[tag]; ok {
+		return locale, true
+	}
+	base, _, _ := strings.Cut(tag, "-")
+	locale, ok := locales[base]
+	return locale, ok
+}
+
+// Default returns the locale of tag, English when it is not registered
+func Default(tag string) *Locale {
+	if locale, ok := Lookup(tag); ok {
+		return locale
+	}
+	locale, _ := Lookup("en")
+	return locale
+}
+
+// Match returns the tag of supported preferred by the Accept-Language header, the languages
+// of the header are tried by quality, then by their base language. Every registered locale is
+// supported when supported is empty. It returns false when none matches.
+func Match(acceptLanguage string, supported []string) (string, bool) {
+	type preference struct {
+		tag     string
+		quality float64
+	}
+
+	preferences := []preference{}
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		quality := 1.0
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil {
+				quality = value
+			}
+		}
+		if tag = normalizeTag(tag); tag != "" && tag != "*" && quality > 0 {
+			preferences = append(preferences, preference{tag, quality})
+		}
+	}
+	sort.SliceStable(preferences, func(i, j int) bool { return preferences[i].quality > preferences[j].quality })
+
+	if len(supported) == 0 {
+		supported = Tags()
+	}
+	for _, preferred := range preferences {
+		base, _, _ := strings.Cut(preferred.tag, "-")
+		// the exact tag, then the tag of the language, then another region of the language
+		for _, candidate := range []func(string) bool{
+			func(tag string) bool { return tag == preferred.tag },
+			func(tag string) bool { return tag == base },
+			func(tag string) bool { return strings.HasPrefix(tag, base+"-") },
+		} {
+			for _, tag := range supported {
+				if candidate(normalizeTag(tag)) {
+					return tag, true
+				}
+			}
+		}
+	}
+	return "", false
+}
+
+// normalizeTag lowercases tag and uses dashes (ex: "pt_BR" becomes "pt-br")
+func normalizeTag(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
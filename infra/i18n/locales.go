@@ -0,0 +1,139 @@
+package i18n
+
+func builtinLocales() []*Locale {
+	english := Locale{
+		Tag: "en", Name: "English", Decimal: ".", Group: ",",
+		CurrencyPrefix: true,
+		Dates: map[string]string{
+			DateShort:  "01/02/2006",
+			DateMedium: "Jan 2, 2006",
+			DateLong:   "January 2, 2006",
+			DateFull:   "Monday, January 2, 2006",
+		},
+		Time:        "3:04 PM",
+		Months:      [12]string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		Days:        [7]string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		ShortDays:   [7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	}
+
+	british := english
+	british.Tag = "en-GB"
+	british.Dates = map[string]string{
+		DateShort:  "02/01/2006",
+		DateMedium: "2 Jan 2006",
+		DateLong:   "2 January 2006",
+		DateFull:   "Monday, 2 January 2006",
+	}
+	british.Time = "15:04"
+
+	return []*Locale{
+		&english,
+		&british,
+		{
+			Tag: "id", Name: "Bahasa Indonesia", Decimal: ",", Group: ".",
+			CurrencyPrefix: true,
+			Dates: map[string]string{
+				DateShort:  "02/01/2006",
+				DateMedium: "2 Jan 2006",
+				DateLong:   "2 January 2006",
+				DateFull:   "Monday, 2 January 2006",
+			},
+			Time:        "15.04",
+			Months:      [12]string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"},
+			ShortMonths: [12]string{"Jan", "Feb", "Mar", "Apr", "Mei", "Jun", "Jul", "Agu", "Sep", "Okt", "Nov", "Des"},
+			Days:        [7]string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"},
+			ShortDays:   [7]string{"Min", "Sen", "Sel", "Rab", "Kam", "Jum", "Sab"},
+		},
+		{
+			Tag: "de", Name: "Deutsch", Decimal: ",", Group: ".",
+			CurrencySpace: true, PercentSpace: true,
+			Dates: map[string]string{
+				DateShort:  "02.01.2006",
+				DateMedium: "02.01.2006",
+				DateLong:   "2. January 2006",
+				DateFull:   "Monday, 2. January 2006",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+			ShortMonths: [12]string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+			Days:        [7]string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+			ShortDays:   [7]string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+		},
+		{
+			Tag: "fr", Name: "Français", Decimal: ",", Group: " ",
+			CurrencySpace: true, PercentSpace: true,
+			Dates: map[string]string{
+				DateShort:  "02/01/2006",
+				DateMedium: "2 Jan 2006",
+				DateLong:   "2 January 2006",
+				DateFull:   "Monday 2 January 2006",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+			ShortMonths: [12]string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+			Days:        [7]string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+			ShortDays:   [7]string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+		},
+		{
+			Tag: "es", Name: "Español", Decimal: ",", Group: ".",
+			CurrencySpace: true, PercentSpace: true,
+			Dates: map[string]string{
+				DateShort:  "2/1/06",
+				DateMedium: "2 Jan 2006",
+				DateLong:   "2 de January de 2006",
+				DateFull:   "Monday, 2 de January de 2006",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+			ShortMonths: [12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+			Days:        [7]string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+			ShortDays:   [7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+		},
+		{
+			Tag: "pt", Name: "Português", Decimal: ",", Group: ".",
+			CurrencyPrefix: true, CurrencySpace: true,
+			Dates: map[string]string{
+				DateShort:  "02/01/2006",
+				DateMedium: "2 de Jan de 2006",
+				DateLong:   "2 de January de 2006",
+				DateFull:   "Monday, 2 de January de 2006",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+			ShortMonths: [12]string{"jan.", "fev.", "mar.", "abr.", "mai.", "jun.", "jul.", "ago.", "set.", "out.", "nov.", "dez."},
+			Days:        [7]string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+			ShortDays:   [7]string{"dom.", "seg.", "ter.", "qua.", "qui.", "sex.", "sáb."},
+		},
+		{
+			Tag: "nl", Name: "Nederlands", Decimal: ",", Group: ".",
+			CurrencyPrefix: true, CurrencySpace: true,
+			Dates: map[string]string{
+				DateShort:  "02-01-2006",
+				DateMedium: "2 Jan 2006",
+				DateLong:   "2 January 2006",
+				DateFull:   "Monday 2 January 2006",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+			ShortMonths: [12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+			Days:        [7]string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+			ShortDays:   [7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+		},
+		{
+			Tag: "ja", Name: "日本語", Decimal: ".", Group: ",",
+			CurrencyPrefix: true,
+			Dates: map[string]string{
+				DateShort:  "2006/01/02",
+				DateMedium: "2006/01/02",
+				DateLong:   "2006年1月2日",
+				DateFull:   "2006年1月2日Monday",
+			},
+			Time:        "15:04",
+			Months:      [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+			ShortMonths: [12]string{"1月", "2月", "3月", "4月", "5月", "6月", "7月", "8月", "9月", "10月", "11月", "12月"},
+			Days:        [7]string{"日曜日", "月曜日", "火曜日", "水曜日", "木曜日", "金曜日", "土曜日"},
+			ShortDays:   [7]string{"日", "月", "火", "水", "木", "金", "土"},
+		},
+	}
+}
@@ -0,0 +1,193 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"io"
+)
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+// Orientation returns the EXIF orientation (1-8) of JPEG data, 1 when it has none
+func Orientation(data []byte) int {
+	exif := jpegExif(data)
+	if len(exif) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(exif[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	offset := int(order.Uint32(exif[4:8]))
+	if offset+2 > len(exif) {
+		return 1
+	}
+	count := int(order.Uint16(exif[offset:]))
+	for i := 0; i < count; i++ {
+		entry := offset + 2 + i*12
+		if entry+12 > len(exif) {
+			break
+		}
+		if order.Uint16(exif[entry:]) == 0x0112 {
+			if value := int(order.Uint16(exif[entry+8:])); value >= 1 && value <= 8 {
+				return value
+			}
+			break
+		}
+	}
+	return 1
+}
+
+// jpegExif returns the TIFF structure of the EXIF segment of JPEG data
+func jpegExif(data []byte) []byte {
+	var exif []byte
+	walkJPEG(data, func(marker byte, segment []byte) bool {
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			exif = segment[6:]
+			return false
+		}
+		return true
+	})
+	return exif
+}
+
+// walkJPEG calls fn with the marker and payload of every segment before the image data,
+// returns the offset where the image data starts or -1 when data is not a valid JPEG
+func walkJPEG(data []byte, fn func(marker byte, segment []byte) bool) int {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return -1
+	}
+
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return -1
+		}
+		marker := data[pos+1]
+		if marker == 0xDA {
+			return pos
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2:]))
+		if length < 2 || pos+2+length > len(data) {
+			return -1
+		}
+		if !fn(marker, data[pos+4:pos+2+length]) {
+			return pos
+		}
+		pos += 2 + length
+	}
+	return -1
+}
+
+// Orient rotates and flips img so it displays upright for an EXIF orientation
+func Orient(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	src := toNRGBA(img)
+	w, h := src.Rect.Dx(), src.Rect.Dy()
+	dw, dh := w, h
+	if orientation >= 5 {
+		dw, dh = h, w
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		for x := 0; x < dw; x++ {
+			var sx, sy int
+			switch orientation {
+			case 2: // mirrored
+				sx, sy = w-1-x, y
+			case 3: // rotated 180
+				sx, sy = w-1-x, h-1-y
+			case 4: // mirrored vertically
+				sx, sy = x, h-1-y
+			case 5: // transposed
+				sx, sy = y, x
+			case 6: // rotated 90 clockwise
+				sx, sy = y, h-1-x
+			case 7: // transversed
+				sx, sy = w-1-y, h-1-x
+			case 8: // rotated 90 counter clockwise
+				sx, sy = w-1-y, x
+			}
+			copy(dst.Pix[y*dst.Stride+x*4:y*dst.Stride+x*4+4], src.Pix[sy*src.Stride+sx*4:])
+		}
+	}
+	return dst
+}
+
+// StripMetadata removes EXIF, XMP, IPTC and comments from JPEG and PNG data without
+// re-encoding the pixels. A rotated JPEG is re-encoded upright instead, since dropping its
+// orientation would turn it. Other formats are returned unchanged.
+func StripMetadata(data []byte, quality int) ([]byte, error) {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xFF, 0xD8}):
+		if Orientation(data) != 1 {
+			var buf bytes.Buffer
+			if _, err := Process(bytes.NewReader(data), &buf, Options{Quality: quality}, 0); err != nil {
+				return nil, err
+			}
+			return buf.Bytes(), nil
+		}
+		return stripJPEG(data)
+	case bytes.HasPrefix(data, pngSignature):
+		return stripPNG(data)
+	}
+	return data, nil
+}
+
+func stripJPEG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, 0xFF, 0xD8)
+
+	start := walkJPEG(data, func(marker byte, segment []byte) bool {
+		// APP1 holds EXIF and XMP, APP13 IPTC and 0xFE comments; ICC profiles (APP2)
+		// and Adobe color transforms (APP14) change how the pixels look and are kept
+		if marker == 0xE1 || marker == 0xED || marker == 0xFE {
+			return true
+		}
+		out = append(out, 0xFF, marker)
+		out = binary.BigEndian.AppendUint16(out, uint16(len(segment)+2))
+		out = append(out, segment...)
+		return true
+	})
+	if start < 0 {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return append(out, data[start:]...), nil
+}
+
+func stripPNG(data []byte) ([]byte, error) {
+	out := make([]byte, 0, len(data))
+	out = append(out, pngSignature...)
+
+	pos := len(pngSignature)
+	for pos+12 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[pos:]))
+		end := pos + 12 + length
+		if length < 0 || end > len(data) {
+			return nil, io.ErrUnexpectedEOF
+		}
+
+		switch string(data[pos+4 : pos+8]) {
+		case "tEXt", "zTXt", "iTXt", "eXIf", "tIME":
+		default:
+			out = append(out, data[pos:end]...)
+		}
+		if string(data[pos+4:pos+8]) == "IEND" {
+			return out, nil
+		}
+		pos = end
+	}
+	return nil, io.ErrUnexpectedEOF
+}
@@ -0,0 +1,195 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// Resize modes
+const (
+	ModeFit    = "fit"    // scale down to fit inside the box, keeping the aspect ratio (default)
+	ModeFill   = "fill"   // scale and crop the center to cover the box exactly
+	ModeResize = "resize" // scale to the box, ignoring the aspect ratio
+)
+
+// Supported formats
+const (
+	FormatJPEG = "jpeg"
+	FormatPNG  = "png"
+	FormatGIF  = "gif"
+)
+
+// ErrTooLarge is returned for images with more pixels than allowed, checked before decoding
+var ErrTooLarge = errors.New("image has too many pixels")
+
+// Options describes a transformation, the zero value re-encodes the image without metadata
+type Options struct {
+	Width   int             `json:"width,omitempty"`  // 0 keeps the aspect ratio from Height
+	Height  int             `json:"height,omitempty"` // 0 keeps the aspect ratio from Width
+	Mode    string          `json:"mode,omitempty"`
+	Crop    image.Rectangle `json:"crop,omitempty"`    // applied before resizing, in source pixels
+	Format  string          `json:"format,omitempty"`  // jpeg, png or gif, defaults to the source format
+	Quality int             `json:"quality,omitempty"` // JPEG quality 1-100, defaults to 85
+	Upscale bool            `json:"upscale,omitempty"` // fit and fill never enlarge unless set
+}
+
+// Info describes an encoded image
+type Info struct {
+	Format      string `json:"format"`
+	ContentType string `json:"content_type"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+	Size        int64  `json:"size"`
+}
+
+// Process decodes src, applies opts and writes the result to w. EXIF orientation is
+// applied and no metadata is written. maxPixels rejects bigger images, 0 for no limit.
+func Process(src io.Reader, w io.Writer, opts Options, maxPixels int) (*Info, error) {
+	img, format, err := Decode(src, maxPixels)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(w, Transform(img, opts), formatOr(opts.Format, format), opts.Quality)
+}
+
+// Decode reads an image rotated according to its EXIF orientation and returns its format
+func Decode(src io.Reader, maxPixels int) (image.Image, string, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, "", err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("unsupported image: %v", err)
+	}
+	if maxPixels > 0 && config.Width*config.Height > maxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if format == FormatJPEG {
+		img = Orient(img, Orientation(data))
+	}
+	return img, format, nil
+}
+
+// Transform crops and resizes img
+func Transform(img image.Image, opts Options) image.Image {
+	if !opts.Crop.Empty() {
+		img = Crop(img, opts.Crop)
+	}
+	if opts.Width <= 0 && opts.Height <= 0 {
+		return img
+	}
+
+	switch opts.Mode {
+	case ModeFill:
+		return Fill(img, opts.Width, opts.Height, opts.Upscale)
+	case ModeResize:
+		return Resize(img, opts.Width, opts.Height)
+	default:
+		return Fit(img, opts.Width, opts.Height, opts.Upscale)
+	}
+}
+
+// Encode writes img in format, JPEG has no transparency so it is flattened on white
+func Encode(w io.Writer, img image.Image, format string, quality int) (*Info, error) {
+	if quality <= 0 || quality > 100 {
+		quality = 85
+	}
+
+	counter := &countingWriter{w: w}
+	var err error
+	switch format {
+	case FormatJPEG:
+		err = jpeg.Encode(counter, flatten(img), &jpeg.Options{Quality: quality})
+	case FormatPNG:
+		err = (&png.Encoder{CompressionLevel: png.BestCompression}).Encode(counter, img)
+	case FormatGIF:
+		err = gif.Encode(counter, img, nil)
+	default:
+		return nil, fmt.Errorf("unsupported image format '%s'", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	bounds := img.Bounds()
+	return &Info{
+		Format:      format,
+		ContentType: ContentType(format),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		Size:        counter.n,
+	}, nil
+}
+
+// ContentType returns the MIME type of a format
+func ContentType(format string) string {
+	return "image/" + format
+}
+
+// Extension returns the file extension of a format
+func Extension(format string) string {
+	if format == FormatJPEG {
+		return ".jpg"
+	}
+	return "." + format
+}
+
+// FormatOf returns the format of a file extension or MIME type, empty when unsupported
+func FormatOf(name string) string {
+	name = strings.ToLower(name)
+	name = name[strings.LastIndexAny(name, "./")+1:]
+	switch name {
+	case "jpg", "jpeg", "jpe", "pjpeg":
+		return FormatJPEG
+	case "png", "gif":
+		return name
+	}
+	return ""
+}
+
+func formatOr(format string, fallback string) string {
+	if format = FormatOf(format); format != "" {
+		return format
+	}
+	return fallback
+}
+
+func flatten(img image.Image) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Over)
+	return dst
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
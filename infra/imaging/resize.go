@@ -0,0 +1,202 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Crop returns the part of img inside rect, clipped to the image
+func Crop(img image.Image, rect image.Rectangle) image.Image {
+	rect = rect.Add(img.Bounds().Min).Intersect(img.Bounds())
+	src := toNRGBA(img)
+	dst := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dst, dst.Bounds(), src, rect.Min, draw.Src)
+	return dst
+}
+
+// Fit scales img down to fit inside width x height keeping the aspect ratio,
+// a zero dimension is unconstrained
+func Fit(img image.Image, width int, height int, upscale bool) image.Image {
+	bounds := img.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+
+	scale := math.Inf(1)
+	if width > 0 {
+		scale = float64(width) / w
+	}
+	if height > 0 {
+		scale = math.Min(scale, float64(height)/h)
+	}
+	if scale >= 1 && !upscale {
+		return img
+	}
+
+	return Resize(img, max(1, int(math.Round(w*scale))), max(1, int(math.Round(h*scale))))
+}
+
+// Fill scales img to cover width x height and crops the center, a zero dimension
+// takes the one of the image
+func Fill(img image.Image, width int, height int, upscale bool) image.Image {
+	bounds := img.Bounds()
+	if width <= 0 {
+		width = bounds.Dx()
+	}
+	if height <= 0 {
+		height = bounds.Dy()
+	}
+	if !upscale {
+		// keep the requested aspect ratio inside the image
+		if width > bounds.Dx() {
+			height = max(1, height*bounds.Dx()/width)
+			width = bounds.Dx()
+		}
+		if height > bounds.Dy() {
+			width = max(1, width*bounds.Dy()/height)
+			height = bounds.Dy()
+		}
+	}
+
+	// crop the source to the target aspect ratio first, then resample
+	w, h := bounds.Dx(), bounds.Dy()
+	cropW, cropH := w, int(math.Round(float64(w)*float64(height)/float64(width)))
+	if cropH > h {
+		cropW, cropH = int(math.Round(float64(h)*float64(width)/float64(height))), h
+	}
+	x, y := (w-cropW)/2, (h-cropH)/2
+	cropped := Crop(img, image.Rect(x, y, x+cropW, y+cropH))
+
+	return Resize(cropped, width, height)
+}
+
+// Resize scales img to width x height with a Catmull-Rom filter, a zero dimension
+// keeps the aspect ratio
+func Resize(img image.Image, width int, height int) image.Image {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	if width <= 0 && height <= 0 || sw == 0 || sh == 0 {
+		return img
+	}
+	if width <= 0 {
+		width = max(1, int(math.Round(float64(sw)*float64(height)/float64(sh))))
+	}
+	if height <= 0 {
+		height = max(1, int(math.Round(float64(sh)*float64(width)/float64(sw))))
+	}
+	if width == sw && height == sh {
+		return img
+	}
+
+	src := toNRGBA(img)
+
+	// horizontal pass into premultiplied floats, then vertical pass into the result
+	xWeights := filterWeights(width, sw)
+	tmp := make([]float32, width*sh*4)
+	for y := 0; y < sh; y++ {
+		row := src.Pix[y*src.Stride:]
+		for x, weight := range xWeights {
+			var r, g, b, a float32
+			for i, v := range weight.values {
+				p := row[(weight.start+i)*4:]
+				alpha := float32(p[3]) * v
+				r += float32(p[0]) * alpha
+				g += float32(p[1]) * alpha
+				b += float32(p[2]) * alpha
+				a += alpha
+			}
+			t := tmp[(y*width+x)*4:]
+			t[0], t[1], t[2], t[3] = r, g, b, a
+		}
+	}
+
+	yWeights := filterWeights(height, sh)
+	dst := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y, weight := range yWeights {
+		row := dst.Pix[y*dst.Stride:]
+		for x := 0; x < width; x++ {
+			var r, g, b, a float32
+			for i, v := range weight.values {
+				t := tmp[((weight.start+i)*width+x)*4:]
+				r += t[0] * v
+				g += t[1] * v
+				b += t[2] * v
+				a += t[3] * v
+			}
+
+			p := row[x*4:]
+			if a <= 0 {
+				p[0], p[1], p[2], p[3] = 0, 0, 0, 0
+				continue
+			}
+			p[0], p[1], p[2], p[3] = clamp(r/a), clamp(g/a), clamp(b/a), clamp(a)
+		}
+	}
+	return dst
+}
+
+type weights struct {
+	start  int
+	values []float32
+}
+
+// filterWeights returns the contributions of the source pixels to every destination pixel,
+// the filter is widened when shrinking so every source pixel is accounted for
+func filterWeights(dst int, src int) []weights {
+	scale := float64(src) / float64(dst)
+	widen := math.Max(scale, 1)
+	support := 2 * widen
+
+	result := make([]weights, dst)
+	for i := range result {
+		center := (float64(i) + 0.5) * scale
+		start := max(0, int(math.Floor(center-support)))
+		end := min(src, int(math.Ceil(center+support)))
+
+		values := make([]float32, 0, end-start)
+		var sum float64
+		for j := start; j < end; j++ {
+			w := catmullRom((float64(j) + 0.5 - center) / widen)
+			values = append(values, float32(w))
+			sum += w
+		}
+		if sum != 0 {
+			for j := range values {
+				values[j] /= float32(sum)
+			}
+		}
+		result[i] = weights{start: start, values: values}
+	}
+	return result
+}
+
+func catmullRom(x float64) float64 {
+	x = math.Abs(x)
+	switch {
+	case x < 1:
+		return (1.5*x-2.5)*x*x + 1
+	case x < 2:
+		return ((-0.5*x+2.5)*x-4)*x + 2
+	}
+	return 0
+}
+
+func clamp(v float32) uint8 {
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v + 0.5)
+}
+
+func toNRGBA(img image.Image) *image.NRGBA {
+	if nrgba, ok := img.(*image.NRGBA); ok && nrgba.Rect.Min == (image.Point{}) {
+		return nrgba
+	}
+
+	bounds := img.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+	return dst
+}
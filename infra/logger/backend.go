@@ -0,0 +1,134 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync/atomic"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+var (
+	// backend writes the lines instead of the default slog logger, see SetBackend
+	backend atomic.Pointer[slog.Handler]
+	// backendLevel is the minimum level of the backend, kept with the one of slog by SetLevel
+	backendLevel slog.LevelVar
+)
+
+// SetBackend writes the lines of every logger with handler (ex: the handler of a zap or a
+// zerolog logger), at the level of the logger whatever the level of handler. The lines reach
+// it masked, the remote log and the taps still receive them. nil writes them again with the
+// default slog logger. The returned function restores the previous backend.
+func SetBackend(handler slog.Handler) (restore func()) {
+	var previous *slog.Handler
+	if handler == nil {
+		previous = backend.Swap(nil)
+	} else {
+		previous = backend.Swap(&handler)
+	}
+	return func() {
+		backend.Store(previous)
+	}
+}
+
+// backendHandler is the handler of the loggers, forwarding the records to the current backend
+// so the loggers created before SetBackend follow it
+type backendHandler struct {
+	attrs []slog.Attr
+	group string
+}
+
+func (h *backendHandler) current() slog.Handler {
+	var handler slog.Handler
+	if b := backend.Load(); b != nil {
+		handler = *b
+	} else {
+		handler = slog.Default().Handler()
+	}
+	if h.group != "" {
+		handler = handler.WithGroup(h.group)
+	}
+	if len(h.attrs) > 0 {
+		handler = handler.WithAttrs(h.attrs)
+	}
+	return handler
+}
+
+func (h *backendHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	if backend.Load() != nil && l < backendLevel.Level() {
+		return false
+	}
+	return h.current().Enabled(ctx, l)
+}
+
+func (h *backendHandler) Handle(ctx context.Context, record slog.Record) error {
+	return h.current().Handle(ctx, record)
+}
+
+func (h *backendHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &backendHandler{attrs: append(slices.Clip(h.attrs), attrs...), group: h.group}
+}
+
+func (h *backendHandler) WithGroup(name string) slog.Handler {
+	if h.group != "" {
+		name = h.group + "." + name
+	}
+	return &backendHandler{attrs: h.attrs, group: name}
+}
+
+// Enabled reports whether the lines of level are logged
+func (l *Logger) Enabled(level slog.Level) bool {
+	if minLevel, ok := namedLevel(l.name); ok {
+		return level >= minLevel
+	}
+	return l.logger.Enabled(l.context, level)
+}
+
+// WithContext returns a logger adding to each line of l the request ID and the trace of ctx
+// (request_id, trace_id and span_id), logging in ctx
+func (l *Logger) WithContext(ctx context.Context) *Logger {
+	var args []any
+	if requestID := helper.RequestID(ctx); requestID != "" {
+		args = append(args, "request_id", requestID)
+	}
+	if traceParent := helper.TraceParent(ctx); traceParent != "" {
+		if traceID, _, ok := helper.ParseTraceParent(traceParent); ok {
+			args = append(args, "trace_id", traceID, "span_id", strings.Split(strings.TrimSpace(traceParent), "-")[2])
+		}
+	}
+	with := l.With(args...)
+	with.context = ctx
+	return with
+}
+
+// Port returns l as a port.Logger
+func (l *Logger) Port() port.Logger {
+	return portLogger{l}
+}
+
+// portLogger is a Logger whose With and WithContext return a port.Logger
+type portLogger struct {
+	*Logger
+}
+
+func (l portLogger) With(args ...any) port.Logger {
+	return portLogger{l.Logger.With(args...)}
+}
+
+func (l portLogger) WithContext(ctx context.Context) port.Logger {
+	return portLogger{l.Logger.WithContext(ctx)}
+}
+
+// Port returns the default logger as a port.Logger, for the code depending on the interface
+func Port() port.Logger {
+	return logDefault().Port()
+}
+
+// WithContext returns the default logger adding the request ID and the trace of ctx to each
+// line, see Logger.WithContext
+func WithContext(ctx context.Context) *Logger {
+	return logDefault().WithContext(ctx)
+}
@@ -0,0 +1,96 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sync"
+)
+
+// Capture is a backend keeping the logged lines in memory, for the tests asserting what the
+// code logs:
+//
+//	capture := logger.NewCapture()
+//	defer logger.SetBackend(capture)()
+type Capture struct {
+	mu      *sync.Mutex
+	entries *[]Entry
+	attrs   []slog.Attr
+	group   string
+}
+
+// NewCapture creates an empty capture
+func NewCapture() *Capture {
+	return &Capture{mu: &sync.Mutex{}, entries: &[]Entry{}}
+}
+
+// Entries returns the lines captured so far, in their order
+func (c *Capture) Entries() []Entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(*c.entries)
+}
+
+// Messages returns the messages of the lines captured at level or above
+func (c *Capture) Messages(level slog.Level) []string {
+	var messages []string
+	for _, entry := range c.Entries() {
+		if entry.Level >= level {
+			messages = append(messages, entry.Message)
+		}
+	}
+	return messages
+}
+
+// Reset forgets the captured lines
+func (c *Capture) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.entries = nil
+}
+
+func (c *Capture) Enabled(ctx context.Context, level slog.Level) bool {
+	return true
+}
+
+func (c *Capture) Handle(ctx context.Context, record slog.Record) error {
+	entry := Entry{Time: record.Time, Level: record.Level, Message: record.Message}
+	if len(c.attrs) > 0 || record.NumAttrs() > 0 {
+		entry.Attrs = make(map[string]any, len(c.attrs)+record.NumAttrs())
+		for _, attr := range c.attrs {
+			entry.Attrs[attr.Key] = attr.Value.Resolve().Any()
+		}
+		record.Attrs(func(attr slog.Attr) bool {
+			entry.Attrs[c.key(attr.Key)] = attr.Value.Resolve().Any()
+			return true
+		})
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.entries = append(*c.entries, entry)
+	return nil
+}
+
+func (c *Capture) WithAttrs(attrs []slog.Attr) slog.Handler {
+	with := *c
+	with.attrs = slices.Clip(c.attrs)
+	for _, attr := range attrs {
+		with.attrs = append(with.attrs, slog.Attr{Key: c.key(attr.Key), Value: attr.Value})
+	}
+	return &with
+}
+
+func (c *Capture) WithGroup(name string) slog.Handler {
+	with := *c
+	with.group = c.key(name)
+	return &with
+}
+
+// key prefixes key with the group of the handler (ex: "http.status")
+func (c *Capture) key(key string) string {
+	if c.group == "" {
+		return key
+	}
+	return c.group + "." + key
+}
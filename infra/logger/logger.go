@@ -2,10 +2,14 @@ package logger
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 
 	"github.com/webcore-go/webcore/app/helper"
 	"github.com/webcore-go/webcore/port"
@@ -13,43 +17,56 @@ import (
 
 var defaultLogger atomic.Pointer[Logger]
 
+// Entry is a logged line passed to the taps
+type Entry struct {
+	Time    time.Time
+	Level   slog.Level
+	Message string
+	Attrs   map[string]any
+}
+
+var (
+	tapsMu sync.RWMutex
+	taps   []func(Entry)
+)
+
+// AddTap registers fn to receive every line logged at the current level (ex: to stream them
+// to developers). fn must not block nor log.
+func AddTap(fn func(Entry)) {
+	tapsMu.Lock()
+	defer tapsMu.Unlock()
+	taps = append(taps, fn)
+}
+
+// Masker hides the personal data of the logged lines before they are written, sent to the
+// remote log or to the taps
+type Masker interface {
+	MaskMessage(msg string) string
+	MaskAttrs(args []any) []any
+	MaskValue(v any) any
+}
+
 // Logger represents shared logger
 type Logger struct {
 	context context.Context
 	logger  *slog.Logger
 	remote  port.IRemoteLog
+	masker  Masker
 	level   slog.Level
+	args    []any  // added to each line, see With
+	name    string // of the loggers created by Named
 }
 
 func PrepareLogger(ctx context.Context, level string) *Logger {
 	if defaultLogger.Load() == nil {
-		var logLevel slog.Level
-
-		// Set log level based on input
-		switch strings.ToLower(level) {
-		case "debug":
-			logLevel = slog.LevelDebug
-		case "info":
-			logLevel = slog.LevelInfo
-		case "warn":
-			logLevel = slog.LevelWarn
-		case "error":
-			logLevel = slog.LevelError
-		default:
+		logLevel, ok := parseLevel(level)
+		if !ok {
 			logLevel = slog.LevelInfo
 		}
 		slog.SetLogLoggerLevel(logLevel)
-		logger := slog.Default()
-		// handler := slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
-		// 	Level: logLevel,
-		// 	ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
-		// 		if a.Key == slog.TimeKey {
-		// 			return slog.Attr{}
-		// 		}
-		// 		return a
-		// 	},
-		// })
-		// logger := slog.New(handler)
+		backendLevel.Set(logLevel)
+		// the lines go to slog.Default until a backend is set, see SetBackend
+		logger := slog.New(&backendHandler{})
 
 		log := &Logger{
 			context: ctx,
@@ -66,12 +83,65 @@ func PrepareLogger(ctx context.Context, level string) *Logger {
 // Default returns the default [Logger].
 func logDefault() *Logger { return defaultLogger.Load() }
 
+func parseLevel(level string) (slog.Level, bool) {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug, true
+	case "info":
+		return slog.LevelInfo, true
+	case "warn":
+		return slog.LevelWarn, true
+	case "error":
+		return slog.LevelError, true
+	}
+	return slog.LevelInfo, false
+}
+
+// SetLevel changes the minimum level logged while the application runs: debug, info, warn or error
+func SetLevel(level string) error {
+	logLevel, ok := parseLevel(level)
+	if !ok {
+		return fmt.Errorf("unknown log level '%s'", level)
+	}
+
+	l := logDefault()
+	slog.SetLogLoggerLevel(logLevel)
+	backendLevel.Set(logLevel)
+	l.level = logLevel
+	if l.remote != nil {
+		l.remote.SetMinimumLevelLog(logLevel)
+	}
+	return nil
+}
+
+// Level returns the minimum level logged, in lower case
+func Level() string {
+	return strings.ToLower(logDefault().level.String())
+}
+
 func SetRemote(remote port.IRemoteLog) {
 	l := logDefault()
 	l.remote = remote
 	l.remote.SetMinimumLevelLog(l.level)
 }
 
+// SetMasker masks every logged line with masker, nil to stop masking
+func SetMasker(masker Masker) {
+	logDefault().masker = masker
+}
+
+// Flush sends the entries still buffered by the remote log, when it sends them in batches
+func Flush(ctx context.Context) error {
+	l := logDefault()
+	if l == nil {
+		return nil
+	}
+	if flusher, ok := l.remote.(port.IRemoteLogFlusher); ok {
+		return flusher.Flush(ctx)
+	}
+	return nil
+}
+
 func SetRemoteTag(key string, value string) {
 	l := logDefault()
 	if l.remote != nil {
@@ -100,19 +170,63 @@ func RemoteCaptureError(err error) {
 	}
 }
 
+// With returns a logger adding args to each line of l (ex: the request ID of a request). It
+// keeps the remote log and the masker l has when it is called.
+func (l *Logger) With(args ...any) *Logger {
+	with := *l
+	with.args = append(slices.Clip(l.args), args...)
+	return &with
+}
+
 // Log logs a message with the given level
 func (l *Logger) Log(level slog.Level, msg string, args ...any) {
-	l.logger.Log(l.context, level, msg, args...)
+	if len(l.args) > 0 {
+		args = append(slices.Clip(l.args), args...)
+	}
+	if l.masker != nil {
+		msg, args = l.masker.MaskMessage(msg), l.masker.MaskAttrs(args)
+	}
+	l.write(level, msg, args)
 	if l.remote != nil {
 		l.remote.Log(level, msg, args...)
 	}
+	l.tap(level, msg, args)
 }
 
 // Log logs a message with the given level
 func (l *Logger) LogJson(level slog.Level, msg string, obj any) {
-	l.logger.Log(l.context, level, msg+helper.ToLogJSON(obj))
+	args := l.args
+	if l.masker != nil {
+		msg, obj = l.masker.MaskMessage(msg), l.masker.MaskValue(obj)
+		args = l.masker.MaskAttrs(args)
+	}
+	l.write(level, msg+helper.ToLogJSON(obj), args)
 	if l.remote != nil {
-		l.remote.Log(level, msg+helper.ToLogJSON(obj))
+		l.remote.Log(level, msg+helper.ToLogJSON(obj), args...)
+	}
+	l.tap(level, msg+helper.ToLogJSON(obj), args)
+}
+
+func (l *Logger) tap(level slog.Level, msg string, args []any) {
+	tapsMu.RLock()
+	defer tapsMu.RUnlock()
+	if len(taps) == 0 || !l.Enabled(level) {
+		return
+	}
+
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.Add(args...)
+	entry := Entry{Time: record.Time, Level: level, Message: msg}
+	if record.NumAttrs() > 0 {
+		entry.Attrs = make(map[string]any, record.NumAttrs())
+		record.Attrs(func(attr slog.Attr) bool {
+			entry.Attrs[attr.Key] = attr.Value.Resolve().Any()
+			return true
+		})
+	}
+
+	for _, fn := range taps {
+		fn(entry)
 	}
 }
 
@@ -168,6 +282,11 @@ func (l *Logger) FatalJson(msg string, obj any) {
 	os.Exit(1)
 }
 
+// With returns a logger adding args to each line of the default logger, see Logger.With
+func With(args ...any) *Logger {
+	return logDefault().With(args...)
+}
+
 // Log logs a message with the given level
 func Log(level slog.Level, msg string, args ...any) {
 	logDefault().Log(level, msg, args...)
@@ -0,0 +1,105 @@
+package logger
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	namedMu sync.RWMutex
+	// named are the names of the loggers created by Named, with their level when it is not
+	// the one of the application
+	named = map[string]*slog.Level{}
+)
+
+// Named returns a logger of the component name (ex: a module or a library), adding
+// "logger": name to each line. Its level is the one of the application until SetNamedLevel
+// changes it, so a component can log in debug while the others do not. It is created once
+// the logger is prepared (ex: in the Init of a module).
+func Named(name string) *Logger {
+	return logDefault().Named(name)
+}
+
+// Named returns a logger of the component name adding the args of l, see Named
+func (l *Logger) Named(name string) *Logger {
+	namedMu.Lock()
+	if _, ok := named[name]; !ok {
+		named[name] = nil
+	}
+	namedMu.Unlock()
+
+	with := l.With("logger", name)
+	with.name = name
+	return with
+}
+
+// SetNamedLevel changes the minimum level of the lines of the loggers name: debug, info, warn
+// or error. The level may be set before the logger is created.
+func SetNamedLevel(name string, level string) error {
+	logLevel, ok := parseLevel(level)
+	if !ok {
+		return fmt.Errorf("unknown log level '%s'", level)
+	}
+
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	named[name] = &logLevel
+	return nil
+}
+
+// ResetNamedLevel logs the loggers name at the level of the application again
+func ResetNamedLevel(name string) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	if _, ok := named[name]; ok {
+		named[name] = nil
+	}
+}
+
+// NamedLevels returns the level of each named logger in lower case, empty for the ones at the
+// level of the application
+func NamedLevels() map[string]string {
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	levels := make(map[string]string, len(named))
+	for name, level := range named {
+		levels[name] = ""
+		if level != nil {
+			levels[name] = strings.ToLower(level.String())
+		}
+	}
+	return levels
+}
+
+// namedLevel returns the level of the named logger name, false when it has none of its own
+func namedLevel(name string) (slog.Level, bool) {
+	if name == "" {
+		return 0, false
+	}
+	namedMu.RLock()
+	defer namedMu.RUnlock()
+	if level := named[name]; level != nil {
+		return *level, true
+	}
+	return 0, false
+}
+
+// write writes a line with the handler of l, at the level of its name when it has one
+func (l *Logger) write(level slog.Level, msg string, args []any) {
+	minLevel, ok := namedLevel(l.name)
+	if !ok {
+		l.logger.Log(l.context, level, msg, args...)
+		return
+	}
+	if level < minLevel {
+		return
+	}
+
+	// the handler would filter the line at the level of the application
+	record := slog.NewRecord(time.Now(), level, msg, 0)
+	record.Add(args...)
+	_ = l.logger.Handler().Handle(l.context, record)
+}
@@ -0,0 +1,262 @@
+package mask
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// Tag is the struct tag naming the kind of data of a field, masked when the policy lists it
+// (ex: `mask:"pii"`)
+const Tag = "mask"
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// Policy masks the personal data of values: the fields named in the policy, the fields tagged
+// with a kind of the policy, the matches of its patterns in the strings, and the credentials
+// hidden by config.Redact. A nil policy masks nothing.
+type Policy struct {
+	fields   map[string]bool
+	tags     map[string]bool
+	patterns []*regexp.Regexp
+	keep     int
+}
+
+// Compile builds the policy of cfg, it fails on an invalid pattern
+func Compile(cfg config.MaskingPolicyConfig) (*Policy, error) {
+	p := &Policy{fields: map[string]bool{}, tags: map[string]bool{}, keep: max(cfg.Keep, 0)}
+	for _, field := range cfg.Fields {
+		p.fields[normalizeName(field)] = true
+	}
+	for _, tag := range cfg.Tags {
+		p.tags[strings.ToLower(tag)] = true
+	}
+	for _, pattern := range cfg.Patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid masking pattern '%s': %v", pattern, err)
+		}
+		p.patterns = append(p.patterns, re)
+	}
+	return p, nil
+}
+
+// Merge returns a policy masking what any of policies masks, keeping the fewest characters
+func Merge(policies ...*Policy) *Policy {
+	merged := &Policy{fields: map[string]bool{}, tags: map[string]bool{}, keep: -1}
+	for _, p := range policies {
+		if p == nil {
+			continue
+		}
+		for field := range p.fields {
+			merged.fields[field] = true
+		}
+		for tag := range p.tags {
+			merged.tags[tag] = true
+		}
+		merged.patterns = append(merged.patterns, p.patterns...)
+		if merged.keep < 0 || p.keep < merged.keep {
+			merged.keep = p.keep
+		}
+	}
+	merged.keep = max(merged.keep, 0)
+	return merged
+}
+
+// Field tells whether the field name (or key, or log attribute) with the mask tag tag is masked
+func (p *Policy) Field(name string, tag string) bool {
+	if p == nil {
+		return false
+	}
+	if p.fields[normalizeName(name)] {
+		return true
+	}
+	// a bare "key" names the keys of the storage and the caches in the logs, not a credential
+	if config.IsSecretKey(name) && !strings.EqualFold(name, "key") {
+		return true
+	}
+	for _, kind := range strings.Split(tag, ",") {
+		if kind = strings.TrimSpace(strings.ToLower(kind)); kind != "" && p.tags[kind] {
+			return true
+		}
+	}
+	return false
+}
+
+// String masks the matches of the patterns in s
+func (p *Policy) String(s string) string {
+	if p == nil {
+		return s
+	}
+	for _, re := range p.patterns {
+		s = re.ReplaceAllStringFunc(s, p.hide)
+	}
+	return s
+}
+
+// Hide returns the masked form of a value, the last characters of the policy left visible
+func (p *Policy) Hide(value any) any {
+	if value == nil {
+		return nil
+	}
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+	}
+	if s == "" {
+		return s
+	}
+	return p.hide(s)
+}
+
+func (p *Policy) hide(s string) string {
+	keep := 0
+	if p != nil && utf8.RuneCountInString(s) > 2*p.keep {
+		keep = p.keep
+	}
+	if keep == 0 {
+		return config.RedactedValue
+	}
+	runes := []rune(s)
+	return strings.Repeat("*", len(runes)-keep) + string(runes[len(runes)-keep:])
+}
+
+// Attrs masks the key-value pairs (and slog.Attr) of a log line
+func (p *Policy) Attrs(args []any) []any {
+	if p == nil || len(args) == 0 {
+		return args
+	}
+
+	masked := make([]any, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		switch arg := args[i].(type) {
+		case slog.Attr:
+			masked = append(masked, slog.Any(arg.Key, p.keyValue(arg.Key, arg.Value.Resolve().Any())))
+		case string:
+			if i+1 < len(args) {
+				masked = append(masked, arg, p.keyValue(arg, args[i+1]))
+				i++
+				continue
+			}
+			masked = append(masked, p.String(arg))
+		default:
+			masked = append(masked, arg)
+		}
+	}
+	return masked
+}
+
+func (p *Policy) keyValue(key string, value any) any {
+	if p.Field(key, "") {
+		return p.Hide(value)
+	}
+	return p.Value(value)
+}
+
+// Value returns a copy of v with its masked fields hidden, structs become maps keyed by their
+// JSON names
+func (p *Policy) Value(v any) any {
+	if p == nil || v == nil {
+		return v
+	}
+	if err, ok := v.(error); ok {
+		return p.String(err.Error())
+	}
+	return p.value(reflect.ValueOf(v))
+}
+
+func (p *Policy) value(v reflect.Value) any {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.CanInterface() {
+		return nil
+	}
+
+	switch value := v.Interface().(type) {
+	case time.Time, time.Duration:
+		return value
+	}
+	if v.Kind() != reflect.String && (v.Type().Implements(jsonMarshalerType) || v.Type().Implements(textMarshalerType)) {
+		return v.Interface()
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		return p.String(v.String())
+	case reflect.Struct:
+		result := map[string]any{}
+		for _, field := range reflect.VisibleFields(v.Type()) {
+			if !field.IsExported() || field.Anonymous {
+				continue
+			}
+			name := jsonName(field)
+			if name == "" {
+				continue
+			}
+			value := v.FieldByIndex(field.Index)
+			if p.Field(name, field.Tag.Get(Tag)) || p.Field(field.Name, "") {
+				if !value.IsZero() {
+					result[name] = p.Hide(value.Interface())
+				}
+				continue
+			}
+			result[name] = p.value(value)
+		}
+		return result
+	case reflect.Map:
+		result := make(map[string]any, v.Len())
+		for _, k := range v.MapKeys() {
+			key := fmt.Sprint(k.Interface())
+			if p.Field(key, "") {
+				result[key] = p.Hide(v.MapIndex(k).Interface())
+				continue
+			}
+			result[key] = p.value(v.MapIndex(k))
+		}
+		return result
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		result := make([]any, v.Len())
+		for i := range result {
+			result[i] = p.value(v.Index(i))
+		}
+		return result
+	}
+	return v.Interface()
+}
+
+func jsonName(field reflect.StructField) string {
+	if tag, ok := field.Tag.Lookup("json"); ok {
+		name, _, _ := strings.Cut(tag, ",")
+		if name == "-" {
+			return ""
+		}
+		if name != "" {
+			return name
+		}
+	}
+	return field.Name
+}
+
+// normalizeName lowercases name without its separators, so "cardNumber", "card_number" and
+// "Card-Number" are the same field
+func normalizeName(name string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "", " ", "", ".", "").Replace(name))
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+)
+
+// HTTP is the metrics of the requests served: their count by method, route and status, and
+// their latency by method and route
+type HTTP struct {
+	requests *Counter
+	duration *Histogram
+}
+
+// NewHTTP creates the metrics of the requests, their latency counted in buckets (DefBuckets
+// when empty)
+func NewHTTP(buckets []float64) *HTTP {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	return &HTTP{
+		requests: NewCounter("http_requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		duration: NewHistogram("http_request_duration_seconds", "Duration of the HTTP requests in seconds.", buckets, "method", "route"),
+	}
+}
+
+// Observe records a request to route, the path of the matched route (ex: /api/orders/:id) so
+// the number of series stays bounded
+func (h *HTTP) Observe(method string, route string, status int, latency time.Duration) {
+	h.requests.Inc(method, route, strconv.Itoa(status))
+	h.duration.ObserveDuration(latency, method, route)
+}
+
+func (h *HTTP) Collect() []Family {
+	return append(h.requests.Collect(), h.duration.Collect()...)
+}
@@ -0,0 +1,203 @@
+// Package metrics keeps the counters, gauges and histograms of the application and serves them
+// in the Prometheus text format.
+//
+// The metrics are created once and registered on a Registry, Default unless another one is
+// given:
+//
+//	var queryDuration = metrics.NewHistogram("db_query_duration_seconds", "Duration of the queries",
+//		metrics.DefBuckets, "operation")
+//
+//	func init() {
+//		metrics.MustRegister(queryDuration)
+//	}
+//
+//	queryDuration.ObserveDuration(time.Since(start), "find")
+//
+// Any value computed on each scrape (ex: the lag of a consumer, the hit ratio of a cache) is a
+// Collector returning its families, or a GaugeFunc.
+package metrics
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Type is the kind of the metrics of a family
+type Type string
+
+const (
+	TypeCounter   Type = "counter"
+	TypeGauge     Type = "gauge"
+	TypeHistogram Type = "histogram"
+	TypeUntyped   Type = "untyped"
+)
+
+// Label is a dimension of a metric
+type Label struct {
+	Name  string
+	Value string
+}
+
+// Bucket counts the observations of a histogram lower or equal to UpperBound
+type Bucket struct {
+	UpperBound float64
+	Count      uint64
+}
+
+// Metric is a value of a family for one set of labels
+type Metric struct {
+	Labels []Label
+	Value  float64 // counters, gauges and untyped
+
+	// histograms only, Buckets cumulative and sorted by UpperBound
+	Buckets []Bucket
+	Count   uint64
+	Sum     float64
+}
+
+// Family is the metrics of the same name, one per set of labels
+type Family struct {
+	Name    string
+	Help    string
+	Type    Type
+	Metrics []Metric
+}
+
+// Collector returns its families on each scrape. A loaded library implementing it (ex: the
+// durations of the queries of a database, the lag of a consumer) is collected from every
+// instance by the LibraryManager.
+type Collector interface {
+	Collect() []Family
+}
+
+// named is a Collector of a single family, whose name is checked when it is registered
+type named interface {
+	Collector
+	name() string
+}
+
+var namePattern = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// Registry is a set of collectors gathered together
+type Registry struct {
+	mu         sync.RWMutex
+	collectors []Collector
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Register adds c to the registry, a pointer or a comparable value. It fails when c is already
+// registered, or when c is a metric of this package whose name is invalid or taken by another
+// one.
+func (r *Registry) Register(c Collector) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if slices.Contains(r.collectors, c) {
+		return errors.New("metrics: collector already registered")
+	}
+	if n, ok := c.(named); ok {
+		if !namePattern.MatchString(n.name()) {
+			return fmt.Errorf("metrics: invalid name '%s'", n.name())
+		}
+		for _, other := range r.collectors {
+			if o, ok := other.(named); ok && o.name() == n.name() {
+				return fmt.Errorf("metrics: '%s' already registered", n.name())
+			}
+		}
+	}
+	r.collectors = append(r.collectors, c)
+	return nil
+}
+
+// MustRegister registers cs, it panics when one fails
+func (r *Registry) MustRegister(cs ...Collector) {
+	for _, c := range cs {
+		if err := r.Register(c); err != nil {
+			panic(err)
+		}
+	}
+}
+
+// Unregister removes c, it returns false when c was not registered
+func (r *Registry) Unregister(c Collector) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i := slices.Index(r.collectors, c)
+	if i < 0 {
+		return false
+	}
+	r.collectors = slices.Delete(r.collectors, i, i+1)
+	return true
+}
+
+// Gather collects the families of the registered collectors, sorted by name. The families of
+// the same name (ex: from two instances of a library) are merged, the help and the type of the
+// first one kept.
+func (r *Registry) Gather() []Family {
+	r.mu.RLock()
+	collectors := slices.Clone(r.collectors)
+	r.mu.RUnlock()
+
+	byName := map[string]*Family{}
+	var families []*Family
+	for _, c := range collectors {
+		for _, family := range c.Collect() {
+			if !namePattern.MatchString(family.Name) || len(family.Metrics) == 0 {
+				continue
+			}
+			merged, ok := byName[family.Name]
+			if !ok {
+				merged = &Family{Name: family.Name, Help: family.Help, Type: cmp.Or(family.Type, TypeUntyped)}
+				byName[family.Name] = merged
+				families = append(families, merged)
+			}
+			merged.Metrics = append(merged.Metrics, family.Metrics...)
+		}
+	}
+
+	gathered := make([]Family, 0, len(families))
+	for _, family := range families {
+		slices.SortStableFunc(family.Metrics, func(a, b Metric) int { return compareLabels(a.Labels, b.Labels) })
+		gathered = append(gathered, *family)
+	}
+	slices.SortFunc(gathered, func(a, b Family) int { return strings.Compare(a.Name, b.Name) })
+	return gathered
+}
+
+func compareLabels(a []Label, b []Label) int {
+	return slices.CompareFunc(a, b, func(x, y Label) int {
+		return cmp.Or(strings.Compare(x.Name, y.Name), strings.Compare(x.Value, y.Value))
+	})
+}
+
+// Default is the registry of the application, served on app.metrics.path. It collects the
+// runtime metrics of the process.
+var Default = NewRegistry()
+
+func init() {
+	Default.MustRegister(runtimeCollector{})
+}
+
+// Register adds c to the Default registry, see Registry.Register
+func Register(c Collector) error {
+	return Default.Register(c)
+}
+
+// MustRegister registers cs on the Default registry, it panics when one fails
+func MustRegister(cs ...Collector) {
+	Default.MustRegister(cs...)
+}
+
+// Unregister removes c from the Default registry
+func Unregister(c Collector) bool {
+	return Default.Unregister(c)
+}
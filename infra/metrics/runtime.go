@@ -0,0 +1,29 @@
+package metrics
+
+import (
+	"runtime"
+	"time"
+)
+
+var processStart = time.Now()
+
+// runtimeCollector collects the goroutines, the memory and the collections of the Go runtime
+type runtimeCollector struct{}
+
+func (runtimeCollector) Collect() []Family {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	gauge := func(name string, help string, value float64) Family {
+		return Family{Name: name, Help: help, Type: TypeGauge, Metrics: []Metric{{Value: value}}}
+	}
+	return []Family{
+		gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine())),
+		gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(mem.Alloc)),
+		gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(mem.HeapInuse)),
+		gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(mem.Sys)),
+		{Name: "go_gc_cycles_total", Help: "Number of completed GC cycles.", Type: TypeCounter, Metrics: []Metric{{Value: float64(mem.NumGC)}}},
+		{Name: "go_gc_pause_seconds_total", Help: "Total GC pause duration in seconds.", Type: TypeCounter, Metrics: []Metric{{Value: time.Duration(mem.PauseTotalNs).Seconds()}}},
+		gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(processStart.UnixNano())/1e9),
+	}
+}
@@ -0,0 +1,96 @@
+package metrics
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ContentType is the media type of the Prometheus text format
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// Handler serves the families of r in the Prometheus text format
+func (r *Registry) Handler() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var buf bytes.Buffer
+		if err := WriteText(&buf, r.Gather()); err != nil {
+			return err
+		}
+		c.Set(fiber.HeaderContentType, ContentType)
+		return c.Send(buf.Bytes())
+	}
+}
+
+// Handler serves the Default registry, see Registry.Handler
+func Handler() fiber.Handler {
+	return Default.Handler()
+}
+
+// WriteText writes families in the Prometheus text format
+func WriteText(w io.Writer, families []Family) error {
+	bw := bufio.NewWriter(w)
+	for _, family := range families {
+		if family.Help != "" {
+			bw.WriteString("# HELP " + family.Name + " " + helpEscaper.Replace(family.Help) + "\n")
+		}
+		bw.WriteString("# TYPE " + family.Name + " " + string(family.Type) + "\n")
+
+		for _, m := range family.Metrics {
+			if family.Type != TypeHistogram {
+				writeSample(bw, family.Name, m.Labels, nil, m.Value)
+				continue
+			}
+			for _, bucket := range m.Buckets {
+				writeSample(bw, family.Name+"_bucket", m.Labels, &Label{"le", formatFloat(bucket.UpperBound)}, float64(bucket.Count))
+			}
+			writeSample(bw, family.Name+"_bucket", m.Labels, &Label{"le", "+Inf"}, float64(m.Count))
+			writeSample(bw, family.Name+"_sum", m.Labels, nil, m.Sum)
+			writeSample(bw, family.Name+"_count", m.Labels, nil, float64(m.Count))
+		}
+	}
+	return bw.Flush()
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// writeSample writes the line of a sample, extra (ex: the le of a bucket) after its labels
+func writeSample(w *bufio.Writer, name string, labels []Label, extra *Label, value float64) {
+	w.WriteString(name)
+	if len(labels) > 0 || extra != nil {
+		w.WriteByte('{')
+		for i, label := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(label.Name + `="` + valueEscaper.Replace(label.Value) + `"`)
+		}
+		if extra != nil {
+			if len(labels) > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(extra.Name + `="` + extra.Value + `"`)
+		}
+		w.WriteByte('}')
+	}
+	w.WriteString(" " + formatFloat(value) + "\n")
+}
+
+func formatFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return "+Inf"
+	case math.IsInf(f, -1):
+		return "-Inf"
+	case math.IsNaN(f):
+		return "NaN"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
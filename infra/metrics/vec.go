@@ -0,0 +1,223 @@
+package metrics
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are the upper bounds, in seconds, of the histograms of durations
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// vec keeps the values of a family by label values, created on their first use
+type vec[T any] struct {
+	mu     sync.Mutex
+	family string
+	help   string
+	labels []string
+	values map[string]*T
+	keys   map[string][]string // label values by key
+}
+
+func newVec[T any](name string, help string, labels []string) vec[T] {
+	return vec[T]{family: name, help: help, labels: labels, values: map[string]*T{}, keys: map[string][]string{}}
+}
+
+func (v *vec[T]) name() string {
+	return v.family
+}
+
+// with returns the value of labelValues, created with init. It panics when their number is not
+// the one of the labels of the family. The lock is held by the caller.
+func (v *vec[T]) with(labelValues []string, init func() *T) *T {
+	if len(labelValues) != len(v.labels) {
+		panic(fmt.Sprintf("metrics: %s has %d labels, %d values given", v.family, len(v.labels), len(labelValues)))
+	}
+	key := strings.Join(labelValues, "\xff")
+	value, ok := v.values[key]
+	if !ok {
+		value = init()
+		v.values[key] = value
+		v.keys[key] = slices.Clone(labelValues)
+	}
+	return value
+}
+
+// collect returns a Family of typ with the metric of each value made by metric
+func (v *vec[T]) collect(typ Type, metric func(value *T) Metric) []Family {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	family := Family{Name: v.family, Help: v.help, Type: typ, Metrics: make([]Metric, 0, len(v.values))}
+	for key, value := range v.values {
+		m := metric(value)
+		m.Labels = make([]Label, len(v.labels))
+		for i, name := range v.labels {
+			m.Labels[i] = Label{Name: name, Value: v.keys[key][i]}
+		}
+		family.Metrics = append(family.Metrics, m)
+	}
+	return []Family{family}
+}
+
+// Reset removes the values of every label values (ex: the partitions a consumer no longer reads)
+func (v *vec[T]) Reset() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	clear(v.values)
+	clear(v.keys)
+}
+
+func newFloat() *float64 {
+	return new(float64)
+}
+
+// Counter is a value going up only (ex: the requests handled), by the values of its labels
+type Counter struct {
+	vec[float64]
+}
+
+// NewCounter creates the counter name, its values given in the order of labels
+func NewCounter(name string, help string, labels ...string) *Counter {
+	return &Counter{newVec[float64](name, help, labels)}
+}
+
+// Inc adds 1 to the counter of labelValues
+func (c *Counter) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds delta to the counter of labelValues, it panics when delta is negative
+func (c *Counter) Add(delta float64, labelValues ...string) {
+	if delta < 0 {
+		panic(fmt.Sprintf("metrics: counter %s cannot decrease", c.family))
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	*c.with(labelValues, newFloat) += delta
+}
+
+func (c *Counter) Collect() []Family {
+	return c.collect(TypeCounter, func(value *float64) Metric { return Metric{Value: *value} })
+}
+
+// Gauge is a value going up and down (ex: the jobs waiting), by the values of its labels
+type Gauge struct {
+	vec[float64]
+}
+
+// NewGauge creates the gauge name, its values given in the order of labels
+func NewGauge(name string, help string, labels ...string) *Gauge {
+	return &Gauge{newVec[float64](name, help, labels)}
+}
+
+// Set sets the gauge of labelValues to value
+func (g *Gauge) Set(value float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues, newFloat) = value
+}
+
+// Add adds delta, negative to decrease it, to the gauge of labelValues
+func (g *Gauge) Add(delta float64, labelValues ...string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	*g.with(labelValues, newFloat) += delta
+}
+
+// Inc adds 1 to the gauge of labelValues
+func (g *Gauge) Inc(labelValues ...string) {
+	g.Add(1, labelValues...)
+}
+
+// Dec subtracts 1 from the gauge of labelValues
+func (g *Gauge) Dec(labelValues ...string) {
+	g.Add(-1, labelValues...)
+}
+
+func (g *Gauge) Collect() []Family {
+	return g.collect(TypeGauge, func(value *float64) Metric { return Metric{Value: *value} })
+}
+
+type histogram struct {
+	counts []uint64 // by bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Histogram counts the observations (ex: the durations of the requests) in buckets, by the
+// values of its labels
+type Histogram struct {
+	vec[histogram]
+	buckets []float64
+}
+
+// NewHistogram creates the histogram name counting the observations lower or equal to each of
+// buckets (ex: DefBuckets), its values given in the order of labels
+func NewHistogram(name string, help string, buckets []float64, labels ...string) *Histogram {
+	buckets = slices.Clone(buckets)
+	slices.Sort(buckets)
+	buckets = slices.Compact(buckets)
+	buckets = slices.DeleteFunc(buckets, func(b float64) bool { return math.IsInf(b, 1) || math.IsNaN(b) })
+	return &Histogram{vec: newVec[histogram](name, help, labels), buckets: buckets}
+}
+
+// Observe adds value to the histogram of labelValues
+func (h *Histogram) Observe(value float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	hist := h.with(labelValues, func() *histogram { return &histogram{counts: make([]uint64, len(h.buckets))} })
+	if i, _ := slices.BinarySearch(h.buckets, value); i < len(h.buckets) {
+		hist.counts[i]++
+	}
+	hist.count++
+	hist.sum += value
+}
+
+// ObserveDuration adds d in seconds to the histogram of labelValues
+func (h *Histogram) ObserveDuration(d time.Duration, labelValues ...string) {
+	h.Observe(d.Seconds(), labelValues...)
+}
+
+func (h *Histogram) Collect() []Family {
+	return h.collect(TypeHistogram, func(hist *histogram) Metric {
+		m := Metric{Buckets: make([]Bucket, len(h.buckets)), Count: hist.count, Sum: hist.sum}
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			m.Buckets[i] = Bucket{UpperBound: bound, Count: cumulative}
+		}
+		return m
+	})
+}
+
+// funcMetric is a counter or a gauge read from a function on each scrape
+type funcMetric struct {
+	family string
+	help   string
+	typ    Type
+	fn     func() float64
+}
+
+// NewGaugeFunc creates the gauge name whose value is read from fn on each scrape (ex: the hit
+// ratio of a cache)
+func NewGaugeFunc(name string, help string, fn func() float64) Collector {
+	return &funcMetric{family: name, help: help, typ: TypeGauge, fn: fn}
+}
+
+// NewCounterFunc creates the counter name whose value is read from fn on each scrape (ex: the
+// hits counted by a client library)
+func NewCounterFunc(name string, help string, fn func() float64) Collector {
+	return &funcMetric{family: name, help: help, typ: TypeCounter, fn: fn}
+}
+
+func (f *funcMetric) name() string {
+	return f.family
+}
+
+func (f *funcMetric) Collect() []Family {
+	return []Family{{Name: f.family, Help: f.help, Type: f.typ, Metrics: []Metric{{Value: f.fn()}}}}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/mask"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// AccessLog creates the middleware logging each request once answered: method, route, status,
+// latency, sizes, user and request ID, with the JSON bodies when cfg.Body, their cfg.Redact
+// fields masked. The requests are sampled at cfg.SampleRate, or the rate of their route, and
+// the 5xx are always logged with cfg.KeepErrors. It handles the error of the handlers itself
+// so the logged status is the one sent.
+func AccessLog(cfg config.AccessLogConfig) (fiber.Handler, error) {
+	redact, err := mask.Compile(config.MaskingPolicyConfig{Fields: cfg.Redact})
+	if err != nil {
+		return nil, err
+	}
+
+	// the keys of the configuration are lowercased
+	routes := make(map[string]float64, len(cfg.Routes))
+	for route, rate := range cfg.Routes {
+		routes[strings.ToLower(route)] = rate
+	}
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if !(cfg.KeepErrors && status >= fiber.StatusInternalServerError) && !sampled(routes, cfg.SampleRate, c.Method(), c.Route().Path) {
+			return nil
+		}
+
+		fields := []any{
+			"method", c.Method(),
+			"path", c.Path(),
+			"route", c.Route().Path,
+			"status", status,
+			"latency", time.Since(start),
+			"request_size", len(c.Request().Body()),
+			"response_size", len(c.Response().Body()),
+			"ip", c.IP(),
+		}
+		if userID := auth.CurrentUserID(c); userID != "" {
+			fields = append(fields, "user_id", userID)
+		}
+		if cfg.Body {
+			if body, ok := logBody(c.Request().Body(), string(c.Request().Header.ContentType()), cfg.MaxBody, redact); ok {
+				fields = append(fields, "request_body", body)
+			}
+			if body, ok := logBody(c.Response().Body(), string(c.Response().Header.ContentType()), cfg.MaxBody, redact); ok {
+				fields = append(fields, "response_body", body)
+			}
+		}
+
+		log := logger.With("request_id", helper.RequestID(c.UserContext()))
+		switch {
+		case status >= fiber.StatusInternalServerError:
+			log.Error("HTTP access", fields...)
+		case status >= fiber.StatusBadRequest:
+			log.Warn("HTTP access", fields...)
+		default:
+			log.Info("HTTP access", fields...)
+		}
+		return nil
+	}, nil
+}
+
+// sampled draws whether the request to route is logged, at the rate of "<method> <route>" in
+// routes, of route, else at rate
+func sampled(routes map[string]float64, rate float64, method string, route string) bool {
+	route = strings.ToLower(route)
+	if r, ok := routes[strings.ToLower(method)+" "+route]; ok {
+		rate = r
+	} else if r, ok := routes[route]; ok {
+		rate = r
+	}
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// logBody returns the JSON body with its redacted fields masked, false when it is not JSON or
+// is larger than maxBody
+func logBody(body []byte, contentType string, maxBody int, redact *mask.Policy) (any, bool) {
+	if len(body) == 0 || len(body) > maxBody || !strings.Contains(contentType, "json") {
+		return nil, false
+	}
+	var value any
+	if err := json.Unmarshal(body, &value); err != nil {
+		return nil, false
+	}
+	return redact.Value(value), true
+}
@@ -53,7 +53,7 @@ func RoleRequired(allowedRoles ...string) fiber.Handler {
 		// For JWT authentication, check the role claims
 		userRole := GetUserRole(c)
 		if userRole == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "User role not found in context"))
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "User role not found in context"))
 		}
 
 		role := userRole.(string)
@@ -82,7 +82,7 @@ func PermissionRequired(requiredPermission string) fiber.Handler {
 		// For JWT authentication, check the permission claims
 		userPermissions := GetUserPermissions(c)
 		if userPermissions == nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "User permissions not found in context"))
+			return out.Send(c, out.Error(fiber.StatusUnauthorized, 2, "UNAUTHORIZED", "User permissions not found in context"))
 		}
 
 		permissions := userPermissions.([]any)
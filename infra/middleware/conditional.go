@@ -0,0 +1,114 @@
+package middleware
+
+import (
+	"bytes"
+	"hash/crc32"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// crc32 table of the ETags of fiber's etag middleware, so the tags stay the same
+var etagTable = crc32.MakeTable(0xD5828281)
+
+// ConditionalRequestMiddleware generates ETag validators for successful GET and HEAD
+// responses and answers If-None-Match / If-Modified-Since requests with 304 Not Modified. A
+// streamed response (ex: out.StreamFile) is sent as it is, its ETag would need the whole body
+// in memory.
+func ConditionalRequestMiddleware(cfg config.ETagConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if c.Response().IsBodyStream() {
+			return nil
+		}
+
+		tagResponse(c, cfg.Weak)
+
+		// If-None-Match takes precedence over If-Modified-Since (RFC 9110 13.1.3)
+		if c.Get(fiber.HeaderIfNoneMatch) != "" {
+			return nil
+		}
+
+		if notModifiedSince(c) {
+			c.Context().ResetBody()
+			c.Status(fiber.StatusNotModified)
+		}
+
+		return nil
+	}
+}
+
+// tagResponse sets the ETag header of a 200 response to GET or HEAD, answering 304 Not
+// Modified with it when it matches If-None-Match, the same way as fiber's etag middleware
+func tagResponse(c *fiber.Ctx, weak bool) {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return
+	}
+
+	body := c.Response().Body()
+	if c.Response().StatusCode() != fiber.StatusOK || len(body) == 0 {
+		return
+	}
+	if len(c.Response().Header.Peek(fiber.HeaderETag)) > 0 {
+		return
+	}
+
+	etag := []byte{}
+	if weak {
+		etag = append(etag, "W/"...)
+	}
+	etag = append(etag, '"')
+	etag = strconv.AppendUint(etag, uint64(len(body)), 10)
+	etag = append(etag, '-')
+	etag = strconv.AppendUint(etag, uint64(crc32.Checksum(body, etagTable)), 10)
+	etag = append(etag, '"')
+
+	// a 304 carries the validator of the representation (RFC 9110 15.4.5)
+	c.Response().Header.SetBytesV(fiber.HeaderETag, etag)
+
+	clientETag := c.Request().Header.Peek(fiber.HeaderIfNoneMatch)
+	matched := bytes.Contains(clientETag, etag)
+	if bytes.HasPrefix(clientETag, []byte("W/")) {
+		// W/1 == 1 || W/1 == W/1
+		matched = bytes.Equal(clientETag[2:], etag) || (weak && bytes.Equal(clientETag[2:], etag[2:]))
+	}
+	if matched {
+		c.Context().ResetBody()
+		c.Status(fiber.StatusNotModified)
+	}
+}
+
+// notModifiedSince reports whether the response Last-Modified header is not newer
+// than the request If-Modified-Since header
+func notModifiedSince(c *fiber.Ctx) bool {
+	if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+		return false
+	}
+
+	if c.Response().StatusCode() != fiber.StatusOK {
+		return false
+	}
+
+	since := c.Get(fiber.HeaderIfModifiedSince)
+	lastModified := string(c.Response().Header.Peek(fiber.HeaderLastModified))
+	if since == "" || lastModified == "" {
+		return false
+	}
+
+	sinceTime, err := http.ParseTime(since)
+	if err != nil {
+		return false
+	}
+
+	modifiedTime, err := http.ParseTime(lastModified)
+	if err != nil {
+		return false
+	}
+
+	return !modifiedTime.Truncate(time.Second).After(sinceTime)
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"net"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port"
+)
+
+// GeoIP resolves the client IP (behind the configured proxy header) with provider and stores
+// the result for the next handlers, read it with helper.CurrentGeo(c) or helper.Geo(ctx)
+func GeoIP(provider port.IGeoIPProvider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if ip := net.ParseIP(c.IP()); ip != nil {
+			info, err := provider.Lookup(ip)
+			if err != nil {
+				logger.Debug("GeoIP lookup failed", "ip", c.IP(), "error", err)
+			} else {
+				c.Locals(helper.GeoLocalsKey, info)
+				c.SetUserContext(helper.WithGeo(c.UserContext(), info))
+			}
+		}
+
+		return c.Next()
+	}
+}
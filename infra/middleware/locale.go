@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/i18n"
+)
+
+// Locale resolves the locale of the request among cfg.Locales, from the cfg.Query parameter,
+// then the Accept-Language header, then cfg.Default, and stores it for the next handlers, read
+// it with helper.CurrentLocale(c) or helper.Locale(ctx). The response tells the chosen locale
+// in Content-Language.
+func Locale(cfg config.I18nConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tag := ""
+		if cfg.Query != "" {
+			if query := c.Query(cfg.Query); query != "" {
+				tag, _ = i18n.Match(query, cfg.Locales)
+			}
+		}
+		if tag == "" {
+			tag, _ = i18n.Match(c.Get(fiber.HeaderAcceptLanguage), cfg.Locales)
+		}
+		if tag == "" {
+			tag = cfg.Default
+		}
+
+		locale := i18n.Default(tag)
+		c.Locals(helper.LocaleLocalsKey, locale)
+		c.SetUserContext(helper.WithLocale(c.UserContext(), locale))
+
+		c.Set(fiber.HeaderContentLanguage, locale.Tag)
+		c.Vary(fiber.HeaderAcceptLanguage)
+		return c.Next()
+	}
+}
@@ -2,9 +2,12 @@ package middleware
 
 import (
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
 	"github.com/webcore-go/webcore/infra/logger"
 )
 
@@ -22,15 +25,26 @@ func RequestLogger() fiber.Handler {
 		// Get response status
 		status := c.Response().StatusCode()
 
-		// Log the request
-		logger.Debug("HTTP Request",
+		fields := []any{
+			"request_id", helper.RequestID(c.UserContext()),
 			"method", c.Method(),
 			"path", c.Path(),
 			"status", status,
 			"latency", latency,
 			"ip", c.IP(),
 			"user_agent", c.Get("User-Agent"),
-		)
+		}
+		if geo := helper.CurrentGeo(c); geo != nil {
+			if geo.Country != "" {
+				fields = append(fields, "country", geo.Country)
+			}
+			if geo.ASN != 0 {
+				fields = append(fields, "asn", geo.ASN)
+			}
+		}
+
+		// Log the request
+		logger.Debug("HTTP Request", fields...)
 
 		return err
 	}
@@ -47,26 +61,83 @@ func RemoveTrailingSlash() fiber.Handler {
 	}
 }
 
-// RequestID creates a middleware for generating and tracking request IDs
+// HeaderTraceParent is the W3C Trace Context header carrying the trace of the request
+const HeaderTraceParent = "traceparent"
+
+// RequestID creates the middleware correlating each request: it honors the X-Request-ID and
+// the traceparent of the caller, generates them when missing, stores them in the user context
+// (helper.RequestID, helper.TraceParent) and answers the request ID in X-Request-ID
 func RequestID() fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		// Generate or get existing request ID
-		requestID := c.Get("X-Request-ID")
-		if requestID == "" {
-			// In a real implementation, you would generate a proper UUID
-			requestID = "req-" + time.Now().Format("20060102150405")
+		traceID, flags, traced := helper.ParseTraceParent(c.Get(HeaderTraceParent))
+
+		// Honor the request ID of the caller, or continue its trace
+		requestID := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(requestID) {
+			requestID = traceID
+			if !traced {
+				requestID = helper.RandomHex(16)
+				traceID = requestID
+			}
 		}
 
 		// Set request ID in response header
-		c.Set("X-Request-ID", requestID)
+		c.Set(fiber.HeaderXRequestID, requestID)
 
 		// Store in context for use in other handlers/middleware
 		c.Locals("request_id", requestID)
+		ctx := helper.WithRequestID(c.UserContext(), requestID)
+		c.SetUserContext(helper.WithTraceParent(ctx, helper.NewTraceParent(traceID, flags)))
 
 		return c.Next()
 	}
 }
 
+// validRequestID reports whether the request ID of a caller can be written as is in the logs
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > 128 {
+		return false
+	}
+	for _, r := range requestID {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_.:", r)) {
+			return false
+		}
+	}
+	return true
+}
+
+// RequestMetric is the measure of a request taken by the Metrics middleware
+type RequestMetric struct {
+	Method    string
+	Route     string // path of the matched route (ex: /api/orders/:id)
+	Status    int
+	Latency   time.Duration
+	Timestamp time.Time
+}
+
+var (
+	metricsMu        sync.Mutex
+	metricsObservers = map[int]func(RequestMetric){}
+	metricsNextID    int
+)
+
+// ObserveMetrics registers fn to receive the metric of every request until the returned
+// function is called (ex: load tests). fn must not block.
+func ObserveMetrics(fn func(RequestMetric)) func() {
+	metricsMu.Lock()
+	defer metricsMu.Unlock()
+
+	metricsNextID++
+	id := metricsNextID
+	metricsObservers[id] = fn
+
+	return func() {
+		metricsMu.Lock()
+		defer metricsMu.Unlock()
+		delete(metricsObservers, id)
+	}
+}
+
 // Metrics creates a middleware for collecting request metrics
 func Metrics() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -78,6 +149,15 @@ func Metrics() fiber.Handler {
 		// Calculate metrics
 		latency := time.Since(start)
 		status := c.Response().StatusCode()
+		if err != nil {
+			// the error handler writes the status after the middlewares
+			status = fiber.StatusInternalServerError
+			if response := out.ErrorResponse(err); response != nil && response.HttpCode != 0 {
+				status = response.HttpCode
+			} else if e, ok := err.(*fiber.Error); ok {
+				status = e.Code
+			}
+		}
 
 		// Store metrics in context for collection
 		c.Locals("metrics", map[string]any{
@@ -86,6 +166,26 @@ func Metrics() fiber.Handler {
 			"timestamp": start,
 		})
 
+		metricsMu.Lock()
+		observers := make([]func(RequestMetric), 0, len(metricsObservers))
+		for _, fn := range metricsObservers {
+			observers = append(observers, fn)
+		}
+		metricsMu.Unlock()
+
+		if len(observers) > 0 {
+			metric := RequestMetric{
+				Method:    c.Method(),
+				Route:     c.Route().Path,
+				Status:    status,
+				Latency:   latency,
+				Timestamp: start,
+			}
+			for _, fn := range observers {
+				fn(metric)
+			}
+		}
+
 		return err
 	}
 }
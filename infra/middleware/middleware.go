@@ -2,14 +2,13 @@ package middleware
 
 import (
 	"errors"
-	"runtime/debug"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/favicon"
 	flogger "github.com/gofiber/fiber/v2/middleware/logger"
-	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/webcore-go/webcore/app/helper"
 	"github.com/webcore-go/webcore/app/out"
 	"github.com/webcore-go/webcore/infra/config"
@@ -21,13 +20,9 @@ func SetupGlobalMiddleware(app *fiber.App, cfg *config.Config) {
 	// Ignore favicon
 	app.Use(favicon.New())
 
-	// Recovery middleware
-	if cfg.App.Features.Recovery {
-		app.Use(recover.New(recover.Config{
-			EnableStackTrace:  true,
-			StackTraceHandler: PanicStackTraceHandler,
-		}))
-	}
+	// Request ID middleware, first so the panics, logs and errors of the request carry its ID
+	app.Use(RequestID())
+
 	// Logger middleware
 	if cfg.App.Features.Logging {
 		app.Use(flogger.New(flogger.Config{
@@ -47,16 +42,20 @@ func SetupGlobalMiddleware(app *fiber.App, cfg *config.Config) {
 	}
 	app.Use(cors.New(corsConfig))
 
-	// Remove Trailing Slash middleware
-	app.Use(RemoveTrailingSlash())
+	// Compression must wrap the ETag middleware so the validator is computed on the raw body
+	if cfg.App.Compression.Enabled {
+		app.Use(CompressMiddleware(cfg.App.Compression))
+	}
 
-	// Request ID middleware
-	if cfg.App.Features.Tracing {
-		app.Use(RequestID())
+	if cfg.App.ETag.Enabled {
+		app.Use(ConditionalRequestMiddleware(cfg.App.ETag))
 	}
 
-	// Request metrics middleware
-	if cfg.App.Features.Metrics {
+	// Remove Trailing Slash middleware
+	app.Use(RemoveTrailingSlash())
+
+	// Request metrics middleware, recorded on app.metrics.path when enabled
+	if cfg.App.Features.Metrics || cfg.App.Metrics.Enabled {
 		app.Use(Metrics())
 	}
 
@@ -77,6 +76,14 @@ func SetupGlobalMiddleware(app *fiber.App, cfg *config.Config) {
 	if cfg.App.RateLimit.Enabled {
 		app.Use(DefaultRateLimit(cfg.App.RateLimit))
 	}
+
+	if cfg.App.OpenAPI.Spec != "" && (cfg.App.OpenAPI.ValidateRequest || cfg.App.OpenAPI.ValidateResponse) {
+		validator, err := OpenAPIValidatorFromConfig(cfg)
+		if err != nil {
+			logger.Fatal("Setup OpenAPI validation middleware", "error", err)
+		}
+		app.Use(validator)
+	}
 }
 
 // SecurityHeadersMiddleware adds security headers
@@ -113,16 +120,21 @@ func AdditionalHeadersMiddleware(headers []string) fiber.Handler {
 	}
 }
 
-// CompressMiddleware enables response compression
-func CompressMiddleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		// Fiber has built-in compression middleware
-		// This is a placeholder for custom compression logic if needed
-		return c.Next()
-	}
+// CompressMiddleware enables gzip/brotli/deflate response compression based on Accept-Encoding
+func CompressMiddleware(cfg config.CompressionConfig) fiber.Handler {
+	return compress.New(compress.Config{
+		Level: compress.Level(cfg.Level),
+	})
 }
 
+// ErrorHandler sends the error returned by a handler as an out.Response: the one out.ErrorResponse
+// maps it to, else the status of a *fiber.Error, else a 500 that is logged
 func ErrorHandler(c *fiber.Ctx, err error) error {
+	// Handlers may return a prepared *out.Response (e.g. from helper.BindBody) or a registered error
+	if response := out.ErrorResponse(err); response != nil {
+		return out.Send(c, response)
+	}
+
 	// Status code defaults to 500
 	code := fiber.StatusInternalServerError
 
@@ -130,21 +142,10 @@ func ErrorHandler(c *fiber.Ctx, err error) error {
 	var e *fiber.Error
 	if errors.As(err, &e) {
 		code = e.Code
+	} else {
+		logger.Error("Request failed", "request_id", helper.RequestID(c.UserContext()), "method", c.Method(), "path", c.Path(), "error", err)
 	}
 
 	// Send custom error page
-	return c.Status(code).JSON(out.ErrorTrace(code, 1, "UNKNOWN", err.Error(), c))
-}
-
-func PanicStackTraceHandler(c *fiber.Ctx, e interface{}) {
-	stackTrace := string(debug.Stack())
-	error := struct {
-		Error interface{}
-		Stack string
-	}{
-		Error: e,
-		Stack: stackTrace,
-	}
-	logger.ErrorJson("Panic occurred: ", error)
-	c.Locals("StackTrace", stackTrace)
+	return out.Send(c, out.ErrorTrace(code, 1, "UNKNOWN", err.Error(), c))
 }
@@ -0,0 +1,182 @@
+package middleware
+
+import (
+	"bytes"
+	"strconv"
+	"strings"
+
+	"github.com/goccy/go-json"
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/infra/openapi"
+)
+
+// OpenAPIValidator validates incoming requests against the OpenAPI document and answers 400
+// with the list of violations. When validateResponse is set, JSON responses are checked too
+// and replaced by a 500 describing the drift between code and contract.
+func OpenAPIValidator(doc *openapi.Document, pathPrefix string, validateRequest bool, validateResponse bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		_, op, params := doc.FindOperation(c.Method(), c.Path())
+		if op == nil && pathPrefix != "" {
+			_, op, params = doc.FindOperation(c.Method(), strings.TrimPrefix(c.Path(), pathPrefix))
+		}
+
+		// routes missing from the contract are not validated
+		if op == nil {
+			return c.Next()
+		}
+
+		if validateRequest {
+			if errs := validateOpenAPIRequest(c, doc, op, params); len(errs) > 0 {
+				response := out.ValidationError(errs)
+				response.HttpCode = fiber.StatusBadRequest
+				response.Message = "Request does not match the API contract"
+				return c.Status(fiber.StatusBadRequest).JSON(response)
+			}
+		}
+
+		if err := c.Next(); err != nil || !validateResponse {
+			return err
+		}
+
+		if errs := validateOpenAPIResponse(c, doc, op); len(errs) > 0 {
+			logger.Warn("Response does not match the API contract", "method", c.Method(), "path", c.Path(), "violations", errs)
+			response := out.Error(fiber.StatusInternalServerError, 3, "CONTRACT_VIOLATION", "Response does not match the API contract")
+			response.Errors = errs
+			return c.Status(fiber.StatusInternalServerError).JSON(response)
+		}
+
+		return nil
+	}
+}
+
+// OpenAPIValidatorFromConfig loads the configured document and builds the validator.
+// Response validation is only enabled in the development environment.
+func OpenAPIValidatorFromConfig(cfg *config.Config) (fiber.Handler, error) {
+	doc, err := openapi.LoadDocument(cfg.App.OpenAPI.Spec)
+	if err != nil {
+		return nil, err
+	}
+
+	validateResponse := cfg.App.OpenAPI.ValidateResponse && cfg.App.Environment == "development"
+	return OpenAPIValidator(doc, cfg.Server.PathPrefix, cfg.App.OpenAPI.ValidateRequest, validateResponse), nil
+}
+
+func validateOpenAPIRequest(c *fiber.Ctx, doc *openapi.Document, op *openapi.Operation, params map[string]string) []out.FieldError {
+	errs := []out.FieldError{}
+
+	for _, param := range op.Parameters {
+		var raw string
+		var present bool
+		switch param.In {
+		case "path":
+			raw, present = params[param.Name]
+		case "query":
+			value := c.Context().QueryArgs().Peek(param.Name)
+			raw, present = string(value), value != nil
+		case "header":
+			value := c.Request().Header.Peek(param.Name)
+			raw, present = string(value), value != nil
+		case "cookie":
+			raw = c.Cookies(param.Name)
+			present = raw != ""
+		}
+
+		field := param.In + "." + param.Name
+		if !present {
+			if param.Required {
+				errs = append(errs, out.FieldError{Field: field, Rule: "required", Message: "parameter is required"})
+			}
+			continue
+		}
+
+		errs = append(errs, doc.Validate(param.Schema, openapi.CoerceParameter(param.Schema, raw), field)...)
+	}
+
+	if op.RequestBody == nil {
+		return errs
+	}
+
+	body := c.Body()
+	if len(body) == 0 {
+		if op.RequestBody.Required {
+			errs = append(errs, out.FieldError{Field: "body", Rule: "required", Message: "request body is required"})
+		}
+		return errs
+	}
+
+	media := findMediaType(op.RequestBody.Content, string(c.Request().Header.ContentType()))
+	if media == nil || media.Schema == nil {
+		return errs
+	}
+
+	value, err := decodeJSON(body)
+	if err != nil {
+		return append(errs, out.FieldError{Field: "body", Rule: "json", Message: "request body is not valid JSON"})
+	}
+
+	return append(errs, doc.Validate(media.Schema, value, "")...)
+}
+
+func validateOpenAPIResponse(c *fiber.Ctx, doc *openapi.Document, op *openapi.Operation) []out.FieldError {
+	status := c.Response().StatusCode()
+	spec, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		spec, ok = op.Responses[strconv.Itoa(status/100)+"XX"]
+	}
+	if !ok {
+		spec, ok = op.Responses["default"]
+	}
+	if !ok {
+		return []out.FieldError{{Field: "status", Rule: "responses", Message: "status code is not documented", Param: strconv.Itoa(status)}}
+	}
+
+	media := findMediaType(spec.Content, string(c.Response().Header.ContentType()))
+	if media == nil || media.Schema == nil {
+		return nil
+	}
+
+	value, err := decodeJSON(c.Response().Body())
+	if err != nil {
+		return nil
+	}
+
+	return doc.Validate(media.Schema, value, "")
+}
+
+func findMediaType(content map[string]*openapi.MediaType, contentType string) *openapi.MediaType {
+	if len(content) == 0 {
+		return nil
+	}
+
+	// only JSON payloads are validated, a form or an upload is left to its handler
+	mime := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mime != "" && !isJSONMediaType(mime) {
+		return nil
+	}
+	if media, ok := content[mime]; ok {
+		return media
+	}
+	for key, media := range content {
+		if isJSONMediaType(key) {
+			return media
+		}
+	}
+
+	return nil
+}
+
+// isJSONMediaType reports whether mime is application/json or a JSON suffix (ex: application/problem+json)
+func isJSONMediaType(mime string) bool {
+	return mime == fiber.MIMEApplicationJSON || strings.HasSuffix(mime, "+json")
+}
+
+func decodeJSON(body []byte) (any, error) {
+	var value any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	err := decoder.Decode(&value)
+	return value, err
+}
@@ -7,13 +7,16 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
 	"github.com/webcore-go/webcore/infra/config"
 )
 
 // RateLimitConfig represents the configuration for rate limiting middleware
 type RateLimitConfig struct {
-	Window time.Duration // Time window (e.g., 1 minute)
-	Limit  int64         // Maximum requests per window
+	Window    time.Duration    // Time window (e.g., 1 minute)
+	Limit     int64            // Maximum requests per window
+	Countries map[string]int64 // Limit per country code of the GeoIP middleware, overriding Limit
+	Clock     helper.Clock     // Optional, defaults to the system clock
 }
 
 // RateLimiter represents a rate limiter implementation
@@ -30,6 +33,10 @@ type clientData struct {
 
 // NewRateLimiter creates a new rate limiter
 func NewRateLimiter(config RateLimitConfig) *RateLimiter {
+	if config.Clock == nil {
+		config.Clock = helper.NewSystemClock()
+	}
+
 	return &RateLimiter{
 		config:  config,
 		clients: make(map[string]*clientData),
@@ -50,8 +57,15 @@ func (rl *RateLimiter) Middleware() fiber.Handler {
 			}
 		}
 
+		// tenants have their own counters
+		if tenant := helper.CurrentTenant(c); tenant != nil {
+			clientID = tenant.ID + ":" + clientID
+		}
+
+		limit := rl.limitFor(c)
+
 		// Check rate limit
-		allowed, resetTime, err := rl.Allow(clientID)
+		allowed, resetTime, err := rl.allow(clientID, limit)
 		if err != nil {
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Internal server error",
@@ -59,7 +73,7 @@ func (rl *RateLimiter) Middleware() fiber.Handler {
 		}
 
 		if !allowed {
-			c.Set("X-RateLimit-Limit", strconv.FormatInt(rl.config.Limit, 10))
+			c.Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
 			c.Set("X-RateLimit-Remaining", "0")
 			c.Set("X-RateLimit-Reset", resetTime.Format(time.RFC3339))
 
@@ -75,11 +89,11 @@ func (rl *RateLimiter) Middleware() fiber.Handler {
 		currentCount := rl.clients[clientID].count
 		rl.mu.RUnlock()
 
-		remaining := rl.config.Limit - currentCount
+		remaining := limit - currentCount
 		if remaining < 0 {
 			remaining = 0
 		}
-		c.Set("X-RateLimit-Limit", strconv.FormatInt(rl.config.Limit, 10))
+		c.Set("X-RateLimit-Limit", strconv.FormatInt(limit, 10))
 		c.Set("X-RateLimit-Remaining", strconv.FormatInt(remaining, 10))
 		c.Set("X-RateLimit-Reset", resetTime.Format(time.RFC3339))
 
@@ -89,13 +103,33 @@ func (rl *RateLimiter) Middleware() fiber.Handler {
 
 // Allow checks if a client is allowed to make a request
 func (rl *RateLimiter) Allow(clientID string) (bool, time.Time, error) {
+	return rl.allow(clientID, rl.config.Limit)
+}
+
+// limitFor returns the limit of the tenant, or of the country of the client, Limit when it has
+// none
+func (rl *RateLimiter) limitFor(c *fiber.Ctx) int64 {
+	if tenant := helper.CurrentTenant(c); tenant != nil && tenant.RateLimit > 0 {
+		return int64(tenant.RateLimit)
+	}
+	if len(rl.config.Countries) > 0 {
+		if geo := helper.CurrentGeo(c); geo != nil {
+			if limit, ok := rl.config.Countries[geo.Country]; ok {
+				return limit
+			}
+		}
+	}
+	return rl.config.Limit
+}
+
+func (rl *RateLimiter) allow(clientID string, limit int64) (bool, time.Time, error) {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
+	now := rl.config.Clock.Now()
 
 	// Handle special case: limit is 0 (no requests allowed)
-	if rl.config.Limit == 0 {
+	if limit == 0 {
 		return false, now.Add(rl.config.Window), nil
 	}
 
@@ -111,7 +145,7 @@ func (rl *RateLimiter) Allow(clientID string) (bool, time.Time, error) {
 	}
 
 	// Check if limit exceeded
-	if data.count >= rl.config.Limit {
+	if data.count >= limit {
 		return false, data.windowStart.Add(rl.config.Window), nil
 	}
 
@@ -145,7 +179,7 @@ func (rl *RateLimiter) Cleanup() {
 	rl.mu.Lock()
 	defer rl.mu.Unlock()
 
-	now := time.Now()
+	now := rl.config.Clock.Now()
 	for clientID, data := range rl.clients {
 		if now.Sub(data.windowStart) > rl.config.Window {
 			delete(rl.clients, clientID)
@@ -161,8 +195,14 @@ func NewRateLimit(config RateLimitConfig) fiber.Handler {
 
 // DefaultRateLimit creates a default rate limiting middleware
 func DefaultRateLimit(config config.RateLimitConfig) fiber.Handler {
+	countries := make(map[string]int64, len(config.Countries))
+	for country, max := range config.Countries {
+		countries[strings.ToUpper(country)] = int64(max)
+	}
+
 	return NewRateLimit(RateLimitConfig{
-		Window: time.Minute,
-		Limit:  int64(config.Max), // 60 requests per minute
+		Window:    time.Minute,
+		Limit:     int64(config.Max), // 60 requests per minute
+		Countries: countries,
 	})
 }
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/port"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// Tenant resolves the tenant of the request with the header, subdomain and path resolvers of
+// cfg, in their order, and stores it for the next handlers, read it with
+// helper.CurrentTenant(c) or helper.Tenant(ctx). The path resolver removes the tenant from the
+// path before the routes are matched (ex: /api/acme/orders is routed as /api/orders). An unknown
+// tenant is refused, a request without tenant goes on.
+func Tenant(cfg config.TenancyConfig, prefix string, store port.ITenantStore) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// the values of fiber live in buffers reused by the next requests, the store may keep id
+		id := ""
+		for _, resolver := range cfg.Resolvers {
+			switch resolver {
+			case "header":
+				id = strings.Clone(c.Get(cfg.Header))
+			case "subdomain":
+				id = strings.Clone(tenantFromHost(c.Hostname(), cfg.Domain))
+			case "path":
+				var path string
+				if id, path = tenantFromPath(c.Path(), prefix); id != "" {
+					// before the path is replaced in its buffer
+					id = strings.Clone(id)
+					c.Path(path)
+				}
+			}
+			if id != "" {
+				break
+			}
+		}
+		if id == "" {
+			return c.Next()
+		}
+
+		tenant, err := store.Get(c.UserContext(), id)
+		if err != nil {
+			return err
+		}
+		if tenant == nil {
+			return fiber.NewError(fiber.StatusNotFound, "Tenant not found")
+		}
+
+		setTenant(c, tenant)
+		return c.Next()
+	}
+}
+
+// TenantClaim checks the tenant of the request against the tenant claimed by the authenticated
+// user, or resolves it from the claim with the "claim" resolver. A user claiming no tenant
+// reaches a tenant only with one of the platform roles. It runs after the authentication, on
+// the protected routes.
+func TenantClaim(cfg config.TenancyConfig, store port.ITenantStore) fiber.Handler {
+	useClaim := slices.Contains(cfg.Resolvers, "claim")
+
+	return func(c *fiber.Ctx) error {
+		tenant := helper.CurrentTenant(c)
+		claim := auth.CurrentUserTenant(c)
+
+		switch {
+		case tenant != nil && claim != "" && claim != tenant.ID:
+			// a user of a tenant never reaches the data of another one
+			return fiber.NewError(fiber.StatusForbidden, "Tenant not allowed")
+		case tenant != nil && claim == "" && !unclaimed(c, cfg.Platform):
+			// nor does a user of no tenant, unless it operates the platform
+			return fiber.NewError(fiber.StatusForbidden, "Tenant not allowed")
+		case tenant == nil && claim != "" && useClaim:
+			found, err := store.Get(c.UserContext(), claim)
+			if err != nil {
+				return err
+			}
+			if found == nil {
+				return fiber.NewError(fiber.StatusNotFound, "Tenant not found")
+			}
+			tenant = found
+			setTenant(c, tenant)
+		}
+
+		if tenant == nil && cfg.Required {
+			return fiber.NewError(fiber.StatusBadRequest, "Tenant is required")
+		}
+		return c.Next()
+	}
+}
+
+// unclaimed reports whether the request may reach a tenant without claiming it: its user has one
+// of roles, or there is no user (auth.type none)
+func unclaimed(c *fiber.Ctx, roles []string) bool {
+	user := auth.CurrentUser(c)
+	if user == nil {
+		return true
+	}
+	userRoles := auth.UserRoles(user)
+	return slices.ContainsFunc(roles, func(role string) bool { return slices.Contains(userRoles, role) })
+}
+
+func setTenant(c *fiber.Ctx, tenant *port.Tenant) {
+	c.Locals(helper.TenantLocalsKey, tenant)
+	c.SetUserContext(helper.WithTenant(c.UserContext(), tenant))
+}
+
+// tenantFromHost returns the subdomain of host under domain (ex: acme.example.com), or the
+// first label of a host of three labels or more when domain is empty
+func tenantFromHost(host, domain string) string {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(host)
+	if domain != "" {
+		sub, ok := strings.CutSuffix(host, "."+strings.ToLower(domain))
+		if !ok || strings.Contains(sub, ".") {
+			return ""
+		}
+		return sub
+	}
+
+	labels := strings.Split(host, ".")
+	if len(labels) < 3 {
+		return ""
+	}
+	return labels[0]
+}
+
+// tenantFromPath returns the first segment of path after prefix and path without it
+func tenantFromPath(path, prefix string) (string, string) {
+	rest, ok := strings.CutPrefix(path, prefix+"/")
+	if !ok || rest == "" {
+		return "", path
+	}
+
+	id, rest, _ := strings.Cut(rest, "/")
+	if rest == "" {
+		return id, prefix
+	}
+	return id, prefix + "/" + rest
+}
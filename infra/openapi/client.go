@@ -0,0 +1,462 @@
+package openapi
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.New("client").Funcs(template.FuncMap{"join": strings.Join}).ParseFS(templateFS, "templates/*.tmpl"))
+
+// clientModel is given to the client templates
+type clientModel struct {
+	Package    string
+	Title      string
+	Version    string
+	Types      []clientType
+	Operations []clientOperation
+}
+
+// clientType is a schema of the components
+type clientType struct {
+	Name        string
+	Description string
+	Alias       string // type of the schemas which are not objects
+	Fields      []clientField
+}
+
+type clientField struct {
+	Name        string
+	JSON        string
+	Type        string
+	Optional    bool
+	Description string
+}
+
+type clientOperation struct {
+	Name        string
+	Method      string
+	Path        string // template of the document (ex: "/orders/{id}")
+	PathExpr    string // expression of the path with the values of the parameters
+	Summary     string
+	Deprecated  bool
+	Params      []clientParam
+	Body        string   // type of the request body, empty without body
+	Result      string   // type of the data of the response envelope
+	QueryParams []string // documented query parameters
+}
+
+type clientParam struct {
+	Name string // variable of the parameter
+	Key  string // name in the path template
+}
+
+// clientLanguage maps the schemas and names of a target language
+type clientLanguage struct {
+	typeName   func(d *Document, s *Schema) string
+	methodName func(name string) string
+	pathExpr   func(path string, params []clientParam) string
+	unknown    string
+}
+
+// GenerateGoClient generates a Go package calling the operations of the document, typed with
+// the schemas of its components, answers are decoded from the out.Response envelope
+func GenerateGoClient(d *Document, pkg string) ([]byte, error) {
+	model := d.clientModel(goLanguage)
+	model.Package = pkg
+
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "client.go.tmpl", model); err != nil {
+		return nil, err
+	}
+
+	code, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format the Go client: %v", err)
+	}
+	return code, nil
+}
+
+// GenerateTypeScriptClient generates a TypeScript module calling the operations of the document
+// with fetch, typed with the schemas of its components
+func GenerateTypeScriptClient(d *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := templates.ExecuteTemplate(&buf, "client.ts.tmpl", d.clientModel(tsLanguage)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (d *Document) clientModel(lang clientLanguage) clientModel {
+	model := clientModel{Title: d.Info.Title, Version: d.Info.Version}
+
+	if d.Components != nil {
+		names := make([]string, 0, len(d.Components.Schemas))
+		for name := range d.Components.Schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			model.Types = append(model.Types, d.clientType(lang, name, d.Components.Schemas[name]))
+		}
+	}
+
+	templates := make([]string, 0, len(d.Paths))
+	for template := range d.Paths {
+		templates = append(templates, template)
+	}
+	sort.Strings(templates)
+
+	used := map[string]int{}
+	for _, template := range templates {
+		item := d.Paths[template]
+		for _, method := range []string{"GET", "POST", "PUT", "PATCH", "DELETE"} {
+			op := item.Operation(method)
+			if op == nil {
+				continue
+			}
+
+			name := op.OperationID
+			if name == "" {
+				name = operationName(method, template)
+			}
+			name = lang.methodName(name)
+			if used[name]++; used[name] > 1 {
+				name += strconv.Itoa(used[name])
+			}
+
+			model.Operations = append(model.Operations, d.clientOperation(lang, name, method, template, item, op))
+		}
+	}
+
+	return model
+}
+
+func (d *Document) clientType(lang clientLanguage, name string, schema *Schema) clientType {
+	t := clientType{Name: exportedName(name), Description: schema.Description}
+	if len(schema.Properties) == 0 || !schema.hasType("object") {
+		t.Alias = lang.typeName(d, schema)
+		return t
+	}
+
+	keys := make([]string, 0, len(schema.Properties))
+	for key := range schema.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property := schema.Properties[key]
+		t.Fields = append(t.Fields, clientField{
+			Name:        exportedName(key),
+			JSON:        key,
+			Type:        lang.typeName(d, property),
+			Optional:    !containsString(schema.Required, key),
+			Description: property.Description,
+		})
+	}
+	return t
+}
+
+func (d *Document) clientOperation(lang clientLanguage, name string, method string, template string, item *PathItem, op *Operation) clientOperation {
+	operation := clientOperation{
+		Name:       name,
+		Method:     method,
+		Path:       template,
+		Summary:    op.Summary,
+		Deprecated: op.Deprecated,
+		Result:     lang.unknown,
+	}
+
+	// the parameters in the order of the path
+	for _, part := range strings.Split(template, "/") {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			key := part[1 : len(part)-1]
+			operation.Params = append(operation.Params, clientParam{Name: paramName(key), Key: key})
+		}
+	}
+	operation.PathExpr = lang.pathExpr(template, operation.Params)
+
+	for _, param := range append(append([]*Parameter{}, item.Parameters...), op.Parameters...) {
+		if param.In == "query" {
+			operation.QueryParams = append(operation.QueryParams, param.Name)
+		}
+	}
+
+	if op.RequestBody != nil {
+		operation.Body = lang.unknown
+		if media := jsonMediaType(op.RequestBody.Content); media != nil && media.Schema != nil {
+			operation.Body = lang.typeName(d, media.Schema)
+		}
+	} else if method == "POST" || method == "PUT" || method == "PATCH" {
+		// routes without contract may still read a body
+		operation.Body = lang.unknown
+	}
+
+	if schema := d.successSchema(op); schema != nil {
+		// the data of the envelope when the schema describes the whole out.Response
+		resolved := schema
+		if schema.Ref != "" {
+			resolved, _ = d.ResolveRef(schema.Ref)
+		}
+		if resolved != nil && resolved.Properties["data"] != nil {
+			schema = resolved.Properties["data"]
+		}
+		operation.Result = lang.typeName(d, schema)
+	}
+
+	return operation
+}
+
+// successSchema returns the JSON schema of the first success response
+func (d *Document) successSchema(op *Operation) *Schema {
+	codes := make([]string, 0, len(op.Responses))
+	for code := range op.Responses {
+		if strings.HasPrefix(code, "2") {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if media := jsonMediaType(op.Responses[code].Content); media != nil && media.Schema != nil {
+			return media.Schema
+		}
+	}
+	return nil
+}
+
+func jsonMediaType(content map[string]*MediaType) *MediaType {
+	if media, ok := content["application/json"]; ok {
+		return media
+	}
+	for contentType, media := range content {
+		if strings.HasSuffix(contentType, "+json") {
+			return media
+		}
+	}
+	return nil
+}
+
+func (s *Schema) hasType(name string) bool {
+	return containsString(s.Type, name)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// operationName names an operation without operationId from its method and path:
+// GET /orders/{id}/items is GetOrdersByIdItems
+func operationName(method string, template string) string {
+	var sb strings.Builder
+	sb.WriteString(strings.ToLower(method))
+	for _, part := range strings.Split(template, "/") {
+		if part == "" {
+			continue
+		}
+		if strings.HasPrefix(part, "{") {
+			sb.WriteString("By_")
+			part = strings.Trim(part, "{}")
+		}
+		sb.WriteString("_" + part)
+	}
+	return sb.String()
+}
+
+// words splits a name on the characters which are not letters nor digits, and on the case
+// changes of camelCase names
+func words(name string) []string {
+	var result []string
+	var current []rune
+	runes := []rune(name)
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			if len(current) > 0 {
+				result = append(result, string(current))
+				current = nil
+			}
+			continue
+		}
+		if unicode.IsUpper(r) && len(current) > 0 && i > 0 && unicode.IsLower(runes[i-1]) {
+			result = append(result, string(current))
+			current = nil
+		}
+		current = append(current, r)
+	}
+	if len(current) > 0 {
+		result = append(result, string(current))
+	}
+	return result
+}
+
+// goInitialisms are written in upper case in Go names
+var goInitialisms = map[string]bool{"id": true, "url": true, "uri": true, "api": true, "http": true, "json": true, "ip": true, "uuid": true, "sql": true}
+
+// exportedName converts a name to an exported Go identifier (ex: "order_id" is OrderID)
+func exportedName(name string) string {
+	var sb strings.Builder
+	for _, word := range words(name) {
+		lower := strings.ToLower(word)
+		if goInitialisms[lower] {
+			sb.WriteString(strings.ToUpper(word))
+			continue
+		}
+		runes := []rune(word)
+		sb.WriteRune(unicode.ToUpper(runes[0]))
+		sb.WriteString(string(runes[1:]))
+	}
+	if sb.Len() == 0 {
+		return "Value"
+	}
+	if unicode.IsDigit([]rune(sb.String())[0]) {
+		return "N" + sb.String()
+	}
+	return sb.String()
+}
+
+// camelName converts a name to a lower camelCase identifier (ex: "order_id" is orderId)
+func camelName(name string) string {
+	var sb strings.Builder
+	for i, word := range words(name) {
+		runes := []rune(strings.ToLower(word))
+		if i > 0 {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+		sb.WriteString(string(runes))
+	}
+	if sb.Len() == 0 {
+		return "value"
+	}
+	return sb.String()
+}
+
+// paramName is the variable of a path parameter, valid in Go and TypeScript
+func paramName(key string) string {
+	name := camelName(key)
+	switch name {
+	case "type", "func", "var", "range", "map", "chan", "default", "package", "interface", "select", "case", "go", "new", "delete", "function", "ctx", "body", "query":
+		return name + "Param"
+	}
+	return name
+}
+
+var goLanguage = clientLanguage{
+	typeName:   goTypeName,
+	methodName: exportedName,
+	unknown:    "json.RawMessage",
+	pathExpr: func(path string, params []clientParam) string {
+		expr := strconv.Quote(path)
+		for _, param := range params {
+			expr = strings.Replace(expr, "{"+param.Key+"}", `"+url.PathEscape(`+param.Name+`)+"`, 1)
+		}
+		return strings.ReplaceAll(expr, `+""`, "")
+	},
+}
+
+func goTypeName(d *Document, s *Schema) string {
+	if s == nil {
+		return "json.RawMessage"
+	}
+	if s.Ref != "" {
+		if name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/"); ok {
+			return exportedName(name)
+		}
+		return "json.RawMessage"
+	}
+	if len(s.AllOf) == 1 {
+		return goTypeName(d, s.AllOf[0])
+	}
+
+	switch {
+	case s.hasType("array"):
+		return "[]" + goTypeName(d, s.Items)
+	case s.hasType("string"):
+		return "string"
+	case s.hasType("integer"):
+		return "int64"
+	case s.hasType("number"):
+		return "float64"
+	case s.hasType("boolean"):
+		return "bool"
+	case s.hasType("object"):
+		return "map[string]any"
+	}
+	return "json.RawMessage"
+}
+
+var tsLanguage = clientLanguage{
+	typeName:   tsTypeName,
+	methodName: camelName,
+	unknown:    "unknown",
+	pathExpr: func(path string, params []clientParam) string {
+		expr := path
+		for _, param := range params {
+			expr = strings.Replace(expr, "{"+param.Key+"}", "${encodeURIComponent("+param.Name+")}", 1)
+		}
+		return "`" + expr + "`"
+	},
+}
+
+func tsTypeName(d *Document, s *Schema) string {
+	if s == nil {
+		return "unknown"
+	}
+	if s.Ref != "" {
+		if name, ok := strings.CutPrefix(s.Ref, "#/components/schemas/"); ok {
+			return exportedName(name)
+		}
+		return "unknown"
+	}
+	if len(s.AllOf) == 1 {
+		return tsTypeName(d, s.AllOf[0])
+	}
+	if len(s.Enum) > 0 {
+		values := make([]string, 0, len(s.Enum))
+		for _, value := range s.Enum {
+			if str, ok := value.(string); ok {
+				values = append(values, strconv.Quote(str))
+			} else {
+				values = append(values, fmt.Sprint(value))
+			}
+		}
+		return strings.Join(values, " | ")
+	}
+
+	name := "unknown"
+	switch {
+	case s.hasType("array"):
+		name = tsTypeName(d, s.Items)
+		if strings.Contains(name, " | ") {
+			name = "(" + name + ")"
+		}
+		name += "[]"
+	case s.hasType("string"):
+		name = "string"
+	case s.hasType("integer"), s.hasType("number"):
+		name = "number"
+	case s.hasType("boolean"):
+		name = "boolean"
+	case s.hasType("object"):
+		name = "Record<string, unknown>"
+	}
+	if s.Nullable || s.hasType("null") {
+		name += " | null"
+	}
+	return name
+}
@@ -0,0 +1,254 @@
+package openapi
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/goccy/go-json"
+)
+
+// Document is the subset of an OpenAPI 3.x document used by the framework
+type Document struct {
+	OpenAPI    string               `json:"openapi"`
+	Info       Info                 `json:"info"`
+	Servers    []Server             `json:"servers,omitempty"`
+	Paths      map[string]*PathItem `json:"paths"`
+	Components *Components          `json:"components,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+type Server struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type   string `json:"type"`
+	Scheme string `json:"scheme,omitempty"`
+	Name   string `json:"name,omitempty"`
+	In     string `json:"in,omitempty"`
+}
+
+type PathItem struct {
+	Get     *Operation `json:"get,omitempty"`
+	Put     *Operation `json:"put,omitempty"`
+	Post    *Operation `json:"post,omitempty"`
+	Delete  *Operation `json:"delete,omitempty"`
+	Options *Operation `json:"options,omitempty"`
+	Head    *Operation `json:"head,omitempty"`
+	Patch   *Operation `json:"patch,omitempty"`
+
+	Parameters []*Parameter `json:"parameters,omitempty"`
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"` // path, query, header, cookie
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Description string                `json:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content"`
+}
+
+type Response struct {
+	Description string                `json:"description"`
+	Content     map[string]*MediaType `json:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// LoadDocument reads an OpenAPI document in JSON format
+func LoadDocument(path string) (*Document, error) {
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &Document{}
+	if err := json.Unmarshal(raw, doc); err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI document %s: %v", path, err)
+	}
+
+	return doc, nil
+}
+
+// Operation returns the operation registered for the method on the path item
+func (p *PathItem) Operation(method string) *Operation {
+	switch strings.ToUpper(method) {
+	case "GET":
+		return p.Get
+	case "PUT":
+		return p.Put
+	case "POST":
+		return p.Post
+	case "DELETE":
+		return p.Delete
+	case "OPTIONS":
+		return p.Options
+	case "HEAD":
+		return p.Head
+	case "PATCH":
+		return p.Patch
+	}
+	return nil
+}
+
+// SetOperation registers the operation for the method on the path item
+func (p *PathItem) SetOperation(method string, op *Operation) {
+	switch strings.ToUpper(method) {
+	case "GET":
+		p.Get = op
+	case "PUT":
+		p.Put = op
+	case "POST":
+		p.Post = op
+	case "DELETE":
+		p.Delete = op
+	case "OPTIONS":
+		p.Options = op
+	case "HEAD":
+		p.Head = op
+	case "PATCH":
+		p.Patch = op
+	}
+}
+
+// AddRoute adds the operation of a route of the router (ex: "/orders/:id") with its path
+// parameters, unless the document already describes it, and returns the operation
+func (d *Document) AddRoute(method string, path string) *Operation {
+	template, params := routeTemplate(path)
+	if d.Paths == nil {
+		d.Paths = map[string]*PathItem{}
+	}
+
+	item, ok := d.Paths[template]
+	if !ok {
+		item = &PathItem{}
+		d.Paths[template] = item
+	}
+	if op := item.Operation(method); op != nil {
+		return op
+	}
+
+	op := &Operation{Responses: map[string]*Response{"200": {Description: "OK"}}}
+	for _, name := range params {
+		op.Parameters = append(op.Parameters, &Parameter{Name: name, In: "path", Required: true, Schema: &Schema{Type: SchemaType{"string"}}})
+	}
+	item.SetOperation(method, op)
+	return op
+}
+
+// routeTemplate converts the parameters of a route (":id", ":id?", "*") to the ones of a path
+// template ("{id}", "{wildcard}")
+func routeTemplate(path string) (string, []string) {
+	parts := strings.Split(path, "/")
+	params := []string{}
+	for i, part := range parts {
+		name := ""
+		switch {
+		case strings.HasPrefix(part, ":"):
+			name = strings.TrimRight(part[1:], "?+*")
+		case part == "*" || part == "+":
+			name = "wildcard"
+		default:
+			continue
+		}
+		parts[i] = "{" + name + "}"
+		params = append(params, name)
+	}
+	return strings.Join(parts, "/"), params
+}
+
+// FindOperation matches a concrete request path against the path templates of the
+// document and returns the operation along with the extracted path parameters
+func (d *Document) FindOperation(method string, path string) (*PathItem, *Operation, map[string]string) {
+	// check templates with fewer parameters first so /items/search wins over /items/{id}
+	templates := make([]string, 0, len(d.Paths))
+	for template := range d.Paths {
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool {
+		ci, cj := strings.Count(templates[i], "{"), strings.Count(templates[j], "{")
+		if ci != cj {
+			return ci < cj
+		}
+		return templates[i] < templates[j]
+	})
+
+	for _, template := range templates {
+		params, ok := matchPath(template, path)
+		if !ok {
+			continue
+		}
+
+		item := d.Paths[template]
+		if op := item.Operation(method); op != nil {
+			return item, op, params
+		}
+	}
+
+	return nil, nil, nil
+}
+
+// ResolveRef returns the schema referenced by "#/components/schemas/Name"
+func (d *Document) ResolveRef(ref string) (*Schema, bool) {
+	name, ok := strings.CutPrefix(ref, "#/components/schemas/")
+	if !ok || d.Components == nil {
+		return nil, false
+	}
+
+	schema, ok := d.Components.Schemas[name]
+	return schema, ok
+}
+
+func matchPath(template string, path string) (map[string]string, bool) {
+	tparts := strings.Split(strings.Trim(template, "/"), "/")
+	pparts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(tparts) != len(pparts) {
+		return nil, false
+	}
+
+	params := map[string]string{}
+	for i, part := range tparts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			params[part[1:len(part)-1]] = pparts[i]
+			continue
+		}
+		if part != pparts[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
@@ -0,0 +1,209 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// SchemaOf returns the schema of the Go value v (ex: a request struct), the named structs are
+// added to the schemas of the components and referenced. The fields are named by their json
+// tag and required unless they are pointers or omitempty, the tags description, format and
+// enum (comma separated) complete their schema:
+//
+//	type CreateOrder struct {
+//		Customer string  `json:"customer" description:"ID of the customer"`
+//		Status   string  `json:"status,omitempty" enum:"draft,confirmed"`
+//		Email    *string `json:"email" format:"email"`
+//	}
+func (d *Document) SchemaOf(v any) *Schema {
+	if v == nil {
+		return nil
+	}
+	return d.schemaOf(reflect.TypeOf(v))
+}
+
+func (d *Document) schemaOf(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: SchemaType{"string"}, Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		return d.componentSchema(t)
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: SchemaType{"boolean"}}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: SchemaType{"integer"}}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: SchemaType{"number"}}
+	case reflect.String:
+		return &Schema{Type: SchemaType{"string"}}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoded in base64 by encoding/json
+			return &Schema{Type: SchemaType{"string"}, Format: "byte"}
+		}
+		return &Schema{Type: SchemaType{"array"}, Items: d.schemaOf(t.Elem())}
+	case reflect.Map:
+		return &Schema{Type: SchemaType{"object"}}
+	case reflect.Struct:
+		return d.structSchema(t)
+	}
+
+	// interfaces and the types JSON does not encode accept any value
+	return &Schema{}
+}
+
+// componentSchema adds the schema of the named struct t to the components, once, and returns
+// its reference
+func (d *Document) componentSchema(t reflect.Type) *Schema {
+	if d.Components == nil {
+		d.Components = &Components{}
+	}
+	if d.Components.Schemas == nil {
+		d.Components.Schemas = map[string]*Schema{}
+	}
+
+	name := t.Name()
+	ref := &Schema{Ref: "#/components/schemas/" + name}
+	if _, ok := d.Components.Schemas[name]; ok {
+		return ref
+	}
+
+	// registered before its fields, for the types referencing themselves
+	d.Components.Schemas[name] = &Schema{}
+	*d.Components.Schemas[name] = *d.structSchema(t)
+	return ref
+}
+
+// structSchema returns the schema of the fields of the struct t, with the ones of its embedded
+// structs
+func (d *Document) structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: SchemaType{"object"}, Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, omitempty, ok := jsonField(field)
+		if !ok {
+			continue
+		}
+
+		if field.Anonymous && name == "" {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				inner := d.structSchema(embedded)
+				for key, property := range inner.Properties {
+					schema.Properties[key] = property
+				}
+				schema.Required = append(schema.Required, inner.Required...)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		property := d.schemaOf(field.Type)
+		property = describeField(property, field)
+		schema.Properties[name] = property
+		if !omitempty && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// QueryParameters returns the query parameters of the struct v, the fields named by their
+// query tag as for fiber.Ctx.QueryParser. They are described like the fields of SchemaOf,
+// and required only with the tag required:"true".
+func (d *Document) QueryParameters(v any) []*Parameter {
+	if v == nil {
+		return nil
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var params []*Parameter
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("query"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		schema := describeField(d.schemaOf(field.Type), field)
+		params = append(params, &Parameter{
+			Name:        name,
+			In:          "query",
+			Description: schema.Description,
+			Required:    field.Tag.Get("required") == "true",
+			Schema:      schema,
+		})
+	}
+	return params
+}
+
+// jsonField returns the name of the field in JSON, empty when it is not tagged, and reports
+// whether the field is encoded
+func jsonField(field reflect.StructField) (name string, omitempty bool, ok bool) {
+	if !field.IsExported() && !field.Anonymous {
+		return "", false, false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	name, options, _ := strings.Cut(tag, ",")
+	return name, strings.Contains(","+options+",", ",omitempty,"), true
+}
+
+// describeField completes the schema of field with its tags, a reference is wrapped since its
+// siblings would be ignored by the OpenAPI 3.0 readers
+func describeField(schema *Schema, field reflect.StructField) *Schema {
+	description := field.Tag.Get("description")
+	format := field.Tag.Get("format")
+	enum := field.Tag.Get("enum")
+	if description == "" && format == "" && enum == "" {
+		return schema
+	}
+
+	if schema.Ref != "" {
+		schema = &Schema{AllOf: []*Schema{schema}}
+	} else {
+		copied := *schema
+		schema = &copied
+	}
+	schema.Description = description
+	if format != "" {
+		schema.Format = format
+	}
+	if enum != "" {
+		for _, value := range strings.Split(enum, ",") {
+			schema.Enum = append(schema.Enum, strings.TrimSpace(value))
+		}
+	}
+	return schema
+}
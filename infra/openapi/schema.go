@@ -0,0 +1,340 @@
+package openapi
+
+import (
+	"fmt"
+	"net/mail"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/webcore-go/webcore/app/out"
+)
+
+// Schema is the subset of JSON Schema (OpenAPI 3.1 dialect) understood by the validator
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 SchemaType         `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []any              `json:"enum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"` // OpenAPI 3.0 style
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	ExclusiveMinimum     *float64           `json:"exclusiveMinimum,omitempty"`
+	ExclusiveMaximum     *float64           `json:"exclusiveMaximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	OneOf                []*Schema          `json:"oneOf,omitempty"`
+	Deprecated           bool               `json:"deprecated,omitempty"`
+}
+
+// SchemaType holds one or more JSON types, serialized as a string when there is only one
+type SchemaType []string
+
+func (t SchemaType) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *SchemaType) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*t = SchemaType{single}
+		return nil
+	}
+
+	var multi []string
+	if err := json.Unmarshal(data, &multi); err != nil {
+		return err
+	}
+	*t = multi
+	return nil
+}
+
+var patternCache sync.Map // string -> *regexp.Regexp
+
+// Validate checks value (decoded from JSON) against the schema and returns the violations
+func (d *Document) Validate(schema *Schema, value any, path string) []out.FieldError {
+	return d.validate(schema, value, path, 0)
+}
+
+func (d *Document) validate(schema *Schema, value any, path string, depth int) []out.FieldError {
+	if schema == nil || depth > 64 {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		resolved, ok := d.ResolveRef(schema.Ref)
+		if !ok {
+			return []out.FieldError{{Field: path, Rule: "$ref", Message: "unresolvable schema reference", Param: schema.Ref}}
+		}
+		return d.validate(resolved, value, path, depth+1)
+	}
+
+	errs := []out.FieldError{}
+	for _, sub := range schema.AllOf {
+		errs = append(errs, d.validate(sub, value, path, depth+1)...)
+	}
+
+	if len(schema.AnyOf) > 0 && d.countMatches(schema.AnyOf, value, path, depth) == 0 {
+		errs = append(errs, out.FieldError{Field: path, Rule: "anyOf", Message: "value does not match any allowed schema"})
+	}
+
+	if len(schema.OneOf) > 0 && d.countMatches(schema.OneOf, value, path, depth) != 1 {
+		errs = append(errs, out.FieldError{Field: path, Rule: "oneOf", Message: "value must match exactly one schema"})
+	}
+
+	if value == nil {
+		if schema.Nullable || slices.Contains(schema.Type, "null") || len(schema.Type) == 0 {
+			return errs
+		}
+		return append(errs, out.FieldError{Field: path, Rule: "type", Message: "value must not be null", Param: strings.Join(schema.Type, ",")})
+	}
+
+	if len(schema.Type) > 0 && !typeMatches(schema.Type, value) {
+		return append(errs, out.FieldError{Field: path, Rule: "type", Message: fmt.Sprintf("value must be of type %s", strings.Join(schema.Type, " or ")), Param: strings.Join(schema.Type, ",")})
+	}
+
+	if len(schema.Enum) > 0 && !enumContains(schema.Enum, value) {
+		errs = append(errs, out.FieldError{Field: path, Rule: "enum", Message: "value is not one of the allowed values", Param: fmt.Sprint(schema.Enum)})
+	}
+
+	switch v := value.(type) {
+	case string:
+		errs = append(errs, validateString(schema, v, path)...)
+	case json.Number, float64, int, int64:
+		errs = append(errs, validateNumber(schema, toFloat(v), path)...)
+	case []any:
+		if schema.MinItems != nil && len(v) < *schema.MinItems {
+			errs = append(errs, out.FieldError{Field: path, Rule: "minItems", Message: fmt.Sprintf("must contain at least %d items", *schema.MinItems), Param: strconv.Itoa(*schema.MinItems)})
+		}
+		if schema.MaxItems != nil && len(v) > *schema.MaxItems {
+			errs = append(errs, out.FieldError{Field: path, Rule: "maxItems", Message: fmt.Sprintf("must contain at most %d items", *schema.MaxItems), Param: strconv.Itoa(*schema.MaxItems)})
+		}
+		for i, item := range v {
+			errs = append(errs, d.validate(schema.Items, item, out.FieldPath(path, i), depth+1)...)
+		}
+	case map[string]any:
+		for _, name := range schema.Required {
+			if _, ok := v[name]; !ok {
+				errs = append(errs, out.FieldError{Field: joinPath(path, name), Rule: "required", Message: "field is required"})
+			}
+		}
+		for name, item := range v {
+			prop, ok := schema.Properties[name]
+			if !ok {
+				if schema.AdditionalProperties != nil && !*schema.AdditionalProperties {
+					errs = append(errs, out.FieldError{Field: joinPath(path, name), Rule: "additionalProperties", Message: "field is not allowed"})
+				}
+				continue
+			}
+			errs = append(errs, d.validate(prop, item, joinPath(path, name), depth+1)...)
+		}
+	}
+
+	return errs
+}
+
+func (d *Document) countMatches(schemas []*Schema, value any, path string, depth int) int {
+	matches := 0
+	for _, sub := range schemas {
+		if len(d.validate(sub, value, path, depth+1)) == 0 {
+			matches++
+		}
+	}
+	return matches
+}
+
+func validateString(schema *Schema, v string, path string) []out.FieldError {
+	errs := []out.FieldError{}
+	length := len([]rune(v))
+	if schema.MinLength != nil && length < *schema.MinLength {
+		errs = append(errs, out.FieldError{Field: path, Rule: "minLength", Message: fmt.Sprintf("must be at least %d characters", *schema.MinLength), Param: strconv.Itoa(*schema.MinLength)})
+	}
+	if schema.MaxLength != nil && length > *schema.MaxLength {
+		errs = append(errs, out.FieldError{Field: path, Rule: "maxLength", Message: fmt.Sprintf("must be at most %d characters", *schema.MaxLength), Param: strconv.Itoa(*schema.MaxLength)})
+	}
+	if schema.Pattern != "" {
+		re, err := compilePattern(schema.Pattern)
+		if err == nil && !re.MatchString(v) {
+			errs = append(errs, out.FieldError{Field: path, Rule: "pattern", Message: "does not match the required pattern", Param: schema.Pattern})
+		}
+	}
+	if !formatMatches(schema.Format, v) {
+		errs = append(errs, out.FieldError{Field: path, Rule: "format", Message: fmt.Sprintf("must be a valid %s", schema.Format), Param: schema.Format})
+	}
+	return errs
+}
+
+func validateNumber(schema *Schema, v float64, path string) []out.FieldError {
+	errs := []out.FieldError{}
+	if schema.Minimum != nil && v < *schema.Minimum {
+		errs = append(errs, out.FieldError{Field: path, Rule: "minimum", Message: fmt.Sprintf("must be greater than or equal to %v", *schema.Minimum), Param: fmt.Sprint(*schema.Minimum)})
+	}
+	if schema.Maximum != nil && v > *schema.Maximum {
+		errs = append(errs, out.FieldError{Field: path, Rule: "maximum", Message: fmt.Sprintf("must be less than or equal to %v", *schema.Maximum), Param: fmt.Sprint(*schema.Maximum)})
+	}
+	if schema.ExclusiveMinimum != nil && v <= *schema.ExclusiveMinimum {
+		errs = append(errs, out.FieldError{Field: path, Rule: "exclusiveMinimum", Message: fmt.Sprintf("must be greater than %v", *schema.ExclusiveMinimum), Param: fmt.Sprint(*schema.ExclusiveMinimum)})
+	}
+	if schema.ExclusiveMaximum != nil && v >= *schema.ExclusiveMaximum {
+		errs = append(errs, out.FieldError{Field: path, Rule: "exclusiveMaximum", Message: fmt.Sprintf("must be less than %v", *schema.ExclusiveMaximum), Param: fmt.Sprint(*schema.ExclusiveMaximum)})
+	}
+	return errs
+}
+
+func typeMatches(types SchemaType, value any) bool {
+	for _, t := range types {
+		switch t {
+		case "string":
+			if _, ok := value.(string); ok {
+				return true
+			}
+		case "boolean":
+			if _, ok := value.(bool); ok {
+				return true
+			}
+		case "number":
+			switch value.(type) {
+			case json.Number, float64, int, int64:
+				return true
+			}
+		case "integer":
+			switch v := value.(type) {
+			case int, int64:
+				return true
+			case json.Number:
+				if _, err := v.Int64(); err == nil {
+					return true
+				}
+			case float64:
+				if v == float64(int64(v)) {
+					return true
+				}
+			}
+		case "array":
+			if _, ok := value.([]any); ok {
+				return true
+			}
+		case "object":
+			if _, ok := value.(map[string]any); ok {
+				return true
+			}
+		case "null":
+			if value == nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func formatMatches(format string, v string) bool {
+	switch format {
+	case "email":
+		_, err := mail.ParseAddress(v)
+		return err == nil
+	case "uuid":
+		return len(v) == 36 && strings.Count(v, "-") == 4
+	case "date-time":
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, v)
+		return err == nil
+	}
+	return true
+}
+
+func enumContains(enum []any, value any) bool {
+	target := fmt.Sprint(value)
+	for _, e := range enum {
+		if fmt.Sprint(e) == target {
+			return true
+		}
+	}
+	return false
+}
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+func toFloat(v any) float64 {
+	switch n := v.(type) {
+	case json.Number:
+		f, _ := n.Float64()
+		return f
+	case float64:
+		return n
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	}
+	return 0
+}
+
+func joinPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// CoerceParameter converts a raw path/query/header value to the JSON type declared by the schema
+// so it can be validated like a body value
+func CoerceParameter(schema *Schema, raw string) any {
+	if schema == nil {
+		return raw
+	}
+
+	for _, t := range schema.Type {
+		switch t {
+		case "integer":
+			if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+				return n
+			}
+		case "number":
+			if f, err := strconv.ParseFloat(raw, 64); err == nil {
+				return f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(raw); err == nil {
+				return b
+			}
+		case "array":
+			items := []any{}
+			for _, part := range strings.Split(raw, ",") {
+				items = append(items, CoerceParameter(schema.Items, part))
+			}
+			return items
+		}
+	}
+
+	return raw
+}
@@ -0,0 +1,247 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// Strategies of a Policy
+const (
+	Exponential = "exponential" // the delay doubles after each attempt, the default
+	Fixed       = "fixed"       // the same delay between the attempts
+)
+
+// Outcomes of a failed Attempt
+const (
+	OutcomeRetry     = "retry"
+	OutcomeExhausted = "exhausted" // no attempt left
+	OutcomeBudget    = "budget"    // the budget of the policy is spent
+	OutcomePermanent = "permanent" // the error is marked with Permanent
+)
+
+// Attempt is a failed attempt and the decision of its policy, given to the observers
+type Attempt struct {
+	Policy  string
+	Attempt int           // number of the failed attempt, from 1
+	Outcome string        // OutcomeRetry or the reason to stop
+	Delay   time.Duration // before the next attempt when retried
+	Err     error
+}
+
+// Policy decides whether and when a failed call is tried again. The zero policy retries
+// without delay and without limit.
+type Policy struct {
+	Name        string        // reported to the observers
+	Strategy    string        // Exponential or Fixed, defaults to Exponential
+	MaxAttempts int           // attempts with the first one, 0 for no limit
+	Backoff     time.Duration // delay before the first retry
+	MaxBackoff  time.Duration // longest delay, 0 for no limit
+	Jitter      float64       // part of the delay drawn at random, from 0 (none) to 1
+	Budget      *Budget       // retries shared by the users of the policy, nil for no limit
+}
+
+// Compile builds the policy name of cfg, it fails on an unknown strategy or a jitter out of
+// [0, 1]. The budget of cfg is shared by the users of the returned policy.
+func Compile(name string, cfg config.RetryPolicyConfig, clock helper.Clock) (Policy, error) {
+	if cfg.Strategy != "" && cfg.Strategy != Exponential && cfg.Strategy != Fixed {
+		return Policy{}, fmt.Errorf("unknown strategy %q, expected exponential or fixed", cfg.Strategy)
+	}
+	if cfg.Jitter < 0 || cfg.Jitter > 1 {
+		return Policy{}, fmt.Errorf("jitter must be between 0 and 1, got %v", cfg.Jitter)
+	}
+	if cfg.MaxAttempts < 0 {
+		return Policy{}, fmt.Errorf("max_attempts must not be negative")
+	}
+
+	policy := Policy{
+		Name:        name,
+		Strategy:    cfg.Strategy,
+		MaxAttempts: cfg.MaxAttempts,
+		Backoff:     cfg.Backoff,
+		MaxBackoff:  cfg.MaxBackoff,
+		Jitter:      cfg.Jitter,
+	}
+	if cfg.Budget > 0 {
+		window := cfg.BudgetWindow
+		if window <= 0 {
+			window = time.Minute
+		}
+		policy.Budget = NewBudget(cfg.Budget, window, clock)
+	}
+	return policy, nil
+}
+
+// Delay returns the wait after the failed attempt (from 1), jitter included
+func (p Policy) Delay(attempt int) time.Duration {
+	delay := p.Backoff
+	if p.Strategy != Fixed {
+		for i := 1; i < attempt && delay < math.MaxInt64/2; i++ {
+			delay *= 2
+			if p.MaxBackoff > 0 && delay >= p.MaxBackoff {
+				break
+			}
+		}
+	}
+	if p.MaxBackoff > 0 && delay > p.MaxBackoff {
+		delay = p.MaxBackoff
+	}
+
+	if p.Jitter > 0 && delay > 0 {
+		// the instances failing together do not retry together
+		delay -= time.Duration(rand.Float64() * min(p.Jitter, 1) * float64(delay))
+	}
+	return delay
+}
+
+// Next decides after the failed attempt (from 1) ending with err: the delay before the next
+// attempt, or false when err is permanent, the attempts are exhausted or the budget is spent.
+// The decision is given to the observers. It is the step of the loops which wait on their own
+// (ex: a job scheduled again), Do runs the whole loop.
+func (p Policy) Next(attempt int, err error) (time.Duration, bool) {
+	decision := Attempt{Policy: p.Name, Attempt: attempt, Err: err}
+	switch {
+	case IsPermanent(err):
+		decision.Outcome = OutcomePermanent
+	case p.MaxAttempts > 0 && attempt >= p.MaxAttempts:
+		decision.Outcome = OutcomeExhausted
+	case p.Budget != nil && !p.Budget.take():
+		decision.Outcome = OutcomeBudget
+	default:
+		decision.Outcome = OutcomeRetry
+		decision.Delay = p.Delay(attempt)
+	}
+
+	notify(decision)
+	return decision.Delay, decision.Outcome == OutcomeRetry
+}
+
+// Do calls fn until it succeeds or the policy stops, waiting on clock between the attempts,
+// and returns the last error. The cancellation of ctx stops the retries.
+func (p Policy) Do(ctx context.Context, clock helper.Clock, fn func(ctx context.Context) error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		delay, ok := p.Next(attempt, err)
+		if !ok {
+			return err
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-clock.After(delay):
+		}
+	}
+}
+
+// Budget limits the retries of the users of a policy to max per window, so a failing
+// dependency does not receive every call several times
+type Budget struct {
+	mu     sync.Mutex
+	max    int
+	window time.Duration
+	clock  helper.Clock
+	start  time.Time
+	used   int
+}
+
+// NewBudget creates a budget of max retries per window
+func NewBudget(max int, window time.Duration, clock helper.Clock) *Budget {
+	return &Budget{max: max, window: window, clock: clock}
+}
+
+// take uses a retry of the budget, false when it is spent until the end of the window
+func (b *Budget) take() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.clock.Now()
+	if now.Sub(b.start) >= b.window {
+		b.start = now
+		b.used = 0
+	}
+	if b.used >= b.max {
+		return false
+	}
+	b.used++
+	return true
+}
+
+// Remaining returns the retries left in the current window
+func (b *Budget) Remaining() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.clock.Now().Sub(b.start) >= b.window {
+		return b.max
+	}
+	return b.max - b.used
+}
+
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as not worth a retry, errors.Is and errors.As still see err
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err}
+}
+
+// IsPermanent reports whether err, or an error it wraps, was marked with Permanent
+func IsPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
+
+var (
+	observersMu     sync.Mutex
+	observers       = map[int]func(Attempt){}
+	observersNextID int
+)
+
+// Observe registers fn to receive every failed attempt of every policy until the returned
+// function is called (ex: retry metrics). fn must not block.
+func Observe(fn func(Attempt)) func() {
+	observersMu.Lock()
+	defer observersMu.Unlock()
+
+	observersNextID++
+	id := observersNextID
+	observers[id] = fn
+
+	return func() {
+		observersMu.Lock()
+		defer observersMu.Unlock()
+		delete(observers, id)
+	}
+}
+
+func notify(attempt Attempt) {
+	observersMu.Lock()
+	fns := make([]func(Attempt), 0, len(observers))
+	for _, fn := range observers {
+		fns = append(fns, fn)
+	}
+	observersMu.Unlock()
+
+	for _, fn := range fns {
+		fn(attempt)
+	}
+}
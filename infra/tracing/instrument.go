@@ -0,0 +1,322 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/port"
+)
+
+// Database returns db whose queries are client spans of the context they are made from, named
+// after the operation and the table (ex: "find orders"). A FindOne without match is not an
+// error of the span.
+func (t *Tracer) Database(db port.IDatabase) port.IDatabase {
+	if t == nil {
+		return db
+	}
+	traced := &tracedDatabase{IDatabase: db, tracer: t}
+	if _, ok := db.(port.IDatabaseExec); ok {
+		return &tracedExecDatabase{traced}
+	}
+	return traced
+}
+
+type tracedDatabase struct {
+	port.IDatabase
+	tracer *Tracer
+}
+
+// trace runs fn in the span of operation on table
+func (d *tracedDatabase) trace(ctx context.Context, operation string, table string, fn func(ctx context.Context) error) error {
+	name := operation
+	if table != "" {
+		name += " " + table
+	}
+	ctx, span := d.tracer.Start(ctx, name, KindClient)
+	defer span.End()
+	span.SetAttribute("db.system", d.GetDriver())
+	span.SetAttribute("db.namespace", d.GetName())
+	span.SetAttribute("db.operation.name", operation)
+	if table != "" {
+		span.SetAttribute("db.collection.name", table)
+	}
+
+	err := fn(ctx)
+	if !errors.Is(err, port.ErrRecordNotFound) {
+		span.RecordError(err)
+	}
+	return err
+}
+
+func (d *tracedDatabase) Ping(ctx context.Context) error {
+	return d.trace(ctx, "ping", "", d.IDatabase.Ping)
+}
+
+func (d *tracedDatabase) Count(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.trace(ctx, "count", table, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Count(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+func (d *tracedDatabase) Find(ctx context.Context, results any, table string, column []string, filter []port.DbExpression, sort map[string]int, limit int64, skip int64) error {
+	return d.trace(ctx, "find", table, func(ctx context.Context) error {
+		return d.IDatabase.Find(ctx, results, table, column, filter, sort, limit, skip)
+	})
+}
+
+func (d *tracedDatabase) FindOne(ctx context.Context, result any, table string, column []string, filter []port.DbExpression, sort map[string]int) error {
+	return d.trace(ctx, "find_one", table, func(ctx context.Context) error {
+		return d.IDatabase.FindOne(ctx, result, table, column, filter, sort)
+	})
+}
+
+func (d *tracedDatabase) InsertOne(ctx context.Context, table string, data any) (id any, err error) {
+	err = d.trace(ctx, "insert", table, func(ctx context.Context) (err error) {
+		id, err = d.IDatabase.InsertOne(ctx, table, data)
+		return err
+	})
+	return id, err
+}
+
+func (d *tracedDatabase) Update(ctx context.Context, table string, filter []port.DbExpression, data any) (count int64, err error) {
+	err = d.trace(ctx, "update", table, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Update(ctx, table, filter, data)
+		return err
+	})
+	return count, err
+}
+
+func (d *tracedDatabase) UpdateOne(ctx context.Context, table string, filter []port.DbExpression, data any) (count int64, err error) {
+	err = d.trace(ctx, "update_one", table, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.UpdateOne(ctx, table, filter, data)
+		return err
+	})
+	return count, err
+}
+
+func (d *tracedDatabase) Delete(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.trace(ctx, "delete", table, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.Delete(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+func (d *tracedDatabase) DeleteOne(ctx context.Context, table string, filter []port.DbExpression) (count int64, err error) {
+	err = d.trace(ctx, "delete_one", table, func(ctx context.Context) (err error) {
+		count, err = d.IDatabase.DeleteOne(ctx, table, filter)
+		return err
+	})
+	return count, err
+}
+
+// FindCursor traces the opening of the cursor, the rows are read without span
+func (d *tracedDatabase) FindCursor(ctx context.Context, table string, column []string, filter []port.DbExpression, sort map[string]int) (cursor port.DbCursor, err error) {
+	err = d.trace(ctx, "find", table, func(ctx context.Context) (err error) {
+		cursor, err = helper.FindCursor(ctx, d.IDatabase, table, column, filter, sort)
+		return err
+	})
+	return cursor, err
+}
+
+// tracedExecDatabase keeps IDatabaseExec of the SQL databases
+type tracedExecDatabase struct {
+	*tracedDatabase
+}
+
+func (d *tracedExecDatabase) Exec(ctx context.Context, statement string) error {
+	return d.trace(ctx, "exec", "", func(ctx context.Context) error {
+		return d.IDatabase.(port.IDatabaseExec).Exec(ctx, statement)
+	})
+}
+
+// Cache returns cache whose Get and Set are client spans of the context given to WithContext,
+// ICacheMemory having no context. Without it they are not traced.
+func (t *Tracer) Cache(cache port.ICacheMemory) *Cache {
+	return &Cache{ICacheMemory: cache, tracer: t}
+}
+
+// Cache is a port.ICacheMemory traced in the context of a request
+type Cache struct {
+	port.ICacheMemory
+	tracer *Tracer
+	ctx    context.Context
+}
+
+// WithContext returns the cache tracing its calls as children of the span of ctx
+func (c *Cache) WithContext(ctx context.Context) *Cache {
+	return &Cache{ICacheMemory: c.ICacheMemory, tracer: c.tracer, ctx: ctx}
+}
+
+func (c *Cache) Set(key string, value any, ttl time.Duration) error {
+	if c.ctx == nil {
+		return c.ICacheMemory.Set(key, value, ttl)
+	}
+	_, span := c.tracer.Start(c.ctx, "cache set", KindClient)
+	defer span.End()
+	span.SetAttribute("db.operation.name", "set")
+
+	err := c.ICacheMemory.Set(key, value, ttl)
+	span.RecordError(err)
+	return err
+}
+
+func (c *Cache) Get(key string, outvalue any) bool {
+	if c.ctx == nil {
+		return c.ICacheMemory.Get(key, outvalue)
+	}
+	_, span := c.tracer.Start(c.ctx, "cache get", KindClient)
+	defer span.End()
+	span.SetAttribute("db.operation.name", "get")
+
+	found := c.ICacheMemory.Get(key, outvalue)
+	span.SetAttribute("cache.hit", found)
+	return found
+}
+
+// PubSub returns pubsub whose publications are producer spans carrying their traceparent and
+// request ID in the attributes of the messages, and whose receivers handle each batch in a
+// consumer span: the child of the publication for a single message, linked to the
+// publications of the messages of a larger batch
+func (t *Tracer) PubSub(pubsub port.IPubSub) port.IPubSub {
+	if t == nil {
+		return pubsub
+	}
+	return &tracedPubSub{IPubSub: pubsub, tracer: t}
+}
+
+type tracedPubSub struct {
+	port.IPubSub
+	tracer *Tracer
+}
+
+func (p *tracedPubSub) Publish(ctx context.Context, message any, attributes map[string]string) (string, error) {
+	ctx, span := p.tracer.Start(ctx, "publish", KindProducer)
+	defer span.End()
+	span.SetAttribute("messaging.system", "pubsub")
+	span.SetAttribute("messaging.operation.type", "send")
+
+	id, err := p.IPubSub.Publish(ctx, message, helper.CorrelationAttributes(ctx, attributes))
+	span.RecordError(err)
+	if id != "" {
+		span.SetAttribute("messaging.message.id", id)
+	}
+	return id, err
+}
+
+func (p *tracedPubSub) RegisterReceiver(receiver port.PubSubReceiver) {
+	p.IPubSub.RegisterReceiver(&tracedReceiver{PubSubReceiver: receiver, tracer: p.tracer})
+}
+
+type tracedReceiver struct {
+	port.PubSubReceiver
+	tracer *Tracer
+}
+
+func (r *tracedReceiver) Consume(ctx context.Context, messages []port.IPubSubMessage) (map[string]bool, error) {
+	var span *Span
+	if len(messages) == 1 {
+		ctx, span = r.tracer.StartMessage(ctx, "receive", messages[0].GetAttributes())
+		span.SetAttribute("messaging.message.id", messages[0].GetID())
+	} else {
+		ctx, span = r.tracer.StartRoot(ctx, "receive", KindConsumer)
+		for _, message := range messages {
+			span.AddLink(message.GetAttributes()[helper.TraceParentAttribute])
+		}
+	}
+	defer span.End()
+	span.SetAttribute("messaging.system", "pubsub")
+	span.SetAttribute("messaging.operation.type", "receive")
+	span.SetAttribute("messaging.batch.message_count", len(messages))
+
+	acks, err := r.PubSubReceiver.Consume(ctx, messages)
+	span.RecordError(err)
+	return acks, err
+}
+
+// StartMessage starts the consumer span name of a received message, the child of the span
+// which published it when attributes carry its traceparent, with the request ID of the
+// attributes in the returned context
+func (t *Tracer) StartMessage(ctx context.Context, name string, attributes map[string]string) (context.Context, *Span) {
+	if t == nil {
+		return helper.CorrelationContext(ctx, attributes), nil
+	}
+	if requestID := attributes[helper.RequestIDAttribute]; requestID != "" {
+		ctx = helper.WithRequestID(ctx, requestID)
+	}
+	if traceID, spanID, flags, ok := parseTraceParent(attributes[helper.TraceParentAttribute]); ok {
+		return t.start(ctx, name, KindConsumer, traceID, spanID, sampledFlag(flags))
+	}
+	return t.StartRoot(ctx, name, KindConsumer)
+}
+
+// HeaderCarrier is a message carrying headers (ex: a Kafka record), the traced Kafka publisher
+// sets the traceparent and the request ID of its span in them
+type HeaderCarrier interface {
+	SetHeader(key string, value string)
+}
+
+// Kafka returns kafka whose publications are producer spans named after their topic. The
+// messages implementing HeaderCarrier receive the traceparent and the request ID, restored by
+// the consumer with StartMessage.
+func (t *Tracer) Kafka(kafka port.IKafka) port.IKafka {
+	if t == nil {
+		return kafka
+	}
+	return &tracedKafka{IKafka: kafka, tracer: t}
+}
+
+type tracedKafka struct {
+	port.IKafka
+	tracer *Tracer
+}
+
+func (k *tracedKafka) Publish(ctx context.Context, topic string, message any) error {
+	ctx, span := k.tracer.Start(ctx, "publish "+topic, KindProducer)
+	defer span.End()
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.operation.type", "send")
+	span.SetAttribute("messaging.destination.name", topic)
+
+	if carrier, ok := message.(HeaderCarrier); ok {
+		for key, value := range helper.CorrelationAttributes(ctx, nil) {
+			carrier.SetHeader(key, value)
+		}
+	}
+	err := k.IKafka.Publish(ctx, topic, message)
+	span.RecordError(err)
+	return err
+}
+
+// KafkaConsumer returns consumer handling each message of topic in a consumer span, the child
+// of the span of the context of the consumer, else the root of a trace as the raw messages
+// carry no header
+func (t *Tracer) KafkaConsumer(topic string, consumer port.KafkaConsumer) port.KafkaConsumer {
+	if t == nil {
+		return consumer
+	}
+	return &tracedKafkaConsumer{KafkaConsumer: consumer, tracer: t, topic: topic}
+}
+
+type tracedKafkaConsumer struct {
+	port.KafkaConsumer
+	tracer *Tracer
+	topic  string
+}
+
+func (k *tracedKafkaConsumer) Consume(ctx context.Context, message []byte) (bool, error) {
+	ctx, span := k.tracer.Start(ctx, "process "+k.topic, KindConsumer)
+	defer span.End()
+	span.SetAttribute("messaging.system", "kafka")
+	span.SetAttribute("messaging.operation.type", "process")
+	span.SetAttribute("messaging.destination.name", k.topic)
+	span.SetAttribute("messaging.message.body.size", len(message))
+
+	ack, err := k.KafkaConsumer.Consume(ctx, message)
+	span.RecordError(err)
+	return ack, err
+}
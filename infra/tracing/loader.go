@@ -0,0 +1,27 @@
+package tracing
+
+import (
+	"github.com/webcore-go/webcore/port"
+)
+
+// OTLPLoader loads the Tracer exporting to an OpenTelemetry collector, register it as
+// "tracing:otlp"
+type OTLPLoader struct {
+	name string
+}
+
+func (l *OTLPLoader) SetName(name string) {
+	l.name = name
+}
+
+func (l *OTLPLoader) Name() string {
+	return l.name
+}
+
+func (l *OTLPLoader) Init(args ...any) (port.Library, error) {
+	tracer := &Tracer{}
+	if err := tracer.Install(args...); err != nil {
+		return nil, err
+	}
+	return tracer, nil
+}
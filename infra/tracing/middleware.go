@@ -0,0 +1,63 @@
+package tracing
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/helper"
+)
+
+// Middleware opens the server span of each request, named after its method and route, as a
+// child of the traceparent header of the caller or as the root of the trace of the request ID.
+// The handlers get the span in their user context. It handles the error of the handlers itself
+// so the recorded status is the one sent.
+func (t *Tracer) Middleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if t == nil {
+			return c.Next()
+		}
+
+		ctx := c.UserContext()
+		traceID, parentID, flags, ok := parseTraceParent(c.Get("traceparent"))
+		sampled := sampledFlag(flags)
+		if !ok {
+			// a new trace, of the ID the request ID was taken from when generated
+			traceID, _, _ = helper.ParseTraceParent(helper.TraceParent(ctx))
+			if traceID == "" {
+				traceID = helper.RandomHex(16)
+			}
+			sampled = t.sample()
+		}
+
+		ctx, span := t.start(ctx, c.Method(), KindServer, traceID, parentID, sampled)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		span.SetAttribute("http.request.method", c.Method())
+		span.SetAttribute("url.path", c.Path())
+		span.SetAttribute("client.address", c.IP())
+		span.SetAttribute("user_agent.original", c.Get(fiber.HeaderUserAgent))
+		if requestID := helper.RequestID(ctx); requestID != "" {
+			span.SetAttribute("request_id", requestID)
+		}
+
+		err := c.Next()
+		if err != nil {
+			span.RecordError(err)
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		span.SetName(c.Method() + " " + c.Route().Path)
+		span.SetAttribute("http.route", c.Route().Path)
+		span.SetAttribute("http.response.status_code", status)
+		switch {
+		case status < fiber.StatusInternalServerError:
+			// the errors of the client are not failures of the server
+			span.SetStatus(StatusUnset, "")
+		case err == nil:
+			span.SetStatus(StatusError, "")
+		}
+		return nil
+	}
+}
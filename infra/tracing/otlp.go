@@ -0,0 +1,208 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-json"
+	"github.com/webcore-go/webcore/infra/config"
+)
+
+// OTLPExporter posts the spans in the JSON encoding of OTLP/HTTP to <endpoint>/v1/traces
+type OTLPExporter struct {
+	url      string
+	headers  map[string]string
+	client   *http.Client
+	resource otlpResource
+}
+
+// NewOTLPExporter creates the exporter to the collector of cfg, the spans of the service
+// cfg.ServiceName in environment
+func NewOTLPExporter(cfg config.TracingConfig, environment string) (*OTLPExporter, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("tracing endpoint is required")
+	}
+	if cfg.ServiceName == "" {
+		return nil, fmt.Errorf("tracing service_name is required")
+	}
+
+	resource := otlpResource{Attributes: []otlpAttribute{otlpAttr("service.name", cfg.ServiceName)}}
+	if environment != "" {
+		resource.Attributes = append(resource.Attributes, otlpAttr("deployment.environment.name", environment))
+	}
+	return &OTLPExporter{
+		url:      strings.TrimSuffix(cfg.Endpoint, "/") + "/v1/traces",
+		headers:  cfg.Headers,
+		client:   &http.Client{},
+		resource: resource,
+	}, nil
+}
+
+func (e *OTLPExporter) Export(ctx context.Context, spans []*Span) error {
+	request := otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: e.resource,
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "github.com/webcore-go/webcore/infra/tracing"},
+			Spans: make([]otlpSpan, 0, len(spans)),
+		}},
+	}}}
+	for _, span := range spans {
+		request.ResourceSpans[0].ScopeSpans[0].Spans = append(request.ResourceSpans[0].ScopeSpans[0].Spans, newOTLPSpan(span))
+	}
+
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("collector answered %d: %s", resp.StatusCode, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
+
+// The messages of OTLP in their JSON encoding: the IDs in hexadecimal, the 64 bits integers
+// and the timestamps as strings
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string          `json:"traceId"`
+	SpanID            string          `json:"spanId"`
+	ParentSpanID      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              SpanKind        `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Events            []otlpEvent     `json:"events,omitempty"`
+	Links             []otlpLink      `json:"links,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string          `json:"timeUnixNano"`
+	Name         string          `json:"name"`
+	Attributes   []otlpAttribute `json:"attributes,omitempty"`
+}
+
+type otlpLink struct {
+	TraceID string `json:"traceId"`
+	SpanID  string `json:"spanId"`
+}
+
+type otlpStatus struct {
+	Code    StatusCode `json:"code,omitempty"`
+	Message string     `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+}
+
+func newOTLPSpan(span *Span) otlpSpan {
+	span.mu.Lock()
+	defer span.mu.Unlock()
+
+	s := otlpSpan{
+		TraceID:           span.traceID,
+		SpanID:            span.spanID,
+		ParentSpanID:      span.parentID,
+		Name:              span.name,
+		Kind:              span.kind,
+		StartTimeUnixNano: unixNano(span.start),
+		EndTimeUnixNano:   unixNano(span.end),
+		Attributes:        otlpAttributes(span.attributes),
+		Status:            otlpStatus{Code: span.status, Message: span.statusMessage},
+	}
+	for _, event := range span.events {
+		s.Events = append(s.Events, otlpEvent{TimeUnixNano: unixNano(event.Time), Name: event.Name, Attributes: otlpAttributes(event.Attributes)})
+	}
+	for _, link := range span.links {
+		s.Links = append(s.Links, otlpLink{TraceID: link.TraceID, SpanID: link.SpanID})
+	}
+	return s
+}
+
+func otlpAttributes(attributes []Attribute) []otlpAttribute {
+	result := make([]otlpAttribute, 0, len(attributes))
+	for _, attribute := range attributes {
+		result = append(result, otlpAttr(attribute.Key, attribute.Value))
+	}
+	return result
+}
+
+// otlpAttr encodes value by its type, the unknown types as their string
+func otlpAttr(key string, value any) otlpAttribute {
+	var v otlpValue
+	switch value := value.(type) {
+	case string:
+		v.StringValue = &value
+	case bool:
+		v.BoolValue = &value
+	case int:
+		i := strconv.Itoa(value)
+		v.IntValue = &i
+	case int64:
+		i := strconv.FormatInt(value, 10)
+		v.IntValue = &i
+	case float64:
+		v.DoubleValue = &value
+	default:
+		s := fmt.Sprint(value)
+		v.StringValue = &s
+	}
+	return otlpAttribute{Key: key, Value: v}
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
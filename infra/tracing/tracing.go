@@ -0,0 +1,431 @@
+// Package tracing records the spans of the requests, the queries and the messages of the
+// application and exports them to an OpenTelemetry collector over OTLP/HTTP.
+//
+// The Tracer is the library "tracing:otlp" of app.tracing. Its middleware opens the span of
+// each request, continuing the W3C traceparent of the caller, and its wrappers open the spans
+// of the libraries used while handling it:
+//
+//	db = app.Context.Tracer.Database(db)
+//	pubsub = app.Context.Tracer.PubSub(pubsub)
+//
+// The spans are carried by the context: a span started from the context of a request is its
+// child, and the traceparent of the context (helper.TraceParent) follows the current span to
+// the HTTP clients and the attributes of the published messages. A nil Tracer and a nil Span do
+// nothing, so the code using them does not depend on app.tracing.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+// SpanKind is the role of a span in its trace, numbered as in OTLP
+type SpanKind int
+
+const (
+	KindInternal SpanKind = 1
+	KindServer   SpanKind = 2 // a request received
+	KindClient   SpanKind = 3 // a call to a dependency (ex: a query)
+	KindProducer SpanKind = 4 // a message published
+	KindConsumer SpanKind = 5 // a message received
+)
+
+// StatusCode is the outcome of a span, numbered as in OTLP
+type StatusCode int
+
+const (
+	StatusUnset StatusCode = 0
+	StatusOK    StatusCode = 1
+	StatusError StatusCode = 2
+)
+
+// Attribute is a key and its value: a string, a bool, an integer or a float
+type Attribute struct {
+	Key   string
+	Value any
+}
+
+// Event is a moment of a span (ex: the error it failed with)
+type Event struct {
+	Name       string
+	Time       time.Time
+	Attributes []Attribute
+}
+
+// Link is a span related to another one without being its parent (ex: the messages of a batch)
+type Link struct {
+	TraceID string
+	SpanID  string
+}
+
+// Span is an operation of a trace, ended by End
+type Span struct {
+	tracer   *Tracer
+	traceID  string
+	spanID   string
+	parentID string
+	sampled  bool
+
+	mu            sync.Mutex
+	name          string
+	kind          SpanKind
+	start         time.Time
+	end           time.Time
+	attributes    []Attribute
+	events        []Event
+	links         []Link
+	status        StatusCode
+	statusMessage string
+	ended         bool
+}
+
+// TraceID returns the trace of the span in hexadecimal, empty for a nil span
+func (s *Span) TraceID() string {
+	if s == nil {
+		return ""
+	}
+	return s.traceID
+}
+
+// SpanID returns the ID of the span in hexadecimal, empty for a nil span
+func (s *Span) SpanID() string {
+	if s == nil {
+		return ""
+	}
+	return s.spanID
+}
+
+// TraceParent returns the W3C traceparent of the children of the span
+func (s *Span) TraceParent() string {
+	if s == nil {
+		return ""
+	}
+	flags := "00"
+	if s.sampled {
+		flags = "01"
+	}
+	return "00-" + s.traceID + "-" + s.spanID + "-" + flags
+}
+
+// SetName renames the span (ex: with the route matched once the request is routed)
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.name = name
+}
+
+// SetAttribute sets the attribute key of the span, replacing its value
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.attributes {
+		if s.attributes[i].Key == key {
+			s.attributes[i].Value = value
+			return
+		}
+	}
+	s.attributes = append(s.attributes, Attribute{Key: key, Value: value})
+}
+
+// AddEvent adds the event name to the span at the current time
+func (s *Span) AddEvent(name string, attributes ...Attribute) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, Event{Name: name, Time: time.Now(), Attributes: attributes})
+}
+
+// AddLink relates the span to the span of traceParent, ignored when it is malformed
+func (s *Span) AddLink(traceParent string) {
+	if s == nil {
+		return
+	}
+	traceID, spanID, _, ok := parseTraceParent(traceParent)
+	if !ok {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.links = append(s.links, Link{TraceID: traceID, SpanID: spanID})
+}
+
+// SetStatus sets the outcome of the span, description explaining an error
+func (s *Span) SetStatus(code StatusCode, description string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.statusMessage = code, description
+}
+
+// RecordError adds err as an "exception" event and sets the status of the span to an error,
+// it does nothing when err is nil
+func (s *Span) RecordError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.AddEvent("exception", Attribute{"exception.type", errorType(err)}, Attribute{"exception.message", err.Error()})
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End ends the span and queues it for the export when its trace is sampled, the calls after
+// the first do nothing
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mu.Unlock()
+
+	if s.sampled {
+		s.tracer.enqueue(s)
+	}
+}
+
+type spanKey struct{}
+
+// SpanFromContext returns the current span of ctx, nil when there is none
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan makes span the current span of ctx, the parent of the spans started from it
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	ctx = context.WithValue(ctx, spanKey{}, span)
+	return helper.WithTraceParent(ctx, span.TraceParent())
+}
+
+// Exporter sends the ended spans to a backend
+type Exporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+// Tracer starts the spans of the application and exports them in batches
+type Tracer struct {
+	config   config.TracingConfig
+	exporter Exporter
+
+	mu      sync.Mutex
+	queue   []*Span
+	dropped int
+	flush   chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+// NewTracer creates a tracer exporting its spans with exporter, started by Connect
+func NewTracer(cfg config.TracingConfig, exporter Exporter) *Tracer {
+	t := &Tracer{}
+	t.configure(cfg, exporter)
+	return t
+}
+
+func (t *Tracer) configure(cfg config.TracingConfig, exporter Exporter) {
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.QueueSize < cfg.BatchSize {
+		cfg.QueueSize = 4 * cfg.BatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	t.config, t.exporter = cfg, exporter
+	t.flush = make(chan struct{}, 1)
+}
+
+// Install configures the tracer with the config.TracingConfig of args[1] and the environment
+// of args[2], exporting to the OTLP collector of the configuration
+func (t *Tracer) Install(args ...any) error {
+	cfg := args[1].(config.TracingConfig)
+	environment := ""
+	if len(args) > 2 {
+		environment, _ = args[2].(string)
+	}
+	exporter, err := NewOTLPExporter(cfg, environment)
+	if err != nil {
+		return err
+	}
+	t.configure(cfg, exporter)
+	return nil
+}
+
+func (t *Tracer) Uninstall() error {
+	return nil
+}
+
+// Connect starts the export of the spans, every flush_interval or once batch_size are queued
+func (t *Tracer) Connect() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.done != nil {
+		return nil
+	}
+	t.done, t.stopped = make(chan struct{}), make(chan struct{})
+
+	go func(done <-chan struct{}, stopped chan<- struct{}) {
+		defer close(stopped)
+		ticker := time.NewTicker(t.config.FlushInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+			case <-t.flush:
+			}
+			t.export()
+		}
+	}(t.done, t.stopped)
+	return nil
+}
+
+// Disconnect stops the export and sends the spans still queued, within the timeout of the
+// configuration
+func (t *Tracer) Disconnect() error {
+	t.mu.Lock()
+	done, stopped := t.done, t.stopped
+	t.done, t.stopped = nil, nil
+	t.mu.Unlock()
+
+	if done != nil {
+		close(done)
+		<-stopped
+	}
+	return t.export()
+}
+
+// Start starts the span name as a child of the current span of ctx, of the traceparent of ctx
+// received from a caller, else as the root of a new trace sampled at sample_rate. The returned
+// context carries the span.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	if parent := SpanFromContext(ctx); parent != nil {
+		return t.start(ctx, name, kind, parent.traceID, parent.spanID, parent.sampled)
+	}
+	if traceID, spanID, flags, ok := parseTraceParent(helper.TraceParent(ctx)); ok {
+		return t.start(ctx, name, kind, traceID, spanID, sampledFlag(flags))
+	}
+	return t.StartRoot(ctx, name, kind)
+}
+
+// StartRoot starts the span name as the root of a new trace, sampled at sample_rate
+func (t *Tracer) StartRoot(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	if t == nil {
+		return ctx, nil
+	}
+	return t.start(ctx, name, kind, helper.RandomHex(16), "", t.sample())
+}
+
+func (t *Tracer) start(ctx context.Context, name string, kind SpanKind, traceID string, parentID string, sampled bool) (context.Context, *Span) {
+	span := &Span{
+		tracer:   t,
+		traceID:  traceID,
+		spanID:   helper.RandomHex(8),
+		parentID: parentID,
+		sampled:  sampled,
+		name:     name,
+		kind:     kind,
+		start:    time.Now(),
+	}
+	return ContextWithSpan(ctx, span), span
+}
+
+// sample draws whether a new trace is recorded
+func (t *Tracer) sample() bool {
+	rate := t.config.SampleRate
+	return rate >= 1 || (rate > 0 && rand.Float64() < rate)
+}
+
+// enqueue queues an ended span, dropped when the queue is full
+func (t *Tracer) enqueue(span *Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.queue) >= t.config.QueueSize {
+		t.dropped++
+		return
+	}
+	t.queue = append(t.queue, span)
+	if len(t.queue) >= t.config.BatchSize {
+		select {
+		case t.flush <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// export sends the queued spans in batches of batch_size
+func (t *Tracer) export() error {
+	t.mu.Lock()
+	queue, dropped := t.queue, t.dropped
+	t.queue, t.dropped = nil, 0
+	t.mu.Unlock()
+
+	if dropped > 0 {
+		logger.Warn("Tracing queue full, spans dropped", "dropped", dropped)
+	}
+	for len(queue) > 0 {
+		batch := queue[:min(len(queue), t.config.BatchSize)]
+		queue = queue[len(batch):]
+
+		ctx, cancel := context.WithTimeout(context.Background(), t.config.Timeout)
+		err := t.exporter.Export(ctx, batch)
+		cancel()
+		if err != nil {
+			logger.Warn("Spans not exported", "spans", len(batch)+len(queue), "error", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// parseTraceParent returns the trace ID, the parent span ID and the flags of a traceparent
+func parseTraceParent(header string) (traceID string, spanID string, flags string, ok bool) {
+	traceID, flags, ok = helper.ParseTraceParent(header)
+	if !ok {
+		return "", "", "", false
+	}
+	return traceID, strings.Split(strings.TrimSpace(header), "-")[2], flags, true
+}
+
+// sampledFlag reports whether the flags of a traceparent have the sampled bit
+func sampledFlag(flags string) bool {
+	return len(flags) == 2 && strings.ContainsRune("13579bdf", rune(flags[1]))
+}
+
+// errorType is the Go type of err (ex: "pq.Error")
+func errorType(err error) string {
+	return strings.TrimPrefix(fmt.Sprintf("%T", err), "*")
+}
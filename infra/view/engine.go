@@ -0,0 +1,279 @@
+package view
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	texttemplate "text/template"
+)
+
+// Options configures an Engine
+type Options struct {
+	// Layout is the default layout of RenderPage (ex: "main" for layouts/main.html), empty renders pages bare
+	Layout string
+	// LayoutsDir and PartialsDir are the directories holding layouts and partials
+	LayoutsDir  string
+	PartialsDir string
+	// Reload re-reads the templates on every render, meant for development
+	Reload bool
+	// Funcs are added to every template
+	Funcs map[string]any
+}
+
+// Engine renders the templates found in a file system (a directory or an embed.FS).
+//
+// Templates are named by their path including the extension (ex: "users/list.html").
+// Files ending with .txt use text/template, every other file uses html/template.
+// Partials are parsed into every template of the same kind and called with
+// {{template "partials/header.html" .}}. A layout includes the page with
+// {{template "content" .}}, pages can override other blocks of the layout with {{define}}.
+type Engine struct {
+	fsys fs.FS
+	opts Options
+
+	mu        sync.RWMutex
+	files     map[string]string
+	templates map[string]executor // "<layout>|<page>" -> compiled template
+}
+
+type source struct {
+	name    string
+	content string
+}
+
+type executor interface {
+	Execute(w io.Writer, data any) error
+}
+
+// New creates an engine reading the templates from fsys
+func New(fsys fs.FS, opts Options) *Engine {
+	if opts.LayoutsDir == "" {
+		opts.LayoutsDir = "layouts"
+	}
+	if opts.PartialsDir == "" {
+		opts.PartialsDir = "partials"
+	}
+
+	return &Engine{
+		fsys:      fsys,
+		opts:      opts,
+		templates: make(map[string]executor),
+	}
+}
+
+// Load reads and compiles every template so syntax errors are reported at startup
+func (e *Engine) Load() error {
+	files, err := e.readFiles()
+	if err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.files = files
+	e.templates = make(map[string]executor)
+	e.mu.Unlock()
+
+	for name := range files {
+		if e.isPartial(name) {
+			continue
+		}
+		if _, err := e.lookup(name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Has reports whether the template exists, with or without the .html extension
+func (e *Engine) Has(name string) bool {
+	files, err := e.getFiles()
+	if err != nil {
+		return false
+	}
+	_, ok := resolve(files, name)
+	return ok
+}
+
+// Render executes a template without layout, it implements port.ITemplateRenderer
+func (e *Engine) Render(w io.Writer, name string, data any) error {
+	t, err := e.lookup(name, "")
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+// RenderPage executes a template inside a layout, the default layout unless one is given.
+// An empty layout renders the page bare.
+func (e *Engine) RenderPage(w io.Writer, name string, data any, layout ...string) error {
+	selected := e.opts.Layout
+	if len(layout) > 0 {
+		selected = layout[0]
+	}
+
+	t, err := e.lookup(name, selected)
+	if err != nil {
+		return err
+	}
+	return t.Execute(w, data)
+}
+
+func (e *Engine) lookup(name string, layout string) (executor, error) {
+	key := layout + "|" + name
+	if !e.opts.Reload {
+		e.mu.RLock()
+		t, ok := e.templates[key]
+		e.mu.RUnlock()
+		if ok {
+			return t, nil
+		}
+	}
+
+	files, err := e.getFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := e.compile(files, name, layout)
+	if err != nil {
+		return nil, err
+	}
+
+	if !e.opts.Reload {
+		e.mu.Lock()
+		e.templates[key] = t
+		e.mu.Unlock()
+	}
+	return t, nil
+}
+
+func (e *Engine) compile(files map[string]string, name string, layout string) (executor, error) {
+	page, ok := resolve(files, name)
+	if !ok {
+		return nil, fmt.Errorf("template '%s' not found", name)
+	}
+
+	var layoutFile string
+	if layout != "" {
+		layoutFile, ok = resolve(files, layout)
+		if !ok {
+			layoutFile, ok = resolve(files, e.opts.LayoutsDir+"/"+layout)
+		}
+		if !ok {
+			return nil, fmt.Errorf("layout '%s' not found", layout)
+		}
+	}
+
+	text := strings.HasSuffix(page, ".txt")
+	partials := []string{}
+	for file := range files {
+		if e.isPartial(file) && strings.HasSuffix(file, ".txt") == text {
+			partials = append(partials, file)
+		}
+	}
+	sort.Strings(partials)
+
+	// the root template is the layout when there is one, the page then becomes its "content"
+	sources := []source{}
+	if layoutFile != "" {
+		sources = append(sources, source{layoutFile, files[layoutFile]}, source{"content", files[page]})
+	} else {
+		sources = append(sources, source{page, files[page]})
+	}
+	for _, partial := range partials {
+		sources = append(sources, source{partial, files[partial]})
+	}
+
+	if text {
+		root := texttemplate.New(sources[0].name).Funcs(e.opts.Funcs)
+		for i, source := range sources {
+			t := root
+			if i > 0 {
+				t = root.New(source.name)
+			}
+			if _, err := t.Parse(source.content); err != nil {
+				return nil, err
+			}
+		}
+		return root, nil
+	}
+
+	root := htmltemplate.New(sources[0].name).Funcs(e.opts.Funcs)
+	for i, source := range sources {
+		t := root
+		if i > 0 {
+			t = root.New(source.name)
+		}
+		if _, err := t.Parse(source.content); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+func (e *Engine) getFiles() (map[string]string, error) {
+	if e.opts.Reload {
+		return e.readFiles()
+	}
+
+	e.mu.RLock()
+	files := e.files
+	e.mu.RUnlock()
+	if files != nil {
+		return files, nil
+	}
+
+	files, err := e.readFiles()
+	if err != nil {
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.files = files
+	e.mu.Unlock()
+	return files, nil
+}
+
+func (e *Engine) readFiles() (map[string]string, error) {
+	files := make(map[string]string)
+	err := fs.WalkDir(e.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+
+		content, err := fs.ReadFile(e.fsys, name)
+		if err != nil {
+			return err
+		}
+		files[name] = string(content)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates: %v", err)
+	}
+	return files, nil
+}
+
+func (e *Engine) isPartial(name string) bool {
+	return strings.HasPrefix(name, e.opts.PartialsDir+"/")
+}
+
+// resolve finds the file of a template name, the .html extension may be omitted
+func resolve(files map[string]string, name string) (string, bool) {
+	name = path.Clean(strings.TrimPrefix(name, "/"))
+	if _, ok := files[name]; ok {
+		return name, true
+	}
+	if _, ok := files[name+".html"]; ok {
+		return name + ".html", true
+	}
+	return "", false
+}
@@ -0,0 +1,416 @@
+package websocket
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Message types (RFC 6455 opcodes)
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	CloseMessage  = 8
+	PingMessage   = 9
+	PongMessage   = 10
+)
+
+// Close codes
+const (
+	CloseNormal          = 1000
+	CloseGoingAway       = 1001
+	CloseProtocolError   = 1002
+	CloseUnsupportedData = 1003
+	CloseInvalidPayload  = 1007
+	ClosePolicyViolation = 1008
+	CloseMessageTooBig   = 1009
+	CloseInternalError   = 1011
+)
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// CloseError is returned by ReadMessage once the peer closed the connection
+type CloseError struct {
+	Code   int
+	Reason string
+}
+
+func (e *CloseError) Error() string {
+	return fmt.Sprintf("websocket: closed with code %d %s", e.Code, e.Reason)
+}
+
+var (
+	ErrNotWebSocket = errors.New("websocket: not a websocket handshake")
+	ErrBadOrigin    = errors.New("websocket: origin not allowed")
+	ErrClosed       = errors.New("websocket: use of closed connection")
+)
+
+// Options configures an upgrade
+type Options struct {
+	// ReadLimit is the largest message accepted, bigger messages close the connection (default 64KB)
+	ReadLimit int64
+	// PingInterval sends a ping when the connection was idle that long, a connection silent
+	// for two intervals is dropped (default 30s, negative disables)
+	PingInterval time.Duration
+	// WriteTimeout bounds every write (default 10s)
+	WriteTimeout time.Duration
+	// Origins allowed to connect (ex: "https://app.example.com", "*" for any). Empty allows
+	// requests without Origin and same host origins only, browsers cannot be used for
+	// cross-site websocket hijacking then.
+	Origins []string
+	// Subprotocols supported by the server in order of preference
+	Subprotocols []string
+}
+
+// IsUpgrade reports whether the request asks for a websocket connection
+func IsUpgrade(c *fiber.Ctx) bool {
+	return c.Method() == fiber.MethodGet &&
+		headerContains(c.Get(fiber.HeaderConnection), "upgrade") &&
+		headerContains(c.Get(fiber.HeaderUpgrade), "websocket")
+}
+
+// Upgrade completes the handshake and runs handler on the connection once the response
+// is sent. The fiber context must not be used by handler, read what it needs before.
+func Upgrade(c *fiber.Ctx, handler func(*Conn), opts ...Options) error {
+	var opt Options
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.ReadLimit <= 0 {
+		opt.ReadLimit = 64 << 10
+	}
+	if opt.PingInterval == 0 {
+		opt.PingInterval = 30 * time.Second
+	}
+	if opt.WriteTimeout <= 0 {
+		opt.WriteTimeout = 10 * time.Second
+	}
+
+	key := c.Get("Sec-WebSocket-Key")
+	if !IsUpgrade(c) || key == "" {
+		return fiber.NewError(fiber.StatusUpgradeRequired, ErrNotWebSocket.Error())
+	}
+	if c.Get("Sec-WebSocket-Version") != "13" {
+		c.Set("Sec-WebSocket-Version", "13")
+		return fiber.NewError(fiber.StatusUpgradeRequired, "websocket: unsupported version")
+	}
+	if !originAllowed(c, opt.Origins) {
+		return fiber.NewError(fiber.StatusForbidden, ErrBadOrigin.Error())
+	}
+
+	subprotocol := ""
+	for _, supported := range opt.Subprotocols {
+		if headerContains(c.Get("Sec-WebSocket-Protocol"), supported) {
+			subprotocol = supported
+			break
+		}
+	}
+
+	c.Status(fiber.StatusSwitchingProtocols)
+	c.Set(fiber.HeaderUpgrade, "websocket")
+	c.Set(fiber.HeaderConnection, "Upgrade")
+	c.Set("Sec-WebSocket-Accept", acceptKey(key))
+	if subprotocol != "" {
+		c.Set("Sec-WebSocket-Protocol", subprotocol)
+	}
+
+	c.Context().Hijack(func(netConn net.Conn) {
+		conn := newConn(netConn, subprotocol, opt)
+		defer conn.close()
+		handler(conn)
+	})
+	return nil
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+func headerContains(header string, token string) bool {
+	for _, value := range strings.Split(header, ",") {
+		if strings.EqualFold(strings.TrimSpace(value), token) {
+			return true
+		}
+	}
+	return false
+}
+
+func originAllowed(c *fiber.Ctx, allowed []string) bool {
+	origin := c.Get(fiber.HeaderOrigin)
+	if origin == "" {
+		return true
+	}
+	for _, value := range allowed {
+		if value == "*" || strings.EqualFold(value, origin) {
+			return true
+		}
+	}
+	if len(allowed) > 0 {
+		return false
+	}
+
+	u, err := url.Parse(origin)
+	return err == nil && strings.EqualFold(u.Host, c.Hostname())
+}
+
+// Conn is a server side websocket connection. ReadMessage must be called from a single
+// goroutine, the write methods are safe for concurrent use.
+type Conn struct {
+	conn        net.Conn
+	reader      *bufio.Reader
+	opts        Options
+	subprotocol string
+
+	writeMu  sync.Mutex
+	closed   bool
+	lastRead time.Time
+	done     chan struct{}
+}
+
+func newConn(netConn net.Conn, subprotocol string, opts Options) *Conn {
+	// clear the deadlines of the HTTP server
+	netConn.SetDeadline(time.Time{})
+
+	conn := &Conn{
+		conn:        netConn,
+		reader:      bufio.NewReader(netConn),
+		opts:        opts,
+		subprotocol: subprotocol,
+		lastRead:    time.Now(),
+		done:        make(chan struct{}),
+	}
+	if opts.PingInterval > 0 {
+		go conn.keepAlive()
+	}
+	return conn
+}
+
+// Subprotocol returns the negotiated subprotocol
+func (c *Conn) Subprotocol() string {
+	return c.subprotocol
+}
+
+// RemoteAddr returns the address of the peer
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
+// ReadMessage returns the next text or binary message, answering pings on the way.
+// It returns a *CloseError once the peer closed the connection.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var (
+		messageType int
+		message     []byte
+	)
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case PingMessage:
+			c.writeFrame(PongMessage, payload)
+			continue
+		case PongMessage:
+			continue
+		case CloseMessage:
+			closeErr := &CloseError{Code: CloseNormal}
+			if len(payload) >= 2 {
+				closeErr.Code = int(binary.BigEndian.Uint16(payload))
+				closeErr.Reason = string(payload[2:])
+			}
+			c.WriteClose(closeErr.Code, "")
+			return 0, nil, closeErr
+		case TextMessage, BinaryMessage:
+			if messageType != 0 {
+				return 0, nil, c.fail(CloseProtocolError, "new message before the end of the fragmented one")
+			}
+			messageType = opcode
+		case 0:
+			if messageType == 0 {
+				return 0, nil, c.fail(CloseProtocolError, "continuation without message")
+			}
+		default:
+			return 0, nil, c.fail(CloseProtocolError, fmt.Sprintf("unknown opcode %d", opcode))
+		}
+
+		if int64(len(message)+len(payload)) > c.opts.ReadLimit {
+			return 0, nil, c.fail(CloseMessageTooBig, "message too big")
+		}
+		message = append(message, payload...)
+
+		if fin {
+			if messageType == TextMessage && !utf8.Valid(message) {
+				return 0, nil, c.fail(CloseInvalidPayload, "invalid UTF-8 text")
+			}
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	if c.opts.PingInterval > 0 {
+		c.conn.SetReadDeadline(time.Now().Add(2 * c.opts.PingInterval))
+	}
+
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+	c.writeMu.Lock()
+	c.lastRead = time.Now()
+	c.writeMu.Unlock()
+
+	fin := header[0]&0x80 != 0
+	if header[0]&0x70 != 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "reserved bits set")
+	}
+	opcode := int(header[0] & 0x0f)
+	if header[1]&0x80 == 0 {
+		return false, 0, nil, c.fail(CloseProtocolError, "client frames must be masked")
+	}
+
+	length := int64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = int64(binary.BigEndian.Uint64(ext))
+	}
+
+	if opcode >= CloseMessage && (!fin || length > 125) {
+		return false, 0, nil, c.fail(CloseProtocolError, "invalid control frame")
+	}
+	if length < 0 || length > c.opts.ReadLimit {
+		return false, 0, nil, c.fail(CloseMessageTooBig, "message too big")
+	}
+
+	mask := make([]byte, 4)
+	if _, err := io.ReadFull(c.reader, mask); err != nil {
+		return false, 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends a text or binary message
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	if messageType != TextMessage && messageType != BinaryMessage {
+		return fmt.Errorf("websocket: invalid message type %d", messageType)
+	}
+	return c.writeFrame(messageType, data)
+}
+
+// WriteClose starts the closing handshake, the connection is closed when the handler returns
+func (c *Conn) WriteClose(code int, reason string) error {
+	payload := make([]byte, 2, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, uint16(code))
+	payload = append(payload, reason...)
+	if len(payload) > 125 {
+		payload = payload[:125]
+	}
+
+	err := c.writeFrame(CloseMessage, payload)
+
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+	return err
+}
+
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	if c.closed {
+		return ErrClosed
+	}
+
+	header := make([]byte, 2, 10)
+	header[0] = 0x80 | byte(opcode)
+	switch length := len(payload); {
+	case length <= 125:
+		header[1] = byte(length)
+	case length <= 0xffff:
+		header[1] = 126
+		header = binary.BigEndian.AppendUint16(header, uint16(length))
+	default:
+		header[1] = 127
+		header = binary.BigEndian.AppendUint64(header, uint64(length))
+	}
+
+	c.conn.SetWriteDeadline(time.Now().Add(c.opts.WriteTimeout))
+	buffers := net.Buffers{header, payload}
+	_, err := buffers.WriteTo(c.conn)
+	return err
+}
+
+// fail closes the connection after a protocol violation of the peer
+func (c *Conn) fail(code int, reason string) error {
+	c.WriteClose(code, reason)
+	return &CloseError{Code: code, Reason: reason}
+}
+
+func (c *Conn) keepAlive() {
+	ticker := time.NewTicker(c.opts.PingInterval / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.writeMu.Lock()
+			idle := time.Since(c.lastRead)
+			c.writeMu.Unlock()
+			if idle >= c.opts.PingInterval/2 {
+				if err := c.writeFrame(PingMessage, nil); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// Close closes the underlying connection without closing handshake
+func (c *Conn) Close() error {
+	c.writeMu.Lock()
+	c.closed = true
+	c.writeMu.Unlock()
+	return c.conn.Close()
+}
+
+func (c *Conn) close() {
+	close(c.done)
+	c.Close()
+}
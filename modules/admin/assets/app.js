@@ -0,0 +1,251 @@
+'use strict';
+
+// the admin endpoints are next to the ui directory
+const base = new URL('..', location.href);
+
+async function api(path, options) {
+  const response = await fetch(new URL(path, base), Object.assign({
+    credentials: 'same-origin',
+    headers: { 'Accept': 'application/json', 'Content-Type': 'application/json' },
+  }, options));
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(body.message || response.status + ' ' + response.statusText);
+  }
+  return body.data;
+}
+
+function el(tag, attrs, ...children) {
+  const node = document.createElement(tag);
+  for (const [name, value] of Object.entries(attrs || {})) {
+    if (name.startsWith('on')) {
+      node.addEventListener(name.slice(2), value);
+    } else {
+      node.setAttribute(name, value);
+    }
+  }
+  for (const child of children.flat()) {
+    node.append(child instanceof Node ? child : document.createTextNode(child == null ? '' : String(child)));
+  }
+  return node;
+}
+
+function table(columns, rows) {
+  return el('table', {},
+    el('thead', {}, el('tr', {}, columns.map(c => el('th', {}, c.title)))),
+    el('tbody', {}, rows.map(row => el('tr', {}, columns.map(c => el('td', c.class ? { class: c.class } : {}, c.value(row)))))));
+}
+
+function keyValues(object) {
+  return table([
+    { title: 'Name', class: 'key', value: r => r[0] },
+    { title: 'Value', value: r => typeof r[1] === 'object' ? JSON.stringify(r[1]) : r[1] },
+  ], Object.entries(object || {}));
+}
+
+function filterable(render) {
+  const container = el('div');
+  const search = el('input', { type: 'search', placeholder: 'Filter…', oninput: () => container.replaceChildren(render(search.value.toLowerCase())) });
+  container.append(render(''));
+  return [search, container];
+}
+
+const views = {
+  async overview() {
+    const data = await api('overview');
+    const memory = data.memory;
+    return [
+      el('h2', {}, 'Application'),
+      keyValues({
+        name: data.name, version: data.version, environment: data.environment,
+        ready: data.ready, started_at: data.started_at, uptime: data.uptime, log_level: data.log_level,
+      }),
+      el('h2', {}, 'Runtime'),
+      keyValues({
+        go_version: data.go_version, goroutines: data.goroutines,
+        heap_alloc: (memory.alloc / 1048576).toFixed(1) + ' MiB', sys: (memory.sys / 1048576).toFixed(1) + ' MiB',
+        heap_objects: memory.heap_objects, gc_cycles: memory.num_gc,
+      }),
+      el('h2', {}, 'Modules'),
+      table([{ title: 'Name', value: r => r }], data.modules || []),
+      el('h2', {}, 'Outbound HTTP clients'),
+      table([
+        { title: 'Upstream', value: r => r.upstream },
+        { title: 'Requests', value: r => r.requests },
+        { title: 'Failures', value: r => r.failures },
+        { title: 'Retries', value: r => r.retries },
+        { title: 'Rejected', value: r => r.rejected },
+        { title: 'Avg latency', value: r => (r.avg_latency / 1e6).toFixed(1) + ' ms' },
+        { title: 'Breaker', value: r => r.breaker },
+      ], data.http_clients || []),
+      data.grpc_services ? [el('h2', {}, 'gRPC services'), table([{ title: 'Service', value: r => r }], data.grpc_services)] : [],
+    ];
+  },
+
+  async routes() {
+    const routes = await api('routes');
+    return filterable(query => table([
+      { title: 'Method', class: 'method', value: r => r.method },
+      { title: 'Path', value: r => r.path },
+      { title: 'Module', value: r => r.module },
+      { title: 'Summary', value: r => r.summary || r.name },
+      { title: 'Roles', value: r => (r.roles || []).join(', ') },
+    ], routes.filter(r => [r.method, r.path, r.name, r.module, r.summary].join(' ').toLowerCase().includes(query))));
+  },
+
+  async libraries() {
+    const libraries = await api('libraries');
+    return table([
+      { title: 'Library', value: r => r.library },
+      { title: 'Instance', value: r => r.key },
+      { title: 'Type', value: r => r.type },
+      { title: 'Connector', value: r => r.connector ? 'yes' : 'no' },
+      { title: 'Loaded', value: r => r.loaded_at },
+      { title: 'Health', value: r => r.health ? r.health.status + (r.health.error ? ': ' + r.health.error : '') : '' },
+    ], libraries);
+  },
+
+  async config() {
+    const config = await api('config');
+    return [el('p', {}, 'Credentials are redacted.'), el('pre', {}, JSON.stringify(config, null, 2))];
+  },
+
+  async events() {
+    const events = await api('events');
+    return table([
+      { title: 'Event', value: r => r[0] },
+      { title: 'Subscribers', value: r => r[1] },
+    ], Object.entries(events).sort());
+  },
+
+  async queue() {
+    const [stats, jobs] = await Promise.all([
+      api('queue/stats').catch(e => ({ error: e.message })),
+      api('scheduler/jobs').catch(() => []),
+    ]);
+    const list = el('div');
+    const queues = stats.error ? [] : Object.keys(stats).sort();
+    return [
+      el('h2', {}, 'Queues'),
+      stats.error ? el('p', { class: 'error' }, stats.error) : table([
+        { title: 'Queue', value: r => r },
+        { title: 'States', value: r => Object.entries(stats[r]).map(([state, n]) => state + ': ' + n).join(', ') },
+        { title: '', value: r => el('button', { class: 'action', onclick: () => showJobs(list, r) }, 'Jobs') },
+      ], queues),
+      list,
+      el('h2', {}, 'Scheduled jobs'),
+      table([
+        { title: 'Name', value: r => r.name },
+        { title: 'Schedule', value: r => r.schedule },
+        { title: 'Next run', value: r => r.next_run },
+        { title: 'Last run', value: r => r.last_run },
+        { title: 'Runs', value: r => r.runs },
+        { title: 'Failures', value: r => r.failures },
+        { title: 'Last error', value: r => r.last_error },
+      ], jobs || []),
+    ];
+  },
+
+  async logging() {
+    const current = await api('logging');
+    const levels = ['debug', 'info', 'warn', 'error'];
+    const message = el('span');
+    const setLevel = async (module, level) => {
+      try {
+        const updated = await api('logging', { method: 'PUT', body: JSON.stringify({ module, level }) });
+        message.textContent = ' Log level of ' + (updated.module || 'the application') + ' set to ' + updated.level;
+      } catch (e) {
+        showError(e);
+      }
+    };
+    const levelSelect = (value, inherit) => {
+      const select = el('select', {}, (inherit ? [''] : []).concat(levels).map(level => el('option', { value: level }, level || inherit)));
+      select.value = value;
+      return select;
+    };
+
+    const select = levelSelect(current.level);
+    const apply = el('button', { class: 'action', onclick: () => setLevel('', select.value) }, 'Apply');
+    const loggers = Object.keys(current.loggers || {}).sort();
+    return [
+      el('h2', {}, 'Log level'),
+      el('p', {}, select, ' ', apply, message),
+      el('h2', {}, 'Loggers'),
+      table([
+        { title: 'Logger', value: r => r },
+        { title: 'Level', value: r => levelSelect(current.loggers[r], 'application (' + current.level + ')') },
+        {
+          title: '', value: r => el('button', {
+            class: 'action',
+            onclick: async (e) => {
+              const level = e.target.closest('tr').querySelector('select').value;
+              if (level) {
+                return setLevel(r, level);
+              }
+              try {
+                await api('logging/' + encodeURIComponent(r), { method: 'DELETE' });
+                message.textContent = ' Log level of ' + r + ' reset to the one of the application';
+              } catch (e) {
+                showError(e);
+              }
+            },
+          }, 'Apply'),
+        },
+      ], loggers),
+    ];
+  },
+};
+
+async function showJobs(container, queue) {
+  try {
+    const jobs = await api('queue/' + encodeURIComponent(queue) + '/jobs?limit=50');
+    container.replaceChildren(el('h2', {}, 'Jobs of ' + queue), table([
+      { title: 'ID', value: r => r.id },
+      { title: 'Type', value: r => r.type },
+      { title: 'State', value: r => r.state },
+      { title: 'Attempts', value: r => r.attempts },
+      { title: 'Run at', value: r => r.run_at },
+      { title: 'Last error', value: r => r.last_error },
+    ], jobs || []));
+  } catch (e) {
+    showError(e);
+  }
+}
+
+function showError(e) {
+  const error = document.getElementById('error');
+  error.textContent = e.message;
+  error.hidden = false;
+}
+
+async function show(name) {
+  document.getElementById('error').hidden = true;
+  for (const button of document.querySelectorAll('nav button')) {
+    button.classList.toggle('active', button.dataset.view === name);
+  }
+  try {
+    document.getElementById('view').replaceChildren(...[await views[name]()].flat(2));
+  } catch (e) {
+    showError(e);
+  }
+}
+
+async function status() {
+  const badge = document.getElementById('status');
+  try {
+    const data = await api('overview');
+    document.getElementById('title').textContent = data.name + ' admin';
+    badge.textContent = data.ready ? 'ready' : 'not ready';
+    badge.className = 'badge ' + (data.ready ? 'ok' : 'ko');
+  } catch (e) {
+    badge.textContent = 'unreachable';
+    badge.className = 'badge ko';
+  }
+}
+
+for (const button of document.querySelectorAll('nav button')) {
+  button.addEventListener('click', () => show(button.dataset.view));
+}
+show('overview');
+status();
+setInterval(status, 15000);
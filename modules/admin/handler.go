@@ -0,0 +1,211 @@
+package admin
+
+import (
+	"io/fs"
+	"path"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/app/helper"
+	"github.com/webcore-go/webcore/app/out"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+type overview struct {
+	Name        string                 `json:"name"`
+	Version     string                 `json:"version"`
+	Environment string                 `json:"environment"`
+	Ready       bool                   `json:"ready"`
+	StartedAt   time.Time              `json:"started_at"`
+	Uptime      string                 `json:"uptime"`
+	GoVersion   string                 `json:"go_version"`
+	Goroutines  int                    `json:"goroutines"`
+	Memory      memoryStats            `json:"memory"`
+	Modules     []string               `json:"modules"`
+	GRPC        []string               `json:"grpc_services,omitempty"`
+	HTTPClients []core.HTTPClientStats `json:"http_clients"`
+	LogLevel    string                 `json:"log_level"`
+}
+
+type memoryStats struct {
+	Alloc       uint64 `json:"alloc"` // bytes of allocated heap objects
+	Sys         uint64 `json:"sys"`   // bytes obtained from the OS
+	HeapObjects uint64 `json:"heap_objects"`
+	NumGC       uint32 `json:"num_gc"`
+}
+
+type routeInfo struct {
+	Method  string   `json:"method"`
+	Path    string   `json:"path"`
+	Name    string   `json:"name,omitempty"`
+	Module  string   `json:"module,omitempty"`
+	Summary string   `json:"summary,omitempty"`
+	Roles   []string `json:"roles,omitempty"`
+}
+
+type logLevel struct {
+	Module string `json:"module,omitempty"` // a logger of logger.Named, the application when empty
+	Level  string `json:"level"`
+}
+
+type logLevels struct {
+	Level   string            `json:"level"`
+	Loggers map[string]string `json:"loggers"` // level of the named loggers, empty at the one of the application
+}
+
+func (l *logLevel) Validate() []out.FieldError {
+	switch l.Level {
+	case "debug", "info", "warn", "error":
+		return nil
+	}
+	return []out.FieldError{{Field: "level", Rule: "oneof", Message: "level must be debug, info, warn or error", Param: "debug info warn error"}}
+}
+
+func (m *Module) registerRoutes(router fiber.Router) {
+	started := time.Now()
+
+	router.Get("/ui", func(c *fiber.Ctx) error {
+		if strings.HasSuffix(c.Path(), "/") {
+			return m.asset(c)
+		}
+		return c.Redirect(c.Path()+"/", fiber.StatusMovedPermanently)
+	})
+	router.Get("/ui/*", m.asset)
+
+	router.Get("/overview", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(m.overview(started)))
+	})
+	router.Get("/routes", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(m.routes()))
+	})
+	router.Get("/config", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(config.Redact(m.context.Config)))
+	})
+	router.Get("/events", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(m.context.EventBus.Subscriptions()))
+	})
+
+	router.Get("/logging", func(c *fiber.Ctx) error {
+		return out.Send(c, out.SuccessData(currentLogLevels()))
+	})
+	// only the admins change the levels, even when the routes are mounted on another router
+	admin := m.context.RequireAdmin()
+	router.Put("/logging", admin, func(c *fiber.Ctx) error {
+		var request logLevel
+		if err := helper.BindBody(c, &request); err != nil {
+			return err
+		}
+
+		var err error
+		if request.Module != "" {
+			err = logger.SetNamedLevel(request.Module, request.Level)
+		} else {
+			err = logger.SetLevel(request.Level)
+		}
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+		}
+
+		logger.Warn("Log level changed", "module", request.Module, "level", request.Level, "user", auth.CurrentUserID(c))
+		return out.Send(c, out.SuccessData(request))
+	})
+	router.Delete("/logging/:module", admin, func(c *fiber.Ctx) error {
+		module := c.Params("module")
+		logger.ResetNamedLevel(module)
+
+		logger.Warn("Log level reset", "module", module, "level", logger.Level(), "user", auth.CurrentUserID(c))
+		return out.Send(c, out.SuccessData(currentLogLevels()))
+	})
+}
+
+func currentLogLevels() logLevels {
+	return logLevels{Level: logger.Level(), Loggers: logger.NamedLevels()}
+}
+
+// asset serves the embedded files of the UI, they hold no data so they are never stale
+func (m *Module) asset(c *fiber.Ctx) error {
+	name := c.Params("*")
+	if name == "" {
+		name = "index.html"
+	}
+
+	content, err := fs.ReadFile(assets, path.Join("assets", path.Clean("/"+name)))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, "Not found")
+	}
+
+	c.Type(strings.TrimPrefix(path.Ext(name), "."), "utf-8")
+	c.Set(fiber.HeaderContentSecurityPolicy, "default-src 'self'; frame-ancestors 'none'")
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return c.Send(content)
+}
+
+func (m *Module) overview(started time.Time) overview {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	modules := core.Instance().ModuleManager.ListModules()
+	sort.Strings(modules)
+
+	result := overview{
+		Name:        m.context.Config.App.Name,
+		Version:     m.context.Config.App.Version,
+		Environment: m.context.Config.App.Environment,
+		Ready:       m.context.Readiness.Ready(),
+		StartedAt:   started,
+		Uptime:      time.Since(started).Round(time.Second).String(),
+		GoVersion:   runtime.Version(),
+		Goroutines:  runtime.NumGoroutine(),
+		Memory: memoryStats{
+			Alloc:       mem.Alloc,
+			Sys:         mem.Sys,
+			HeapObjects: mem.HeapObjects,
+			NumGC:       mem.NumGC,
+		},
+		Modules:     modules,
+		HTTPClients: m.context.HTTPClientStats(),
+		LogLevel:    logger.Level(),
+	}
+	if m.context.GRPC != nil {
+		result.GRPC = m.context.GRPC.Services()
+	}
+	return result
+}
+
+// routes lists the routes of the server, the HEAD twins of GET routes excluded, with the
+// description of the routes of the modules
+func (m *Module) routes() []routeInfo {
+	declared := map[string]core.RouteInfo{}
+	for _, route := range core.Instance().ModuleManager.RouteRegistry() {
+		declared[route.Method+" "+route.Path] = route
+	}
+
+	routes := []routeInfo{}
+	for _, route := range m.context.Web.GetRoutes(true) {
+		if route.Method == fiber.MethodHead {
+			continue
+		}
+		info := routeInfo{Method: route.Method, Path: route.Path, Name: route.Name}
+		if route, ok := declared[route.Method+" "+route.Path]; ok {
+			info.Module = route.Module
+			info.Summary = route.Summary
+			info.Roles = route.Roles
+		}
+		routes = append(routes, info)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
@@ -0,0 +1,75 @@
+package admin
+
+import (
+	"embed"
+
+	"github.com/webcore-go/webcore/app/core"
+	"github.com/webcore-go/webcore/infra/config"
+	"github.com/webcore-go/webcore/infra/logger"
+)
+
+const (
+	ModuleName    = "admin"
+	ModuleVersion = "1.0.0"
+)
+
+//go:embed assets
+var assets embed.FS
+
+// Module mounts the admin UI on <app.admin.path>/ui: routes, libraries, health, the redacted
+// configuration, event subscriptions, the job queue and the log level. It is served behind the
+// authentication of the admin endpoints, so app.admin must be enabled.
+type Module struct {
+	context *core.AppContext
+}
+
+// NewModule creates the admin UI module, add it to the modules of the application
+func NewModule() *Module {
+	return &Module{}
+}
+
+func (m *Module) Name() string {
+	return ModuleName
+}
+
+func (m *Module) Version() string {
+	return ModuleVersion
+}
+
+func (m *Module) Dependencies() []string {
+	return []string{}
+}
+
+func (m *Module) Config() config.ConfigObject {
+	return nil
+}
+
+func (m *Module) Routes() []*core.ModuleRoute {
+	return nil
+}
+
+func (m *Module) Services() map[string]any {
+	return nil
+}
+
+func (m *Module) Repositories() map[string]any {
+	return nil
+}
+
+func (m *Module) Init(ctx *core.AppContext) error {
+	if ctx.Admin == nil {
+		logger.Warn("Admin UI is not mounted, app.admin is disabled")
+		return nil
+	}
+
+	m.context = ctx
+	m.registerRoutes(ctx.Admin)
+
+	logger.Info("Admin UI mounted", "path", ctx.Config.Server.PathPrefix+ctx.Config.App.Admin.Path+"/ui/")
+	return nil
+}
+
+func (m *Module) Destroy() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
@@ -82,19 +82,19 @@ func (a *Authenticator) Logout(ctx *fiber.Ctx) error {
 	return fmt.Errorf("Refresh Token operation not supported for this Authentication scheme")
 }
 
-func (a *Authenticator) Check(ctx *fiber.Ctx) error {
+// Check returns the user of the request, authenticated by the validator
+func (a *Authenticator) Check(ctx *fiber.Ctx) (IUserAuthInfo, error) {
 	// userKey := a.Validator.GetValue()
-	err := a.AuthStore.CheckUser(ctx, a.Validator)
+	userInfo, err := a.AuthStore.CheckUser(ctx, a.Validator)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	userInfo := a.AuthStore.GetLoadedUser()
 	if userInfo == nil {
-		return fmt.Errorf("User not found: nil")
+		return nil, fmt.Errorf("User not found: nil")
 	}
 
-	return nil
+	return userInfo, nil
 }
 
 func (a *Authenticator) GetLoginRequest(ctx *fiber.Ctx) (string, string, error) {
@@ -1,15 +1,20 @@
 package auth
 
 import (
+	"errors"
 	"fmt"
 	"slices"
 	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/webcore-go/webcore/infra/logger"
 	"github.com/webcore-go/webcore/port"
 )
 
+// ErrAccessDenied is the error of the authorizations refusing the user
+var ErrAccessDenied = errors.New("User access denied")
+
 type IAuthorizationManager interface {
 	GetAuthorization() IAuthorization
 }
@@ -20,8 +25,75 @@ type IAuthorization interface {
 	Check(user IUserAuthInfo, method string, path string) error
 }
 
+// IRouteAuthorization is an IAuthorization checking the access declared by the modules on their
+// routes before the resources of its store
+type IRouteAuthorization interface {
+	SetRoutes(routes []RouteRule)
+}
+
+// RouteRule is the access to a route declared by its module
+type RouteRule struct {
+	Method     string
+	Path       string   // pattern of the route (ex: /api/orders/:id)
+	Roles      []string // the user has one of them
+	Permission string   // the user has it
+}
+
+// Matches reports whether the request method path is served by the route
+func (r RouteRule) Matches(method string, path string) bool {
+	return strings.EqualFold(r.Method, method) && matchRoutePath(r.Path, path)
+}
+
+// IsUserPermitted checks that user has one of the roles or the permission of the route, among
+// its RBAC permissions or its ABAC groups
+func (r RouteRule) IsUserPermitted(user IUserAuthInfo) error {
+	roles := UserRoles(user)
+	if r.Permission != "" && !slices.Contains(roles, r.Permission) {
+		return ErrAccessDenied
+	}
+	if len(r.Roles) > 0 && !slices.ContainsFunc(r.Roles, func(role string) bool { return slices.Contains(roles, role) }) {
+		return ErrAccessDenied
+	}
+	return nil
+}
+
+// matchRoutePath reports whether path matches the pattern of a Fiber route: a ":param" segment
+// matches any segment, ":param?" also none, "*" and "+" the rest of the path
+func matchRoutePath(pattern string, path string) bool {
+	patterns := strings.Split(strings.Trim(pattern, "/"), "/")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) == 1 && segments[0] == "" {
+		segments = nil
+	}
+
+	for i, p := range patterns {
+		switch {
+		case p == "*":
+			return true
+		case p == "+":
+			return i < len(segments)
+		case strings.HasPrefix(p, ":") && strings.HasSuffix(p, "?"):
+			if i >= len(segments) {
+				return i == len(patterns)-1
+			}
+		case i >= len(segments):
+			return p == "" && len(patterns) == 1
+		case strings.HasPrefix(p, ":"):
+			if segments[i] == "" {
+				return false
+			}
+		case p != segments[i]:
+			return false
+		}
+	}
+	return len(patterns) == len(segments)
+}
+
 type Authorization struct {
 	Loader IStoreWrapper
+
+	mu     sync.RWMutex
+	routes []RouteRule
 }
 
 func NewAuthorization(loader IStoreWrapper) (*Authorization, error) {
@@ -30,17 +102,42 @@ func NewAuthorization(loader IStoreWrapper) (*Authorization, error) {
 	}, nil
 }
 
+// Install library
+func (a *Authorization) Install(args ...any) error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+func (a *Authorization) Uninstall() error {
+	// Tidak melakukan apa-apa
+	return nil
+}
+
+// SetRoutes replaces the access declared on the routes, checked in place of the resources of the
+// store for the requests they serve
+func (a *Authorization) SetRoutes(routes []RouteRule) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.routes = routes
+}
+
 func (a *Authorization) Check(user IUserAuthInfo, method string, path string) error {
-	ok, err := a.Loader.CheckResource(method, path)
+	a.mu.RLock()
+	routes := a.routes
+	a.mu.RUnlock()
+	for _, route := range routes {
+		if route.Matches(method, path) {
+			return route.IsUserPermitted(user)
+		}
+	}
+
+	resourceInfo, err := a.Loader.CheckResource(method, path)
 	if err != nil {
 		return err
 	}
 
-	if ok {
-		resourceInfo := a.Loader.GetLoadedResource()
-		if resourceInfo != nil {
-			return resourceInfo.IsUserPermitted(user)
-		}
+	if resourceInfo != nil {
+		return resourceInfo.IsUserPermitted(user)
 	}
 
 	// defaulf permission untuk resource yang tidak memiliki permission
@@ -108,7 +205,7 @@ func (r1 *ResourceInfoRBAC) IsUserPermitted(user IUserAuthInfo) error {
 	}
 
 	// The user has no roles that grant access to this resource.
-	return fmt.Errorf("User access denied")
+	return ErrAccessDenied
 }
 
 type ResourceInfoABAC struct {
@@ -163,7 +260,7 @@ func (r2 *ResourceInfoABAC) IsUserPermitted(user IUserAuthInfo) error {
 		}
 	}
 
-	return fmt.Errorf("User access denied")
+	return ErrAccessDenied
 }
 
 func (r2 *ResourceInfoABAC) IsAccessGranted(userPolicy PolicyABAC, policies []PolicyABAC) bool {
@@ -1,5 +1,10 @@
 package auth
 
+import "github.com/gofiber/fiber/v2"
+
+// CurrentUserKey is the fiber Locals key holding the authenticated IUserAuthInfo
+const CurrentUserKey = "current_user"
+
 type IUserAuthInfo interface {
 	GetControlType() string // 'RBAC' or 'ABAC'
 }
@@ -13,6 +18,7 @@ type UserAuthInfoRBAC struct {
 	Password *string  `mapstructure:"password"`    // used by Basic Auth
 	Groups   []string `mapstructure:"groups"`      // used by JWT Auth
 	Roles    []string `mapstructure:"permissions"` // combination of roles from all user groups owned by user
+	Tenant   string   `mapstructure:"tenant"`      // tenant of the user, checked against the tenant of the request
 }
 
 func (u1 *UserAuthInfoRBAC) GetControlType() string {
@@ -42,6 +48,7 @@ type UserAuthInfoABAC struct {
 	Password *string      `mapstructure:"password"` // used by Basic Auth
 	Groups   []string     `mapstructure:"groups"`   // used by JWT Auth
 	Policies []PolicyABAC `mapstructure:"policies"`
+	Tenant   string       `mapstructure:"tenant"` // tenant of the user, checked against the tenant of the request
 }
 
 func (u2 *UserAuthInfoABAC) GetControlType() string {
@@ -51,3 +58,61 @@ func (u2 *UserAuthInfoABAC) GetControlType() string {
 func (u2 *UserAuthInfoABAC) GetUserID() string {
 	return u2.UserId
 }
+
+// SetCurrentUser stores the authenticated user of the request
+func SetCurrentUser(c *fiber.Ctx, user IUserAuthInfo) {
+	c.Locals(CurrentUserKey, user)
+}
+
+// CurrentUser returns the authenticated user of the request, nil when anonymous
+func CurrentUser(c *fiber.Ctx) IUserAuthInfo {
+	user, _ := c.Locals(CurrentUserKey).(IUserAuthInfo)
+	return user
+}
+
+// CurrentUserRoles returns the roles of the authenticated user (RBAC permissions or ABAC groups)
+func CurrentUserRoles(c *fiber.Ctx) []string {
+	return UserRoles(CurrentUser(c))
+}
+
+// UserRoles returns the roles of user (RBAC permissions or ABAC groups)
+func UserRoles(user IUserAuthInfo) []string {
+	switch user := user.(type) {
+	case *UserAuthInfoRBAC:
+		return user.Roles
+	case *UserAuthInfoABAC:
+		return user.Groups
+	default:
+		return nil
+	}
+}
+
+// CurrentUserID returns the key of the authenticated user, or the username with Basic Auth
+func CurrentUserID(c *fiber.Ctx) string {
+	switch user := CurrentUser(c).(type) {
+	case *UserAuthInfoRBAC:
+		if user.UserId == "" && user.Username != nil {
+			return *user.Username
+		}
+		return user.UserId
+	case *UserAuthInfoABAC:
+		if user.UserId == "" && user.Username != nil {
+			return *user.Username
+		}
+		return user.UserId
+	default:
+		return ""
+	}
+}
+
+// CurrentUserTenant returns the tenant claimed by the authenticated user, empty when it has none
+func CurrentUserTenant(c *fiber.Ctx) string {
+	switch user := CurrentUser(c).(type) {
+	case *UserAuthInfoRBAC:
+		return user.Tenant
+	case *UserAuthInfoABAC:
+		return user.Tenant
+	default:
+		return ""
+	}
+}
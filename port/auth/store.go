@@ -13,12 +13,11 @@ type IStore interface {
 	GetResourceInfo(method string, path string) (IResourceInfo, error)
 }
 
+// IStoreWrapper resolves the user and the resource of a request, it is shared by the requests
+// so what it resolves is returned to the request instead of being kept
 type IStoreWrapper interface {
-	CheckUser(ctx *fiber.Ctx, validator IAuthValidator) error
-	GetLoadedUser() IUserAuthInfo
-
-	CheckResource(method string, path string) (bool, error)
-	GetLoadedResource() IResourceInfo
+	CheckUser(ctx *fiber.Ctx, validator IAuthValidator) (IUserAuthInfo, error)
+	CheckResource(method string, path string) (IResourceInfo, error)
 }
 
 type IAuthStore interface {
@@ -26,9 +25,7 @@ type IAuthStore interface {
 }
 
 type StoreWrapper struct {
-	Store    IStore
-	User     IUserAuthInfo
-	Resource IResourceInfo
+	Store IStore
 }
 
 func NewStoreWrapper(store IStore) *StoreWrapper {
@@ -37,32 +34,22 @@ func NewStoreWrapper(store IStore) *StoreWrapper {
 	}
 }
 
-func (u *StoreWrapper) CheckUser(ctx *fiber.Ctx, validator IAuthValidator) error {
+func (u *StoreWrapper) CheckUser(ctx *fiber.Ctx, validator IAuthValidator) (IUserAuthInfo, error) {
 	userKey := validator.GetValue()
 	info, err := u.Store.GetUserAuthInfo(ctx, validator) // mencari user aktif
 	if err != nil {
-		return fmt.Errorf("User not found: %s", userKey)
+		return nil, fmt.Errorf("User not found: %s", userKey)
 	}
 
-	u.User = info
-	return nil
+	return info, nil
 }
 
-func (u *StoreWrapper) CheckResource(method string, path string) (bool, error) {
+func (u *StoreWrapper) CheckResource(method string, path string) (IResourceInfo, error) {
 	info, err := u.Store.GetResourceInfo(method, path) // mencari user aktif
 	if err != nil {
 		logger.Info(err.Error(), "method", method, "path", path)
-		return false, err
+		return nil, err
 	}
 
-	u.Resource = info
-	return true, nil
-}
-
-func (u *StoreWrapper) GetLoadedUser() IUserAuthInfo {
-	return u.User
-}
-
-func (u *StoreWrapper) GetLoadedResource() IResourceInfo {
-	return u.Resource
+	return info, nil
 }
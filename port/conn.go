@@ -2,9 +2,17 @@ package port
 
 import (
 	"context"
+	"errors"
 	"time"
 )
 
+// ErrRecordNotFound is wrapped in the error of IDatabase.FindOne when no row matches the filter
+var ErrRecordNotFound = errors.New("record not found")
+
+// ErrDuplicateKey is wrapped in the error of IDatabase.InsertOne when the primary key or a
+// unique column is already used
+var ErrDuplicateKey = errors.New("duplicate key")
+
 type DbMap map[string]any
 
 type DbExpression struct {
@@ -24,6 +32,9 @@ type IDatabase interface {
 	GetDriver() string
 	GetName() string
 
+	// The expressions of a filter are combined with AND. Update and Delete return the number
+	// of rows matched, UpdateOne and DeleteOne at most 1. The package port/dbtest checks that
+	// a driver follows these rules.
 	Count(ctx context.Context, table string, filter []DbExpression) (int64, error)
 	Find(ctx context.Context, results any, table string, column []string, filter []DbExpression, sort map[string]int, limit int64, skip int64) error
 	FindOne(ctx context.Context, result any, table string, column []string, filter []DbExpression, sort map[string]int) error
@@ -36,6 +47,33 @@ type IDatabase interface {
 	StartMigration(ctx context.Context, service string, command string, dir string, args []string) error
 }
 
+// DbCursor iterates over a result one row or document at a time, the caller must close it
+type DbCursor interface {
+	Next(ctx context.Context) bool
+	Decode(result any) error
+	Err() error
+	Close(ctx context.Context) error
+}
+
+// IDatabaseCursor is implemented by databases streaming large results (ex: MongoDB cursors, SQL rows),
+// helper.FindCursor pages through Find for the others
+type IDatabaseCursor interface {
+	FindCursor(ctx context.Context, table string, column []string, filter []DbExpression, sort map[string]int) (DbCursor, error)
+}
+
+// IDatabaseExec is implemented by the SQL databases, the migrate command applies the files of
+// the migrations directory through it. The others handle the command in StartMigration.
+type IDatabaseExec interface {
+	Exec(ctx context.Context, statement string) error
+}
+
+// IDatabaseTx is implemented by the databases running a function in a transaction: the
+// operations made with the ctx given to fn are committed together when fn returns nil, and
+// rolled back when it returns an error. The outbox stores its messages through it.
+type IDatabaseTx interface {
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
 // Generic for Memory Caching (ex: Redis, MemCached)
 type ICacheMemory interface {
 	Connector
@@ -44,6 +82,13 @@ type ICacheMemory interface {
 	Get(key string, outvalue any) bool
 }
 
+// ICacheCounter is implemented by the caches with atomic counters (ex: Redis INCRBY), the
+// feature quotas count through it. The others are counted with Get and Set.
+type ICacheCounter interface {
+	// Increment adds delta to the counter key, created with ttl, and returns its new value
+	Increment(key string, delta int64, ttl time.Duration) (int64, error)
+}
+
 type IPubSub interface {
 	Connector
 
@@ -0,0 +1,320 @@
+// Package dbtest checks that a port.IDatabase driver follows the semantics shared by all the
+// drivers, so modules behave the same on MongoDB, PostgreSQL, MySQL or SQLite. A driver runs
+// the suite from its own tests:
+//
+//	func TestConformance(t *testing.T) {
+//		dbtest.TestDatabase(t, func(t *testing.T) port.IDatabase {
+//			db := openTestDatabase(t)
+//			// create or empty dbtest.Table
+//			return db
+//		})
+//	}
+package dbtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/webcore-go/webcore/port"
+)
+
+// Table is the table used by the suite, the driver creates it with the columns of Item:
+//
+//	id         64 bits integer, primary key
+//	name       text
+//	qty        64 bits integer
+//	price      double precision
+//	active     boolean
+//	created_at timestamp (second precision is enough)
+const Table = "dbtest_items"
+
+// Item is a row of Table
+type Item struct {
+	ID        int64     `db:"id"`
+	Name      string    `db:"name"`
+	Qty       int64     `db:"qty"`
+	Price     float64   `db:"price"`
+	Active    bool      `db:"active"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+var base = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+// items are inserted before each check
+var items = []Item{
+	{ID: 1, Name: "apple", Qty: 10, Price: 1.5, Active: true, CreatedAt: base},
+	{ID: 2, Name: "banana", Qty: 0, Price: 0.25, Active: false, CreatedAt: base.Add(time.Hour)},
+	{ID: 3, Name: "cherry", Qty: 25, Price: 7, Active: true, CreatedAt: base.Add(2 * time.Hour)},
+	{ID: 4, Name: "apricot", Qty: 5, Price: 3.75, Active: true, CreatedAt: base.Add(3 * time.Hour)},
+	{ID: 5, Name: "date", Qty: 40, Price: 12, Active: false, CreatedAt: base.Add(4 * time.Hour)},
+}
+
+// TestDatabase runs the suite, open is called by each check and returns the database with
+// Table empty
+func TestDatabase(t *testing.T, open func(t *testing.T) port.IDatabase) {
+	checks := []struct {
+		name  string
+		check func(t *testing.T, db port.IDatabase)
+	}{
+		{"InsertFindOne", testInsertFindOne},
+		{"FindOneNotFound", testFindOneNotFound},
+		{"DuplicateKey", testDuplicateKey},
+		{"Filters", testFilters},
+		{"Sort", testSort},
+		{"LimitSkip", testLimitSkip},
+		{"Columns", testColumns},
+		{"Count", testCount},
+		{"Update", testUpdate},
+		{"UpdateOne", testUpdateOne},
+		{"Delete", testDelete},
+		{"DeleteOne", testDeleteOne},
+	}
+
+	for _, c := range checks {
+		t.Run(c.name, func(t *testing.T) {
+			db := open(t)
+			for _, item := range items {
+				if _, err := db.InsertOne(context.Background(), Table, item); err != nil {
+					t.Fatalf("InsertOne(%d): %v", item.ID, err)
+				}
+			}
+			c.check(t, db)
+		})
+	}
+}
+
+func testInsertFindOne(t *testing.T, db port.IDatabase) {
+	for _, want := range items {
+		got := Item{}
+		err := db.FindOne(context.Background(), &got, Table, nil, []port.DbExpression{{Expr: "id", Op: "=", Args: []any{want.ID}}}, nil)
+		if err != nil {
+			t.Fatalf("FindOne(%d): %v", want.ID, err)
+		}
+		if !got.CreatedAt.Equal(want.CreatedAt) {
+			t.Errorf("FindOne(%d).CreatedAt = %v, want %v", want.ID, got.CreatedAt, want.CreatedAt)
+		}
+		got.CreatedAt = want.CreatedAt
+		if got != want {
+			t.Errorf("FindOne(%d) = %+v, want %+v", want.ID, got, want)
+		}
+	}
+}
+
+func testFindOneNotFound(t *testing.T, db port.IDatabase) {
+	got := Item{}
+	err := db.FindOne(context.Background(), &got, Table, nil, []port.DbExpression{{Expr: "id", Args: []any{int64(99)}}}, nil)
+	if !errors.Is(err, port.ErrRecordNotFound) {
+		t.Errorf("FindOne of a missing row returned %v, want an error wrapping port.ErrRecordNotFound", err)
+	}
+
+	rows := []Item{}
+	err = db.Find(context.Background(), &rows, Table, nil, []port.DbExpression{{Expr: "id", Args: []any{int64(99)}}}, nil, 0, 0)
+	if err != nil || len(rows) != 0 {
+		t.Errorf("Find without match returned %d rows and %v, want none and no error", len(rows), err)
+	}
+}
+
+func testDuplicateKey(t *testing.T, db port.IDatabase) {
+	_, err := db.InsertOne(context.Background(), Table, items[0])
+	if !errors.Is(err, port.ErrDuplicateKey) {
+		t.Errorf("InsertOne of an existing id returned %v, want an error wrapping port.ErrDuplicateKey", err)
+	}
+}
+
+func testFilters(t *testing.T, db port.IDatabase) {
+	cases := []struct {
+		name   string
+		filter []port.DbExpression
+		want   []int64
+	}{
+		{"none", nil, []int64{1, 2, 3, 4, 5}},
+		{"default operator", []port.DbExpression{{Expr: "name", Args: []any{"cherry"}}}, []int64{3}},
+		{"=", []port.DbExpression{{Expr: "qty", Op: "=", Args: []any{int64(0)}}}, []int64{2}},
+		{"!=", []port.DbExpression{{Expr: "qty", Op: "!=", Args: []any{int64(0)}}}, []int64{1, 3, 4, 5}},
+		{"<", []port.DbExpression{{Expr: "qty", Op: "<", Args: []any{int64(10)}}}, []int64{2, 4}},
+		{"<=", []port.DbExpression{{Expr: "qty", Op: "<=", Args: []any{int64(10)}}}, []int64{1, 2, 4}},
+		{">", []port.DbExpression{{Expr: "price", Op: ">", Args: []any{3.75}}}, []int64{3, 5}},
+		{">=", []port.DbExpression{{Expr: "price", Op: ">=", Args: []any{3.75}}}, []int64{3, 4, 5}},
+		{"IN", []port.DbExpression{{Expr: "name", Op: "IN", Args: []any{"apple", "date", "fig"}}}, []int64{1, 5}},
+		{"LIKE", []port.DbExpression{{Expr: "name", Op: "LIKE", Args: []any{"ap%"}}}, []int64{1, 4}},
+		{"boolean", []port.DbExpression{{Expr: "active", Args: []any{true}}}, []int64{1, 3, 4}},
+		{"time", []port.DbExpression{{Expr: "created_at", Op: "<", Args: []any{base.Add(2 * time.Hour)}}}, []int64{1, 2}},
+		{"AND", []port.DbExpression{
+			{Expr: "active", Args: []any{true}},
+			{Expr: "qty", Op: ">=", Args: []any{int64(10)}},
+		}, []int64{1, 3}},
+	}
+
+	for _, c := range cases {
+		got := findIDs(t, db, c.filter, map[string]int{"id": 1}, 0, 0)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("filter %s returned %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func testSort(t *testing.T, db port.IDatabase) {
+	if got, want := findIDs(t, db, nil, map[string]int{"qty": 1}, 0, 0), []int64{2, 4, 1, 3, 5}; !slices.Equal(got, want) {
+		t.Errorf("ascending sort returned %v, want %v", got, want)
+	}
+	if got, want := findIDs(t, db, nil, map[string]int{"name": -1}, 0, 0), []int64{5, 3, 2, 4, 1}; !slices.Equal(got, want) {
+		t.Errorf("descending sort returned %v, want %v", got, want)
+	}
+	if got, want := findIDs(t, db, nil, map[string]int{"created_at": -1}, 0, 0), []int64{5, 4, 3, 2, 1}; !slices.Equal(got, want) {
+		t.Errorf("sort by time returned %v, want %v", got, want)
+	}
+}
+
+func testLimitSkip(t *testing.T, db port.IDatabase) {
+	sort := map[string]int{"id": 1}
+	cases := []struct {
+		limit, skip int64
+		want        []int64
+	}{
+		{0, 0, []int64{1, 2, 3, 4, 5}}, // 0 is no limit
+		{2, 0, []int64{1, 2}},
+		{2, 1, []int64{2, 3}},
+		{0, 3, []int64{4, 5}},
+		{10, 4, []int64{5}},
+		{2, 5, []int64{}},
+	}
+
+	for _, c := range cases {
+		got := findIDs(t, db, nil, sort, c.limit, c.skip)
+		if !slices.Equal(got, c.want) {
+			t.Errorf("limit %d skip %d returned %v, want %v", c.limit, c.skip, got, c.want)
+		}
+	}
+}
+
+func testColumns(t *testing.T, db port.IDatabase) {
+	rows := []port.DbMap{}
+	err := db.Find(context.Background(), &rows, Table, []string{"id", "name"}, []port.DbExpression{{Expr: "id", Args: []any{int64(3)}}}, nil, 0, 0)
+	if err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+	if len(rows) != 1 {
+		t.Fatalf("Find returned %d rows, want 1", len(rows))
+	}
+	if fmt.Sprint(rows[0]["name"]) != "cherry" || !sameNumber(rows[0]["id"], 3) {
+		t.Errorf("Find returned %v, want id 3 and name cherry", rows[0])
+	}
+	if _, ok := rows[0]["qty"]; ok {
+		t.Errorf("Find returned the column qty which was not selected: %v", rows[0])
+	}
+}
+
+func testCount(t *testing.T, db port.IDatabase) {
+	count, err := db.Count(context.Background(), Table, []port.DbExpression{{Expr: "active", Args: []any{true}}})
+	if err != nil || count != 3 {
+		t.Errorf("Count returned %d and %v, want 3", count, err)
+	}
+
+	count, err = db.Count(context.Background(), Table, []port.DbExpression{{Expr: "qty", Op: ">", Args: []any{int64(100)}}})
+	if err != nil || count != 0 {
+		t.Errorf("Count without match returned %d and %v, want 0", count, err)
+	}
+}
+
+func testUpdate(t *testing.T, db port.IDatabase) {
+	ctx := context.Background()
+	active := []port.DbExpression{{Expr: "active", Args: []any{true}}}
+
+	count, err := db.Update(ctx, Table, active, port.DbMap{"qty": int64(7)})
+	if err != nil || count != 3 {
+		t.Errorf("Update returned %d and %v, want 3", count, err)
+	}
+	if got := findIDs(t, db, []port.DbExpression{{Expr: "qty", Args: []any{int64(7)}}}, map[string]int{"id": 1}, 0, 0); !slices.Equal(got, []int64{1, 3, 4}) {
+		t.Errorf("Update changed %v, want [1 3 4]", got)
+	}
+
+	// the rows matched are counted even when their values do not change
+	count, err = db.Update(ctx, Table, active, port.DbMap{"qty": int64(7)})
+	if err != nil || count != 3 {
+		t.Errorf("Update without change returned %d and %v, want 3", count, err)
+	}
+
+	count, err = db.Update(ctx, Table, []port.DbExpression{{Expr: "id", Args: []any{int64(99)}}}, port.DbMap{"qty": int64(1)})
+	if err != nil || count != 0 {
+		t.Errorf("Update without match returned %d and %v, want 0", count, err)
+	}
+
+	// columns absent from the data keep their value
+	got := Item{}
+	if err := db.FindOne(ctx, &got, Table, nil, []port.DbExpression{{Expr: "id", Args: []any{int64(1)}}}, nil); err != nil {
+		t.Fatalf("FindOne: %v", err)
+	}
+	if got.Name != "apple" || got.Price != 1.5 {
+		t.Errorf("Update changed other columns: %+v", got)
+	}
+}
+
+func testUpdateOne(t *testing.T, db port.IDatabase) {
+	count, err := db.UpdateOne(context.Background(), Table, []port.DbExpression{{Expr: "active", Args: []any{true}}}, port.DbMap{"name": "updated"})
+	if err != nil || count != 1 {
+		t.Errorf("UpdateOne returned %d and %v, want 1", count, err)
+	}
+	if got := findIDs(t, db, []port.DbExpression{{Expr: "name", Args: []any{"updated"}}}, nil, 0, 0); len(got) != 1 {
+		t.Errorf("UpdateOne changed %v, want one row", got)
+	}
+}
+
+func testDelete(t *testing.T, db port.IDatabase) {
+	count, err := db.Delete(context.Background(), Table, []port.DbExpression{{Expr: "id", Op: "IN", Args: []any{int64(2), int64(4), int64(99)}}})
+	if err != nil || count != 2 {
+		t.Errorf("Delete returned %d and %v, want 2", count, err)
+	}
+	if got := findIDs(t, db, nil, map[string]int{"id": 1}, 0, 0); !slices.Equal(got, []int64{1, 3, 5}) {
+		t.Errorf("rows left after Delete are %v, want [1 3 5]", got)
+	}
+
+	count, err = db.Delete(context.Background(), Table, []port.DbExpression{{Expr: "id", Args: []any{int64(99)}}})
+	if err != nil || count != 0 {
+		t.Errorf("Delete without match returned %d and %v, want 0", count, err)
+	}
+}
+
+func testDeleteOne(t *testing.T, db port.IDatabase) {
+	count, err := db.DeleteOne(context.Background(), Table, []port.DbExpression{{Expr: "active", Args: []any{false}}})
+	if err != nil || count != 1 {
+		t.Errorf("DeleteOne returned %d and %v, want 1", count, err)
+	}
+	if got := findIDs(t, db, nil, nil, 0, 0); len(got) != 4 {
+		t.Errorf("rows left after DeleteOne are %v, want 4 rows", got)
+	}
+}
+
+// findIDs returns the ids of the rows found, decoded in Item structs
+func findIDs(t *testing.T, db port.IDatabase, filter []port.DbExpression, sort map[string]int, limit, skip int64) []int64 {
+	t.Helper()
+
+	rows := []Item{}
+	if err := db.Find(context.Background(), &rows, Table, nil, filter, sort, limit, skip); err != nil {
+		t.Fatalf("Find: %v", err)
+	}
+
+	ids := make([]int64, len(rows))
+	for i, row := range rows {
+		ids[i] = row.ID
+	}
+	return ids
+}
+
+// sameNumber compares numbers of any type, drivers decode integers with various sizes
+func sameNumber(v any, want float64) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()) == want
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()) == want
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == want
+	}
+	return false
+}
@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// ServiceInstance is an instance of the application registered for service discovery
+type ServiceInstance struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Address         string            `json:"address"`
+	Port            int               `json:"port"`
+	Tags            []string          `json:"tags,omitempty"`
+	Meta            map[string]string `json:"meta,omitempty"` // version, environment, grpc_port, ...
+	TTL             time.Duration     `json:"ttl"`            // the instance turns critical without a heartbeat during this delay
+	DeregisterAfter time.Duration     `json:"deregister_after"`
+}
+
+// Generic for service registries (ex: Consul)
+type IServiceRegistry interface {
+	Library
+
+	// Register adds or replaces the instance, critical until the first passing heartbeat
+	Register(ctx context.Context, instance *ServiceInstance) error
+	// Heartbeat reports whether the instance accepts traffic, before its TTL expires
+	Heartbeat(ctx context.Context, id string, ready bool, note string) error
+	Deregister(ctx context.Context, id string) error
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrVersionConflict is returned by IEventStore.Append when the stream was appended by another
+// writer since it was read
+var ErrVersionConflict = errors.New("stream version conflict")
+
+// EventRecord is an event of a stream persisted by the event store. Data holds the JSON encoded
+// event, Version its position in the stream from 1.
+type EventRecord struct {
+	ID        string    `json:"id" db:"id"`
+	Stream    string    `json:"stream" db:"stream"`
+	Version   int64     `json:"version" db:"version"`
+	Type      string    `json:"type" db:"type"`
+	Data      []byte    `json:"data" db:"data"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// SnapshotRecord is the JSON encoded state of a stream folded up to Version
+type SnapshotRecord struct {
+	Stream    string    `json:"stream" db:"stream"`
+	Version   int64     `json:"version" db:"version"`
+	Data      []byte    `json:"data" db:"data"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IEventStore persists the streams of events of the aggregates (ex: database)
+type IEventStore interface {
+	// Append adds events to stream when its last version is still expected (0 for a new
+	// stream), all of them or none, and ErrVersionConflict when another writer appended first
+	Append(ctx context.Context, stream string, expected int64, events []EventRecord) error
+	// Load returns the events of stream after version, in order
+	Load(ctx context.Context, stream string, after int64) ([]EventRecord, error)
+	// SaveSnapshot replaces the snapshot of its stream
+	SaveSnapshot(ctx context.Context, snapshot *SnapshotRecord) error
+	// LoadSnapshot returns nil without error when the stream has no snapshot
+	LoadSnapshot(ctx context.Context, stream string) (*SnapshotRecord, error)
+}
@@ -0,0 +1,35 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// FeatureFlag is a flag of an IFlagProvider. A disabled flag is off for everyone, otherwise
+// the first rule matching the target decides its rollout, Rollout applies when none matches.
+type FeatureFlag struct {
+	Key         string     `json:"key"`
+	Description string     `json:"description,omitempty"`
+	Enabled     bool       `json:"enabled"`
+	Rollout     int        `json:"rollout"` // percent of the principals seeing the flag, 100 for everyone
+	Rules       []FlagRule `json:"rules,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// FlagRule targets the principals whose attribute is one of Values: "principal", "tenant",
+// "role" or an attribute of the target (ex: "country")
+type FlagRule struct {
+	Attribute string   `json:"attribute"`
+	Values    []string `json:"values"`
+	Rollout   int      `json:"rollout"` // percent of the matching principals, 0 excludes them
+}
+
+// IFlagProvider stores the feature flags (ex: file, database, cache)
+type IFlagProvider interface {
+	// Load returns all the flags
+	Load(ctx context.Context) ([]FeatureFlag, error)
+	// Save creates or replaces a flag
+	Save(ctx context.Context, flag FeatureFlag) error
+	// Delete removes a flag, it does nothing when the flag does not exist
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,22 @@
+package port
+
+import "net"
+
+// GeoInfo is the location and network of an IP address
+type GeoInfo struct {
+	IP           string `json:"ip"`
+	Country      string `json:"country,omitempty"` // ISO 3166-1 alpha-2 code (ex: "ID")
+	CountryName  string `json:"country_name,omitempty"`
+	Continent    string `json:"continent,omitempty"` // continent code (ex: "AS")
+	City         string `json:"city,omitempty"`
+	ASN          uint32 `json:"asn,omitempty"`
+	Organization string `json:"organization,omitempty"` // owner of the ASN
+}
+
+// Generic for IP geolocation (ex: MaxMind databases)
+type IGeoIPProvider interface {
+	Library
+
+	// Lookup resolves ip, unknown addresses give a GeoInfo holding only the IP
+	Lookup(ip net.IP) (*GeoInfo, error)
+}
@@ -0,0 +1,22 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// IHubBroker carries the messages of the websocket hub between the instances of the
+// application (ex: Redis). Every instance receives every message published on topic.
+type IHubBroker interface {
+	Publish(ctx context.Context, topic string, message []byte) error
+	// Subscribe blocks calling handler for the messages of topic until ctx is done
+	Subscribe(ctx context.Context, topic string, handler func(message []byte)) error
+}
+
+// IHubPresence is implemented by brokers sharing the members of channels between instances.
+// A member expires after ttl unless it is joined again.
+type IHubPresence interface {
+	Join(ctx context.Context, channel string, member string, ttl time.Duration) error
+	Leave(ctx context.Context, channel string, member string) error
+	Members(ctx context.Context, channel string) ([]string, error)
+}
@@ -1,5 +1,7 @@
 package port
 
+import "context"
+
 type Library interface {
 	Install(args ...any) error
 	Uninstall() error
@@ -10,3 +12,53 @@ type Connector interface {
 	Connect() error
 	Disconnect() error
 }
+
+// ContextInstaller is a Library whose installation honors the cancellation of ctx, the
+// LibraryManager calls InstallCtx in place of Install with the init timeout of the library
+type ContextInstaller interface {
+	InstallCtx(ctx context.Context, args ...any) error
+}
+
+// ContextConnector is a Connector whose connection honors the cancellation of ctx (ex: a
+// database which does not answer), the LibraryManager calls ConnectCtx in place of Connect
+type ContextConnector interface {
+	ConnectCtx(ctx context.Context) error
+}
+
+// Pausable is a library whose work can be suspended (ex: a consumer leaving its group), the
+// old version of a library is paused on the cutover of its upgrade
+type Pausable interface {
+	Pause(ctx context.Context) error
+	// Resume restarts the work, it does nothing when the library is running
+	Resume(ctx context.Context) error
+}
+
+// Drainer is a library finishing its work in flight (ex: the messages being handled) before
+// it is closed by the cutover of its upgrade
+type Drainer interface {
+	Drain(ctx context.Context) error
+}
+
+// HealthChecker is a library reporting whether its dependency answers (ex: a ping of the
+// database or the broker), the health report and the /readyz probe call it
+type HealthChecker interface {
+	Health(ctx context.Context) error
+}
+
+// PreInstaller is a library preparing its installation (ex: checking its configuration), the
+// installation does not happen when PreInstall fails
+type PreInstaller interface {
+	PreInstall(ctx context.Context, args ...any) error
+}
+
+// PostConnector is a library finishing its start once connected (ex: warming up a cache), the
+// library is closed when PostConnect fails
+type PostConnector interface {
+	PostConnect(ctx context.Context) error
+}
+
+// PreUninstaller is a library finishing its work before it is disconnected and uninstalled
+// (ex: flushing the buffered messages of a producer)
+type PreUninstaller interface {
+	PreUninstall(ctx context.Context) error
+}
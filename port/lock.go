@@ -0,0 +1,14 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// ILocker is a distributed lock shared by every instance of the application.
+// TryLock returns false without error when the key is already held by someone else;
+// the lock expires on its own after ttl if it is never released.
+type ILocker interface {
+	TryLock(ctx context.Context, key string, ttl time.Duration) (bool, error)
+	Unlock(ctx context.Context, key string) error
+}
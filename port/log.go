@@ -1,11 +1,32 @@
 package port
 
 import (
+	"context"
 	"log/slog"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+// Logger is the structured logger of the application (infra/logger), for the code depending on
+// an interface, ex: to log into a capture in its tests
+type Logger interface {
+	Debug(msg string, args ...any)
+	Info(msg string, args ...any)
+	Warn(msg string, args ...any)
+	Error(msg string, args ...any)
+	Enabled(level slog.Level) bool
+	// With returns a logger adding args to each line
+	With(args ...any) Logger
+	// WithContext returns a logger adding the request ID and the trace of ctx to each line
+	WithContext(ctx context.Context) Logger
+}
+
+// ILogBackend writes the lines of the logger, the library of app.logging.backend (ex:
+// "logger:zap"). The lines reach its handler masked and at the level of the logger.
+type ILogBackend interface {
+	Handler() slog.Handler
+}
+
 type IRemoteLog interface {
 	Connector
 
@@ -20,3 +41,15 @@ type IRemoteLog interface {
 	CaptureMessage(msg string)
 	CaptureError(err error)
 }
+
+// IRemoteLogFlusher is implemented by the remote logs sending their entries in batches, the
+// entries still buffered are sent by Flush when the application stops
+type IRemoteLogFlusher interface {
+	Flush(ctx context.Context) error
+}
+
+// IErrorReporter receives the panics recovered in the requests (ex: Sentry), with the tags of
+// the request: request_id, method, path and route
+type IErrorReporter interface {
+	ReportPanic(ctx context.Context, err error, stack string, tags map[string]string)
+}
@@ -0,0 +1,36 @@
+package port
+
+import "context"
+
+type MailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	ContentID   string // set to reference the attachment inline from HTML (cid:...)
+}
+
+type MailMessage struct {
+	From        string
+	To          []string
+	Cc          []string
+	Bcc         []string
+	ReplyTo     string
+	Subject     string
+	Text        string
+	HTML        string
+	Headers     map[string]string
+	Attachments []MailAttachment
+
+	// Template is rendered into HTML ("<template>.html") and Text ("<template>.txt")
+	// with Data before sending, when Text/HTML are empty
+	Template string
+	Data     any
+}
+
+// Generic for Email delivery (ex: SMTP, SES, SendGrid)
+type IMailer interface {
+	Library
+
+	Send(ctx context.Context, message *MailMessage) error
+	SendBatch(ctx context.Context, messages []*MailMessage) error
+}
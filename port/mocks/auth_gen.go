@@ -0,0 +1,427 @@
+// Code generated by mockgen; DO NOT EDIT.
+
+package mocks
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/port/auth"
+)
+
+// MockAuthenticationManager is a mock of auth.IAuthenticationManager
+type MockAuthenticationManager struct {
+	Recorder
+
+	GetAuthenticatonHandlerFunc func() fiber.Handler
+}
+
+func (_m *MockAuthenticationManager) GetAuthenticatonHandler() (r0 fiber.Handler) {
+	_m.RecordCall("GetAuthenticatonHandler")
+	if _m.GetAuthenticatonHandlerFunc != nil {
+		return _m.GetAuthenticatonHandlerFunc()
+	}
+	return
+}
+
+var _ auth.IAuthenticationManager = (*MockAuthenticationManager)(nil)
+
+// MockAuthValidator is a mock of auth.IAuthValidator
+type MockAuthValidator struct {
+	Recorder
+
+	NameFunc           func() string
+	GetValueFunc       func() string
+	ValidateKeyFunc    func(*fiber.Ctx) error
+	VerifyUserFunc     func(*fiber.Ctx, string, auth.IUserAuthInfo) (bool, error)
+	IsRequireLoginFunc func() bool
+	GetAuthSessionFunc func() auth.IAuthSession
+}
+
+func (_m *MockAuthValidator) Name() (r0 string) {
+	_m.RecordCall("Name")
+	if _m.NameFunc != nil {
+		return _m.NameFunc()
+	}
+	return
+}
+
+func (_m *MockAuthValidator) GetValue() (r0 string) {
+	_m.RecordCall("GetValue")
+	if _m.GetValueFunc != nil {
+		return _m.GetValueFunc()
+	}
+	return
+}
+
+func (_m *MockAuthValidator) ValidateKey(ctx *fiber.Ctx) (r0 error) {
+	_m.RecordCall("ValidateKey", ctx)
+	if _m.ValidateKeyFunc != nil {
+		return _m.ValidateKeyFunc(ctx)
+	}
+	return
+}
+
+func (_m *MockAuthValidator) VerifyUser(ctx *fiber.Ctx, userKey string, userInfo auth.IUserAuthInfo) (r0 bool, r1 error) {
+	_m.RecordCall("VerifyUser", ctx, userKey, userInfo)
+	if _m.VerifyUserFunc != nil {
+		return _m.VerifyUserFunc(ctx, userKey, userInfo)
+	}
+	return
+}
+
+func (_m *MockAuthValidator) IsRequireLogin() (r0 bool) {
+	_m.RecordCall("IsRequireLogin")
+	if _m.IsRequireLoginFunc != nil {
+		return _m.IsRequireLoginFunc()
+	}
+	return
+}
+
+func (_m *MockAuthValidator) GetAuthSession() (r0 auth.IAuthSession) {
+	_m.RecordCall("GetAuthSession")
+	if _m.GetAuthSessionFunc != nil {
+		return _m.GetAuthSessionFunc()
+	}
+	return
+}
+
+var _ auth.IAuthValidator = (*MockAuthValidator)(nil)
+
+// MockAuthorizationManager is a mock of auth.IAuthorizationManager
+type MockAuthorizationManager struct {
+	Recorder
+
+	GetAuthorizationFunc func() auth.IAuthorization
+}
+
+func (_m *MockAuthorizationManager) GetAuthorization() (r0 auth.IAuthorization) {
+	_m.RecordCall("GetAuthorization")
+	if _m.GetAuthorizationFunc != nil {
+		return _m.GetAuthorizationFunc()
+	}
+	return
+}
+
+var _ auth.IAuthorizationManager = (*MockAuthorizationManager)(nil)
+
+// MockAuthorization is a mock of auth.IAuthorization
+type MockAuthorization struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	CheckFunc     func(auth.IUserAuthInfo, string, string) error
+}
+
+func (_m *MockAuthorization) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockAuthorization) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockAuthorization) Check(user auth.IUserAuthInfo, method string, path string) (r0 error) {
+	_m.RecordCall("Check", user, method, path)
+	if _m.CheckFunc != nil {
+		return _m.CheckFunc(user, method, path)
+	}
+	return
+}
+
+var _ auth.IAuthorization = (*MockAuthorization)(nil)
+
+// MockRouteAuthorization is a mock of auth.IRouteAuthorization
+type MockRouteAuthorization struct {
+	Recorder
+
+	SetRoutesFunc func([]auth.RouteRule)
+}
+
+func (_m *MockRouteAuthorization) SetRoutes(routes []auth.RouteRule) {
+	_m.RecordCall("SetRoutes", routes)
+	if _m.SetRoutesFunc != nil {
+		_m.SetRoutesFunc(routes)
+		return
+	}
+}
+
+var _ auth.IRouteAuthorization = (*MockRouteAuthorization)(nil)
+
+// MockResourceInfo is a mock of auth.IResourceInfo
+type MockResourceInfo struct {
+	Recorder
+
+	GetActionFunc       func() string
+	GetMethodFunc       func() string
+	GetPathFunc         func() string
+	GetControlTypeFunc  func() string
+	IsUserPermittedFunc func(auth.IUserAuthInfo) error
+}
+
+func (_m *MockResourceInfo) GetAction() (r0 string) {
+	_m.RecordCall("GetAction")
+	if _m.GetActionFunc != nil {
+		return _m.GetActionFunc()
+	}
+	return
+}
+
+func (_m *MockResourceInfo) GetMethod() (r0 string) {
+	_m.RecordCall("GetMethod")
+	if _m.GetMethodFunc != nil {
+		return _m.GetMethodFunc()
+	}
+	return
+}
+
+func (_m *MockResourceInfo) GetPath() (r0 string) {
+	_m.RecordCall("GetPath")
+	if _m.GetPathFunc != nil {
+		return _m.GetPathFunc()
+	}
+	return
+}
+
+func (_m *MockResourceInfo) GetControlType() (r0 string) {
+	_m.RecordCall("GetControlType")
+	if _m.GetControlTypeFunc != nil {
+		return _m.GetControlTypeFunc()
+	}
+	return
+}
+
+func (_m *MockResourceInfo) IsUserPermitted(user auth.IUserAuthInfo) (r0 error) {
+	_m.RecordCall("IsUserPermitted", user)
+	if _m.IsUserPermittedFunc != nil {
+		return _m.IsUserPermittedFunc(user)
+	}
+	return
+}
+
+var _ auth.IResourceInfo = (*MockResourceInfo)(nil)
+
+// MockUserAuthInfo is a mock of auth.IUserAuthInfo
+type MockUserAuthInfo struct {
+	Recorder
+
+	GetControlTypeFunc func() string
+}
+
+func (_m *MockUserAuthInfo) GetControlType() (r0 string) {
+	_m.RecordCall("GetControlType")
+	if _m.GetControlTypeFunc != nil {
+		return _m.GetControlTypeFunc()
+	}
+	return
+}
+
+var _ auth.IUserAuthInfo = (*MockUserAuthInfo)(nil)
+
+// MockAuthSessionStore is a mock of auth.IAuthSessionStore
+type MockAuthSessionStore struct {
+	Recorder
+
+	GetSessionStoreFunc func() auth.ISessionStore
+}
+
+func (_m *MockAuthSessionStore) GetSessionStore() (r0 auth.ISessionStore) {
+	_m.RecordCall("GetSessionStore")
+	if _m.GetSessionStoreFunc != nil {
+		return _m.GetSessionStoreFunc()
+	}
+	return
+}
+
+var _ auth.IAuthSessionStore = (*MockAuthSessionStore)(nil)
+
+// MockSessionStore is a mock of auth.ISessionStore
+type MockSessionStore struct {
+	Recorder
+
+	SaveFunc              func(*auth.UserLoginInfo) error
+	RefreshFunc           func(string, *auth.UserLoginInfo) error
+	DeleteFunc            func(*auth.UserLoginInfo) error
+	GetByAccessTokenFunc  func(string) (*auth.UserLoginInfo, error)
+	GetByRefreshTokenFunc func(string) (*auth.UserLoginInfo, error)
+	GetByUsernameFunc     func(string) (*auth.UserLoginInfo, error)
+}
+
+func (_m *MockSessionStore) Save(loginInfo *auth.UserLoginInfo) (r0 error) {
+	_m.RecordCall("Save", loginInfo)
+	if _m.SaveFunc != nil {
+		return _m.SaveFunc(loginInfo)
+	}
+	return
+}
+
+func (_m *MockSessionStore) Refresh(oldAccessToken string, loginInfo *auth.UserLoginInfo) (r0 error) {
+	_m.RecordCall("Refresh", oldAccessToken, loginInfo)
+	if _m.RefreshFunc != nil {
+		return _m.RefreshFunc(oldAccessToken, loginInfo)
+	}
+	return
+}
+
+func (_m *MockSessionStore) Delete(loginInfo *auth.UserLoginInfo) (r0 error) {
+	_m.RecordCall("Delete", loginInfo)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(loginInfo)
+	}
+	return
+}
+
+func (_m *MockSessionStore) GetByAccessToken(accessToken string) (r0 *auth.UserLoginInfo, r1 error) {
+	_m.RecordCall("GetByAccessToken", accessToken)
+	if _m.GetByAccessTokenFunc != nil {
+		return _m.GetByAccessTokenFunc(accessToken)
+	}
+	return
+}
+
+func (_m *MockSessionStore) GetByRefreshToken(refreshToken string) (r0 *auth.UserLoginInfo, r1 error) {
+	_m.RecordCall("GetByRefreshToken", refreshToken)
+	if _m.GetByRefreshTokenFunc != nil {
+		return _m.GetByRefreshTokenFunc(refreshToken)
+	}
+	return
+}
+
+func (_m *MockSessionStore) GetByUsername(username string) (r0 *auth.UserLoginInfo, r1 error) {
+	_m.RecordCall("GetByUsername", username)
+	if _m.GetByUsernameFunc != nil {
+		return _m.GetByUsernameFunc(username)
+	}
+	return
+}
+
+var _ auth.ISessionStore = (*MockSessionStore)(nil)
+
+// MockAuthSession is a mock of auth.IAuthSession
+type MockAuthSession struct {
+	Recorder
+
+	SetSessionStoreFunc func(auth.ISessionStore)
+	LoginFunc           func(*fiber.Ctx, auth.IUserAuthInfo) (*auth.UserLoginInfo, error)
+	RefreshFunc         func(*fiber.Ctx, string) (*auth.UserLoginInfo, error)
+	LogoutFunc          func(*fiber.Ctx, string) error
+}
+
+func (_m *MockAuthSession) SetSessionStore(session auth.ISessionStore) {
+	_m.RecordCall("SetSessionStore", session)
+	if _m.SetSessionStoreFunc != nil {
+		_m.SetSessionStoreFunc(session)
+		return
+	}
+}
+
+func (_m *MockAuthSession) Login(ctx *fiber.Ctx, userInfo auth.IUserAuthInfo) (r0 *auth.UserLoginInfo, r1 error) {
+	_m.RecordCall("Login", ctx, userInfo)
+	if _m.LoginFunc != nil {
+		return _m.LoginFunc(ctx, userInfo)
+	}
+	return
+}
+
+func (_m *MockAuthSession) Refresh(ctx *fiber.Ctx, userKey string) (r0 *auth.UserLoginInfo, r1 error) {
+	_m.RecordCall("Refresh", ctx, userKey)
+	if _m.RefreshFunc != nil {
+		return _m.RefreshFunc(ctx, userKey)
+	}
+	return
+}
+
+func (_m *MockAuthSession) Logout(ctx *fiber.Ctx, userKey string) (r0 error) {
+	_m.RecordCall("Logout", ctx, userKey)
+	if _m.LogoutFunc != nil {
+		return _m.LogoutFunc(ctx, userKey)
+	}
+	return
+}
+
+var _ auth.IAuthSession = (*MockAuthSession)(nil)
+
+// MockStore is a mock of auth.IStore
+type MockStore struct {
+	Recorder
+
+	GetUserLoginInfoFunc func(*fiber.Ctx, string, string) (auth.IUserAuthInfo, error)
+	GetUserAuthInfoFunc  func(*fiber.Ctx, auth.IAuthValidator) (auth.IUserAuthInfo, error)
+	GetResourceInfoFunc  func(string, string) (auth.IResourceInfo, error)
+}
+
+func (_m *MockStore) GetUserLoginInfo(ctx *fiber.Ctx, username string, password string) (r0 auth.IUserAuthInfo, r1 error) {
+	_m.RecordCall("GetUserLoginInfo", ctx, username, password)
+	if _m.GetUserLoginInfoFunc != nil {
+		return _m.GetUserLoginInfoFunc(ctx, username, password)
+	}
+	return
+}
+
+func (_m *MockStore) GetUserAuthInfo(ctx *fiber.Ctx, validator auth.IAuthValidator) (r0 auth.IUserAuthInfo, r1 error) {
+	_m.RecordCall("GetUserAuthInfo", ctx, validator)
+	if _m.GetUserAuthInfoFunc != nil {
+		return _m.GetUserAuthInfoFunc(ctx, validator)
+	}
+	return
+}
+
+func (_m *MockStore) GetResourceInfo(method string, path string) (r0 auth.IResourceInfo, r1 error) {
+	_m.RecordCall("GetResourceInfo", method, path)
+	if _m.GetResourceInfoFunc != nil {
+		return _m.GetResourceInfoFunc(method, path)
+	}
+	return
+}
+
+var _ auth.IStore = (*MockStore)(nil)
+
+// MockStoreWrapper is a mock of auth.IStoreWrapper
+type MockStoreWrapper struct {
+	Recorder
+
+	CheckUserFunc     func(*fiber.Ctx, auth.IAuthValidator) (auth.IUserAuthInfo, error)
+	CheckResourceFunc func(string, string) (auth.IResourceInfo, error)
+}
+
+func (_m *MockStoreWrapper) CheckUser(ctx *fiber.Ctx, validator auth.IAuthValidator) (r0 auth.IUserAuthInfo, r1 error) {
+	_m.RecordCall("CheckUser", ctx, validator)
+	if _m.CheckUserFunc != nil {
+		return _m.CheckUserFunc(ctx, validator)
+	}
+	return
+}
+
+func (_m *MockStoreWrapper) CheckResource(method string, path string) (r0 auth.IResourceInfo, r1 error) {
+	_m.RecordCall("CheckResource", method, path)
+	if _m.CheckResourceFunc != nil {
+		return _m.CheckResourceFunc(method, path)
+	}
+	return
+}
+
+var _ auth.IStoreWrapper = (*MockStoreWrapper)(nil)
+
+// MockAuthStore is a mock of auth.IAuthStore
+type MockAuthStore struct {
+	Recorder
+
+	GetStoreFunc func() auth.IStore
+}
+
+func (_m *MockAuthStore) GetStore() (r0 auth.IStore) {
+	_m.RecordCall("GetStore")
+	if _m.GetStoreFunc != nil {
+		return _m.GetStoreFunc()
+	}
+	return
+}
+
+var _ auth.IAuthStore = (*MockAuthStore)(nil)
@@ -0,0 +1,431 @@
+// Command mockgen writes the mocks of the interfaces declared by the port packages, it is run
+// by go generate in the directory of the mocks package.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// packages are read from the directory of the mocks package
+var packages = []struct {
+	dir    string
+	path   string
+	output string
+}{
+	{"..", "github.com/webcore-go/webcore/port", "port_gen.go"},
+	{"../auth", "github.com/webcore-go/webcore/port/auth", "auth_gen.go"},
+}
+
+// recorderMethods are promoted from the embedded Recorder
+var recorderMethods = []string{"RecordCall", "Calls", "CallCount", "LastCall", "ResetCalls"}
+
+type source struct {
+	path  string
+	name  string
+	types map[string]bool // declared type names
+	decls []*interfaceDecl
+}
+
+type interfaceDecl struct {
+	name  string
+	iface *ast.InterfaceType
+	file  *ast.File
+	src   *source
+}
+
+type method struct {
+	name  string
+	ftype *ast.FuncType
+	decl  *interfaceDecl
+}
+
+// generator writes the mocks of one source package
+type generator struct {
+	sources map[string]*source // by import path
+	imports map[string]string  // import path to name
+	buf     bytes.Buffer
+}
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("mockgen: ")
+
+	sources := map[string]*source{}
+	for _, pkg := range packages {
+		src, err := parseSource(pkg.dir, pkg.path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sources[pkg.path] = src
+	}
+
+	mocks := map[string]string{}
+	for _, pkg := range packages {
+		g := &generator{sources: sources, imports: map[string]string{}}
+		code, err := g.generate(sources[pkg.path], mocks)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(pkg.output, code, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func parseSource(dir, path string) (*source, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &source{path: path, types: map[string]bool{}}
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.SkipObjectResolution)
+		if err != nil {
+			return nil, err
+		}
+		src.name = file.Name.Name
+
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				ts := spec.(*ast.TypeSpec)
+				src.types[ts.Name.Name] = true
+				if iface, ok := ts.Type.(*ast.InterfaceType); ok && ts.TypeParams == nil {
+					src.decls = append(src.decls, &interfaceDecl{name: ts.Name.Name, iface: iface, file: file, src: src})
+				}
+			}
+		}
+	}
+	return src, nil
+}
+
+func (g *generator) generate(src *source, mocks map[string]string) ([]byte, error) {
+	var body bytes.Buffer
+	for _, decl := range src.decls {
+		mock := mockName(decl.name)
+		if other, ok := mocks[mock]; ok {
+			return nil, fmt.Errorf("%s.%s and %s are both mocked as %s", src.name, decl.name, other, mock)
+		}
+		mocks[mock] = src.name + "." + decl.name
+
+		methods, err := g.methods(decl)
+		if err != nil {
+			return nil, err
+		}
+		if err := g.writeMock(&body, decl, mock, methods); err != nil {
+			return nil, err
+		}
+	}
+
+	g.buf.WriteString("// Code generated by mockgen; DO NOT EDIT.\n\npackage mocks\n\nimport (\n")
+	paths := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		paths = append(paths, path)
+	}
+	slices.SortFunc(paths, func(a, b string) int {
+		// the standard library first
+		if std(a) != std(b) {
+			if std(a) {
+				return -1
+			}
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	for i, path := range paths {
+		if i > 0 && std(paths[i-1]) != std(path) {
+			g.buf.WriteString("\n")
+		}
+		if name := g.imports[path]; name != filepath.Base(path) && !strings.HasSuffix(path, "/v2") {
+			fmt.Fprintf(&g.buf, "%s %q\n", name, path)
+		} else {
+			fmt.Fprintf(&g.buf, "%q\n", path)
+		}
+	}
+	g.buf.WriteString(")\n")
+	g.buf.Write(body.Bytes())
+
+	return format.Source(g.buf.Bytes())
+}
+
+// methods lists the methods of decl in the order of the declaration, embedded interfaces
+// included
+func (g *generator) methods(decl *interfaceDecl) ([]method, error) {
+	methods := []method{}
+	seen := map[string]bool{}
+	for _, field := range decl.iface.Methods.List {
+		if ftype, ok := field.Type.(*ast.FuncType); ok {
+			for _, name := range field.Names {
+				if seen[name.Name] {
+					continue
+				}
+				seen[name.Name] = true
+				methods = append(methods, method{name: name.Name, ftype: ftype, decl: decl})
+			}
+			continue
+		}
+
+		embedded, err := g.embedded(decl, field.Type)
+		if err != nil {
+			return nil, err
+		}
+		inherited, err := g.methods(embedded)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range inherited {
+			if !seen[m.name] {
+				seen[m.name] = true
+				methods = append(methods, m)
+			}
+		}
+	}
+	return methods, nil
+}
+
+func (g *generator) embedded(decl *interfaceDecl, expr ast.Expr) (*interfaceDecl, error) {
+	src, name := decl.src, ""
+	switch t := expr.(type) {
+	case *ast.Ident:
+		name = t.Name
+	case *ast.SelectorExpr:
+		path, err := importPath(decl.file, t.X.(*ast.Ident).Name)
+		if err != nil {
+			return nil, err
+		}
+		if src = g.sources[path]; src == nil {
+			return nil, fmt.Errorf("%s embeds %s from an unknown package", decl.name, path)
+		}
+		name = t.Sel.Name
+	default:
+		return nil, fmt.Errorf("%s embeds an unsupported type", decl.name)
+	}
+
+	for _, d := range src.decls {
+		if d.name == name {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("%s embeds %s which is not an interface of %s", decl.name, name, src.path)
+}
+
+func (g *generator) writeMock(w *bytes.Buffer, decl *interfaceDecl, mock string, methods []method) error {
+	qualified := g.importName(decl.src.path, decl.src.name) + "." + decl.name
+
+	fmt.Fprintf(w, "\n// %s is a mock of %s\ntype %s struct {\n\tRecorder\n\n", mock, qualified, mock)
+	for _, m := range methods {
+		if slices.Contains(recorderMethods, m.name) {
+			return fmt.Errorf("%s.%s conflicts with the Recorder", decl.name, m.name)
+		}
+		params, results, err := g.signature(m, false)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "\t%sFunc func(%s) %s\n", m.name, params, results)
+	}
+	w.WriteString("}\n")
+
+	for _, m := range methods {
+		params, results, err := g.signature(m, true)
+		if err != nil {
+			return err
+		}
+		names, args := paramNames(m.ftype)
+
+		fmt.Fprintf(w, "\nfunc (_m *%s) %s(%s) %s {\n", mock, m.name, params, results)
+		fmt.Fprintf(w, "\t_m.RecordCall(%s)\n", strings.Join(append([]string{strconv.Quote(m.name)}, names...), ", "))
+		fmt.Fprintf(w, "\tif _m.%sFunc != nil {\n", m.name)
+		if results == "" {
+			fmt.Fprintf(w, "\t\t_m.%sFunc(%s)\n\t\treturn\n\t}\n}\n", m.name, strings.Join(args, ", "))
+		} else {
+			fmt.Fprintf(w, "\t\treturn _m.%sFunc(%s)\n\t}\n\treturn\n}\n", m.name, strings.Join(args, ", "))
+		}
+	}
+
+	fmt.Fprintf(w, "\nvar _ %s = (*%s)(nil)\n", qualified, mock)
+	return nil
+}
+
+// signature returns the parameters and the results of m, named for a method declaration
+func (g *generator) signature(m method, named bool) (string, string, error) {
+	names, _ := paramNames(m.ftype)
+
+	params := []string{}
+	i := 0
+	for _, field := range m.ftype.Params.List {
+		typ, err := g.typeString(m.decl, field.Type)
+		if err != nil {
+			return "", "", err
+		}
+		for range max(len(field.Names), 1) {
+			if named {
+				params = append(params, names[i]+" "+typ)
+			} else {
+				params = append(params, typ)
+			}
+			i++
+		}
+	}
+
+	results := []string{}
+	if m.ftype.Results != nil {
+		for _, field := range m.ftype.Results.List {
+			typ, err := g.typeString(m.decl, field.Type)
+			if err != nil {
+				return "", "", err
+			}
+			for range max(len(field.Names), 1) {
+				if named {
+					results = append(results, fmt.Sprintf("r%d %s", len(results), typ))
+				} else {
+					results = append(results, typ)
+				}
+			}
+		}
+	}
+
+	switch {
+	case len(results) == 0:
+		return strings.Join(params, ", "), "", nil
+	case len(results) == 1 && !named:
+		return strings.Join(params, ", "), results[0], nil
+	}
+	return strings.Join(params, ", "), "(" + strings.Join(results, ", ") + ")", nil
+}
+
+// paramNames returns the names of the parameters, and the arguments passing them on
+func paramNames(ftype *ast.FuncType) ([]string, []string) {
+	names, args := []string{}, []string{}
+	for _, field := range ftype.Params.List {
+		_, variadic := field.Type.(*ast.Ellipsis)
+		for j := range max(len(field.Names), 1) {
+			name := fmt.Sprintf("a%d", len(names))
+			if j < len(field.Names) && field.Names[j].Name != "_" {
+				name = field.Names[j].Name
+			}
+			names = append(names, name)
+			if variadic {
+				args = append(args, name+"...")
+			} else {
+				args = append(args, name)
+			}
+		}
+	}
+	return names, args
+}
+
+// typeString prints expr as seen from the mocks package
+func (g *generator) typeString(decl *interfaceDecl, expr ast.Expr) (string, error) {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		if decl.src.types[t.Name] {
+			return g.importName(decl.src.path, decl.src.name) + "." + t.Name, nil
+		}
+		return t.Name, nil
+	case *ast.SelectorExpr:
+		pkg := t.X.(*ast.Ident).Name
+		path, err := importPath(decl.file, pkg)
+		if err != nil {
+			return "", err
+		}
+		return g.importName(path, pkg) + "." + t.Sel.Name, nil
+	case *ast.StarExpr:
+		elem, err := g.typeString(decl, t.X)
+		return "*" + elem, err
+	case *ast.Ellipsis:
+		elem, err := g.typeString(decl, t.Elt)
+		return "..." + elem, err
+	case *ast.ArrayType:
+		elem, err := g.typeString(decl, t.Elt)
+		if t.Len == nil {
+			return "[]" + elem, err
+		}
+		size, ok := t.Len.(*ast.BasicLit)
+		if !ok {
+			return "", fmt.Errorf("%s: unsupported array length", decl.name)
+		}
+		return "[" + size.Value + "]" + elem, err
+	case *ast.MapType:
+		key, err := g.typeString(decl, t.Key)
+		if err != nil {
+			return "", err
+		}
+		value, err := g.typeString(decl, t.Value)
+		return "map[" + key + "]" + value, err
+	case *ast.ChanType:
+		elem, err := g.typeString(decl, t.Value)
+		switch t.Dir {
+		case ast.SEND:
+			return "chan<- " + elem, err
+		case ast.RECV:
+			return "<-chan " + elem, err
+		}
+		return "chan " + elem, err
+	case *ast.FuncType:
+		params, results, err := g.signature(method{ftype: t, decl: decl}, false)
+		return strings.TrimSpace("func(" + params + ") " + results), err
+	case *ast.InterfaceType:
+		if len(t.Methods.List) == 0 {
+			return "interface{}", nil
+		}
+	case *ast.StructType:
+		if len(t.Fields.List) == 0 {
+			return "struct{}", nil
+		}
+	}
+	return "", fmt.Errorf("%s: unsupported type %T", decl.name, expr)
+}
+
+func (g *generator) importName(path, name string) string {
+	if existing, ok := g.imports[path]; ok {
+		return existing
+	}
+	g.imports[path] = name
+	return name
+}
+
+func importPath(file *ast.File, name string) (string, error) {
+	for _, spec := range file.Imports {
+		path, _ := strconv.Unquote(spec.Path.Value)
+		if spec.Name != nil && spec.Name.Name == name {
+			return path, nil
+		}
+		if spec.Name == nil && (filepath.Base(path) == name || strings.HasSuffix(path, "/"+name+"/v2")) {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s: package %s is not imported", file.Name.Name, name)
+}
+
+func std(path string) bool {
+	return !strings.Contains(strings.Split(path, "/")[0], ".")
+}
+
+// mockName removes the I prefix of the interface names (ex: IDatabase is MockDatabase)
+func mockName(name string) string {
+	if len(name) > 1 && name[0] == 'I' && name[1] >= 'A' && name[1] <= 'Z' {
+		name = name[1:]
+	}
+	return "Mock" + name
+}
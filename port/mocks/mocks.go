@@ -0,0 +1,75 @@
+// Package mocks provides a mock of every interface of the port and port/auth packages. Each
+// mock records the calls it receives and answers with the function set in the field named
+// after the method, or with zero values when the field is nil:
+//
+//	db := &mocks.MockDatabase{}
+//	db.CountFunc = func(ctx context.Context, table string, filter []port.DbExpression) (int64, error) {
+//		return 3, nil
+//	}
+//	...
+//	if db.CallCount("Count") != 1 { ... }
+//
+// The mocks are generated from the interfaces, run go generate ./port/mocks after changing
+// them.
+package mocks
+
+//go:generate go run ./internal/mockgen
+
+import "sync"
+
+// Call is a call received by a mock, variadic arguments are recorded as one slice
+type Call struct {
+	Method string
+	Args   []any
+}
+
+// Recorder records the calls received by the mock embedding it
+type Recorder struct {
+	mu    sync.Mutex
+	calls []Call
+}
+
+// RecordCall is called by the mocked methods
+func (r *Recorder) RecordCall(method string, args ...any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = append(r.calls, Call{Method: method, Args: args})
+}
+
+// Calls returns the calls of method in the order they were received, all the calls when
+// method is empty
+func (r *Recorder) Calls(method string) []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	calls := []Call{}
+	for _, call := range r.calls {
+		if method == "" || call.Method == method {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// CallCount returns how many times method was called
+func (r *Recorder) CallCount(method string) int {
+	return len(r.Calls(method))
+}
+
+// LastCall returns the last call of method, false when it was not called
+func (r *Recorder) LastCall(method string) (Call, bool) {
+	calls := r.Calls(method)
+	if len(calls) == 0 {
+		return Call{}, false
+	}
+	return calls[len(calls)-1], true
+}
+
+// ResetCalls forgets the calls recorded so far
+func (r *Recorder) ResetCalls() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.calls = nil
+}
@@ -0,0 +1,2006 @@
+// Code generated by mockgen; DO NOT EDIT.
+
+package mocks
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/webcore-go/webcore/port"
+)
+
+// MockDatabase is a mock of port.IDatabase
+type MockDatabase struct {
+	Recorder
+
+	InstallFunc        func(...any) error
+	UninstallFunc      func() error
+	ConnectFunc        func() error
+	DisconnectFunc     func() error
+	PingFunc           func(context.Context) error
+	GetConnectionFunc  func() any
+	GetDriverFunc      func() string
+	GetNameFunc        func() string
+	CountFunc          func(context.Context, string, []port.DbExpression) (int64, error)
+	FindFunc           func(context.Context, any, string, []string, []port.DbExpression, map[string]int, int64, int64) error
+	FindOneFunc        func(context.Context, any, string, []string, []port.DbExpression, map[string]int) error
+	InsertOneFunc      func(context.Context, string, any) (any, error)
+	UpdateFunc         func(context.Context, string, []port.DbExpression, any) (int64, error)
+	UpdateOneFunc      func(context.Context, string, []port.DbExpression, any) (int64, error)
+	DeleteFunc         func(context.Context, string, []port.DbExpression) (int64, error)
+	DeleteOneFunc      func(context.Context, string, []port.DbExpression) (int64, error)
+	StartMigrationFunc func(context.Context, string, string, string, []string) error
+}
+
+func (_m *MockDatabase) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockDatabase) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) Ping(ctx context.Context) (r0 error) {
+	_m.RecordCall("Ping", ctx)
+	if _m.PingFunc != nil {
+		return _m.PingFunc(ctx)
+	}
+	return
+}
+
+func (_m *MockDatabase) GetConnection() (r0 any) {
+	_m.RecordCall("GetConnection")
+	if _m.GetConnectionFunc != nil {
+		return _m.GetConnectionFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) GetDriver() (r0 string) {
+	_m.RecordCall("GetDriver")
+	if _m.GetDriverFunc != nil {
+		return _m.GetDriverFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) GetName() (r0 string) {
+	_m.RecordCall("GetName")
+	if _m.GetNameFunc != nil {
+		return _m.GetNameFunc()
+	}
+	return
+}
+
+func (_m *MockDatabase) Count(ctx context.Context, table string, filter []port.DbExpression) (r0 int64, r1 error) {
+	_m.RecordCall("Count", ctx, table, filter)
+	if _m.CountFunc != nil {
+		return _m.CountFunc(ctx, table, filter)
+	}
+	return
+}
+
+func (_m *MockDatabase) Find(ctx context.Context, results any, table string, column []string, filter []port.DbExpression, sort map[string]int, limit int64, skip int64) (r0 error) {
+	_m.RecordCall("Find", ctx, results, table, column, filter, sort, limit, skip)
+	if _m.FindFunc != nil {
+		return _m.FindFunc(ctx, results, table, column, filter, sort, limit, skip)
+	}
+	return
+}
+
+func (_m *MockDatabase) FindOne(ctx context.Context, result any, table string, column []string, filter []port.DbExpression, sort map[string]int) (r0 error) {
+	_m.RecordCall("FindOne", ctx, result, table, column, filter, sort)
+	if _m.FindOneFunc != nil {
+		return _m.FindOneFunc(ctx, result, table, column, filter, sort)
+	}
+	return
+}
+
+func (_m *MockDatabase) InsertOne(ctx context.Context, table string, data any) (r0 any, r1 error) {
+	_m.RecordCall("InsertOne", ctx, table, data)
+	if _m.InsertOneFunc != nil {
+		return _m.InsertOneFunc(ctx, table, data)
+	}
+	return
+}
+
+func (_m *MockDatabase) Update(ctx context.Context, table string, filter []port.DbExpression, data any) (r0 int64, r1 error) {
+	_m.RecordCall("Update", ctx, table, filter, data)
+	if _m.UpdateFunc != nil {
+		return _m.UpdateFunc(ctx, table, filter, data)
+	}
+	return
+}
+
+func (_m *MockDatabase) UpdateOne(ctx context.Context, table string, filter []port.DbExpression, data any) (r0 int64, r1 error) {
+	_m.RecordCall("UpdateOne", ctx, table, filter, data)
+	if _m.UpdateOneFunc != nil {
+		return _m.UpdateOneFunc(ctx, table, filter, data)
+	}
+	return
+}
+
+func (_m *MockDatabase) Delete(ctx context.Context, table string, filter []port.DbExpression) (r0 int64, r1 error) {
+	_m.RecordCall("Delete", ctx, table, filter)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(ctx, table, filter)
+	}
+	return
+}
+
+func (_m *MockDatabase) DeleteOne(ctx context.Context, table string, filter []port.DbExpression) (r0 int64, r1 error) {
+	_m.RecordCall("DeleteOne", ctx, table, filter)
+	if _m.DeleteOneFunc != nil {
+		return _m.DeleteOneFunc(ctx, table, filter)
+	}
+	return
+}
+
+func (_m *MockDatabase) StartMigration(ctx context.Context, service string, command string, dir string, args []string) (r0 error) {
+	_m.RecordCall("StartMigration", ctx, service, command, dir, args)
+	if _m.StartMigrationFunc != nil {
+		return _m.StartMigrationFunc(ctx, service, command, dir, args)
+	}
+	return
+}
+
+var _ port.IDatabase = (*MockDatabase)(nil)
+
+// MockDbCursor is a mock of port.DbCursor
+type MockDbCursor struct {
+	Recorder
+
+	NextFunc   func(context.Context) bool
+	DecodeFunc func(any) error
+	ErrFunc    func() error
+	CloseFunc  func(context.Context) error
+}
+
+func (_m *MockDbCursor) Next(ctx context.Context) (r0 bool) {
+	_m.RecordCall("Next", ctx)
+	if _m.NextFunc != nil {
+		return _m.NextFunc(ctx)
+	}
+	return
+}
+
+func (_m *MockDbCursor) Decode(result any) (r0 error) {
+	_m.RecordCall("Decode", result)
+	if _m.DecodeFunc != nil {
+		return _m.DecodeFunc(result)
+	}
+	return
+}
+
+func (_m *MockDbCursor) Err() (r0 error) {
+	_m.RecordCall("Err")
+	if _m.ErrFunc != nil {
+		return _m.ErrFunc()
+	}
+	return
+}
+
+func (_m *MockDbCursor) Close(ctx context.Context) (r0 error) {
+	_m.RecordCall("Close", ctx)
+	if _m.CloseFunc != nil {
+		return _m.CloseFunc(ctx)
+	}
+	return
+}
+
+var _ port.DbCursor = (*MockDbCursor)(nil)
+
+// MockDatabaseCursor is a mock of port.IDatabaseCursor
+type MockDatabaseCursor struct {
+	Recorder
+
+	FindCursorFunc func(context.Context, string, []string, []port.DbExpression, map[string]int) (port.DbCursor, error)
+}
+
+func (_m *MockDatabaseCursor) FindCursor(ctx context.Context, table string, column []string, filter []port.DbExpression, sort map[string]int) (r0 port.DbCursor, r1 error) {
+	_m.RecordCall("FindCursor", ctx, table, column, filter, sort)
+	if _m.FindCursorFunc != nil {
+		return _m.FindCursorFunc(ctx, table, column, filter, sort)
+	}
+	return
+}
+
+var _ port.IDatabaseCursor = (*MockDatabaseCursor)(nil)
+
+// MockDatabaseExec is a mock of port.IDatabaseExec
+type MockDatabaseExec struct {
+	Recorder
+
+	ExecFunc func(context.Context, string) error
+}
+
+func (_m *MockDatabaseExec) Exec(ctx context.Context, statement string) (r0 error) {
+	_m.RecordCall("Exec", ctx, statement)
+	if _m.ExecFunc != nil {
+		return _m.ExecFunc(ctx, statement)
+	}
+	return
+}
+
+var _ port.IDatabaseExec = (*MockDatabaseExec)(nil)
+
+// MockDatabaseTx is a mock of port.IDatabaseTx
+type MockDatabaseTx struct {
+	Recorder
+
+	TransactionFunc func(context.Context, func(context.Context) error) error
+}
+
+func (_m *MockDatabaseTx) Transaction(ctx context.Context, fn func(context.Context) error) (r0 error) {
+	_m.RecordCall("Transaction", ctx, fn)
+	if _m.TransactionFunc != nil {
+		return _m.TransactionFunc(ctx, fn)
+	}
+	return
+}
+
+var _ port.IDatabaseTx = (*MockDatabaseTx)(nil)
+
+// MockCacheMemory is a mock of port.ICacheMemory
+type MockCacheMemory struct {
+	Recorder
+
+	InstallFunc    func(...any) error
+	UninstallFunc  func() error
+	ConnectFunc    func() error
+	DisconnectFunc func() error
+	SetFunc        func(string, any, time.Duration) error
+	GetFunc        func(string, any) bool
+}
+
+func (_m *MockCacheMemory) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockCacheMemory) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockCacheMemory) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockCacheMemory) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+func (_m *MockCacheMemory) Set(key string, value any, ttl time.Duration) (r0 error) {
+	_m.RecordCall("Set", key, value, ttl)
+	if _m.SetFunc != nil {
+		return _m.SetFunc(key, value, ttl)
+	}
+	return
+}
+
+func (_m *MockCacheMemory) Get(key string, outvalue any) (r0 bool) {
+	_m.RecordCall("Get", key, outvalue)
+	if _m.GetFunc != nil {
+		return _m.GetFunc(key, outvalue)
+	}
+	return
+}
+
+var _ port.ICacheMemory = (*MockCacheMemory)(nil)
+
+// MockCacheCounter is a mock of port.ICacheCounter
+type MockCacheCounter struct {
+	Recorder
+
+	IncrementFunc func(string, int64, time.Duration) (int64, error)
+}
+
+func (_m *MockCacheCounter) Increment(key string, delta int64, ttl time.Duration) (r0 int64, r1 error) {
+	_m.RecordCall("Increment", key, delta, ttl)
+	if _m.IncrementFunc != nil {
+		return _m.IncrementFunc(key, delta, ttl)
+	}
+	return
+}
+
+var _ port.ICacheCounter = (*MockCacheCounter)(nil)
+
+// MockPubSub is a mock of port.IPubSub
+type MockPubSub struct {
+	Recorder
+
+	InstallFunc          func(...any) error
+	UninstallFunc        func() error
+	ConnectFunc          func() error
+	DisconnectFunc       func() error
+	PublishFunc          func(context.Context, any, map[string]string) (string, error)
+	RegisterReceiverFunc func(port.PubSubReceiver)
+	StartReceivingFunc   func(context.Context)
+}
+
+func (_m *MockPubSub) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockPubSub) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockPubSub) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockPubSub) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+func (_m *MockPubSub) Publish(ctx context.Context, message any, attributes map[string]string) (r0 string, r1 error) {
+	_m.RecordCall("Publish", ctx, message, attributes)
+	if _m.PublishFunc != nil {
+		return _m.PublishFunc(ctx, message, attributes)
+	}
+	return
+}
+
+func (_m *MockPubSub) RegisterReceiver(receiver port.PubSubReceiver) {
+	_m.RecordCall("RegisterReceiver", receiver)
+	if _m.RegisterReceiverFunc != nil {
+		_m.RegisterReceiverFunc(receiver)
+		return
+	}
+}
+
+func (_m *MockPubSub) StartReceiving(ctx context.Context) {
+	_m.RecordCall("StartReceiving", ctx)
+	if _m.StartReceivingFunc != nil {
+		_m.StartReceivingFunc(ctx)
+		return
+	}
+}
+
+var _ port.IPubSub = (*MockPubSub)(nil)
+
+// MockPubSubMessage is a mock of port.IPubSubMessage
+type MockPubSubMessage struct {
+	Recorder
+
+	GetIDFunc          func() string
+	GetDataFunc        func() []byte
+	GetPublishTimeFunc func() time.Time
+	GetAttributesFunc  func() map[string]string
+}
+
+func (_m *MockPubSubMessage) GetID() (r0 string) {
+	_m.RecordCall("GetID")
+	if _m.GetIDFunc != nil {
+		return _m.GetIDFunc()
+	}
+	return
+}
+
+func (_m *MockPubSubMessage) GetData() (r0 []byte) {
+	_m.RecordCall("GetData")
+	if _m.GetDataFunc != nil {
+		return _m.GetDataFunc()
+	}
+	return
+}
+
+func (_m *MockPubSubMessage) GetPublishTime() (r0 time.Time) {
+	_m.RecordCall("GetPublishTime")
+	if _m.GetPublishTimeFunc != nil {
+		return _m.GetPublishTimeFunc()
+	}
+	return
+}
+
+func (_m *MockPubSubMessage) GetAttributes() (r0 map[string]string) {
+	_m.RecordCall("GetAttributes")
+	if _m.GetAttributesFunc != nil {
+		return _m.GetAttributesFunc()
+	}
+	return
+}
+
+var _ port.IPubSubMessage = (*MockPubSubMessage)(nil)
+
+// MockPubSubReceiver is a mock of port.PubSubReceiver
+type MockPubSubReceiver struct {
+	Recorder
+
+	ConsumeFunc func(context.Context, []port.IPubSubMessage) (map[string]bool, error)
+}
+
+func (_m *MockPubSubReceiver) Consume(ctx context.Context, messages []port.IPubSubMessage) (r0 map[string]bool, r1 error) {
+	_m.RecordCall("Consume", ctx, messages)
+	if _m.ConsumeFunc != nil {
+		return _m.ConsumeFunc(ctx, messages)
+	}
+	return
+}
+
+var _ port.PubSubReceiver = (*MockPubSubReceiver)(nil)
+
+// MockKafka is a mock of port.IKafka
+type MockKafka struct {
+	Recorder
+
+	InstallFunc    func(...any) error
+	UninstallFunc  func() error
+	ConnectFunc    func() error
+	DisconnectFunc func() error
+	PublishFunc    func(context.Context, string, any) error
+	ConsumeFunc    func(context.Context, string) (<-chan any, error)
+}
+
+func (_m *MockKafka) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockKafka) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockKafka) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockKafka) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+func (_m *MockKafka) Publish(ctx context.Context, topic string, message any) (r0 error) {
+	_m.RecordCall("Publish", ctx, topic, message)
+	if _m.PublishFunc != nil {
+		return _m.PublishFunc(ctx, topic, message)
+	}
+	return
+}
+
+func (_m *MockKafka) Consume(ctx context.Context, topic string) (r0 <-chan any, r1 error) {
+	_m.RecordCall("Consume", ctx, topic)
+	if _m.ConsumeFunc != nil {
+		return _m.ConsumeFunc(ctx, topic)
+	}
+	return
+}
+
+var _ port.IKafka = (*MockKafka)(nil)
+
+// MockKafkaConsumer is a mock of port.KafkaConsumer
+type MockKafkaConsumer struct {
+	Recorder
+
+	ConsumeFunc func(context.Context, []byte) (bool, error)
+}
+
+func (_m *MockKafkaConsumer) Consume(ctx context.Context, message []byte) (r0 bool, r1 error) {
+	_m.RecordCall("Consume", ctx, message)
+	if _m.ConsumeFunc != nil {
+		return _m.ConsumeFunc(ctx, message)
+	}
+	return
+}
+
+var _ port.KafkaConsumer = (*MockKafkaConsumer)(nil)
+
+// MockServiceRegistry is a mock of port.IServiceRegistry
+type MockServiceRegistry struct {
+	Recorder
+
+	InstallFunc    func(...any) error
+	UninstallFunc  func() error
+	RegisterFunc   func(context.Context, *port.ServiceInstance) error
+	HeartbeatFunc  func(context.Context, string, bool, string) error
+	DeregisterFunc func(context.Context, string) error
+}
+
+func (_m *MockServiceRegistry) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockServiceRegistry) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockServiceRegistry) Register(ctx context.Context, instance *port.ServiceInstance) (r0 error) {
+	_m.RecordCall("Register", ctx, instance)
+	if _m.RegisterFunc != nil {
+		return _m.RegisterFunc(ctx, instance)
+	}
+	return
+}
+
+func (_m *MockServiceRegistry) Heartbeat(ctx context.Context, id string, ready bool, note string) (r0 error) {
+	_m.RecordCall("Heartbeat", ctx, id, ready, note)
+	if _m.HeartbeatFunc != nil {
+		return _m.HeartbeatFunc(ctx, id, ready, note)
+	}
+	return
+}
+
+func (_m *MockServiceRegistry) Deregister(ctx context.Context, id string) (r0 error) {
+	_m.RecordCall("Deregister", ctx, id)
+	if _m.DeregisterFunc != nil {
+		return _m.DeregisterFunc(ctx, id)
+	}
+	return
+}
+
+var _ port.IServiceRegistry = (*MockServiceRegistry)(nil)
+
+// MockEventStore is a mock of port.IEventStore
+type MockEventStore struct {
+	Recorder
+
+	AppendFunc       func(context.Context, string, int64, []port.EventRecord) error
+	LoadFunc         func(context.Context, string, int64) ([]port.EventRecord, error)
+	SaveSnapshotFunc func(context.Context, *port.SnapshotRecord) error
+	LoadSnapshotFunc func(context.Context, string) (*port.SnapshotRecord, error)
+}
+
+func (_m *MockEventStore) Append(ctx context.Context, stream string, expected int64, events []port.EventRecord) (r0 error) {
+	_m.RecordCall("Append", ctx, stream, expected, events)
+	if _m.AppendFunc != nil {
+		return _m.AppendFunc(ctx, stream, expected, events)
+	}
+	return
+}
+
+func (_m *MockEventStore) Load(ctx context.Context, stream string, after int64) (r0 []port.EventRecord, r1 error) {
+	_m.RecordCall("Load", ctx, stream, after)
+	if _m.LoadFunc != nil {
+		return _m.LoadFunc(ctx, stream, after)
+	}
+	return
+}
+
+func (_m *MockEventStore) SaveSnapshot(ctx context.Context, snapshot *port.SnapshotRecord) (r0 error) {
+	_m.RecordCall("SaveSnapshot", ctx, snapshot)
+	if _m.SaveSnapshotFunc != nil {
+		return _m.SaveSnapshotFunc(ctx, snapshot)
+	}
+	return
+}
+
+func (_m *MockEventStore) LoadSnapshot(ctx context.Context, stream string) (r0 *port.SnapshotRecord, r1 error) {
+	_m.RecordCall("LoadSnapshot", ctx, stream)
+	if _m.LoadSnapshotFunc != nil {
+		return _m.LoadSnapshotFunc(ctx, stream)
+	}
+	return
+}
+
+var _ port.IEventStore = (*MockEventStore)(nil)
+
+// MockFlagProvider is a mock of port.IFlagProvider
+type MockFlagProvider struct {
+	Recorder
+
+	LoadFunc   func(context.Context) ([]port.FeatureFlag, error)
+	SaveFunc   func(context.Context, port.FeatureFlag) error
+	DeleteFunc func(context.Context, string) error
+}
+
+func (_m *MockFlagProvider) Load(ctx context.Context) (r0 []port.FeatureFlag, r1 error) {
+	_m.RecordCall("Load", ctx)
+	if _m.LoadFunc != nil {
+		return _m.LoadFunc(ctx)
+	}
+	return
+}
+
+func (_m *MockFlagProvider) Save(ctx context.Context, flag port.FeatureFlag) (r0 error) {
+	_m.RecordCall("Save", ctx, flag)
+	if _m.SaveFunc != nil {
+		return _m.SaveFunc(ctx, flag)
+	}
+	return
+}
+
+func (_m *MockFlagProvider) Delete(ctx context.Context, key string) (r0 error) {
+	_m.RecordCall("Delete", ctx, key)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(ctx, key)
+	}
+	return
+}
+
+var _ port.IFlagProvider = (*MockFlagProvider)(nil)
+
+// MockGeoIPProvider is a mock of port.IGeoIPProvider
+type MockGeoIPProvider struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	LookupFunc    func(net.IP) (*port.GeoInfo, error)
+}
+
+func (_m *MockGeoIPProvider) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockGeoIPProvider) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockGeoIPProvider) Lookup(ip net.IP) (r0 *port.GeoInfo, r1 error) {
+	_m.RecordCall("Lookup", ip)
+	if _m.LookupFunc != nil {
+		return _m.LookupFunc(ip)
+	}
+	return
+}
+
+var _ port.IGeoIPProvider = (*MockGeoIPProvider)(nil)
+
+// MockHubBroker is a mock of port.IHubBroker
+type MockHubBroker struct {
+	Recorder
+
+	PublishFunc   func(context.Context, string, []byte) error
+	SubscribeFunc func(context.Context, string, func([]byte)) error
+}
+
+func (_m *MockHubBroker) Publish(ctx context.Context, topic string, message []byte) (r0 error) {
+	_m.RecordCall("Publish", ctx, topic, message)
+	if _m.PublishFunc != nil {
+		return _m.PublishFunc(ctx, topic, message)
+	}
+	return
+}
+
+func (_m *MockHubBroker) Subscribe(ctx context.Context, topic string, handler func([]byte)) (r0 error) {
+	_m.RecordCall("Subscribe", ctx, topic, handler)
+	if _m.SubscribeFunc != nil {
+		return _m.SubscribeFunc(ctx, topic, handler)
+	}
+	return
+}
+
+var _ port.IHubBroker = (*MockHubBroker)(nil)
+
+// MockHubPresence is a mock of port.IHubPresence
+type MockHubPresence struct {
+	Recorder
+
+	JoinFunc    func(context.Context, string, string, time.Duration) error
+	LeaveFunc   func(context.Context, string, string) error
+	MembersFunc func(context.Context, string) ([]string, error)
+}
+
+func (_m *MockHubPresence) Join(ctx context.Context, channel string, member string, ttl time.Duration) (r0 error) {
+	_m.RecordCall("Join", ctx, channel, member, ttl)
+	if _m.JoinFunc != nil {
+		return _m.JoinFunc(ctx, channel, member, ttl)
+	}
+	return
+}
+
+func (_m *MockHubPresence) Leave(ctx context.Context, channel string, member string) (r0 error) {
+	_m.RecordCall("Leave", ctx, channel, member)
+	if _m.LeaveFunc != nil {
+		return _m.LeaveFunc(ctx, channel, member)
+	}
+	return
+}
+
+func (_m *MockHubPresence) Members(ctx context.Context, channel string) (r0 []string, r1 error) {
+	_m.RecordCall("Members", ctx, channel)
+	if _m.MembersFunc != nil {
+		return _m.MembersFunc(ctx, channel)
+	}
+	return
+}
+
+var _ port.IHubPresence = (*MockHubPresence)(nil)
+
+// MockLibrary is a mock of port.Library
+type MockLibrary struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+}
+
+func (_m *MockLibrary) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockLibrary) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+var _ port.Library = (*MockLibrary)(nil)
+
+// MockConnector is a mock of port.Connector
+type MockConnector struct {
+	Recorder
+
+	InstallFunc    func(...any) error
+	UninstallFunc  func() error
+	ConnectFunc    func() error
+	DisconnectFunc func() error
+}
+
+func (_m *MockConnector) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockConnector) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockConnector) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockConnector) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+var _ port.Connector = (*MockConnector)(nil)
+
+// MockContextInstaller is a mock of port.ContextInstaller
+type MockContextInstaller struct {
+	Recorder
+
+	InstallCtxFunc func(context.Context, ...any) error
+}
+
+func (_m *MockContextInstaller) InstallCtx(ctx context.Context, args ...any) (r0 error) {
+	_m.RecordCall("InstallCtx", ctx, args)
+	if _m.InstallCtxFunc != nil {
+		return _m.InstallCtxFunc(ctx, args...)
+	}
+	return
+}
+
+var _ port.ContextInstaller = (*MockContextInstaller)(nil)
+
+// MockContextConnector is a mock of port.ContextConnector
+type MockContextConnector struct {
+	Recorder
+
+	ConnectCtxFunc func(context.Context) error
+}
+
+func (_m *MockContextConnector) ConnectCtx(ctx context.Context) (r0 error) {
+	_m.RecordCall("ConnectCtx", ctx)
+	if _m.ConnectCtxFunc != nil {
+		return _m.ConnectCtxFunc(ctx)
+	}
+	return
+}
+
+var _ port.ContextConnector = (*MockContextConnector)(nil)
+
+// MockPausable is a mock of port.Pausable
+type MockPausable struct {
+	Recorder
+
+	PauseFunc  func(context.Context) error
+	ResumeFunc func(context.Context) error
+}
+
+func (_m *MockPausable) Pause(ctx context.Context) (r0 error) {
+	_m.RecordCall("Pause", ctx)
+	if _m.PauseFunc != nil {
+		return _m.PauseFunc(ctx)
+	}
+	return
+}
+
+func (_m *MockPausable) Resume(ctx context.Context) (r0 error) {
+	_m.RecordCall("Resume", ctx)
+	if _m.ResumeFunc != nil {
+		return _m.ResumeFunc(ctx)
+	}
+	return
+}
+
+var _ port.Pausable = (*MockPausable)(nil)
+
+// MockDrainer is a mock of port.Drainer
+type MockDrainer struct {
+	Recorder
+
+	DrainFunc func(context.Context) error
+}
+
+func (_m *MockDrainer) Drain(ctx context.Context) (r0 error) {
+	_m.RecordCall("Drain", ctx)
+	if _m.DrainFunc != nil {
+		return _m.DrainFunc(ctx)
+	}
+	return
+}
+
+var _ port.Drainer = (*MockDrainer)(nil)
+
+// MockHealthChecker is a mock of port.HealthChecker
+type MockHealthChecker struct {
+	Recorder
+
+	HealthFunc func(context.Context) error
+}
+
+func (_m *MockHealthChecker) Health(ctx context.Context) (r0 error) {
+	_m.RecordCall("Health", ctx)
+	if _m.HealthFunc != nil {
+		return _m.HealthFunc(ctx)
+	}
+	return
+}
+
+var _ port.HealthChecker = (*MockHealthChecker)(nil)
+
+// MockPreInstaller is a mock of port.PreInstaller
+type MockPreInstaller struct {
+	Recorder
+
+	PreInstallFunc func(context.Context, ...any) error
+}
+
+func (_m *MockPreInstaller) PreInstall(ctx context.Context, args ...any) (r0 error) {
+	_m.RecordCall("PreInstall", ctx, args)
+	if _m.PreInstallFunc != nil {
+		return _m.PreInstallFunc(ctx, args...)
+	}
+	return
+}
+
+var _ port.PreInstaller = (*MockPreInstaller)(nil)
+
+// MockPostConnector is a mock of port.PostConnector
+type MockPostConnector struct {
+	Recorder
+
+	PostConnectFunc func(context.Context) error
+}
+
+func (_m *MockPostConnector) PostConnect(ctx context.Context) (r0 error) {
+	_m.RecordCall("PostConnect", ctx)
+	if _m.PostConnectFunc != nil {
+		return _m.PostConnectFunc(ctx)
+	}
+	return
+}
+
+var _ port.PostConnector = (*MockPostConnector)(nil)
+
+// MockPreUninstaller is a mock of port.PreUninstaller
+type MockPreUninstaller struct {
+	Recorder
+
+	PreUninstallFunc func(context.Context) error
+}
+
+func (_m *MockPreUninstaller) PreUninstall(ctx context.Context) (r0 error) {
+	_m.RecordCall("PreUninstall", ctx)
+	if _m.PreUninstallFunc != nil {
+		return _m.PreUninstallFunc(ctx)
+	}
+	return
+}
+
+var _ port.PreUninstaller = (*MockPreUninstaller)(nil)
+
+// MockLocker is a mock of port.ILocker
+type MockLocker struct {
+	Recorder
+
+	TryLockFunc func(context.Context, string, time.Duration) (bool, error)
+	UnlockFunc  func(context.Context, string) error
+}
+
+func (_m *MockLocker) TryLock(ctx context.Context, key string, ttl time.Duration) (r0 bool, r1 error) {
+	_m.RecordCall("TryLock", ctx, key, ttl)
+	if _m.TryLockFunc != nil {
+		return _m.TryLockFunc(ctx, key, ttl)
+	}
+	return
+}
+
+func (_m *MockLocker) Unlock(ctx context.Context, key string) (r0 error) {
+	_m.RecordCall("Unlock", ctx, key)
+	if _m.UnlockFunc != nil {
+		return _m.UnlockFunc(ctx, key)
+	}
+	return
+}
+
+var _ port.ILocker = (*MockLocker)(nil)
+
+// MockLogger is a mock of port.Logger
+type MockLogger struct {
+	Recorder
+
+	DebugFunc       func(string, ...any)
+	InfoFunc        func(string, ...any)
+	WarnFunc        func(string, ...any)
+	ErrorFunc       func(string, ...any)
+	EnabledFunc     func(slog.Level) bool
+	WithFunc        func(...any) port.Logger
+	WithContextFunc func(context.Context) port.Logger
+}
+
+func (_m *MockLogger) Debug(msg string, args ...any) {
+	_m.RecordCall("Debug", msg, args)
+	if _m.DebugFunc != nil {
+		_m.DebugFunc(msg, args...)
+		return
+	}
+}
+
+func (_m *MockLogger) Info(msg string, args ...any) {
+	_m.RecordCall("Info", msg, args)
+	if _m.InfoFunc != nil {
+		_m.InfoFunc(msg, args...)
+		return
+	}
+}
+
+func (_m *MockLogger) Warn(msg string, args ...any) {
+	_m.RecordCall("Warn", msg, args)
+	if _m.WarnFunc != nil {
+		_m.WarnFunc(msg, args...)
+		return
+	}
+}
+
+func (_m *MockLogger) Error(msg string, args ...any) {
+	_m.RecordCall("Error", msg, args)
+	if _m.ErrorFunc != nil {
+		_m.ErrorFunc(msg, args...)
+		return
+	}
+}
+
+func (_m *MockLogger) Enabled(level slog.Level) (r0 bool) {
+	_m.RecordCall("Enabled", level)
+	if _m.EnabledFunc != nil {
+		return _m.EnabledFunc(level)
+	}
+	return
+}
+
+func (_m *MockLogger) With(args ...any) (r0 port.Logger) {
+	_m.RecordCall("With", args)
+	if _m.WithFunc != nil {
+		return _m.WithFunc(args...)
+	}
+	return
+}
+
+func (_m *MockLogger) WithContext(ctx context.Context) (r0 port.Logger) {
+	_m.RecordCall("WithContext", ctx)
+	if _m.WithContextFunc != nil {
+		return _m.WithContextFunc(ctx)
+	}
+	return
+}
+
+var _ port.Logger = (*MockLogger)(nil)
+
+// MockLogBackend is a mock of port.ILogBackend
+type MockLogBackend struct {
+	Recorder
+
+	HandlerFunc func() slog.Handler
+}
+
+func (_m *MockLogBackend) Handler() (r0 slog.Handler) {
+	_m.RecordCall("Handler")
+	if _m.HandlerFunc != nil {
+		return _m.HandlerFunc()
+	}
+	return
+}
+
+var _ port.ILogBackend = (*MockLogBackend)(nil)
+
+// MockRemoteLog is a mock of port.IRemoteLog
+type MockRemoteLog struct {
+	Recorder
+
+	InstallFunc                func(...any) error
+	UninstallFunc              func() error
+	ConnectFunc                func() error
+	DisconnectFunc             func() error
+	SetMinimumLevelLogFunc     func(slog.Level)
+	SetMinimumLevelCaptureFunc func(slog.Level)
+	SetDefaultTagsFunc         func(map[string]string)
+	SetDefaultContextsFunc     func(map[string]map[string]any)
+	NewHandlerFunc             func() fiber.Handler
+	SetTagFunc                 func(string, string)
+	SetContextFunc             func(string, map[string]any)
+	LogFunc                    func(slog.Level, string, ...any)
+	CaptureMessageFunc         func(string)
+	CaptureErrorFunc           func(error)
+}
+
+func (_m *MockRemoteLog) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockRemoteLog) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockRemoteLog) Connect() (r0 error) {
+	_m.RecordCall("Connect")
+	if _m.ConnectFunc != nil {
+		return _m.ConnectFunc()
+	}
+	return
+}
+
+func (_m *MockRemoteLog) Disconnect() (r0 error) {
+	_m.RecordCall("Disconnect")
+	if _m.DisconnectFunc != nil {
+		return _m.DisconnectFunc()
+	}
+	return
+}
+
+func (_m *MockRemoteLog) SetMinimumLevelLog(level slog.Level) {
+	_m.RecordCall("SetMinimumLevelLog", level)
+	if _m.SetMinimumLevelLogFunc != nil {
+		_m.SetMinimumLevelLogFunc(level)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) SetMinimumLevelCapture(level slog.Level) {
+	_m.RecordCall("SetMinimumLevelCapture", level)
+	if _m.SetMinimumLevelCaptureFunc != nil {
+		_m.SetMinimumLevelCaptureFunc(level)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) SetDefaultTags(tags map[string]string) {
+	_m.RecordCall("SetDefaultTags", tags)
+	if _m.SetDefaultTagsFunc != nil {
+		_m.SetDefaultTagsFunc(tags)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) SetDefaultContexts(contexts map[string]map[string]any) {
+	_m.RecordCall("SetDefaultContexts", contexts)
+	if _m.SetDefaultContextsFunc != nil {
+		_m.SetDefaultContextsFunc(contexts)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) NewHandler() (r0 fiber.Handler) {
+	_m.RecordCall("NewHandler")
+	if _m.NewHandlerFunc != nil {
+		return _m.NewHandlerFunc()
+	}
+	return
+}
+
+func (_m *MockRemoteLog) SetTag(key string, value string) {
+	_m.RecordCall("SetTag", key, value)
+	if _m.SetTagFunc != nil {
+		_m.SetTagFunc(key, value)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) SetContext(key string, context map[string]any) {
+	_m.RecordCall("SetContext", key, context)
+	if _m.SetContextFunc != nil {
+		_m.SetContextFunc(key, context)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) Log(level slog.Level, msg string, args ...any) {
+	_m.RecordCall("Log", level, msg, args)
+	if _m.LogFunc != nil {
+		_m.LogFunc(level, msg, args...)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) CaptureMessage(msg string) {
+	_m.RecordCall("CaptureMessage", msg)
+	if _m.CaptureMessageFunc != nil {
+		_m.CaptureMessageFunc(msg)
+		return
+	}
+}
+
+func (_m *MockRemoteLog) CaptureError(err error) {
+	_m.RecordCall("CaptureError", err)
+	if _m.CaptureErrorFunc != nil {
+		_m.CaptureErrorFunc(err)
+		return
+	}
+}
+
+var _ port.IRemoteLog = (*MockRemoteLog)(nil)
+
+// MockRemoteLogFlusher is a mock of port.IRemoteLogFlusher
+type MockRemoteLogFlusher struct {
+	Recorder
+
+	FlushFunc func(context.Context) error
+}
+
+func (_m *MockRemoteLogFlusher) Flush(ctx context.Context) (r0 error) {
+	_m.RecordCall("Flush", ctx)
+	if _m.FlushFunc != nil {
+		return _m.FlushFunc(ctx)
+	}
+	return
+}
+
+var _ port.IRemoteLogFlusher = (*MockRemoteLogFlusher)(nil)
+
+// MockErrorReporter is a mock of port.IErrorReporter
+type MockErrorReporter struct {
+	Recorder
+
+	ReportPanicFunc func(context.Context, error, string, map[string]string)
+}
+
+func (_m *MockErrorReporter) ReportPanic(ctx context.Context, err error, stack string, tags map[string]string) {
+	_m.RecordCall("ReportPanic", ctx, err, stack, tags)
+	if _m.ReportPanicFunc != nil {
+		_m.ReportPanicFunc(ctx, err, stack, tags)
+		return
+	}
+}
+
+var _ port.IErrorReporter = (*MockErrorReporter)(nil)
+
+// MockMailer is a mock of port.IMailer
+type MockMailer struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	SendFunc      func(context.Context, *port.MailMessage) error
+	SendBatchFunc func(context.Context, []*port.MailMessage) error
+}
+
+func (_m *MockMailer) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockMailer) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockMailer) Send(ctx context.Context, message *port.MailMessage) (r0 error) {
+	_m.RecordCall("Send", ctx, message)
+	if _m.SendFunc != nil {
+		return _m.SendFunc(ctx, message)
+	}
+	return
+}
+
+func (_m *MockMailer) SendBatch(ctx context.Context, messages []*port.MailMessage) (r0 error) {
+	_m.RecordCall("SendBatch", ctx, messages)
+	if _m.SendBatchFunc != nil {
+		return _m.SendBatchFunc(ctx, messages)
+	}
+	return
+}
+
+var _ port.IMailer = (*MockMailer)(nil)
+
+// MockNotifier is a mock of port.INotifier
+type MockNotifier struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	NotifyFunc    func(context.Context, *port.Notification) error
+}
+
+func (_m *MockNotifier) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockNotifier) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockNotifier) Notify(ctx context.Context, notification *port.Notification) (r0 error) {
+	_m.RecordCall("Notify", ctx, notification)
+	if _m.NotifyFunc != nil {
+		return _m.NotifyFunc(ctx, notification)
+	}
+	return
+}
+
+var _ port.INotifier = (*MockNotifier)(nil)
+
+// MockNotificationPreferences is a mock of port.INotificationPreferences
+type MockNotificationPreferences struct {
+	Recorder
+
+	GetRecipientFunc func(context.Context, string) (*port.NotificationRecipient, error)
+}
+
+func (_m *MockNotificationPreferences) GetRecipient(ctx context.Context, userID string) (r0 *port.NotificationRecipient, r1 error) {
+	_m.RecordCall("GetRecipient", ctx, userID)
+	if _m.GetRecipientFunc != nil {
+		return _m.GetRecipientFunc(ctx, userID)
+	}
+	return
+}
+
+var _ port.INotificationPreferences = (*MockNotificationPreferences)(nil)
+
+// MockPaymentGateway is a mock of port.IPaymentGateway
+type MockPaymentGateway struct {
+	Recorder
+
+	InstallFunc        func(...any) error
+	UninstallFunc      func() error
+	CreatePaymentFunc  func(context.Context, *port.PaymentRequest) (*port.Payment, error)
+	GetPaymentFunc     func(context.Context, string) (*port.Payment, error)
+	CapturePaymentFunc func(context.Context, string, int64) (*port.Payment, error)
+	CancelPaymentFunc  func(context.Context, string) (*port.Payment, error)
+	RefundFunc         func(context.Context, *port.RefundRequest) (*port.Refund, error)
+	VerifyWebhookFunc  func(map[string]string, []byte) (string, error)
+	ParseWebhookFunc   func([]byte) (*port.PaymentEvent, error)
+}
+
+func (_m *MockPaymentGateway) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) CreatePayment(ctx context.Context, request *port.PaymentRequest) (r0 *port.Payment, r1 error) {
+	_m.RecordCall("CreatePayment", ctx, request)
+	if _m.CreatePaymentFunc != nil {
+		return _m.CreatePaymentFunc(ctx, request)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) GetPayment(ctx context.Context, id string) (r0 *port.Payment, r1 error) {
+	_m.RecordCall("GetPayment", ctx, id)
+	if _m.GetPaymentFunc != nil {
+		return _m.GetPaymentFunc(ctx, id)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) CapturePayment(ctx context.Context, id string, amount int64) (r0 *port.Payment, r1 error) {
+	_m.RecordCall("CapturePayment", ctx, id, amount)
+	if _m.CapturePaymentFunc != nil {
+		return _m.CapturePaymentFunc(ctx, id, amount)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) CancelPayment(ctx context.Context, id string) (r0 *port.Payment, r1 error) {
+	_m.RecordCall("CancelPayment", ctx, id)
+	if _m.CancelPaymentFunc != nil {
+		return _m.CancelPaymentFunc(ctx, id)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) Refund(ctx context.Context, request *port.RefundRequest) (r0 *port.Refund, r1 error) {
+	_m.RecordCall("Refund", ctx, request)
+	if _m.RefundFunc != nil {
+		return _m.RefundFunc(ctx, request)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) VerifyWebhook(headers map[string]string, body []byte) (r0 string, r1 error) {
+	_m.RecordCall("VerifyWebhook", headers, body)
+	if _m.VerifyWebhookFunc != nil {
+		return _m.VerifyWebhookFunc(headers, body)
+	}
+	return
+}
+
+func (_m *MockPaymentGateway) ParseWebhook(body []byte) (r0 *port.PaymentEvent, r1 error) {
+	_m.RecordCall("ParseWebhook", body)
+	if _m.ParseWebhookFunc != nil {
+		return _m.ParseWebhookFunc(body)
+	}
+	return
+}
+
+var _ port.IPaymentGateway = (*MockPaymentGateway)(nil)
+
+// MockQueueStore is a mock of port.IQueueStore
+type MockQueueStore struct {
+	Recorder
+
+	PushFunc    func(context.Context, *port.QueueJob) error
+	ReserveFunc func(context.Context, string, time.Time, time.Duration) (*port.QueueJob, error)
+	RenewFunc   func(context.Context, *port.QueueJob, time.Time) error
+	UpdateFunc  func(context.Context, *port.QueueJob, int) error
+	StatsFunc   func(context.Context, string) (map[string]int64, error)
+	ListFunc    func(context.Context, string, string, int) ([]port.QueueJob, error)
+}
+
+func (_m *MockQueueStore) Push(ctx context.Context, job *port.QueueJob) (r0 error) {
+	_m.RecordCall("Push", ctx, job)
+	if _m.PushFunc != nil {
+		return _m.PushFunc(ctx, job)
+	}
+	return
+}
+
+func (_m *MockQueueStore) Reserve(ctx context.Context, queue string, now time.Time, lease time.Duration) (r0 *port.QueueJob, r1 error) {
+	_m.RecordCall("Reserve", ctx, queue, now, lease)
+	if _m.ReserveFunc != nil {
+		return _m.ReserveFunc(ctx, queue, now, lease)
+	}
+	return
+}
+
+func (_m *MockQueueStore) Renew(ctx context.Context, job *port.QueueJob, until time.Time) (r0 error) {
+	_m.RecordCall("Renew", ctx, job, until)
+	if _m.RenewFunc != nil {
+		return _m.RenewFunc(ctx, job, until)
+	}
+	return
+}
+
+func (_m *MockQueueStore) Update(ctx context.Context, job *port.QueueJob, attempt int) (r0 error) {
+	_m.RecordCall("Update", ctx, job, attempt)
+	if _m.UpdateFunc != nil {
+		return _m.UpdateFunc(ctx, job, attempt)
+	}
+	return
+}
+
+func (_m *MockQueueStore) Stats(ctx context.Context, queue string) (r0 map[string]int64, r1 error) {
+	_m.RecordCall("Stats", ctx, queue)
+	if _m.StatsFunc != nil {
+		return _m.StatsFunc(ctx, queue)
+	}
+	return
+}
+
+func (_m *MockQueueStore) List(ctx context.Context, queue string, state string, limit int) (r0 []port.QueueJob, r1 error) {
+	_m.RecordCall("List", ctx, queue, state, limit)
+	if _m.ListFunc != nil {
+		return _m.ListFunc(ctx, queue, state, limit)
+	}
+	return
+}
+
+var _ port.IQueueStore = (*MockQueueStore)(nil)
+
+// MockPDFRenderer is a mock of port.IPDFRenderer
+type MockPDFRenderer struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	RenderPDFFunc func(context.Context, string, port.PDFOptions, io.Writer) error
+}
+
+func (_m *MockPDFRenderer) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockPDFRenderer) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockPDFRenderer) RenderPDF(ctx context.Context, html string, opts port.PDFOptions, w io.Writer) (r0 error) {
+	_m.RecordCall("RenderPDF", ctx, html, opts, w)
+	if _m.RenderPDFFunc != nil {
+		return _m.RenderPDFFunc(ctx, html, opts, w)
+	}
+	return
+}
+
+var _ port.IPDFRenderer = (*MockPDFRenderer)(nil)
+
+// MockSpreadsheetRenderer is a mock of port.ISpreadsheetRenderer
+type MockSpreadsheetRenderer struct {
+	Recorder
+
+	InstallFunc    func(...any) error
+	UninstallFunc  func() error
+	RenderXLSXFunc func(context.Context, []port.ReportSheet, io.Writer) error
+}
+
+func (_m *MockSpreadsheetRenderer) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockSpreadsheetRenderer) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockSpreadsheetRenderer) RenderXLSX(ctx context.Context, sheets []port.ReportSheet, w io.Writer) (r0 error) {
+	_m.RecordCall("RenderXLSX", ctx, sheets, w)
+	if _m.RenderXLSXFunc != nil {
+		return _m.RenderXLSXFunc(ctx, sheets, w)
+	}
+	return
+}
+
+var _ port.ISpreadsheetRenderer = (*MockSpreadsheetRenderer)(nil)
+
+// MockSpreadsheetReader is a mock of port.ISpreadsheetReader
+type MockSpreadsheetReader struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	ReadXLSXFunc  func(context.Context, io.ReaderAt, int64, string, func([]string) error) error
+}
+
+func (_m *MockSpreadsheetReader) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockSpreadsheetReader) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockSpreadsheetReader) ReadXLSX(ctx context.Context, r io.ReaderAt, size int64, sheet string, row func([]string) error) (r0 error) {
+	_m.RecordCall("ReadXLSX", ctx, r, size, sheet, row)
+	if _m.ReadXLSXFunc != nil {
+		return _m.ReadXLSXFunc(ctx, r, size, sheet, row)
+	}
+	return
+}
+
+var _ port.ISpreadsheetReader = (*MockSpreadsheetReader)(nil)
+
+// MockSagaStore is a mock of port.ISagaStore
+type MockSagaStore struct {
+	Recorder
+
+	InsertFunc  func(context.Context, *port.SagaRecord) error
+	UpdateFunc  func(context.Context, *port.SagaRecord, int64) (bool, error)
+	GetFunc     func(context.Context, string) (*port.SagaRecord, error)
+	StalledFunc func(context.Context, time.Time, int) ([]port.SagaRecord, error)
+}
+
+func (_m *MockSagaStore) Insert(ctx context.Context, saga *port.SagaRecord) (r0 error) {
+	_m.RecordCall("Insert", ctx, saga)
+	if _m.InsertFunc != nil {
+		return _m.InsertFunc(ctx, saga)
+	}
+	return
+}
+
+func (_m *MockSagaStore) Update(ctx context.Context, saga *port.SagaRecord, version int64) (r0 bool, r1 error) {
+	_m.RecordCall("Update", ctx, saga, version)
+	if _m.UpdateFunc != nil {
+		return _m.UpdateFunc(ctx, saga, version)
+	}
+	return
+}
+
+func (_m *MockSagaStore) Get(ctx context.Context, id string) (r0 *port.SagaRecord, r1 error) {
+	_m.RecordCall("Get", ctx, id)
+	if _m.GetFunc != nil {
+		return _m.GetFunc(ctx, id)
+	}
+	return
+}
+
+func (_m *MockSagaStore) Stalled(ctx context.Context, now time.Time, limit int) (r0 []port.SagaRecord, r1 error) {
+	_m.RecordCall("Stalled", ctx, now, limit)
+	if _m.StalledFunc != nil {
+		return _m.StalledFunc(ctx, now, limit)
+	}
+	return
+}
+
+var _ port.ISagaStore = (*MockSagaStore)(nil)
+
+// MockFileScanner is a mock of port.IFileScanner
+type MockFileScanner struct {
+	Recorder
+
+	ScanFunc func(context.Context, string, io.Reader) error
+}
+
+func (_m *MockFileScanner) Scan(ctx context.Context, filename string, content io.Reader) (r0 error) {
+	_m.RecordCall("Scan", ctx, filename, content)
+	if _m.ScanFunc != nil {
+		return _m.ScanFunc(ctx, filename, content)
+	}
+	return
+}
+
+var _ port.IFileScanner = (*MockFileScanner)(nil)
+
+// MockSearchIndex is a mock of port.ISearchIndex
+type MockSearchIndex struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	IndexFunc     func(context.Context, string, []port.SearchDocument) error
+	DeleteFunc    func(context.Context, string, []string) error
+}
+
+func (_m *MockSearchIndex) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockSearchIndex) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockSearchIndex) Index(ctx context.Context, index string, docs []port.SearchDocument) (r0 error) {
+	_m.RecordCall("Index", ctx, index, docs)
+	if _m.IndexFunc != nil {
+		return _m.IndexFunc(ctx, index, docs)
+	}
+	return
+}
+
+func (_m *MockSearchIndex) Delete(ctx context.Context, index string, ids []string) (r0 error) {
+	_m.RecordCall("Delete", ctx, index, ids)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(ctx, index, ids)
+	}
+	return
+}
+
+var _ port.ISearchIndex = (*MockSearchIndex)(nil)
+
+// MockSecrets is a mock of port.ISecrets
+type MockSecrets struct {
+	Recorder
+
+	InstallFunc     func(...any) error
+	UninstallFunc   func() error
+	GetSecretFunc   func(context.Context, string) (*port.Secret, error)
+	RenewSecretFunc func(context.Context, *port.Secret) (*port.Secret, error)
+}
+
+func (_m *MockSecrets) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockSecrets) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockSecrets) GetSecret(ctx context.Context, name string) (r0 *port.Secret, r1 error) {
+	_m.RecordCall("GetSecret", ctx, name)
+	if _m.GetSecretFunc != nil {
+		return _m.GetSecretFunc(ctx, name)
+	}
+	return
+}
+
+func (_m *MockSecrets) RenewSecret(ctx context.Context, secret *port.Secret) (r0 *port.Secret, r1 error) {
+	_m.RecordCall("RenewSecret", ctx, secret)
+	if _m.RenewSecretFunc != nil {
+		return _m.RenewSecretFunc(ctx, secret)
+	}
+	return
+}
+
+var _ port.ISecrets = (*MockSecrets)(nil)
+
+// MockObjectStorage is a mock of port.IObjectStorage
+type MockObjectStorage struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	PutFunc       func(context.Context, string, io.Reader, port.PutOptions) (*port.ObjectInfo, error)
+	GetFunc       func(context.Context, string) (io.ReadCloser, *port.ObjectInfo, error)
+	StatFunc      func(context.Context, string) (*port.ObjectInfo, error)
+	DeleteFunc    func(context.Context, string) error
+	ListFunc      func(context.Context, string, int) ([]port.ObjectInfo, error)
+	SignedURLFunc func(context.Context, string, string, time.Duration) (string, error)
+}
+
+func (_m *MockObjectStorage) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockObjectStorage) Put(ctx context.Context, key string, content io.Reader, opts port.PutOptions) (r0 *port.ObjectInfo, r1 error) {
+	_m.RecordCall("Put", ctx, key, content, opts)
+	if _m.PutFunc != nil {
+		return _m.PutFunc(ctx, key, content, opts)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) Get(ctx context.Context, key string) (r0 io.ReadCloser, r1 *port.ObjectInfo, r2 error) {
+	_m.RecordCall("Get", ctx, key)
+	if _m.GetFunc != nil {
+		return _m.GetFunc(ctx, key)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) Stat(ctx context.Context, key string) (r0 *port.ObjectInfo, r1 error) {
+	_m.RecordCall("Stat", ctx, key)
+	if _m.StatFunc != nil {
+		return _m.StatFunc(ctx, key)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) Delete(ctx context.Context, key string) (r0 error) {
+	_m.RecordCall("Delete", ctx, key)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(ctx, key)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) List(ctx context.Context, prefix string, limit int) (r0 []port.ObjectInfo, r1 error) {
+	_m.RecordCall("List", ctx, prefix, limit)
+	if _m.ListFunc != nil {
+		return _m.ListFunc(ctx, prefix, limit)
+	}
+	return
+}
+
+func (_m *MockObjectStorage) SignedURL(ctx context.Context, key string, method string, expires time.Duration) (r0 string, r1 error) {
+	_m.RecordCall("SignedURL", ctx, key, method, expires)
+	if _m.SignedURLFunc != nil {
+		return _m.SignedURLFunc(ctx, key, method, expires)
+	}
+	return
+}
+
+var _ port.IObjectStorage = (*MockObjectStorage)(nil)
+
+// MockTemplateRenderer is a mock of port.ITemplateRenderer
+type MockTemplateRenderer struct {
+	Recorder
+
+	RenderFunc func(io.Writer, string, any) error
+	HasFunc    func(string) bool
+}
+
+func (_m *MockTemplateRenderer) Render(w io.Writer, name string, data any) (r0 error) {
+	_m.RecordCall("Render", w, name, data)
+	if _m.RenderFunc != nil {
+		return _m.RenderFunc(w, name, data)
+	}
+	return
+}
+
+func (_m *MockTemplateRenderer) Has(name string) (r0 bool) {
+	_m.RecordCall("Has", name)
+	if _m.HasFunc != nil {
+		return _m.HasFunc(name)
+	}
+	return
+}
+
+var _ port.ITemplateRenderer = (*MockTemplateRenderer)(nil)
+
+// MockTenantStore is a mock of port.ITenantStore
+type MockTenantStore struct {
+	Recorder
+
+	GetFunc func(context.Context, string) (*port.Tenant, error)
+}
+
+func (_m *MockTenantStore) Get(ctx context.Context, id string) (r0 *port.Tenant, r1 error) {
+	_m.RecordCall("Get", ctx, id)
+	if _m.GetFunc != nil {
+		return _m.GetFunc(ctx, id)
+	}
+	return
+}
+
+var _ port.ITenantStore = (*MockTenantStore)(nil)
+
+// MockFileTransfer is a mock of port.IFileTransfer
+type MockFileTransfer struct {
+	Recorder
+
+	InstallFunc   func(...any) error
+	UninstallFunc func() error
+	ListFunc      func(context.Context, string) ([]port.RemoteFile, error)
+	GetFunc       func(context.Context, string, io.Writer) error
+	PutFunc       func(context.Context, string, io.Reader) error
+	MoveFunc      func(context.Context, string, string) error
+	DeleteFunc    func(context.Context, string) error
+}
+
+func (_m *MockFileTransfer) Install(args ...any) (r0 error) {
+	_m.RecordCall("Install", args)
+	if _m.InstallFunc != nil {
+		return _m.InstallFunc(args...)
+	}
+	return
+}
+
+func (_m *MockFileTransfer) Uninstall() (r0 error) {
+	_m.RecordCall("Uninstall")
+	if _m.UninstallFunc != nil {
+		return _m.UninstallFunc()
+	}
+	return
+}
+
+func (_m *MockFileTransfer) List(ctx context.Context, dir string) (r0 []port.RemoteFile, r1 error) {
+	_m.RecordCall("List", ctx, dir)
+	if _m.ListFunc != nil {
+		return _m.ListFunc(ctx, dir)
+	}
+	return
+}
+
+func (_m *MockFileTransfer) Get(ctx context.Context, path string, w io.Writer) (r0 error) {
+	_m.RecordCall("Get", ctx, path, w)
+	if _m.GetFunc != nil {
+		return _m.GetFunc(ctx, path, w)
+	}
+	return
+}
+
+func (_m *MockFileTransfer) Put(ctx context.Context, path string, content io.Reader) (r0 error) {
+	_m.RecordCall("Put", ctx, path, content)
+	if _m.PutFunc != nil {
+		return _m.PutFunc(ctx, path, content)
+	}
+	return
+}
+
+func (_m *MockFileTransfer) Move(ctx context.Context, from string, to string) (r0 error) {
+	_m.RecordCall("Move", ctx, from, to)
+	if _m.MoveFunc != nil {
+		return _m.MoveFunc(ctx, from, to)
+	}
+	return
+}
+
+func (_m *MockFileTransfer) Delete(ctx context.Context, path string) (r0 error) {
+	_m.RecordCall("Delete", ctx, path)
+	if _m.DeleteFunc != nil {
+		return _m.DeleteFunc(ctx, path)
+	}
+	return
+}
+
+var _ port.IFileTransfer = (*MockFileTransfer)(nil)
+
+// MockUsageStore is a mock of port.IUsageStore
+type MockUsageStore struct {
+	Recorder
+
+	SaveFunc  func(context.Context, []port.UsageRecord) error
+	QueryFunc func(context.Context, port.UsageQuery) ([]port.UsageRecord, error)
+}
+
+func (_m *MockUsageStore) Save(ctx context.Context, records []port.UsageRecord) (r0 error) {
+	_m.RecordCall("Save", ctx, records)
+	if _m.SaveFunc != nil {
+		return _m.SaveFunc(ctx, records)
+	}
+	return
+}
+
+func (_m *MockUsageStore) Query(ctx context.Context, query port.UsageQuery) (r0 []port.UsageRecord, r1 error) {
+	_m.RecordCall("Query", ctx, query)
+	if _m.QueryFunc != nil {
+		return _m.QueryFunc(ctx, query)
+	}
+	return
+}
+
+var _ port.IUsageStore = (*MockUsageStore)(nil)
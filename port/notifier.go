@@ -0,0 +1,39 @@
+package port
+
+import "context"
+
+// Notification channels
+const (
+	NotifyChannelEmail = "email"
+	NotifyChannelSMS   = "sms"
+	NotifyChannelPush  = "push"
+)
+
+type Notification struct {
+	To    string            `json:"to"` // phone number, device token or email address depending on the channel
+	Title string            `json:"title,omitempty"`
+	Body  string            `json:"body"`
+	Data  map[string]string `json:"data,omitempty"` // custom push payload
+}
+
+// Generic for SMS / Push delivery (ex: Twilio, FCM, APNs)
+type INotifier interface {
+	Library
+
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// NotificationRecipient holds the contact points of a user and the channels
+// they accept, ordered by preference
+type NotificationRecipient struct {
+	UserID       string
+	Channels     []string
+	Email        string
+	Phone        string
+	DeviceTokens []string
+}
+
+// INotificationPreferences resolves where and how a user wants to be notified
+type INotificationPreferences interface {
+	GetRecipient(ctx context.Context, userID string) (*NotificationRecipient, error)
+}
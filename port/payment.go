@@ -0,0 +1,82 @@
+package port
+
+import "context"
+
+// Payment statuses
+const (
+	PaymentPending        = "pending"         // waiting for the customer or the provider
+	PaymentRequiresAction = "requires_action" // the customer must authenticate (ex: 3-D Secure), see Payment.RedirectURL
+	PaymentAuthorized     = "authorized"      // funds held, waiting for capture
+	PaymentSucceeded      = "succeeded"
+	PaymentFailed         = "failed"
+	PaymentCanceled       = "canceled"
+	PaymentRefunded       = "refunded" // fully refunded
+)
+
+// PaymentRequest creates a payment, amounts are in the smallest currency unit (ex: cents)
+type PaymentRequest struct {
+	Amount         int64             `json:"amount"`
+	Currency       string            `json:"currency,omitempty"` // ISO 4217, defaults to payment.currency
+	Description    string            `json:"description,omitempty"`
+	CustomerEmail  string            `json:"customer_email,omitempty"` // receipt address
+	ManualCapture  bool              `json:"manual_capture,omitempty"` // only authorize, capture later
+	Metadata       map[string]string `json:"metadata,omitempty"`       // echoed in webhook events (ex: order id)
+	IdempotencyKey string            `json:"-"`                        // retries with the same key create a single payment
+}
+
+type Payment struct {
+	ID           string            `json:"id"`
+	Status       string            `json:"status"`
+	Amount       int64             `json:"amount"`
+	Currency     string            `json:"currency"`
+	ClientSecret string            `json:"client_secret,omitempty"` // lets the frontend confirm the payment with the provider SDK
+	RedirectURL  string            `json:"redirect_url,omitempty"`  // set when the status is requires_action
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+// RefundRequest refunds a payment, a zero Amount refunds what is left
+type RefundRequest struct {
+	PaymentID      string            `json:"payment_id"`
+	Amount         int64             `json:"amount,omitempty"`
+	Reason         string            `json:"reason,omitempty"` // "duplicate", "fraudulent" or "requested_by_customer"
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	IdempotencyKey string            `json:"-"`
+}
+
+type Refund struct {
+	ID        string `json:"id"`
+	PaymentID string `json:"payment_id"`
+	Status    string `json:"status"` // pending, succeeded, failed or canceled
+	Amount    int64  `json:"amount"`
+	Currency  string `json:"currency"`
+}
+
+// PaymentEvent is a status update of a payment received through a webhook
+type PaymentEvent struct {
+	ID        string            `json:"id"`   // event id of the provider
+	Type      string            `json:"type"` // event type of the provider (ex: "payment_intent.succeeded")
+	PaymentID string            `json:"payment_id"`
+	Status    string            `json:"status"`
+	Amount    int64             `json:"amount"`
+	Refunded  int64             `json:"refunded,omitempty"` // total refunded amount
+	Currency  string            `json:"currency"`
+	Metadata  map[string]string `json:"metadata,omitempty"`
+}
+
+// Generic for Payment gateways (ex: Stripe)
+type IPaymentGateway interface {
+	Library
+
+	CreatePayment(ctx context.Context, request *PaymentRequest) (*Payment, error)
+	GetPayment(ctx context.Context, id string) (*Payment, error)
+	// CapturePayment collects an authorized payment, a zero amount captures all of it
+	CapturePayment(ctx context.Context, id string, amount int64) (*Payment, error)
+	CancelPayment(ctx context.Context, id string) (*Payment, error)
+	Refund(ctx context.Context, request *RefundRequest) (*Refund, error)
+
+	// VerifyWebhook checks the signature of a webhook delivery and returns its event id,
+	// headers are keyed in canonical form (ex: "Stripe-Signature")
+	VerifyWebhook(headers map[string]string, body []byte) (string, error)
+	// ParseWebhook decodes a verified delivery, nil without error for events unrelated to payments
+	ParseWebhook(body []byte) (*PaymentEvent, error)
+}
@@ -0,0 +1,55 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrJobLost is returned by an IQueueStore updating a job whose reservation was taken by another
+// worker, its lease having ended
+var ErrJobLost = errors.New("job reservation lost")
+
+// Job states stored by an IQueueStore
+const (
+	JobStatePending = "pending"
+	JobStateRunning = "running"
+	JobStateDone    = "done"
+	JobStateFailed  = "failed"
+)
+
+// QueueJob is a job persisted by the queue store. Payload holds the JSON encoded job data.
+type QueueJob struct {
+	ID          string    `json:"id" db:"id"`
+	Queue       string    `json:"queue" db:"queue"`
+	Type        string    `json:"type" db:"type"`
+	Payload     []byte    `json:"payload" db:"payload"`
+	Priority    int       `json:"priority" db:"priority"`
+	State       string    `json:"state" db:"state"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	MaxAttempts int       `json:"max_attempts" db:"max_attempts"`
+	RunAt       time.Time `json:"run_at" db:"run_at"`
+	LastError   string    `json:"last_error,omitempty" db:"last_error"`
+	// ReservedUntil is the end of the lease of a running job, a job still running past it was
+	// lost with its instance and is reserved again
+	ReservedUntil time.Time `json:"reserved_until,omitempty" db:"reserved_until"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// IQueueStore persists background jobs (ex: Redis, database)
+type IQueueStore interface {
+	Push(ctx context.Context, job *QueueJob) error
+	// Reserve atomically moves the due pending job with the highest priority, or else a running
+	// job whose lease ended, to running for lease and counts its attempt. It returns nil
+	// without error when nothing is due.
+	Reserve(ctx context.Context, queue string, now time.Time, lease time.Duration) (*QueueJob, error)
+	// Renew extends the lease of the running job to until while the job still holds the
+	// reservation of its attempts, else it returns ErrJobLost
+	Renew(ctx context.Context, job *QueueJob, until time.Time) error
+	// Update saves the outcome of the job reserved at attempt, while it still holds that
+	// reservation, else it returns ErrJobLost
+	Update(ctx context.Context, job *QueueJob, attempt int) error
+	Stats(ctx context.Context, queue string) (map[string]int64, error)
+	List(ctx context.Context, queue string, state string, limit int) ([]QueueJob, error)
+}
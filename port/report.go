@@ -0,0 +1,66 @@
+package port
+
+import (
+	"context"
+	"io"
+)
+
+// Report formats
+const (
+	ReportPDF  = "pdf"
+	ReportXLSX = "xlsx"
+)
+
+type PDFOptions struct {
+	PageSize  string `json:"page_size,omitempty"` // A4, Letter, ... defaults to report.page_size
+	Landscape bool   `json:"landscape,omitempty"`
+	Margin    string `json:"margin,omitempty"` // CSS length applied to every side (ex: "10mm")
+}
+
+type ReportColumn struct {
+	Title string  `json:"title"`
+	Width float64 `json:"width,omitempty"` // in characters, 0 for the default width
+}
+
+// ReportSheet is a worksheet: a header row of Columns followed by Rows.
+// Cells are strings, numbers, booleans, time.Time or nil.
+type ReportSheet struct {
+	Name    string         `json:"name"`
+	Columns []ReportColumn `json:"columns,omitempty"`
+	Rows    [][]any        `json:"rows"`
+}
+
+type Report struct {
+	// Template is rendered with Data into the HTML of a PDF when HTML is empty
+	Template string `json:"template,omitempty"`
+	HTML     string `json:"html,omitempty"`
+	Data     any    `json:"data,omitempty"`
+
+	// Sheets of a workbook, a slice of structs or maps in Data becomes a single sheet when empty
+	Sheets []ReportSheet `json:"sheets,omitempty"`
+
+	PDF PDFOptions `json:"pdf,omitempty"`
+}
+
+// Generic for HTML to PDF conversion (ex: wkhtmltopdf, headless Chromium)
+type IPDFRenderer interface {
+	Library
+
+	RenderPDF(ctx context.Context, html string, opts PDFOptions, w io.Writer) error
+}
+
+// Generic for spreadsheet generation (ex: xlsx)
+type ISpreadsheetRenderer interface {
+	Library
+
+	RenderXLSX(ctx context.Context, sheets []ReportSheet, w io.Writer) error
+}
+
+// Generic for spreadsheet reading (ex: xlsx)
+type ISpreadsheetReader interface {
+	Library
+
+	// ReadXLSX calls row with the cells of every row of sheet (the first one when empty) as text,
+	// dates are their serial number. Returning an error from row stops the reading.
+	ReadXLSX(ctx context.Context, r io.ReaderAt, size int64, sheet string, row func(cells []string) error) error
+}
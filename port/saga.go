@@ -0,0 +1,44 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// Saga states stored by an ISagaStore
+const (
+	SagaRunning      = "running"
+	SagaCompensating = "compensating"
+	SagaCompleted    = "completed"
+	SagaCompensated  = "compensated"
+	SagaFailed       = "failed" // a compensation failed, the saga needs a manual intervention
+)
+
+// SagaRecord is the state of a saga persisted by the saga store. Data holds the JSON encoded
+// saga data, Step the step executed next (or compensated next while compensating).
+type SagaRecord struct {
+	ID          string    `json:"id" db:"id"`
+	Saga        string    `json:"saga" db:"saga"`
+	State       string    `json:"state" db:"state"`
+	Step        int       `json:"step" db:"step"`
+	Attempts    int       `json:"attempts" db:"attempts"`
+	Data        []byte    `json:"-" db:"data"`
+	LastError   string    `json:"last_error,omitempty" db:"last_error"`
+	Deadline    time.Time `json:"deadline" db:"deadline"`         // zero when the saga has no timeout
+	LockedUntil time.Time `json:"locked_until" db:"locked_until"` // lease of the instance executing the saga
+	Version     int64     `json:"version" db:"version"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ISagaStore persists the state of sagas (ex: database)
+type ISagaStore interface {
+	Insert(ctx context.Context, saga *SagaRecord) error
+	// Update saves saga when the stored version is still version, it returns false when
+	// another instance saved the saga in between
+	Update(ctx context.Context, saga *SagaRecord, version int64) (bool, error)
+	// Get returns nil without error when the saga does not exist
+	Get(ctx context.Context, id string) (*SagaRecord, error)
+	// Stalled lists the running or compensating sagas whose lease expired before now
+	Stalled(ctx context.Context, now time.Time, limit int) ([]SagaRecord, error)
+}
@@ -0,0 +1,12 @@
+package port
+
+import (
+	"context"
+	"io"
+)
+
+// IFileScanner inspects uploaded content (e.g. antivirus) before it is accepted.
+// Scan returns a non-nil error when the content must be rejected.
+type IFileScanner interface {
+	Scan(ctx context.Context, filename string, content io.Reader) error
+}
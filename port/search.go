@@ -0,0 +1,19 @@
+package port
+
+import "context"
+
+// SearchDocument is a document of a search index, Body is encoded as JSON
+type SearchDocument struct {
+	ID   string
+	Body any
+}
+
+// Generic for external search engines (ex: Elasticsearch, Meilisearch)
+type ISearchIndex interface {
+	Library
+
+	// Index adds the documents or replaces those with the same ID
+	Index(ctx context.Context, index string, docs []SearchDocument) error
+	// Delete removes the documents, unknown IDs are ignored
+	Delete(ctx context.Context, index string, ids []string) error
+}
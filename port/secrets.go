@@ -0,0 +1,41 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrSecretNotFound is wrapped in the error of ISecrets.GetSecret when the secret does not exist
+var ErrSecretNotFound = errors.New("secret not found")
+
+// Secret is a value of a secrets manager. Secrets holding a JSON object of strings, or the
+// key/value pairs of a Vault path, also have their fields in Data.
+type Secret struct {
+	Name      string            `json:"name"`
+	Value     string            `json:"-"`
+	Data      map[string]string `json:"-"`
+	Version   string            `json:"version,omitempty"`    // changes when the secret is rotated
+	LeaseID   string            `json:"lease_id,omitempty"`   // dynamic secrets (ex: database credentials of Vault)
+	Renewable bool              `json:"renewable,omitempty"`  // the lease can be extended with RenewSecret
+	ExpiresAt time.Time         `json:"expires_at,omitempty"` // end of the lease, zero without lease
+}
+
+// Field returns the field key of Data, or Value when key is empty
+func (s *Secret) Field(key string) (string, bool) {
+	if key == "" {
+		return s.Value, true
+	}
+	value, ok := s.Data[key]
+	return value, ok
+}
+
+// Generic for secrets managers (ex: Vault, AWS Secrets Manager, GCP Secret Manager)
+type ISecrets interface {
+	Library
+
+	// GetSecret reads the current version of a secret
+	GetSecret(ctx context.Context, name string) (*Secret, error)
+	// RenewSecret extends the lease of a renewable secret and returns it with its new expiry
+	RenewSecret(ctx context.Context, secret *Secret) (*Secret, error)
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrObjectNotFound is returned by IObjectStorage when the key does not exist
+var ErrObjectNotFound = errors.New("object not found")
+
+type ObjectInfo struct {
+	Key          string            `json:"key"`
+	Size         int64             `json:"size"`
+	ContentType  string            `json:"content_type,omitempty"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified time.Time         `json:"last_modified"`
+	Metadata     map[string]string `json:"metadata,omitempty"`
+}
+
+type PutOptions struct {
+	ContentType  string
+	Size         int64 // -1 or 0 when unknown
+	CacheControl string
+	Metadata     map[string]string
+}
+
+// Generic for Object Storage (ex: S3, GCS, local disk)
+type IObjectStorage interface {
+	Library
+
+	// Put streams content into key, replacing any existing object
+	Put(ctx context.Context, key string, content io.Reader, opts PutOptions) (*ObjectInfo, error)
+	// Get streams the object, the caller must close the reader
+	Get(ctx context.Context, key string) (io.ReadCloser, *ObjectInfo, error)
+	Stat(ctx context.Context, key string) (*ObjectInfo, error)
+	Delete(ctx context.Context, key string) error
+	// List returns up to limit objects (0 for all) whose key starts with prefix
+	List(ctx context.Context, prefix string, limit int) ([]ObjectInfo, error)
+	// SignedURL returns a temporary URL granting method (GET or PUT) on key without credentials
+	SignedURL(ctx context.Context, key string, method string, expires time.Duration) (string, error)
+}
@@ -0,0 +1,9 @@
+package port
+
+import "io"
+
+// ITemplateRenderer renders named templates, used for HTML responses and emails
+type ITemplateRenderer interface {
+	Render(w io.Writer, name string, data any) error
+	Has(name string) bool
+}
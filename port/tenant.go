@@ -0,0 +1,20 @@
+package port
+
+import "context"
+
+// Tenant is the customer owning a request in a multi-tenant application
+type Tenant struct {
+	ID        string         `json:"id"`
+	Name      string         `json:"name,omitempty"`
+	Database  string         `json:"-"`                    // URI of the database of the tenant, empty uses the default database
+	Schema    string         `json:"-"`                    // schema of the tenant in the default database
+	RateLimit int            `json:"rate_limit,omitempty"` // requests per minute, 0 uses app.rate_limit.max
+	Settings  map[string]any `json:"settings,omitempty"`   // configuration overriding the application one, by key (ex: "module.orders.page_size")
+}
+
+// ITenantStore finds the tenants (ex: in the database of the platform), lookups happen on every
+// request so implementations should cache them
+type ITenantStore interface {
+	// Get returns nil without error when the tenant does not exist
+	Get(ctx context.Context, id string) (*Tenant, error)
+}
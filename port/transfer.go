@@ -0,0 +1,35 @@
+package port
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+)
+
+// ErrRemoteFileNotFound is returned by IFileTransfer when the remote path does not exist
+var ErrRemoteFileNotFound = errors.New("remote file not found")
+
+type RemoteFile struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"` // relative to the root of the connection
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"` // zero when the server does not tell
+	IsDir   bool      `json:"is_dir"`
+}
+
+// Generic for file-transfer connectors exchanging files with partners (ex: SFTP, FTP, FTPS).
+// Paths are relative to the root directory of the connection and use forward slashes.
+type IFileTransfer interface {
+	Library
+
+	// List returns the entries of a directory, without "." and ".."
+	List(ctx context.Context, dir string) ([]RemoteFile, error)
+	// Get streams the content of a remote file into w
+	Get(ctx context.Context, path string, w io.Writer) error
+	// Put uploads content into path, replacing any existing file
+	Put(ctx context.Context, path string, content io.Reader) error
+	// Move renames a remote file, typically into an archive or processed directory
+	Move(ctx context.Context, from string, to string) error
+	Delete(ctx context.Context, path string) error
+}
@@ -0,0 +1,39 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// UsageRecord is the traffic of a client on a route during a period of an aggregation window
+type UsageRecord struct {
+	Window    string    `json:"window" db:"period"`                 // aggregation window (ex: "1h")
+	Start     time.Time `json:"start" db:"period_start"`            // start of the period
+	Principal string    `json:"principal,omitempty" db:"principal"` // authenticated user
+	APIKey    string    `json:"api_key,omitempty" db:"api_key"`     // fingerprint of the API key of the requests
+	Route     string    `json:"route,omitempty" db:"route"`         // method and path of the route (ex: "GET /api/orders/:id")
+	Instance  string    `json:"-" db:"instance"`                    // instance counting the requests
+	Requests  int64     `json:"requests" db:"requests"`
+	Errors    int64     `json:"errors" db:"errors"` // responses with a status of 400 or more
+	BytesIn   int64     `json:"bytes_in" db:"bytes_in"`
+	BytesOut  int64     `json:"bytes_out" db:"bytes_out"`
+}
+
+// UsageQuery selects the usage records of a window, the empty fields match every record
+type UsageQuery struct {
+	Window    string
+	From      time.Time // periods starting at or after From
+	To        time.Time // periods starting at or before To, zero for no limit
+	Principal string
+	APIKey    string
+	Route     string
+}
+
+// IUsageStore keeps the usage counted by the instances (ex: database)
+type IUsageStore interface {
+	// Save stores the totals of the instance of the records, replacing the ones it saved
+	// before for the same period, principal, API key and route
+	Save(ctx context.Context, records []UsageRecord) error
+	// Query returns the records matching query, the totals of the instances added together
+	Query(ctx context.Context, query UsageQuery) ([]UsageRecord, error)
+}